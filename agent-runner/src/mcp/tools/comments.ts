@@ -145,8 +145,10 @@ export function createCommentTools(context: WorkspaceContext) {
         commentId: z.string().describe("The ID of the review comment to resolve"),
         resolutionType: z.enum(["fixed", "ignored"]).default("fixed")
           .describe("How the comment was resolved: 'fixed' if code was changed, 'ignored' if intentionally skipped"),
+        reply: z.string().optional()
+          .describe("Reply to post on the linked GitHub review thread, e.g. what was changed or why it was ignored"),
       },
-      async ({ commentId, resolutionType }) => {
+      async ({ commentId, resolutionType, reply }) => {
         try {
           const response = await fetchWithRetry(
             `${BACKEND_URL}/api/repos/${context.workspaceId}/sessions/${context.sessionId}/comments/${commentId}`,
@@ -157,6 +159,7 @@ export function createCommentTools(context: WorkspaceContext) {
                 resolved: true,
                 resolvedBy: "Claude",
                 resolutionType,
+                reply,
               }),
             }
           );
@@ -0,0 +1,30 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/chatml/chatml-backend/logger"
+	"github.com/chatml/chatml-backend/models"
+)
+
+// recordTaskList persists the task list reported by a task_list_update event,
+// so it can be listed while the conversation isn't running. Failures are
+// logged: the event still reaches the frontend.
+func (m *Manager) recordTaskList(ctx context.Context, convID string, items []TaskItem) {
+	tasks := make([]models.ConversationTask, 0, len(items))
+	for _, item := range items {
+		tasks = append(tasks, models.ConversationTask{
+			ID:          item.ID,
+			Subject:     item.Subject,
+			Description: item.Description,
+			ActiveForm:  item.ActiveForm,
+			Status:      item.Status,
+			Owner:       item.Owner,
+			Blocks:      item.Blocks,
+			BlockedBy:   item.BlockedBy,
+		})
+	}
+	if err := m.store.ReplaceConversationTasks(ctx, convID, tasks); err != nil {
+		logger.Manager.Errorf("[%s] Failed to record task list: %v", convID, err)
+	}
+}
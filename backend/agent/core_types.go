@@ -12,6 +12,7 @@ import (
 
 type AgentEvent = coreagent.AgentEvent
 type TodoItem = coreagent.TodoItem
+type TaskItem = coreagent.TaskItem
 type RunStats = coreagent.RunStats
 type PermissionDenial = coreagent.PermissionDenial
 type FilePersistedEntry = coreagent.FilePersistedEntry
@@ -35,6 +36,9 @@ type BatchApprovalItem = coreagent.BatchApprovalItem
 type StreamEvent = coreagent.StreamEvent
 type ProcessOptions = coreagent.ProcessOptions
 type ToolApprovalOverride = coreagent.ToolApprovalOverride
+type CompactReport = coreagent.CompactReport
+type Compacter = coreagent.Compacter
+type ContextReporter = coreagent.ContextReporter
 
 // --- Function re-exports ---
 
@@ -52,6 +56,7 @@ const (
 	EventTypeToolEnd        = coreagent.EventTypeToolEnd
 	EventTypeNameSuggestion = coreagent.EventTypeNameSuggestion
 	EventTypeTodoUpdate     = coreagent.EventTypeTodoUpdate
+	EventTypeTaskListUpdate = coreagent.EventTypeTaskListUpdate
 	EventTypeResult         = coreagent.EventTypeResult
 	EventTypeComplete       = coreagent.EventTypeComplete
 	EventTypeTurnComplete   = coreagent.EventTypeTurnComplete
@@ -100,6 +105,7 @@ const (
 
 	EventTypeContextUsage      = coreagent.EventTypeContextUsage
 	EventTypeContextWindowSize = coreagent.EventTypeContextWindowSize
+	EventTypeContextBreakdown  = coreagent.EventTypeContextBreakdown
 
 	EventTypeUserQuestionRequest = coreagent.EventTypeUserQuestionRequest
 	EventTypeUserQuestionTimeout = coreagent.EventTypeUserQuestionTimeout
@@ -145,6 +151,8 @@ const (
 
 	EventTypeMemoryRecall = coreagent.EventTypeMemoryRecall
 
+	EventTypePermissionDecision = coreagent.EventTypePermissionDecision
+
 	// ChatML-specific events (emitted by agent-runner, not the Claude SDK)
 	EventTypeMessageReceived = "message_received"
 )
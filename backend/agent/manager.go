@@ -3,6 +3,7 @@ package agent
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"regexp"
@@ -13,6 +14,7 @@ import (
 	"unicode/utf8"
 
 	"github.com/chatml/chatml-backend/ai"
+	core "github.com/chatml/chatml-core"
 	"github.com/chatml/chatml-core/crypto"
 	"github.com/chatml/chatml-core/git"
 	"github.com/chatml/chatml-backend/logger"
@@ -192,6 +194,7 @@ type StartConversationOptions struct {
 	Model             string              // Model name override (e.g., "claude-opus-4-6", "claude-sonnet-4-6")
 	PermissionMode    string              // Permission mode: default, acceptEdits, bypassPermissions, dontAsk (empty = bypassPermissions)
 	Backend           string              // Backend type: "agent-runner" (default) or "native" (Go loop)
+	MaxBudgetUsd      float64             // Spend cap for the conversation (0 = unlimited)
 }
 
 // StartConversation creates and starts a new conversation within a session
@@ -315,6 +318,7 @@ func (m *Manager) StartConversation(ctx context.Context, sessionID, conversation
 		procOpts.PermissionMode = opts.PermissionMode
 		procOpts.FastMode = opts.FastMode
 		procOpts.Model = opts.Model
+		procOpts.MaxBudgetUsd = opts.MaxBudgetUsd
 	}
 
 	// Enable 1M context window for models that support it
@@ -356,6 +360,9 @@ func (m *Manager) StartConversation(ctx context.Context, sessionID, conversation
 		procOpts.McpServersJSON = mcpJSON
 	}
 
+	// Secret redaction for tool output (on unless disabled for the workspace)
+	m.applySecretRedaction(ctx, session.WorkspaceID, &procOpts)
+
 	// Check .mcp.json trust for this workspace — skip loading unless explicitly trusted.
 	// Also respect the global "never load" kill switch.
 	neverLoadDotMcp, _, _ := m.store.GetSetting(ctx, "never-load-dot-mcp")
@@ -517,6 +524,7 @@ func (m *Manager) handleConversationOutput(convID string, proc ConversationBacke
 	var pendingPlanApprovalSnapshot *PendingPlanApprovalSnapshot
 	var pendingUserQuestionSnapshot *PendingUserQuestionSnapshot
 	var pendingElicitationSnapshot *PendingElicitationSnapshot
+	var auditScope permissionAuditScope
 
 	// Per-turn accumulation for message persistence (Phase 3)
 	var completedTools []models.ToolUsageRecord
@@ -730,8 +738,14 @@ outer:
 				}
 				markSnapshotDirty()
 
-			case EventTypeSessionIdUpdate:
-				// Track the session ID so restarts can resume the correct session
+			case EventTypeSessionIdUpdate, EventTypeSessionStarted:
+				// Track the session ID so restarts can resume the correct session.
+				// The native loop reports it only through session_started;
+				// agent-runner also emits session_started from hooks, so only
+				// session_id_update counts there.
+				if event.Type == EventTypeSessionStarted && backendType != BackendNative {
+					break
+				}
 				if event.SessionID != "" {
 					proc.SetSessionID(event.SessionID)
 					// Persist to DB so the session ID survives process cleanup
@@ -1091,6 +1105,12 @@ outer:
 			case EventTypeCwdChanged, EventTypeFileChanged, EventTypeTaskCreated, EventTypeSessionStateChanged, EventTypeMemoryRecall:
 				// Informational — no state tracking needed, forwarded to frontend via onConversationEvent
 
+			case EventTypePermissionDecision:
+				m.recordPermissionDecision(ctx, convID, &auditScope, event)
+
+			case EventTypeTaskListUpdate:
+				m.recordTaskList(ctx, convID, event.Tasks)
+
 			case EventTypeTurnComplete, EventTypeComplete, EventTypeResult:
 				// Atomically clear the active turn flag and take any deferred
 				// user message. When an assistant message exists, both are
@@ -2028,6 +2048,7 @@ func (m *Manager) ResumeConversation(ctx context.Context, convID string) error {
 		logger.Manager.Errorf("Failed to load MCP servers for resume %s: %v", convID, err)
 	}
 	opts.McpServersJSON = mcpServersJSON
+	m.applySecretRedaction(ctx, session.WorkspaceID, &opts)
 
 	// Check .mcp.json trust for this workspace — skip loading unless explicitly trusted.
 	// Also respect the global "never load" kill switch.
@@ -3285,6 +3306,62 @@ func (m *Manager) SendMessage(agentID, message string) error {
 	return proc.SendMessage(message)
 }
 
+// ErrConversationNotRunning is returned for operations that need a live
+// conversation backend.
+var ErrConversationNotRunning = errors.New("conversation process not running")
+
+// ErrConversationBusy is returned for operations that must wait for the
+// current turn to finish.
+var ErrConversationBusy = errors.New("conversation is processing a turn")
+
+// CompactConversation summarizes a conversation's context on demand, focused
+// by the user's instructions. Native-loop conversations compact in place and
+// return a report with before/after token counts. The agent-runner receives
+// "/compact" and reports through compact_boundary events, so the report is nil.
+func (m *Manager) CompactConversation(ctx context.Context, convID, instructions string) (*CompactReport, error) {
+	m.mu.RLock()
+	proc, ok := m.convProcesses[convID]
+	m.mu.RUnlock()
+
+	if !ok || proc.IsStopped() || !proc.IsRunning() {
+		return nil, ErrConversationNotRunning
+	}
+	if proc.IsInActiveTurn() {
+		return nil, ErrConversationBusy
+	}
+
+	if c, ok := proc.(Compacter); ok {
+		return c.Compact(ctx, instructions)
+	}
+	return nil, proc.SendMessage(strings.TrimSpace("/compact " + instructions))
+}
+
+// ErrContextBreakdownUnsupported is returned when the conversation's backend
+// cannot attribute its context usage (the agent-runner process).
+var ErrContextBreakdownUnsupported = errors.New("context breakdown is only available for native-loop conversations")
+
+// ConversationContextBreakdown estimates a running conversation's context
+// usage per category. The breakdown is also broadcast as a context_breakdown
+// event.
+func (m *Manager) ConversationContextBreakdown(ctx context.Context, convID string) (*core.ContextBreakdown, error) {
+	m.mu.RLock()
+	proc, ok := m.convProcesses[convID]
+	m.mu.RUnlock()
+
+	if !ok || proc.IsStopped() || !proc.IsRunning() {
+		return nil, ErrConversationNotRunning
+	}
+	if proc.IsInActiveTurn() {
+		return nil, ErrConversationBusy
+	}
+
+	r, ok := proc.(ContextReporter)
+	if !ok {
+		return nil, ErrContextBreakdownUnsupported
+	}
+	return r.ContextBreakdown(ctx)
+}
+
 // SetConversationModel switches the model for a running conversation process.
 func (m *Manager) SetConversationModel(convID, model string) error {
 	m.mu.RLock()
@@ -3407,6 +3484,23 @@ func (m *Manager) loadMcpServers(ctx context.Context, workspaceID string) (strin
 	return raw, nil
 }
 
+// applySecretRedaction copies the workspace's secret redaction settings into
+// the process options. Unreadable settings leave redaction on with no
+// allowlist.
+func (m *Manager) applySecretRedaction(ctx context.Context, workspaceID string, opts *ProcessOptions) {
+	raw, found, err := m.store.GetSetting(ctx, "secret-redaction:"+workspaceID)
+	if err != nil || !found || raw == "" {
+		return
+	}
+	var settings models.SecretRedactionSettings
+	if err := json.Unmarshal([]byte(raw), &settings); err != nil {
+		logger.Manager.Errorf("Failed to parse secret redaction settings for workspace %s: %v", workspaceID, err)
+		return
+	}
+	opts.DisableSecretRedaction = !settings.Enabled
+	opts.SecretRedactionAllowlist = settings.Allowlist
+}
+
 // buildAppPrompt constructs the app-level system prompt with session context
 // and behavioral rules for the git worktree environment.
 func buildAppPrompt(session *models.Session, sessionWithWs *models.SessionWithWorkspace) string {
@@ -3600,6 +3694,7 @@ type OllamaManager interface {
 	IsRunning() bool
 	IsAlive() bool // Cheap process-state check (no HTTP call)
 	Endpoint() string
+	AuthToken() string // Bearer token for a remote endpoint; "" for the managed binary
 	EnsureRunning(ctx context.Context) error
 	Install(ctx context.Context) error
 	EnsureModelAvailable(ctx context.Context, model string) error
@@ -3631,6 +3726,7 @@ func (m *Manager) startOllamaBackend(ctx context.Context, convID string, procOpt
 		return nil
 	}
 	procOpts.OllamaEndpoint = m.ollamaMgr.Endpoint()
+	procOpts.OllamaAuthToken = m.ollamaMgr.AuthToken()
 	m.ollamaMgr.TouchActivity()
 
 	backend, err := m.createNativeBackend(ctx, procOpts)
@@ -3901,6 +3997,7 @@ func (m *Manager) ensureOllamaReady(ctx context.Context, opts ProcessOptions) (P
 	if m.ollamaMgr.IsAlive() {
 		if err := m.ollamaMgr.EnsureModelAvailable(ctx, opts.Model); err == nil {
 			opts.OllamaEndpoint = m.ollamaMgr.Endpoint()
+			opts.OllamaAuthToken = m.ollamaMgr.AuthToken()
 			m.ollamaMgr.TouchActivity()
 			return opts, true
 		}
@@ -962,6 +962,116 @@ func TestHandleConversationOutput_FinalDropReport(t *testing.T) {
 	assert.Contains(t, warningEvents[len(warningEvents)-1].Message, "3 streaming events were dropped")
 }
 
+func TestHandleConversationOutput_RecordsPermissionDecisions(t *testing.T) {
+	m, s := setupTestManager(t)
+	createTestRepo(t, s, "ws-audit")
+	createTestSession(t, s, "sess-audit", "ws-audit")
+	createTestConversation(t, s, "conv-audit", "sess-audit")
+
+	proc := NewProcess("audit-test", t.TempDir(), "conv-audit")
+	m.InsertProcessForTest("conv-audit", proc)
+
+	proc.output <- `{"type":"permission_decision","toolUseId":"t1","toolName":"Bash","specifier":"npm test","permissionDecision":"allow","decidedBy":"rule:project","mode":"default"}`
+	proc.output <- `{"type":"permission_decision","toolUseId":"t2","toolName":"Write","specifier":".env","permissionDecision":"deny","decidedBy":"user","approvalAction":"deny_once","reason":"User denied tool execution","mode":"default"}`
+	close(proc.output)
+
+	done := make(chan struct{})
+	go func() {
+		m.handleConversationOutput("conv-audit", proc, BackendNative)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("handleConversationOutput did not finish in time")
+	}
+
+	entries, err := s.ListPermissionAudit(context.Background(), store.PermissionAuditFilter{WorkspaceID: "ws-audit"})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	for _, e := range entries {
+		assert.Equal(t, "sess-audit", e.SessionID)
+		assert.Equal(t, "conv-audit", e.ConversationID)
+	}
+	byTool := map[string]*models.PermissionAuditEntry{}
+	for _, e := range entries {
+		byTool[e.ToolName] = e
+	}
+	assert.Equal(t, "rule:project", byTool["Bash"].DecidedBy)
+	assert.Equal(t, "deny", byTool["Write"].Decision)
+	assert.Equal(t, "deny_once", byTool["Write"].ApprovalAction)
+}
+
+func TestHandleConversationOutput_RecordsTaskList(t *testing.T) {
+	m, s := setupTestManager(t)
+	createTestRepo(t, s, "ws-tasks")
+	createTestSession(t, s, "sess-tasks", "ws-tasks")
+	createTestConversation(t, s, "conv-tasks", "sess-tasks")
+
+	proc := NewProcess("tasks-test", t.TempDir(), "conv-tasks")
+	m.InsertProcessForTest("conv-tasks", proc)
+
+	proc.output <- `{"type":"task_list_update","tasks":[{"id":"task-1","subject":"Write migration","status":"pending"}]}`
+	proc.output <- `{"type":"task_list_update","tasks":[{"id":"task-1","subject":"Write migration","status":"completed","blocks":["task-2"]},{"id":"task-2","subject":"Backfill","status":"pending","blockedBy":["task-1"]}]}`
+	close(proc.output)
+
+	done := make(chan struct{})
+	go func() {
+		m.handleConversationOutput("conv-tasks", proc, BackendNative)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("handleConversationOutput did not finish in time")
+	}
+
+	tasks, err := s.ListConversationTasks(context.Background(), "conv-tasks")
+	require.NoError(t, err)
+	require.Len(t, tasks, 2)
+	assert.Equal(t, "completed", tasks[0].Status)
+	assert.Equal(t, []string{"task-2"}, tasks[0].Blocks)
+	assert.Equal(t, []string{"task-1"}, tasks[1].BlockedBy)
+}
+
+func TestHandleConversationOutput_NativeSessionStartedRecordsSessionID(t *testing.T) {
+	for _, tc := range []struct {
+		backend string
+		want    string
+	}{
+		{BackendNative, "native-sess"},
+		{BackendAgentRunner, ""},
+	} {
+		t.Run(tc.backend, func(t *testing.T) {
+			m, s := setupTestManager(t)
+			createTestRepo(t, s, "ws-sid")
+			createTestSession(t, s, "sess-sid", "ws-sid")
+			createTestConversation(t, s, "conv-sid", "sess-sid")
+
+			proc := NewProcess("sid-test", t.TempDir(), "conv-sid")
+			m.InsertProcessForTest("conv-sid", proc)
+
+			proc.output <- `{"type":"session_started","sessionId":"native-sess","source":"startup"}`
+			close(proc.output)
+
+			done := make(chan struct{})
+			go func() {
+				m.handleConversationOutput("conv-sid", proc, tc.backend)
+				close(done)
+			}()
+			select {
+			case <-done:
+			case <-time.After(10 * time.Second):
+				t.Fatal("handleConversationOutput did not finish in time")
+			}
+
+			conv, err := s.GetConversationMeta(context.Background(), "conv-sid")
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, conv.AgentSessionID)
+		})
+	}
+}
+
 // ============================================================================
 // GetActiveStreamingConversations Tests
 // ============================================================================
@@ -1048,6 +1158,28 @@ PORT=8080`
 	assert.Len(t, envMap, 3)
 }
 
+func TestManager_ApplySecretRedaction(t *testing.T) {
+	ctx := context.Background()
+	m, s := setupTestManager(t)
+
+	// No setting: redaction stays on
+	var opts ProcessOptions
+	m.applySecretRedaction(ctx, "ws-1", &opts)
+	assert.False(t, opts.DisableSecretRedaction)
+	assert.Nil(t, opts.SecretRedactionAllowlist)
+
+	require.NoError(t, s.SetSetting(ctx, "secret-redaction:ws-1", `{"enabled":false,"allowlist":["test-*","rule:jwt"]}`))
+	m.applySecretRedaction(ctx, "ws-1", &opts)
+	assert.True(t, opts.DisableSecretRedaction)
+	assert.Equal(t, []string{"test-*", "rule:jwt"}, opts.SecretRedactionAllowlist)
+
+	// Malformed JSON is ignored
+	require.NoError(t, s.SetSetting(ctx, "secret-redaction:ws-2", `{`))
+	opts = ProcessOptions{}
+	m.applySecretRedaction(ctx, "ws-2", &opts)
+	assert.False(t, opts.DisableSecretRedaction)
+}
+
 func TestManager_LoadEnvVars_Empty(t *testing.T) {
 	ctx := context.Background()
 	m, _ := setupTestManager(t)
@@ -0,0 +1,51 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/chatml/chatml-backend/logger"
+	"github.com/chatml/chatml-backend/models"
+)
+
+// permissionAuditScope caches the session and workspace that a conversation's
+// permission audit entries are filed under, so each decision doesn't cost two
+// lookups.
+type permissionAuditScope struct {
+	resolved    bool
+	sessionID   string
+	workspaceID string
+}
+
+// recordPermissionDecision persists a permission_decision event to the audit
+// log. Failures are logged: auditing must never interrupt the conversation.
+func (m *Manager) recordPermissionDecision(ctx context.Context, convID string, scope *permissionAuditScope, event *AgentEvent) {
+	if !scope.resolved {
+		conv, err := m.store.GetConversationMeta(ctx, convID)
+		if err != nil {
+			logger.Manager.Warnf("[%s] Permission audit: failed to look up conversation: %v", convID, err)
+		} else if conv != nil {
+			scope.resolved = true
+			scope.sessionID = conv.SessionID
+			if session, err := m.store.GetSession(ctx, conv.SessionID); err == nil && session != nil {
+				scope.workspaceID = session.WorkspaceID
+			}
+		}
+	}
+
+	entry := &models.PermissionAuditEntry{
+		WorkspaceID:    scope.workspaceID,
+		SessionID:      scope.sessionID,
+		ConversationID: convID,
+		ToolUseID:      event.ToolUseId,
+		ToolName:       event.ToolName,
+		Specifier:      event.Specifier,
+		Decision:       event.PermissionDecision,
+		DecidedBy:      event.DecidedBy,
+		ApprovalAction: event.ApprovalAction,
+		Reason:         event.Reason,
+		Mode:           event.Mode,
+	}
+	if err := m.store.AddPermissionAudit(ctx, entry); err != nil {
+		logger.Manager.Errorf("[%s] Failed to record permission decision for %s: %v", convID, event.ToolName, err)
+	}
+}
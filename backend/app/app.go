@@ -12,6 +12,7 @@ import (
 
 	"github.com/chatml/chatml-backend/agent"
 	"github.com/chatml/chatml-backend/appdir"
+	"github.com/chatml/chatml-backend/autofix"
 	"github.com/chatml/chatml-backend/branch"
 	"github.com/chatml/chatml-core/git"
 	"github.com/chatml/chatml-backend/github"
@@ -23,6 +24,7 @@ import (
 	ollamapkg "github.com/chatml/chatml-backend/ollama"
 	"github.com/chatml/chatml-backend/scheduler"
 	"github.com/chatml/chatml-backend/server"
+	"github.com/chatml/chatml-backend/stack"
 	"github.com/chatml/chatml-backend/stats"
 	"github.com/chatml/chatml-backend/store"
 	"github.com/chatml/chatml-core/naming"
@@ -39,6 +41,8 @@ type App struct {
 	PRWatcher     *branch.PRWatcher
 	Stats         *stats.Computer
 	Scheduler     *scheduler.Scheduler
+	AutoFix       *autofix.Controller
+	Restacker     *stack.Restacker
 	GitHub        *github.Client
 	Linear        *linear.Client
 	Ollama        *ollamapkg.Manager
@@ -103,6 +107,7 @@ func New(ctx context.Context, port int) (_ *App, retErr error) {
 		})
 	})
 	app.Ollama = ollamaMgr
+	server.NewOllamaHandlers(ollamaMgr, hub, s).RestoreFromStore(ctx)
 	agentMgr.SetOllamaManager(ollamaMgr)
 	if err := agentMgr.Init(ctx); err != nil {
 		logger.Main.Errorf("Agent manager init: %v", err)
@@ -167,6 +172,8 @@ func New(ctx context.Context, port int) (_ *App, retErr error) {
 	prWatcher := branch.NewPRWatcher(ghClient, rm, s, prCache, app.onPRChange)
 	app.PRWatcher = prWatcher
 	nativeSvc.PRWatcher = prWatcher
+	prWatcher.SetReviewCommentStore(s)
+	prWatcher.SetOnReviewSync(app.onReviewSync)
 
 	// Wire PR events to agent manager
 	agentMgr.SetOnPRCreated(prWatcher.RegisterPRFromAgent)
@@ -210,6 +217,13 @@ func New(ctx context.Context, port int) (_ *App, retErr error) {
 			})
 		},
 	)
+	scriptRunner.SetServiceHandler(func(sessionID string, svc scripts.Service) {
+		hub.Broadcast(server.Event{
+			Type:      "service_status",
+			SessionID: sessionID,
+			Payload:   svc,
+		})
+	})
 	app.ScriptRunner = scriptRunner
 
 	// Create router and handlers — pass shared RepoManager and StatsComputer
@@ -229,6 +243,29 @@ func New(ctx context.Context, port int) (_ *App, retErr error) {
 	handlers.SetScheduler(taskScheduler)
 	app.Scheduler = taskScheduler
 
+	// PR automation (address review feedback, repair failing CI)
+	autoFix := autofix.NewController(ctx, s, agentMgr, prWatcher, prWatcher, func(sessionID, eventType string, payload map[string]interface{}) {
+		hub.Broadcast(server.Event{
+			Type:      eventType,
+			SessionID: sessionID,
+			Payload:   payload,
+		})
+	})
+	autoFix.SetScriptRunner(scriptRunner)
+	handlers.SetAutoFix(autoFix)
+	app.AutoFix = autoFix
+
+	// Stacked sessions (restack on parent changes, retarget on parent merge)
+	restacker := stack.NewRestacker(s, rm, ghClient, func(sessionID, eventType string, payload map[string]interface{}) {
+		hub.Broadcast(server.Event{
+			Type:      eventType,
+			SessionID: sessionID,
+			Payload:   payload,
+		})
+	})
+	handlers.SetRestacker(restacker)
+	app.Restacker = restacker
+
 	return app, nil
 }
 
@@ -236,6 +273,7 @@ func New(ctx context.Context, port int) (_ *App, retErr error) {
 func (a *App) Start() {
 	go a.Hub.Run()
 	go a.Scheduler.Start()
+	a.AutoFix.Start()
 
 	// Initialize watches for existing sessions
 	a.initBranchWatches()
@@ -253,6 +291,9 @@ func (a *App) Shutdown() {
 	if a.Scheduler != nil {
 		a.Scheduler.Stop()
 	}
+	if a.AutoFix != nil {
+		a.AutoFix.Stop()
+	}
 	if a.routerClnp != nil {
 		a.routerClnp()
 	}
@@ -386,6 +427,15 @@ func (a *App) onPRChange(event branch.PRChangeEvent) {
 			payload["taskStatus"] = sess.TaskStatus
 		}
 
+		if a.AutoFix != nil {
+			a.AutoFix.HandlePRChange(event)
+		}
+
+		// Sessions stacked on a merged PR move to the branch it merged into
+		if event.PRStatus == models.PRStatusMerged && a.Restacker != nil {
+			a.Restacker.RetargetChildren(a.ctx, event.SessionID)
+		}
+
 		a.Hub.Broadcast(server.Event{
 			Type:      "session_pr_update",
 			SessionID: event.SessionID,
@@ -401,9 +451,43 @@ func (a *App) onPRChange(event branch.PRChangeEvent) {
 		})
 
 		a.AgentMgr.RegenerateSessionSuggestions(a.ctx, event.SessionID)
+
+		// Merges made outside ChatML (GitHub UI, gh pr merge from the agent)
+		// run the post-merge hook here; MergePR has usually run it already.
+		if event.PRStatus == models.PRStatusMerged && a.handlers != nil {
+			if err := a.handlers.RunPostMergeHook(a.ctx, event.SessionID); err != nil {
+				logger.PRWatcher.Warnf("Post-merge hook failed for session %s: %v", event.SessionID, err)
+			}
+		}
 	}()
 }
 
+// onReviewSync broadcasts review comments imported or updated from GitHub PR
+// review threads, using the same events as locally-created comments.
+func (a *App) onReviewSync(event branch.ReviewSyncEvent) {
+	if a.AutoFix != nil {
+		a.AutoFix.HandleReviewSync(event)
+	}
+	for _, c := range event.Added {
+		a.Hub.Broadcast(server.Event{
+			Type:      "comment_added",
+			SessionID: event.SessionID,
+			Payload:   c,
+		})
+	}
+	for _, c := range event.Updated {
+		eventType := "comment_updated"
+		if c.Resolved {
+			eventType = "comment_resolved"
+		}
+		a.Hub.Broadcast(server.Event{
+			Type:      eventType,
+			SessionID: event.SessionID,
+			Payload:   c,
+		})
+	}
+}
+
 // initBranchWatches sets up file watchers for all existing sessions.
 func (a *App) initBranchWatches() {
 	if a.BranchWatcher == nil {
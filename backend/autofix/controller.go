@@ -0,0 +1,716 @@
+// Package autofix runs opt-in, agent-driven follow-up on a session's pull
+// request: when reviewers leave feedback or CI fails, it starts a task
+// conversation in the session with the feedback or failure logs as context.
+// Each session's loops are bounded by attempt limits and a shared spend budget
+// (models.PRAutomationPolicy).
+package autofix
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chatml/chatml-backend/agent"
+	"github.com/chatml/chatml-backend/branch"
+	"github.com/chatml/chatml-backend/github"
+	"github.com/chatml/chatml-backend/logger"
+	"github.com/chatml/chatml-backend/models"
+	"github.com/chatml/chatml-core/scripts"
+)
+
+// tickInterval is how often all enabled policies are re-evaluated. Events from
+// the PR watcher trigger evaluation immediately; the tick picks up feedback
+// that arrived while an automated or user conversation was still running.
+const tickInterval = 2 * time.Minute
+
+// Store is the persistence needed by the controller.
+type Store interface {
+	GetSession(ctx context.Context, id string) (*models.Session, error)
+	GetRepo(ctx context.Context, id string) (*models.Repo, error)
+	ListConversations(ctx context.Context, sessionID string) ([]*models.Conversation, error)
+	GetConversationCost(ctx context.Context, conversationID string) (float64, error)
+	GetPRAutomationPolicy(ctx context.Context, sessionID string) (*models.PRAutomationPolicy, error)
+	ListActivePRAutomationPolicies(ctx context.Context) ([]*models.PRAutomationPolicy, error)
+	SavePRAutomationPolicy(ctx context.Context, p *models.PRAutomationPolicy) error
+}
+
+// ConversationStarter starts agent conversations (implemented by agent.Manager).
+type ConversationStarter interface {
+	StartConversation(ctx context.Context, sessionID, conversationType, initialMessage string, opts *agent.StartConversationOptions) (*models.Conversation, error)
+}
+
+// FeedbackSource collects outstanding PR feedback (implemented by branch.PRWatcher).
+type FeedbackSource interface {
+	CollectFeedback(ctx context.Context, sessionID string) (*branch.PRFeedback, error)
+}
+
+// CIFailureSource assembles CI failure context for a session's PR and re-polls
+// its status (implemented by branch.PRWatcher).
+type CIFailureSource interface {
+	CollectCIFailures(ctx context.Context, sessionID string) (*github.CIFailureContext, error)
+	ForceCheckSession(sessionID string)
+}
+
+// ScriptRunner runs a workspace script to completion (implemented by
+// scripts.Runner).
+type ScriptRunner interface {
+	RunScriptAndWait(ctx context.Context, sessionID, workdir, scriptKey string, script scripts.ScriptDef) (*scripts.ScriptRun, error)
+}
+
+// BroadcastFunc notifies the frontend of automation events for a session.
+type BroadcastFunc func(sessionID, eventType string, payload map[string]interface{})
+
+// Controller evaluates PR automation policies and starts fix conversations.
+type Controller struct {
+	store     Store
+	starter   ConversationStarter
+	feedback  FeedbackSource
+	ci        CIFailureSource
+	scripts   ScriptRunner
+	broadcast BroadcastFunc
+
+	mu       sync.Mutex
+	inflight map[string]bool // sessionID -> evaluation running
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewController creates a controller. Call Start to begin periodic evaluation.
+func NewController(ctx context.Context, s Store, starter ConversationStarter, feedback FeedbackSource, ci CIFailureSource, broadcast BroadcastFunc) *Controller {
+	ctx, cancel := context.WithCancel(ctx)
+	return &Controller{
+		store:     s,
+		starter:   starter,
+		feedback:  feedback,
+		ci:        ci,
+		broadcast: broadcast,
+		inflight:  make(map[string]bool),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+// SetScriptRunner enables local verification: after each automated round of a
+// session with CI repair on, the workspace's test scripts are run and failures
+// start another repair round.
+func (c *Controller) SetScriptRunner(r ScriptRunner) {
+	c.scripts = r
+}
+
+func (c *Controller) Start() {
+	c.wg.Add(1)
+	go c.run()
+}
+
+func (c *Controller) Stop() {
+	c.cancel()
+	c.wg.Wait()
+}
+
+func (c *Controller) run() {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+			c.evaluateAll()
+		}
+	}
+}
+
+func (c *Controller) evaluateAll() {
+	policies, err := c.store.ListActivePRAutomationPolicies(c.ctx)
+	if err != nil {
+		logger.AutoFix.Errorf("Failed to list PR automation policies: %v", err)
+		return
+	}
+	for _, p := range policies {
+		c.Trigger(p.SessionID)
+	}
+}
+
+// HandlePRChange reacts to PR watcher status changes. A transition to
+// "changes requested" or to failing checks triggers an evaluation for the
+// session.
+func (c *Controller) HandlePRChange(event branch.PRChangeEvent) {
+	if event.PRStatus != models.PRStatusOpen {
+		return
+	}
+	if event.ReviewDecision == string(github.ReviewChangesRequested) || event.CheckStatus == string(github.CheckStatusFailure) {
+		c.Trigger(event.SessionID)
+	}
+}
+
+// HandleReviewSync reacts to review thread syncs. New or reopened GitHub
+// threads trigger an evaluation for the session.
+func (c *Controller) HandleReviewSync(event branch.ReviewSyncEvent) {
+	for _, list := range [][]*models.ReviewComment{event.Added, event.Updated} {
+		for _, cm := range list {
+			if !cm.Resolved && cm.Source == models.CommentSourceGitHub {
+				c.Trigger(event.SessionID)
+				return
+			}
+		}
+	}
+}
+
+// Trigger evaluates a session's policy in the background. Concurrent triggers
+// for the same session collapse into the running evaluation.
+func (c *Controller) Trigger(sessionID string) {
+	c.mu.Lock()
+	if c.inflight[sessionID] || c.ctx.Err() != nil {
+		c.mu.Unlock()
+		return
+	}
+	c.inflight[sessionID] = true
+	c.mu.Unlock()
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		defer func() {
+			c.mu.Lock()
+			delete(c.inflight, sessionID)
+			c.mu.Unlock()
+		}()
+		if err := c.Evaluate(c.ctx, sessionID); err != nil {
+			logger.AutoFix.Warnf("Evaluation failed for session %s: %v", sessionID, err)
+		}
+	}()
+}
+
+// Evaluate settles the previous automated round, enforces limits, and starts a
+// new fix conversation if the previous round left the workspace's test scripts
+// failing, the session's PR has feedback not yet handed to the agent, or CI
+// failed on a commit the agent hasn't tried to fix, in that order. At most one
+// conversation is started per evaluation.
+func (c *Controller) Evaluate(ctx context.Context, sessionID string) error {
+	policy, err := c.store.GetPRAutomationPolicy(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if policy == nil || !(policy.FeedbackActive() || policy.CIRepairActive()) {
+		return nil
+	}
+
+	sess, err := c.store.GetSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if sess == nil || sess.Archived || sess.PRStatus != models.PRStatusOpen {
+		return nil
+	}
+
+	// Never run alongside another conversation in the same worktree; the next
+	// tick retries once the session is idle.
+	convs, err := c.store.ListConversations(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	for _, conv := range convs {
+		if conv.Status == models.ConversationStatusActive {
+			return nil
+		}
+	}
+
+	dirty, settled := false, false
+	if policy.ActiveConversationID != "" {
+		cost, err := c.store.GetConversationCost(ctx, policy.ActiveConversationID)
+		if err != nil {
+			return err
+		}
+		policy.SpentUSD += cost
+		policy.ActiveConversationID = ""
+		dirty, settled = true, true
+
+		// The round most likely pushed a commit; re-poll so new check runs are
+		// picked up without waiting for the watcher's next cycle.
+		if c.ci != nil {
+			c.ci.ForceCheckSession(sessionID)
+		}
+	}
+
+	if policy.BudgetUSD > 0 && policy.SpentUSD >= policy.BudgetUSD {
+		return c.stop(ctx, policy, models.PRAutomationStoppedBudget)
+	}
+
+	if settled && policy.CIRepairActive() && c.scripts != nil {
+		done, err := c.verifyLocally(ctx, sess, policy)
+		if err != nil {
+			if dirty {
+				_ = c.store.SavePRAutomationPolicy(ctx, policy)
+			}
+			return err
+		}
+		if done {
+			return nil
+		}
+	}
+
+	if policy.FeedbackActive() {
+		done, err := c.evaluateFeedback(ctx, policy)
+		if err != nil {
+			if dirty {
+				_ = c.store.SavePRAutomationPolicy(ctx, policy)
+			}
+			return err
+		}
+		if done {
+			return nil
+		}
+	}
+
+	if policy.CIRepairActive() && sess.CheckStatus == models.CheckStatusFailure && c.ci != nil {
+		done, err := c.evaluateCI(ctx, sess, policy)
+		if err != nil {
+			if dirty {
+				_ = c.store.SavePRAutomationPolicy(ctx, policy)
+			}
+			return err
+		}
+		if done {
+			return nil
+		}
+	}
+
+	if dirty {
+		return c.store.SavePRAutomationPolicy(ctx, policy)
+	}
+	return nil
+}
+
+// evaluateFeedback starts a feedback round if there is unhandled feedback.
+// It reports whether the policy was saved (a round started or the loop stopped).
+func (c *Controller) evaluateFeedback(ctx context.Context, policy *models.PRAutomationPolicy) (bool, error) {
+	fb, err := c.feedback.CollectFeedback(ctx, policy.SessionID)
+	if err != nil {
+		return false, err
+	}
+	items := pendingFeedback(policy, fb)
+	if len(items) == 0 {
+		return false, nil
+	}
+
+	if policy.Iterations >= policy.MaxIterations {
+		return true, c.stop(ctx, policy, models.PRAutomationStoppedMaxIterations)
+	}
+
+	conv, err := c.startRound(ctx, policy, buildFeedbackPrompt(fb.PRNumber, items))
+	if err != nil {
+		return false, err
+	}
+
+	policy.Iterations++
+	policy.ActiveConversationID = conv.ID
+	for _, it := range items {
+		policy.HandledFeedback = append(policy.HandledFeedback, it.key)
+	}
+	if err := c.store.SavePRAutomationPolicy(ctx, policy); err != nil {
+		return true, err
+	}
+
+	logger.AutoFix.Infof("Started feedback round %d/%d for session %s (PR #%d, %d item(s)) in conversation %s",
+		policy.Iterations, policy.MaxIterations, policy.SessionID, fb.PRNumber, len(items), conv.ID)
+	c.emit(policy.SessionID, "pr_autofix_started", map[string]interface{}{
+		"conversationId": conv.ID,
+		"iteration":      policy.Iterations,
+		"maxIterations":  policy.MaxIterations,
+		"spentUsd":       policy.SpentUSD,
+		"budgetUsd":      policy.BudgetUSD,
+	})
+	return true, nil
+}
+
+// evaluateCI starts a CI repair round if the latest commit on the PR branch
+// has failed jobs and the agent hasn't already been asked to fix that commit.
+// It reports whether the policy was saved (a round started or the loop stopped).
+func (c *Controller) evaluateCI(ctx context.Context, sess *models.Session, policy *models.PRAutomationPolicy) (bool, error) {
+	failure, err := c.ci.CollectCIFailures(ctx, sess.ID)
+	if err != nil {
+		return false, err
+	}
+	if failure.Status != github.CIStatusHasFailures || failure.HeadSHA == "" || failure.HeadSHA == policy.CILastSHA {
+		return false, nil
+	}
+
+	jobs := failedJobNames(failure)
+	repeats := 0
+	if policy.CIAttempts > 0 && overlaps(jobs, policy.CILastFailedJobs) {
+		repeats = policy.CIRepeatCount + 1
+	}
+	if repeats >= models.PRAutomationMaxCIRepeats {
+		policy.CIRepeatCount = repeats
+		return true, c.stop(ctx, policy, models.PRAutomationStoppedCIRepeating)
+	}
+	if policy.CIAttempts >= policy.MaxCIAttempts {
+		return true, c.stop(ctx, policy, models.PRAutomationStoppedMaxCIAttempts)
+	}
+
+	conv, err := c.startRound(ctx, policy, buildCIFixPrompt(sess.PRNumber, failure, c.testScripts(ctx, sess), c.scripts != nil))
+	if err != nil {
+		return false, err
+	}
+
+	policy.CIAttempts++
+	policy.CILastSHA = failure.HeadSHA
+	policy.CILastFailedJobs = jobs
+	policy.CIRepeatCount = repeats
+	policy.ActiveConversationID = conv.ID
+	if err := c.store.SavePRAutomationPolicy(ctx, policy); err != nil {
+		return true, err
+	}
+
+	logger.AutoFix.Infof("Started CI repair attempt %d/%d for session %s (PR #%d, jobs: %s) in conversation %s",
+		policy.CIAttempts, policy.MaxCIAttempts, policy.SessionID, sess.PRNumber, strings.Join(jobs, ", "), conv.ID)
+	c.emit(policy.SessionID, "pr_ci_fix_started", map[string]interface{}{
+		"conversationId": conv.ID,
+		"attempt":        policy.CIAttempts,
+		"maxAttempts":    policy.MaxCIAttempts,
+		"headSha":        failure.HeadSHA,
+		"failedJobs":     jobs,
+		"spentUsd":       policy.SpentUSD,
+		"budgetUsd":      policy.BudgetUSD,
+	})
+	return true, nil
+}
+
+// testOutputLines is how much of a failing test script's output is handed to
+// the agent.
+const testOutputLines = 100
+
+// localJobPrefix marks test script names in CILastFailedJobs so they don't
+// match CI job names.
+const localJobPrefix = "local:"
+
+// scriptFailure is a test script that failed during local verification.
+type scriptFailure struct {
+	script testScript
+	err    error
+	output []string
+}
+
+// verifyLocally runs the workspace's test scripts after an automated round
+// and, if any fail, starts a repair round with their output. The round counts
+// as a CI repair attempt. It reports whether the policy was saved (a round
+// started or the loop stopped).
+func (c *Controller) verifyLocally(ctx context.Context, sess *models.Session, policy *models.PRAutomationPolicy) (bool, error) {
+	if sess.WorktreePath == "" {
+		return false, nil
+	}
+	tests := c.testScripts(ctx, sess)
+	if len(tests) == 0 {
+		return false, nil
+	}
+
+	var failures []scriptFailure
+	for _, t := range tests {
+		run, err := c.scripts.RunScriptAndWait(ctx, sess.ID, sess.WorktreePath, t.key, t.def)
+		if ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+		if err != nil {
+			f := scriptFailure{script: t, err: err}
+			if run != nil {
+				f.output = run.OutputTail(testOutputLines)
+			}
+			failures = append(failures, f)
+		}
+	}
+	if len(failures) == 0 {
+		logger.AutoFix.Infof("Test scripts pass for session %s after the automated round", sess.ID)
+		return false, nil
+	}
+
+	jobs := make([]string, len(failures))
+	for i, f := range failures {
+		jobs[i] = localJobPrefix + f.script.key
+	}
+	repeats := 0
+	if policy.CIAttempts > 0 && overlaps(jobs, policy.CILastFailedJobs) {
+		repeats = policy.CIRepeatCount + 1
+	}
+	if repeats >= models.PRAutomationMaxCIRepeats {
+		policy.CIRepeatCount = repeats
+		return true, c.stop(ctx, policy, models.PRAutomationStoppedCIRepeating)
+	}
+	if policy.CIAttempts >= policy.MaxCIAttempts {
+		return true, c.stop(ctx, policy, models.PRAutomationStoppedMaxCIAttempts)
+	}
+
+	conv, err := c.startRound(ctx, policy, buildTestFixPrompt(sess.PRNumber, failures))
+	if err != nil {
+		return false, err
+	}
+
+	policy.CIAttempts++
+	policy.CILastFailedJobs = jobs
+	policy.CIRepeatCount = repeats
+	policy.ActiveConversationID = conv.ID
+	if err := c.store.SavePRAutomationPolicy(ctx, policy); err != nil {
+		return true, err
+	}
+
+	logger.AutoFix.Infof("Started test repair attempt %d/%d for session %s (scripts: %s) in conversation %s",
+		policy.CIAttempts, policy.MaxCIAttempts, policy.SessionID, strings.Join(jobs, ", "), conv.ID)
+	c.emit(policy.SessionID, "pr_ci_fix_started", map[string]interface{}{
+		"conversationId": conv.ID,
+		"attempt":        policy.CIAttempts,
+		"maxAttempts":    policy.MaxCIAttempts,
+		"failedJobs":     jobs,
+		"local":          true,
+		"spentUsd":       policy.SpentUSD,
+		"budgetUsd":      policy.BudgetUSD,
+	})
+	return true, nil
+}
+
+// startRound starts an automated task conversation capped at the remaining budget.
+func (c *Controller) startRound(ctx context.Context, policy *models.PRAutomationPolicy, prompt string) (*models.Conversation, error) {
+	opts := &agent.StartConversationOptions{
+		// The prompt carries reviewer and CI text, so edits are allowed but
+		// commands still need the user's approval in the conversation.
+		PermissionMode: "acceptEdits",
+	}
+	if policy.BudgetUSD > 0 {
+		opts.MaxBudgetUsd = policy.BudgetUSD - policy.SpentUSD
+	}
+	conv, err := c.starter.StartConversation(ctx, policy.SessionID, models.ConversationTypeTask, prompt, opts)
+	if err != nil {
+		return nil, fmt.Errorf("start conversation: %w", err)
+	}
+	return conv, nil
+}
+
+// stop records why a loop stopped and notifies the frontend. CI limits stop
+// only the CI repair loop, the budget stops both, and anything else stops the
+// feedback loop. A stopped loop stays stopped until the user saves the policy
+// again.
+func (c *Controller) stop(ctx context.Context, policy *models.PRAutomationPolicy, reason string) error {
+	switch reason {
+	case models.PRAutomationStoppedBudget:
+		policy.StoppedReason = reason
+		policy.CIStoppedReason = reason
+	case models.PRAutomationStoppedMaxCIAttempts, models.PRAutomationStoppedCIRepeating:
+		policy.CIStoppedReason = reason
+	default:
+		policy.StoppedReason = reason
+	}
+	if err := c.store.SavePRAutomationPolicy(ctx, policy); err != nil {
+		return err
+	}
+	logger.AutoFix.Infof("Stopped PR automation for session %s: %s (iterations=%d, ciAttempts=%d, spent=$%.2f)",
+		policy.SessionID, reason, policy.Iterations, policy.CIAttempts, policy.SpentUSD)
+	c.emit(policy.SessionID, "pr_autofix_stopped", map[string]interface{}{
+		"reason":     reason,
+		"iterations": policy.Iterations,
+		"ciAttempts": policy.CIAttempts,
+		"spentUsd":   policy.SpentUSD,
+	})
+	return nil
+}
+
+func (c *Controller) emit(sessionID, eventType string, payload map[string]interface{}) {
+	if c.broadcast != nil {
+		c.broadcast(sessionID, eventType, payload)
+	}
+}
+
+// feedbackItem is a single review or thread not yet handed to the agent.
+type feedbackItem struct {
+	key    string
+	review *github.PRReview
+	thread *models.ReviewComment
+}
+
+// pendingFeedback returns the feedback in fb that the policy hasn't handled.
+// Reviews without a body carry no actionable text on their own (their inline
+// comments arrive as threads) and are skipped.
+func pendingFeedback(policy *models.PRAutomationPolicy, fb *branch.PRFeedback) []feedbackItem {
+	if fb.IsEmpty() {
+		return nil
+	}
+	var items []feedbackItem
+	for i := range fb.ChangesRequested {
+		r := &fb.ChangesRequested[i]
+		key := fmt.Sprintf("review:%d", r.ID)
+		if strings.TrimSpace(r.Body) == "" || policy.HasHandled(key) {
+			continue
+		}
+		items = append(items, feedbackItem{key: key, review: r})
+	}
+	for _, t := range fb.Threads {
+		key := "thread:" + t.GitHubThreadID
+		if policy.HasHandled(key) {
+			continue
+		}
+		items = append(items, feedbackItem{key: key, thread: t})
+	}
+	return items
+}
+
+// buildFeedbackPrompt renders the task message for a feedback round.
+func buildFeedbackPrompt(prNumber int, items []feedbackItem) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Reviewers left feedback on PR #%d. Address it:\n\n", prNumber)
+
+	for _, it := range items {
+		if it.review != nil {
+			fmt.Fprintf(&sb, "## Review from @%s (changes requested)\n\n%s\n\n", it.review.Author, strings.TrimSpace(it.review.Body))
+		}
+	}
+	for _, it := range items {
+		if it.thread != nil {
+			fmt.Fprintf(&sb, "## Thread on %s:%d from @%s (comment ID: %s)\n\n%s\n\n",
+				it.thread.FilePath, it.thread.LineNumber, it.thread.Author, it.thread.ID, it.thread.Content)
+		}
+	}
+
+	sb.WriteString(`When you are done:
+1. Commit your changes and push them to the PR branch.
+2. For each thread above, call mcp__chatml__resolve_review_comment with its comment ID and a short ` + "`reply`" + ` describing what you changed. If you disagree with a comment, resolve it as "ignored" with a reply explaining why instead of changing the code.
+`)
+	return sb.String()
+}
+
+// failedJobNames returns the sorted, de-duplicated names of the failed jobs.
+func failedJobNames(failure *github.CIFailureContext) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, run := range failure.FailedRuns {
+		for _, job := range run.FailedJobs {
+			if !seen[job.JobName] {
+				seen[job.JobName] = true
+				names = append(names, job.JobName)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// overlaps reports whether a and b share an element.
+func overlaps(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// testScript is a run script from .chatml/config.json that looks like a test
+// command.
+type testScript struct {
+	key string
+	def scripts.ScriptDef
+}
+
+// testScripts returns the run scripts from the workspace's .chatml/config.json
+// that look like test commands, sorted by key. Services are skipped. The
+// config is read from the workspace repository, not the session's worktree:
+// the agent can edit the worktree copy without approval, and these scripts
+// run without it.
+func (c *Controller) testScripts(ctx context.Context, sess *models.Session) []testScript {
+	repo, err := c.store.GetRepo(ctx, sess.WorkspaceID)
+	if err != nil || repo == nil || repo.Path == "" {
+		return nil
+	}
+	config, err := scripts.LoadConfig(repo.Path)
+	if err != nil {
+		logger.AutoFix.Warnf("Failed to load .chatml/config.json from %s: %v", repo.Path, err)
+		return nil
+	}
+	if config == nil {
+		return nil
+	}
+	keys := make([]string, 0, len(config.RunScripts))
+	for key, def := range config.RunScripts {
+		if def.Service {
+			continue
+		}
+		if strings.Contains(strings.ToLower(key), "test") || strings.Contains(strings.ToLower(def.Name), "test") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	tests := make([]testScript, 0, len(keys))
+	for _, key := range keys {
+		tests = append(tests, testScript{key: key, def: config.RunScripts[key]})
+	}
+	return tests
+}
+
+// buildCIFixPrompt renders the task message for a CI repair round. When
+// verified is set, the controller runs the test scripts itself after the round.
+func buildCIFixPrompt(prNumber int, failure *github.CIFailureContext, tests []testScript, verified bool) string {
+	var sb strings.Builder
+	sha := failure.HeadSHA
+	if len(sha) > 7 {
+		sha = sha[:7]
+	}
+	fmt.Fprintf(&sb, "CI is failing on PR #%d (commit %s). Fix it:\n\n", prNumber, sha)
+
+	for _, run := range failure.FailedRuns {
+		for _, job := range run.FailedJobs {
+			fmt.Fprintf(&sb, "## Job %q in workflow %q\n\n", job.JobName, run.RunName)
+			if len(job.FailedSteps) > 0 {
+				fmt.Fprintf(&sb, "Failed steps: %s\n", strings.Join(job.FailedSteps, ", "))
+			}
+			if job.JobURL != "" {
+				fmt.Fprintf(&sb, "%s\n", job.JobURL)
+			}
+			if job.Truncated {
+				fmt.Fprintf(&sb, "Log truncated to its last lines (%d lines in total):\n", job.LogLines)
+			}
+			fmt.Fprintf(&sb, "\n```\n%s\n```\n\n", strings.TrimRight(job.Logs, "\n"))
+		}
+	}
+	if failure.Truncated {
+		fmt.Fprintf(&sb, "Note: %d jobs failed in total; only the first few are shown above.\n\n", failure.TotalFailed)
+	}
+
+	sb.WriteString("When you are done:\n")
+	if len(tests) > 0 {
+		sb.WriteString("1. Verify the fix locally by running the workspace's test scripts:\n")
+		for _, t := range tests {
+			fmt.Fprintf(&sb, "   - %s: `%s`\n", t.def.Name, t.def.Command)
+		}
+		if verified {
+			sb.WriteString("   They are run again after you finish; failures start another repair round.\n")
+		}
+	} else {
+		sb.WriteString("1. Verify the fix locally by running the checks that failed above.\n")
+	}
+	sb.WriteString("2. Commit your changes and push them to the PR branch. CI will run again on the new commit.\n")
+	return sb.String()
+}
+
+// buildTestFixPrompt renders the task message for a round started because the
+// workspace's test scripts failed after the previous round.
+func buildTestFixPrompt(prNumber int, failures []scriptFailure) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "The workspace's test scripts fail after the last round of fixes on PR #%d. Fix them:\n\n", prNumber)
+	for _, f := range failures {
+		fmt.Fprintf(&sb, "## %s (`%s`)\n\n%v\n", f.script.def.Name, f.script.def.Command, f.err)
+		if len(f.output) > 0 {
+			fmt.Fprintf(&sb, "\n```\n%s\n```\n", strings.Join(f.output, "\n"))
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("When you are done:\n")
+	sb.WriteString("1. Run the failing scripts above again and make sure they pass.\n")
+	sb.WriteString("2. Commit your changes and push them to the PR branch.\n")
+	return sb.String()
+}
@@ -0,0 +1,21 @@
+package branch
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/chatml/chatml-backend/github"
+)
+
+// CollectCIFailures assembles the CI failure context (failed runs, jobs,
+// steps and truncated logs) for the latest commit on a session's PR branch.
+func (w *PRWatcher) CollectCIFailures(ctx context.Context, sessionID string) (*github.CIFailureContext, error) {
+	if w.ghClient == nil || !w.ghClient.IsAuthenticated() {
+		return nil, fmt.Errorf("GitHub not authenticated")
+	}
+	owner, repo, entry, err := w.reviewTarget(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return w.ghClient.GetCIFailureContext(ctx, owner, repo, entry.Branch)
+}
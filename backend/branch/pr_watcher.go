@@ -21,6 +21,7 @@ type PRWatchEntry struct {
 	PRUrl       string
 	PRTitle     string
 	CheckStatus string
+	ReviewDecision string // "approved", "changes_requested", "review_required", "none"
 	Mergeable      *bool
 	LastChecked    time.Time
 	SuppressUntil  time.Time // Suppress PR re-detection until this time (set by UnlinkPR)
@@ -28,13 +29,14 @@ type PRWatchEntry struct {
 
 // PRChangeEvent is emitted when a session's PR status changes
 type PRChangeEvent struct {
-	SessionID   string
-	PRStatus    string
-	PRNumber    int
-	PRUrl       string
-	PRTitle     string
-	CheckStatus string
-	Mergeable   *bool
+	SessionID      string
+	PRStatus       string
+	PRNumber       int
+	PRUrl          string
+	PRTitle        string
+	CheckStatus    string
+	Mergeable      *bool
+	ReviewDecision string // See github.ReviewDecision
 }
 
 // PRWatcherStore is the interface for database operations needed by PRWatcher
@@ -59,6 +61,8 @@ type PRWatcher struct {
 	prCache       *github.PRCache // Shared cache with ListPRs handler
 	onChange      func(PRChangeEvent)
 	onPRDetails   func(sessionID string, details *github.PRDetails) // Wired by handlers to keep prStatusCache warm.
+	reviewStore   ReviewCommentStore                                // Optional; enables review thread sync (see review_sync.go)
+	onReviewSync  func(ReviewSyncEvent)
 	ctx           context.Context
 	cancel        context.CancelFunc
 	backfillOnce  sync.Once
@@ -718,6 +722,9 @@ func (w *PRWatcher) checkRepoSessions(repoSessions map[repoKey][]*PRWatchEntry,
 		for _, entry := range entries {
 			w.checkSessionPR(key.owner, key.repo, entry, branchToPR)
 		}
+
+		// Mirror review threads for sessions that (still) have an open PR.
+		w.syncReviewThreadsForRepo(key.owner, key.repo, entries)
 	}
 }
 
@@ -756,6 +763,7 @@ func (w *PRWatcher) checkSessionPR(owner, repo string, entry *PRWatchEntry, bran
 	var prUrl string
 	var prTitle string
 	var checkStatus string
+	var reviewDecision string
 	var mergeable *bool
 
 	if hasPR {
@@ -779,6 +787,7 @@ func (w *PRWatcher) checkSessionPR(owner, repo string, entry *PRWatchEntry, bran
 		}
 		if details != nil {
 			checkStatus = string(details.CheckStatus)
+			reviewDecision = string(details.ReviewDecision)
 			mergeable = details.Mergeable
 			// Warm the per-session pr-status cache (handler reads this on GET).
 			w.emitPRDetails(entry.SessionID, details)
@@ -813,6 +822,7 @@ func (w *PRWatcher) checkSessionPR(owner, repo string, entry *PRWatchEntry, bran
 				prUrl = entry.PRUrl
 				prTitle = entry.PRTitle
 				checkStatus = entry.CheckStatus
+				reviewDecision = entry.ReviewDecision
 			}
 		} else {
 			// Couldn't fetch details - check merge endpoint directly
@@ -843,6 +853,9 @@ func (w *PRWatcher) checkSessionPR(owner, repo string, entry *PRWatchEntry, bran
 	if checkStatus != entry.CheckStatus {
 		changed = true
 	}
+	if reviewDecision != entry.ReviewDecision {
+		changed = true
+	}
 	if !boolPtrEqual(mergeable, entry.Mergeable) {
 		changed = true
 	}
@@ -862,6 +875,7 @@ func (w *PRWatcher) checkSessionPR(owner, repo string, entry *PRWatchEntry, bran
 	entry.PRUrl = prUrl
 	entry.PRTitle = prTitle
 	entry.CheckStatus = checkStatus
+	entry.ReviewDecision = reviewDecision
 	entry.Mergeable = mergeable
 	entry.LastChecked = time.Now()
 	w.mu.Unlock()
@@ -902,13 +916,14 @@ func (w *PRWatcher) checkSessionPR(owner, repo string, entry *PRWatchEntry, bran
 	// Emit change event
 	if w.onChange != nil {
 		w.onChange(PRChangeEvent{
-			SessionID:   entry.SessionID,
-			PRStatus:    newStatus,
-			PRNumber:    prNumber,
-			PRUrl:       prUrl,
-			PRTitle:     prTitle,
-			CheckStatus: checkStatus,
-			Mergeable:   mergeable,
+			SessionID:      entry.SessionID,
+			PRStatus:       newStatus,
+			PRNumber:       prNumber,
+			PRUrl:          prUrl,
+			PRTitle:        prTitle,
+			CheckStatus:    checkStatus,
+			Mergeable:      mergeable,
+			ReviewDecision: reviewDecision,
 		})
 	}
 }
@@ -0,0 +1,560 @@
+package branch
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/chatml/chatml-backend/github"
+	"github.com/chatml/chatml-backend/logger"
+	"github.com/chatml/chatml-backend/models"
+	"github.com/google/uuid"
+)
+
+// ReviewCommentStore is the interface for review comment persistence needed to
+// mirror GitHub PR review threads into a session's review comments.
+type ReviewCommentStore interface {
+	AddReviewComment(ctx context.Context, comment *models.ReviewComment) error
+	GetReviewComment(ctx context.Context, id string) (*models.ReviewComment, error)
+	ListReviewComments(ctx context.Context, sessionID string) ([]*models.ReviewComment, error)
+	UpdateReviewComment(ctx context.Context, id string, updates func(*models.ReviewComment)) error
+}
+
+// ReviewSyncEvent is emitted when review comments for a session were created or
+// changed by a GitHub sync.
+type ReviewSyncEvent struct {
+	SessionID string
+	Added     []*models.ReviewComment
+	Updated   []*models.ReviewComment
+}
+
+// PublishResult reports the outcome of publishing local comments to GitHub.
+type PublishResult struct {
+	ReviewID  int64  `json:"reviewId"`
+	ReviewURL string `json:"reviewUrl,omitempty"`
+	Published int    `json:"published"`
+}
+
+// SetReviewCommentStore enables two-way review thread sync. Without it the
+// watcher only tracks PR lifecycle.
+func (w *PRWatcher) SetReviewCommentStore(s ReviewCommentStore) {
+	w.mu.Lock()
+	w.reviewStore = s
+	w.mu.Unlock()
+}
+
+// SetOnReviewSync registers a callback fired after a sync changed local comments.
+func (w *PRWatcher) SetOnReviewSync(cb func(ReviewSyncEvent)) {
+	w.mu.Lock()
+	w.onReviewSync = cb
+	w.mu.Unlock()
+}
+
+// SyncReviewThreads imports review threads for a session's open PR immediately.
+func (w *PRWatcher) SyncReviewThreads(ctx context.Context, sessionID string) error {
+	owner, repo, entry, err := w.reviewTarget(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	return w.syncReviewThreads(ctx, owner, repo, entry)
+}
+
+// reviewTarget resolves the GitHub repo and PR for a watched session.
+func (w *PRWatcher) reviewTarget(ctx context.Context, sessionID string) (string, string, PRWatchEntry, error) {
+	if !w.ensureSessionWatched(sessionID, "reviewTarget") {
+		return "", "", PRWatchEntry{}, fmt.Errorf("session %s is not watched", sessionID)
+	}
+	w.mu.RLock()
+	entry := *w.sessions[sessionID]
+	w.mu.RUnlock()
+
+	// Auto-registered entries start without PR data until the next poll.
+	if entry.PRNumber == 0 && w.store != nil {
+		if sess, err := w.store.GetSession(ctx, sessionID); err == nil && sess != nil {
+			entry.PRNumber = sess.PRNumber
+			entry.PRStatus = sess.PRStatus
+		}
+	}
+	if entry.PRNumber == 0 {
+		return "", "", entry, fmt.Errorf("session %s has no pull request", sessionID)
+	}
+	owner, repo, err := w.repoManager.GetGitHubRemote(ctx, entry.RepoPath)
+	if err != nil {
+		return "", "", entry, fmt.Errorf("resolve GitHub remote: %w", err)
+	}
+	return owner, repo, entry, nil
+}
+
+// syncReviewThreadsForRepo runs a thread sync for every open-PR session in a
+// repo group. Errors are logged; one failing PR must not block the others.
+func (w *PRWatcher) syncReviewThreadsForRepo(owner, repo string, entries []*PRWatchEntry) {
+	w.mu.RLock()
+	enabled := w.reviewStore != nil
+	w.mu.RUnlock()
+	if !enabled {
+		return
+	}
+
+	for _, e := range entries {
+		if w.ctx.Err() != nil {
+			return
+		}
+		w.mu.RLock()
+		entry := *e
+		w.mu.RUnlock()
+		if entry.PRStatus != models.PRStatusOpen || entry.PRNumber == 0 {
+			continue
+		}
+		if err := w.syncReviewThreads(w.ctx, owner, repo, entry); err != nil {
+			logger.PRWatcher.Warnf("Review thread sync failed for session %s (PR #%d): %v", entry.SessionID, entry.PRNumber, err)
+		}
+	}
+}
+
+// syncReviewThreads mirrors GitHub review threads into local review comments.
+// Threads are matched to local comments by thread ID or by the root comment's
+// database ID (for comments published from ChatML). Imported comments track
+// thread content and replies; resolution changes made on GitHub are applied
+// locally, while local resolutions are pushed by MirrorCommentResolution;
+// pushes that failed earlier are retried here.
+func (w *PRWatcher) syncReviewThreads(ctx context.Context, owner, repo string, entry PRWatchEntry) error {
+	w.mu.RLock()
+	rs := w.reviewStore
+	w.mu.RUnlock()
+	if rs == nil {
+		return fmt.Errorf("review sync not enabled")
+	}
+
+	threads, err := w.ghClient.ListPRReviewThreads(ctx, owner, repo, entry.PRNumber)
+	if err != nil {
+		return err
+	}
+
+	existing, err := rs.ListReviewComments(ctx, entry.SessionID)
+	if err != nil {
+		return fmt.Errorf("list local comments: %w", err)
+	}
+	byThread := make(map[string]*models.ReviewComment)
+	byCommentID := make(map[int64]*models.ReviewComment)
+	for _, c := range existing {
+		if c.GitHubThreadID != "" {
+			byThread[c.GitHubThreadID] = c
+		}
+		if c.GitHubCommentID != 0 {
+			byCommentID[c.GitHubCommentID] = c
+		}
+	}
+
+	evt := ReviewSyncEvent{SessionID: entry.SessionID}
+	for _, t := range threads {
+		if len(t.Comments) == 0 {
+			continue
+		}
+		root := t.Comments[0]
+
+		local := byThread[t.ID]
+		if local == nil {
+			local = byCommentID[root.DatabaseID]
+		}
+
+		if local == nil {
+			comment := newCommentFromThread(entry.SessionID, t)
+			if err := rs.AddReviewComment(ctx, comment); err != nil {
+				logger.PRWatcher.Warnf("Failed to import review thread %s for session %s: %v", t.ID, entry.SessionID, err)
+				continue
+			}
+			evt.Added = append(evt.Added, comment)
+			continue
+		}
+
+		if threadChanged(local, t) {
+			if err := rs.UpdateReviewComment(ctx, local.ID, func(c *models.ReviewComment) {
+				applyThread(c, t)
+			}); err != nil {
+				logger.PRWatcher.Warnf("Failed to update review comment %s from thread %s: %v", local.ID, t.ID, err)
+				continue
+			}
+			if updated, err := rs.GetReviewComment(ctx, local.ID); err == nil && updated != nil {
+				evt.Updated = append(evt.Updated, updated)
+				local = updated
+			}
+		}
+		if local.Resolved != local.GitHubResolved {
+			if err := w.MirrorCommentResolution(ctx, local); err != nil {
+				logger.PRWatcher.Warnf("Failed to mirror resolution of review comment %s to thread %s: %v", local.ID, t.ID, err)
+			}
+		}
+	}
+
+	if len(evt.Added) == 0 && len(evt.Updated) == 0 {
+		return nil
+	}
+	logger.PRWatcher.Infof("Synced review threads for session %s (PR #%d): %d added, %d updated",
+		entry.SessionID, entry.PRNumber, len(evt.Added), len(evt.Updated))
+
+	w.mu.RLock()
+	cb := w.onReviewSync
+	w.mu.RUnlock()
+	if cb != nil {
+		cb(evt)
+	}
+	return nil
+}
+
+// newCommentFromThread builds a local review comment for a GitHub thread.
+func newCommentFromThread(sessionID string, t github.ReviewThread) *models.ReviewComment {
+	root := t.Comments[0]
+	comment := &models.ReviewComment{
+		ID:         uuid.New().String(),
+		SessionID:  sessionID,
+		FilePath:   t.Path,
+		LineNumber: max(t.Line, 1),
+		Content:    threadContent(t),
+		Source:     models.CommentSourceGitHub,
+		Author:     root.Author,
+		CreatedAt:  root.CreatedAt,
+	}
+	if comment.CreatedAt.IsZero() {
+		comment.CreatedAt = time.Now()
+	}
+	applyThread(comment, t)
+	return comment
+}
+
+// applyThread copies GitHub-owned state from a thread onto a local comment.
+// Content is only rewritten for comments that originated on GitHub; comments
+// authored in ChatML keep their local text.
+func applyThread(c *models.ReviewComment, t github.ReviewThread) {
+	root := t.Comments[0]
+	c.GitHubThreadID = t.ID
+	c.GitHubCommentID = root.DatabaseID
+	c.GitHubURL = root.URL
+	if c.Source == models.CommentSourceGitHub {
+		c.Content = threadContent(t)
+		if t.Line > 0 {
+			c.LineNumber = t.Line
+		}
+	}
+
+	// Only a change on the GitHub side moves local resolution; otherwise a
+	// local resolve that hasn't been pushed yet would be reverted.
+	if t.IsResolved != c.GitHubResolved {
+		c.GitHubResolved = t.IsResolved
+		c.Resolved = t.IsResolved
+		if t.IsResolved {
+			now := time.Now()
+			c.ResolvedAt = &now
+			c.ResolvedBy = t.ResolvedBy
+			c.ResolutionType = models.CommentResolutionFixed
+		} else {
+			c.ResolvedAt = nil
+			c.ResolvedBy = ""
+			c.ResolutionType = ""
+		}
+	}
+}
+
+// threadChanged reports whether applying the thread would modify the comment.
+func threadChanged(c *models.ReviewComment, t github.ReviewThread) bool {
+	cp := *c
+	applyThread(&cp, t)
+	return cp.GitHubThreadID != c.GitHubThreadID ||
+		cp.GitHubCommentID != c.GitHubCommentID ||
+		cp.GitHubURL != c.GitHubURL ||
+		cp.Content != c.Content ||
+		cp.LineNumber != c.LineNumber ||
+		cp.GitHubResolved != c.GitHubResolved
+}
+
+// threadContent renders a thread's root comment followed by its replies.
+func threadContent(t github.ReviewThread) string {
+	var sb strings.Builder
+	sb.WriteString(t.Comments[0].Body)
+	for _, reply := range t.Comments[1:] {
+		fmt.Fprintf(&sb, "\n\n---\n**@%s** replied:\n\n%s", reply.Author, reply.Body)
+	}
+	return sb.String()
+}
+
+// PublishReviewComments publishes unresolved local comments that are not yet on
+// GitHub as a single pending review on the session's PR. When commentIDs is
+// empty, all eligible comments are published. Published comments are linked to
+// their GitHub counterparts so later syncs and resolutions round-trip.
+func (w *PRWatcher) PublishReviewComments(ctx context.Context, sessionID string, commentIDs []string) (*PublishResult, error) {
+	w.mu.RLock()
+	rs := w.reviewStore
+	w.mu.RUnlock()
+	if rs == nil {
+		return nil, fmt.Errorf("review sync not enabled")
+	}
+
+	owner, repo, entry, err := w.reviewTarget(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	all, err := rs.ListReviewComments(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("list local comments: %w", err)
+	}
+	wanted := make(map[string]bool, len(commentIDs))
+	for _, id := range commentIDs {
+		wanted[id] = true
+	}
+
+	var pending []*models.ReviewComment
+	var drafts []github.DraftReviewComment
+	for _, c := range all {
+		if c.Resolved || c.IsLinkedToGitHub() || c.Source == models.CommentSourceGitHub {
+			continue
+		}
+		if len(wanted) > 0 && !wanted[c.ID] {
+			continue
+		}
+		pending = append(pending, c)
+		drafts = append(drafts, github.DraftReviewComment{
+			Path: c.FilePath,
+			Line: c.LineNumber,
+			Body: publishedBody(c),
+		})
+	}
+	if len(pending) == 0 {
+		return &PublishResult{}, nil
+	}
+
+	review, err := w.ghClient.CreatePendingReview(ctx, owner, repo, entry.PRNumber, "", drafts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Link local comments to the created GitHub comments. GitHub doesn't echo
+	// comment IDs on review creation, so match them back by path, line and body.
+	created, err := w.ghClient.ListReviewComments(ctx, owner, repo, entry.PRNumber, review.ID)
+	if err != nil {
+		logger.PRWatcher.Warnf("Published review %d for session %s but failed to list its comments: %v", review.ID, sessionID, err)
+	}
+	// Thread IDs are only exposed through GraphQL; they are what resolution
+	// mirroring needs.
+	threadByRoot := make(map[int64]string)
+	if len(created) > 0 {
+		threads, err := w.ghClient.ListPRReviewThreads(ctx, owner, repo, entry.PRNumber)
+		if err != nil {
+			logger.PRWatcher.Warnf("Published review %d for session %s but failed to list its threads: %v", review.ID, sessionID, err)
+		}
+		for _, t := range threads {
+			if len(t.Comments) > 0 {
+				threadByRoot[t.Comments[0].DatabaseID] = t.ID
+			}
+		}
+	}
+	used := make(map[int64]bool)
+	for i, c := range pending {
+		for _, gc := range created {
+			if used[gc.ID] || gc.Path != c.FilePath || gc.Body != drafts[i].Body {
+				continue
+			}
+			used[gc.ID] = true
+			if err := rs.UpdateReviewComment(ctx, c.ID, func(rc *models.ReviewComment) {
+				rc.GitHubCommentID = gc.ID
+				rc.GitHubURL = gc.HTMLURL
+				rc.GitHubThreadID = threadByRoot[gc.ID]
+			}); err != nil {
+				logger.PRWatcher.Warnf("Failed to link review comment %s to GitHub comment %d: %v", c.ID, gc.ID, err)
+			}
+			break
+		}
+	}
+
+	logger.PRWatcher.Infof("Published %d review comment(s) for session %s as pending review %d on PR #%d",
+		len(pending), sessionID, review.ID, entry.PRNumber)
+	return &PublishResult{ReviewID: review.ID, ReviewURL: review.HTMLURL, Published: len(pending)}, nil
+}
+
+// publishedBody renders a local comment for GitHub, folding in title and severity.
+func publishedBody(c *models.ReviewComment) string {
+	var sb strings.Builder
+	if c.Title != "" {
+		sb.WriteString("**" + c.Title + "**")
+		if c.Severity != "" {
+			sb.WriteString(" (" + c.Severity + ")")
+		}
+		sb.WriteString("\n\n")
+	}
+	sb.WriteString(c.Content)
+	return sb.String()
+}
+
+// MirrorCommentResolution pushes a local resolve/unresolve of a GitHub-linked
+// comment to its review thread. No-op for comments without a thread or whose
+// state already matches GitHub.
+func (w *PRWatcher) MirrorCommentResolution(ctx context.Context, comment *models.ReviewComment) error {
+	if comment == nil || comment.GitHubThreadID == "" || comment.Resolved == comment.GitHubResolved {
+		return nil
+	}
+	w.mu.RLock()
+	rs := w.reviewStore
+	w.mu.RUnlock()
+	if rs == nil || w.ghClient == nil || !w.ghClient.IsAuthenticated() {
+		return nil
+	}
+
+	var err error
+	if comment.Resolved {
+		err = w.ghClient.ResolveReviewThread(ctx, comment.GitHubThreadID)
+	} else {
+		err = w.ghClient.UnresolveReviewThread(ctx, comment.GitHubThreadID)
+	}
+	if err != nil {
+		return err
+	}
+
+	resolved := comment.Resolved
+	return rs.UpdateReviewComment(ctx, comment.ID, func(c *models.ReviewComment) {
+		c.GitHubResolved = resolved
+	})
+}
+
+// ReplyToReviewComment posts a reply on the GitHub review thread a local
+// comment is linked to.
+func (w *PRWatcher) ReplyToReviewComment(ctx context.Context, comment *models.ReviewComment, body string) error {
+	if comment == nil || comment.GitHubCommentID == 0 {
+		return fmt.Errorf("comment is not linked to a GitHub review thread")
+	}
+	if w.ghClient == nil || !w.ghClient.IsAuthenticated() {
+		return fmt.Errorf("GitHub not authenticated")
+	}
+	owner, repo, entry, err := w.reviewTarget(ctx, comment.SessionID)
+	if err != nil {
+		return err
+	}
+	_, err = w.ghClient.ReplyToReviewComment(ctx, owner, repo, entry.PRNumber, comment.GitHubCommentID, body)
+	return err
+}
+
+// PRFeedback is the outstanding reviewer feedback on a session's PR.
+type PRFeedback struct {
+	PRNumber int
+	// ChangesRequested holds each reviewer's latest review when it requests
+	// changes (reviews later superseded by an approval or dismissal are dropped).
+	ChangesRequested []github.PRReview
+	// Threads holds unresolved review comments imported from GitHub threads.
+	Threads []*models.ReviewComment
+}
+
+// IsEmpty reports whether there is no outstanding feedback.
+func (f *PRFeedback) IsEmpty() bool {
+	return f == nil || (len(f.ChangesRequested) == 0 && len(f.Threads) == 0)
+}
+
+// CollectFeedback gathers the outstanding reviewer feedback for a session's
+// open PR: blocking "changes requested" reviews and unresolved review threads.
+// Feedback is handed to an agent, so only trusted authors count: reviews and
+// threads from anyone else are dropped, as are untrusted replies within a
+// thread (see trustedAuthors).
+func (w *PRWatcher) CollectFeedback(ctx context.Context, sessionID string) (*PRFeedback, error) {
+	w.mu.RLock()
+	rs := w.reviewStore
+	w.mu.RUnlock()
+	if rs == nil {
+		return nil, fmt.Errorf("review sync not enabled")
+	}
+	if w.ghClient == nil || !w.ghClient.IsAuthenticated() {
+		return nil, fmt.Errorf("GitHub not authenticated")
+	}
+
+	owner, repo, entry, err := w.reviewTarget(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	fb := &PRFeedback{PRNumber: entry.PRNumber}
+	trusted := w.trustedAuthors(ctx, owner, repo)
+
+	reviews, err := w.ghClient.ListPRReviews(ctx, owner, repo, entry.PRNumber)
+	if err != nil {
+		return nil, err
+	}
+	// Same precedence rules as github.computeReviewDecision.
+	latest := make(map[string]github.PRReview)
+	var order []string
+	for _, r := range reviews {
+		switch r.State {
+		case "APPROVED", "CHANGES_REQUESTED":
+			if _, seen := latest[r.Author]; !seen {
+				order = append(order, r.Author)
+			}
+			latest[r.Author] = r
+		case "DISMISSED":
+			delete(latest, r.Author)
+		}
+	}
+	for _, author := range order {
+		if r, ok := latest[author]; ok && r.State == "CHANGES_REQUESTED" && trusted(author) {
+			fb.ChangesRequested = append(fb.ChangesRequested, r)
+		}
+	}
+
+	comments, err := rs.ListReviewComments(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("list local comments: %w", err)
+	}
+	var open []*models.ReviewComment
+	for _, c := range comments {
+		if !c.Resolved && c.Source == models.CommentSourceGitHub && c.GitHubThreadID != "" {
+			open = append(open, c)
+		}
+	}
+	if len(open) == 0 {
+		return fb, nil
+	}
+
+	// Local content includes every reply, so rebuild it from the live thread
+	// with untrusted comments left out.
+	threads, err := w.ghClient.ListPRReviewThreads(ctx, owner, repo, entry.PRNumber)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]github.ReviewThread, len(threads))
+	for _, t := range threads {
+		byID[t.ID] = t
+	}
+	for _, c := range open {
+		t, ok := byID[c.GitHubThreadID]
+		if !ok || len(t.Comments) == 0 || !trusted(t.Comments[0].Author) {
+			continue
+		}
+		kept := t
+		kept.Comments = nil
+		for _, tc := range t.Comments {
+			if trusted(tc.Author) {
+				kept.Comments = append(kept.Comments, tc)
+			}
+		}
+		cp := *c
+		cp.Content = threadContent(kept)
+		fb.Threads = append(fb.Threads, &cp)
+	}
+	return fb, nil
+}
+
+// trustedAuthors returns a predicate for the authors whose feedback may be
+// handed to an agent: the authenticated user and the repository's
+// collaborators. Lookups are cached for the predicate's lifetime; a failed
+// lookup counts as untrusted.
+func (w *PRWatcher) trustedAuthors(ctx context.Context, owner, repo string) func(string) bool {
+	cache := make(map[string]bool)
+	if u := w.ghClient.GetStoredUser(); u != nil && u.Login != "" {
+		cache[u.Login] = true
+	}
+	return func(login string) bool {
+		if ok, seen := cache[login]; seen {
+			return ok
+		}
+		ok, err := w.ghClient.IsCollaborator(ctx, owner, repo, login)
+		if err != nil {
+			logger.PRWatcher.Warnf("Failed to check whether %s collaborates on %s/%s: %v", login, owner, repo, err)
+		}
+		cache[login] = ok
+		return ok
+	}
+}
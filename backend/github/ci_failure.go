@@ -0,0 +1,268 @@
+package github
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/chatml/chatml-backend/logger"
+)
+
+// CIFailureContext holds aggregated CI failure information for all failing jobs.
+//
+// Status describes the snapshot so the frontend (and the agent) can tell apart
+// the four reasons FailedRuns can be empty:
+//   - "has_failures" — FailedRuns is non-empty.
+//   - "all_passed"   — runs exist on the latest SHA and all completed without
+//     a failure-equivalent conclusion.
+//   - "in_progress"  — runs exist on the latest SHA but at least one is still
+//     queued/in-progress and no failed jobs have surfaced yet.
+//   - "no_runs"      — no workflow runs were returned for the branch.
+type CIFailureContext struct {
+	Branch      string             `json:"branch"`
+	HeadSHA     string             `json:"headSha,omitempty"`
+	Status      string             `json:"status"`
+	FailedRuns  []FailedRunContext `json:"failedRuns"`
+	TotalFailed int                `json:"totalFailed"`
+	Truncated   bool               `json:"truncated"`
+}
+
+// CI status values for CIFailureContext.Status. Kept as constants so callers
+// can reference them without typo risk.
+const (
+	CIStatusHasFailures = "has_failures"
+	CIStatusAllPassed   = "all_passed"
+	CIStatusInProgress  = "in_progress"
+	CIStatusNoRuns      = "no_runs"
+)
+
+// FailedRunContext holds failure details for a single workflow run.
+type FailedRunContext struct {
+	RunID      int64              `json:"runId"`
+	RunName    string             `json:"runName"`
+	RunURL     string             `json:"runUrl"`
+	FailedJobs []FailedJobContext `json:"failedJobs"`
+}
+
+// FailedJobContext holds failure details and truncated logs for a single job.
+type FailedJobContext struct {
+	JobID       int64    `json:"jobId"`
+	JobName     string   `json:"jobName"`
+	JobURL      string   `json:"jobUrl"`
+	FailedSteps []string `json:"failedSteps"`
+	Logs        string   `json:"logs"`
+	LogLines    int      `json:"logLines"`
+	Truncated   bool     `json:"truncated"`
+}
+
+const (
+	maxLogLinesPerJob = 150
+	// maxFailedJobs is the total number of failed jobs collected across all workflow runs.
+	// Jobs beyond this limit are still counted in TotalFailed but their logs are not fetched.
+	maxFailedJobs = 5
+)
+
+// truncateLogLines returns the last n lines of a log string.
+func truncateLogLines(logs string, maxLines int) (string, int, bool) {
+	lines := strings.Split(logs, "\n")
+	totalLines := len(lines)
+	if totalLines <= maxLines {
+		return logs, totalLines, false
+	}
+	truncated := strings.Join(lines[totalLines-maxLines:], "\n")
+	return truncated, totalLines, true
+}
+
+// GetCIFailureContext aggregates CI failure context for a branch: failed
+// workflow runs on the latest head SHA, their failed jobs, failed step names,
+// and truncated logs.
+func (c *Client) GetCIFailureContext(ctx context.Context, owner, repo, branch string) (*CIFailureContext, error) {
+	// Fetch workflow runs for this branch
+	runs, err := c.ListWorkflowRuns(ctx, owner, repo, branch)
+	if err != nil {
+		return nil, err
+	}
+
+	// Find the latest head SHA from any run (runs are returned newest first).
+	// We intentionally do not restrict to "completed" runs here — a workflow run
+	// may still be "in_progress" if some jobs are still running, even though one
+	// or more individual jobs have already completed with a failure.
+	var latestSHA string
+	for _, run := range runs {
+		latestSHA = run.HeadSHA
+		break // runs are returned newest first
+	}
+
+	if latestSHA == "" {
+		return &CIFailureContext{
+			Branch:      branch,
+			Status:      CIStatusNoRuns,
+			FailedRuns:  []FailedRunContext{},
+			TotalFailed: 0,
+		}, nil
+	}
+
+	// Filter to runs from the latest SHA that have or may have failures.
+	// Include both fully-completed failed runs and in-progress runs (their
+	// individual jobs may already be completed with a failure conclusion).
+	// Skip runs that are still queued/waiting and have no jobs to inspect yet.
+	//
+	// hasPendingOnLatestSHA tracks whether any latest-SHA run is still queued
+	// or in-progress, so we can distinguish "still running" from "all passed"
+	// when no failures surface.
+	var eligibleRuns []WorkflowRun
+	hasPendingOnLatestSHA := false
+	for _, run := range runs {
+		if run.HeadSHA != latestSHA {
+			continue
+		}
+		if run.Status != "completed" {
+			hasPendingOnLatestSHA = true
+		}
+		if run.Status == "queued" || run.Status == "waiting" || run.Status == "pending" || run.Status == "requested" {
+			continue
+		}
+		if run.Status == "completed" &&
+			run.Conclusion != "failure" &&
+			run.Conclusion != "timed_out" &&
+			run.Conclusion != "action_required" {
+			continue
+		}
+		eligibleRuns = append(eligibleRuns, run)
+	}
+
+	// Fetch jobs for all eligible runs concurrently. Each ListWorkflowJobs call
+	// can paginate up to 5 pages, so serializing them across runs would
+	// linearly inflate p99 latency.
+	type runJobsResult struct {
+		jobs []WorkflowJob
+		err  error
+	}
+	jobResults := make([]runJobsResult, len(eligibleRuns))
+	var fetchWg sync.WaitGroup
+	for i, run := range eligibleRuns {
+		fetchWg.Add(1)
+		go func(idx int, runID int64) {
+			defer fetchWg.Done()
+			jobs, err := c.ListWorkflowJobs(ctx, owner, repo, runID)
+			jobResults[idx] = runJobsResult{jobs: jobs, err: err}
+		}(i, run.ID)
+	}
+	fetchWg.Wait()
+
+	// Process results serially so jobCount cap and truncation flag are
+	// applied deterministically (matches GitHub's newest-first ordering).
+	var failedRuns []FailedRunContext
+	totalFailed := 0
+	truncatedOverall := false
+	jobCount := 0
+
+	for i, run := range eligibleRuns {
+		if jobResults[i].err != nil {
+			logger.GitHub.Warnf("Failed to fetch jobs for run %d: %v", run.ID, jobResults[i].err)
+			continue
+		}
+
+		var failedJobs []FailedJobContext
+		for _, job := range jobResults[i].jobs {
+			if job.Status != "completed" {
+				// Defensive: GitHub usually completes all jobs before
+				// flipping run.Status, so the run-level pending check
+				// above already covers this. Keep the per-job set in
+				// case a straggler job appears under a "completed" run.
+				hasPendingOnLatestSHA = true
+			}
+			// action_required is a failure-equivalent: GitHub flags the run
+			// red and the Checks panel surfaces it the same way. Stay
+			// consistent with frontend and pr_status filters.
+			if job.Conclusion != "failure" &&
+				job.Conclusion != "timed_out" &&
+				job.Conclusion != "action_required" {
+				continue
+			}
+
+			totalFailed++
+			jobCount++
+			if jobCount > maxFailedJobs {
+				truncatedOverall = true
+				continue
+			}
+
+			// Extract failed step names
+			var failedSteps []string
+			for _, step := range job.Steps {
+				if step.Conclusion == "failure" || step.Conclusion == "timed_out" {
+					failedSteps = append(failedSteps, step.Name)
+				}
+			}
+
+			failedJobs = append(failedJobs, FailedJobContext{
+				JobID:       job.ID,
+				JobName:     job.Name,
+				JobURL:      job.HTMLURL,
+				FailedSteps: failedSteps,
+			})
+		}
+
+		if len(failedJobs) > 0 {
+			failedRuns = append(failedRuns, FailedRunContext{
+				RunID:      run.ID,
+				RunName:    run.Name,
+				RunURL:     run.HTMLURL,
+				FailedJobs: failedJobs,
+			})
+		}
+	}
+
+	// Fetch logs for all failed jobs in parallel.
+	// Each goroutine writes to a distinct slice element so no mutex is needed.
+	var wg sync.WaitGroup
+
+	for i := range failedRuns {
+		for j := range failedRuns[i].FailedJobs {
+			wg.Add(1)
+			go func(runIdx, jobIdx int) {
+				defer wg.Done()
+				job := &failedRuns[runIdx].FailedJobs[jobIdx]
+
+				logs, err := c.GetJobLogs(ctx, owner, repo, job.JobID)
+				if err != nil {
+					logger.GitHub.Warnf("Failed to fetch logs for job %d: %v", job.JobID, err)
+					job.Logs = "(logs unavailable)"
+					job.LogLines = 0
+					return
+				}
+
+				truncatedLogs, totalLines, wasTruncated := truncateLogLines(logs, maxLogLinesPerJob)
+				job.Logs = truncatedLogs
+				job.LogLines = totalLines
+				job.Truncated = wasTruncated
+			}(i, j)
+		}
+	}
+	wg.Wait()
+
+	status := CIStatusHasFailures
+	if len(failedRuns) == 0 {
+		if hasPendingOnLatestSHA {
+			status = CIStatusInProgress
+		} else {
+			status = CIStatusAllPassed
+		}
+	}
+
+	result := &CIFailureContext{
+		Branch:      branch,
+		HeadSHA:     latestSHA,
+		Status:      status,
+		FailedRuns:  failedRuns,
+		TotalFailed: totalFailed,
+		Truncated:   truncatedOverall,
+	}
+
+	if result.FailedRuns == nil {
+		result.FailedRuns = []FailedRunContext{}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,114 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetCIFailureContext_LatestSHAFailures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/owner/repo/actions/runs":
+			assert.Equal(t, "feature", r.URL.Query().Get("branch"))
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"workflow_runs": []map[string]interface{}{
+					{"id": 2, "name": "CI", "status": "completed", "conclusion": "failure", "head_sha": "new"},
+					{"id": 1, "name": "CI", "status": "completed", "conclusion": "failure", "head_sha": "old"},
+				},
+			})
+		case "/repos/owner/repo/actions/runs/2/jobs":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"jobs": []map[string]interface{}{
+					{"id": 20, "name": "build", "status": "completed", "conclusion": "success"},
+					{"id": 21, "name": "test", "status": "completed", "conclusion": "failure",
+						"steps": []map[string]interface{}{
+							{"name": "Run tests", "status": "completed", "conclusion": "failure", "number": 3},
+						}},
+				},
+			})
+		case "/repos/owner/repo/actions/jobs/21/logs":
+			w.Header().Set("Location", "http://"+r.Host+"/blob/21")
+			w.WriteHeader(http.StatusFound)
+		case "/blob/21":
+			w.Write([]byte("--- FAIL: TestThing\n"))
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("", "")
+	client.SetToken("test_token")
+	client.SetAPIURL(server.URL)
+
+	result, err := client.GetCIFailureContext(context.Background(), "owner", "repo", "feature")
+	require.NoError(t, err)
+	assert.Equal(t, CIStatusHasFailures, result.Status)
+	assert.Equal(t, "new", result.HeadSHA)
+	assert.Equal(t, 1, result.TotalFailed)
+	require.Len(t, result.FailedRuns, 1, "runs for older commits are ignored")
+	require.Len(t, result.FailedRuns[0].FailedJobs, 1)
+	job := result.FailedRuns[0].FailedJobs[0]
+	assert.Equal(t, "test", job.JobName)
+	assert.Equal(t, []string{"Run tests"}, job.FailedSteps)
+	assert.Contains(t, job.Logs, "FAIL: TestThing")
+}
+
+func TestGetCIFailureContext_NoRuns(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"workflow_runs": []interface{}{}})
+	}))
+	defer server.Close()
+
+	client := NewClient("", "")
+	client.SetToken("test_token")
+	client.SetAPIURL(server.URL)
+
+	result, err := client.GetCIFailureContext(context.Background(), "owner", "repo", "feature")
+	require.NoError(t, err)
+	assert.Equal(t, CIStatusNoRuns, result.Status)
+	assert.NotNil(t, result.FailedRuns)
+}
+
+func TestTruncateLogLines_UnderLimit(t *testing.T) {
+	logs := "line1\nline2\nline3"
+	result, totalLines, truncated := truncateLogLines(logs, 10)
+
+	assert.Equal(t, logs, result, "logs under limit should be returned unchanged")
+	assert.Equal(t, 3, totalLines, "totalLines should reflect actual line count")
+	assert.False(t, truncated, "should not be truncated when under limit")
+}
+
+func TestTruncateLogLines_OverLimit(t *testing.T) {
+	logs := "line1\nline2\nline3\nline4\nline5"
+	result, totalLines, truncated := truncateLogLines(logs, 3)
+
+	assert.Equal(t, "line3\nline4\nline5", result, "should return last N lines")
+	assert.Equal(t, 5, totalLines, "totalLines should reflect original count")
+	assert.True(t, truncated, "should be marked as truncated")
+}
+
+func TestTruncateLogLines_ExactLimit(t *testing.T) {
+	logs := "line1\nline2\nline3"
+	result, totalLines, truncated := truncateLogLines(logs, 3)
+
+	assert.Equal(t, logs, result, "logs at exact limit should be returned unchanged")
+	assert.Equal(t, 3, totalLines, "totalLines should equal maxLines")
+	assert.False(t, truncated, "should not be truncated when exactly at limit")
+}
+
+func TestTruncateLogLines_EmptyString(t *testing.T) {
+	result, totalLines, truncated := truncateLogLines("", 10)
+
+	assert.Equal(t, "", result, "empty string should return empty")
+	// strings.Split("", "\n") returns [""], so totalLines is 1
+	assert.Equal(t, 1, totalLines, "empty string splits into one element")
+	assert.False(t, truncated, "empty string should not be truncated")
+}
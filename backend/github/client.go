@@ -628,6 +628,44 @@ func (c *Client) CreatePullRequest(ctx context.Context, owner, repo string, pr C
 	return &result, nil
 }
 
+// UpdatePullRequestBase changes the base branch of an open pull request, e.g.
+// retargeting a stacked PR to main once the PR below it has merged.
+func (c *Client) UpdatePullRequestBase(ctx context.Context, owner, repo string, prNumber int, base string) error {
+	token, err := c.getValidToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	prURL := fmt.Sprintf("%s/repos/%s/%s/pulls/%d", c.apiURL, owner, repo, prNumber)
+
+	body, err := json.Marshal(map[string]string{"base": base})
+	if err != nil {
+		return fmt.Errorf("marshaling PR update: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "PATCH", prURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("updating pull request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("GitHub returned %d: %s", resp.StatusCode, respBody)
+	}
+
+	return nil
+}
+
 // GetCombinedStatus gets the combined status for a ref (branch, tag, or SHA)
 func (c *Client) GetCombinedStatus(ctx context.Context, owner, repo, ref string) (*CombinedStatus, error) {
 	token, err := c.getValidToken(ctx)
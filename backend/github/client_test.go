@@ -598,6 +598,43 @@ func TestClient_CreatePullRequest_APIError(t *testing.T) {
 	require.Contains(t, err.Error(), "already exists")
 }
 
+func TestClient_UpdatePullRequestBase_Success(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/repos/owner/repo/pulls/7", r.URL.Path)
+		require.Equal(t, "PATCH", r.Method)
+
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		require.Equal(t, map[string]string{"base": "main"}, body)
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"number":7}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("", "")
+	client.apiURL = server.URL
+	client.SetToken("test-token")
+
+	require.NoError(t, client.UpdatePullRequestBase(context.Background(), "owner", "repo", 7, "main"))
+}
+
+func TestClient_UpdatePullRequestBase_APIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"message":"Proposed base branch 'gone' was not found"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("", "")
+	client.apiURL = server.URL
+	client.SetToken("test-token")
+
+	err := client.UpdatePullRequestBase(context.Background(), "owner", "repo", 7, "gone")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "422")
+}
+
 func TestClient_CreatePullRequest_ContextCancellation(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		time.Sleep(100 * time.Millisecond)
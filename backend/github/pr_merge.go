@@ -0,0 +1,173 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// MergeMethod is the strategy GitHub uses to merge a pull request
+type MergeMethod string
+
+const (
+	MergeMethodMerge  MergeMethod = "merge"
+	MergeMethodSquash MergeMethod = "squash"
+	MergeMethodRebase MergeMethod = "rebase"
+)
+
+// Valid reports whether m is a merge method GitHub accepts.
+func (m MergeMethod) Valid() bool {
+	switch m {
+	case MergeMethodMerge, MergeMethodSquash, MergeMethodRebase:
+		return true
+	}
+	return false
+}
+
+// graphQLName returns the PullRequestMergeMethod enum value for m.
+func (m MergeMethod) graphQLName() string {
+	return strings.ToUpper(string(m))
+}
+
+// MergePullRequestRequest contains the parameters for merging a pull request
+type MergePullRequestRequest struct {
+	MergeMethod   MergeMethod `json:"merge_method"`
+	CommitTitle   string      `json:"commit_title,omitempty"`
+	CommitMessage string      `json:"commit_message,omitempty"`
+	SHA           string      `json:"sha,omitempty"` // Head SHA the merge must match; guards against racing pushes
+}
+
+// MergePullRequestResponse contains the response from merging a pull request
+type MergePullRequestResponse struct {
+	SHA     string `json:"sha"`
+	Merged  bool   `json:"merged"`
+	Message string `json:"message"`
+}
+
+// MergeError is returned when GitHub refuses a merge (405: not mergeable,
+// 409: head SHA changed). Message is GitHub's explanation.
+type MergeError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *MergeError) Error() string {
+	return fmt.Sprintf("GitHub refused merge (%d): %s", e.StatusCode, e.Message)
+}
+
+// MergePullRequest merges a pull request immediately
+func (c *Client) MergePullRequest(ctx context.Context, owner, repo string, prNumber int, merge MergePullRequestRequest) (*MergePullRequestResponse, error) {
+	token, err := c.getValidToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	mergeURL := fmt.Sprintf("%s/repos/%s/%s/pulls/%d/merge", c.apiURL, owner, repo, prNumber)
+
+	body, err := json.Marshal(merge)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling merge request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "PUT", mergeURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("merging pull request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusConflict {
+		var ghErr struct {
+			Message string `json:"message"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&ghErr)
+		return nil, &MergeError{StatusCode: resp.StatusCode, Message: ghErr.Message}
+	}
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("GitHub returned %d: %s", resp.StatusCode, respBody)
+	}
+
+	var result MergePullRequestResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// EnableAutoMerge turns on auto-merge for a pull request so GitHub merges it
+// once required checks and reviews pass. prNodeID is PRFullDetails.NodeID.
+func (c *Client) EnableAutoMerge(ctx context.Context, prNodeID string, method MergeMethod) error {
+	const mutation = `mutation($id: ID!, $method: PullRequestMergeMethod!) {
+		enablePullRequestAutoMerge(input: {pullRequestId: $id, mergeMethod: $method}) {
+			pullRequest { id }
+		}
+	}`
+	return c.graphql(ctx, mutation, map[string]any{"id": prNodeID, "method": method.graphQLName()}, nil)
+}
+
+// EnqueuePullRequest adds a pull request to the base branch's merge queue.
+// The queue's own settings decide the merge method.
+func (c *Client) EnqueuePullRequest(ctx context.Context, prNodeID string) error {
+	const mutation = `mutation($id: ID!) {
+		enqueuePullRequest(input: {pullRequestId: $id}) {
+			mergeQueueEntry { id }
+		}
+	}`
+	return c.graphql(ctx, mutation, map[string]any{"id": prNodeID}, nil)
+}
+
+// DeleteBranch deletes a branch on GitHub. A branch that no longer exists is
+// not an error.
+func (c *Client) DeleteBranch(ctx context.Context, owner, repo, branch string) error {
+	token, err := c.getValidToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	refURL := fmt.Sprintf("%s/repos/%s/%s/git/refs/heads/%s", c.apiURL, owner, repo, escapeRef(branch))
+	req, err := http.NewRequestWithContext(ctx, "DELETE", refURL, nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("deleting branch: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNoContent, http.StatusNotFound, http.StatusUnprocessableEntity:
+		// 422 "Reference does not exist" when the branch was already deleted
+		return nil
+	}
+	respBody, _ := io.ReadAll(resp.Body)
+	return fmt.Errorf("GitHub returned %d: %s", resp.StatusCode, respBody)
+}
+
+// escapeRef path-escapes each segment of a ref name, keeping the slashes.
+func escapeRef(ref string) string {
+	parts := strings.Split(ref, "/")
+	for i, p := range parts {
+		parts[i] = url.PathEscape(p)
+	}
+	return strings.Join(parts, "/")
+}
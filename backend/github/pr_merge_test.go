@@ -0,0 +1,117 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMergeTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	client := NewClient("", "")
+	client.SetToken("test_token")
+	client.SetAPIURL(server.URL)
+	return client
+}
+
+func TestMergePullRequest_Success(t *testing.T) {
+	client := newMergeTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "PUT", r.Method)
+		assert.Equal(t, "/repos/owner/repo/pulls/42/merge", r.URL.Path)
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "squash", body["merge_method"])
+		assert.Equal(t, "abc123", body["sha"])
+		assert.Equal(t, "Add feature (#42)", body["commit_title"])
+		json.NewEncoder(w).Encode(map[string]interface{}{"sha": "merged1", "merged": true, "message": "Pull Request successfully merged"})
+	})
+
+	result, err := client.MergePullRequest(context.Background(), "owner", "repo", 42, MergePullRequestRequest{
+		MergeMethod: MergeMethodSquash,
+		CommitTitle: "Add feature (#42)",
+		SHA:         "abc123",
+	})
+	require.NoError(t, err)
+	assert.True(t, result.Merged)
+	assert.Equal(t, "merged1", result.SHA)
+}
+
+func TestMergePullRequest_Refused(t *testing.T) {
+	for _, status := range []int{http.StatusMethodNotAllowed, http.StatusConflict} {
+		client := newMergeTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Head branch was modified"})
+		})
+
+		_, err := client.MergePullRequest(context.Background(), "owner", "repo", 42, MergePullRequestRequest{MergeMethod: MergeMethodMerge})
+		var mergeErr *MergeError
+		require.True(t, errors.As(err, &mergeErr), "status %d", status)
+		assert.Equal(t, status, mergeErr.StatusCode)
+		assert.Equal(t, "Head branch was modified", mergeErr.Message)
+	}
+}
+
+func TestEnableAutoMerge(t *testing.T) {
+	client := newMergeTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/graphql", r.URL.Path)
+		var body struct {
+			Query     string         `json:"query"`
+			Variables map[string]any `json:"variables"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Contains(t, body.Query, "enablePullRequestAutoMerge")
+		assert.Equal(t, "PR_node", body.Variables["id"])
+		assert.Equal(t, "REBASE", body.Variables["method"])
+		w.Write([]byte(`{"data":{"enablePullRequestAutoMerge":{"pullRequest":{"id":"PR_node"}}}}`))
+	})
+
+	require.NoError(t, client.EnableAutoMerge(context.Background(), "PR_node", MergeMethodRebase))
+}
+
+func TestEnqueuePullRequest_GraphQLError(t *testing.T) {
+	client := newMergeTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Contains(t, string(body), "enqueuePullRequest")
+		w.Write([]byte(`{"errors":[{"message":"Merge queue is not enabled"}]}`))
+	})
+
+	err := client.EnqueuePullRequest(context.Background(), "PR_node")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Merge queue is not enabled")
+}
+
+func TestDeleteBranch(t *testing.T) {
+	var paths []string
+	client := newMergeTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "DELETE", r.Method)
+		paths = append(paths, r.URL.EscapedPath())
+		switch r.URL.Path {
+		case "/repos/owner/repo/git/refs/heads/feature/foo":
+			w.WriteHeader(http.StatusNoContent)
+		case "/repos/owner/repo/git/refs/heads/gone":
+			w.WriteHeader(http.StatusUnprocessableEntity)
+		default:
+			w.WriteHeader(http.StatusForbidden)
+		}
+	})
+	ctx := context.Background()
+
+	require.NoError(t, client.DeleteBranch(ctx, "owner", "repo", "feature/foo"))
+	require.NoError(t, client.DeleteBranch(ctx, "owner", "repo", "gone"), "already-deleted branch is not an error")
+	require.Error(t, client.DeleteBranch(ctx, "owner", "repo", "protected"))
+	assert.Equal(t, "/repos/owner/repo/git/refs/heads/feature/foo", paths[0])
+}
+
+func TestMergeMethod_Valid(t *testing.T) {
+	assert.True(t, MergeMethodSquash.Valid())
+	assert.False(t, MergeMethod("fast-forward").Valid())
+}
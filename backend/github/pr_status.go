@@ -370,11 +370,22 @@ type PRFullDetails struct {
 	Additions    int       `json:"additions"`
 	Deletions    int       `json:"deletions"`
 	ChangedFiles int       `json:"changedFiles"`
+
+	// Merge readiness, used by the merge flow
+	NodeID         string `json:"nodeId"`         // GraphQL node ID (for auto-merge and merge queue mutations)
+	HeadSHA        string `json:"headSha"`        // head commit the merge is pinned to
+	Merged         bool   `json:"merged"`         // true if the PR has been merged
+	Mergeable      *bool  `json:"mergeable"`      // Can be null while GitHub computes it
+	MergeableState string `json:"mergeableState"` // "clean", "dirty", "blocked", "behind", "unknown", "unstable", "has_hooks"
 }
 
 // githubPRFull extends the API response to decode additional fields
 type githubPRFull struct {
 	Number         int    `json:"number"`
+	NodeID         string `json:"node_id"`
+	Merged         bool   `json:"merged"`
+	Mergeable      *bool  `json:"mergeable"`
+	MergeableState string `json:"mergeable_state"`
 	State          string `json:"state"`
 	Title          string `json:"title"`
 	HTMLURL        string `json:"html_url"`
@@ -462,6 +473,12 @@ func (c *Client) GetPRFullDetails(ctx context.Context, owner, repo string, prNum
 		Additions:    pr.Additions,
 		Deletions:    pr.Deletions,
 		ChangedFiles: pr.ChangedFiles,
+
+		NodeID:         pr.NodeID,
+		HeadSHA:        pr.Head.SHA,
+		Merged:         pr.Merged,
+		Mergeable:      pr.Mergeable,
+		MergeableState: pr.MergeableState,
 	}, nil
 }
 
@@ -369,3 +369,36 @@ func ParsePageParam(s string, defaultVal int) int {
 	}
 	return v
 }
+
+// IsCollaborator reports whether login is a collaborator on the repository
+// (including organization members with access through a team). The token
+// needs push access to the repository to ask.
+func (c *Client) IsCollaborator(ctx context.Context, owner, repo, login string) (bool, error) {
+	token, err := c.getValidToken(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	reqURL := fmt.Sprintf("%s/repos/%s/%s/collaborators/%s", c.apiURL, owner, repo, url.PathEscape(login))
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return false, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("checking collaborator: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNoContent:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	}
+	respBody, _ := io.ReadAll(resp.Body)
+	return false, fmt.Errorf("GitHub returned %d: %s", resp.StatusCode, respBody)
+}
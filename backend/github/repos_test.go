@@ -331,3 +331,28 @@ func TestListUserRepos_DefaultParams(t *testing.T) {
 	_, _, err := client.ListUserRepos(context.Background(), 0, 0, "", "")
 	require.NoError(t, err)
 }
+
+func TestIsCollaborator(t *testing.T) {
+	_, client := setupMockGitHubServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/owner/repo/collaborators/alice":
+			w.WriteHeader(http.StatusNoContent)
+		case "/repos/owner/repo/collaborators/mallory":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusForbidden)
+		}
+	})
+	ctx := context.Background()
+
+	ok, err := client.IsCollaborator(ctx, "owner", "repo", "alice")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = client.IsCollaborator(ctx, "owner", "repo", "mallory")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, err = client.IsCollaborator(ctx, "owner", "other", "alice")
+	assert.Error(t, err)
+}
@@ -0,0 +1,441 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ReviewThread is a PR review thread (an inline comment plus its replies).
+// Resolution state is only exposed through the GraphQL API, so threads are
+// fetched there rather than via the REST review comments endpoint.
+type ReviewThread struct {
+	ID         string                `json:"id"` // GraphQL node ID, used to resolve/unresolve
+	Path       string                `json:"path"`
+	Line       int                   `json:"line"` // Current line; falls back to the original line for outdated threads
+	IsResolved bool                  `json:"isResolved"`
+	IsOutdated bool                  `json:"isOutdated"`
+	ResolvedBy string                `json:"resolvedBy,omitempty"`
+	Comments   []ReviewThreadComment `json:"comments"`
+}
+
+// ReviewThreadComment is a single comment within a review thread.
+type ReviewThreadComment struct {
+	DatabaseID int64     `json:"databaseId"` // Matches the REST pull request review comment ID
+	Author     string    `json:"author"`
+	Body       string    `json:"body"`
+	URL        string    `json:"url"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// DraftReviewComment is an inline comment to include in a new PR review.
+type DraftReviewComment struct {
+	Path string `json:"path"`
+	Line int    `json:"line"`
+	Side string `json:"side,omitempty"` // "RIGHT" (default) or "LEFT"
+	Body string `json:"body"`
+}
+
+// PullRequestReview is the response from creating a PR review.
+type PullRequestReview struct {
+	ID      int64  `json:"id"`
+	State   string `json:"state"` // "PENDING" for reviews created without an event
+	HTMLURL string `json:"html_url"`
+}
+
+// PullRequestReviewComment is a REST pull request review comment.
+type PullRequestReviewComment struct {
+	ID      int64  `json:"id"`
+	Path    string `json:"path"`
+	Line    int    `json:"line"`
+	Body    string `json:"body"`
+	HTMLURL string `json:"html_url"`
+}
+
+// graphqlURL returns the GraphQL endpoint for the configured API URL.
+// GitHub Enterprise serves REST under /api/v3 and GraphQL under /api/graphql.
+func (c *Client) graphqlURL() string {
+	if strings.HasSuffix(c.apiURL, "/api/v3") {
+		return strings.TrimSuffix(c.apiURL, "/v3") + "/graphql"
+	}
+	return c.apiURL + "/graphql"
+}
+
+// graphql executes a GraphQL query and decodes the "data" field into out.
+func (c *Client) graphql(ctx context.Context, query string, variables map[string]any, out any) error {
+	token, err := c.getValidToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(map[string]any{"query": query, "variables": variables})
+	if err != nil {
+		return fmt.Errorf("marshaling GraphQL request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.graphqlURL(), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("executing GraphQL request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("GitHub returned %d: %s", resp.StatusCode, respBody)
+	}
+
+	var result struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("decoding GraphQL response: %w", err)
+	}
+	if len(result.Errors) > 0 {
+		return fmt.Errorf("GitHub GraphQL error: %s", result.Errors[0].Message)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(result.Data, out); err != nil {
+		return fmt.Errorf("decoding GraphQL data: %w", err)
+	}
+	return nil
+}
+
+const reviewThreadsQuery = `query($owner: String!, $repo: String!, $number: Int!, $cursor: String) {
+  repository(owner: $owner, name: $repo) {
+    pullRequest(number: $number) {
+      reviewThreads(first: 50, after: $cursor) {
+        pageInfo { hasNextPage endCursor }
+        nodes {
+          id
+          path
+          line
+          originalLine
+          isResolved
+          isOutdated
+          resolvedBy { login }
+          comments(first: 50) {
+            nodes { databaseId body url createdAt author { login } }
+          }
+        }
+      }
+    }
+  }
+}`
+
+type githubReviewThreadsData struct {
+	Repository struct {
+		PullRequest *struct {
+			ReviewThreads struct {
+				PageInfo struct {
+					HasNextPage bool   `json:"hasNextPage"`
+					EndCursor   string `json:"endCursor"`
+				} `json:"pageInfo"`
+				Nodes []struct {
+					ID           string `json:"id"`
+					Path         string `json:"path"`
+					Line         *int   `json:"line"`
+					OriginalLine *int   `json:"originalLine"`
+					IsResolved   bool   `json:"isResolved"`
+					IsOutdated   bool   `json:"isOutdated"`
+					ResolvedBy   *struct {
+						Login string `json:"login"`
+					} `json:"resolvedBy"`
+					Comments struct {
+						Nodes []struct {
+							DatabaseID int64     `json:"databaseId"`
+							Body       string    `json:"body"`
+							URL        string    `json:"url"`
+							CreatedAt  time.Time `json:"createdAt"`
+							Author     *struct {
+								Login string `json:"login"`
+							} `json:"author"`
+						} `json:"nodes"`
+					} `json:"comments"`
+				} `json:"nodes"`
+			} `json:"reviewThreads"`
+		} `json:"pullRequest"`
+	} `json:"repository"`
+}
+
+// maxReviewThreadPages bounds pagination so a pathological PR can't stall the watcher.
+const maxReviewThreadPages = 10
+
+// ListPRReviewThreads returns all review threads on a pull request, including
+// their resolution state and comments.
+func (c *Client) ListPRReviewThreads(ctx context.Context, owner, repo string, prNumber int) ([]ReviewThread, error) {
+	var threads []ReviewThread
+	var cursor *string
+
+	for page := 0; page < maxReviewThreadPages; page++ {
+		var data githubReviewThreadsData
+		vars := map[string]any{"owner": owner, "repo": repo, "number": prNumber, "cursor": cursor}
+		if err := c.graphql(ctx, reviewThreadsQuery, vars, &data); err != nil {
+			return nil, fmt.Errorf("listing review threads: %w", err)
+		}
+		pr := data.Repository.PullRequest
+		if pr == nil {
+			return nil, fmt.Errorf("PR #%d not found in %s/%s", prNumber, owner, repo)
+		}
+
+		for _, n := range pr.ReviewThreads.Nodes {
+			t := ReviewThread{
+				ID:         n.ID,
+				Path:       n.Path,
+				IsResolved: n.IsResolved,
+				IsOutdated: n.IsOutdated,
+			}
+			if n.Line != nil {
+				t.Line = *n.Line
+			} else if n.OriginalLine != nil {
+				t.Line = *n.OriginalLine
+			}
+			if n.ResolvedBy != nil {
+				t.ResolvedBy = n.ResolvedBy.Login
+			}
+			for _, cm := range n.Comments.Nodes {
+				author := "ghost" // GitHub's placeholder for deleted accounts
+				if cm.Author != nil {
+					author = cm.Author.Login
+				}
+				t.Comments = append(t.Comments, ReviewThreadComment{
+					DatabaseID: cm.DatabaseID,
+					Author:     author,
+					Body:       cm.Body,
+					URL:        cm.URL,
+					CreatedAt:  cm.CreatedAt,
+				})
+			}
+			threads = append(threads, t)
+		}
+
+		if !pr.ReviewThreads.PageInfo.HasNextPage {
+			break
+		}
+		next := pr.ReviewThreads.PageInfo.EndCursor
+		cursor = &next
+	}
+
+	return threads, nil
+}
+
+// ResolveReviewThread marks a PR review thread as resolved.
+func (c *Client) ResolveReviewThread(ctx context.Context, threadID string) error {
+	const mutation = `mutation($id: ID!) { resolveReviewThread(input: {threadId: $id}) { thread { id isResolved } } }`
+	if err := c.graphql(ctx, mutation, map[string]any{"id": threadID}, nil); err != nil {
+		return fmt.Errorf("resolving review thread: %w", err)
+	}
+	return nil
+}
+
+// UnresolveReviewThread marks a PR review thread as unresolved.
+func (c *Client) UnresolveReviewThread(ctx context.Context, threadID string) error {
+	const mutation = `mutation($id: ID!) { unresolveReviewThread(input: {threadId: $id}) { thread { id isResolved } } }`
+	if err := c.graphql(ctx, mutation, map[string]any{"id": threadID}, nil); err != nil {
+		return fmt.Errorf("unresolving review thread: %w", err)
+	}
+	return nil
+}
+
+// CreatePendingReview creates a PR review containing the given inline comments
+// without submitting it. The review stays in the PENDING state so the user can
+// edit and submit it from GitHub.
+func (c *Client) CreatePendingReview(ctx context.Context, owner, repo string, prNumber int, body string, comments []DraftReviewComment) (*PullRequestReview, error) {
+	token, err := c.getValidToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range comments {
+		if comments[i].Side == "" {
+			comments[i].Side = "RIGHT"
+		}
+	}
+	// Omitting "event" is what keeps the review pending.
+	payload, err := json.Marshal(map[string]any{"body": body, "comments": comments})
+	if err != nil {
+		return nil, fmt.Errorf("marshaling review: %w", err)
+	}
+
+	reviewURL := fmt.Sprintf("%s/repos/%s/%s/pulls/%d/reviews", c.apiURL, owner, repo, prNumber)
+	req, err := http.NewRequestWithContext(ctx, "POST", reviewURL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("creating review: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("GitHub returned %d: %s", resp.StatusCode, respBody)
+	}
+
+	var result PullRequestReview
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+	return &result, nil
+}
+
+// ListReviewComments returns the inline comments belonging to a single PR review.
+func (c *Client) ListReviewComments(ctx context.Context, owner, repo string, prNumber int, reviewID int64) ([]PullRequestReviewComment, error) {
+	token, err := c.getValidToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	commentsURL := fmt.Sprintf("%s/repos/%s/%s/pulls/%d/reviews/%d/comments?per_page=100", c.apiURL, owner, repo, prNumber, reviewID)
+	req, err := http.NewRequestWithContext(ctx, "GET", commentsURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("listing review comments: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("GitHub returned %d: %s", resp.StatusCode, respBody)
+	}
+
+	var result []PullRequestReviewComment
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+	return result, nil
+}
+
+// PRReview is a submitted pull request review.
+type PRReview struct {
+	ID          int64     `json:"id"`
+	Author      string    `json:"author"`
+	State       string    `json:"state"` // "APPROVED", "CHANGES_REQUESTED", "COMMENTED", "DISMISSED", "PENDING"
+	Body        string    `json:"body"`
+	HTMLURL     string    `json:"htmlUrl"`
+	SubmittedAt time.Time `json:"submittedAt"`
+}
+
+// ListPRReviews returns the reviews submitted on a pull request, oldest first.
+func (c *Client) ListPRReviews(ctx context.Context, owner, repo string, prNumber int) ([]PRReview, error) {
+	token, err := c.getValidToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reviewsURL := fmt.Sprintf("%s/repos/%s/%s/pulls/%d/reviews?per_page=100", c.apiURL, owner, repo, prNumber)
+	req, err := http.NewRequestWithContext(ctx, "GET", reviewsURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("listing reviews: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("GitHub returned %d: %s", resp.StatusCode, respBody)
+	}
+
+	var raw []struct {
+		ID   int64 `json:"id"`
+		User *struct {
+			Login string `json:"login"`
+		} `json:"user"`
+		State       string    `json:"state"`
+		Body        string    `json:"body"`
+		HTMLURL     string    `json:"html_url"`
+		SubmittedAt time.Time `json:"submitted_at"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+
+	reviews := make([]PRReview, 0, len(raw))
+	for _, r := range raw {
+		author := "ghost"
+		if r.User != nil {
+			author = r.User.Login
+		}
+		reviews = append(reviews, PRReview{
+			ID:          r.ID,
+			Author:      author,
+			State:       r.State,
+			Body:        r.Body,
+			HTMLURL:     r.HTMLURL,
+			SubmittedAt: r.SubmittedAt,
+		})
+	}
+	return reviews, nil
+}
+
+// ReplyToReviewComment posts a reply in the thread of a pull request review comment.
+func (c *Client) ReplyToReviewComment(ctx context.Context, owner, repo string, prNumber int, commentID int64, body string) (*PullRequestReviewComment, error) {
+	token, err := c.getValidToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := json.Marshal(map[string]string{"body": body})
+	if err != nil {
+		return nil, fmt.Errorf("marshaling reply: %w", err)
+	}
+
+	replyURL := fmt.Sprintf("%s/repos/%s/%s/pulls/%d/comments/%d/replies", c.apiURL, owner, repo, prNumber, commentID)
+	req, err := http.NewRequestWithContext(ctx, "POST", replyURL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("replying to review comment: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("GitHub returned %d: %s", resp.StatusCode, respBody)
+	}
+
+	var result PullRequestReviewComment
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+	return &result, nil
+}
@@ -0,0 +1,188 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_ListPRReviewThreads_Paginates(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/graphql", r.URL.Path)
+		require.Equal(t, "Bearer test_token", r.Header.Get("Authorization"))
+
+		var req struct {
+			Variables map[string]any `json:"variables"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "testowner", req.Variables["owner"])
+		assert.Equal(t, float64(7), req.Variables["number"])
+
+		calls++
+		if calls == 1 {
+			assert.Nil(t, req.Variables["cursor"])
+			w.Write([]byte(`{"data":{"repository":{"pullRequest":{"reviewThreads":{
+				"pageInfo":{"hasNextPage":true,"endCursor":"c1"},
+				"nodes":[{"id":"T1","path":"a.go","line":3,"isResolved":false,"isOutdated":false,"resolvedBy":null,
+					"comments":{"nodes":[
+						{"databaseId":11,"body":"root","url":"https://x/11","createdAt":"2024-01-01T00:00:00Z","author":{"login":"alice"}},
+						{"databaseId":12,"body":"reply","url":"https://x/12","createdAt":"2024-01-01T01:00:00Z","author":null}
+					]}}]}}}}}`))
+			return
+		}
+		assert.Equal(t, "c1", req.Variables["cursor"])
+		w.Write([]byte(`{"data":{"repository":{"pullRequest":{"reviewThreads":{
+			"pageInfo":{"hasNextPage":false,"endCursor":""},
+			"nodes":[{"id":"T2","path":"b.go","line":null,"originalLine":9,"isResolved":true,"isOutdated":true,"resolvedBy":{"login":"bob"},
+				"comments":{"nodes":[{"databaseId":21,"body":"old","url":"https://x/21","createdAt":"2024-01-02T00:00:00Z","author":{"login":"bob"}}]}}]}}}}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("", "")
+	client.apiURL = server.URL
+	client.SetToken("test_token")
+
+	threads, err := client.ListPRReviewThreads(context.Background(), "testowner", "testrepo", 7)
+	require.NoError(t, err)
+	require.Len(t, threads, 2)
+	assert.Equal(t, 2, calls)
+
+	assert.Equal(t, "T1", threads[0].ID)
+	assert.Equal(t, 3, threads[0].Line)
+	require.Len(t, threads[0].Comments, 2)
+	assert.Equal(t, int64(11), threads[0].Comments[0].DatabaseID)
+	assert.Equal(t, "alice", threads[0].Comments[0].Author)
+	assert.Equal(t, "ghost", threads[0].Comments[1].Author)
+
+	assert.Equal(t, 9, threads[1].Line, "outdated thread falls back to original line")
+	assert.True(t, threads[1].IsResolved)
+	assert.Equal(t, "bob", threads[1].ResolvedBy)
+}
+
+func TestClient_ListPRReviewThreads_GraphQLError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":null,"errors":[{"message":"Could not resolve to a Repository"}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("", "")
+	client.apiURL = server.URL
+	client.SetToken("test_token")
+
+	_, err := client.ListPRReviewThreads(context.Background(), "o", "r", 1)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Could not resolve to a Repository")
+}
+
+func TestClient_GraphqlURL_Enterprise(t *testing.T) {
+	client := NewClient("", "")
+	client.apiURL = "https://ghe.example.com/api/v3"
+	assert.Equal(t, "https://ghe.example.com/api/graphql", client.graphqlURL())
+
+	client.apiURL = "https://api.github.com"
+	assert.Equal(t, "https://api.github.com/graphql", client.graphqlURL())
+}
+
+func TestClient_ResolveReviewThread(t *testing.T) {
+	var gotQuery string
+	var gotID any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Query     string         `json:"query"`
+			Variables map[string]any `json:"variables"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		gotQuery = req.Query
+		gotID = req.Variables["id"]
+		w.Write([]byte(`{"data":{"resolveReviewThread":{"thread":{"id":"T1","isResolved":true}}}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("", "")
+	client.apiURL = server.URL
+	client.SetToken("test_token")
+
+	require.NoError(t, client.ResolveReviewThread(context.Background(), "T1"))
+	assert.Contains(t, gotQuery, "resolveReviewThread")
+	assert.Equal(t, "T1", gotID)
+}
+
+func TestClient_CreatePendingReview_OmitsEvent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "POST", r.Method)
+		require.Equal(t, "/repos/testowner/testrepo/pulls/5/reviews", r.URL.Path)
+
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		_, hasEvent := body["event"]
+		assert.False(t, hasEvent, "pending reviews must not set an event")
+		comments := body["comments"].([]any)
+		require.Len(t, comments, 1)
+		assert.Equal(t, "RIGHT", comments[0].(map[string]any)["side"])
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id":99,"state":"PENDING","html_url":"https://github.com/testowner/testrepo/pull/5#pullrequestreview-99"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("", "")
+	client.apiURL = server.URL
+	client.SetToken("test_token")
+
+	review, err := client.CreatePendingReview(context.Background(), "testowner", "testrepo", 5, "",
+		[]DraftReviewComment{{Path: "a.go", Line: 3, Body: "fix"}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(99), review.ID)
+	assert.Equal(t, "PENDING", review.State)
+}
+
+func TestClient_ListPRReviews(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/repos/testowner/testrepo/pulls/5/reviews", r.URL.Path)
+		w.Write([]byte(`[
+			{"id":1,"user":{"login":"alice"},"state":"CHANGES_REQUESTED","body":"Needs tests","html_url":"https://x/1","submitted_at":"2024-01-01T00:00:00Z"},
+			{"id":2,"user":null,"state":"COMMENTED","body":"","html_url":"https://x/2","submitted_at":"2024-01-02T00:00:00Z"}
+		]`))
+	}))
+	defer server.Close()
+
+	client := NewClient("", "")
+	client.apiURL = server.URL
+	client.SetToken("test_token")
+
+	reviews, err := client.ListPRReviews(context.Background(), "testowner", "testrepo", 5)
+	require.NoError(t, err)
+	require.Len(t, reviews, 2)
+	assert.Equal(t, int64(1), reviews[0].ID)
+	assert.Equal(t, "alice", reviews[0].Author)
+	assert.Equal(t, "CHANGES_REQUESTED", reviews[0].State)
+	assert.Equal(t, "Needs tests", reviews[0].Body)
+	assert.Equal(t, "ghost", reviews[1].Author)
+}
+
+func TestClient_ReplyToReviewComment(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "POST", r.Method)
+		require.Equal(t, "/repos/testowner/testrepo/pulls/5/comments/77/replies", r.URL.Path)
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "Fixed in abc123", body["body"])
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":78,"body":"Fixed in abc123","html_url":"https://x/78"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("", "")
+	client.apiURL = server.URL
+	client.SetToken("test_token")
+
+	reply, err := client.ReplyToReviewComment(context.Background(), "testowner", "testrepo", 5, 77, "Fixed in abc123")
+	require.NoError(t, err)
+	assert.Equal(t, int64(78), reply.ID)
+}
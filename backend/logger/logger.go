@@ -23,6 +23,7 @@ var (
 	BranchWatcher *log.Logger
 	PRWatcher     *log.Logger
 	StatsWatcher  *log.Logger
+	Stack         *log.Logger
 
 	// Storage
 	Store   *log.Logger
@@ -41,6 +42,7 @@ var (
 	// Agent management
 	Manager *log.Logger
 	Process *log.Logger
+	AutoFix *log.Logger
 
 	// Relay
 	Relay *log.Logger
@@ -61,6 +63,7 @@ func init() {
 	BranchWatcher = corelogger.New("branch-watcher", corelogger.ColorWatch)
 	PRWatcher = corelogger.New("pr-watcher", corelogger.ColorWatch)
 	StatsWatcher = corelogger.New("stats-watcher", corelogger.ColorWatch)
+	Stack = corelogger.New("stack", corelogger.ColorWatch)
 
 	Store = corelogger.New("store", corelogger.ColorStorage)
 	SQLite = corelogger.New("sqlite", corelogger.ColorStorage)
@@ -75,6 +78,7 @@ func init() {
 
 	Manager = corelogger.New("manager", corelogger.ColorAgent)
 	Process = corelogger.New("process", corelogger.ColorAgent)
+	AutoFix = corelogger.New("autofix", corelogger.ColorAgent)
 
 	Relay = corelogger.New("relay", corelogger.ColorHTTP)
 
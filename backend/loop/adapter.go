@@ -6,6 +6,7 @@
 package loop
 
 import (
+	"context"
 	"encoding/json"
 	"sync"
 
@@ -53,6 +54,16 @@ func (r *Runner) SetModel(model string) error            { return r.core.SetMode
 func (r *Runner) SetMaxThinkingTokens(tokens int) error  { return r.core.SetMaxThinkingTokens(tokens) }
 func (r *Runner) SetEffort(effort string) error           { return r.core.SetEffort(effort) }
 
+// --- Context management ---
+
+func (r *Runner) Compact(ctx context.Context, instructions string) (*agent.CompactReport, error) {
+	return r.core.Compact(ctx, instructions)
+}
+
+func (r *Runner) ContextBreakdown(ctx context.Context) (*core.ContextBreakdown, error) {
+	return r.core.ContextBreakdown(ctx)
+}
+
 // --- Task management ---
 
 func (r *Runner) StopTask(taskId string) error { return r.core.StopTask(taskId) }
@@ -157,7 +157,8 @@ func (t *resolveReviewCommentTool) InputSchema() json.RawMessage {
 		"type": "object",
 		"properties": {
 			"commentId": {"type": "string", "description": "The ID of the review comment to resolve"},
-			"resolutionType": {"type": "string", "enum": ["fixed", "ignored"], "description": "Resolution type"}
+			"resolutionType": {"type": "string", "enum": ["fixed", "ignored"], "description": "Resolution type"},
+			"reply": {"type": "string", "description": "Reply to post on the linked GitHub review thread (e.g. what was changed, or why it was ignored)"}
 		},
 		"required": ["commentId"]
 	}`)
@@ -167,6 +168,7 @@ func (t *resolveReviewCommentTool) Execute(ctx context.Context, input json.RawMe
 	var params struct {
 		CommentID      string `json:"commentId"`
 		ResolutionType string `json:"resolutionType"`
+		Reply          string `json:"reply"`
 	}
 	if err := json.Unmarshal(input, &params); err != nil {
 		return tool.ErrorResult(fmt.Sprintf("invalid input: %v", err)), nil
@@ -189,6 +191,21 @@ func (t *resolveReviewCommentTool) Execute(ctx context.Context, input json.RawMe
 		return tool.ErrorResult(fmt.Sprintf("failed to resolve comment: %v", err)), nil
 	}
 
+	// Reply on and resolve the linked GitHub review thread too (best-effort).
+	if t.svc.PRWatcher != nil {
+		if comment, err := t.svc.Store.GetReviewComment(ctx, params.CommentID); err == nil && comment != nil && comment.GitHubThreadID != "" {
+			if params.Reply != "" {
+				if err := t.svc.PRWatcher.ReplyToReviewComment(ctx, comment, params.Reply); err != nil {
+					return &tool.Result{Content: fmt.Sprintf("Comment %s resolved as %s locally, but replying on the GitHub thread failed: %v", params.CommentID, params.ResolutionType, err)}, nil
+				}
+			}
+			if err := t.svc.PRWatcher.MirrorCommentResolution(ctx, comment); err != nil {
+				return &tool.Result{Content: fmt.Sprintf("Comment %s resolved as %s locally, but resolving the GitHub thread failed: %v", params.CommentID, params.ResolutionType, err)}, nil
+			}
+			return &tool.Result{Content: fmt.Sprintf("Comment %s resolved as %s (GitHub thread resolved).", params.CommentID, params.ResolutionType)}, nil
+		}
+	}
+
 	return &tool.Result{Content: fmt.Sprintf("Comment %s resolved as %s.", params.CommentID, params.ResolutionType)}, nil
 }
 
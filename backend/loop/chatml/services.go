@@ -52,6 +52,8 @@ type PRWatcher interface {
 	RegisterPRFromAgent(sessionID string, prNumber int, prURL string)
 	ForceCheckSession(sessionID string)
 	UnlinkPR(sessionID string)
+	MirrorCommentResolution(ctx context.Context, comment *models.ReviewComment) error
+	ReplyToReviewComment(ctx context.Context, comment *models.ReviewComment, body string) error
 }
 
 // ToolContext holds per-session immutable context available to all ChatML tools.
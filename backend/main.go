@@ -94,6 +94,17 @@ func main() {
 		defer os.Remove(portFile)
 	}
 
+	// Publish the auth token next to it (owner-only) so terminal clients on
+	// this machine can connect without being handed the token.
+	if token := os.Getenv("CHATML_AUTH_TOKEN"); token != "" {
+		tokenFile := filepath.Join(appdir.StateDir(), "backend.token")
+		if err := os.WriteFile(tokenFile, []byte(token), 0600); err != nil {
+			logger.Main.Warnf("Failed to write token file: %v", err)
+		} else {
+			defer os.Remove(tokenFile)
+		}
+	}
+
 	// Initialize and wire all subsystems
 	a, err := app.New(ctx, actualPort)
 	if err != nil {
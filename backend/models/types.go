@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	coregit "github.com/chatml/chatml-core/git"
@@ -65,6 +66,7 @@ type Session struct {
 	ArchiveSummaryStatus string `json:"archiveSummaryStatus,omitempty"` // "", "generating", "completed", "failed"
 	AutoNamed            bool   `json:"autoNamed,omitempty"`            // True if session was auto-renamed based on context
 	ScheduledTaskID      string `json:"scheduledTaskId,omitempty"`      // FK to scheduled_tasks if created by scheduler
+	ParentSessionID      string `json:"parentSessionId,omitempty"`      // Stacked sessions: the session whose branch this one is based on
 	CreatedAt        time.Time     `json:"createdAt"`
 	UpdatedAt        time.Time     `json:"updatedAt"`
 }
@@ -110,6 +112,18 @@ func (s *SessionWithWorkspace) EffectiveTargetBranch() string {
 	return s.EffectiveRemote() + "/" + s.DefaultBranch()
 }
 
+// SyncBaseRef returns the ref branch sync compares against and rebases onto.
+// Stacked sessions use the parent session's local branch, which is current
+// even before the parent has been pushed; other sessions use the remote
+// target branch.
+func (s *SessionWithWorkspace) SyncBaseRef() string {
+	target := s.EffectiveTargetBranch()
+	if s.IsStacked() {
+		return strings.TrimPrefix(target, s.EffectiveRemote()+"/")
+	}
+	return target
+}
+
 type Agent struct {
 	ID        string    `json:"id"`
 	RepoID    string    `json:"repoId"`
@@ -305,6 +319,11 @@ func (s *Session) IsBaseSession() bool {
 	return s.SessionType == SessionTypeBase
 }
 
+// IsStacked returns true if this session is based on another session's branch.
+func (s *Session) IsStacked() bool {
+	return s.ParentSessionID != ""
+}
+
 // IsMainRepoSession returns true if this session operates on the main repo directory
 // (not a worktree). Only base sessions have this property.
 // Deprecated: Identical to IsBaseSession. Prefer IsBaseSession for new code.
@@ -447,12 +466,25 @@ type ReviewComment struct {
 	ResolvedAt     *time.Time `json:"resolvedAt,omitempty"`
 	ResolvedBy     string     `json:"resolvedBy,omitempty"`
 	ResolutionType string     `json:"resolutionType,omitempty"` // "fixed" or "ignored"
+
+	// GitHub linkage. Set for comments imported from a PR review thread and for
+	// local comments that have been published to the PR as a review.
+	GitHubCommentID int64  `json:"githubCommentId,omitempty"` // Database ID of the thread's root PR review comment
+	GitHubThreadID  string `json:"githubThreadId,omitempty"`  // GraphQL node ID of the PR review thread
+	GitHubURL       string `json:"githubUrl,omitempty"`
+	GitHubResolved  bool   `json:"-"` // Last thread resolution state observed on GitHub
+}
+
+// IsLinkedToGitHub returns true if the comment is mirrored to a GitHub PR review comment.
+func (c *ReviewComment) IsLinkedToGitHub() bool {
+	return c.GitHubCommentID != 0 || c.GitHubThreadID != ""
 }
 
 // ReviewCommentSource constants
 const (
 	CommentSourceClaude = "claude"
 	CommentSourceUser   = "user"
+	CommentSourceGitHub = "github" // Imported from a GitHub PR review thread
 )
 
 // ReviewCommentSeverity constants
@@ -544,6 +576,13 @@ type McpServerConfig struct {
 	Enabled bool              `json:"enabled"`
 }
 
+// SecretRedactionSettings controls scrubbing of secrets from tool output for a
+// workspace. Redaction is on unless explicitly disabled.
+type SecretRedactionSettings struct {
+	Enabled   bool     `json:"enabled"`
+	Allowlist []string `json:"allowlist"` // Values or "*" globs to keep; "rule:<kind>" disables a detector
+}
+
 // BranchSyncResult represents the result of a branch sync operation
 type BranchSyncResult struct {
 	Success       bool     `json:"success"`
@@ -571,6 +610,101 @@ type Checkpoint struct {
 	Timestamp      time.Time `json:"timestamp"`
 }
 
+// ConversationTask is one task of a conversation's agent task list
+// (TaskCreate/TaskUpdate tools), as last reported by the agent.
+type ConversationTask struct {
+	ID          string    `json:"id"`
+	Subject     string    `json:"subject"`
+	Description string    `json:"description,omitempty"`
+	ActiveForm  string    `json:"activeForm,omitempty"`
+	Status      string    `json:"status"` // pending, in_progress, completed, failed, stopped
+	Owner       string    `json:"owner,omitempty"`
+	Blocks      []string  `json:"blocks"`
+	BlockedBy   []string  `json:"blockedBy"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// PermissionAuditEntry is a durable record of one permission decision for a
+// tool call. Entries outlive their session and conversation.
+type PermissionAuditEntry struct {
+	ID             int64     `json:"id"`
+	WorkspaceID    string    `json:"workspaceId"`
+	SessionID      string    `json:"sessionId"`
+	ConversationID string    `json:"conversationId"`
+	ToolUseID      string    `json:"toolUseId,omitempty"`
+	ToolName       string    `json:"toolName"`
+	Specifier      string    `json:"specifier,omitempty"`
+	Decision       string    `json:"decision"`                 // allow, deny
+	DecidedBy      string    `json:"decidedBy"`                // policy, rule:<source>, session, mode, user, hook:<event>, ...
+	ApprovalAction string    `json:"approvalAction,omitempty"` // allow_once, allow_session, ... when decided by the user
+	Reason         string    `json:"reason,omitempty"`
+	Mode           string    `json:"mode,omitempty"` // Permission mode at the time of the decision
+	Timestamp      time.Time `json:"timestamp"`
+}
+
+// PRAutomationPolicy is a session's opt-in policy for agent-driven follow-up
+// on its pull request, plus the bookkeeping needed to bound it.
+type PRAutomationPolicy struct {
+	SessionID       string  `json:"sessionId"`
+	AddressFeedback bool    `json:"addressFeedback"` // Start a task conversation when reviewers leave feedback
+	MaxIterations   int     `json:"maxIterations"`   // Max feedback rounds before the loop stops
+	BudgetUSD       float64 `json:"budgetUsd"`       // Total spend cap across all automated conversations
+
+	Iterations           int      `json:"iterations"`                     // Feedback rounds started so far
+	SpentUSD             float64  `json:"spentUsd"`                       // Cost of finished automated conversations
+	ActiveConversationID string   `json:"activeConversationId,omitempty"` // Automated conversation currently running
+	HandledFeedback      []string `json:"-"`                              // Keys of reviews/threads already handed to the agent
+	StoppedReason        string   `json:"stoppedReason,omitempty"`        // Set when a limit stopped the loop
+
+	FixCI         bool `json:"fixCi"`         // Start a task conversation when CI fails on the PR
+	MaxCIAttempts int  `json:"maxCiAttempts"` // Max CI repair attempts before the repair loop stops
+
+	CIAttempts       int      `json:"ciAttempts"`                // CI repair attempts started so far
+	CILastSHA        string   `json:"ciLastSha,omitempty"`       // Head SHA whose failure was last handed to the agent
+	CILastFailedJobs []string `json:"ciLastFailedJobs"`          // Job names that failed on CILastSHA
+	CIRepeatCount    int      `json:"ciRepeatCount"`             // Consecutive attempts after which a previously failing job failed again
+	CIStoppedReason  string   `json:"ciStoppedReason,omitempty"` // Set when a limit stopped the CI repair loop
+
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// PR automation defaults and stop reasons
+const (
+	DefaultPRAutomationMaxIterations = 3
+	DefaultPRAutomationBudgetUSD     = 5.0
+
+	DefaultPRAutomationMaxCIAttempts = 3
+
+	// PRAutomationMaxCIRepeats is how many repair attempts a job may keep
+	// failing through before the CI repair loop gives up on it.
+	PRAutomationMaxCIRepeats = 2
+
+	PRAutomationStoppedMaxIterations = "max_iterations"
+	PRAutomationStoppedBudget        = "budget"
+	PRAutomationStoppedMaxCIAttempts = "max_ci_attempts"
+	PRAutomationStoppedCIRepeating   = "same_job_failing"
+)
+
+// FeedbackActive reports whether the feedback loop is enabled and not stopped.
+func (p *PRAutomationPolicy) FeedbackActive() bool {
+	return p.AddressFeedback && p.StoppedReason == ""
+}
+
+// CIRepairActive reports whether the CI repair loop is enabled and not stopped.
+func (p *PRAutomationPolicy) CIRepairActive() bool {
+	return p.FixCI && p.CIStoppedReason == ""
+}
+
+// HasHandled reports whether a feedback key was already handed to the agent.
+func (p *PRAutomationPolicy) HasHandled(key string) bool {
+	for _, k := range p.HandledFeedback {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
 // ScheduledTask represents a recurring task template that spawns sessions on a schedule
 type ScheduledTask struct {
 	ID                 string     `json:"id"`
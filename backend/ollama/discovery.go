@@ -0,0 +1,229 @@
+package ollama
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	ollamaprov "github.com/chatml/chatml-core/provider/ollama"
+)
+
+// DiscoveredModel is an installed model with the capabilities probed from
+// Ollama's show API. Catalog models keep their catalog ID; any other model
+// is addressed as "ollama/<name>".
+type DiscoveredModel struct {
+	ID               string    `json:"id"`
+	Name             string    `json:"name"`
+	Digest           string    `json:"digest"`
+	Size             int64     `json:"size"`
+	ModifiedAt       time.Time `json:"modified_at"`
+	Family           string    `json:"family,omitempty"`
+	ParameterSize    string    `json:"parameter_size,omitempty"`
+	Quantization     string    `json:"quantization,omitempty"`
+	ContextLength    int       `json:"context_length"`
+	SupportsTools    bool      `json:"supports_tools"`
+	SupportsVision   bool      `json:"supports_vision"`
+	SupportsThinking bool      `json:"supports_thinking"`
+	Catalog          bool      `json:"catalog"`
+}
+
+func (m *Manager) discoveredFile() string { return filepath.Join(m.dataDir, "models.json") }
+
+// DiscoveredModels returns the models found by the last discovery, sorted by
+// name. The list survives restarts, so it is available before Ollama runs.
+func (m *Manager) DiscoveredModels() []DiscoveredModel {
+	m.discoveryMu.Lock()
+	defer m.discoveryMu.Unlock()
+	out := make([]DiscoveredModel, 0, len(m.discovered))
+	for _, d := range m.discovered {
+		out = append(out, d)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// DiscoverModels lists the installed models and probes each one whose digest
+// changed since the last discovery. The results are persisted and registered
+// with the provider catalog so the native loop can run any of them. Models
+// that fail to probe are logged and left out.
+func (m *Manager) DiscoverModels(ctx context.Context) ([]DiscoveredModel, error) {
+	installed, err := m.ListModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	m.discoveryMu.Lock()
+	defer m.discoveryMu.Unlock()
+
+	found := make([]DiscoveredModel, 0, len(installed))
+	for _, info := range installed {
+		d, ok := m.discovered[info.Name]
+		if !ok || info.Digest == "" || d.Digest != info.Digest {
+			if d, err = m.ProbeModel(ctx, info.Name); err != nil {
+				log.Printf("ollama: probe %s: %v", info.Name, err)
+				continue
+			}
+		}
+		d.Digest, d.Size, d.ModifiedAt = info.Digest, info.Size, info.ModifiedAt
+		found = append(found, d)
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].Name < found[j].Name })
+
+	m.discovered = make(map[string]DiscoveredModel, len(found))
+	for _, d := range found {
+		m.discovered[d.Name] = d
+	}
+	if err := m.saveDiscovered(found); err != nil {
+		log.Printf("ollama: warning: failed to save discovered models: %v", err)
+	}
+	registerDiscovered(found)
+	return found, nil
+}
+
+// refreshDiscovered runs a discovery in the background after the server
+// starts or a model is pulled.
+func (m *Manager) refreshDiscovered() {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	if _, err := m.DiscoverModels(ctx); err != nil {
+		log.Printf("ollama: model discovery failed: %v", err)
+	}
+}
+
+// showResponse is the subset of /api/show used for probing.
+type showResponse struct {
+	Template string `json:"template"`
+	Details  struct {
+		Family            string `json:"family"`
+		ParameterSize     string `json:"parameter_size"`
+		QuantizationLevel string `json:"quantization_level"`
+	} `json:"details"`
+	ModelInfo     map[string]any `json:"model_info"`
+	ProjectorInfo map[string]any `json:"projector_info"`
+	Capabilities  []string       `json:"capabilities"`
+}
+
+// ProbeModel asks Ollama's show API for a model's context length and its
+// tool-calling, vision and thinking support. Servers that predate the
+// capabilities list are probed from the chat template and model metadata.
+func (m *Manager) ProbeModel(ctx context.Context, name string) (DiscoveredModel, error) {
+	body, _ := json.Marshal(map[string]string{"model": name})
+	req, err := m.newRequest(ctx, http.MethodPost, "/api/show", bytes.NewReader(body))
+	if err != nil {
+		return DiscoveredModel{}, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return DiscoveredModel{}, fmt.Errorf("show model: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 16*1024))
+		return DiscoveredModel{}, fmt.Errorf("show model: HTTP %d", resp.StatusCode)
+	}
+
+	var show showResponse
+	if err := json.NewDecoder(resp.Body).Decode(&show); err != nil {
+		return DiscoveredModel{}, fmt.Errorf("decode response: %w", err)
+	}
+	return probeResult(name, show), nil
+}
+
+func probeResult(name string, show showResponse) DiscoveredModel {
+	d := DiscoveredModel{
+		ID:            ollamaprov.DiscoveredModelID(name),
+		Name:          name,
+		Family:        show.Details.Family,
+		ParameterSize: show.Details.ParameterSize,
+		Quantization:  show.Details.QuantizationLevel,
+	}
+	if def := ollamaprov.LookupByOllamaName(name); def != nil && !def.Discovered {
+		d.ID, d.Catalog = def.ID, true
+	}
+
+	for key, v := range show.ModelInfo {
+		if strings.HasSuffix(key, ".context_length") {
+			if n, ok := v.(float64); ok && int(n) > d.ContextLength {
+				d.ContextLength = int(n)
+			}
+		}
+		if strings.Contains(key, ".vision.") {
+			d.SupportsVision = true
+		}
+	}
+
+	if show.Capabilities != nil {
+		for _, c := range show.Capabilities {
+			switch c {
+			case "tools":
+				d.SupportsTools = true
+			case "vision":
+				d.SupportsVision = true
+			case "thinking":
+				d.SupportsThinking = true
+			}
+		}
+		return d
+	}
+	d.SupportsTools = strings.Contains(show.Template, ".Tools")
+	d.SupportsVision = d.SupportsVision || len(show.ProjectorInfo) > 0
+	return d
+}
+
+// loadDiscovered restores the last discovery from disk and registers it.
+func (m *Manager) loadDiscovered() {
+	data, err := os.ReadFile(m.discoveredFile())
+	if err != nil {
+		return
+	}
+	var models []DiscoveredModel
+	if err := json.Unmarshal(data, &models); err != nil {
+		log.Printf("ollama: ignoring invalid %s: %v", m.discoveredFile(), err)
+		return
+	}
+	m.discovered = make(map[string]DiscoveredModel, len(models))
+	for _, d := range models {
+		m.discovered[d.Name] = d
+	}
+	registerDiscovered(models)
+}
+
+func (m *Manager) saveDiscovered(models []DiscoveredModel) error {
+	if err := os.MkdirAll(m.dataDir, 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(models, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(m.discoveredFile(), data, 0644)
+}
+
+// registerDiscovered makes non-catalog models available to the provider.
+func registerDiscovered(models []DiscoveredModel) {
+	defs := make([]ollamaprov.LocalModelDef, 0, len(models))
+	for _, d := range models {
+		if d.Catalog {
+			continue
+		}
+		defs = append(defs, ollamaprov.LocalModelDef{
+			OllamaName:       d.Name,
+			Description:      strings.TrimSpace(d.Family + " " + d.ParameterSize),
+			ContextWindow:    d.ContextLength,
+			SupportsTools:    d.SupportsTools,
+			SupportsImages:   d.SupportsVision,
+			SupportsThinking: d.SupportsThinking,
+		})
+	}
+	ollamaprov.SetDiscoveredModels(defs)
+}
@@ -0,0 +1,134 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	ollamaprov "github.com/chatml/chatml-core/provider/ollama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newStubManager points a Manager at a stub Ollama server that reports the
+// given tags and answers /api/show from shows.
+func newStubManager(t *testing.T, tags []map[string]any, shows map[string]any) (*Manager, *atomic.Int32) {
+	t.Helper()
+	var probes atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/tags":
+			json.NewEncoder(w).Encode(map[string]any{"models": tags})
+		case "/api/show":
+			probes.Add(1)
+			var req struct{ Model string }
+			json.NewDecoder(r.Body).Decode(&req)
+			show, ok := shows[req.Model]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			json.NewEncoder(w).Encode(show)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { ollamaprov.SetDiscoveredModels(nil) })
+
+	m := NewManager(t.TempDir())
+	m.port = srv.Listener.Addr().(*net.TCPAddr).Port
+	return m, &probes
+}
+
+func TestDiscoverModels_ProbesCapabilities(t *testing.T) {
+	m, _ := newStubManager(t, []map[string]any{
+		{"name": "qwen2.5-coder:7b", "digest": "d1", "size": 100},
+		{"name": "llava:13b", "digest": "d2"},
+		{"name": "gemma4:27b", "digest": "d3"},
+		{"name": "broken:1b", "digest": "d4"},
+	}, map[string]any{
+		"qwen2.5-coder:7b": map[string]any{
+			"details":      map[string]any{"family": "qwen2", "parameter_size": "7.6B", "quantization_level": "Q4_K_M"},
+			"model_info":   map[string]any{"general.architecture": "qwen2", "qwen2.context_length": 32768},
+			"capabilities": []string{"completion", "tools"},
+		},
+		// Older servers: no capabilities list
+		"llava:13b": map[string]any{
+			"template":       "{{ .Prompt }}",
+			"model_info":     map[string]any{"llama.context_length": 4096},
+			"projector_info": map[string]any{"clip.has_vision_encoder": true},
+		},
+		"gemma4:27b": map[string]any{
+			"model_info":   map[string]any{"gemma4.context_length": 262144},
+			"capabilities": []string{"completion", "tools", "vision"},
+		},
+	})
+
+	models, err := m.DiscoverModels(context.Background())
+	require.NoError(t, err)
+	require.Len(t, models, 3, "models that fail to probe are skipped")
+
+	byName := map[string]DiscoveredModel{}
+	for _, d := range models {
+		byName[d.Name] = d
+	}
+
+	qwen := byName["qwen2.5-coder:7b"]
+	assert.Equal(t, "ollama/qwen2.5-coder:7b", qwen.ID)
+	assert.Equal(t, 32768, qwen.ContextLength)
+	assert.True(t, qwen.SupportsTools)
+	assert.False(t, qwen.SupportsVision)
+	assert.Equal(t, "7.6B", qwen.ParameterSize)
+	assert.Equal(t, int64(100), qwen.Size)
+
+	llava := byName["llava:13b"]
+	assert.False(t, llava.SupportsTools)
+	assert.True(t, llava.SupportsVision)
+	assert.Equal(t, 4096, llava.ContextLength)
+
+	gemma := byName["gemma4:27b"]
+	assert.Equal(t, "gemma-4-27b", gemma.ID)
+	assert.True(t, gemma.Catalog)
+
+	// Registered with the provider catalog for the native loop
+	def := ollamaprov.LookupByID("ollama/qwen2.5-coder:7b")
+	require.NotNil(t, def)
+	assert.Equal(t, 32768, def.ContextWindow)
+	assert.True(t, def.SupportsTools)
+	assert.False(t, ollamaprov.LookupByOllamaName("gemma4:27b").Discovered, "catalog models are not duplicated")
+}
+
+func TestDiscoverModels_CachesByDigestAndPersists(t *testing.T) {
+	tags := []map[string]any{{"name": "llama3:8b", "digest": "abc"}}
+	shows := map[string]any{"llama3:8b": map[string]any{
+		"model_info":   map[string]any{"llama.context_length": 8192},
+		"capabilities": []string{"completion", "tools"},
+	}}
+	m, probes := newStubManager(t, tags, shows)
+
+	_, err := m.DiscoverModels(context.Background())
+	require.NoError(t, err)
+	_, err = m.DiscoverModels(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), probes.Load(), "unchanged digests are not re-probed")
+
+	tags[0]["digest"] = "def"
+	_, err = m.DiscoverModels(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), probes.Load())
+
+	// A new manager on the same data dir restores the discovery.
+	ollamaprov.SetDiscoveredModels(nil)
+	restored := NewManager(m.dataDir)
+	models := restored.DiscoveredModels()
+	require.Len(t, models, 1)
+	assert.Equal(t, "def", models[0].Digest)
+	w, ok := ollamaprov.ContextWindowForOllamaModel("llama3:8b")
+	assert.True(t, ok)
+	assert.Equal(t, 8192, w)
+}
@@ -1,6 +1,8 @@
 // Package ollama manages the lifecycle of a bundled Ollama binary for local model inference.
 // It handles downloading, starting/stopping, health checks, and model management
-// so that users don't need to install Ollama separately.
+// so that users don't need to install Ollama separately. It can instead target
+// an existing Ollama server (see SetRemote), in which case only health checks
+// and model management apply.
 package ollama
 
 import (
@@ -41,16 +43,22 @@ type ProgressEvent struct {
 }
 
 // StatusInfo describes the current state of the managed Ollama instance.
+// Mode is "managed" for the bundled binary or "remote" for an external server,
+// whose Endpoint is reported as well.
 type StatusInfo struct {
+	Mode      string      `json:"mode"`
+	Endpoint  string      `json:"endpoint,omitempty"`
 	Installed bool        `json:"installed"`
 	Running   bool        `json:"running"`
 	Version   string      `json:"version"`
 	Models    []ModelInfo `json:"models"`
+	Error     string      `json:"error,omitempty"`
 }
 
 // ModelInfo describes a locally available model.
 type ModelInfo struct {
 	Name       string    `json:"name"`
+	Digest     string    `json:"digest,omitempty"`
 	Size       int64     `json:"size"`
 	ModifiedAt time.Time `json:"modified_at"`
 }
@@ -64,6 +72,7 @@ type Manager struct {
 	port     int
 	stopping bool            // true while Stop() is in progress — prevents concurrent SIGTERM
 	stopCh   chan struct{} // closed when process exits
+	remote   *RemoteConfig // external server; nil for the managed binary
 
 	// installMu serializes Install() calls. Protected by its own mutex
 	// (not m.mu) to avoid holding the main lock during a long download.
@@ -72,6 +81,11 @@ type Manager struct {
 	// progressFunc is called during download/pull operations to stream progress.
 	// Set once at construction time; safe to leave nil.
 	progressFunc func(ProgressEvent)
+
+	// discoveryMu serializes model discovery and guards discovered, the
+	// probed installed models keyed by Ollama name.
+	discoveryMu sync.Mutex
+	discovered  map[string]DiscoveredModel
 }
 
 // NewManager creates a new Ollama manager. dataDir is the parent directory
@@ -82,6 +96,7 @@ func NewManager(dataDir string, progressFunc ...func(ProgressEvent)) *Manager {
 	if len(progressFunc) > 0 {
 		m.progressFunc = progressFunc[0]
 	}
+	m.loadDiscovered()
 	return m
 }
 
@@ -125,6 +140,11 @@ func (m *Manager) InstalledVersion() string {
 // with the pinned version is a no-op. Concurrent callers are serialized via
 // installMu — only the first caller downloads; others block and see the result.
 func (m *Manager) Install(ctx context.Context) error {
+	// An external server needs nothing installed locally.
+	if m.IsRemote() {
+		return nil
+	}
+
 	// Fast path (lock-free): already installed with the pinned version.
 	if m.IsInstalled() && m.InstalledVersion() == PinnedVersion {
 		return nil
@@ -328,19 +348,28 @@ func (m *Manager) emitDownloadProgress(downloaded, total int64) {
 
 // --- Lifecycle ---
 
-// Endpoint returns the HTTP endpoint of the running Ollama server.
-// Returns "" if not running.
+// Endpoint returns the HTTP endpoint of the running Ollama server, or the
+// external server in remote mode. Returns "" if not running.
 func (m *Manager) Endpoint() string {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	if m.remote != nil {
+		return m.remote.Endpoint
+	}
 	if m.port == 0 {
 		return ""
 	}
 	return fmt.Sprintf("http://127.0.0.1:%d", m.port)
 }
 
-// IsRunning returns true if the managed Ollama process is alive and healthy.
+// IsRunning returns true if the managed Ollama process is alive and healthy,
+// or in remote mode if the external server answers its health check.
 func (m *Manager) IsRunning() bool {
+	if m.IsRemote() {
+		_, err := m.RemoteVersion(context.Background())
+		return err == nil
+	}
+
 	m.mu.Lock()
 	port := m.port
 	cmd := m.cmd
@@ -364,10 +393,14 @@ func (m *Manager) IsRunning() bool {
 
 // IsAlive returns true if the managed process handle exists and hasn't exited.
 // This is a cheap, lock-only check (no HTTP call) suitable for fast-path
-// decisions where a full health check is not needed.
+// decisions where a full health check is not needed. A configured remote
+// server is always considered alive; the request that follows finds out.
 func (m *Manager) IsAlive() bool {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	if m.remote != nil {
+		return true
+	}
 	return m.cmd != nil && m.cmd.Process != nil && m.cmd.ProcessState == nil && m.port != 0
 }
 
@@ -376,6 +409,11 @@ func (m *Manager) IsAlive() bool {
 // unlocked HTTP health check. If unhealthy, falls through to Start() which
 // re-validates under its own lock, so concurrent callers cannot double-start.
 func (m *Manager) EnsureRunning(ctx context.Context) error {
+	if m.IsRemote() {
+		_, err := m.RemoteVersion(ctx)
+		return err
+	}
+
 	m.mu.Lock()
 	// Fast path: process handle exists and hasn't exited
 	if m.cmd != nil && m.cmd.Process != nil && m.cmd.ProcessState == nil {
@@ -395,6 +433,10 @@ func (m *Manager) EnsureRunning(ctx context.Context) error {
 // Retries up to 3 times to handle transient port-binding races (TOCTOU between
 // findFreePort and Ollama's bind).
 func (m *Manager) Start(ctx context.Context) error {
+	if m.IsRemote() {
+		return fmt.Errorf("ollama: using a remote server, not starting the managed binary")
+	}
+
 	const maxRetries = 3
 	var lastErr error
 	for attempt := range maxRetries {
@@ -482,6 +524,7 @@ func (m *Manager) tryStart(ctx context.Context) error {
 	}()
 
 	log.Printf("ollama: server started on port %d", port)
+	go m.refreshDiscovered()
 	return nil
 }
 
@@ -567,12 +610,7 @@ func (m *Manager) TouchActivity() {}
 
 // ListModels returns the locally available models.
 func (m *Manager) ListModels(ctx context.Context) ([]ModelInfo, error) {
-	endpoint := m.Endpoint()
-	if endpoint == "" {
-		return nil, fmt.Errorf("ollama not running")
-	}
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"/api/tags", nil)
+	req, err := m.newRequest(ctx, http.MethodGet, "/api/tags", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -582,10 +620,14 @@ func (m *Manager) ListModels(ctx context.Context) ([]ModelInfo, error) {
 		return nil, fmt.Errorf("list models: %w", err)
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("list models: HTTP %d", resp.StatusCode)
+	}
 
 	var result struct {
 		Models []struct {
 			Name       string    `json:"name"`
+			Digest     string    `json:"digest"`
 			Size       int64     `json:"size"`
 			ModifiedAt time.Time `json:"modified_at"`
 		} `json:"models"`
@@ -598,6 +640,7 @@ func (m *Manager) ListModels(ctx context.Context) ([]ModelInfo, error) {
 	for i, m := range result.Models {
 		models[i] = ModelInfo{
 			Name:       m.Name,
+			Digest:     m.Digest,
 			Size:       m.Size,
 			ModifiedAt: m.ModifiedAt,
 		}
@@ -609,17 +652,11 @@ func (m *Manager) ListModels(ctx context.Context) ([]ModelInfo, error) {
 // Uses Ollama's /api/show endpoint to check a single model directly,
 // avoiding the overhead of listing and decoding all models.
 func (m *Manager) IsModelAvailable(ctx context.Context, model string) (bool, error) {
-	endpoint := m.Endpoint()
-	if endpoint == "" {
-		return false, fmt.Errorf("ollama not running")
-	}
-
 	body, _ := json.Marshal(map[string]string{"name": model})
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"/api/show", bytes.NewReader(body))
+	req, err := m.newRequest(ctx, http.MethodPost, "/api/show", bytes.NewReader(body))
 	if err != nil {
 		return false, err
 	}
-	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
@@ -641,21 +678,15 @@ func (m *Manager) IsModelAvailable(ctx context.Context, model string) (bool, err
 
 // Pull downloads a model. It streams progress via m.progressFunc.
 func (m *Manager) Pull(ctx context.Context, model string) error {
-	endpoint := m.Endpoint()
-	if endpoint == "" {
-		return fmt.Errorf("ollama not running")
-	}
-
 	body, _ := json.Marshal(map[string]interface{}{
 		"name":   model,
 		"stream": true,
 	})
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"/api/pull", bytes.NewReader(body))
+	req, err := m.newRequest(ctx, http.MethodPost, "/api/pull", bytes.NewReader(body))
 	if err != nil {
 		return err
 	}
-	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
@@ -705,12 +736,16 @@ func (m *Manager) Pull(ctx context.Context, model string) error {
 	}
 
 	log.Printf("ollama: pulled model %s", model)
+	if _, err := m.DiscoverModels(ctx); err != nil {
+		log.Printf("ollama: model discovery after pull failed: %v", err)
+	}
 	return nil
 }
 
 // EnsureModelAvailable checks if a model is available and pulls it if not.
 // model can be either a ChatML model ID (e.g., "gemma-4-27b") or an Ollama
-// model tag (e.g., "gemma4:27b") — it is normalized before use.
+// model tag (e.g., "gemma4:27b") — it is normalized before use. A remote
+// server is shared, so models missing there are reported rather than pulled.
 func (m *Manager) EnsureModelAvailable(ctx context.Context, model string) error {
 	ollamaName := ToOllamaName(model)
 	available, err := m.IsModelAvailable(ctx, ollamaName)
@@ -720,12 +755,30 @@ func (m *Manager) EnsureModelAvailable(ctx context.Context, model string) error
 	if available {
 		return nil
 	}
+	if remote, ok := m.Remote(); ok {
+		return fmt.Errorf("model %s is not available on %s", ollamaName, remote.Endpoint)
+	}
 	return m.Pull(ctx, ollamaName)
 }
 
 // Status returns the current state of the Ollama installation and server.
 func (m *Manager) Status(ctx context.Context) StatusInfo {
+	if remote, ok := m.Remote(); ok {
+		info := StatusInfo{Mode: "remote", Endpoint: remote.Endpoint, Installed: true}
+		version, err := m.RemoteVersion(ctx)
+		if err != nil {
+			info.Error = err.Error()
+			return info
+		}
+		info.Running, info.Version = true, version
+		if models, err := m.ListModels(ctx); err == nil {
+			info.Models = models
+		}
+		return info
+	}
+
 	info := StatusInfo{
+		Mode:      "managed",
 		Installed: m.IsInstalled(),
 		Version:   m.InstalledVersion(),
 		Running:   m.IsRunning(),
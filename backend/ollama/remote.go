@@ -0,0 +1,148 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// RemoteConfig points the manager at an Ollama server it does not own, such
+// as a shared GPU box on the LAN. Token, when set, is sent as a bearer token
+// for servers behind an authenticating proxy.
+type RemoteConfig struct {
+	Endpoint string `json:"endpoint"`
+	Token    string `json:"token,omitempty"`
+}
+
+// NormalizeEndpoint validates an Ollama base URL and strips any trailing
+// slash. A bare host:port is assumed to be plain HTTP.
+func NormalizeEndpoint(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", fmt.Errorf("endpoint is required")
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid endpoint: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("endpoint must use http or https")
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("endpoint must include a host")
+	}
+	if u.User != nil || u.RawQuery != "" || u.Fragment != "" {
+		return "", fmt.Errorf("endpoint must not include credentials, a query or a fragment")
+	}
+	return strings.TrimRight(u.String(), "/"), nil
+}
+
+// SetRemote switches the manager to an external Ollama server, or back to
+// the managed binary when cfg is nil. In remote mode the manager never
+// installs, starts or stops a process; it only health-checks the endpoint.
+// Callers should Stop a running managed server before switching.
+func (m *Manager) SetRemote(cfg *RemoteConfig) error {
+	if cfg == nil {
+		m.mu.Lock()
+		m.remote = nil
+		m.mu.Unlock()
+		return nil
+	}
+
+	endpoint, err := NormalizeEndpoint(cfg.Endpoint)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	m.remote = &RemoteConfig{Endpoint: endpoint, Token: strings.TrimSpace(cfg.Token)}
+	m.mu.Unlock()
+	return nil
+}
+
+// Remote returns the external server configuration, if one is set.
+func (m *Manager) Remote() (RemoteConfig, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.remote == nil {
+		return RemoteConfig{}, false
+	}
+	return *m.remote, true
+}
+
+// IsRemote reports whether the manager targets an external server.
+func (m *Manager) IsRemote() bool {
+	_, ok := m.Remote()
+	return ok
+}
+
+// AuthToken returns the bearer token for the current endpoint, or "" when
+// none is needed. The native loop sends it with every chat request.
+func (m *Manager) AuthToken() string {
+	remote, _ := m.Remote()
+	return remote.Token
+}
+
+// newRequest builds a request against the current endpoint and attaches the
+// bearer token in remote mode.
+func (m *Manager) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	endpoint := m.Endpoint()
+	if endpoint == "" {
+		return nil, fmt.Errorf("ollama not running")
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if token := m.AuthToken(); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req, nil
+}
+
+// RemoteVersion health-checks the external server and returns the version
+// it reports. Authentication failures are called out separately because a
+// reachable server with a bad token is the most common misconfiguration.
+func (m *Manager) RemoteVersion(ctx context.Context) (string, error) {
+	remote, ok := m.Remote()
+	if !ok {
+		return "", fmt.Errorf("no remote ollama server configured")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	req, err := m.newRequest(ctx, http.MethodGet, "/api/version", nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("ollama at %s unreachable: %w", remote.Endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return "", fmt.Errorf("ollama at %s rejected the credentials (HTTP %d)", remote.Endpoint, resp.StatusCode)
+	case resp.StatusCode != http.StatusOK:
+		return "", fmt.Errorf("ollama at %s: HTTP %d", remote.Endpoint, resp.StatusCode)
+	}
+
+	var v struct {
+		Version string `json:"version"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 16*1024)).Decode(&v); err != nil {
+		return "", fmt.Errorf("ollama at %s: decode version: %w", remote.Endpoint, err)
+	}
+	return v.Version, nil
+}
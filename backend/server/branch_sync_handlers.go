@@ -8,6 +8,7 @@ import (
 	"github.com/chatml/chatml-core/git"
 	"github.com/chatml/chatml-backend/logger"
 	"github.com/chatml/chatml-backend/models"
+	"github.com/chatml/chatml-backend/stack"
 	"github.com/go-chi/chi/v5"
 )
 
@@ -30,8 +31,8 @@ func (h *Handlers) GetSessionBranchSyncStatus(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	// Get sync status using effective target branch
-	targetBranch := session.EffectiveTargetBranch()
+	// Get sync status against the target branch (the parent's branch for stacked sessions)
+	targetBranch := session.SyncBaseRef()
 	status, err := h.repoManager.GetBranchSyncStatus(ctx, session.WorktreePath, session.BaseCommitSHA, targetBranch)
 	if err != nil {
 		writeInternalError(w, "failed to get branch sync status", err)
@@ -87,15 +88,20 @@ func (h *Handlers) SyncSessionBranch(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Determine effective target branch
-	targetBranch := session.EffectiveTargetBranch()
+	// Determine effective target branch (the parent's branch for stacked sessions)
+	targetBranch := session.SyncBaseRef()
 
 	// Perform the operation with the effective target branch
 	var result *git.BranchSyncResult
-	if req.Operation == "rebase" {
-		result, err = h.repoManager.RebaseOntoTarget(ctx, session.WorktreePath, targetBranch)
-	} else {
+	switch {
+	case req.Operation == "merge":
 		result, err = h.repoManager.MergeFromTarget(ctx, session.WorktreePath, targetBranch)
+	case session.IsStacked() && session.BaseCommitSHA != "":
+		// Move only this session's commits: the parent branch may have been
+		// rewritten since this session was last based on it.
+		result, err = h.repoManager.RestackOnto(ctx, session.WorktreePath, targetBranch, session.BaseCommitSHA)
+	default:
+		result, err = h.repoManager.RebaseOntoTarget(ctx, session.WorktreePath, targetBranch)
 	}
 
 	if err != nil {
@@ -117,6 +123,11 @@ func (h *Handlers) SyncSessionBranch(w http.ResponseWriter, r *http.Request) {
 		h.branchCache.InvalidateRepo(repo.Path)
 	}
 
+	// This branch moved; bring any sessions stacked on it along
+	if result.Success && h.restacker != nil {
+		h.goBackground(func() { h.restacker.RestackChildren(h.serverCtx, sessionID) })
+	}
+
 	// Convert to response format
 	response := models.BranchSyncResult{
 		Success:       result.Success,
@@ -178,3 +189,36 @@ func (h *Handlers) AbortSessionSync(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// RestackSession rebases every session stacked on this one onto its current
+// branch tip, cascading down the stack. Use it after committing to a parent
+// session outside of a branch sync.
+func (h *Handlers) RestackSession(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	sessionID := chi.URLParam(r, "sessionId")
+
+	session, err := h.store.GetSession(ctx, sessionID)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	if session == nil {
+		writeNotFound(w, "session")
+		return
+	}
+	if h.restacker == nil {
+		writeServiceUnavailable(w, "restacking is not available")
+		return
+	}
+
+	results := h.restacker.RestackChildren(ctx, sessionID)
+	if results == nil {
+		results = []stack.Result{}
+	}
+
+	if repo, err := h.store.GetRepo(ctx, session.WorkspaceID); err == nil && repo != nil {
+		h.branchCache.InvalidateRepo(repo.Path)
+	}
+
+	writeJSON(w, results)
+}
@@ -1,11 +1,13 @@
 package server
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"os"
 	"strconv"
 	"strings"
 	"time"
@@ -15,6 +17,7 @@ import (
 	"github.com/chatml/chatml-backend/logger"
 	"github.com/chatml/chatml-backend/models"
 	"github.com/chatml/chatml-backend/store"
+	coreloop "github.com/chatml/chatml-core/loop"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 )
@@ -200,6 +203,14 @@ func (h *Handlers) CreateConversation(w http.ResponseWriter, r *http.Request) {
 		writeValidationError(w, "permissionMode must be one of: default, acceptEdits, bypassPermissions, dontAsk")
 		return
 	}
+	if msg := checkPolicyPermissionMode(req.PermissionMode); msg != "" {
+		writePolicyLocked(w, msg)
+		return
+	}
+	if msg := checkPolicyModel(req.Model); msg != "" {
+		writePolicyLocked(w, msg)
+		return
+	}
 
 	// Build options for starting the conversation
 	var opts *agent.StartConversationOptions
@@ -355,6 +366,11 @@ func (h *Handlers) SendConversationMessage(w http.ResponseWriter, r *http.Reques
 		}
 	}
 
+	if msg := checkPolicyModel(req.Model); msg != "" {
+		writePolicyLocked(w, msg)
+		return
+	}
+
 	// Switch model if specified; "auto" clears the override so the SDK picks the default.
 	if req.Model != "" {
 		dbModel := req.Model
@@ -403,6 +419,29 @@ func (h *Handlers) StopConversation(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// ListConversationTasks returns the conversation's agent task list as last
+// reported by the agent, including while the conversation isn't running.
+func (h *Handlers) ListConversationTasks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	convID := chi.URLParam(r, "convId")
+	conv, err := h.store.GetConversationMeta(ctx, convID)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	if conv == nil {
+		writeNotFound(w, "conversation")
+		return
+	}
+
+	tasks, err := h.store.ListConversationTasks(ctx, convID)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, tasks)
+}
+
 func (h *Handlers) StopTask(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	convID := chi.URLParam(r, "convId")
@@ -560,6 +599,136 @@ func (h *Handlers) RewindConversation(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, map[string]string{"status": "rewinding"})
 }
 
+type ForkConversationRequest struct {
+	MessageID string `json:"messageId,omitempty"` // Fork after this message's turn (empty = whole conversation)
+	SessionID string `json:"sessionId,omitempty"` // Session to create the fork in (empty = the source's session)
+	Name      string `json:"name,omitempty"`
+}
+
+// ForkConversation branches a conversation into a new one that shares its
+// history up to a message and then continues independently. The fork gets
+// its own copy of the agent transcript, so both conversations can be resumed
+// side by side. To explore the fork on a separate branch, create a session
+// (e.g. one stacked on the source session) first and pass it as sessionId.
+func (h *Handlers) ForkConversation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	convID := chi.URLParam(r, "convId")
+	conv, err := h.store.GetConversationMeta(ctx, convID)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	if conv == nil {
+		writeNotFound(w, "conversation")
+		return
+	}
+
+	var req ForkConversationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeValidationError(w, "invalid request body")
+		return
+	}
+
+	source, err := h.store.GetSession(ctx, conv.SessionID)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	if source == nil {
+		writeNotFound(w, "session")
+		return
+	}
+	target := source
+	if req.SessionID != "" && req.SessionID != conv.SessionID {
+		target, err = h.store.GetSession(ctx, req.SessionID)
+		if err != nil {
+			writeDBError(w, err)
+			return
+		}
+		if target == nil {
+			writeNotFound(w, "session")
+			return
+		}
+		if target.WorkspaceID != source.WorkspaceID {
+			writeValidationError(w, "sessionId must belong to the same workspace")
+			return
+		}
+	}
+
+	turns := -1
+	if req.MessageID != "" {
+		n, found, err := h.store.CountUserTurnsThrough(ctx, convID, req.MessageID)
+		if err != nil {
+			writeDBError(w, err)
+			return
+		}
+		if !found {
+			writeNotFound(w, "message")
+			return
+		}
+		turns = n
+	}
+
+	// The fork's transcript is named after the fork's conversation ID, which
+	// is the session ID the native loop uses when the fork is resumed.
+	dir := coreloop.TranscriptDir(source.WorktreePath)
+	path := ""
+	if conv.AgentSessionID != "" && !strings.ContainsAny(conv.AgentSessionID, `/\`) && !strings.Contains(conv.AgentSessionID, "..") {
+		path = coreloop.FindTranscript(dir, conv.AgentSessionID)
+	}
+	if path == "" {
+		writeConflict(w, "conversation has no agent transcript to fork")
+		return
+	}
+	keep := 0
+	if turns >= 0 {
+		msgs, _, err := coreloop.ReadTranscript(path)
+		if err != nil {
+			writeInternalError(w, "failed to read transcript", err)
+			return
+		}
+		if keep = coreloop.TurnBoundary(msgs, turns); keep == 0 {
+			writeValidationError(w, "nothing to fork before this message")
+			return
+		}
+	}
+
+	now := time.Now()
+	forkID := uuid.New().String()[:8]
+	name := req.Name
+	if name == "" {
+		name = conv.Name + " (fork)"
+	}
+	fork := &models.Conversation{
+		ID:             forkID,
+		SessionID:      target.ID,
+		Type:           conv.Type,
+		Name:           name,
+		Status:         models.ConversationStatusIdle,
+		Model:          conv.Model,
+		AgentSessionID: forkID,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	forkPath, err := coreloop.ForkTranscript(dir, conv.AgentSessionID, forkID, keep)
+	if err != nil {
+		writeInternalError(w, "failed to fork transcript", err)
+		return
+	}
+	if err := h.store.ForkConversation(ctx, fork, convID, turns); err != nil {
+		os.Remove(forkPath) //nolint:errcheck
+		writeDBError(w, err)
+		return
+	}
+
+	created, err := h.store.GetConversation(ctx, forkID)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSONStatus(w, http.StatusCreated, created)
+}
+
 // AnswerQuestionRequest represents user answers to AskUserQuestion tool
 type AnswerQuestionRequest struct {
 	RequestID string            `json:"requestId"`
@@ -728,6 +897,10 @@ func (h *Handlers) SetConversationPermissionMode(w http.ResponseWriter, r *http.
 		writeValidationError(w, "mode must be one of: default, acceptEdits, bypassPermissions, dontAsk")
 		return
 	}
+	if msg := checkPolicyPermissionMode(req.Mode); msg != "" {
+		writePolicyLocked(w, msg)
+		return
+	}
 
 	if err := h.agentManager.SetConversationPermissionMode(convID, req.Mode); err != nil {
 		writeInternalError(w, "failed to set permission mode", err)
@@ -768,6 +941,94 @@ func (h *Handlers) SetConversationFastMode(w http.ResponseWriter, r *http.Reques
 	writeJSON(w, map[string]bool{"enabled": req.Enabled})
 }
 
+// CompactConversationRequest optionally focuses the summary, e.g.
+// "keep the DB migration details".
+type CompactConversationRequest struct {
+	Instructions string `json:"instructions,omitempty"`
+}
+
+// CompactConversation summarizes a running conversation's context on demand.
+// Native-loop conversations return before/after token counts; agent-runner
+// conversations compact asynchronously and report via compact_boundary (202).
+func (h *Handlers) CompactConversation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	convID := chi.URLParam(r, "convId")
+	conv, err := h.store.GetConversationMeta(ctx, convID)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	if conv == nil {
+		writeNotFound(w, "conversation")
+		return
+	}
+
+	var req CompactConversationRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeValidationError(w, "invalid request body")
+			return
+		}
+	}
+	if len(req.Instructions) > 4000 {
+		writeValidationError(w, "instructions must be at most 4000 characters")
+		return
+	}
+
+	report, err := h.agentManager.CompactConversation(ctx, convID, req.Instructions)
+	switch {
+	case errors.Is(err, agent.ErrConversationNotRunning):
+		writeConflict(w, "conversation is not running")
+		return
+	case errors.Is(err, agent.ErrConversationBusy):
+		writeConflict(w, "wait for the current turn to finish before compacting")
+		return
+	case err != nil:
+		writeInternalError(w, "failed to compact conversation", err)
+		return
+	}
+
+	if report == nil {
+		writeJSONStatus(w, http.StatusAccepted, map[string]string{"status": "compacting"})
+		return
+	}
+	writeJSON(w, report)
+}
+
+// GetConversationContext returns a running conversation's estimated context
+// usage by category: system prompt sections, tool definitions per MCP server,
+// messages, tool results by tool, and attachments.
+func (h *Handlers) GetConversationContext(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	convID := chi.URLParam(r, "convId")
+	conv, err := h.store.GetConversationMeta(ctx, convID)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	if conv == nil {
+		writeNotFound(w, "conversation")
+		return
+	}
+
+	breakdown, err := h.agentManager.ConversationContextBreakdown(ctx, convID)
+	switch {
+	case errors.Is(err, agent.ErrConversationNotRunning):
+		writeConflict(w, "conversation is not running")
+		return
+	case errors.Is(err, agent.ErrConversationBusy):
+		writeConflict(w, "wait for the current turn to finish")
+		return
+	case errors.Is(err, agent.ErrContextBreakdownUnsupported):
+		writeConflict(w, err.Error())
+		return
+	case err != nil:
+		writeInternalError(w, "failed to compute context breakdown", err)
+		return
+	}
+	writeJSON(w, breakdown)
+}
+
 type SetMaxThinkingTokensRequest struct {
 	MaxThinkingTokens int `json:"maxThinkingTokens"`
 }
@@ -849,6 +1110,16 @@ type ToolApprovalRequest struct {
 	UpdatedInput json.RawMessage `json:"updatedInput,omitempty"` // User-edited tool input (e.g., modified Bash command)
 }
 
+// updatedInputOrNil treats an explicit JSON null as no edit. Decoding
+// "updatedInput": null yields RawMessage("null"), which the agent would
+// otherwise run as the tool's input.
+func updatedInputOrNil(raw json.RawMessage) json.RawMessage {
+	if trimmed := bytes.TrimSpace(raw); len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")) {
+		return nil
+	}
+	return raw
+}
+
 func (h *Handlers) ApproveTool(w http.ResponseWriter, r *http.Request) {
 	convID := chi.URLParam(r, "convId")
 
@@ -876,6 +1147,7 @@ func (h *Handlers) ApproveTool(w http.ResponseWriter, r *http.Request) {
 	if strings.HasPrefix(req.Action, "deny") {
 		req.UpdatedInput = nil
 	}
+	req.UpdatedInput = updatedInputOrNil(req.UpdatedInput)
 
 	// Validate specifier for persistent actions (defense-in-depth)
 	if (req.Action == "allow_always" || req.Action == "deny_always") && req.Specifier != "" {
@@ -964,7 +1236,7 @@ func (h *Handlers) ApproveBatchTools(w http.ResponseWriter, r *http.Request) {
 			agentPerTool[id] = agent.ToolApprovalOverride{
 				Action:       override.Action,
 				Specifier:    override.Specifier,
-				UpdatedInput: override.UpdatedInput,
+				UpdatedInput: updatedInputOrNil(override.UpdatedInput),
 			}
 		}
 	}
@@ -14,6 +14,8 @@ import (
 	"github.com/chatml/chatml-backend/ai"
 	"github.com/chatml/chatml-backend/models"
 	"github.com/chatml/chatml-backend/store"
+	coreloop "github.com/chatml/chatml-core/loop"
+	"github.com/chatml/chatml-core/provider"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -190,6 +192,118 @@ func TestSetConversationPlanMode_ProcessNotRunning(t *testing.T) {
 	require.NoError(t, err)
 	assert.True(t, resp["enabled"])
 }
+func TestCompactConversation_NotFound(t *testing.T) {
+	h, _, _ := setupTestHandlersWithAgentManager(t)
+
+	req := httptest.NewRequest("POST", "/api/conversations/nonexistent/compact", nil)
+	req = withChiContext(req, map[string]string{"convId": "nonexistent"})
+	w := httptest.NewRecorder()
+
+	h.CompactConversation(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestCompactConversation_ProcessNotRunning(t *testing.T) {
+	h, s, _ := setupTestHandlersWithAgentManager(t)
+
+	createTestRepo(t, s, "ws-1", "/path/to/repo")
+	createTestSession(t, s, "sess-1", "ws-1")
+	createTestConversation(t, s, "conv-1", "sess-1")
+
+	body := strings.NewReader(`{"instructions": "keep the DB migration details"}`)
+	req := httptest.NewRequest("POST", "/api/conversations/conv-1/compact", body)
+	req.Header.Set("Content-Type", "application/json")
+	req = withChiContext(req, map[string]string{"convId": "conv-1"})
+	w := httptest.NewRecorder()
+
+	h.CompactConversation(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "not running")
+}
+
+func TestCompactConversation_InstructionsTooLong(t *testing.T) {
+	h, s, _ := setupTestHandlersWithAgentManager(t)
+
+	createTestRepo(t, s, "ws-1", "/path/to/repo")
+	createTestSession(t, s, "sess-1", "ws-1")
+	createTestConversation(t, s, "conv-1", "sess-1")
+
+	body := strings.NewReader(`{"instructions": "` + strings.Repeat("x", 4001) + `"}`)
+	req := httptest.NewRequest("POST", "/api/conversations/conv-1/compact", body)
+	req = withChiContext(req, map[string]string{"convId": "conv-1"})
+	w := httptest.NewRecorder()
+
+	h.CompactConversation(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGetConversationContext_NotFound(t *testing.T) {
+	h, _, _ := setupTestHandlersWithAgentManager(t)
+
+	req := httptest.NewRequest("GET", "/api/conversations/nonexistent/context", nil)
+	req = withChiContext(req, map[string]string{"convId": "nonexistent"})
+	w := httptest.NewRecorder()
+
+	h.GetConversationContext(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestGetConversationContext_ProcessNotRunning(t *testing.T) {
+	h, s, _ := setupTestHandlersWithAgentManager(t)
+
+	createTestRepo(t, s, "ws-1", "/path/to/repo")
+	createTestSession(t, s, "sess-1", "ws-1")
+	createTestConversation(t, s, "conv-1", "sess-1")
+
+	req := httptest.NewRequest("GET", "/api/conversations/conv-1/context", nil)
+	req = withChiContext(req, map[string]string{"convId": "conv-1"})
+	w := httptest.NewRecorder()
+
+	h.GetConversationContext(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestListConversationTasks_NotFound(t *testing.T) {
+	h, _ := setupTestHandlers(t)
+
+	req := httptest.NewRequest("GET", "/api/conversations/nonexistent/tasks", nil)
+	req = withChiContext(req, map[string]string{"convId": "nonexistent"})
+	w := httptest.NewRecorder()
+
+	h.ListConversationTasks(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestListConversationTasks_ReturnsStoredTasks(t *testing.T) {
+	h, s := setupTestHandlers(t)
+
+	createTestRepo(t, s, "ws-1", "/path/to/repo")
+	createTestSession(t, s, "sess-1", "ws-1")
+	createTestConversation(t, s, "conv-1", "sess-1")
+	require.NoError(t, s.ReplaceConversationTasks(context.Background(), "conv-1", []models.ConversationTask{
+		{ID: "task-1", Subject: "Write migration", Status: "completed"},
+		{ID: "task-2", Subject: "Backfill", Status: "pending", BlockedBy: []string{"task-1"}},
+	}))
+
+	req := httptest.NewRequest("GET", "/api/conversations/conv-1/tasks", nil)
+	req = withChiContext(req, map[string]string{"convId": "conv-1"})
+	w := httptest.NewRecorder()
+
+	h.ListConversationTasks(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var tasks []models.ConversationTask
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tasks))
+	require.Len(t, tasks, 2)
+	assert.Equal(t, []string{"task-1"}, tasks[1].BlockedBy)
+}
+
 func TestSendConversationMessage_NotFound(t *testing.T) {
 	h, _, _ := setupTestHandlersWithAgentManager(t)
 
@@ -216,6 +330,83 @@ func TestRewindConversation_NotFound(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, w.Code)
 }
 
+func TestForkConversation_FromMessage(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	h, s := setupTestHandlers(t)
+	ctx := context.Background()
+
+	createTestRepo(t, s, "ws-1", "/path/to/repo")
+	createTestSession(t, s, "sess-1", "ws-1")
+	require.NoError(t, s.AddConversation(ctx, &models.Conversation{
+		ID: "conv-1", SessionID: "sess-1", Type: "task", Name: "Email column", Status: "idle",
+		AgentSessionID: "agent-sess-1", CreatedAt: time.Now(), UpdatedAt: time.Now(),
+	}))
+	for _, msg := range []models.Message{
+		{ID: "m-1", Role: "user", Content: "Add an email column", Timestamp: time.Now()},
+		{ID: "m-2", Role: "assistant", Content: "Added", Timestamp: time.Now()},
+		{ID: "m-3", Role: "user", Content: "Backfill it", Timestamp: time.Now()},
+		{ID: "m-4", Role: "assistant", Content: "Backfilled", Timestamp: time.Now()},
+	} {
+		require.NoError(t, s.AddMessageToConversation(ctx, "conv-1", msg))
+	}
+
+	dir := coreloop.TranscriptDir("")
+	tw, err := coreloop.NewTranscriptWriter(dir, "agent-sess-1", "")
+	require.NoError(t, err)
+	for _, text := range []string{"Add an email column", "Added", "Backfill it", "Backfilled"} {
+		role := provider.RoleUser
+		if text == "Added" || text == "Backfilled" {
+			role = provider.RoleAssistant
+		}
+		require.NoError(t, tw.WriteMessage(provider.Message{Role: role, Content: []provider.ContentBlock{provider.NewTextBlock(text)}}))
+	}
+	require.NoError(t, tw.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/api/conversations/conv-1/fork", strings.NewReader(`{"messageId":"m-2"}`))
+	req = withChiContext(req, map[string]string{"convId": "conv-1"})
+	w := httptest.NewRecorder()
+
+	h.ForkConversation(w, req)
+
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var fork models.Conversation
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &fork))
+	assert.Equal(t, "Email column (fork)", fork.Name)
+	assert.Equal(t, "sess-1", fork.SessionID)
+	assert.Equal(t, "idle", fork.Status)
+	assert.Equal(t, fork.ID, fork.AgentSessionID, "resumes under its own conversation ID")
+	assert.Equal(t, 2, fork.MessageCount)
+
+	msgs, meta, err := coreloop.ReadTranscript(coreloop.FindTranscript(dir, fork.ID))
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+	assert.Equal(t, "agent-sess-1", meta.ForkedFrom)
+}
+
+func TestForkConversation_Errors(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	h, s := setupTestHandlers(t)
+
+	createTestRepo(t, s, "ws-1", "/path/to/repo")
+	createTestRepo(t, s, "ws-2", "/path/to/other")
+	createTestSession(t, s, "sess-1", "ws-1")
+	createTestSession(t, s, "sess-2", "ws-2")
+	createTestConversation(t, s, "conv-1", "sess-1")
+
+	fork := func(convID, body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/conversations/"+convID+"/fork", strings.NewReader(body))
+		req = withChiContext(req, map[string]string{"convId": convID})
+		w := httptest.NewRecorder()
+		h.ForkConversation(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusNotFound, fork("nonexistent", `{}`))
+	assert.Equal(t, http.StatusBadRequest, fork("conv-1", `{"sessionId":"sess-2"}`), "other workspace")
+	assert.Equal(t, http.StatusNotFound, fork("conv-1", `{"messageId":"missing"}`))
+	assert.Equal(t, http.StatusConflict, fork("conv-1", `{}`), "no transcript")
+}
+
 func TestStopConversation_ExistingConversation(t *testing.T) {
 	h, s, _ := setupTestHandlersWithAgentManager(t)
 	ctx := context.Background()
@@ -1250,3 +1441,11 @@ func addTestMessages(t *testing.T, s *store.SQLiteStore, convID string, n int) {
 		require.NoError(t, s.AddMessageToConversation(ctx, convID, msg))
 	}
 }
+
+func TestUpdatedInputOrNil(t *testing.T) {
+	var req ToolApprovalRequest
+	require.NoError(t, json.Unmarshal([]byte(`{"requestId":"r","action":"allow_once","updatedInput":null}`), &req))
+	assert.Nil(t, updatedInputOrNil(req.UpdatedInput))
+	assert.Nil(t, updatedInputOrNil(nil))
+	assert.JSONEq(t, `{"command":"ls"}`, string(updatedInputOrNil(json.RawMessage(`{"command":"ls"}`))))
+}
@@ -24,6 +24,7 @@ const (
 	ErrCodePayloadTooLarge    = "PAYLOAD_TOO_LARGE"
 	ErrCodeServiceUnavailable = "SERVICE_UNAVAILABLE"
 	ErrCodeWorktreeNotFound   = "WORKTREE_NOT_FOUND"
+	ErrCodePolicyLocked       = "POLICY_LOCKED"
 )
 
 // writeError writes a JSON error response and logs the internal error server-side
@@ -18,6 +18,7 @@ import (
 	"github.com/chatml/chatml-backend/github"
 	"github.com/chatml/chatml-backend/logger"
 	"github.com/chatml/chatml-backend/models"
+	"github.com/chatml/chatml-backend/stack"
 	"github.com/chatml/chatml-backend/stats"
 	"github.com/chatml/chatml-core/scripts"
 	"github.com/chatml/chatml-backend/store"
@@ -543,6 +544,18 @@ type ScheduledTaskTrigger interface {
 	TriggerNow(ctx context.Context, taskID string) (*models.ScheduledTaskRun, error)
 }
 
+// AutoFixTrigger is the interface the handlers need from the PR automation controller
+type AutoFixTrigger interface {
+	Trigger(sessionID string)
+}
+
+// StackRestacker is the interface the handlers need to keep stacked sessions
+// in line with their parent (implemented by stack.Restacker)
+type StackRestacker interface {
+	RestackChildren(ctx context.Context, parentID string) []stack.Result
+	RetargetChildren(ctx context.Context, parentID string) []stack.Result
+}
+
 type Handlers struct {
 	store            *store.SQLiteStore
 	repoManager      *git.RepoManager
@@ -580,6 +593,12 @@ type Handlers struct {
 	aiClient         ai.Provider
 	scriptRunner     *scripts.Runner
 	scheduler        ScheduledTaskTrigger // Set after init via SetScheduler
+	autoFix          AutoFixTrigger       // Set after init via SetAutoFix
+	restacker        StackRestacker       // Set after init via SetRestacker
+
+	// Sessions whose post-merge hook has run (sessionID -> struct{})
+	postMergeHooksRun sync.Map
+
 	serverCtx        context.Context
 	serverCancel     context.CancelFunc
 	bgWg             sync.WaitGroup
@@ -778,6 +797,16 @@ func (h *Handlers) SetScheduler(s ScheduledTaskTrigger) {
 	h.scheduler = s
 }
 
+// SetAutoFix injects the PR automation controller after initialization
+func (h *Handlers) SetAutoFix(a AutoFixTrigger) {
+	h.autoFix = a
+}
+
+// SetRestacker injects the stacked-session restacker after initialization
+func (h *Handlers) SetRestacker(r StackRestacker) {
+	h.restacker = r
+}
+
 // getSessionAndWorkspace fetches session and workspace data in a single query.
 // Returns the session with embedded workspace info, the working path, and base ref.
 // This helper eliminates the N+1 pattern of fetching session then workspace separately.
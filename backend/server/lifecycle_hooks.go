@@ -0,0 +1,96 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/chatml/chatml-backend/logger"
+	"github.com/chatml/chatml-backend/models"
+	"github.com/chatml/chatml-core/scripts"
+)
+
+// lifecycleHookOutputLines is how much hook output a blocking failure reports.
+const lifecycleHookOutputLines = 20
+
+// runLifecycleHook runs a .chatml/config.json lifecycle hook (pre-session,
+// post-session, post-merge) for a session. It goes through the script runner,
+// so output streams to the session like setup scripts and the run is listed in
+// the session's script runs. It returns an error only when the hook failed and
+// its policy is "block"; "warn" failures are logged.
+func (h *Handlers) runLifecycleHook(hookName, repoPath string, sess *models.Session) error {
+	if h.scriptRunner == nil || repoPath == "" {
+		return nil
+	}
+	config, err := scripts.LoadConfig(repoPath)
+	if err != nil {
+		logger.Handlers.Warnf("Skipping %s hook for session %s: %v", hookName, sess.ID, err)
+		return nil
+	}
+	if config == nil || config.Hooks[hookName] == "" {
+		return nil
+	}
+
+	// Run in the worktree when it exists (it may already be gone after a merge)
+	workdir := sess.WorktreePath
+	if workdir == "" {
+		workdir = repoPath
+	} else if _, err := os.Stat(workdir); err != nil {
+		workdir = repoPath
+	}
+
+	env := map[string]string{
+		"CHATML_HOOK":           hookName,
+		"CHATML_SESSION_ID":     sess.ID,
+		"CHATML_SESSION_NAME":   sess.Name,
+		"CHATML_BRANCH":         sess.Branch,
+		"CHATML_TARGET_BRANCH":  sess.TargetBranch,
+		"CHATML_WORKSPACE_PATH": repoPath,
+		"CHATML_WORKTREE_PATH":  sess.WorktreePath,
+	}
+	if sess.PRNumber > 0 {
+		env["CHATML_PR_NUMBER"] = strconv.Itoa(sess.PRNumber)
+	}
+
+	run, err := h.scriptRunner.RunHook(h.serverCtx, sess.ID, workdir, hookName, config.Hooks[hookName], env)
+	if err == nil {
+		logger.Handlers.Infof("Ran %s hook for session %s", hookName, sess.ID)
+		return nil
+	}
+	if config.HookPolicy(hookName) != scripts.HookPolicyBlock {
+		logger.Handlers.Warnf("%s hook failed for session %s (continuing): %v", hookName, sess.ID, err)
+		return nil
+	}
+	logger.Handlers.Warnf("%s hook failed for session %s: %v", hookName, sess.ID, err)
+	if tail := run.OutputTail(lifecycleHookOutputLines); len(tail) > 0 {
+		return fmt.Errorf("%w:\n%s", err, strings.Join(tail, "\n"))
+	}
+	return err
+}
+
+// runSessionLifecycleHook runs a lifecycle hook for a stored session,
+// resolving its workspace path.
+func (h *Handlers) runSessionLifecycleHook(ctx context.Context, hookName string, sess *models.Session) error {
+	repo, err := h.store.GetRepo(ctx, sess.WorkspaceID)
+	if err != nil || repo == nil {
+		logger.Handlers.Warnf("Skipping %s hook for session %s: workspace not found", hookName, sess.ID)
+		return nil
+	}
+	return h.runLifecycleHook(hookName, repo.Path, sess)
+}
+
+// RunPostMergeHook runs the post-merge hook for a session whose PR merged.
+// Merges are seen both by MergePR and by the PR watcher, so the hook runs at
+// most once per session.
+func (h *Handlers) RunPostMergeHook(ctx context.Context, sessionID string) error {
+	if _, ran := h.postMergeHooksRun.LoadOrStore(sessionID, struct{}{}); ran {
+		return nil
+	}
+	sess, err := h.store.GetSession(ctx, sessionID)
+	if err != nil || sess == nil {
+		return nil
+	}
+	return h.runSessionLifecycleHook(ctx, scripts.HookPostMerge, sess)
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chatml/chatml-core/scripts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withLifecycleHooks writes .chatml/config.json hooks into repoPath and gives
+// the handlers a script runner.
+func withLifecycleHooks(t *testing.T, h *Handlers, repoPath string, hooks, policies map[string]string) {
+	t.Helper()
+	require.NoError(t, scripts.WriteConfig(repoPath, &scripts.ChatMLConfig{Hooks: hooks, HookPolicies: policies}))
+	h.scriptRunner = scripts.NewRunner(nil, nil, nil)
+}
+
+func archiveRequest(h *Handlers, sessionID string) *httptest.ResponseRecorder {
+	archived := true
+	body, _ := json.Marshal(UpdateSessionRequest{Archived: &archived})
+	req := httptest.NewRequest("PATCH", "/api/repos/ws-1/sessions/"+sessionID, bytes.NewReader(body))
+	req = withChiContext(req, map[string]string{"id": "ws-1", "sessionId": sessionID})
+	w := httptest.NewRecorder()
+	h.UpdateSession(w, req)
+	return w
+}
+
+func TestCreateSession_PreSessionHookRunsInWorktree(t *testing.T) {
+	h, s := setupTestHandlers(t)
+	repo := createTestRepo(t, s, "ws-1", createTestGitRepo(t))
+	withLifecycleHooks(t, h, repo.Path, map[string]string{
+		scripts.HookPreSession: `echo "$CHATML_SESSION_NAME" > pre-session.txt`,
+	}, nil)
+
+	sess := createSessionViaHandler(t, h, repo.ID, CreateSessionRequest{Name: "hooked"})
+
+	data, err := os.ReadFile(filepath.Join(sess.WorktreePath, "pre-session.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "hooked\n", string(data))
+
+	runs := h.scriptRunner.GetSessionRuns(sess.ID)
+	require.Len(t, runs, 1)
+	assert.Equal(t, "hook:pre-session", runs[0].ScriptKey)
+}
+
+func TestCreateSession_PreSessionHookBlocks(t *testing.T) {
+	h, s := setupTestHandlers(t)
+	repo := createTestRepo(t, s, "ws-1", createTestGitRepo(t))
+	withLifecycleHooks(t, h, repo.Path, map[string]string{
+		scripts.HookPreSession: `echo "missing license header" >&2; exit 1`,
+	}, nil)
+
+	body, _ := json.Marshal(CreateSessionRequest{Name: "blocked"})
+	req := httptest.NewRequest("POST", "/api/repos/ws-1/sessions", bytes.NewReader(body))
+	req = withChiContext(req, map[string]string{"id": repo.ID})
+	w := httptest.NewRecorder()
+	h.CreateSession(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "missing license header")
+	sessions, err := s.ListSessions(context.Background(), repo.ID, true)
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+}
+
+func TestUpdateSession_Archive_PostSessionHookBlocks(t *testing.T) {
+	h, s := setupTestHandlers(t)
+	repoPath := t.TempDir()
+	createTestRepo(t, s, "ws-1", repoPath)
+	createTestSession(t, s, "sess-1", "ws-1")
+	createTestConversation(t, s, "conv-1", "sess-1")
+	addTestMessage(t, s, "conv-1")
+	withLifecycleHooks(t, h, repoPath,
+		map[string]string{scripts.HookPostSession: `echo "uncommitted work" && exit 1`},
+		map[string]string{scripts.HookPostSession: scripts.HookPolicyBlock})
+
+	w := archiveRequest(h, "sess-1")
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "uncommitted work")
+	sess, err := s.GetSession(context.Background(), "sess-1")
+	require.NoError(t, err)
+	assert.False(t, sess.Archived)
+}
+
+func TestUpdateSession_Archive_PostSessionHookWarns(t *testing.T) {
+	h, s := setupTestHandlers(t)
+	repoPath := t.TempDir()
+	createTestRepo(t, s, "ws-1", repoPath)
+	createTestSession(t, s, "sess-1", "ws-1")
+	createTestConversation(t, s, "conv-1", "sess-1")
+	addTestMessage(t, s, "conv-1")
+	withLifecycleHooks(t, h, repoPath, map[string]string{scripts.HookPostSession: "exit 1"}, nil)
+
+	w := archiveRequest(h, "sess-1")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	sess, err := s.GetSession(context.Background(), "sess-1")
+	require.NoError(t, err)
+	assert.True(t, sess.Archived)
+	runs := h.scriptRunner.GetSessionRuns("sess-1")
+	require.Len(t, runs, 1)
+	assert.Equal(t, scripts.ScriptStatusFailed, runs[0].Status)
+}
+
+func TestRunPostMergeHook_RunsOnce(t *testing.T) {
+	h, s := setupTestHandlers(t)
+	repoPath := t.TempDir()
+	createTestRepo(t, s, "ws-1", repoPath)
+	createTestSession(t, s, "sess-1", "ws-1")
+	withLifecycleHooks(t, h, repoPath, map[string]string{
+		scripts.HookPostMerge: `echo "$CHATML_SESSION_ID" >> merged.log`,
+	}, nil)
+
+	require.NoError(t, h.RunPostMergeHook(context.Background(), "sess-1"))
+	require.NoError(t, h.RunPostMergeHook(context.Background(), "sess-1"))
+
+	data, err := os.ReadFile(filepath.Join(repoPath, "merged.log"))
+	require.NoError(t, err)
+	assert.Equal(t, "sess-1\n", string(data))
+}
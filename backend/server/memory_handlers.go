@@ -6,8 +6,10 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/chatml/chatml-backend/agent"
+	coreloop "github.com/chatml/chatml-core/loop"
 	"github.com/go-chi/chi/v5"
 )
 
@@ -212,6 +214,83 @@ func (h *Handlers) resolveMemoryDir(w http.ResponseWriter, r *http.Request) (str
 	return memDir, nil
 }
 
+// maxWorkingMemorySize caps the body of a working memory update (256KB).
+const maxWorkingMemorySize = 256 * 1024
+
+// GetWorkingMemory returns a conversation's structured working memory (goal,
+// decisions, open questions, file map, tasks). Native-loop sessions update it
+// every turn and re-inject it after compaction.
+func (h *Handlers) GetWorkingMemory(w http.ResponseWriter, r *http.Request) {
+	path, err := h.resolveWorkingMemoryPath(w, r)
+	if err != nil {
+		return
+	}
+	mem, err := coreloop.LoadWorkingMemory(path)
+	if err != nil {
+		writeInternalError(w, "failed to read working memory", err)
+		return
+	}
+	writeJSON(w, mem)
+}
+
+// SaveWorkingMemory replaces a conversation's working memory. A running
+// session picks up the edit on its next update; the task list is refreshed
+// from the session's tasks after every turn.
+func (h *Handlers) SaveWorkingMemory(w http.ResponseWriter, r *http.Request) {
+	path, err := h.resolveWorkingMemoryPath(w, r)
+	if err != nil {
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxWorkingMemorySize)
+	var mem coreloop.WorkingMemory
+	if err := json.NewDecoder(r.Body).Decode(&mem); err != nil {
+		writeValidationError(w, "invalid request body")
+		return
+	}
+	for _, f := range mem.Files {
+		if f.Path == "" || (f.Access != coreloop.FileAccessRead && f.Access != coreloop.FileAccessModified) {
+			writeValidationError(w, "files need a path and an access of \"read\" or \"modified\"")
+			return
+		}
+	}
+
+	mem.UpdatedAt = time.Now()
+	if err := coreloop.SaveWorkingMemory(path, &mem); err != nil {
+		writeInternalError(w, "failed to write working memory", err)
+		return
+	}
+	writeJSON(w, mem)
+}
+
+// resolveWorkingMemoryPath looks up the conversation from the route and
+// returns its working memory file, stored next to the session transcript.
+// On error it writes the HTTP response and returns an error.
+func (h *Handlers) resolveWorkingMemoryPath(w http.ResponseWriter, r *http.Request) (string, error) {
+	ctx := r.Context()
+	convID := chi.URLParam(r, "convId")
+
+	conv, err := h.store.GetConversationMeta(ctx, convID)
+	if err != nil {
+		writeDBError(w, err)
+		return "", err
+	}
+	if conv == nil {
+		writeNotFound(w, "conversation")
+		return "", os.ErrNotExist
+	}
+	if conv.AgentSessionID == "" || strings.ContainsAny(conv.AgentSessionID, `/\`) || strings.Contains(conv.AgentSessionID, "..") {
+		writeNotFound(w, "working memory")
+		return "", os.ErrNotExist
+	}
+
+	workdir := ""
+	if session, err := h.store.GetSession(ctx, conv.SessionID); err == nil && session != nil {
+		workdir = session.WorktreePath
+	}
+	return coreloop.WorkingMemoryPath(coreloop.TranscriptDir(workdir), conv.AgentSessionID), nil
+}
+
 // isValidMemoryFileName validates that a file name is safe:
 // - must end in .md
 // - must not contain path separators or ".."
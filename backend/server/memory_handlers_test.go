@@ -0,0 +1,88 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chatml/chatml-backend/models"
+	coreloop "github.com/chatml/chatml-core/loop"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkingMemory_RoundTrip(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	h, s := setupTestHandlers(t)
+	createTestRepo(t, s, "ws-1", "/path/to/repo")
+	createTestSession(t, s, "sess-1", "ws-1")
+	require.NoError(t, s.AddConversation(context.Background(), &models.Conversation{
+		ID: "conv-1", SessionID: "sess-1", Type: "task", Status: "active",
+		AgentSessionID: "agent-sess-1", CreatedAt: time.Now(), UpdatedAt: time.Now(),
+	}))
+
+	get := func() coreloop.WorkingMemory {
+		req := httptest.NewRequest("GET", "/api/conversations/conv-1/working-memory", nil)
+		req = withChiContext(req, map[string]string{"convId": "conv-1"})
+		w := httptest.NewRecorder()
+		h.GetWorkingMemory(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var mem coreloop.WorkingMemory
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &mem))
+		return mem
+	}
+
+	empty := get()
+	assert.True(t, empty.IsEmpty())
+
+	body := `{"goal":"Ship v2","decisions":["Keep the v1 API"],"files":[{"path":"api.go","access":"modified"}]}`
+	req := httptest.NewRequest("PUT", "/api/conversations/conv-1/working-memory", strings.NewReader(body))
+	req = withChiContext(req, map[string]string{"convId": "conv-1"})
+	w := httptest.NewRecorder()
+	h.SaveWorkingMemory(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	mem := get()
+	assert.Equal(t, "Ship v2", mem.Goal)
+	assert.Equal(t, []string{"Keep the v1 API"}, mem.Decisions)
+	assert.False(t, mem.UpdatedAt.IsZero())
+
+	// Stored next to the session transcript, where the runner reads it.
+	stored, err := coreloop.LoadWorkingMemory(coreloop.WorkingMemoryPath(coreloop.TranscriptDir(""), "agent-sess-1"))
+	require.NoError(t, err)
+	assert.Equal(t, "Ship v2", stored.Goal)
+}
+
+func TestWorkingMemory_InvalidFileAccess(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	h, s := setupTestHandlers(t)
+	createTestRepo(t, s, "ws-1", "/path/to/repo")
+	createTestSession(t, s, "sess-1", "ws-1")
+	require.NoError(t, s.AddConversation(context.Background(), &models.Conversation{
+		ID: "conv-1", SessionID: "sess-1", Type: "task", Status: "active",
+		AgentSessionID: "agent-sess-1", CreatedAt: time.Now(), UpdatedAt: time.Now(),
+	}))
+
+	req := httptest.NewRequest("PUT", "/api/conversations/conv-1/working-memory", strings.NewReader(`{"files":[{"path":"a.go","access":"deleted"}]}`))
+	req = withChiContext(req, map[string]string{"convId": "conv-1"})
+	w := httptest.NewRecorder()
+	h.SaveWorkingMemory(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestWorkingMemory_NotStarted(t *testing.T) {
+	h, s := setupTestHandlers(t)
+	createTestRepo(t, s, "ws-1", "/path/to/repo")
+	createTestSession(t, s, "sess-1", "ws-1")
+	createTestConversation(t, s, "conv-1", "sess-1")
+
+	req := httptest.NewRequest("GET", "/api/conversations/conv-1/working-memory", nil)
+	req = withChiContext(req, map[string]string{"convId": "conv-1"})
+	w := httptest.NewRecorder()
+	h.GetWorkingMemory(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
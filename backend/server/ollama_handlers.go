@@ -9,22 +9,29 @@ import (
 	"regexp"
 
 	"github.com/chatml/chatml-backend/ollama"
+	"github.com/chatml/chatml-backend/store"
+	"github.com/chatml/chatml-core/crypto"
 )
 
+// settingOllamaRemote holds the external Ollama server as JSON, with the
+// bearer token encrypted. Absent means the managed binary is used.
+const settingOllamaRemote = "ollama-remote"
+
 // validModelName limits model names to safe characters (alphanumeric, dots, dashes, colons, slashes)
 // and a reasonable length. Rejects empty strings and overly long names.
 var validModelName = regexp.MustCompile(`^[a-zA-Z0-9._:/-]{1,128}$`)
 
 // OllamaHandlers serves REST endpoints for managing the bundled Ollama runtime.
 type OllamaHandlers struct {
-	mgr *ollama.Manager
-	hub *Hub
+	mgr   *ollama.Manager
+	hub   *Hub
+	store *store.SQLiteStore
 }
 
 // NewOllamaHandlers creates handlers wired to the given Ollama manager.
 // Progress events are wired at manager construction time (see main.go).
-func NewOllamaHandlers(mgr *ollama.Manager, hub *Hub) *OllamaHandlers {
-	return &OllamaHandlers{mgr: mgr, hub: hub}
+func NewOllamaHandlers(mgr *ollama.Manager, hub *Hub, s *store.SQLiteStore) *OllamaHandlers {
+	return &OllamaHandlers{mgr: mgr, hub: hub, store: s}
 }
 
 // GetStatus returns the current Ollama installation and runtime state.
@@ -39,6 +46,10 @@ func (h *OllamaHandlers) GetStatus(w http.ResponseWriter, r *http.Request) {
 // Returns 202 Accepted immediately so the HTTP request doesn't block.
 // POST /api/ollama/install
 func (h *OllamaHandlers) Install(w http.ResponseWriter, r *http.Request) {
+	if h.mgr.IsRemote() {
+		writeError(w, http.StatusConflict, "OLLAMA_REMOTE", "A remote Ollama server is configured", nil)
+		return
+	}
 	if h.mgr.IsInstalled() {
 		writeJSON(w, map[string]string{"status": "already_installed"})
 		return
@@ -104,15 +115,16 @@ func (h *OllamaHandlers) Stop(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, map[string]string{"status": "stopped"})
 }
 
-// ListModels returns locally available models.
+// ListModels returns the installed models with their probed capabilities.
+// While Ollama is stopped it returns the last discovery instead.
 // GET /api/ollama/models
 func (h *OllamaHandlers) ListModels(w http.ResponseWriter, r *http.Request) {
 	if !h.mgr.IsRunning() {
-		writeJSON(w, []interface{}{})
+		writeJSON(w, h.mgr.DiscoveredModels())
 		return
 	}
 
-	models, err := h.mgr.ListModels(r.Context())
+	models, err := h.mgr.DiscoverModels(r.Context())
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "OLLAMA_ERROR", "Failed to list models", err)
 		return
@@ -120,6 +132,145 @@ func (h *OllamaHandlers) ListModels(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, models)
 }
 
+// remoteResponse describes the remote server setting. The token itself is
+// never returned, only whether one is stored.
+type remoteResponse struct {
+	Enabled   bool   `json:"enabled"`
+	Endpoint  string `json:"endpoint,omitempty"`
+	HasToken  bool   `json:"hasToken"`
+	Reachable bool   `json:"reachable"`
+	Version   string `json:"version,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// GetRemote returns the configured remote server and whether it is healthy.
+// GET /api/ollama/remote
+func (h *OllamaHandlers) GetRemote(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, h.remoteStatus(r.Context()))
+}
+
+// SetRemote points ChatML at an external Ollama server, or back at the
+// managed binary when endpoint is empty. An omitted token keeps the stored
+// one; an empty token clears it. The setting is saved even if the server is
+// unreachable right now, and the health check result is returned.
+// PUT /api/ollama/remote
+func (h *OllamaHandlers) SetRemote(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req struct {
+		Endpoint string  `json:"endpoint"`
+		Token    *string `json:"token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeValidationError(w, "invalid request body")
+		return
+	}
+
+	if req.Endpoint == "" {
+		if err := h.store.DeleteSetting(ctx, settingOllamaRemote); err != nil {
+			writeInternalError(w, "failed to remove remote Ollama setting", err)
+			return
+		}
+		h.mgr.SetRemote(nil)
+		log.Printf("ollama: using managed binary")
+		writeJSON(w, h.remoteStatus(ctx))
+		return
+	}
+
+	endpoint, err := ollama.NormalizeEndpoint(req.Endpoint)
+	if err != nil {
+		writeValidationError(w, err.Error())
+		return
+	}
+	token := ""
+	if req.Token != nil {
+		token = *req.Token
+	} else if current, ok := h.mgr.Remote(); ok {
+		token = current.Token
+	}
+
+	saved := storedRemote{Endpoint: endpoint}
+	if token != "" {
+		if saved.Token, err = crypto.Encrypt(token); err != nil {
+			writeInternalError(w, "failed to encrypt token", err)
+			return
+		}
+	}
+	data, _ := json.Marshal(saved)
+	if err := h.store.SetSetting(ctx, settingOllamaRemote, string(data)); err != nil {
+		writeInternalError(w, "failed to save remote Ollama setting", err)
+		return
+	}
+
+	// The managed server is no longer used; don't leave it holding memory.
+	if !h.mgr.IsRemote() {
+		if err := h.mgr.Stop(); err != nil {
+			log.Printf("ollama: failed to stop managed server: %v", err)
+		}
+	}
+	if err := h.mgr.SetRemote(&ollama.RemoteConfig{Endpoint: endpoint, Token: token}); err != nil {
+		writeValidationError(w, err.Error())
+		return
+	}
+	log.Printf("ollama: using remote server %s", endpoint)
+
+	status := h.remoteStatus(ctx)
+	if status.Reachable {
+		go func() {
+			if _, err := h.mgr.DiscoverModels(context.Background()); err != nil {
+				log.Printf("ollama: model discovery failed: %v", err)
+			}
+		}()
+	}
+	writeJSON(w, status)
+}
+
+func (h *OllamaHandlers) remoteStatus(ctx context.Context) remoteResponse {
+	remote, ok := h.mgr.Remote()
+	if !ok {
+		return remoteResponse{}
+	}
+	resp := remoteResponse{Enabled: true, Endpoint: remote.Endpoint, HasToken: remote.Token != ""}
+	version, err := h.mgr.RemoteVersion(ctx)
+	if err != nil {
+		resp.Error = err.Error()
+		return resp
+	}
+	resp.Reachable, resp.Version = true, version
+	return resp
+}
+
+// storedRemote is the persisted form of ollama.RemoteConfig.
+type storedRemote struct {
+	Endpoint string `json:"endpoint"`
+	Token    string `json:"token,omitempty"` // encrypted
+}
+
+// RestoreFromStore applies the persisted remote server setting.
+// Called once at startup.
+func (h *OllamaHandlers) RestoreFromStore(ctx context.Context) {
+	value, found, err := h.store.GetSetting(ctx, settingOllamaRemote)
+	if err != nil || !found {
+		return
+	}
+	var saved storedRemote
+	if err := json.Unmarshal([]byte(value), &saved); err != nil {
+		log.Printf("ollama: ignoring invalid remote setting: %v", err)
+		return
+	}
+	token := ""
+	if saved.Token != "" {
+		if token, err = crypto.Decrypt(saved.Token); err != nil {
+			log.Printf("ollama: failed to decrypt remote token: %v", err)
+			return
+		}
+	}
+	if err := h.mgr.SetRemote(&ollama.RemoteConfig{Endpoint: saved.Endpoint, Token: token}); err != nil {
+		log.Printf("ollama: ignoring remote setting: %v", err)
+		return
+	}
+	log.Printf("ollama: using remote server %s", saved.Endpoint)
+}
+
 // broadcastError sends an error progress event via WebSocket so the frontend
 // can display the failure to the user.
 func (h *OllamaHandlers) broadcastError(eventType, status string) {
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/chatml/chatml-backend/ollama"
+	"github.com/chatml/chatml-core/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func putOllamaRemote(t *testing.T, h *OllamaHandlers, body string) (*httptest.ResponseRecorder, remoteResponse) {
+	t.Helper()
+	req := httptest.NewRequest("PUT", "/api/ollama/remote", bytes.NewReader([]byte(body)))
+	w := httptest.NewRecorder()
+	h.SetRemote(w, req)
+	var resp remoteResponse
+	if w.Code == http.StatusOK {
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	}
+	return w, resp
+}
+
+func TestOllamaHandlers_RemoteRoundTrip(t *testing.T) {
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/version":
+			w.Write([]byte(`{"version":"0.14.1"}`))
+		case "/api/tags":
+			w.Write([]byte(`{"models":[]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer stub.Close()
+
+	s := newTestAuthStore(t)
+	mgr := ollama.NewManager(t.TempDir())
+	h := NewOllamaHandlers(mgr, nil, s)
+
+	w, resp := putOllamaRemote(t, h, `{"endpoint":"`+stub.URL+`/","token":"secret"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.True(t, resp.Enabled)
+	assert.Equal(t, stub.URL, resp.Endpoint)
+	assert.True(t, resp.HasToken)
+	assert.True(t, resp.Reachable)
+	assert.Equal(t, "0.14.1", resp.Version)
+	assert.NotContains(t, w.Body.String(), "secret")
+	assert.Equal(t, stub.URL, mgr.Endpoint())
+
+	// The token is stored encrypted.
+	value, found, err := s.GetSetting(context.Background(), settingOllamaRemote)
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.NotContains(t, value, "secret")
+	var saved storedRemote
+	require.NoError(t, json.Unmarshal([]byte(value), &saved))
+	token, err := crypto.Decrypt(saved.Token)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", token)
+
+	// Omitting the token keeps the stored one.
+	_, resp = putOllamaRemote(t, h, `{"endpoint":"`+stub.URL+`"}`)
+	assert.True(t, resp.Reachable)
+
+	// A fresh manager restores the setting at startup.
+	restored := ollama.NewManager(t.TempDir())
+	NewOllamaHandlers(restored, nil, s).RestoreFromStore(context.Background())
+	assert.Equal(t, stub.URL, restored.Endpoint())
+	assert.Equal(t, "secret", restored.AuthToken())
+
+	// A wrong token is saved but reported as unreachable.
+	_, resp = putOllamaRemote(t, h, `{"endpoint":"`+stub.URL+`","token":"wrong"}`)
+	assert.True(t, resp.Enabled)
+	assert.False(t, resp.Reachable)
+	assert.Contains(t, resp.Error, "rejected the credentials")
+
+	// An empty endpoint returns to the managed binary.
+	_, resp = putOllamaRemote(t, h, `{"endpoint":""}`)
+	assert.False(t, resp.Enabled)
+	assert.False(t, mgr.IsRemote())
+	_, found, _ = s.GetSetting(context.Background(), settingOllamaRemote)
+	assert.False(t, found)
+
+	req := httptest.NewRequest("GET", "/api/ollama/remote", nil)
+	rec := httptest.NewRecorder()
+	h.GetRemote(rec, req)
+	assert.JSONEq(t, `{"enabled":false,"hasToken":false,"reachable":false}`, rec.Body.String())
+}
+
+func TestOllamaHandlers_SetRemote_InvalidEndpoint(t *testing.T) {
+	h := NewOllamaHandlers(ollama.NewManager(t.TempDir()), nil, newTestAuthStore(t))
+
+	w, _ := putOllamaRemote(t, h, `{"endpoint":"ftp://gpu-box"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.False(t, h.mgr.IsRemote())
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/chatml/chatml-backend/logger"
+	"github.com/chatml/chatml-backend/models"
+	"github.com/chatml/chatml-backend/store"
+)
+
+const (
+	defaultPermissionAuditLimit = 200
+	maxPermissionAuditLimit     = 1000
+)
+
+// parsePermissionAuditFilter reads the audit filters shared by the list and
+// export endpoints: workspaceId, sessionId, conversationId, tool, decision,
+// and since/until as RFC 3339 timestamps.
+func parsePermissionAuditFilter(r *http.Request) (store.PermissionAuditFilter, string) {
+	q := r.URL.Query()
+	f := store.PermissionAuditFilter{
+		WorkspaceID:    q.Get("workspaceId"),
+		SessionID:      q.Get("sessionId"),
+		ConversationID: q.Get("conversationId"),
+		ToolName:       q.Get("tool"),
+		Decision:       q.Get("decision"),
+	}
+	switch f.Decision {
+	case "", "allow", "deny":
+	default:
+		return f, "decision must be allow or deny"
+	}
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{{"since", &f.Since}, {"until", &f.Until}} {
+		v := q.Get(p.name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return f, "invalid '" + p.name + "' parameter: expected an RFC 3339 timestamp"
+		}
+		*p.dst = t
+	}
+	return f, ""
+}
+
+// ListPermissionAudit returns recorded permission decisions, newest first.
+// limit defaults to 200 and is capped at 1000.
+func (h *Handlers) ListPermissionAudit(w http.ResponseWriter, r *http.Request) {
+	f, msg := parsePermissionAuditFilter(r)
+	if msg != "" {
+		writeValidationError(w, msg)
+		return
+	}
+	f.Limit = defaultPermissionAuditLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		v, err := strconv.Atoi(limitStr)
+		if err != nil || v < 1 {
+			writeValidationError(w, "invalid 'limit' parameter")
+			return
+		}
+		f.Limit = min(v, maxPermissionAuditLimit)
+	}
+
+	entries, err := h.store.ListPermissionAudit(r.Context(), f)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, entries)
+}
+
+// ExportPermissionAudit streams every matching permission decision as JSON
+// Lines, oldest first, for archiving or feeding into compliance tooling.
+func (h *Handlers) ExportPermissionAudit(w http.ResponseWriter, r *http.Request) {
+	f, msg := parsePermissionAuditFilter(r)
+	if msg != "" {
+		writeValidationError(w, msg)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", `attachment; filename="permission-audit.jsonl"`)
+	enc := json.NewEncoder(w) // Encode terminates each entry with a newline
+	err := h.store.ExportPermissionAudit(r.Context(), f, func(e *models.PermissionAuditEntry) error {
+		return enc.Encode(e)
+	})
+	if err != nil {
+		// Headers are already sent; all we can do is stop and log.
+		logger.Handlers.Errorf("ExportPermissionAudit: %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chatml/chatml-backend/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func seedPermissionAudit(t *testing.T, h *Handlers) {
+	t.Helper()
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i, e := range []models.PermissionAuditEntry{
+		{WorkspaceID: "ws-1", SessionID: "s-1", ConversationID: "c-1", ToolName: "Bash", Specifier: "npm test", Decision: "allow", DecidedBy: "rule:project"},
+		{WorkspaceID: "ws-1", SessionID: "s-1", ConversationID: "c-1", ToolName: "Bash", Specifier: "rm -rf /", Decision: "deny", DecidedBy: "hook:PreToolUse"},
+		{WorkspaceID: "ws-2", SessionID: "s-2", ConversationID: "c-2", ToolName: "Write", Specifier: "main.go", Decision: "allow", DecidedBy: "user", ApprovalAction: "allow_session"},
+	} {
+		e.Timestamp = base.Add(time.Duration(i) * time.Minute)
+		require.NoError(t, h.store.AddPermissionAudit(context.Background(), &e))
+	}
+}
+
+func TestListPermissionAudit_Filters(t *testing.T) {
+	h, _ := setupTestHandlers(t)
+	seedPermissionAudit(t, h)
+
+	list := func(query string) []models.PermissionAuditEntry {
+		t.Helper()
+		w := httptest.NewRecorder()
+		h.ListPermissionAudit(w, httptest.NewRequest("GET", "/api/permission-audit?"+query, nil))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var entries []models.PermissionAuditEntry
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &entries))
+		return entries
+	}
+
+	assert.Len(t, list(""), 3)
+	assert.Len(t, list("workspaceId=ws-1"), 2)
+	assert.Len(t, list("sessionId=s-2"), 1)
+
+	denied := list("tool=Bash&decision=deny")
+	require.Len(t, denied, 1)
+	assert.Equal(t, "hook:PreToolUse", denied[0].DecidedBy)
+
+	recent := list("since=2026-03-01T12:00:30Z&limit=1")
+	require.Len(t, recent, 1)
+	assert.Equal(t, "Write", recent[0].ToolName, "newest first")
+}
+
+func TestListPermissionAudit_InvalidParams(t *testing.T) {
+	h, _ := setupTestHandlers(t)
+
+	for _, query := range []string{"decision=maybe", "since=yesterday", "limit=0"} {
+		w := httptest.NewRecorder()
+		h.ListPermissionAudit(w, httptest.NewRequest("GET", "/api/permission-audit?"+query, nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func TestExportPermissionAudit_JSONL(t *testing.T) {
+	h, _ := setupTestHandlers(t)
+	seedPermissionAudit(t, h)
+
+	w := httptest.NewRecorder()
+	h.ExportPermissionAudit(w, httptest.NewRequest("GET", "/api/permission-audit/export?workspaceId=ws-1", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "permission-audit.jsonl")
+
+	var specifiers []string
+	scanner := bufio.NewScanner(strings.NewReader(w.Body.String()))
+	for scanner.Scan() {
+		var e models.PermissionAuditEntry
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &e))
+		specifiers = append(specifiers, e.Specifier)
+	}
+	assert.Equal(t, []string{"npm test", "rm -rf /"}, specifiers, "oldest first")
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/chatml/chatml-core/paths"
+)
+
+// ManagedPolicyResponse describes the organization-managed settings in
+// effect, so the UI can show which settings are policy-controlled.
+type ManagedPolicyResponse struct {
+	Managed        bool     `json:"managed"`                  // Managed settings exist
+	SettingsDir    string   `json:"settingsDir,omitempty"`    // Where they are read from
+	LockedSettings []string `json:"lockedSettings"`           // Settings user config cannot override
+	PermissionMode string   `json:"permissionMode,omitempty"` // Imposed mode, when locked
+	AllowedModels  []string `json:"allowedModels,omitempty"`
+	Permissions    struct {
+		Allow []string `json:"allow"`
+		Deny  []string `json:"deny"`
+		Ask   []string `json:"ask"`
+	} `json:"permissions"`
+	MCPServers []string `json:"mcpServers"` // Names of managed MCP servers
+	HasHooks   bool     `json:"hasHooks"`
+}
+
+// GetManagedPolicy returns the managed policy and which settings it locks.
+func (h *Handlers) GetManagedPolicy(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, buildManagedPolicyResponse(paths.LoadManagedPolicy()))
+}
+
+func buildManagedPolicyResponse(p *paths.ManagedPolicy) ManagedPolicyResponse {
+	resp := ManagedPolicyResponse{LockedSettings: p.Locked(), MCPServers: []string{}}
+	resp.Permissions.Allow, resp.Permissions.Deny, resp.Permissions.Ask = []string{}, []string{}, []string{}
+	if p == nil {
+		return resp
+	}
+	resp.Managed = true
+	resp.SettingsDir = paths.ManagedSettingsDir()
+	resp.PermissionMode = p.PermissionMode()
+	resp.AllowedModels = p.AllowedModels
+	resp.Permissions.Allow = append(resp.Permissions.Allow, p.Permissions.Allow...)
+	resp.Permissions.Deny = append(resp.Permissions.Deny, p.Permissions.Deny...)
+	resp.Permissions.Ask = append(resp.Permissions.Ask, p.Permissions.Ask...)
+	for name := range p.MCPServers {
+		resp.MCPServers = append(resp.MCPServers, name)
+	}
+	sort.Strings(resp.MCPServers)
+	resp.HasHooks = len(p.Hooks) > 0
+	return resp
+}
+
+// writePolicyLocked writes a 403 for a change to a policy-controlled setting.
+func writePolicyLocked(w http.ResponseWriter, msg string) {
+	writeError(w, http.StatusForbidden, ErrCodePolicyLocked, msg, nil)
+}
+
+// checkPolicyModel returns an error message if managed policy forbids model.
+// "auto" and "" select the default model and are always allowed.
+func checkPolicyModel(model string) string {
+	if model == "" || model == "auto" {
+		return ""
+	}
+	if !paths.LoadManagedPolicy().ModelAllowed(model) {
+		return fmt.Sprintf("model %q is not allowed by managed policy", model)
+	}
+	return ""
+}
+
+// checkPolicyPermissionMode returns an error message if managed policy locks
+// the permission mode to something other than mode.
+func checkPolicyPermissionMode(mode string) string {
+	if mode == "" {
+		return ""
+	}
+	if locked := paths.LoadManagedPolicy().PermissionMode(); locked != "" && locked != mode {
+		return fmt.Sprintf("permission mode is locked to %q by managed policy", locked)
+	}
+	return ""
+}
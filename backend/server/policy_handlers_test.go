@@ -0,0 +1,97 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chatml/chatml-core/paths"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withManagedPolicy installs managed-settings.json content for the test.
+func withManagedPolicy(t *testing.T, settings string) {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "managed-settings.json"), []byte(settings), 0644))
+	t.Cleanup(paths.SetManagedSettingsDir(dir))
+}
+
+func TestGetManagedPolicy_NotManaged(t *testing.T) {
+	t.Cleanup(paths.SetManagedSettingsDir(t.TempDir()))
+	h, _ := setupTestHandlers(t)
+
+	w := httptest.NewRecorder()
+	h.GetManagedPolicy(w, httptest.NewRequest("GET", "/api/settings/managed-policy", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp ManagedPolicyResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.False(t, resp.Managed)
+	assert.Empty(t, resp.LockedSettings)
+}
+
+func TestGetManagedPolicy_ReportsLockedSettings(t *testing.T) {
+	withManagedPolicy(t, `{
+		"permissions": {"deny": ["Bash(curl *)"], "defaultMode": "acceptEdits"},
+		"allowedModels": ["claude-sonnet-*"],
+		"mcpServers": {"corp-docs": {"command": "corp-docs-mcp"}},
+		"lockedSettings": ["permissionMode", "mcpServers"]
+	}`)
+	h, _ := setupTestHandlers(t)
+
+	w := httptest.NewRecorder()
+	h.GetManagedPolicy(w, httptest.NewRequest("GET", "/api/settings/managed-policy", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp ManagedPolicyResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.True(t, resp.Managed)
+	assert.Equal(t, []string{"permissionMode", "allowedModels", "mcpServers"}, resp.LockedSettings)
+	assert.Equal(t, "acceptEdits", resp.PermissionMode)
+	assert.Equal(t, []string{"Bash(curl *)"}, resp.Permissions.Deny)
+	assert.Equal(t, []string{"corp-docs"}, resp.MCPServers)
+}
+
+func TestSetConversationPermissionMode_PolicyLocked(t *testing.T) {
+	withManagedPolicy(t, `{"permissions": {"defaultMode": "default"}, "lockedSettings": ["permissionMode"]}`)
+	h, s := setupTestHandlers(t)
+	createTestRepo(t, s, "ws-1", t.TempDir())
+	createTestSession(t, s, "sess-1", "ws-1")
+	createTestConversation(t, s, "conv-1", "sess-1")
+
+	body, _ := json.Marshal(SetPermissionModeRequest{Mode: "bypassPermissions"})
+	req := httptest.NewRequest("PUT", "/api/conversations/conv-1/permission-mode", bytes.NewReader(body))
+	req = withChiContext(req, map[string]string{"convId": "conv-1"})
+	w := httptest.NewRecorder()
+	h.SetConversationPermissionMode(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), ErrCodePolicyLocked)
+}
+
+func TestSetMcpServers_PolicyLocked(t *testing.T) {
+	withManagedPolicy(t, `{"lockedSettings": ["mcpServers"]}`)
+	h, _ := setupTestHandlers(t)
+
+	body := `[{"name": "mine", "type": "stdio", "command": "my-mcp", "enabled": true}]`
+	req := httptest.NewRequest("PUT", "/api/repos/ws-1/mcp-servers", bytes.NewBufferString(body))
+	req = withChiContext(req, map[string]string{"id": "ws-1"})
+	w := httptest.NewRecorder()
+	h.SetMcpServers(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestCheckPolicyModel(t *testing.T) {
+	withManagedPolicy(t, `{"allowedModels": ["claude-sonnet-*"]}`)
+
+	assert.Empty(t, checkPolicyModel("claude-sonnet-4-6"))
+	assert.Empty(t, checkPolicyModel("auto"))
+	assert.Contains(t, checkPolicyModel("claude-opus-4-7"), "not allowed by managed policy")
+}
@@ -0,0 +1,144 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/chatml/chatml-backend/models"
+	"github.com/go-chi/chi/v5"
+)
+
+// PR automation policy handlers
+
+// maxPRAutomationIterations caps the user-configurable iteration and CI
+// attempt limits.
+const maxPRAutomationIterations = 20
+
+type UpdatePRAutomationPolicyRequest struct {
+	AddressFeedback *bool    `json:"addressFeedback,omitempty"`
+	MaxIterations   *int     `json:"maxIterations,omitempty"`
+	BudgetUSD       *float64 `json:"budgetUsd,omitempty"`
+	FixCI           *bool    `json:"fixCi,omitempty"`
+	MaxCIAttempts   *int     `json:"maxCiAttempts,omitempty"`
+}
+
+// defaultPRAutomationPolicy returns the policy used before a session saves one.
+func defaultPRAutomationPolicy(sessionID string) *models.PRAutomationPolicy {
+	return &models.PRAutomationPolicy{
+		SessionID:     sessionID,
+		MaxIterations: models.DefaultPRAutomationMaxIterations,
+		BudgetUSD:     models.DefaultPRAutomationBudgetUSD,
+		MaxCIAttempts: models.DefaultPRAutomationMaxCIAttempts,
+	}
+}
+
+func (h *Handlers) GetPRAutomationPolicy(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	sessionID := chi.URLParam(r, "sessionId")
+
+	session, err := h.store.GetSession(ctx, sessionID)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	if session == nil {
+		writeNotFound(w, "session")
+		return
+	}
+
+	policy, err := h.store.GetPRAutomationPolicy(ctx, sessionID)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	if policy == nil {
+		policy = defaultPRAutomationPolicy(sessionID)
+	}
+	writeJSON(w, policy)
+}
+
+// UpdatePRAutomationPolicy saves a session's PR automation settings. Saving
+// restarts a loop that was stopped by a limit, with fresh counters; feedback
+// and failing commits already handed to the agent are not re-sent.
+func (h *Handlers) UpdatePRAutomationPolicy(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	sessionID := chi.URLParam(r, "sessionId")
+
+	session, err := h.store.GetSession(ctx, sessionID)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	if session == nil {
+		writeNotFound(w, "session")
+		return
+	}
+
+	var req UpdatePRAutomationPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeValidationError(w, "invalid request body")
+		return
+	}
+	if req.MaxIterations != nil && (*req.MaxIterations < 1 || *req.MaxIterations > maxPRAutomationIterations) {
+		writeValidationError(w, "maxIterations must be between 1 and 20")
+		return
+	}
+	if req.MaxCIAttempts != nil && (*req.MaxCIAttempts < 1 || *req.MaxCIAttempts > maxPRAutomationIterations) {
+		writeValidationError(w, "maxCiAttempts must be between 1 and 20")
+		return
+	}
+	if req.BudgetUSD != nil && *req.BudgetUSD < 0 {
+		writeValidationError(w, "budgetUsd must not be negative")
+		return
+	}
+
+	policy, err := h.store.GetPRAutomationPolicy(ctx, sessionID)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	if policy == nil {
+		policy = defaultPRAutomationPolicy(sessionID)
+	}
+
+	wasFeedbackActive := policy.FeedbackActive()
+	wasCIActive := policy.CIRepairActive()
+	if req.AddressFeedback != nil {
+		policy.AddressFeedback = *req.AddressFeedback
+	}
+	if req.MaxIterations != nil {
+		policy.MaxIterations = *req.MaxIterations
+	}
+	if req.BudgetUSD != nil {
+		policy.BudgetUSD = *req.BudgetUSD
+	}
+	if req.FixCI != nil {
+		policy.FixCI = *req.FixCI
+	}
+	if req.MaxCIAttempts != nil {
+		policy.MaxCIAttempts = *req.MaxCIAttempts
+	}
+	if !wasFeedbackActive {
+		policy.StoppedReason = ""
+		policy.Iterations = 0
+	}
+	if !wasCIActive {
+		policy.CIStoppedReason = ""
+		policy.CIAttempts = 0
+		policy.CIRepeatCount = 0
+	}
+	if !wasFeedbackActive && !wasCIActive {
+		policy.SpentUSD = 0
+	}
+
+	if err := h.store.SavePRAutomationPolicy(ctx, policy); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	if (policy.AddressFeedback || policy.FixCI) && h.autoFix != nil {
+		h.autoFix.Trigger(sessionID)
+	}
+
+	writeJSON(w, policy)
+}
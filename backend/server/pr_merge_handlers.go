@@ -0,0 +1,242 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/chatml/chatml-backend/github"
+	"github.com/chatml/chatml-backend/logger"
+	"github.com/chatml/chatml-backend/models"
+)
+
+// Merge modes for MergePR
+const (
+	mergeModeNow   = "now"   // merge immediately (default)
+	mergeModeAuto  = "auto"  // enable auto-merge; GitHub merges once requirements pass
+	mergeModeQueue = "queue" // add to the base branch's merge queue
+)
+
+// Merge results reported in MergePRResponse.Status
+const (
+	mergeStatusMerged           = "merged"
+	mergeStatusAutoMergeEnabled = "auto_merge_enabled"
+	mergeStatusQueued           = "queued"
+)
+
+type MergePRRequest struct {
+	Method        string `json:"method,omitempty"` // merge, squash, rebase (default: merge)
+	Mode          string `json:"mode,omitempty"`   // now, auto, queue (default: now)
+	CommitTitle   string `json:"commitTitle,omitempty"`
+	CommitMessage string `json:"commitMessage,omitempty"`
+	DeleteBranch  *bool  `json:"deleteBranch,omitempty"` // Delete the remote branch after merging (default: true)
+	Archive       *bool  `json:"archive,omitempty"`      // Archive the session after merging (default: true, except for base sessions)
+}
+
+type MergePRResponse struct {
+	Status    string `json:"status"`
+	SHA       string `json:"sha,omitempty"`
+	HookError string `json:"hookError,omitempty"` // A blocking lifecycle hook failed; branch deletion and archiving were skipped
+	// The remote branch is deleted in the background, once stacked sessions
+	// have been moved off it.
+	BranchDeleteScheduled bool            `json:"branchDeleteScheduled"`
+	Session               *models.Session `json:"session,omitempty"`
+}
+
+// MergePR merges a session's pull request on GitHub. With mode "now" it checks
+// mergeability first, merges pinned to the current head SHA, retargets stacked
+// sessions and deletes the remote branch (both in the background), and
+// archives the session the same way UpdateSession does. Branch deletion and
+// archiving are on unless the request turns them off. Modes "auto" and "queue"
+// only enable auto-merge or enqueue the PR; the PR watcher picks up the merge
+// when it happens.
+func (h *Handlers) MergePR(w http.ResponseWriter, r *http.Request) {
+	var req MergePRRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeValidationError(w, "invalid request body")
+		return
+	}
+	if req.Method == "" {
+		req.Method = string(github.MergeMethodMerge)
+	}
+	method := github.MergeMethod(req.Method)
+	if !method.Valid() {
+		writeValidationError(w, "method must be one of merge, squash, rebase")
+		return
+	}
+	if req.Mode == "" {
+		req.Mode = mergeModeNow
+	}
+	switch req.Mode {
+	case mergeModeNow, mergeModeAuto, mergeModeQueue:
+	default:
+		writeValidationError(w, "mode must be one of now, auto, queue")
+		return
+	}
+
+	ghCtx, ok := h.resolveGitHubContext(w, r)
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+	session := ghCtx.session
+
+	if session.PRNumber == 0 {
+		writeNotFound(w, "no PR for this session")
+		return
+	}
+	archive := !session.IsBaseSession()
+	if req.Archive != nil {
+		if *req.Archive && session.IsBaseSession() {
+			writeValidationError(w, "this session cannot be archived")
+			return
+		}
+		archive = *req.Archive
+	}
+	deleteBranch := req.DeleteBranch == nil || *req.DeleteBranch
+
+	details, err := h.ghClient.GetPRFullDetails(ctx, ghCtx.owner, ghCtx.repo, session.PRNumber)
+	if err != nil {
+		writeBadGateway(w, "failed to fetch PR details", err)
+		return
+	}
+	if msg := mergeBlockedReason(details, req.Mode); msg != "" {
+		writeConflict(w, msg)
+		return
+	}
+
+	switch req.Mode {
+	case mergeModeAuto:
+		if err := h.ghClient.EnableAutoMerge(ctx, details.NodeID, method); err != nil {
+			writeBadGateway(w, "failed to enable auto-merge", err)
+			return
+		}
+		logger.Handlers.Infof("MergePR: enabled auto-merge (%s) for session %s, PR #%d", method, session.ID, session.PRNumber)
+		writeJSON(w, MergePRResponse{Status: mergeStatusAutoMergeEnabled})
+		return
+	case mergeModeQueue:
+		if err := h.ghClient.EnqueuePullRequest(ctx, details.NodeID); err != nil {
+			writeBadGateway(w, "failed to add PR to merge queue", err)
+			return
+		}
+		logger.Handlers.Infof("MergePR: queued session %s, PR #%d", session.ID, session.PRNumber)
+		writeJSON(w, MergePRResponse{Status: mergeStatusQueued})
+		return
+	}
+
+	result, err := h.ghClient.MergePullRequest(ctx, ghCtx.owner, ghCtx.repo, session.PRNumber, github.MergePullRequestRequest{
+		MergeMethod:   method,
+		CommitTitle:   req.CommitTitle,
+		CommitMessage: req.CommitMessage,
+		SHA:           details.HeadSHA,
+	})
+	if err != nil {
+		var mergeErr *github.MergeError
+		if errors.As(err, &mergeErr) {
+			writeConflict(w, mergeErr.Message)
+			return
+		}
+		writeBadGateway(w, "failed to merge pull request", err)
+		return
+	}
+	logger.Handlers.Infof("MergePR: merged session %s, PR #%d (%s) as %s", session.ID, session.PRNumber, method, result.SHA)
+
+	resp := MergePRResponse{Status: mergeStatusMerged, SHA: result.SHA}
+
+	// From here on the merge has happened; failures are logged, not returned.
+
+	// Lifecycle hooks run before cleanup; a blocking failure leaves the branch
+	// and session in place.
+	hookErr := h.RunPostMergeHook(ctx, session.ID)
+	if hookErr == nil && archive {
+		hookErr = h.beginArchive(ctx, session)
+	}
+	if hookErr != nil {
+		resp.HookError = hookErr.Error()
+		archive = false
+	}
+
+	// Move stacked sessions onto this PR's base, then delete its branch: the
+	// order matters because GitHub closes pull requests whose base branch
+	// disappears. Restacking can take a while, so both run in the background.
+	remoteBranch := ""
+	if deleteBranch && details.Branch != "" && hookErr == nil {
+		remoteBranch = details.Branch
+		resp.BranchDeleteScheduled = true
+	}
+	h.goBackground(func() {
+		bgCtx := h.serverCtx
+		if h.restacker != nil {
+			h.restacker.RetargetChildren(bgCtx, session.ID)
+		}
+		if remoteBranch == "" {
+			return
+		}
+		if err := h.ghClient.DeleteBranch(bgCtx, ghCtx.owner, ghCtx.repo, remoteBranch); err != nil {
+			logger.Handlers.Warnf("MergePR: failed to delete remote branch %q: %v", remoteBranch, err)
+		}
+	})
+
+	if err := h.store.UpdateSession(ctx, session.ID, func(s *models.Session) {
+		s.PRStatus = models.PRStatusMerged
+		if archive {
+			s.Archived = true
+		}
+		s.UpdatedAt = time.Now()
+	}); err != nil {
+		logger.Handlers.Errorf("MergePR: failed to update session %s: %v", session.ID, err)
+	}
+	h.prStatusCache.Invalidate(prStatusCacheKey(session.ID))
+
+	if updated, err := h.store.GetSession(ctx, session.ID); err == nil && updated != nil {
+		resp.Session = updated
+		if archive {
+			h.startArchiveSummary(ctx, updated)
+		}
+	}
+
+	// Let the PR watcher observe the merge so the usual pr_merged flow runs
+	// (WebSocket broadcast, post-merge handling).
+	if h.prWatcher != nil {
+		sessionID := session.ID
+		h.goBackground(func() { h.prWatcher.ForceCheckSession(sessionID) })
+	}
+
+	writeJSON(w, resp)
+}
+
+// mergeBlockedReason returns a user-facing reason the PR can't be merged in
+// the given mode, or "" if it can. Required checks and reviews surface through
+// GitHub's mergeable_state: "blocked" means branch protection isn't satisfied.
+func mergeBlockedReason(details *github.PRFullDetails, mode string) string {
+	if details.Merged {
+		return "pull request is already merged"
+	}
+	if details.State != "open" {
+		return "pull request is closed"
+	}
+	if details.IsDraft {
+		return "draft pull requests cannot be merged"
+	}
+	if mode != mergeModeNow {
+		// Auto-merge and the merge queue wait for requirements themselves.
+		if details.NodeID == "" {
+			return "pull request node ID unavailable"
+		}
+		return ""
+	}
+	if details.Mergeable == nil || details.MergeableState == "unknown" {
+		return "GitHub is still computing mergeability; try again shortly"
+	}
+	if !*details.Mergeable || details.MergeableState == "dirty" {
+		return "pull request has merge conflicts"
+	}
+	switch details.MergeableState {
+	case "blocked":
+		return "required checks or reviews have not passed; enable auto-merge to merge once they do"
+	case "behind":
+		return "branch is out of date with its base branch"
+	}
+	return ""
+}
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/chatml/chatml-backend/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergePR_ValidatesRequest(t *testing.T) {
+	h, _ := setupTestHandlers(t)
+
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"invalid json", `{`, "invalid request body"},
+		{"unknown method", `{"method":"octopus"}`, "method must be one of"},
+		{"unknown mode", `{"mode":"later"}`, "mode must be one of"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/api/repos/ws-1/sessions/sess-1/pr/merge", bytes.NewBufferString(tt.body))
+			req = withChiContext(req, map[string]string{"sessionId": "sess-1"})
+			w := httptest.NewRecorder()
+
+			h.MergePR(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			var apiErr APIError
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &apiErr))
+			assert.Contains(t, apiErr.Error, tt.want)
+		})
+	}
+}
+
+func TestMergePR_SessionNotFound(t *testing.T) {
+	h, _ := setupTestHandlers(t)
+
+	req := httptest.NewRequest("POST", "/api/repos/ws-1/sessions/missing/pr/merge", bytes.NewBufferString(`{"method":"squash"}`))
+	req = withChiContext(req, map[string]string{"sessionId": "missing"})
+	w := httptest.NewRecorder()
+
+	h.MergePR(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestMergeBlockedReason(t *testing.T) {
+	yes, no := true, false
+	open := func(mergeable *bool, state string) *github.PRFullDetails {
+		return &github.PRFullDetails{State: "open", NodeID: "PR_1", Mergeable: mergeable, MergeableState: state}
+	}
+
+	tests := []struct {
+		name    string
+		details *github.PRFullDetails
+		mode    string
+		want    string
+	}{
+		{"clean", open(&yes, "clean"), mergeModeNow, ""},
+		{"unstable non-required checks", open(&yes, "unstable"), mergeModeNow, ""},
+		{"already merged", &github.PRFullDetails{State: "closed", Merged: true}, mergeModeNow, "already merged"},
+		{"closed", &github.PRFullDetails{State: "closed"}, mergeModeNow, "closed"},
+		{"draft", &github.PRFullDetails{State: "open", IsDraft: true}, mergeModeAuto, "draft"},
+		{"computing", open(nil, ""), mergeModeNow, "still computing"},
+		{"conflicts", open(&no, "dirty"), mergeModeNow, "merge conflicts"},
+		{"required checks", open(&yes, "blocked"), mergeModeNow, "enable auto-merge"},
+		{"behind", open(&yes, "behind"), mergeModeNow, "out of date"},
+		{"auto waits for checks", open(&yes, "blocked"), mergeModeAuto, ""},
+		{"queue waits for checks", open(nil, "unknown"), mergeModeQueue, ""},
+		{"queue needs node id", &github.PRFullDetails{State: "open"}, mergeModeQueue, "node ID"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mergeBlockedReason(tt.details, tt.mode)
+			if tt.want == "" {
+				assert.Empty(t, got)
+			} else {
+				assert.Contains(t, got, tt.want)
+			}
+		})
+	}
+}
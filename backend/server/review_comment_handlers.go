@@ -1,10 +1,12 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"time"
 
+	"github.com/chatml/chatml-backend/logger"
 	"github.com/chatml/chatml-backend/models"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
@@ -174,6 +176,7 @@ type UpdateReviewCommentRequest struct {
 	Resolved       *bool   `json:"resolved,omitempty"`
 	ResolvedBy     *string `json:"resolvedBy,omitempty"`
 	ResolutionType *string `json:"resolutionType,omitempty"`
+	Reply          *string `json:"reply,omitempty"` // Posted to the linked GitHub review thread
 }
 
 func (h *Handlers) UpdateReviewComment(w http.ResponseWriter, r *http.Request) {
@@ -271,6 +274,22 @@ func (h *Handlers) UpdateReviewComment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Reply on and mirror resolution to the linked GitHub review thread.
+	// Best-effort: the local update already succeeded, and the next review
+	// thread sync retries resolution on mismatch.
+	if h.prWatcher != nil && comment.GitHubThreadID != "" {
+		mirrorCtx, cancel := context.WithTimeout(h.serverCtx, ghFetchTimeout)
+		if req.Reply != nil && *req.Reply != "" {
+			if err := h.prWatcher.ReplyToReviewComment(mirrorCtx, comment, *req.Reply); err != nil {
+				logger.Handlers.Warnf("Failed to reply to GitHub thread for comment %s: %v", commentID, err)
+			}
+		}
+		if err := h.prWatcher.MirrorCommentResolution(mirrorCtx, comment); err != nil {
+			logger.Handlers.Warnf("Failed to mirror resolution of comment %s to GitHub: %v", commentID, err)
+		}
+		cancel()
+	}
+
 	// Broadcast WebSocket event for real-time updates
 	if h.hub != nil {
 		eventType := "comment_updated"
@@ -333,3 +352,101 @@ func (h *Handlers) DeleteReviewComment(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+type PublishReviewCommentsRequest struct {
+	CommentIDs []string `json:"commentIds,omitempty"` // Empty publishes all unresolved, unpublished comments
+}
+
+// PublishReviewComments publishes local review comments to the session's
+// GitHub PR as a pending review.
+func (h *Handlers) PublishReviewComments(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	sessionID := chi.URLParam(r, "sessionId")
+
+	session, err := h.store.GetSession(ctx, sessionID)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	if session == nil {
+		writeNotFound(w, "session")
+		return
+	}
+	if session.PRNumber == 0 {
+		writeValidationError(w, "session has no pull request")
+		return
+	}
+	if h.prWatcher == nil {
+		writeServiceUnavailable(w, "PR watcher not available")
+		return
+	}
+	if h.ghClient == nil || !h.ghClient.IsAuthenticated() {
+		writeUnauthorized(w, "GitHub authentication required to publish review comments")
+		return
+	}
+
+	var req PublishReviewCommentsRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeValidationError(w, "invalid request body")
+			return
+		}
+	}
+
+	result, err := h.prWatcher.PublishReviewComments(ctx, sessionID, req.CommentIDs)
+	if err != nil {
+		writeBadGateway(w, "failed to publish review comments", err)
+		return
+	}
+
+	if h.hub != nil && result.Published > 0 {
+		h.hub.Broadcast(Event{
+			Type:      "comments_published",
+			SessionID: sessionID,
+			Payload:   result,
+		})
+	}
+
+	writeJSON(w, result)
+}
+
+// SyncReviewComments imports the session's GitHub PR review threads immediately
+// instead of waiting for the next PR watcher poll.
+func (h *Handlers) SyncReviewComments(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	sessionID := chi.URLParam(r, "sessionId")
+
+	session, err := h.store.GetSession(ctx, sessionID)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	if session == nil {
+		writeNotFound(w, "session")
+		return
+	}
+	if session.PRNumber == 0 {
+		writeValidationError(w, "session has no pull request")
+		return
+	}
+	if h.prWatcher == nil {
+		writeServiceUnavailable(w, "PR watcher not available")
+		return
+	}
+	if h.ghClient == nil || !h.ghClient.IsAuthenticated() {
+		writeUnauthorized(w, "GitHub authentication required to sync review comments")
+		return
+	}
+
+	if err := h.prWatcher.SyncReviewThreads(ctx, sessionID); err != nil {
+		writeBadGateway(w, "failed to sync review threads", err)
+		return
+	}
+
+	comments, err := h.store.ListReviewComments(ctx, sessionID)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, comments)
+}
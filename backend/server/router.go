@@ -146,6 +146,10 @@ func NewRouter(ctx context.Context, s *store.SQLiteStore, hub *Hub, agentMgr *ag
 	// Dashboard stats endpoints
 	r.Get("/api/stats/spend", h.GetSpendStats)
 
+	// Permission decision audit log (filter by workspace, session, tool, decision)
+	r.Get("/api/permission-audit", h.ListPermissionAudit)
+	r.Get("/api/permission-audit/export", h.ExportPermissionAudit)
+
 	// Scheduled tasks endpoints
 	r.Get("/api/scheduled-tasks", h.ListAllScheduledTasks)
 	r.Route("/api/scheduled-tasks/{taskId}", func(r chi.Router) {
@@ -189,7 +193,10 @@ func NewRouter(ctx context.Context, s *store.SQLiteStore, hub *Hub, agentMgr *ag
 		r.Post("/{id}/sessions/{sessionId}/pr-refresh", h.RefreshPRStatus)
 		r.Post("/{id}/sessions/{sessionId}/pr/report", h.ReportPRCreated)
 		r.Post("/{id}/sessions/{sessionId}/pr/report-merge", h.ReportPRMerged)
+		r.Post("/{id}/sessions/{sessionId}/pr/merge", h.MergePR)
 		r.Post("/{id}/sessions/{sessionId}/pr/unlink", h.UnlinkPR)
+		r.Get("/{id}/sessions/{sessionId}/pr/automation", h.GetPRAutomationPolicy)
+		r.Put("/{id}/sessions/{sessionId}/pr/automation", h.UpdatePRAutomationPolicy)
 		r.Get("/{id}/settings/pr-template", h.GetPRTemplate)
 		r.Put("/{id}/settings/pr-template", h.SetPRTemplate)
 		r.Get("/{id}/settings/review-prompts", h.GetWorkspaceReviewPrompts)
@@ -206,6 +213,7 @@ func NewRouter(ctx context.Context, s *store.SQLiteStore, hub *Hub, agentMgr *ag
 		r.Get("/{id}/sessions/{sessionId}/branch-sync", h.GetSessionBranchSyncStatus)
 		r.Post("/{id}/sessions/{sessionId}/branch-sync", h.SyncSessionBranch)
 		r.Post("/{id}/sessions/{sessionId}/branch-sync/abort", h.AbortSessionSync)
+		r.Post("/{id}/sessions/{sessionId}/restack", h.RestackSession)
 		r.Get("/{id}/sessions/{sessionId}/diff", h.GetSessionFileDiff)
 		r.Get("/{id}/sessions/{sessionId}/diff-summary", h.GetSessionDiffSummary)
 		r.Get("/{id}/sessions/{sessionId}/file-history", h.GetSessionFileHistory)
@@ -233,6 +241,8 @@ func NewRouter(ctx context.Context, s *store.SQLiteStore, hub *Hub, agentMgr *ag
 		r.Get("/{id}/sessions/{sessionId}/comments", h.ListReviewComments)
 		r.With(commentRateLimiter).Post("/{id}/sessions/{sessionId}/comments", h.CreateReviewComment)
 		r.Get("/{id}/sessions/{sessionId}/comments/stats", h.GetReviewCommentStats)
+		r.Post("/{id}/sessions/{sessionId}/comments/publish", h.PublishReviewComments)
+		r.Post("/{id}/sessions/{sessionId}/comments/sync", h.SyncReviewComments)
 		r.Post("/{id}/sessions/{sessionId}/review-scorecards", h.CreateReviewScorecard)
 		r.Get("/{id}/sessions/{sessionId}/review-scorecards", h.ListReviewScorecards)
 		r.Patch("/{id}/sessions/{sessionId}/comments/{commentId}", h.UpdateReviewComment)
@@ -281,6 +291,9 @@ func NewRouter(ctx context.Context, s *store.SQLiteStore, hub *Hub, agentMgr *ag
 		r.Get("/{id}/dot-mcp-trust", h.GetDotMcpTrust)
 		r.Put("/{id}/dot-mcp-trust", h.SetDotMcpTrust)
 		r.Get("/{id}/dot-mcp-info", h.GetDotMcpInfo)
+		// Secret redaction settings for tool output
+		r.Get("/{id}/secret-redaction", h.GetSecretRedaction)
+		r.Put("/{id}/secret-redaction", h.SetSecretRedaction)
 		// Scripts config endpoints
 		r.Get("/{id}/config", h.GetWorkspaceConfig)
 		r.Put("/{id}/config", h.UpdateWorkspaceConfig)
@@ -290,6 +303,7 @@ func NewRouter(ctx context.Context, s *store.SQLiteStore, hub *Hub, agentMgr *ag
 		r.Post("/{id}/sessions/{sessionId}/scripts/setup", h.RunSetupScripts)
 		r.Post("/{id}/sessions/{sessionId}/scripts/stop", h.StopSessionScript)
 		r.Get("/{id}/sessions/{sessionId}/scripts/runs", h.ListScriptRuns)
+		r.Get("/{id}/sessions/{sessionId}/scripts/services", h.ListScriptServices)
 		// Scheduled task endpoints (workspace-scoped creation)
 		r.Get("/{id}/scheduled-tasks", h.ListWorkspaceScheduledTasks)
 		r.Post("/{id}/scheduled-tasks", h.CreateScheduledTask)
@@ -306,14 +320,20 @@ func NewRouter(ctx context.Context, s *store.SQLiteStore, hub *Hub, agentMgr *ag
 		r.With(messageRateLimiter).Post("/{convId}/messages", h.SendConversationMessage)
 		r.Post("/{convId}/system-message", h.AddSystemMessage)
 		r.Post("/{convId}/stop", h.StopConversation)
+		r.Get("/{convId}/tasks", h.ListConversationTasks)
 		r.Post("/{convId}/tasks/{taskId}/stop", h.StopTask)
 		r.Get("/{convId}/streaming-snapshot", h.GetStreamingSnapshot)
 		r.Get("/{convId}/drop-stats", h.GetConversationDropStats)
 		r.Post("/{convId}/rewind", h.RewindConversation)
+		r.Post("/{convId}/fork", h.ForkConversation)
 		r.Post("/{convId}/plan-mode", h.SetConversationPlanMode)
 		r.Post("/{convId}/permission-mode", h.SetConversationPermissionMode)
 		r.Post("/{convId}/fast-mode", h.SetConversationFastMode)
 		r.Post("/{convId}/max-thinking-tokens", h.SetConversationMaxThinkingTokens)
+		r.Post("/{convId}/compact", h.CompactConversation)
+		r.Get("/{convId}/context", h.GetConversationContext)
+		r.Get("/{convId}/working-memory", h.GetWorkingMemory)
+		r.Put("/{convId}/working-memory", h.SaveWorkingMemory)
 		r.Post("/{convId}/approve-plan", h.ApprovePlan)
 		r.Post("/{convId}/approve-tool", h.ApproveTool)
 		r.Post("/{convId}/approve-batch-tools", h.ApproveBatchTools)
@@ -346,6 +366,7 @@ func NewRouter(ctx context.Context, s *store.SQLiteStore, hub *Hub, agentMgr *ag
 	r.Put("/api/settings/action-templates", h.SetActionTemplates)
 	r.Get("/api/settings/claude-auth-status", h.GetClaudeAuthStatus)
 	r.Get("/api/settings/claude-env", h.GetClaudeEnv)
+	r.Get("/api/settings/managed-policy", h.GetManagedPolicy)
 	r.Get("/api/settings/never-load-dot-mcp", h.GetNeverLoadDotMcp)
 	r.Put("/api/settings/never-load-dot-mcp", h.SetNeverLoadDotMcp)
 	r.Post("/api/settings/aws-auth-refresh", h.RefreshAWSCredentials)
@@ -374,13 +395,15 @@ func NewRouter(ctx context.Context, s *store.SQLiteStore, hub *Hub, agentMgr *ag
 
 	// Ollama lifecycle endpoints (local model management)
 	if ollamaMgr != nil {
-		ollamaH := NewOllamaHandlers(ollamaMgr, hub)
+		ollamaH := NewOllamaHandlers(ollamaMgr, hub, s)
 		r.Route("/api/ollama", func(r chi.Router) {
 			r.Get("/status", ollamaH.GetStatus)
 			r.Post("/install", ollamaH.Install)
 			r.Post("/pull", ollamaH.Pull)
 			r.Post("/stop", ollamaH.Stop)
 			r.Get("/models", ollamaH.ListModels)
+			r.Get("/remote", ollamaH.GetRemote)
+			r.Put("/remote", ollamaH.SetRemote)
 		})
 	}
 
@@ -149,6 +149,16 @@ func (h *Handlers) RunScript(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if script.Service {
+		runID, err := h.scriptRunner.StartService(context.Background(), sessionID, workingPath, req.ScriptKey, config.RunScripts)
+		if err != nil {
+			writeConflict(w, err.Error())
+			return
+		}
+		writeJSON(w, map[string]string{"runId": runID})
+		return
+	}
+
 	runID, err := h.scriptRunner.RunScript(context.Background(), sessionID, workingPath, req.ScriptKey, script)
 	if err != nil {
 		writeInternalError(w, "failed to start script", err)
@@ -245,3 +255,22 @@ func (h *Handlers) ListScriptRuns(w http.ResponseWriter, r *http.Request) {
 
 	writeJSON(w, runs)
 }
+
+// ListScriptServices returns the long-running services started in a session
+func (h *Handlers) ListScriptServices(w http.ResponseWriter, r *http.Request) {
+	if h.scriptRunner == nil {
+		writeJSON(w, []interface{}{})
+		return
+	}
+
+	sessionID := chi.URLParam(r, "sessionId")
+	writeJSON(w, h.scriptRunner.GetSessionServices(sessionID))
+}
+
+// stopSessionScripts stops a session's running scripts and services, releasing
+// their ports. Called when the session is archived or deleted.
+func (h *Handlers) stopSessionScripts(sessionID string) {
+	if h.scriptRunner != nil {
+		h.scriptRunner.CancelSessionRuns(sessionID)
+	}
+}
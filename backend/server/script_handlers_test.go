@@ -0,0 +1,65 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/chatml/chatml-core/scripts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func listServices(t *testing.T, h *Handlers, sessionID string) []scripts.Service {
+	t.Helper()
+	req := httptest.NewRequest("GET", "/api/repos/ws-1/sessions/"+sessionID+"/scripts/services", nil)
+	req = withChiContext(req, map[string]string{"id": "ws-1", "sessionId": sessionID})
+	w := httptest.NewRecorder()
+	h.ListScriptServices(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var services []scripts.Service
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &services))
+	return services
+}
+
+func TestRunScript_StartsServiceAndArchiveStopsIt(t *testing.T) {
+	h, s := setupTestHandlers(t)
+	repo := createTestRepo(t, s, "ws-1", createTestGitRepo(t))
+	require.NoError(t, scripts.WriteConfig(repo.Path, &scripts.ChatMLConfig{
+		RunScripts: map[string]scripts.ScriptDef{
+			"dev": {Name: "Dev", Command: `echo "ready on $PORT"; sleep 30`, Service: true, Ready: &scripts.ReadyProbe{Log: "ready on"}},
+		},
+	}))
+	h.scriptRunner = scripts.NewRunner(nil, nil, nil)
+	sess := createSessionViaHandler(t, h, repo.ID, CreateSessionRequest{Name: "svc"})
+
+	body, _ := json.Marshal(map[string]string{"scriptKey": "dev"})
+	req := httptest.NewRequest("POST", "/api/repos/ws-1/sessions/"+sess.ID+"/scripts/run", bytes.NewReader(body))
+	req = withChiContext(req, map[string]string{"id": repo.ID, "sessionId": sess.ID})
+	w := httptest.NewRecorder()
+	h.RunScript(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	require.Eventually(t, func() bool {
+		services := listServices(t, h, sess.ID)
+		return len(services) == 1 && services[0].Status == scripts.ServiceStatusReady
+	}, 5*time.Second, 20*time.Millisecond)
+	services := listServices(t, h, sess.ID)
+	assert.Equal(t, "dev", services[0].Key)
+	assert.NotZero(t, services[0].Ports["PORT"])
+
+	// Starting it again while it runs is a conflict
+	req = httptest.NewRequest("POST", "/api/repos/ws-1/sessions/"+sess.ID+"/scripts/run", bytes.NewReader(body))
+	req = withChiContext(req, map[string]string{"id": repo.ID, "sessionId": sess.ID})
+	w = httptest.NewRecorder()
+	h.RunScript(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = archiveRequest(h, sess.ID)
+	require.Less(t, w.Code, 300, w.Body.String())
+	assert.Empty(t, listServices(t, h, sess.ID))
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/chatml/chatml-backend/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSecretRedaction_Default(t *testing.T) {
+	h, _ := setupTestHandlers(t)
+
+	req := httptest.NewRequest("GET", "/api/repos/ws-1/secret-redaction", nil)
+	req = withChiContext(req, map[string]string{"id": "ws-1"})
+	w := httptest.NewRecorder()
+
+	h.GetSecretRedaction(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response models.SecretRedactionSettings
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.True(t, response.Enabled)
+	assert.Empty(t, response.Allowlist)
+	assert.Contains(t, w.Body.String(), `"allowlist":[]`)
+}
+
+func TestSetSecretRedaction_RoundTrip(t *testing.T) {
+	h, s := setupTestHandlers(t)
+
+	body, _ := json.Marshal(models.SecretRedactionSettings{Enabled: false, Allowlist: []string{" sk-test-* ", "", "rule:high_entropy"}})
+	req := httptest.NewRequest("PUT", "/api/repos/ws-1/secret-redaction", bytes.NewReader(body))
+	req = withChiContext(req, map[string]string{"id": "ws-1"})
+	w := httptest.NewRecorder()
+
+	h.SetSecretRedaction(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	raw, found, err := s.GetSetting(context.Background(), "secret-redaction:ws-1")
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.JSONEq(t, `{"enabled":false,"allowlist":["sk-test-*","rule:high_entropy"]}`, raw)
+
+	req = httptest.NewRequest("GET", "/api/repos/ws-1/secret-redaction", nil)
+	req = withChiContext(req, map[string]string{"id": "ws-1"})
+	w = httptest.NewRecorder()
+	h.GetSecretRedaction(w, req)
+
+	var response models.SecretRedactionSettings
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.False(t, response.Enabled)
+	assert.Equal(t, []string{"sk-test-*", "rule:high_entropy"}, response.Allowlist)
+}
+
+func TestSetSecretRedaction_RejectsCatchAll(t *testing.T) {
+	h, _ := setupTestHandlers(t)
+
+	req := httptest.NewRequest("PUT", "/api/repos/ws-1/secret-redaction", bytes.NewBufferString(`{"enabled":true,"allowlist":["*"]}`))
+	req = withChiContext(req, map[string]string{"id": "ws-1"})
+	w := httptest.NewRecorder()
+
+	h.SetSecretRedaction(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestSetSecretRedaction_InvalidBody(t *testing.T) {
+	h, _ := setupTestHandlers(t)
+
+	req := httptest.NewRequest("PUT", "/api/repos/ws-1/secret-redaction", bytes.NewBufferString(`not json`))
+	req = withChiContext(req, map[string]string{"id": "ws-1"})
+	w := httptest.NewRecorder()
+
+	h.SetSecretRedaction(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -154,6 +154,9 @@ type CreateSessionRequest struct {
 	SystemMessage string `json:"systemMessage,omitempty"`
 	// SessionType is "worktree" (default) or "base" — base sessions operate on the repo directly
 	SessionType string `json:"sessionType,omitempty"`
+	// ParentSessionID is optional - stacks the new session on another session's branch.
+	// The branch starts from the parent's tip and PRs target the parent branch.
+	ParentSessionID string `json:"parentSessionId,omitempty"`
 }
 
 // initBaseSession creates a base session with its initial conversation and setup message,
@@ -278,6 +281,28 @@ func (h *Handlers) CreateSession(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Resolve the parent of a stacked session before any git work
+	var parent *models.Session
+	if req.ParentSessionID != "" {
+		if req.SessionType == models.SessionTypeBase || req.CheckoutExisting || req.TargetBranch != "" {
+			writeValidationError(w, "parentSessionId cannot be combined with sessionType 'base', checkoutExisting or targetBranch")
+			return
+		}
+		parent, err = h.store.GetSession(ctx, req.ParentSessionID)
+		if err != nil {
+			writeDBError(w, err)
+			return
+		}
+		if parent == nil || parent.WorkspaceID != workspaceID {
+			writeNotFound(w, "parent session")
+			return
+		}
+		if parent.IsBaseSession() || parent.Archived || parent.Branch == "" {
+			writeValidationError(w, "parent session must be an active worktree session")
+			return
+		}
+	}
+
 	// ─── Base session fast path ───
 	// Base sessions operate directly on the repo checkout: no worktree, no branch creation.
 	if req.SessionType == models.SessionTypeBase {
@@ -332,7 +357,14 @@ func (h *Handlers) CreateSession(w http.ResponseWriter, r *http.Request) {
 		remote = "origin"
 	}
 
+	// Stacked sessions branch from the parent's local branch; their stored
+	// target is the parent's remote branch so PRs are opened against it.
 	targetBranch := req.TargetBranch
+	sessionTarget := req.TargetBranch
+	if parent != nil {
+		targetBranch = parent.Branch
+		sessionTarget = remote + "/" + parent.Branch
+	}
 	if targetBranch == "" {
 		targetBranch = remote + "/" + repo.Branch
 		if targetBranch == remote+"/" {
@@ -523,20 +555,28 @@ func (h *Handlers) CreateSession(w http.ResponseWriter, r *http.Request) {
 	now := time.Now()
 
 	sess := &models.Session{
-		ID:            sessionID,
-		WorkspaceID:   workspaceID,
-		Name:          sessionName,
-		Branch:        branchName,
-		WorktreePath:  worktreePath,
-		BaseCommitSHA: baseCommitSHA,
-		TargetBranch:  req.TargetBranch,
-		Task:          req.Task,
-		Status:        "idle",
-		PRStatus:      "none",
-		Priority:      models.PriorityNone,
-		TaskStatus:    models.TaskStatusInProgress,
-		CreatedAt:     now,
-		UpdatedAt:     now,
+		ID:              sessionID,
+		WorkspaceID:     workspaceID,
+		Name:            sessionName,
+		Branch:          branchName,
+		WorktreePath:    worktreePath,
+		BaseCommitSHA:   baseCommitSHA,
+		TargetBranch:    sessionTarget,
+		ParentSessionID: req.ParentSessionID,
+		Task:            req.Task,
+		Status:          "idle",
+		PRStatus:        "none",
+		Priority:        models.PriorityNone,
+		TaskStatus:      models.TaskStatusInProgress,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+
+	// Run the pre-session hook before the session becomes visible. A blocking
+	// failure rolls back the worktree.
+	if err := h.runLifecycleHook(scripts.HookPreSession, repo.Path, sess); err != nil {
+		writeConflict(w, err.Error())
+		return
 	}
 
 	if err := h.store.AddSession(ctx, sess); err != nil {
@@ -697,6 +737,14 @@ func (h *Handlers) UpdateSession(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The session is ending: run the post-session hook, which may veto it
+	if req.Archived != nil && *req.Archived && !session.Archived {
+		if err := h.beginArchive(ctx, session); err != nil {
+			writeConflict(w, err.Error())
+			return
+		}
+	}
+
 	// If archiving, check if session has any messages. Delete blank sessions instead.
 	if req.Archived != nil && *req.Archived {
 		hasMessages, msgErr := h.store.SessionHasMessages(ctx, id)
@@ -737,6 +785,10 @@ func (h *Handlers) UpdateSession(w http.ResponseWriter, r *http.Request) {
 			s.Status = *req.Status
 		}
 		if req.TargetBranch != nil {
+			// Pointing a stacked session at another branch takes it off the stack
+			if *req.TargetBranch != s.TargetBranch {
+				s.ParentSessionID = ""
+			}
 			s.TargetBranch = *req.TargetBranch
 		}
 		if req.PRStatus != nil {
@@ -780,17 +832,7 @@ func (h *Handlers) UpdateSession(w http.ResponseWriter, r *http.Request) {
 
 	// Trigger archive summary generation when session is being archived
 	if req.Archived != nil && *req.Archived {
-		if aiClient := h.getAIClient(); aiClient != nil {
-			// Set generating status synchronously so the frontend sees it immediately
-			if err := h.store.UpdateSession(ctx, id, func(s *models.Session) {
-				s.ArchiveSummaryStatus = models.SummaryStatusGenerating
-			}); err != nil {
-				logger.Error.Errorf("Failed to set generating status for session %s: %v", id, err)
-			} else {
-				session.ArchiveSummaryStatus = models.SummaryStatusGenerating
-				h.goBackground(func() { h.generateArchiveSummary(id, aiClient) })
-			}
-		}
+		h.startArchiveSummary(ctx, session)
 	}
 
 	// Delete local branch on archive if requested
@@ -839,6 +881,36 @@ func (h *Handlers) UpdateSession(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, session)
 }
 
+// beginArchive runs the steps that precede marking a session archived: the
+// post-session hook, which may veto archiving by returning an error, then
+// stopping the session's scripts.
+func (h *Handlers) beginArchive(ctx context.Context, session *models.Session) error {
+	if err := h.runSessionLifecycleHook(ctx, scripts.HookPostSession, session); err != nil {
+		return err
+	}
+	h.stopSessionScripts(session.ID)
+	return nil
+}
+
+// startArchiveSummary kicks off summary generation for a session that was just
+// archived. It is a no-op when no AI client is configured.
+func (h *Handlers) startArchiveSummary(ctx context.Context, session *models.Session) {
+	aiClient := h.getAIClient()
+	if aiClient == nil {
+		return
+	}
+	id := session.ID
+	// Set generating status synchronously so the frontend sees it immediately
+	if err := h.store.UpdateSession(ctx, id, func(s *models.Session) {
+		s.ArchiveSummaryStatus = models.SummaryStatusGenerating
+	}); err != nil {
+		logger.Error.Errorf("Failed to set generating status for session %s: %v", id, err)
+		return
+	}
+	session.ArchiveSummaryStatus = models.SummaryStatusGenerating
+	h.goBackground(func() { h.generateArchiveSummary(id, aiClient) })
+}
+
 // generateArchiveSummary fetches all conversations for a session and generates a combined summary.
 func (h *Handlers) generateArchiveSummary(sessionID string, aiClient ai.Provider) {
 	bgCtx, cancel := context.WithTimeout(h.serverCtx, 90*time.Second)
@@ -960,6 +1032,17 @@ func (h *Handlers) DeleteSession(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Archived sessions already ran their post-session hook
+	if sess != nil && !sess.Archived {
+		if err := h.runSessionLifecycleHook(ctx, scripts.HookPostSession, sess); err != nil {
+			writeConflict(w, err.Error())
+			return
+		}
+	}
+	if sess != nil {
+		h.stopSessionScripts(sessionID)
+	}
+
 	// Track worktree path for locking - we need to hold the lock through DB deletion
 	var worktreePath string
 	if sess != nil && sess.WorktreePath != "" {
@@ -1231,3 +1231,116 @@ func TestResolveRepoBranchPrefix_Unknown(t *testing.T) {
 	repo := &models.Repo{BranchPrefix: "something-unknown"}
 	assert.Equal(t, "session", h.resolveRepoBranchPrefix(repo))
 }
+
+// createSessionViaHandler creates a worktree session through the CreateSession handler.
+func createSessionViaHandler(t *testing.T, h *Handlers, workspaceID string, body CreateSessionRequest) *models.Session {
+	t.Helper()
+
+	raw, _ := json.Marshal(body)
+	req := httptest.NewRequest("POST", "/api/repos/"+workspaceID+"/sessions", bytes.NewReader(raw))
+	req = withChiContext(req, map[string]string{"id": workspaceID})
+	w := httptest.NewRecorder()
+	h.CreateSession(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var sess models.Session
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &sess))
+	return &sess
+}
+
+func TestCreateSession_StackedOnParent(t *testing.T) {
+	h, s := setupTestHandlers(t)
+	repo := createTestRepo(t, s, "ws-1", createTestGitRepo(t))
+
+	parent := createSessionViaHandler(t, h, repo.ID, CreateSessionRequest{Name: "stack-parent"})
+	writeFile(t, parent.WorktreePath, "parent.txt", "parent work")
+	runGit(t, parent.WorktreePath, "add", ".")
+	runGit(t, parent.WorktreePath, "commit", "-m", "Parent work")
+	out, err := exec.Command("git", "-C", parent.WorktreePath, "rev-parse", "HEAD").Output()
+	require.NoError(t, err)
+	parentTip := strings.TrimSpace(string(out))
+
+	child := createSessionViaHandler(t, h, repo.ID, CreateSessionRequest{Name: "stack-child", ParentSessionID: parent.ID})
+
+	assert.Equal(t, parent.ID, child.ParentSessionID)
+	assert.Equal(t, "origin/"+parent.Branch, child.TargetBranch, "PRs should target the parent branch")
+	assert.Equal(t, parentTip, child.BaseCommitSHA, "child should branch from the parent's tip")
+
+	// The parent advances; the child reports itself behind the parent's local branch
+	writeFile(t, parent.WorktreePath, "parent2.txt", "more parent work")
+	runGit(t, parent.WorktreePath, "add", ".")
+	runGit(t, parent.WorktreePath, "commit", "-m", "Parent follow-up")
+
+	req := httptest.NewRequest("GET", "/api/repos/ws-1/sessions/"+child.ID+"/branch-sync", nil)
+	req = withChiContext(req, map[string]string{"id": repo.ID, "sessionId": child.ID})
+	w := httptest.NewRecorder()
+	h.GetSessionBranchSyncStatus(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var status models.BranchSyncStatus
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+	assert.Equal(t, parent.Branch, status.BaseBranch)
+	assert.Equal(t, 1, status.BehindBy)
+}
+
+func TestCreateSession_StackedParentNotFound(t *testing.T) {
+	h, s := setupTestHandlers(t)
+	createTestRepo(t, s, "ws-1", "/path/to/repo")
+
+	body, _ := json.Marshal(CreateSessionRequest{ParentSessionID: "missing"})
+	req := httptest.NewRequest("POST", "/api/repos/ws-1/sessions", bytes.NewReader(body))
+	req = withChiContext(req, map[string]string{"id": "ws-1"})
+	w := httptest.NewRecorder()
+	h.CreateSession(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestCreateSession_StackedRejectsTargetBranch(t *testing.T) {
+	h, s := setupTestHandlers(t)
+	createTestRepo(t, s, "ws-1", "/path/to/repo")
+	createTestSession(t, s, "parent", "ws-1")
+
+	body, _ := json.Marshal(CreateSessionRequest{ParentSessionID: "parent", TargetBranch: "origin/develop"})
+	req := httptest.NewRequest("POST", "/api/repos/ws-1/sessions", bytes.NewReader(body))
+	req = withChiContext(req, map[string]string{"id": "ws-1"})
+	w := httptest.NewRecorder()
+	h.CreateSession(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestUpdateSession_TargetBranchChangeUnstacks(t *testing.T) {
+	h, s := setupTestHandlers(t)
+	createTestRepo(t, s, "ws-1", "/path/to/repo")
+	createTestSession(t, s, "parent", "ws-1")
+	createTestSession(t, s, "child", "ws-1")
+	require.NoError(t, s.UpdateSession(context.Background(), "child", func(sess *models.Session) {
+		sess.ParentSessionID = "parent"
+		sess.TargetBranch = "origin/feature/parent"
+	}))
+
+	target := "origin/develop"
+	body, _ := json.Marshal(UpdateSessionRequest{TargetBranch: &target})
+	req := httptest.NewRequest("PATCH", "/api/repos/ws-1/sessions/child", bytes.NewReader(body))
+	req = withChiContext(req, map[string]string{"id": "ws-1", "sessionId": "child"})
+	w := httptest.NewRecorder()
+	h.UpdateSession(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	got, err := s.GetSession(context.Background(), "child")
+	require.NoError(t, err)
+	assert.Equal(t, "origin/develop", got.TargetBranch)
+	assert.Empty(t, got.ParentSessionID)
+}
+
+func TestRestackSession_NotFound(t *testing.T) {
+	h, _ := setupTestHandlers(t)
+
+	req := httptest.NewRequest("POST", "/api/repos/ws-1/sessions/missing/restack", nil)
+	req = withChiContext(req, map[string]string{"id": "ws-1", "sessionId": "missing"})
+	w := httptest.NewRecorder()
+	h.RestackSession(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
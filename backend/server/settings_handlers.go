@@ -16,6 +16,7 @@ import (
 	"github.com/chatml/chatml-backend/agent"
 	"github.com/chatml/chatml-backend/ai"
 	"github.com/chatml/chatml-core/crypto"
+	"github.com/chatml/chatml-core/paths"
 	"github.com/chatml/chatml-backend/models"
 	"github.com/chatml/chatml-backend/store"
 	"github.com/go-chi/chi/v5"
@@ -785,6 +786,11 @@ func (h *Handlers) SetMcpServers(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	repoID := chi.URLParam(r, "id")
 
+	if paths.LoadManagedPolicy().IsLocked(paths.LockMCPServers) {
+		writePolicyLocked(w, "MCP servers are controlled by managed policy")
+		return
+	}
+
 	var servers []models.McpServerConfig
 	if err := json.NewDecoder(r.Body).Decode(&servers); err != nil {
 		writeValidationError(w, "invalid request body")
@@ -828,6 +834,75 @@ func (h *Handlers) SetMcpServers(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, servers)
 }
 
+// settingKeySecretRedaction returns the settings key for secret redaction in a workspace.
+func settingKeySecretRedaction(workspaceID string) string {
+	return "secret-redaction:" + workspaceID
+}
+
+// GetSecretRedaction returns the secret redaction settings for a workspace.
+func (h *Handlers) GetSecretRedaction(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	repoID := chi.URLParam(r, "id")
+
+	raw, found, err := h.store.GetSetting(ctx, settingKeySecretRedaction(repoID))
+	if err != nil {
+		writeInternalError(w, "failed to get secret redaction settings", err)
+		return
+	}
+
+	settings := models.SecretRedactionSettings{Enabled: true, Allowlist: []string{}}
+	if found && raw != "" {
+		if err := json.Unmarshal([]byte(raw), &settings); err != nil {
+			writeInternalError(w, "failed to parse secret redaction settings", err)
+			return
+		}
+		if settings.Allowlist == nil {
+			settings.Allowlist = []string{}
+		}
+	}
+
+	writeJSON(w, settings)
+}
+
+// SetSecretRedaction saves the secret redaction settings for a workspace.
+func (h *Handlers) SetSecretRedaction(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	repoID := chi.URLParam(r, "id")
+
+	var settings models.SecretRedactionSettings
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+		writeValidationError(w, "invalid request body")
+		return
+	}
+
+	allowlist := make([]string, 0, len(settings.Allowlist))
+	for _, entry := range settings.Allowlist {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if entry == "*" || entry == "rule:" {
+			writeValidationError(w, fmt.Sprintf("allowlist entry %q would disable redaction entirely; set enabled to false instead", entry))
+			return
+		}
+		allowlist = append(allowlist, entry)
+	}
+	settings.Allowlist = allowlist
+
+	data, err := json.Marshal(settings)
+	if err != nil {
+		writeInternalError(w, "failed to serialize secret redaction settings", err)
+		return
+	}
+
+	if err := h.store.SetSetting(ctx, settingKeySecretRedaction(repoID), string(data)); err != nil {
+		writeInternalError(w, "failed to save secret redaction settings", err)
+		return
+	}
+
+	writeJSON(w, settings)
+}
+
 // settingKeyEnabledAgents returns the settings key for enabled agents in a workspace.
 func settingKeyEnabledAgents(workspaceID string) string {
 	return "enabled-agents:" + workspaceID
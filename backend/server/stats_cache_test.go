@@ -291,43 +291,3 @@ func TestSessionLockManager_ConcurrentSamePath(t *testing.T) {
 	assert.Equal(t, int64(numGoroutines*increments), atomic.LoadInt64(&counter),
 		"counter should equal total increments if mutual exclusion holds")
 }
-
-// ---------------------------------------------------------------------------
-// truncateLogLines tests
-// ---------------------------------------------------------------------------
-
-func TestTruncateLogLines_UnderLimit(t *testing.T) {
-	logs := "line1\nline2\nline3"
-	result, totalLines, truncated := truncateLogLines(logs, 10)
-
-	assert.Equal(t, logs, result, "logs under limit should be returned unchanged")
-	assert.Equal(t, 3, totalLines, "totalLines should reflect actual line count")
-	assert.False(t, truncated, "should not be truncated when under limit")
-}
-
-func TestTruncateLogLines_OverLimit(t *testing.T) {
-	logs := "line1\nline2\nline3\nline4\nline5"
-	result, totalLines, truncated := truncateLogLines(logs, 3)
-
-	assert.Equal(t, "line3\nline4\nline5", result, "should return last N lines")
-	assert.Equal(t, 5, totalLines, "totalLines should reflect original count")
-	assert.True(t, truncated, "should be marked as truncated")
-}
-
-func TestTruncateLogLines_ExactLimit(t *testing.T) {
-	logs := "line1\nline2\nline3"
-	result, totalLines, truncated := truncateLogLines(logs, 3)
-
-	assert.Equal(t, logs, result, "logs at exact limit should be returned unchanged")
-	assert.Equal(t, 3, totalLines, "totalLines should equal maxLines")
-	assert.False(t, truncated, "should not be truncated when exactly at limit")
-}
-
-func TestTruncateLogLines_EmptyString(t *testing.T) {
-	result, totalLines, truncated := truncateLogLines("", 10)
-
-	assert.Equal(t, "", result, "empty string should return empty")
-	// strings.Split("", "\n") returns [""], so totalLines is 1
-	assert.Equal(t, 1, totalLines, "empty string splits into one element")
-	assert.False(t, truncated, "empty string should not be truncated")
-}
@@ -44,6 +44,12 @@ const (
 var upgrader = websocket.Upgrader{
 	CheckOrigin: func(r *http.Request) bool {
 		origin := r.Header.Get("Origin")
+		// Browsers always send Origin on WebSocket upgrades. Without one the
+		// caller is a non-browser client (e.g. the terminal client), which
+		// HandleWebSocket has already authenticated with the token.
+		if origin == "" && os.Getenv("CHATML_AUTH_TOKEN") != "" {
+			return true
+		}
 		return AllowedOriginsMap[origin]
 	},
 }
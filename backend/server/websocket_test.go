@@ -627,6 +627,33 @@ func TestHandleWebSocket_ValidToken(t *testing.T) {
 	assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
 }
 
+func TestHandleWebSocket_NonBrowserClientWithToken(t *testing.T) {
+	testToken := "test-secret-token-12345"
+	os.Setenv("CHATML_AUTH_TOKEN", testToken)
+	defer os.Unsetenv("CHATML_AUTH_TOKEN")
+
+	hub := NewHub()
+	go hub.Run()
+	defer func() { time.Sleep(10 * time.Millisecond) }()
+
+	server := httptest.NewServer(http.HandlerFunc(hub.HandleWebSocket))
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "?token=" + testToken
+
+	// No Origin header (as sent by non-browser clients) and no allowed-origin override
+	conn, resp, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	require.NoError(t, err, "Expected a non-browser client with a valid token to connect")
+	defer conn.Close()
+	assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+
+	// A browser origin that is not allowed is still rejected
+	header := http.Header{"Origin": {"https://evil.example.com"}}
+	_, resp, err = websocket.DefaultDialer.Dial(wsURL, header)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
+
 func TestHandleWebSocket_InvalidToken(t *testing.T) {
 	// Set expected token
 	testToken := "test-secret-token-12345"
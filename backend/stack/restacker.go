@@ -0,0 +1,244 @@
+// Package stack keeps stacked sessions — sessions whose branch is based on
+// another session's branch — in line with their parent. When a parent branch
+// moves, its children are rebased onto the new tip (and their children after
+// them); when a parent's PR merges, its children are retargeted to the branch
+// the parent merged into.
+package stack
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/chatml/chatml-backend/logger"
+	"github.com/chatml/chatml-backend/models"
+	"github.com/chatml/chatml-core/git"
+)
+
+// Store is the persistence needed by the restacker.
+type Store interface {
+	GetSessionWithWorkspace(ctx context.Context, id string) (*models.SessionWithWorkspace, error)
+	ListChildSessions(ctx context.Context, parentID string) ([]*models.Session, error)
+	UpdateSession(ctx context.Context, id string, fn func(*models.Session)) error
+}
+
+// GitOps is the git access needed by the restacker (implemented by git.RepoManager).
+type GitOps interface {
+	RestackOnto(ctx context.Context, worktreePath, newBase, upstream string) (*git.BranchSyncResult, error)
+	AbortRebase(ctx context.Context, worktreePath string) error
+	GetGitHubRemote(ctx context.Context, repoPath string) (owner, repo string, err error)
+}
+
+// PRBaseUpdater retargets pull requests (implemented by github.Client).
+type PRBaseUpdater interface {
+	UpdatePullRequestBase(ctx context.Context, owner, repo string, prNumber int, base string) error
+}
+
+// BroadcastFunc notifies the frontend of restack events for a session.
+type BroadcastFunc func(sessionID, eventType string, payload map[string]interface{})
+
+// Result describes what happened to one stacked session.
+type Result struct {
+	SessionID     string   `json:"sessionId"`
+	Success       bool     `json:"success"`
+	NewBaseSha    string   `json:"newBaseSha,omitempty"`
+	NewTarget     string   `json:"newTarget,omitempty"` // Set when the session was retargeted after its parent merged
+	ConflictFiles []string `json:"conflictFiles,omitempty"`
+	Skipped       string   `json:"skipped,omitempty"` // Why the session was left untouched
+	ErrorMessage  string   `json:"errorMessage,omitempty"`
+}
+
+// Restacker rebases and retargets stacked sessions.
+type Restacker struct {
+	store     Store
+	git       GitOps
+	gh        PRBaseUpdater
+	broadcast BroadcastFunc
+
+	// mu serializes restacks so two cascades never rebase the same worktree.
+	mu sync.Mutex
+}
+
+// NewRestacker creates a restacker. gh and broadcast may be nil.
+func NewRestacker(s Store, g GitOps, gh PRBaseUpdater, broadcast BroadcastFunc) *Restacker {
+	return &Restacker{store: s, git: g, gh: gh, broadcast: broadcast}
+}
+
+// RestackChildren rebases every session stacked on parentID onto the parent's
+// current branch tip, then cascades to their own children. A child that
+// conflicts is restored to its previous state and its subtree is left alone.
+func (r *Restacker) RestackChildren(ctx context.Context, parentID string) []Result {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	parent, err := r.store.GetSessionWithWorkspace(ctx, parentID)
+	if err != nil || parent == nil {
+		logger.Stack.Warnf("Restack: parent session %s not found: %v", parentID, err)
+		return nil
+	}
+	return r.restackChildren(ctx, parentID, parent.Branch)
+}
+
+func (r *Restacker) restackChildren(ctx context.Context, parentID, parentBranch string) []Result {
+	children, err := r.store.ListChildSessions(ctx, parentID)
+	if err != nil {
+		logger.Stack.Errorf("Restack: failed to list children of %s: %v", parentID, err)
+		return nil
+	}
+
+	var results []Result
+	for _, child := range children {
+		res := r.restackOne(ctx, child, parentBranch)
+		r.notify(res)
+		results = append(results, res)
+		if res.Success {
+			results = append(results, r.restackChildren(ctx, child.ID, child.Branch)...)
+		}
+	}
+	return results
+}
+
+// RetargetChildren handles the parent's PR having merged: each child takes
+// over the parent's target (the workspace default, or the grandparent when the
+// parent was itself stacked), its open PR is pointed at that branch, and its
+// commits are moved onto it. The retarget is recorded even if the rebase
+// conflicts, so the user can finish it with a regular branch sync.
+func (r *Restacker) RetargetChildren(ctx context.Context, parentID string) []Result {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	parent, err := r.store.GetSessionWithWorkspace(ctx, parentID)
+	if err != nil || parent == nil {
+		logger.Stack.Warnf("Retarget: parent session %s not found: %v", parentID, err)
+		return nil
+	}
+	children, err := r.store.ListChildSessions(ctx, parentID)
+	if err != nil {
+		logger.Stack.Errorf("Retarget: failed to list children of %s: %v", parentID, err)
+		return nil
+	}
+	if len(children) == 0 {
+		return nil
+	}
+
+	newTarget := parent.EffectiveTargetBranch()
+	prBase := strings.TrimPrefix(newTarget, parent.EffectiveRemote()+"/")
+	syncRef := parent.SyncBaseRef()
+
+	var owner, repoName string
+	var remoteErr error
+	if r.gh != nil {
+		owner, repoName, remoteErr = r.git.GetGitHubRemote(ctx, parent.WorkspacePath)
+	}
+
+	var results []Result
+	for _, child := range children {
+		if err := r.store.UpdateSession(ctx, child.ID, func(s *models.Session) {
+			s.ParentSessionID = parent.ParentSessionID
+			s.TargetBranch = parent.TargetBranch
+		}); err != nil {
+			logger.Stack.Errorf("Retarget: failed to update session %s: %v", child.ID, err)
+			res := Result{SessionID: child.ID, ErrorMessage: fmt.Sprintf("failed to update session: %v", err)}
+			r.notify(res)
+			results = append(results, res)
+			continue
+		}
+		logger.Stack.Infof("Retarget: session %s now targets %s (parent %s merged)", child.ID, newTarget, parentID)
+
+		var prErr error
+		if child.PRNumber > 0 && child.PRStatus == models.PRStatusOpen && r.gh != nil {
+			if remoteErr != nil {
+				prErr = remoteErr
+			} else {
+				prErr = r.gh.UpdatePullRequestBase(ctx, owner, repoName, child.PRNumber, prBase)
+			}
+			if prErr != nil {
+				logger.Stack.Warnf("Retarget: failed to retarget PR #%d of session %s to %s: %v", child.PRNumber, child.ID, prBase, prErr)
+			}
+		}
+
+		res := r.restackOne(ctx, child, syncRef)
+		res.NewTarget = newTarget
+		if prErr != nil && res.ErrorMessage == "" {
+			res.ErrorMessage = fmt.Sprintf("failed to retarget PR to %s: %v", prBase, prErr)
+		}
+		r.notify(res)
+		results = append(results, res)
+		if res.Success {
+			results = append(results, r.restackChildren(ctx, child.ID, child.Branch)...)
+		}
+	}
+	return results
+}
+
+// restackOne moves the child's own commits (BaseCommitSHA..HEAD) onto newBase
+// and records the new base. Conflicting rebases are aborted.
+func (r *Restacker) restackOne(ctx context.Context, child *models.Session, newBase string) Result {
+	res := Result{SessionID: child.ID}
+	if child.WorktreePath == "" {
+		res.Skipped = "session has no worktree"
+		return res
+	}
+	if child.Status == models.SessionStatusActive {
+		res.Skipped = "an agent is running in this session"
+		return res
+	}
+
+	upstream := child.BaseCommitSHA
+	if upstream == "" {
+		upstream = newBase
+	}
+	result, err := r.git.RestackOnto(ctx, child.WorktreePath, newBase, upstream)
+	if err != nil {
+		res.ErrorMessage = err.Error()
+		return res
+	}
+	if !result.Success {
+		res.ConflictFiles = result.ConflictFiles
+		res.ErrorMessage = result.ErrorMessage
+		if len(result.ConflictFiles) > 0 {
+			if err := r.git.AbortRebase(ctx, child.WorktreePath); err != nil {
+				logger.Stack.Warnf("Restack: failed to abort rebase in %s: %v", child.WorktreePath, err)
+			}
+		}
+		return res
+	}
+
+	res.Success = true
+	res.NewBaseSha = result.NewBaseSha
+	if result.NewBaseSha != "" {
+		if err := r.store.UpdateSession(ctx, child.ID, func(s *models.Session) {
+			s.BaseCommitSHA = result.NewBaseSha
+		}); err != nil {
+			logger.Stack.Warnf("Restack: failed to update base commit of session %s: %v", child.ID, err)
+		}
+	}
+	logger.Stack.Infof("Restack: session %s rebased onto %s", child.ID, newBase)
+	return res
+}
+
+func (r *Restacker) notify(res Result) {
+	if r.broadcast == nil {
+		return
+	}
+	payload := map[string]interface{}{
+		"success": res.Success,
+	}
+	if res.NewBaseSha != "" {
+		payload["newBaseSha"] = res.NewBaseSha
+	}
+	if res.NewTarget != "" {
+		payload["newTarget"] = res.NewTarget
+	}
+	if len(res.ConflictFiles) > 0 {
+		payload["conflictFiles"] = res.ConflictFiles
+	}
+	if res.Skipped != "" {
+		payload["skipped"] = res.Skipped
+	}
+	if res.ErrorMessage != "" {
+		payload["errorMessage"] = res.ErrorMessage
+	}
+	r.broadcast(res.SessionID, "session_restacked", payload)
+}
@@ -0,0 +1,229 @@
+package stack
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/chatml/chatml-backend/models"
+	"github.com/chatml/chatml-core/git"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockStore struct {
+	mu       sync.Mutex
+	sessions map[string]*models.Session
+}
+
+func newMockStore(sessions ...*models.Session) *mockStore {
+	m := &mockStore{sessions: map[string]*models.Session{}}
+	for _, s := range sessions {
+		m.sessions[s.ID] = s
+	}
+	return m
+}
+
+func (m *mockStore) GetSessionWithWorkspace(_ context.Context, id string) (*models.SessionWithWorkspace, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[id]
+	if !ok {
+		return nil, nil
+	}
+	return &models.SessionWithWorkspace{Session: *s, WorkspacePath: "/repo", WorkspaceBranch: "main"}, nil
+}
+
+func (m *mockStore) ListChildSessions(_ context.Context, parentID string) ([]*models.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []*models.Session
+	for _, s := range m.sessions {
+		if s.ParentSessionID == parentID {
+			cp := *s
+			out = append(out, &cp)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockStore) UpdateSession(_ context.Context, id string, fn func(*models.Session)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[id]
+	if !ok {
+		return errors.New("not found")
+	}
+	fn(s)
+	return nil
+}
+
+func (m *mockStore) get(id string) models.Session {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return *m.sessions[id]
+}
+
+type restackCall struct {
+	worktree, newBase, upstream string
+}
+
+type mockGit struct {
+	calls     []restackCall
+	aborted   []string
+	conflicts map[string][]string // worktree -> conflict files
+}
+
+func (g *mockGit) RestackOnto(_ context.Context, worktreePath, newBase, upstream string) (*git.BranchSyncResult, error) {
+	g.calls = append(g.calls, restackCall{worktreePath, newBase, upstream})
+	if files := g.conflicts[worktreePath]; len(files) > 0 {
+		return &git.BranchSyncResult{ConflictFiles: files, ErrorMessage: "Rebase resulted in conflicts"}, nil
+	}
+	return &git.BranchSyncResult{Success: true, NewBaseSha: "sha-" + newBase}, nil
+}
+
+func (g *mockGit) AbortRebase(_ context.Context, worktreePath string) error {
+	g.aborted = append(g.aborted, worktreePath)
+	return nil
+}
+
+func (g *mockGit) GetGitHubRemote(context.Context, string) (string, string, error) {
+	return "owner", "repo", nil
+}
+
+type baseUpdate struct {
+	prNumber int
+	base     string
+}
+
+type mockGH struct {
+	updates []baseUpdate
+}
+
+func (g *mockGH) UpdatePullRequestBase(_ context.Context, _, _ string, prNumber int, base string) error {
+	g.updates = append(g.updates, baseUpdate{prNumber, base})
+	return nil
+}
+
+func TestRestackChildren_CascadesDownTheStack(t *testing.T) {
+	s := newMockStore(
+		&models.Session{ID: "p", Branch: "feat/p", WorktreePath: "/wt/p"},
+		&models.Session{ID: "c", Branch: "feat/c", WorktreePath: "/wt/c", BaseCommitSHA: "old-p", ParentSessionID: "p", TargetBranch: "origin/feat/p"},
+		&models.Session{ID: "g", Branch: "feat/g", WorktreePath: "/wt/g", BaseCommitSHA: "old-c", ParentSessionID: "c", TargetBranch: "origin/feat/c"},
+	)
+	g := &mockGit{}
+	var events []string
+	r := NewRestacker(s, g, nil, func(sessionID, eventType string, _ map[string]interface{}) {
+		events = append(events, eventType+":"+sessionID)
+	})
+
+	results := r.RestackChildren(context.Background(), "p")
+
+	require.Len(t, results, 2)
+	assert.True(t, results[0].Success)
+	assert.True(t, results[1].Success)
+	assert.Equal(t, []restackCall{
+		{"/wt/c", "feat/p", "old-p"},
+		{"/wt/g", "feat/c", "old-c"},
+	}, g.calls)
+	assert.Equal(t, "sha-feat/p", s.get("c").BaseCommitSHA)
+	assert.Equal(t, "sha-feat/c", s.get("g").BaseCommitSHA)
+	assert.Equal(t, []string{"session_restacked:c", "session_restacked:g"}, events)
+}
+
+func TestRestackChildren_ConflictAbortsAndStopsCascade(t *testing.T) {
+	s := newMockStore(
+		&models.Session{ID: "p", Branch: "feat/p", WorktreePath: "/wt/p"},
+		&models.Session{ID: "c", Branch: "feat/c", WorktreePath: "/wt/c", BaseCommitSHA: "old-p", ParentSessionID: "p"},
+		&models.Session{ID: "g", Branch: "feat/g", WorktreePath: "/wt/g", BaseCommitSHA: "old-c", ParentSessionID: "c"},
+	)
+	g := &mockGit{conflicts: map[string][]string{"/wt/c": {"a.go"}}}
+	r := NewRestacker(s, g, nil, nil)
+
+	results := r.RestackChildren(context.Background(), "p")
+
+	require.Len(t, results, 1)
+	assert.False(t, results[0].Success)
+	assert.Equal(t, []string{"a.go"}, results[0].ConflictFiles)
+	assert.Equal(t, []string{"/wt/c"}, g.aborted)
+	assert.Equal(t, "old-p", s.get("c").BaseCommitSHA)
+}
+
+func TestRestackChildren_SkipsRunningAgent(t *testing.T) {
+	s := newMockStore(
+		&models.Session{ID: "p", Branch: "feat/p", WorktreePath: "/wt/p"},
+		&models.Session{ID: "c", Branch: "feat/c", WorktreePath: "/wt/c", ParentSessionID: "p", Status: models.SessionStatusActive},
+	)
+	g := &mockGit{}
+	r := NewRestacker(s, g, nil, nil)
+
+	results := r.RestackChildren(context.Background(), "p")
+
+	require.Len(t, results, 1)
+	assert.NotEmpty(t, results[0].Skipped)
+	assert.Empty(t, g.calls)
+}
+
+func TestRetargetChildren_MovesToWorkspaceDefault(t *testing.T) {
+	s := newMockStore(
+		&models.Session{ID: "p", Branch: "feat/p", WorktreePath: "/wt/p", PRStatus: models.PRStatusMerged},
+		&models.Session{ID: "c", Branch: "feat/c", WorktreePath: "/wt/c", BaseCommitSHA: "p-tip", ParentSessionID: "p",
+			TargetBranch: "origin/feat/p", PRNumber: 12, PRStatus: models.PRStatusOpen},
+	)
+	g := &mockGit{}
+	gh := &mockGH{}
+	r := NewRestacker(s, g, gh, nil)
+
+	results := r.RetargetChildren(context.Background(), "p")
+
+	require.Len(t, results, 1)
+	assert.True(t, results[0].Success)
+	assert.Equal(t, "origin/main", results[0].NewTarget)
+	assert.Equal(t, []baseUpdate{{12, "main"}}, gh.updates)
+	assert.Equal(t, []restackCall{{"/wt/c", "origin/main", "p-tip"}}, g.calls)
+
+	child := s.get("c")
+	assert.False(t, child.IsStacked())
+	assert.Empty(t, child.TargetBranch)
+	assert.Equal(t, "sha-origin/main", child.BaseCommitSHA)
+}
+
+func TestRetargetChildren_ReparentsOntoGrandparent(t *testing.T) {
+	s := newMockStore(
+		&models.Session{ID: "gp", Branch: "feat/gp", WorktreePath: "/wt/gp"},
+		&models.Session{ID: "p", Branch: "feat/p", WorktreePath: "/wt/p", ParentSessionID: "gp", TargetBranch: "origin/feat/gp"},
+		&models.Session{ID: "c", Branch: "feat/c", WorktreePath: "/wt/c", BaseCommitSHA: "p-tip", ParentSessionID: "p",
+			TargetBranch: "origin/feat/p", PRNumber: 3, PRStatus: models.PRStatusOpen},
+	)
+	g := &mockGit{}
+	gh := &mockGH{}
+	r := NewRestacker(s, g, gh, nil)
+
+	results := r.RetargetChildren(context.Background(), "p")
+
+	require.Len(t, results, 1)
+	child := s.get("c")
+	assert.Equal(t, "gp", child.ParentSessionID)
+	assert.Equal(t, "origin/feat/gp", child.TargetBranch)
+	assert.Equal(t, []baseUpdate{{3, "feat/gp"}}, gh.updates)
+	// Stacked sessions restack onto the grandparent's local branch
+	assert.Equal(t, []restackCall{{"/wt/c", "feat/gp", "p-tip"}}, g.calls)
+}
+
+func TestRetargetChildren_ConflictStillRetargets(t *testing.T) {
+	s := newMockStore(
+		&models.Session{ID: "p", Branch: "feat/p", WorktreePath: "/wt/p"},
+		&models.Session{ID: "c", Branch: "feat/c", WorktreePath: "/wt/c", BaseCommitSHA: "p-tip", ParentSessionID: "p", TargetBranch: "origin/feat/p"},
+	)
+	g := &mockGit{conflicts: map[string][]string{"/wt/c": {"x.go"}}}
+	r := NewRestacker(s, g, &mockGH{}, nil)
+
+	results := r.RetargetChildren(context.Background(), "p")
+
+	require.Len(t, results, 1)
+	assert.False(t, results[0].Success)
+	assert.Equal(t, []string{"/wt/c"}, g.aborted)
+	assert.Empty(t, s.get("c").ParentSessionID)
+	assert.Equal(t, "p-tip", s.get("c").BaseCommitSHA)
+}
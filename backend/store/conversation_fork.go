@@ -0,0 +1,118 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/chatml/chatml-backend/models"
+	"github.com/google/uuid"
+)
+
+// CountUserTurnsThrough returns how many user messages of the conversation
+// are positioned at or before msgID, i.e. the number of turns up to and
+// including the turn msgID belongs to. found is false if the message does not
+// belong to the conversation.
+func (s *SQLiteStore) CountUserTurnsThrough(ctx context.Context, convID, msgID string) (turns int, found bool, err error) {
+	var position int
+	err = s.db.QueryRowContext(ctx, `
+		SELECT position FROM messages WHERE id = ? AND conversation_id = ?`, msgID, convID).Scan(&position)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("CountUserTurnsThrough: %w", err)
+	}
+	err = s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM messages WHERE conversation_id = ? AND role = 'user' AND position <= ?`,
+		convID, position).Scan(&turns)
+	if err != nil {
+		return 0, false, fmt.Errorf("CountUserTurnsThrough: count: %w", err)
+	}
+	return turns, true, nil
+}
+
+// ForkConversation inserts fork as a new conversation holding a copy of the
+// first turns user turns of srcConvID: every message positioned before the
+// (turns+1)-th user message, with its attachments. A negative turns copies
+// all messages. Copies get new IDs and no checkpoint, since checkpoints
+// belong to the source conversation's file history.
+func (s *SQLiteStore) ForkConversation(ctx context.Context, fork *models.Conversation, srcConvID string, turns int) error {
+	return RetryDBExec(ctx, "ForkConversation", DefaultRetryConfig(), func(ctx context.Context) error {
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("begin: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO conversations (id, session_id, type, name, status, model, agent_session_id, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			fork.ID, fork.SessionID, fork.Type, fork.Name,
+			fork.Status, fork.Model, fork.AgentSessionID, fork.CreatedAt, fork.UpdatedAt)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		// Position of the first user message past the fork point, if any.
+		var cutoff sql.NullInt64
+		if turns >= 0 {
+			err = tx.QueryRowContext(ctx, `
+				SELECT position FROM messages WHERE conversation_id = ? AND role = 'user'
+				ORDER BY position LIMIT 1 OFFSET ?`, srcConvID, turns).Scan(&cutoff)
+			if err != nil && err != sql.ErrNoRows {
+				tx.Rollback()
+				return err
+			}
+		}
+
+		query := `SELECT id FROM messages WHERE conversation_id = ?`
+		args := []interface{}{srcConvID}
+		if cutoff.Valid {
+			query += ` AND position < ?`
+			args = append(args, cutoff.Int64)
+		}
+		rows, err := tx.QueryContext(ctx, query+` ORDER BY position`, args...)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		var msgIDs []string
+		for rows.Next() {
+			var id string
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				tx.Rollback()
+				return err
+			}
+			msgIDs = append(msgIDs, id)
+		}
+		rows.Close()
+
+		for _, srcID := range msgIDs {
+			newID := uuid.New().String()
+			_, err := tx.ExecContext(ctx, `
+				INSERT INTO messages (id, conversation_id, role, content, setup_info, run_summary, tool_usage,
+					thinking_content, duration_ms, timeline, plan_content, checkpoint_uuid, timestamp, position)
+				SELECT ?, ?, role, content, setup_info, run_summary, tool_usage,
+					thinking_content, duration_ms, timeline, plan_content, NULL, timestamp, position
+				FROM messages WHERE id = ?`, newID, fork.ID, srcID)
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+			_, err = tx.ExecContext(ctx, `
+				INSERT INTO attachments (id, message_id, type, name, path, mime_type, size, line_count,
+					width, height, base64_data, preview, created_at)
+				SELECT lower(hex(randomblob(16))), ?, type, name, path, mime_type, size, line_count,
+					width, height, base64_data, preview, created_at
+				FROM attachments WHERE message_id = ?`, newID, srcID)
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+
+		return tx.Commit()
+	})
+}
@@ -0,0 +1,66 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/chatml/chatml-backend/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForkConversation(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	createTestRepo(t, s, "ws-1")
+	createTestSession(t, s, "s-1", "ws-1")
+	createTestConversation(t, s, "c-1", "s-1")
+
+	first := createTestMessage("m-1", "user", "Add an email column")
+	first.CheckpointUuid = "cp-1"
+	for _, msg := range []models.Message{
+		first,
+		createTestMessage("m-2", "assistant", "Added the column"),
+		createTestMessage("m-3", "user", "Now backfill it"),
+		createTestMessage("m-4", "assistant", "Backfilled"),
+	} {
+		require.NoError(t, s.AddMessageToConversation(ctx, "c-1", msg))
+	}
+	require.NoError(t, s.SaveAttachments(ctx, "m-1", []models.Attachment{
+		{ID: "a-1", Type: "file", Name: "schema.sql", MimeType: "text/plain", Size: 12},
+	}))
+
+	turns, found, err := s.CountUserTurnsThrough(ctx, "c-1", "m-2")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, 1, turns)
+	_, found, err = s.CountUserTurnsThrough(ctx, "c-1", "missing")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	now := time.Now()
+	fork := &models.Conversation{ID: "c-2", SessionID: "s-1", Type: "task", Name: "Fork", Status: "idle", AgentSessionID: "c-2", CreatedAt: now, UpdatedAt: now}
+	require.NoError(t, s.ForkConversation(ctx, fork, "c-1", turns))
+
+	page, err := s.GetConversationMessages(ctx, "c-2", nil, 50, false)
+	require.NoError(t, err)
+	require.Len(t, page.Messages, 2)
+	assert.Equal(t, "Add an email column", page.Messages[0].Content)
+	assert.Equal(t, "Added the column", page.Messages[1].Content)
+	assert.NotEqual(t, "m-1", page.Messages[0].ID)
+	assert.Empty(t, page.Messages[0].CheckpointUuid, "checkpoints stay with the source")
+	require.Len(t, page.Messages[0].Attachments, 1)
+	assert.Equal(t, "schema.sql", page.Messages[0].Attachments[0].Name)
+	assert.NotEqual(t, "a-1", page.Messages[0].Attachments[0].ID)
+
+	all := &models.Conversation{ID: "c-3", SessionID: "s-1", Type: "task", Status: "idle", CreatedAt: now, UpdatedAt: now}
+	require.NoError(t, s.ForkConversation(ctx, all, "c-1", -1))
+	count, err := s.GetConversationMessageCount(ctx, "c-3")
+	require.NoError(t, err)
+	assert.Equal(t, 4, count)
+
+	source, err := s.GetConversationMessageCount(ctx, "c-1")
+	require.NoError(t, err)
+	assert.Equal(t, 4, source, "source conversation is untouched")
+}
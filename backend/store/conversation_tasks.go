@@ -0,0 +1,82 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/chatml/chatml-backend/models"
+)
+
+// ReplaceConversationTasks stores tasks as the conversation's full task list,
+// in order, replacing whatever was stored before.
+func (s *SQLiteStore) ReplaceConversationTasks(ctx context.Context, convID string, tasks []models.ConversationTask) error {
+	now := time.Now().UTC()
+	return RetryDBExec(ctx, "ReplaceConversationTasks", DefaultRetryConfig(), func(ctx context.Context) error {
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("begin: %w", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM conversation_tasks WHERE conversation_id = ?`, convID); err != nil {
+			tx.Rollback()
+			return err
+		}
+		for i, t := range tasks {
+			blocks, _ := json.Marshal(nonNilStrings(t.Blocks))
+			blockedBy, _ := json.Marshal(nonNilStrings(t.BlockedBy))
+			_, err := tx.ExecContext(ctx, `
+				INSERT INTO conversation_tasks (conversation_id, task_id, position, subject, description,
+					active_form, status, owner, blocks, blocked_by, updated_at)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				convID, t.ID, i, t.Subject, t.Description, t.ActiveForm, t.Status, t.Owner,
+				string(blocks), string(blockedBy), now)
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+
+		return tx.Commit()
+	})
+}
+
+// ListConversationTasks returns the conversation's task list in order.
+func (s *SQLiteStore) ListConversationTasks(ctx context.Context, convID string) ([]models.ConversationTask, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT task_id, subject, description, active_form, status, owner, blocks, blocked_by, updated_at
+		FROM conversation_tasks WHERE conversation_id = ? ORDER BY position`, convID)
+	if err != nil {
+		return nil, fmt.Errorf("ListConversationTasks: %w", err)
+	}
+	defer rows.Close()
+
+	tasks := []models.ConversationTask{}
+	for rows.Next() {
+		var t models.ConversationTask
+		var blocks, blockedBy string
+		if err := rows.Scan(&t.ID, &t.Subject, &t.Description, &t.ActiveForm, &t.Status, &t.Owner,
+			&blocks, &blockedBy, &t.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("ListConversationTasks: scan: %w", err)
+		}
+		if json.Unmarshal([]byte(blocks), &t.Blocks) != nil || t.Blocks == nil {
+			t.Blocks = []string{}
+		}
+		if json.Unmarshal([]byte(blockedBy), &t.BlockedBy) != nil || t.BlockedBy == nil {
+			t.BlockedBy = []string{}
+		}
+		tasks = append(tasks, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ListConversationTasks: %w", err)
+	}
+	return tasks, nil
+}
+
+func nonNilStrings(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
@@ -0,0 +1,45 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chatml/chatml-backend/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConversationTasks_ReplaceAndList(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	createTestRepo(t, s, "ws-1")
+	createTestSession(t, s, "s-1", "ws-1")
+	createTestConversation(t, s, "c-1", "s-1")
+
+	empty, err := s.ListConversationTasks(ctx, "c-1")
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+	assert.NotNil(t, empty)
+
+	require.NoError(t, s.ReplaceConversationTasks(ctx, "c-1", []models.ConversationTask{
+		{ID: "task-1", Subject: "Old", Status: "pending"},
+	}))
+	require.NoError(t, s.ReplaceConversationTasks(ctx, "c-1", []models.ConversationTask{
+		{ID: "task-2", Subject: "Backfill", Status: "pending", BlockedBy: []string{"task-3"}},
+		{ID: "task-3", Subject: "Write migration", ActiveForm: "Writing migration", Status: "in_progress", Blocks: []string{"task-2"}},
+	}))
+
+	tasks, err := s.ListConversationTasks(ctx, "c-1")
+	require.NoError(t, err)
+	require.Len(t, tasks, 2)
+	assert.Equal(t, "task-2", tasks[0].ID, "kept in reported order")
+	assert.Equal(t, []string{"task-3"}, tasks[0].BlockedBy)
+	assert.Equal(t, []string{}, tasks[0].Blocks)
+	assert.Equal(t, "Writing migration", tasks[1].ActiveForm)
+	assert.False(t, tasks[1].UpdatedAt.IsZero())
+
+	require.NoError(t, s.DeleteConversation(ctx, "c-1"))
+	tasks, err = s.ListConversationTasks(ctx, "c-1")
+	require.NoError(t, err)
+	assert.Empty(t, tasks, "tasks are deleted with their conversation")
+}
@@ -176,6 +176,131 @@ var migrations = []Migration{
 			return err
 		},
 	},
+	{
+		Version:     11,
+		Description: "Add GitHub review thread linkage columns to review_comments",
+		Up: func(_ context.Context, tx *sql.Tx) error {
+			for _, stmt := range []string{
+				`ALTER TABLE review_comments ADD COLUMN github_comment_id INTEGER NOT NULL DEFAULT 0`,
+				`ALTER TABLE review_comments ADD COLUMN github_thread_id TEXT NOT NULL DEFAULT ''`,
+				`ALTER TABLE review_comments ADD COLUMN github_url TEXT NOT NULL DEFAULT ''`,
+				`ALTER TABLE review_comments ADD COLUMN github_resolved INTEGER NOT NULL DEFAULT 0`,
+			} {
+				if _, err := tx.Exec(stmt); err != nil && !isDuplicateColumnError(err) {
+					return err
+				}
+			}
+			_, err := tx.Exec(`CREATE INDEX IF NOT EXISTS idx_review_comments_github ON review_comments(session_id, github_comment_id) WHERE github_comment_id != 0`)
+			return err
+		},
+	},
+	{
+		Version:     12,
+		Description: "Add pr_automation_policies table",
+		Up: func(_ context.Context, tx *sql.Tx) error {
+			_, err := tx.Exec(`
+				CREATE TABLE IF NOT EXISTS pr_automation_policies (
+					session_id TEXT PRIMARY KEY,
+					address_feedback INTEGER NOT NULL DEFAULT 0,
+					max_iterations INTEGER NOT NULL DEFAULT 3,
+					budget_usd REAL NOT NULL DEFAULT 5,
+					iterations INTEGER NOT NULL DEFAULT 0,
+					spent_usd REAL NOT NULL DEFAULT 0,
+					active_conversation_id TEXT NOT NULL DEFAULT '',
+					handled_feedback TEXT NOT NULL DEFAULT '[]',
+					stopped_reason TEXT NOT NULL DEFAULT '',
+					updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+					FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
+				)`)
+			return err
+		},
+	},
+	{
+		Version:     13,
+		Description: "Add CI repair columns to pr_automation_policies",
+		Up: func(_ context.Context, tx *sql.Tx) error {
+			for _, stmt := range []string{
+				`ALTER TABLE pr_automation_policies ADD COLUMN fix_ci INTEGER NOT NULL DEFAULT 0`,
+				`ALTER TABLE pr_automation_policies ADD COLUMN max_ci_attempts INTEGER NOT NULL DEFAULT 3`,
+				`ALTER TABLE pr_automation_policies ADD COLUMN ci_attempts INTEGER NOT NULL DEFAULT 0`,
+				`ALTER TABLE pr_automation_policies ADD COLUMN ci_last_sha TEXT NOT NULL DEFAULT ''`,
+				`ALTER TABLE pr_automation_policies ADD COLUMN ci_last_failed_jobs TEXT NOT NULL DEFAULT '[]'`,
+				`ALTER TABLE pr_automation_policies ADD COLUMN ci_repeat_count INTEGER NOT NULL DEFAULT 0`,
+				`ALTER TABLE pr_automation_policies ADD COLUMN ci_stopped_reason TEXT NOT NULL DEFAULT ''`,
+			} {
+				if _, err := tx.Exec(stmt); err != nil && !isDuplicateColumnError(err) {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version:     14,
+		Description: "Add parent_session_id to sessions for stacked sessions",
+		Up: func(_ context.Context, tx *sql.Tx) error {
+			if _, err := tx.Exec(`ALTER TABLE sessions ADD COLUMN parent_session_id TEXT DEFAULT NULL`); err != nil && !isDuplicateColumnError(err) {
+				return err
+			}
+			_, err := tx.Exec(`CREATE INDEX IF NOT EXISTS idx_sessions_parent_session_id ON sessions(parent_session_id)`)
+			return err
+		},
+	},
+	{
+		Version:     15,
+		Description: "Add permission_audit table",
+		Up: func(_ context.Context, tx *sql.Tx) error {
+			// No foreign keys: audit entries must survive session and
+			// conversation deletion.
+			for _, stmt := range []string{
+				`CREATE TABLE IF NOT EXISTS permission_audit (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					workspace_id TEXT NOT NULL DEFAULT '',
+					session_id TEXT NOT NULL DEFAULT '',
+					conversation_id TEXT NOT NULL DEFAULT '',
+					tool_use_id TEXT NOT NULL DEFAULT '',
+					tool_name TEXT NOT NULL,
+					specifier TEXT NOT NULL DEFAULT '',
+					decision TEXT NOT NULL,
+					decided_by TEXT NOT NULL DEFAULT '',
+					approval_action TEXT NOT NULL DEFAULT '',
+					reason TEXT NOT NULL DEFAULT '',
+					mode TEXT NOT NULL DEFAULT '',
+					timestamp DATETIME NOT NULL
+				)`,
+				`CREATE INDEX IF NOT EXISTS idx_permission_audit_workspace ON permission_audit(workspace_id, timestamp)`,
+				`CREATE INDEX IF NOT EXISTS idx_permission_audit_session ON permission_audit(session_id, timestamp)`,
+			} {
+				if _, err := tx.Exec(stmt); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version:     16,
+		Description: "Add conversation_tasks table",
+		Up: func(_ context.Context, tx *sql.Tx) error {
+			// blocks and blocked_by hold JSON arrays of task IDs.
+			_, err := tx.Exec(`CREATE TABLE IF NOT EXISTS conversation_tasks (
+				conversation_id TEXT NOT NULL,
+				task_id TEXT NOT NULL,
+				position INTEGER NOT NULL,
+				subject TEXT NOT NULL,
+				description TEXT NOT NULL DEFAULT '',
+				active_form TEXT NOT NULL DEFAULT '',
+				status TEXT NOT NULL,
+				owner TEXT NOT NULL DEFAULT '',
+				blocks TEXT NOT NULL DEFAULT '[]',
+				blocked_by TEXT NOT NULL DEFAULT '[]',
+				updated_at DATETIME NOT NULL,
+				PRIMARY KEY (conversation_id, task_id),
+				FOREIGN KEY (conversation_id) REFERENCES conversations(id) ON DELETE CASCADE
+			)`)
+			return err
+		},
+	},
 }
 
 // RunMigrations ensures the schema_version table exists, applies the baseline
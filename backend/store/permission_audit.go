@@ -0,0 +1,141 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/chatml/chatml-backend/models"
+)
+
+const permissionAuditColumns = `id, workspace_id, session_id, conversation_id, tool_use_id, tool_name,
+	specifier, decision, decided_by, approval_action, reason, mode, timestamp`
+
+// PermissionAuditFilter narrows permission audit queries. Empty fields match
+// everything; a zero Limit returns all matching entries.
+type PermissionAuditFilter struct {
+	WorkspaceID    string
+	SessionID      string
+	ConversationID string
+	ToolName       string
+	Decision       string
+	Since          time.Time
+	Until          time.Time
+	Limit          int
+}
+
+func (f PermissionAuditFilter) where() (string, []any) {
+	var conds []string
+	var args []any
+	add := func(cond string, arg any) {
+		conds = append(conds, cond)
+		args = append(args, arg)
+	}
+	if f.WorkspaceID != "" {
+		add("workspace_id = ?", f.WorkspaceID)
+	}
+	if f.SessionID != "" {
+		add("session_id = ?", f.SessionID)
+	}
+	if f.ConversationID != "" {
+		add("conversation_id = ?", f.ConversationID)
+	}
+	if f.ToolName != "" {
+		add("tool_name = ?", f.ToolName)
+	}
+	if f.Decision != "" {
+		add("decision = ?", f.Decision)
+	}
+	if !f.Since.IsZero() {
+		add("timestamp >= ?", f.Since.UTC())
+	}
+	if !f.Until.IsZero() {
+		add("timestamp < ?", f.Until.UTC())
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+func scanPermissionAuditEntry(row rowScanner) (*models.PermissionAuditEntry, error) {
+	var e models.PermissionAuditEntry
+	if err := row.Scan(&e.ID, &e.WorkspaceID, &e.SessionID, &e.ConversationID, &e.ToolUseID, &e.ToolName,
+		&e.Specifier, &e.Decision, &e.DecidedBy, &e.ApprovalAction, &e.Reason, &e.Mode, &e.Timestamp); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// AddPermissionAudit appends a permission decision to the audit log and sets
+// the entry's ID.
+func (s *SQLiteStore) AddPermissionAudit(ctx context.Context, e *models.PermissionAuditEntry) error {
+	if e.Timestamp.IsZero() {
+		e.Timestamp = time.Now()
+	}
+	// Stored in UTC so the Since/Until filters compare like with like.
+	e.Timestamp = e.Timestamp.UTC()
+	return RetryDBExec(ctx, "AddPermissionAudit", DefaultRetryConfig(), func(ctx context.Context) error {
+		res, err := s.db.ExecContext(ctx, `
+			INSERT INTO permission_audit (workspace_id, session_id, conversation_id, tool_use_id, tool_name,
+				specifier, decision, decided_by, approval_action, reason, mode, timestamp)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			e.WorkspaceID, e.SessionID, e.ConversationID, e.ToolUseID, e.ToolName,
+			e.Specifier, e.Decision, e.DecidedBy, e.ApprovalAction, e.Reason, e.Mode, e.Timestamp)
+		if err != nil {
+			return err
+		}
+		e.ID, err = res.LastInsertId()
+		return err
+	})
+}
+
+// ListPermissionAudit returns matching audit entries, newest first.
+func (s *SQLiteStore) ListPermissionAudit(ctx context.Context, f PermissionAuditFilter) ([]*models.PermissionAuditEntry, error) {
+	entries := []*models.PermissionAuditEntry{}
+	err := s.eachPermissionAudit(ctx, f, "DESC", func(e *models.PermissionAuditEntry) error {
+		entries = append(entries, e)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ListPermissionAudit: %w", err)
+	}
+	return entries, nil
+}
+
+// ExportPermissionAudit calls fn for each matching audit entry, oldest first,
+// without loading the whole log into memory. Iteration stops at the first
+// error fn returns.
+func (s *SQLiteStore) ExportPermissionAudit(ctx context.Context, f PermissionAuditFilter, fn func(*models.PermissionAuditEntry) error) error {
+	if err := s.eachPermissionAudit(ctx, f, "ASC", fn); err != nil {
+		return fmt.Errorf("ExportPermissionAudit: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) eachPermissionAudit(ctx context.Context, f PermissionAuditFilter, order string, fn func(*models.PermissionAuditEntry) error) error {
+	where, args := f.where()
+	query := `SELECT ` + permissionAuditColumns + ` FROM permission_audit` + where +
+		` ORDER BY timestamp ` + order + `, id ` + order
+	if f.Limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, f.Limit)
+	}
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e, err := scanPermissionAuditEntry(rows)
+		if err != nil {
+			return fmt.Errorf("scan: %w", err)
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
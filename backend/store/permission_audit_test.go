@@ -0,0 +1,69 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/chatml/chatml-backend/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPermissionAudit_AddAndList(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	base := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	for i, e := range []models.PermissionAuditEntry{
+		{WorkspaceID: "ws-1", SessionID: "s-1", ConversationID: "c-1", ToolName: "Bash", Specifier: "npm test", Decision: "allow", DecidedBy: "rule:project"},
+		{WorkspaceID: "ws-1", SessionID: "s-1", ConversationID: "c-1", ToolName: "Write", Specifier: ".env", Decision: "deny", DecidedBy: "user", ApprovalAction: "deny_once", Reason: "User denied tool execution"},
+		{WorkspaceID: "ws-2", SessionID: "s-2", ConversationID: "c-2", ToolName: "Bash", Specifier: "curl x", Decision: "deny", DecidedBy: "policy"},
+	} {
+		e.Timestamp = base.Add(time.Duration(i) * time.Minute)
+		require.NoError(t, s.AddPermissionAudit(ctx, &e))
+		assert.NotZero(t, e.ID)
+	}
+
+	all, err := s.ListPermissionAudit(ctx, PermissionAuditFilter{})
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	assert.Equal(t, "policy", all[0].DecidedBy, "newest first")
+
+	ws1, err := s.ListPermissionAudit(ctx, PermissionAuditFilter{WorkspaceID: "ws-1"})
+	require.NoError(t, err)
+	assert.Len(t, ws1, 2)
+
+	denied, err := s.ListPermissionAudit(ctx, PermissionAuditFilter{ToolName: "Bash", Decision: "deny"})
+	require.NoError(t, err)
+	require.Len(t, denied, 1)
+	assert.Equal(t, "s-2", denied[0].SessionID)
+
+	limited, err := s.ListPermissionAudit(ctx, PermissionAuditFilter{SessionID: "s-1", Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, limited, 1)
+	assert.Equal(t, "deny_once", limited[0].ApprovalAction)
+
+	since, err := s.ListPermissionAudit(ctx, PermissionAuditFilter{Since: base.Add(30 * time.Second)})
+	require.NoError(t, err)
+	assert.Len(t, since, 2)
+}
+
+func TestPermissionAudit_ExportOldestFirst(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	base := time.Now().Add(-time.Hour)
+	for i, tool := range []string{"Read", "Bash", "Edit"} {
+		require.NoError(t, s.AddPermissionAudit(ctx, &models.PermissionAuditEntry{
+			SessionID: "s-1", ToolName: tool, Decision: "allow", Timestamp: base.Add(time.Duration(i) * time.Second),
+		}))
+	}
+
+	var tools []string
+	err := s.ExportPermissionAudit(ctx, PermissionAuditFilter{SessionID: "s-1"}, func(e *models.PermissionAuditEntry) error {
+		tools = append(tools, e.ToolName)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Read", "Bash", "Edit"}, tools)
+}
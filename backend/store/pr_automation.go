@@ -0,0 +1,146 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/chatml/chatml-backend/models"
+)
+
+const prAutomationColumns = `session_id, address_feedback, max_iterations, budget_usd,
+	iterations, spent_usd, active_conversation_id, handled_feedback, stopped_reason,
+	fix_ci, max_ci_attempts, ci_attempts, ci_last_sha, ci_last_failed_jobs, ci_repeat_count, ci_stopped_reason,
+	updated_at`
+
+func scanPRAutomationPolicy(row rowScanner) (*models.PRAutomationPolicy, error) {
+	var p models.PRAutomationPolicy
+	var addressFeedback, fixCI int
+	var handled, failedJobs string
+	if err := row.Scan(&p.SessionID, &addressFeedback, &p.MaxIterations, &p.BudgetUSD,
+		&p.Iterations, &p.SpentUSD, &p.ActiveConversationID, &handled, &p.StoppedReason,
+		&fixCI, &p.MaxCIAttempts, &p.CIAttempts, &p.CILastSHA, &failedJobs, &p.CIRepeatCount, &p.CIStoppedReason,
+		&p.UpdatedAt); err != nil {
+		return nil, err
+	}
+	p.AddressFeedback = intToBool(addressFeedback)
+	p.FixCI = intToBool(fixCI)
+	if handled != "" {
+		_ = json.Unmarshal([]byte(handled), &p.HandledFeedback)
+	}
+	if failedJobs != "" {
+		_ = json.Unmarshal([]byte(failedJobs), &p.CILastFailedJobs)
+	}
+	return &p, nil
+}
+
+// GetPRAutomationPolicy returns a session's PR automation policy, or nil if the
+// session has never configured one.
+func (s *SQLiteStore) GetPRAutomationPolicy(ctx context.Context, sessionID string) (*models.PRAutomationPolicy, error) {
+	p, err := scanPRAutomationPolicy(s.db.QueryRowContext(ctx,
+		`SELECT `+prAutomationColumns+` FROM pr_automation_policies WHERE session_id = ?`, sessionID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("GetPRAutomationPolicy: %w", err)
+	}
+	return p, nil
+}
+
+// ListActivePRAutomationPolicies returns policies with at least one automation
+// enabled that has not been stopped by a limit.
+func (s *SQLiteStore) ListActivePRAutomationPolicies(ctx context.Context) ([]*models.PRAutomationPolicy, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+prAutomationColumns+` FROM pr_automation_policies
+		WHERE (address_feedback = 1 AND stopped_reason = '')
+		   OR (fix_ci = 1 AND ci_stopped_reason = '')`)
+	if err != nil {
+		return nil, fmt.Errorf("ListActivePRAutomationPolicies: %w", err)
+	}
+	defer rows.Close()
+
+	var policies []*models.PRAutomationPolicy
+	for rows.Next() {
+		p, err := scanPRAutomationPolicy(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ListActivePRAutomationPolicies scan: %w", err)
+		}
+		policies = append(policies, p)
+	}
+	return policies, rows.Err()
+}
+
+// SavePRAutomationPolicy inserts or replaces a session's PR automation policy.
+func (s *SQLiteStore) SavePRAutomationPolicy(ctx context.Context, p *models.PRAutomationPolicy) error {
+	handled, err := json.Marshal(p.HandledFeedback)
+	if err != nil {
+		return fmt.Errorf("SavePRAutomationPolicy: marshal handled feedback: %w", err)
+	}
+	if p.HandledFeedback == nil {
+		handled = []byte("[]")
+	}
+	failedJobs, err := json.Marshal(p.CILastFailedJobs)
+	if err != nil {
+		return fmt.Errorf("SavePRAutomationPolicy: marshal failed jobs: %w", err)
+	}
+	if p.CILastFailedJobs == nil {
+		failedJobs = []byte("[]")
+	}
+	p.UpdatedAt = time.Now()
+
+	return RetryDBExec(ctx, "SavePRAutomationPolicy", DefaultRetryConfig(), func(ctx context.Context) error {
+		_, err := s.db.ExecContext(ctx, `
+			INSERT INTO pr_automation_policies (`+prAutomationColumns+`)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(session_id) DO UPDATE SET
+				address_feedback = excluded.address_feedback,
+				max_iterations = excluded.max_iterations,
+				budget_usd = excluded.budget_usd,
+				iterations = excluded.iterations,
+				spent_usd = excluded.spent_usd,
+				active_conversation_id = excluded.active_conversation_id,
+				handled_feedback = excluded.handled_feedback,
+				stopped_reason = excluded.stopped_reason,
+				fix_ci = excluded.fix_ci,
+				max_ci_attempts = excluded.max_ci_attempts,
+				ci_attempts = excluded.ci_attempts,
+				ci_last_sha = excluded.ci_last_sha,
+				ci_last_failed_jobs = excluded.ci_last_failed_jobs,
+				ci_repeat_count = excluded.ci_repeat_count,
+				ci_stopped_reason = excluded.ci_stopped_reason,
+				updated_at = excluded.updated_at`,
+			p.SessionID, boolToInt(p.AddressFeedback), p.MaxIterations, p.BudgetUSD,
+			p.Iterations, p.SpentUSD, p.ActiveConversationID, string(handled), p.StoppedReason,
+			boolToInt(p.FixCI), p.MaxCIAttempts, p.CIAttempts, p.CILastSHA, string(failedJobs), p.CIRepeatCount, p.CIStoppedReason,
+			p.UpdatedAt)
+		return err
+	})
+}
+
+// GetConversationCost sums the run cost recorded on a conversation's messages.
+func (s *SQLiteStore) GetConversationCost(ctx context.Context, conversationID string) (float64, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT run_summary FROM messages
+		WHERE conversation_id = ? AND run_summary IS NOT NULL`, conversationID)
+	if err != nil {
+		return 0, fmt.Errorf("GetConversationCost: %w", err)
+	}
+	defer rows.Close()
+
+	var total float64
+	for rows.Next() {
+		var runSummaryJSON string
+		if err := rows.Scan(&runSummaryJSON); err != nil {
+			return 0, fmt.Errorf("GetConversationCost scan: %w", err)
+		}
+		var rs models.RunSummary
+		if err := json.Unmarshal([]byte(runSummaryJSON), &rs); err != nil {
+			continue // skip malformed entries
+		}
+		total += rs.Cost
+	}
+	return total, rows.Err()
+}
@@ -0,0 +1,124 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chatml/chatml-backend/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPRAutomationPolicy_SaveAndGet(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	repo := createTestRepo(t, s, "repo-1")
+	createTestSession(t, s, "session-1", repo.ID)
+
+	got, err := s.GetPRAutomationPolicy(ctx, "session-1")
+	require.NoError(t, err)
+	assert.Nil(t, got, "no policy before the first save")
+
+	policy := &models.PRAutomationPolicy{
+		SessionID:       "session-1",
+		AddressFeedback: true,
+		MaxIterations:   4,
+		BudgetUSD:       2.5,
+	}
+	require.NoError(t, s.SavePRAutomationPolicy(ctx, policy))
+
+	policy.Iterations = 1
+	policy.SpentUSD = 0.75
+	policy.ActiveConversationID = "conv-1"
+	policy.HandledFeedback = []string{"review:1", "thread:T1"}
+	require.NoError(t, s.SavePRAutomationPolicy(ctx, policy))
+
+	got, err = s.GetPRAutomationPolicy(ctx, "session-1")
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.True(t, got.AddressFeedback)
+	assert.Equal(t, 4, got.MaxIterations)
+	assert.Equal(t, 2.5, got.BudgetUSD)
+	assert.Equal(t, 1, got.Iterations)
+	assert.Equal(t, 0.75, got.SpentUSD)
+	assert.Equal(t, "conv-1", got.ActiveConversationID)
+	assert.Equal(t, []string{"review:1", "thread:T1"}, got.HandledFeedback)
+	assert.True(t, got.HasHandled("thread:T1"))
+}
+
+func TestPRAutomationPolicy_CIRepairFieldsPersist(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	repo := createTestRepo(t, s, "repo-1")
+	createTestSession(t, s, "session-1", repo.ID)
+
+	require.NoError(t, s.SavePRAutomationPolicy(ctx, &models.PRAutomationPolicy{
+		SessionID:        "session-1",
+		FixCI:            true,
+		MaxCIAttempts:    2,
+		CIAttempts:       1,
+		CILastSHA:        "abc123",
+		CILastFailedJobs: []string{"test", "lint"},
+		CIRepeatCount:    1,
+		CIStoppedReason:  models.PRAutomationStoppedCIRepeating,
+	}))
+
+	got, err := s.GetPRAutomationPolicy(ctx, "session-1")
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.True(t, got.FixCI)
+	assert.False(t, got.AddressFeedback)
+	assert.Equal(t, 2, got.MaxCIAttempts)
+	assert.Equal(t, 1, got.CIAttempts)
+	assert.Equal(t, "abc123", got.CILastSHA)
+	assert.Equal(t, []string{"test", "lint"}, got.CILastFailedJobs)
+	assert.Equal(t, 1, got.CIRepeatCount)
+	assert.Equal(t, models.PRAutomationStoppedCIRepeating, got.CIStoppedReason)
+	assert.False(t, got.CIRepairActive())
+}
+
+func TestListActivePRAutomationPolicies(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	repo := createTestRepo(t, s, "repo-1")
+	createTestSession(t, s, "s-active", repo.ID)
+	createTestSession(t, s, "s-disabled", repo.ID)
+	createTestSession(t, s, "s-stopped", repo.ID)
+	createTestSession(t, s, "s-ci", repo.ID)
+	createTestSession(t, s, "s-ci-stopped", repo.ID)
+
+	require.NoError(t, s.SavePRAutomationPolicy(ctx, &models.PRAutomationPolicy{SessionID: "s-active", AddressFeedback: true}))
+	require.NoError(t, s.SavePRAutomationPolicy(ctx, &models.PRAutomationPolicy{SessionID: "s-disabled"}))
+	require.NoError(t, s.SavePRAutomationPolicy(ctx, &models.PRAutomationPolicy{
+		SessionID: "s-stopped", AddressFeedback: true, StoppedReason: models.PRAutomationStoppedBudget,
+	}))
+	// A stopped feedback loop doesn't hide a CI repair loop that is still running.
+	require.NoError(t, s.SavePRAutomationPolicy(ctx, &models.PRAutomationPolicy{
+		SessionID: "s-ci", AddressFeedback: true, StoppedReason: models.PRAutomationStoppedMaxIterations, FixCI: true,
+	}))
+	require.NoError(t, s.SavePRAutomationPolicy(ctx, &models.PRAutomationPolicy{
+		SessionID: "s-ci-stopped", FixCI: true, CIStoppedReason: models.PRAutomationStoppedMaxCIAttempts,
+	}))
+
+	policies, err := s.ListActivePRAutomationPolicies(ctx)
+	require.NoError(t, err)
+	var ids []string
+	for _, p := range policies {
+		ids = append(ids, p.SessionID)
+	}
+	assert.ElementsMatch(t, []string{"s-active", "s-ci"}, ids)
+}
+
+func TestPRAutomationPolicy_DeletedWithSession(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	repo := createTestRepo(t, s, "repo-1")
+	createTestSession(t, s, "session-1", repo.ID)
+	require.NoError(t, s.SavePRAutomationPolicy(ctx, &models.PRAutomationPolicy{SessionID: "session-1", AddressFeedback: true}))
+
+	require.NoError(t, s.DeleteSession(ctx, "session-1"))
+
+	got, err := s.GetPRAutomationPolicy(ctx, "session-1")
+	require.NoError(t, err)
+	assert.Nil(t, got)
+}
@@ -627,3 +627,45 @@ func TestReviewComment_TitleFieldRoundTrip(t *testing.T) {
 	require.Len(t, fileList, 1)
 	assert.Equal(t, "Memory leak fixed", fileList[0].Title)
 }
+
+func TestReviewComment_GitHubLinkagePersists(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	repo := createTestRepo(t, s, "repo-1")
+	session := createTestSession(t, s, "session-1", repo.ID)
+
+	comment := &models.ReviewComment{
+		ID:              "gh-1",
+		SessionID:       session.ID,
+		FilePath:        "src/main.go",
+		LineNumber:      7,
+		Content:         "Please add a nil check",
+		Source:          models.CommentSourceGitHub,
+		Author:          "octocat",
+		CreatedAt:       time.Now(),
+		GitHubCommentID: 1001,
+		GitHubThreadID:  "PRRT_abc",
+		GitHubURL:       "https://github.com/org/repo/pull/1#discussion_r1001",
+	}
+	require.NoError(t, s.AddReviewComment(ctx, comment))
+
+	got, err := s.GetReviewComment(ctx, "gh-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1001), got.GitHubCommentID)
+	assert.Equal(t, "PRRT_abc", got.GitHubThreadID)
+	assert.Equal(t, "https://github.com/org/repo/pull/1#discussion_r1001", got.GitHubURL)
+	assert.False(t, got.GitHubResolved)
+	assert.True(t, got.IsLinkedToGitHub())
+
+	require.NoError(t, s.UpdateReviewComment(ctx, "gh-1", func(c *models.ReviewComment) {
+		c.Resolved = true
+		c.GitHubResolved = true
+	}))
+
+	list, err := s.ListReviewComments(ctx, session.ID)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.True(t, list[0].Resolved)
+	assert.True(t, list[0].GitHubResolved)
+	assert.Equal(t, "PRRT_abc", list[0].GitHubThreadID)
+}
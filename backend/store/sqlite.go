@@ -518,8 +518,8 @@ func (s *SQLiteStore) AddSession(ctx context.Context, session *models.Session) e
 				task, status, agent_id, pr_status, pr_url, pr_number, pr_title, has_merge_conflict,
 				has_check_failures, check_status, stats_additions, stats_deletions, pinned, archived,
 				priority, task_status, archive_summary, archive_summary_status, auto_named,
-				session_type, scheduled_task_id, created_at, updated_at)
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				session_type, scheduled_task_id, parent_session_id, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 			session.ID, session.WorkspaceID, session.Name, session.Branch,
 			session.WorktreePath, session.BaseCommitSHA, nullString(session.TargetBranch),
 			session.Task, session.Status, session.AgentID,
@@ -530,7 +530,7 @@ func (s *SQLiteStore) AddSession(ctx context.Context, session *models.Session) e
 			session.Priority, session.TaskStatus,
 			session.ArchiveSummary, session.ArchiveSummaryStatus,
 			boolToInt(session.AutoNamed),
-			sessionType, nullString(session.ScheduledTaskID), session.CreatedAt, session.UpdatedAt)
+			sessionType, nullString(session.ScheduledTaskID), nullString(session.ParentSessionID), session.CreatedAt, session.UpdatedAt)
 		return err
 	})
 }
@@ -538,7 +538,7 @@ func (s *SQLiteStore) AddSession(ctx context.Context, session *models.Session) e
 func (s *SQLiteStore) GetSession(ctx context.Context, id string) (*models.Session, error) {
 	var session models.Session
 	var hasMergeConflict, hasCheckFailures, statsAdditions, statsDeletions, pinned, archived, autoNamed int
-	var agentID, targetBranch, scheduledTaskID sql.NullString
+	var agentID, targetBranch, scheduledTaskID, parentSessionID sql.NullString
 
 	err := s.db.QueryRowContext(ctx, `
 		SELECT id, workspace_id, name, branch, worktree_path, base_commit_sha, target_branch,
@@ -546,7 +546,7 @@ func (s *SQLiteStore) GetSession(ctx context.Context, id string) (*models.Sessio
 			pr_status, pr_url, pr_number, pr_title, has_merge_conflict, has_check_failures, check_status,
 			stats_additions, stats_deletions, pinned, archived, priority, task_status,
 			archive_summary, archive_summary_status, auto_named, session_type,
-			scheduled_task_id, created_at, updated_at
+			scheduled_task_id, parent_session_id, created_at, updated_at
 		FROM sessions WHERE id = ?`, id).Scan(
 		&session.ID, &session.WorkspaceID, &session.Name, &session.Branch,
 		&session.WorktreePath, &session.BaseCommitSHA, &targetBranch,
@@ -556,7 +556,7 @@ func (s *SQLiteStore) GetSession(ctx context.Context, id string) (*models.Sessio
 		&pinned, &archived, &session.Priority, &session.TaskStatus,
 		&session.ArchiveSummary, &session.ArchiveSummaryStatus,
 		&autoNamed, &session.SessionType,
-		&scheduledTaskID, &session.CreatedAt, &session.UpdatedAt)
+		&scheduledTaskID, &parentSessionID, &session.CreatedAt, &session.UpdatedAt)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -578,6 +578,9 @@ func (s *SQLiteStore) GetSession(ctx context.Context, id string) (*models.Sessio
 	if scheduledTaskID.Valid {
 		session.ScheduledTaskID = scheduledTaskID.String
 	}
+	if parentSessionID.Valid {
+		session.ParentSessionID = parentSessionID.String
+	}
 	if statsAdditions > 0 || statsDeletions > 0 {
 		session.Stats = &models.SessionStats{
 			Additions: statsAdditions,
@@ -593,14 +596,14 @@ func (s *SQLiteStore) GetSession(ctx context.Context, id string) (*models.Sessio
 func (s *SQLiteStore) GetSessionWithWorkspace(ctx context.Context, id string) (*models.SessionWithWorkspace, error) {
 	var result models.SessionWithWorkspace
 	var hasMergeConflict, hasCheckFailures, statsAdditions, statsDeletions, pinned, archived, autoNamed int
-	var agentID, targetBranch, scheduledTaskID sql.NullString
+	var agentID, targetBranch, scheduledTaskID, parentSessionID sql.NullString
 
 	err := s.db.QueryRowContext(ctx, `
 		SELECT s.id, s.workspace_id, s.name, s.branch, s.worktree_path, s.base_commit_sha,
 			s.target_branch, s.task, s.status, s.agent_id, s.pr_status, s.pr_url, s.pr_number, s.pr_title,
 			s.has_merge_conflict, s.has_check_failures, s.check_status, s.stats_additions, s.stats_deletions,
 			s.pinned, s.archived, s.priority, s.task_status, s.archive_summary, s.archive_summary_status,
-			s.auto_named, s.session_type, s.scheduled_task_id,
+			s.auto_named, s.session_type, s.scheduled_task_id, s.parent_session_id,
 			s.created_at, s.updated_at,
 			r.path, r.branch, r.remote
 		FROM sessions s
@@ -612,7 +615,7 @@ func (s *SQLiteStore) GetSessionWithWorkspace(ctx context.Context, id string) (*
 		&result.PRStatus, &result.PRUrl, &result.PRNumber, &result.PRTitle,
 		&hasMergeConflict, &hasCheckFailures, &result.CheckStatus, &statsAdditions, &statsDeletions,
 		&pinned, &archived, &result.Priority, &result.TaskStatus, &result.ArchiveSummary, &result.ArchiveSummaryStatus,
-		&autoNamed, &result.SessionType, &scheduledTaskID,
+		&autoNamed, &result.SessionType, &scheduledTaskID, &parentSessionID,
 		&result.CreatedAt, &result.UpdatedAt,
 		&result.WorkspacePath, &result.WorkspaceBranch, &result.WorkspaceRemote)
 	if err == sql.ErrNoRows {
@@ -636,6 +639,9 @@ func (s *SQLiteStore) GetSessionWithWorkspace(ctx context.Context, id string) (*
 	if scheduledTaskID.Valid {
 		result.ScheduledTaskID = scheduledTaskID.String
 	}
+	if parentSessionID.Valid {
+		result.ParentSessionID = parentSessionID.String
+	}
 	if statsAdditions > 0 || statsDeletions > 0 {
 		result.Stats = &models.SessionStats{
 			Additions: statsAdditions,
@@ -652,7 +658,7 @@ func (s *SQLiteStore) ListSessions(ctx context.Context, workspaceID string, incl
 		pr_status, pr_url, pr_number, pr_title, has_merge_conflict, has_check_failures, check_status,
 		stats_additions, stats_deletions, pinned, archived, priority, task_status,
 		archive_summary, archive_summary_status, auto_named, session_type,
-		scheduled_task_id, created_at, updated_at
+		scheduled_task_id, parent_session_id, created_at, updated_at
 		FROM sessions WHERE workspace_id = ?`
 	if !includeArchived {
 		query += " AND archived = 0"
@@ -668,7 +674,7 @@ func (s *SQLiteStore) ListSessions(ctx context.Context, workspaceID string, incl
 	for rows.Next() {
 		var session models.Session
 		var hasMergeConflict, hasCheckFailures, statsAdditions, statsDeletions, pinned, archived, autoNamed int
-		var agentID, targetBranch, scheduledTaskID sql.NullString
+		var agentID, targetBranch, scheduledTaskID, parentSessionID sql.NullString
 
 		if err := rows.Scan(
 			&session.ID, &session.WorkspaceID, &session.Name, &session.Branch,
@@ -679,7 +685,7 @@ func (s *SQLiteStore) ListSessions(ctx context.Context, workspaceID string, incl
 			&pinned, &archived, &session.Priority, &session.TaskStatus,
 			&session.ArchiveSummary, &session.ArchiveSummaryStatus,
 			&autoNamed, &session.SessionType,
-			&scheduledTaskID, &session.CreatedAt, &session.UpdatedAt); err != nil {
+			&scheduledTaskID, &parentSessionID, &session.CreatedAt, &session.UpdatedAt); err != nil {
 			return nil, fmt.Errorf("ListSessions scan: %w", err)
 		}
 
@@ -697,6 +703,9 @@ func (s *SQLiteStore) ListSessions(ctx context.Context, workspaceID string, incl
 		if scheduledTaskID.Valid {
 			session.ScheduledTaskID = scheduledTaskID.String
 		}
+		if parentSessionID.Valid {
+			session.ParentSessionID = parentSessionID.String
+		}
 		if statsAdditions > 0 || statsDeletions > 0 {
 			session.Stats = &models.SessionStats{
 				Additions: statsAdditions,
@@ -720,7 +729,7 @@ func (s *SQLiteStore) ListAllSessions(ctx context.Context, includeArchived bool)
 		pr_status, pr_url, pr_number, pr_title, has_merge_conflict, has_check_failures, check_status,
 		stats_additions, stats_deletions, pinned, archived, priority, task_status,
 		archive_summary, archive_summary_status, auto_named, session_type,
-		scheduled_task_id, created_at, updated_at
+		scheduled_task_id, parent_session_id, created_at, updated_at
 		FROM sessions`
 	if !includeArchived {
 		query += " WHERE archived = 0"
@@ -736,7 +745,7 @@ func (s *SQLiteStore) ListAllSessions(ctx context.Context, includeArchived bool)
 	for rows.Next() {
 		var session models.Session
 		var hasMergeConflict, hasCheckFailures, statsAdditions, statsDeletions, pinned, archived, autoNamed int
-		var agentID, targetBranch, scheduledTaskID sql.NullString
+		var agentID, targetBranch, scheduledTaskID, parentSessionID sql.NullString
 
 		if err := rows.Scan(
 			&session.ID, &session.WorkspaceID, &session.Name, &session.Branch,
@@ -747,7 +756,7 @@ func (s *SQLiteStore) ListAllSessions(ctx context.Context, includeArchived bool)
 			&pinned, &archived, &session.Priority, &session.TaskStatus,
 			&session.ArchiveSummary, &session.ArchiveSummaryStatus,
 			&autoNamed, &session.SessionType,
-			&scheduledTaskID, &session.CreatedAt, &session.UpdatedAt); err != nil {
+			&scheduledTaskID, &parentSessionID, &session.CreatedAt, &session.UpdatedAt); err != nil {
 			return nil, fmt.Errorf("ListAllSessions scan: %w", err)
 		}
 
@@ -765,6 +774,9 @@ func (s *SQLiteStore) ListAllSessions(ctx context.Context, includeArchived bool)
 		if scheduledTaskID.Valid {
 			session.ScheduledTaskID = scheduledTaskID.String
 		}
+		if parentSessionID.Valid {
+			session.ParentSessionID = parentSessionID.String
+		}
 		if statsAdditions > 0 || statsDeletions > 0 {
 			session.Stats = &models.SessionStats{
 				Additions: statsAdditions,
@@ -780,6 +792,73 @@ func (s *SQLiteStore) ListAllSessions(ctx context.Context, includeArchived bool)
 	return sessions, nil
 }
 
+// ListChildSessions returns the unarchived sessions stacked directly on
+// parentID, oldest first.
+func (s *SQLiteStore) ListChildSessions(ctx context.Context, parentID string) ([]*models.Session, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, workspace_id, name, branch, worktree_path, base_commit_sha, target_branch,
+		task, status, agent_id,
+		pr_status, pr_url, pr_number, pr_title, has_merge_conflict, has_check_failures, check_status,
+		stats_additions, stats_deletions, pinned, archived, priority, task_status,
+		archive_summary, archive_summary_status, auto_named, session_type,
+		scheduled_task_id, parent_session_id, created_at, updated_at
+		FROM sessions WHERE parent_session_id = ? AND archived = 0
+		ORDER BY created_at ASC`, parentID)
+	if err != nil {
+		return nil, fmt.Errorf("ListChildSessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []*models.Session{}
+	for rows.Next() {
+		var session models.Session
+		var hasMergeConflict, hasCheckFailures, statsAdditions, statsDeletions, pinned, archived, autoNamed int
+		var agentID, targetBranch, scheduledTaskID, parentSessionID sql.NullString
+
+		if err := rows.Scan(
+			&session.ID, &session.WorkspaceID, &session.Name, &session.Branch,
+			&session.WorktreePath, &session.BaseCommitSHA, &targetBranch,
+			&session.Task, &session.Status, &agentID,
+			&session.PRStatus, &session.PRUrl, &session.PRNumber, &session.PRTitle,
+			&hasMergeConflict, &hasCheckFailures, &session.CheckStatus, &statsAdditions, &statsDeletions,
+			&pinned, &archived, &session.Priority, &session.TaskStatus,
+			&session.ArchiveSummary, &session.ArchiveSummaryStatus,
+			&autoNamed, &session.SessionType,
+			&scheduledTaskID, &parentSessionID, &session.CreatedAt, &session.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("ListChildSessions scan: %w", err)
+		}
+
+		session.HasMergeConflict = intToBool(hasMergeConflict)
+		session.HasCheckFailures = intToBool(hasCheckFailures)
+		session.Pinned = intToBool(pinned)
+		session.Archived = intToBool(archived)
+		session.AutoNamed = intToBool(autoNamed)
+		if agentID.Valid {
+			session.AgentID = agentID.String
+		}
+		if targetBranch.Valid {
+			session.TargetBranch = targetBranch.String
+		}
+		if scheduledTaskID.Valid {
+			session.ScheduledTaskID = scheduledTaskID.String
+		}
+		if parentSessionID.Valid {
+			session.ParentSessionID = parentSessionID.String
+		}
+		if statsAdditions > 0 || statsDeletions > 0 {
+			session.Stats = &models.SessionStats{
+				Additions: statsAdditions,
+				Deletions: statsDeletions,
+			}
+		}
+
+		sessions = append(sessions, &session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ListChildSessions rows: %w", err)
+	}
+	return sessions, nil
+}
+
 func (s *SQLiteStore) UpdateSession(ctx context.Context, id string, updates func(*models.Session)) error {
 	// Read current state outside retry to avoid stale data on retry
 	session, err := s.getSessionNoLock(ctx, id)
@@ -809,7 +888,7 @@ func (s *SQLiteStore) UpdateSession(ctx context.Context, id string, updates func
 				pr_number = ?, pr_title = ?, has_merge_conflict = ?, has_check_failures = ?, check_status = ?,
 				stats_additions = ?, stats_deletions = ?, pinned = ?, archived = ?,
 				priority = ?, task_status = ?, archive_summary = ?, archive_summary_status = ?,
-				auto_named = ?, session_type = ?, scheduled_task_id = ?, updated_at = ?
+				auto_named = ?, session_type = ?, scheduled_task_id = ?, parent_session_id = ?, updated_at = ?
 			WHERE id = ?`,
 			session.Name, session.Branch, session.WorktreePath, session.BaseCommitSHA,
 			nullString(session.TargetBranch),
@@ -820,7 +899,7 @@ func (s *SQLiteStore) UpdateSession(ctx context.Context, id string, updates func
 			session.Priority, session.TaskStatus,
 			session.ArchiveSummary, session.ArchiveSummaryStatus,
 			boolToInt(session.AutoNamed), session.SessionType,
-			nullString(session.ScheduledTaskID), session.UpdatedAt, id)
+			nullString(session.ScheduledTaskID), nullString(session.ParentSessionID), session.UpdatedAt, id)
 		return err
 	})
 }
@@ -828,7 +907,7 @@ func (s *SQLiteStore) UpdateSession(ctx context.Context, id string, updates func
 func (s *SQLiteStore) getSessionNoLock(ctx context.Context, id string) (*models.Session, error) {
 	var session models.Session
 	var hasMergeConflict, hasCheckFailures, statsAdditions, statsDeletions, pinned, archived, autoNamed int
-	var agentID, targetBranch, scheduledTaskID sql.NullString
+	var agentID, targetBranch, scheduledTaskID, parentSessionID sql.NullString
 
 	err := s.db.QueryRowContext(ctx, `
 		SELECT id, workspace_id, name, branch, worktree_path, base_commit_sha, target_branch,
@@ -836,7 +915,7 @@ func (s *SQLiteStore) getSessionNoLock(ctx context.Context, id string) (*models.
 			pr_status, pr_url, pr_number, pr_title, has_merge_conflict, has_check_failures, check_status,
 			stats_additions, stats_deletions, pinned, archived, priority, task_status,
 			archive_summary, archive_summary_status, auto_named, session_type,
-			scheduled_task_id, created_at, updated_at
+			scheduled_task_id, parent_session_id, created_at, updated_at
 		FROM sessions WHERE id = ?`, id).Scan(
 		&session.ID, &session.WorkspaceID, &session.Name, &session.Branch,
 		&session.WorktreePath, &session.BaseCommitSHA, &targetBranch,
@@ -846,7 +925,7 @@ func (s *SQLiteStore) getSessionNoLock(ctx context.Context, id string) (*models.
 		&pinned, &archived, &session.Priority, &session.TaskStatus,
 		&session.ArchiveSummary, &session.ArchiveSummaryStatus,
 		&autoNamed, &session.SessionType,
-		&scheduledTaskID, &session.CreatedAt, &session.UpdatedAt)
+		&scheduledTaskID, &parentSessionID, &session.CreatedAt, &session.UpdatedAt)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -868,6 +947,9 @@ func (s *SQLiteStore) getSessionNoLock(ctx context.Context, id string) (*models.
 	if scheduledTaskID.Valid {
 		session.ScheduledTaskID = scheduledTaskID.String
 	}
+	if parentSessionID.Valid {
+		session.ParentSessionID = parentSessionID.String
+	}
 	if statsAdditions > 0 || statsDeletions > 0 {
 		session.Stats = &models.SessionStats{
 			Additions: statsAdditions,
@@ -2561,48 +2643,36 @@ func (s *SQLiteStore) SaveFileTabs(ctx context.Context, workspaceID string, tabs
 // ReviewComment methods
 // ============================================================================
 
-func (s *SQLiteStore) AddReviewComment(ctx context.Context, comment *models.ReviewComment) error {
-	var severity sql.NullString
-	if comment.Severity != "" {
-		severity = sql.NullString{String: comment.Severity, Valid: true}
-	}
+// reviewCommentColumns is the column list shared by all review comment SELECTs.
+// Keep in sync with scanReviewComment.
+const reviewCommentColumns = `id, session_id, file_path, line_number, title, content, source, author, severity, created_at, resolved, resolved_at, resolved_by, resolution_type, github_comment_id, github_thread_id, github_url, github_resolved`
 
-	_, err := s.db.ExecContext(ctx, `
-		INSERT INTO review_comments (id, session_id, file_path, line_number, title, content, source, author, severity, created_at, resolved, resolution_type)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-		comment.ID, comment.SessionID, comment.FilePath, comment.LineNumber,
-		comment.Title, comment.Content, comment.Source, comment.Author, severity,
-		comment.CreatedAt, boolToInt(comment.Resolved), comment.ResolutionType)
-	if err != nil {
-		return fmt.Errorf("AddReviewComment: %w", err)
-	}
-	return nil
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
 }
 
-func (s *SQLiteStore) GetReviewComment(ctx context.Context, id string) (*models.ReviewComment, error) {
+// scanReviewComment scans a row selected with reviewCommentColumns.
+func scanReviewComment(row rowScanner) (*models.ReviewComment, error) {
 	var comment models.ReviewComment
+	var title sql.NullString
 	var severity sql.NullString
 	var resolved int
 	var resolvedAt sql.NullTime
 	var resolvedBy sql.NullString
 	var resolutionType sql.NullString
+	var githubResolved int
 
-	var title sql.NullString
-
-	err := s.db.QueryRowContext(ctx, `
-		SELECT id, session_id, file_path, line_number, title, content, source, author, severity, created_at, resolved, resolved_at, resolved_by, resolution_type
-		FROM review_comments WHERE id = ?`, id).Scan(
+	if err := row.Scan(
 		&comment.ID, &comment.SessionID, &comment.FilePath, &comment.LineNumber,
 		&title, &comment.Content, &comment.Source, &comment.Author, &severity,
-		&comment.CreatedAt, &resolved, &resolvedAt, &resolvedBy, &resolutionType)
-	if err == sql.ErrNoRows {
-		return nil, nil
-	}
-	if err != nil {
-		return nil, fmt.Errorf("GetReviewComment: %w", err)
+		&comment.CreatedAt, &resolved, &resolvedAt, &resolvedBy, &resolutionType,
+		&comment.GitHubCommentID, &comment.GitHubThreadID, &comment.GitHubURL, &githubResolved); err != nil {
+		return nil, err
 	}
 
 	comment.Resolved = intToBool(resolved)
+	comment.GitHubResolved = intToBool(githubResolved)
 	if title.Valid {
 		comment.Title = title.String
 	}
@@ -2618,13 +2688,48 @@ func (s *SQLiteStore) GetReviewComment(ctx context.Context, id string) (*models.
 	if resolutionType.Valid {
 		comment.ResolutionType = resolutionType.String
 	}
-
 	return &comment, nil
 }
 
+func (s *SQLiteStore) AddReviewComment(ctx context.Context, comment *models.ReviewComment) error {
+	var severity sql.NullString
+	if comment.Severity != "" {
+		severity = sql.NullString{String: comment.Severity, Valid: true}
+	}
+	var resolvedAt sql.NullTime
+	if comment.ResolvedAt != nil {
+		resolvedAt = sql.NullTime{Time: *comment.ResolvedAt, Valid: true}
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO review_comments (id, session_id, file_path, line_number, title, content, source, author, severity, created_at, resolved, resolved_at, resolved_by, resolution_type, github_comment_id, github_thread_id, github_url, github_resolved)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		comment.ID, comment.SessionID, comment.FilePath, comment.LineNumber,
+		comment.Title, comment.Content, comment.Source, comment.Author, severity,
+		comment.CreatedAt, boolToInt(comment.Resolved), resolvedAt, nullString(comment.ResolvedBy), comment.ResolutionType,
+		comment.GitHubCommentID, comment.GitHubThreadID, comment.GitHubURL, boolToInt(comment.GitHubResolved))
+	if err != nil {
+		return fmt.Errorf("AddReviewComment: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) GetReviewComment(ctx context.Context, id string) (*models.ReviewComment, error) {
+	comment, err := scanReviewComment(s.db.QueryRowContext(ctx, `
+		SELECT `+reviewCommentColumns+`
+		FROM review_comments WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("GetReviewComment: %w", err)
+	}
+	return comment, nil
+}
+
 func (s *SQLiteStore) ListReviewComments(ctx context.Context, sessionID string) ([]*models.ReviewComment, error) {
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT id, session_id, file_path, line_number, title, content, source, author, severity, created_at, resolved, resolved_at, resolved_by, resolution_type
+		SELECT `+reviewCommentColumns+`
 		FROM review_comments WHERE session_id = ?
 		ORDER BY file_path, line_number`, sessionID)
 	if err != nil {
@@ -2634,39 +2739,11 @@ func (s *SQLiteStore) ListReviewComments(ctx context.Context, sessionID string)
 
 	comments := []*models.ReviewComment{}
 	for rows.Next() {
-		var comment models.ReviewComment
-		var title sql.NullString
-		var severity sql.NullString
-		var resolved int
-		var resolvedAt sql.NullTime
-		var resolvedBy sql.NullString
-		var resolutionType sql.NullString
-
-		if err := rows.Scan(
-			&comment.ID, &comment.SessionID, &comment.FilePath, &comment.LineNumber,
-			&title, &comment.Content, &comment.Source, &comment.Author, &severity,
-			&comment.CreatedAt, &resolved, &resolvedAt, &resolvedBy, &resolutionType); err != nil {
+		comment, err := scanReviewComment(rows)
+		if err != nil {
 			return nil, fmt.Errorf("ListReviewComments scan: %w", err)
 		}
-
-		comment.Resolved = intToBool(resolved)
-		if title.Valid {
-			comment.Title = title.String
-		}
-		if severity.Valid {
-			comment.Severity = severity.String
-		}
-		if resolvedAt.Valid {
-			comment.ResolvedAt = &resolvedAt.Time
-		}
-		if resolvedBy.Valid {
-			comment.ResolvedBy = resolvedBy.String
-		}
-		if resolutionType.Valid {
-			comment.ResolutionType = resolutionType.String
-		}
-
-		comments = append(comments, &comment)
+		comments = append(comments, comment)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("ListReviewComments rows: %w", err)
@@ -2676,7 +2753,7 @@ func (s *SQLiteStore) ListReviewComments(ctx context.Context, sessionID string)
 
 func (s *SQLiteStore) ListReviewCommentsForFile(ctx context.Context, sessionID, filePath string) ([]*models.ReviewComment, error) {
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT id, session_id, file_path, line_number, title, content, source, author, severity, created_at, resolved, resolved_at, resolved_by, resolution_type
+		SELECT `+reviewCommentColumns+`
 		FROM review_comments WHERE session_id = ? AND file_path = ?
 		ORDER BY line_number`, sessionID, filePath)
 	if err != nil {
@@ -2686,39 +2763,11 @@ func (s *SQLiteStore) ListReviewCommentsForFile(ctx context.Context, sessionID,
 
 	comments := []*models.ReviewComment{}
 	for rows.Next() {
-		var comment models.ReviewComment
-		var title sql.NullString
-		var severity sql.NullString
-		var resolved int
-		var resolvedAt sql.NullTime
-		var resolvedBy sql.NullString
-		var resolutionType sql.NullString
-
-		if err := rows.Scan(
-			&comment.ID, &comment.SessionID, &comment.FilePath, &comment.LineNumber,
-			&title, &comment.Content, &comment.Source, &comment.Author, &severity,
-			&comment.CreatedAt, &resolved, &resolvedAt, &resolvedBy, &resolutionType); err != nil {
+		comment, err := scanReviewComment(rows)
+		if err != nil {
 			return nil, fmt.Errorf("ListReviewCommentsForFile scan: %w", err)
 		}
-
-		comment.Resolved = intToBool(resolved)
-		if title.Valid {
-			comment.Title = title.String
-		}
-		if severity.Valid {
-			comment.Severity = severity.String
-		}
-		if resolvedAt.Valid {
-			comment.ResolvedAt = &resolvedAt.Time
-		}
-		if resolvedBy.Valid {
-			comment.ResolvedBy = resolvedBy.String
-		}
-		if resolutionType.Valid {
-			comment.ResolutionType = resolutionType.String
-		}
-
-		comments = append(comments, &comment)
+		comments = append(comments, comment)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("ListReviewCommentsForFile rows: %w", err)
@@ -2781,9 +2830,11 @@ func (s *SQLiteStore) UpdateReviewComment(ctx context.Context, id string, update
 
 	_, err = s.db.ExecContext(ctx, `
 		UPDATE review_comments SET
-			title = ?, content = ?, severity = ?, resolved = ?, resolved_at = ?, resolved_by = ?, resolution_type = ?
+			title = ?, content = ?, severity = ?, resolved = ?, resolved_at = ?, resolved_by = ?, resolution_type = ?,
+			github_comment_id = ?, github_thread_id = ?, github_url = ?, github_resolved = ?
 		WHERE id = ?`,
-		comment.Title, comment.Content, severity, boolToInt(comment.Resolved), resolvedAt, resolvedBy, comment.ResolutionType, id)
+		comment.Title, comment.Content, severity, boolToInt(comment.Resolved), resolvedAt, resolvedBy, comment.ResolutionType,
+		comment.GitHubCommentID, comment.GitHubThreadID, comment.GitHubURL, boolToInt(comment.GitHubResolved), id)
 	if err != nil {
 		return fmt.Errorf("UpdateReviewComment: %w", err)
 	}
@@ -451,6 +451,51 @@ func TestListAllSessions_ReadsArchivedField(t *testing.T) {
 	assert.True(t, sessions[0].Archived, "Archived field should be read from DB")
 }
 
+func TestListChildSessions(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+	createTestRepo(t, s, "ws-1")
+
+	createTestSession(t, s, "parent", "ws-1")
+	createTestSession(t, s, "unrelated", "ws-1")
+	for _, id := range []string{"child-1", "child-2", "child-archived"} {
+		require.NoError(t, s.AddSession(ctx, &models.Session{
+			ID:              id,
+			WorkspaceID:     "ws-1",
+			Name:            id,
+			Branch:          "feature/" + id,
+			TargetBranch:    "origin/feature/parent",
+			ParentSessionID: "parent",
+			Status:          "idle",
+			CreatedAt:       time.Now(),
+			UpdatedAt:       time.Now(),
+		}))
+	}
+	require.NoError(t, s.UpdateSession(ctx, "child-archived", func(sess *models.Session) {
+		sess.Archived = true
+	}))
+
+	children, err := s.ListChildSessions(ctx, "parent")
+	require.NoError(t, err)
+	require.Len(t, children, 2)
+	assert.Equal(t, "child-1", children[0].ID)
+	assert.Equal(t, "child-2", children[1].ID)
+	assert.Equal(t, "parent", children[0].ParentSessionID)
+	assert.True(t, children[0].IsStacked())
+
+	// Unstacking clears the parent link
+	require.NoError(t, s.UpdateSession(ctx, "child-1", func(sess *models.Session) {
+		sess.ParentSessionID = ""
+	}))
+	got, err := s.GetSession(ctx, "child-1")
+	require.NoError(t, err)
+	assert.False(t, got.IsStacked())
+
+	children, err = s.ListChildSessions(ctx, "parent")
+	require.NoError(t, err)
+	assert.Len(t, children, 1)
+}
+
 func TestGetReposByIDs(t *testing.T) {
 	ctx := context.Background()
 	s := newTestStore(t)
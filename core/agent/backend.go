@@ -1,6 +1,7 @@
 package agent
 
 import (
+	"context"
 	"encoding/json"
 
 	core "github.com/chatml/chatml-core"
@@ -150,6 +151,33 @@ type ConversationBackend interface {
 	Options() ProcessOptions
 }
 
+// CompactReport describes a finished context compaction. Token counts are
+// estimates of the conversation history before and after, including any
+// context restored afterwards.
+type CompactReport struct {
+	Trigger    string `json:"trigger"` // "auto" or "manual"
+	PreTokens  int    `json:"preTokens"`
+	PostTokens int    `json:"postTokens"`
+	Summary    string `json:"summary"`
+}
+
+// Compacter is implemented by backends that can compact their context on
+// demand. The agent-runner process has no such method; it handles "/compact"
+// as a slash command instead.
+type Compacter interface {
+	// Compact summarizes the conversation so far, optionally focused by the
+	// user's instructions, and waits for the result. It runs between turns.
+	Compact(ctx context.Context, instructions string) (*CompactReport, error)
+}
+
+// ContextReporter is implemented by backends that can attribute their context
+// window usage to categories (system prompt, tools, messages, ...).
+type ContextReporter interface {
+	// ContextBreakdown estimates what the next request would send, per
+	// category, and waits for the result. It runs between turns.
+	ContextBreakdown(ctx context.Context) (*core.ContextBreakdown, error)
+}
+
 // NativeBackendFactory is a factory function that creates a ConversationBackend
 // using the native Go agentic loop. The apiKey and oauthToken are passed so
 // the factory can select and authenticate a provider without importing backend
@@ -10,6 +10,7 @@ type ProcessOptions struct {
 	BackendSessionID    string            // Backend session ID for MCP tools (distinct from SDK session ID)
 	ResumeSession       string            // Session ID to resume
 	ForkSession         bool              // Whether to fork the session
+	ForkAtTurn          int               // With ForkSession: keep only the first N user turns (0 = all; native loop only)
 	LinearIssue         string            // Linear issue identifier (e.g., "LIN-123")
 	ToolPreset          string            // Tool preset: full, read-only, no-bash, safe-edit
 	EnableCheckpointing bool              // Enable file checkpointing for rewind
@@ -33,5 +34,9 @@ type ProcessOptions struct {
 	AgentsJSON          string            // JSON object of programmatic agent definitions (SDK 0.2.62+)
 	PermissionRulesFile string            // Path to JSON file with persistent permission rules
 	OllamaEndpoint      string            // Ollama server endpoint for local model inference (e.g., "http://127.0.0.1:39421")
+	OllamaAuthToken     string            // Bearer token for a remote Ollama endpoint behind an authenticating proxy
 	Skills              string            // Comma-separated skill IDs, or "all" (SDK 0.2.120+)
+
+	DisableSecretRedaction   bool     // Pass tool output to the model without scrubbing secrets
+	SecretRedactionAllowlist []string // Values (or "*" globs) never to redact; "rule:<kind>" disables a detector
 }
@@ -3,6 +3,8 @@ package agent
 import (
 	"encoding/json"
 	"strings"
+
+	core "github.com/chatml/chatml-core"
 )
 
 // AgentEvent represents a parsed event from the agent-runner stdout
@@ -28,6 +30,7 @@ type AgentEvent struct {
 	Cost           float64                `json:"cost,omitempty"`
 	Turns          int                    `json:"turns,omitempty"`
 	Todos          []TodoItem             `json:"todos,omitempty"`
+	Tasks          []TaskItem             `json:"tasks,omitempty"` // task_list_update: full task list
 	Raw            string                 `json:"-"`
 
 	// Session management fields
@@ -82,6 +85,7 @@ type AgentEvent struct {
 	// Compaction fields — shared across compact_boundary, pre_compact, and post_compact events
 	Trigger            string `json:"trigger,omitempty"`
 	PreTokens          int    `json:"preTokens,omitempty"`
+	PostTokens         int    `json:"postTokens,omitempty"` // Native loop: estimated tokens after compaction
 	CustomInstructions string `json:"customInstructions,omitempty"`
 	CompactSummary     string `json:"compactSummary,omitempty"` // SDK 0.2.76: summary text from PostCompact hook
 
@@ -93,6 +97,9 @@ type AgentEvent struct {
 	ContextWindow            int `json:"contextWindow,omitempty"`
 	CumulativeTokens         int `json:"cumulativeTokens,omitempty"`
 
+	// Context breakdown (native loop): estimated usage per category
+	ContextBreakdown *core.ContextBreakdown `json:"contextBreakdown,omitempty"`
+
 	// Status fields
 	Status string `json:"status,omitempty"`
 
@@ -145,6 +152,11 @@ type AgentEvent struct {
 	Specifier          string               `json:"specifier,omitempty"`
 	BatchApprovalItems []BatchApprovalItem  `json:"batchApprovalItems,omitempty"`
 
+	// Permission decision fields (permission_decision audit events)
+	PermissionDecision string `json:"permissionDecision,omitempty"` // allow, deny
+	DecidedBy          string `json:"decidedBy,omitempty"`          // policy, rule:<source>, session, mode, user, hook:<event>, ...
+	ApprovalAction     string `json:"approvalAction,omitempty"`     // allow_once, allow_session, ... when decided by the user
+
 	// Input suggestion fields (Haiku-generated prompt suggestions)
 	GhostText string              `json:"ghostText,omitempty"`
 	Pills json.RawMessage `json:"pills,omitempty"`
@@ -331,6 +343,7 @@ const (
 	EventTypeToolEnd        = "tool_end"
 	EventTypeNameSuggestion = "name_suggestion"
 	EventTypeTodoUpdate     = "todo_update"
+	EventTypeTaskListUpdate = "task_list_update"
 	EventTypeResult         = "result"
 	EventTypeComplete       = "complete"
 	EventTypeTurnComplete   = "turn_complete"
@@ -382,6 +395,7 @@ const (
 	// Context usage events
 	EventTypeContextUsage      = "context_usage"
 	EventTypeContextWindowSize = "context_window_size"
+	EventTypeContextBreakdown  = "context_breakdown"
 
 	// User question events (AskUserQuestion tool)
 	EventTypeUserQuestionRequest = "user_question_request"
@@ -444,8 +458,24 @@ const (
 
 	// New event types from SDK 0.2.105
 	EventTypeMemoryRecall = "memory_recall"
+
+	// Native runner permission audit trail
+	EventTypePermissionDecision = "permission_decision"
 )
 
+// TaskItem is one task of the native loop's task list (TaskCreate/TaskUpdate
+// tools), as reported in task_list_update events.
+type TaskItem struct {
+	ID          string   `json:"id"`
+	Subject     string   `json:"subject"`
+	Description string   `json:"description,omitempty"`
+	ActiveForm  string   `json:"activeForm,omitempty"`
+	Status      string   `json:"status"` // "pending", "in_progress", "completed", "failed", "stopped"
+	Owner       string   `json:"owner,omitempty"`
+	Blocks      []string `json:"blocks,omitempty"`
+	BlockedBy   []string `json:"blockedBy,omitempty"`
+}
+
 // TodoItem represents a single todo item from the agent's TodoWrite tool
 type TodoItem struct {
 	Content    string `json:"content"`
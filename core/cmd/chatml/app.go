@@ -247,6 +247,9 @@ func (a *appState) handleEvent(e *agent.AgentEvent) {
 
 	case agent.EventTypePostCompact:
 		summary := "Context compacted"
+		if e.PostTokens > 0 {
+			summary += fmt.Sprintf(" (%d -> %d tokens)", e.PreTokens, e.PostTokens)
+		}
 		if e.CompactSummary != "" {
 			summary += ": " + truncate(e.CompactSummary, 60)
 		}
@@ -0,0 +1,582 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/chatml/chatml-core/agent"
+	"github.com/chatml/chatml-core/git"
+	"github.com/chatml/chatml-core/loop"
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+// chatml batch runs one prompt per manifest item, each in its own worktree on
+// a new branch, and writes a report of what changed. Nobody is there to
+// answer the agent: tool approvals are denied, questions are answered with a
+// fixed reply and plans are approved.
+//
+// Items run in acceptEdits mode unless they set permissionMode or -mode sets
+// another default: file edits in the worktree go through, while Bash commands
+// and other tools that need approval are denied unless allowed by permission
+// rules. bypassPermissions is only honoured when -mode bypassPermissions is
+// passed explicitly, whether it comes from the flag or the manifest.
+//
+// Manifest (YAML or JSON):
+//
+//	defaults:
+//	  model: claude-sonnet-4-6
+//	  maxBudgetUsd: 2
+//	items:
+//	  - repo: ~/src/api
+//	    prompt: Migrate from pkg/errors to the standard library
+//	  - repo: ~/src/web
+//	    prompt: Fix the lint errors reported by `make lint`
+//	    base: origin/develop
+//	    branch: chore/lint
+
+const (
+	batchQuestionReply = "No user is available to answer. Use your best judgement and continue."
+	batchStopTimeout   = 5 * time.Second
+)
+
+type batchManifest struct {
+	Defaults batchItem   `yaml:"defaults"`
+	Items    []batchItem `yaml:"items"`
+}
+
+type batchItem struct {
+	ID             string  `yaml:"id"`
+	Repo           string  `yaml:"repo"`
+	Prompt         string  `yaml:"prompt"`
+	Model          string  `yaml:"model"`
+	MaxBudgetUsd   float64 `yaml:"maxBudgetUsd"`
+	PermissionMode string  `yaml:"permissionMode"`
+	Instructions   string  `yaml:"instructions"`
+	Base           string  `yaml:"base"`   // ref to branch from (default HEAD)
+	Branch         string  `yaml:"branch"` // default chatml/batch-<run>-<id>
+}
+
+// Batch item outcomes.
+const (
+	batchChanged   = "changed"
+	batchUnchanged = "unchanged"
+	batchFailed    = "failed"
+)
+
+type batchResult struct {
+	ID           string           `json:"id"`
+	Repo         string           `json:"repo"`
+	Prompt       string           `json:"prompt"`
+	Model        string           `json:"model"`
+	Status       string           `json:"status"`
+	Error        string           `json:"error,omitempty"`
+	Branch       string           `json:"branch,omitempty"`
+	Worktree     string           `json:"worktree,omitempty"` // removed for unchanged items
+	BaseCommit   string           `json:"baseCommit,omitempty"`
+	Files        []git.FileChange `json:"files,omitempty"`
+	DiffFile     string           `json:"diffFile,omitempty"`
+	Pushed       bool             `json:"pushed,omitempty"`
+	CostUSD      float64          `json:"costUsd"`
+	Turns        int              `json:"turns"`
+	DeniedTools  int              `json:"deniedTools,omitempty"`
+	Summary      string           `json:"summary,omitempty"` // the agent's final message
+	DurationSecs float64          `json:"durationSecs"`
+}
+
+type batchReport struct {
+	RunID    string        `json:"runId"`
+	Started  time.Time     `json:"started"`
+	Finished time.Time     `json:"finished"`
+	CostUSD  float64       `json:"costUsd"`
+	Results  []batchResult `json:"results"`
+}
+
+type batchRunner struct {
+	runID       string
+	apiKey      string
+	factory     agent.NativeBackendFactory
+	worktrees   *git.WorktreeManager
+	repos       *git.RepoManager
+	worktreeDir string // parent of the items' worktrees
+	outDir      string
+	timeout     time.Duration
+	push        bool
+
+	// git worktree add takes repository locks; items in the same repo
+	// create their worktrees one at a time.
+	repoLocksMu sync.Mutex
+	repoLocks   map[string]*sync.Mutex
+}
+
+func runBatch(args []string) {
+	fs := flag.NewFlagSet("batch", flag.ExitOnError)
+	manifestPath := fs.String("manifest", "", "Manifest of items to run (YAML or JSON)")
+	concurrency := fs.Int("concurrency", 4, "Number of items to run at once")
+	outDir := fs.String("out", "", "Directory for the report and diffs (default: chatml-batch-<run id>)")
+	timeout := fs.Duration("timeout", 30*time.Minute, "Time limit per item")
+	modelFlag := fs.String("model", defaultModel, "Model for items that do not set one")
+	mode := fs.String("mode", "acceptEdits", "Permission mode for items that do not set one (bypassPermissions is only allowed when set here)")
+	push := fs.Bool("push", false, "Push the branches of changed items to origin")
+	apiKey := fs.String("api-key", "", "API key (default: ANTHROPIC_API_KEY env)")
+	fs.Parse(args) //nolint:errcheck // ExitOnError
+
+	if *manifestPath == "" && fs.NArg() > 0 {
+		*manifestPath = fs.Arg(0)
+	}
+	if *manifestPath == "" {
+		fmt.Fprintln(os.Stderr, "Usage: chatml batch [flags] -manifest items.yaml")
+		fs.PrintDefaults()
+		os.Exit(2)
+	}
+
+	key := *apiKey
+	if key == "" {
+		key = os.Getenv("ANTHROPIC_API_KEY")
+	}
+	if key == "" {
+		key = os.Getenv("OPENAI_API_KEY")
+	}
+	if key == "" {
+		fmt.Fprintln(os.Stderr, "Error: No API key. Set ANTHROPIC_API_KEY or use --api-key")
+		os.Exit(1)
+	}
+
+	items, err := loadBatchManifest(*manifestPath, batchItem{Model: *modelFlag, PermissionMode: *mode})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	runID := time.Now().Format("20060102-150405")
+	workspaces, err := git.WorkspacesBaseDir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if *outDir == "" {
+		*outDir = "chatml-batch-" + runID
+	}
+	if err := os.MkdirAll(filepath.Join(*outDir, "diffs"), 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	br := &batchRunner{
+		runID:       runID,
+		apiKey:      key,
+		factory:     loop.NewBackendFactory(),
+		worktrees:   git.NewWorktreeManager(),
+		repos:       git.NewRepoManager(),
+		worktreeDir: filepath.Join(workspaces, "batch-"+runID),
+		outDir:      *outDir,
+		timeout:     *timeout,
+		push:        *push,
+		repoLocks:   make(map[string]*sync.Mutex),
+	}
+
+	// First Ctrl+C cancels running items (they are reported as failed);
+	// the second exits immediately.
+	ctx, cancel := context.WithCancel(context.Background())
+	sigCh := make(chan os.Signal, 2)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		fmt.Fprintln(os.Stderr, "\nCancelling batch…")
+		cancel()
+		<-sigCh
+		os.Exit(130)
+	}()
+
+	fmt.Fprintf(os.Stderr, "chatml batch %s: %d items, concurrency %d\n", runID, len(items), *concurrency)
+	report := br.run(ctx, items, *concurrency)
+
+	if err := br.writeReport(report); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing report: %v\n", err)
+		os.Exit(1)
+	}
+	printBatchSummary(report, br.outDir)
+	for _, r := range report.Results {
+		if r.Status == batchFailed {
+			os.Exit(1)
+		}
+	}
+}
+
+// loadBatchManifest reads and validates a manifest. Item fields fall back to
+// the manifest's defaults, then to flagDefaults.
+func loadBatchManifest(path string, flagDefaults batchItem) ([]batchItem, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+	// YAML is a superset of JSON, so one decoder handles both.
+	var m batchManifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parse manifest: %w", err)
+	}
+	if len(m.Items) == 0 {
+		return nil, fmt.Errorf("manifest %s has no items", path)
+	}
+
+	home, _ := os.UserHomeDir()
+	seen := make(map[string]bool)
+	items := make([]batchItem, len(m.Items))
+	for i, it := range m.Items {
+		it = mergeBatchItem(mergeBatchItem(it, m.Defaults), flagDefaults)
+		if it.Repo == "" || strings.TrimSpace(it.Prompt) == "" {
+			return nil, fmt.Errorf("item %d: repo and prompt are required", i+1)
+		}
+		if rest, ok := strings.CutPrefix(it.Repo, "~/"); ok && home != "" {
+			it.Repo = filepath.Join(home, rest)
+		}
+		if abs, err := filepath.Abs(it.Repo); err == nil {
+			it.Repo = abs
+		}
+		if it.PermissionMode == "bypassPermissions" && flagDefaults.PermissionMode != "bypassPermissions" {
+			return nil, fmt.Errorf("item %d: permissionMode bypassPermissions requires -mode bypassPermissions", i+1)
+		}
+		if it.ID == "" {
+			it.ID = fmt.Sprintf("%02d-%s", i+1, filepath.Base(it.Repo))
+		}
+		it.ID = batchIDPattern.ReplaceAllString(it.ID, "-")
+		if seen[it.ID] {
+			return nil, fmt.Errorf("item %d: duplicate id %q", i+1, it.ID)
+		}
+		seen[it.ID] = true
+		items[i] = it
+	}
+	return items, nil
+}
+
+var batchIDPattern = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// mergeBatchItem fills the item's unset settings from defaults. Repo, prompt and
+// id are per item.
+func mergeBatchItem(it, defaults batchItem) batchItem {
+	if it.Model == "" {
+		it.Model = defaults.Model
+	}
+	if it.MaxBudgetUsd == 0 {
+		it.MaxBudgetUsd = defaults.MaxBudgetUsd
+	}
+	if it.PermissionMode == "" {
+		it.PermissionMode = defaults.PermissionMode
+	}
+	if it.Instructions == "" {
+		it.Instructions = defaults.Instructions
+	}
+	if it.Base == "" {
+		it.Base = defaults.Base
+	}
+	return it
+}
+
+// run executes the items with at most concurrency running at once. Results
+// keep manifest order.
+func (br *batchRunner) run(ctx context.Context, items []batchItem, concurrency int) *batchReport {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	report := &batchReport{RunID: br.runID, Started: time.Now(), Results: make([]batchResult, len(items))}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, it := range items {
+		wg.Add(1)
+		go func(i int, it batchItem) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			fmt.Fprintf(os.Stderr, "[%s] started\n", it.ID)
+			r := br.runItem(ctx, it)
+			line := fmt.Sprintf("[%s] %s · $%.4f · %s", it.ID, r.Status, r.CostUSD, formatDurationShort(time.Duration(r.DurationSecs*float64(time.Second))))
+			if r.Error != "" {
+				line += " · " + r.Error
+			}
+			fmt.Fprintln(os.Stderr, line)
+			report.Results[i] = r
+		}(i, it)
+	}
+	wg.Wait()
+	report.Finished = time.Now()
+	for _, r := range report.Results {
+		report.CostUSD += r.CostUSD
+	}
+	return report
+}
+
+func (br *batchRunner) runItem(ctx context.Context, it batchItem) (res batchResult) {
+	start := time.Now()
+	res = batchResult{ID: it.ID, Repo: it.Repo, Prompt: it.Prompt, Model: it.Model}
+	defer func() { res.DurationSecs = time.Since(start).Round(time.Second).Seconds() }()
+	fail := func(err error) batchResult {
+		res.Status = batchFailed
+		res.Error = err.Error()
+		return res
+	}
+
+	if err := br.repos.ValidateRepo(it.Repo); err != nil {
+		return fail(err)
+	}
+	base := it.Base
+	if base == "" {
+		base = "HEAD"
+	}
+	branch := it.Branch
+	if branch == "" {
+		branch = fmt.Sprintf("chatml/batch-%s-%s", br.runID, it.ID)
+	}
+	if err := git.ValidateGitRef(branch); err != nil {
+		return fail(fmt.Errorf("invalid branch: %w", err))
+	}
+
+	lock := br.repoLock(it.Repo)
+	lock.Lock()
+	wt, _, baseCommit, err := br.worktrees.CreateAtPath(ctx, it.Repo, filepath.Join(br.worktreeDir, it.ID), branch, base)
+	lock.Unlock()
+	if err != nil {
+		return fail(err)
+	}
+	res.Worktree, res.Branch, res.BaseCommit = wt, branch, baseCommit
+
+	itemCtx, cancel := context.WithTimeout(ctx, br.timeout)
+	defer cancel()
+	runErr := br.runAgent(itemCtx, it, wt, &res)
+
+	// Commit whatever the agent left behind, even after a failure or
+	// cancellation, so the branch holds all of its work.
+	ctx = context.WithoutCancel(ctx)
+	if err := commitAll(ctx, wt, it.Prompt); err != nil && runErr == nil {
+		runErr = err
+	}
+	files, err := br.repos.GetChangedFilesWithStats(ctx, wt, baseCommit)
+	if err != nil && runErr == nil {
+		runErr = fmt.Errorf("list changes: %w", err)
+	}
+	res.Files = files
+
+	if len(files) > 0 {
+		diff, err := gitOutput(ctx, wt, "diff", baseCommit, "HEAD")
+		if err == nil {
+			res.DiffFile = filepath.Join(br.outDir, "diffs", it.ID+".diff")
+			if err := os.WriteFile(res.DiffFile, []byte(diff), 0644); err != nil {
+				res.DiffFile = ""
+			}
+		}
+	}
+
+	switch {
+	case runErr != nil:
+		// Keep the worktree for inspection.
+		return fail(runErr)
+	case len(files) == 0:
+		res.Status = batchUnchanged
+		lock.Lock()
+		err := br.worktrees.RemoveAtPath(ctx, it.Repo, wt, branch)
+		lock.Unlock()
+		if err == nil {
+			res.Worktree, res.Branch = "", ""
+		}
+		return res
+	}
+
+	res.Status = batchChanged
+	if br.push {
+		if err := br.repos.PushBranch(ctx, wt, branch); err != nil {
+			res.Error = err.Error()
+		} else {
+			res.Pushed = true
+		}
+	}
+	return res
+}
+
+func (br *batchRunner) repoLock(repo string) *sync.Mutex {
+	br.repoLocksMu.Lock()
+	defer br.repoLocksMu.Unlock()
+	l := br.repoLocks[repo]
+	if l == nil {
+		l = &sync.Mutex{}
+		br.repoLocks[repo] = l
+	}
+	return l
+}
+
+// runAgent runs the item's prompt to the end of the turn in workdir.
+func (br *batchRunner) runAgent(ctx context.Context, it batchItem, workdir string, res *batchResult) error {
+	backend, err := br.factory(agent.ProcessOptions{
+		ConversationID: uuid.New().String(),
+		Workdir:        workdir,
+		Model:          it.Model,
+		PermissionMode: it.PermissionMode,
+		Instructions:   it.Instructions,
+		MaxBudgetUsd:   it.MaxBudgetUsd,
+	}, br.apiKey, "")
+	if err != nil {
+		return fmt.Errorf("create backend: %w", err)
+	}
+	if err := backend.Start(); err != nil {
+		return fmt.Errorf("start backend: %w", err)
+	}
+	defer func() {
+		backend.Stop()
+		select {
+		case <-backend.Done():
+		case <-time.After(batchStopTimeout):
+		}
+	}()
+	if err := backend.SendMessage(it.Prompt); err != nil {
+		return fmt.Errorf("send prompt: %w", err)
+	}
+
+	var text strings.Builder
+	var lastErr string
+	for {
+		select {
+		case <-ctx.Done():
+			_ = backend.SendInterrupt()
+			res.Summary = strings.TrimSpace(text.String())
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return fmt.Errorf("timed out after %s", br.timeout)
+			}
+			return fmt.Errorf("cancelled")
+		case line, ok := <-backend.Output():
+			if !ok {
+				res.Summary = strings.TrimSpace(text.String())
+				return fmt.Errorf("agent exited before finishing")
+			}
+			var e agent.AgentEvent
+			if json.Unmarshal([]byte(line), &e) != nil {
+				continue
+			}
+			switch e.Type {
+			case agent.EventTypeAssistantText:
+				text.WriteString(e.Content)
+			case agent.EventTypeToolStart:
+				// Keep only the text after the last tool call: the final message.
+				text.Reset()
+			case agent.EventTypeResult:
+				res.CostUSD += e.Cost
+				res.Turns += e.Turns
+			case agent.EventTypeError:
+				lastErr = e.Message
+			case agent.EventTypeToolApprovalRequest:
+				res.DeniedTools++
+				_ = backend.SendToolApprovalResponse(e.RequestID, "deny_once", "", nil)
+			case agent.EventTypeUserQuestionRequest:
+				answers := make(map[string]string, len(e.Questions))
+				for _, q := range e.Questions {
+					answers[q.Question] = batchQuestionReply
+				}
+				_ = backend.SendUserQuestionResponse(e.RequestID, answers)
+			case agent.EventTypePlanApprovalRequest:
+				_ = backend.SendPlanApprovalResponse(e.RequestID, true, "")
+			case agent.EventTypeTurnComplete:
+				res.Summary = strings.TrimSpace(text.String())
+				if lastErr != "" && res.Summary == "" {
+					return errors.New(lastErr)
+				}
+				return nil
+			}
+		}
+	}
+}
+
+// commitAll commits any uncommitted changes in the worktree.
+func commitAll(ctx context.Context, worktree, prompt string) error {
+	if _, err := gitOutput(ctx, worktree, "add", "-A"); err != nil {
+		return err
+	}
+	if _, err := gitOutput(ctx, worktree, "diff", "--cached", "--quiet"); err == nil {
+		return nil // nothing staged
+	}
+	subject := strings.TrimSpace(strings.SplitN(prompt, "\n", 2)[0])
+	if len(subject) > 72 {
+		subject = subject[:69] + "..."
+	}
+	_, err := gitOutput(ctx, worktree, "commit", "-q", "-m", subject, "-m", "Generated by chatml batch.\n\n"+prompt)
+	return err
+}
+
+func gitOutput(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return string(out), nil
+}
+
+// writeReport writes report.json and a Markdown summary to the output directory.
+func (br *batchRunner) writeReport(report *batchReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(br.outDir, "report.json"), data, 0644); err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# chatml batch %s\n\n", report.RunID)
+	fmt.Fprintf(&b, "%d items · $%.4f · %s\n\n", len(report.Results), report.CostUSD,
+		formatDurationShort(report.Finished.Sub(report.Started)))
+	b.WriteString("| Item | Status | Files | +/- | Cost | Branch |\n")
+	b.WriteString("|------|--------|-------|-----|------|--------|\n")
+	for _, r := range report.Results {
+		adds, dels := 0, 0
+		for _, f := range r.Files {
+			adds += f.Additions
+			dels += f.Deletions
+		}
+		status := r.Status
+		if r.Error != "" {
+			status += ": " + strings.ReplaceAll(r.Error, "|", "\\|")
+		}
+		fmt.Fprintf(&b, "| %s | %s | %d | +%d/-%d | $%.4f | %s |\n", r.ID, status, len(r.Files), adds, dels, r.CostUSD, r.Branch)
+	}
+
+	var push []string
+	for _, r := range report.Results {
+		if r.Status == batchChanged && !r.Pushed {
+			push = append(push, fmt.Sprintf("git -C %q push -u origin %s", r.Worktree, r.Branch))
+		}
+	}
+	if len(push) > 0 {
+		b.WriteString("\n## Ready to push\n\n```sh\n" + strings.Join(push, "\n") + "\n```\n")
+	}
+	for _, r := range report.Results {
+		if r.Summary == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "\n## %s\n\n%s\n", r.ID, r.Summary)
+	}
+	return os.WriteFile(filepath.Join(br.outDir, "report.md"), []byte(b.String()), 0644)
+}
+
+func printBatchSummary(report *batchReport, outDir string) {
+	counts := map[string]int{}
+	for _, r := range report.Results {
+		counts[r.Status]++
+	}
+	fmt.Fprintf(os.Stderr, "\n%d changed · %d unchanged · %d failed · $%.4f\nReport: %s\n",
+		counts[batchChanged], counts[batchUnchanged], counts[batchFailed], report.CostUSD,
+		filepath.Join(outDir, "report.md"))
+}
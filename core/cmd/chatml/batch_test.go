@@ -0,0 +1,297 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chatml/chatml-core/agent"
+	"github.com/chatml/chatml-core/git"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeManifest(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "batch.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestLoadBatchManifest_BypassRequiresFlag(t *testing.T) {
+	manifest := `
+items:
+  - repo: /tmp/repo
+    prompt: fix it
+    permissionMode: bypassPermissions
+`
+	_, err := loadBatchManifest(writeManifest(t, manifest), batchItem{PermissionMode: "acceptEdits"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "requires -mode bypassPermissions")
+
+	// Bypass set through the manifest defaults is rejected too.
+	manifest = `
+defaults:
+  permissionMode: bypassPermissions
+items:
+  - repo: /tmp/repo
+    prompt: fix it
+`
+	_, err = loadBatchManifest(writeManifest(t, manifest), batchItem{PermissionMode: "acceptEdits"})
+	require.Error(t, err)
+
+	items, err := loadBatchManifest(writeManifest(t, manifest), batchItem{PermissionMode: "bypassPermissions"})
+	require.NoError(t, err)
+	assert.Equal(t, "bypassPermissions", items[0].PermissionMode)
+}
+
+func TestLoadBatchManifest_DefaultsMergeOrder(t *testing.T) {
+	manifest := `
+defaults:
+  model: manifest-model
+  instructions: manifest instructions
+items:
+  - repo: /tmp/a
+    prompt: one
+    model: item-model
+  - repo: /tmp/b
+    prompt: two
+`
+	flags := batchItem{Model: "flag-model", MaxBudgetUsd: 2, PermissionMode: "acceptEdits", Base: "main"}
+	items, err := loadBatchManifest(writeManifest(t, manifest), flags)
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+
+	assert.Equal(t, "item-model", items[0].Model)
+	assert.Equal(t, "manifest-model", items[1].Model)
+	for _, it := range items {
+		assert.Equal(t, "manifest instructions", it.Instructions)
+		assert.Equal(t, 2.0, it.MaxBudgetUsd)
+		assert.Equal(t, "acceptEdits", it.PermissionMode)
+		assert.Equal(t, "main", it.Base)
+	}
+}
+
+func TestLoadBatchManifest_ExpandsHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	manifest := `
+items:
+  - repo: ~/src/app
+    prompt: fix it
+`
+	items, err := loadBatchManifest(writeManifest(t, manifest), batchItem{})
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, "src", "app"), items[0].Repo)
+}
+
+func TestLoadBatchManifest_IDs(t *testing.T) {
+	manifest := `
+items:
+  - repo: /tmp/app
+    prompt: one
+  - repo: /tmp/app
+    prompt: two
+    id: fix lint/errors!
+`
+	items, err := loadBatchManifest(writeManifest(t, manifest), batchItem{})
+	require.NoError(t, err)
+	assert.Equal(t, "01-app", items[0].ID)
+	assert.Equal(t, "fix-lint-errors-", items[1].ID)
+
+	// IDs that only differ before sanitizing still collide.
+	manifest = `
+items:
+  - repo: /tmp/app
+    prompt: one
+    id: a/b
+  - repo: /tmp/app
+    prompt: two
+    id: a b
+`
+	_, err = loadBatchManifest(writeManifest(t, manifest), batchItem{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "duplicate id")
+}
+
+// initBatchRepo creates a git repository with one commit on main.
+func initBatchRepo(t *testing.T) string {
+	t.Helper()
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	dir := t.TempDir()
+	ctx := context.Background()
+	_, err := gitOutput(ctx, dir, "init", "-q", "-b", "main")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("hello\n"), 0644))
+	_, err = gitOutput(ctx, dir, "add", "-A")
+	require.NoError(t, err)
+	_, err = gitOutput(ctx, dir, "commit", "-q", "-m", "initial")
+	require.NoError(t, err)
+	return dir
+}
+
+func TestCommitAll(t *testing.T) {
+	dir := initBatchRepo(t)
+	ctx := context.Background()
+
+	// Nothing to commit.
+	require.NoError(t, commitAll(ctx, dir, "do nothing"))
+	count, err := gitOutput(ctx, dir, "rev-list", "--count", "HEAD")
+	require.NoError(t, err)
+	assert.Equal(t, "1", strings.TrimSpace(count))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "new.txt"), []byte("new\n"), 0644))
+	prompt := strings.Repeat("x", 80) + "\nsecond line"
+	require.NoError(t, commitAll(ctx, dir, prompt))
+
+	subject, err := gitOutput(ctx, dir, "log", "-1", "--format=%s")
+	require.NoError(t, err)
+	assert.Equal(t, strings.Repeat("x", 69)+"...", strings.TrimSpace(subject))
+	body, err := gitOutput(ctx, dir, "log", "-1", "--format=%b")
+	require.NoError(t, err)
+	assert.Contains(t, body, "Generated by chatml batch.")
+	assert.Contains(t, body, "second line")
+	status, err := gitOutput(ctx, dir, "status", "--porcelain")
+	require.NoError(t, err)
+	assert.Empty(t, strings.TrimSpace(status))
+}
+
+// batchBackend is a conversation backend that runs edit in the workdir when
+// it gets the prompt, then ends the turn or, when !finish, exits.
+type batchBackend struct {
+	agent.ConversationBackend
+	workdir string
+	edit    func(workdir string) error
+	finish  bool
+
+	out      chan string
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func (b *batchBackend) Start() error          { return nil }
+func (b *batchBackend) Output() <-chan string { return b.out }
+func (b *batchBackend) Done() <-chan struct{} { return b.done }
+func (b *batchBackend) Stop()                 { b.stopOnce.Do(func() { close(b.done) }) }
+
+func (b *batchBackend) SendMessage(string) error {
+	if b.edit != nil {
+		if err := b.edit(b.workdir); err != nil {
+			return err
+		}
+	}
+	if !b.finish {
+		close(b.out)
+		return nil
+	}
+	for _, e := range []agent.AgentEvent{
+		{Type: agent.EventTypeAssistantText, Content: "Done."},
+		{Type: agent.EventTypeResult, Cost: 0.25, Turns: 3},
+		{Type: agent.EventTypeTurnComplete},
+	} {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		b.out <- string(line)
+	}
+	return nil
+}
+
+func newTestBatchRunner(t *testing.T, edit func(string) error, finish bool) *batchRunner {
+	t.Helper()
+	outDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(outDir, "diffs"), 0755))
+	return &batchRunner{
+		runID: "test",
+		factory: func(opts agent.ProcessOptions, _, _ string) (agent.ConversationBackend, error) {
+			return &batchBackend{
+				workdir: opts.Workdir,
+				edit:    edit,
+				finish:  finish,
+				out:     make(chan string, 8),
+				done:    make(chan struct{}),
+			}, nil
+		},
+		worktrees:   git.NewWorktreeManager(),
+		repos:       git.NewRepoManager(),
+		worktreeDir: t.TempDir(),
+		outDir:      outDir,
+		timeout:     time.Minute,
+		repoLocks:   make(map[string]*sync.Mutex),
+	}
+}
+
+func writeFileEdit(name, content string) func(string) error {
+	return func(workdir string) error {
+		return os.WriteFile(filepath.Join(workdir, name), []byte(content), 0644)
+	}
+}
+
+func TestBatchRunItem_Changed(t *testing.T) {
+	repo := initBatchRepo(t)
+	br := newTestBatchRunner(t, writeFileEdit("fix.txt", "fixed\n"), true)
+
+	res := br.runItem(context.Background(), batchItem{ID: "item", Repo: repo, Prompt: "add fix.txt", Base: "main"})
+
+	require.Equal(t, batchChanged, res.Status, res.Error)
+	assert.Equal(t, "chatml/batch-test-item", res.Branch)
+	assert.DirExists(t, res.Worktree)
+	require.Len(t, res.Files, 1)
+	assert.Equal(t, "fix.txt", res.Files[0].Path)
+	assert.Equal(t, "Done.", res.Summary)
+	assert.Equal(t, 0.25, res.CostUSD)
+	assert.Equal(t, 3, res.Turns)
+
+	diff, err := os.ReadFile(res.DiffFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(diff), "+fixed")
+
+	subject, err := gitOutput(context.Background(), repo, "log", "-1", "--format=%s", res.Branch)
+	require.NoError(t, err)
+	assert.Equal(t, "add fix.txt", strings.TrimSpace(subject))
+}
+
+func TestBatchRunItem_UnchangedRemovesWorktree(t *testing.T) {
+	repo := initBatchRepo(t)
+	br := newTestBatchRunner(t, nil, true)
+
+	res := br.runItem(context.Background(), batchItem{ID: "item", Repo: repo, Prompt: "look around"})
+
+	require.Equal(t, batchUnchanged, res.Status, res.Error)
+	assert.Empty(t, res.Worktree)
+	assert.Empty(t, res.Branch)
+	assert.Empty(t, res.Files)
+	assert.Empty(t, res.DiffFile)
+	assert.NoDirExists(t, filepath.Join(br.worktreeDir, "item"))
+	branches, err := gitOutput(context.Background(), repo, "branch", "--list", "chatml/batch-test-item")
+	require.NoError(t, err)
+	assert.Empty(t, strings.TrimSpace(branches))
+}
+
+func TestBatchRunItem_FailedKeepsWorktree(t *testing.T) {
+	repo := initBatchRepo(t)
+	br := newTestBatchRunner(t, writeFileEdit("partial.txt", "half done\n"), false)
+
+	res := br.runItem(context.Background(), batchItem{ID: "item", Repo: repo, Prompt: "start a fix"})
+
+	require.Equal(t, batchFailed, res.Status)
+	assert.Equal(t, "agent exited before finishing", res.Error)
+	assert.DirExists(t, res.Worktree)
+	assert.Equal(t, "chatml/batch-test-item", res.Branch)
+	// The partial work is committed on the branch.
+	require.Len(t, res.Files, 1)
+	assert.Equal(t, "partial.txt", res.Files[0].Path)
+	status, err := gitOutput(context.Background(), res.Worktree, "status", "--porcelain")
+	require.NoError(t, err)
+	assert.Empty(t, strings.TrimSpace(status))
+}
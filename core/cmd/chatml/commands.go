@@ -1,10 +1,13 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strings"
 	"time"
+
+	"github.com/chatml/chatml-core/agent"
 )
 
 type slashCmd struct {
@@ -28,7 +31,7 @@ func init() {
 		{"status", "Show status", "", 0, cmdStatus},
 		{"cost", "Show cost", "", 0, cmdCost},
 		{"verbose", "Toggle verbose", "", 0, cmdVerbose},
-		{"compact", "Trigger compaction", "", 0, cmdCompact},
+		{"compact", "Summarize the conversation to free context", "/compact [focus]", 0, cmdCompact},
 		{"doctor", "Run diagnostics", "", 0, cmdDoctor},
 	}
 }
@@ -131,9 +134,32 @@ func cmdVerbose(a *appState, _ []string) {
 	a.renderer.printSystem("Verbose -> " + state)
 }
 
-func cmdCompact(a *appState, _ []string) {
+func cmdCompact(a *appState, args []string) {
+	instructions := strings.Join(args, " ")
+	compacter, ok := a.backend.(agent.Compacter)
+	if !ok {
+		// The agent-runner handles /compact as a slash command.
+		a.renderer.printSystem("Compacting...")
+		_ = a.backend.SendMessage(strings.TrimSpace("/compact " + instructions))
+		return
+	}
+	a.mu.Lock()
+	running := a.running
+	a.mu.Unlock()
+	if running {
+		a.renderer.printError("Wait for the current turn to finish before compacting")
+		return
+	}
+
 	a.renderer.printSystem("Compacting...")
-	_ = a.backend.SendMessage("/compact")
+	// The summary and token counts arrive as a post_compact event.
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+		if _, err := compacter.Compact(ctx, instructions); err != nil {
+			a.renderer.printError("Compaction failed: " + err.Error())
+		}
+	}()
 }
 
 func cmdDoctor(a *appState, _ []string) {
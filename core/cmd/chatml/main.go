@@ -15,6 +15,15 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		runServe(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "batch" {
+		runBatch(os.Args[2:])
+		return
+	}
+
 	// Same flags as nativeloop
 	modelFlag := flag.String("model", defaultModel, "Model to use")
 	workdir := flag.String("workdir", "", "Working directory")
@@ -0,0 +1,777 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	core "github.com/chatml/chatml-core"
+	"github.com/chatml/chatml-core/agent"
+	"github.com/chatml/chatml-core/loop"
+	"github.com/google/uuid"
+)
+
+// chatml serve runs the native loop headless behind a small HTTP API: each
+// conversation is a ConversationBackend whose events are buffered and streamed
+// to clients as server-sent events. The API is documented in docs/SERVE_API.md.
+
+const (
+	serveDefaultAddr   = "127.0.0.1:9900"
+	serveEventBuffer   = 2000 // events kept per conversation for replay
+	serveMaxBodyBytes  = 10 << 20
+	serveStopTimeout   = 5 * time.Second
+	serveSSEKeepalive  = 30 * time.Second
+	serveShutdownGrace = 10 * time.Second
+)
+
+// Conversation statuses reported by the API.
+const (
+	serveStatusIdle    = "idle"
+	serveStatusRunning = "running"
+	serveStatusWaiting = "waiting" // blocked on a tool approval, question or plan approval
+	serveStatusStopped = "stopped"
+)
+
+func runServe(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", serveDefaultAddr, "Address to listen on")
+	token := fs.String("token", "", "Bearer token clients must send (default: CHATML_SERVE_TOKEN env, or a generated one)")
+	modelFlag := fs.String("model", defaultModel, "Default model for new conversations")
+	workdir := fs.String("workdir", "", "Default working directory for new conversations (default: current)")
+	mode := fs.String("mode", "default", "Default permission mode for new conversations")
+	apiKey := fs.String("api-key", "", "API key (default: ANTHROPIC_API_KEY env)")
+	maxConversations := fs.Int("max-conversations", 16, "Maximum number of open conversations")
+	fs.Parse(args) //nolint:errcheck // ExitOnError
+
+	key := *apiKey
+	if key == "" {
+		key = os.Getenv("ANTHROPIC_API_KEY")
+	}
+	if key == "" {
+		key = os.Getenv("OPENAI_API_KEY")
+	}
+	if key == "" {
+		fmt.Fprintln(os.Stderr, "Error: No API key. Set ANTHROPIC_API_KEY or use --api-key")
+		os.Exit(1)
+	}
+
+	wd := *workdir
+	if wd == "" {
+		var err error
+		if wd, err = os.Getwd(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	tok := *token
+	if tok == "" {
+		tok = os.Getenv("CHATML_SERVE_TOKEN")
+	}
+	if tok == "" {
+		buf := make([]byte, 24)
+		if _, err := rand.Read(buf); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: generate token: %v\n", err)
+			os.Exit(1)
+		}
+		tok = hex.EncodeToString(buf)
+		fmt.Fprintf(os.Stderr, "Generated token: %s\n", tok)
+	}
+
+	srv := newServeServer(loop.NewBackendFactory(), serveConfig{
+		apiKey:           key,
+		token:            tok,
+		maxConversations: *maxConversations,
+		defaults: agent.ProcessOptions{
+			Workdir:        wd,
+			Model:          *modelFlag,
+			PermissionMode: *mode,
+		},
+	})
+	httpSrv := &http.Server{
+		Addr:              *addr,
+		Handler:           srv.routes(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		ctx, cancel := context.WithTimeout(context.Background(), serveShutdownGrace)
+		defer cancel()
+		httpSrv.Shutdown(ctx) //nolint:errcheck
+	}()
+
+	fmt.Fprintf(os.Stderr, "chatml %s serving on http://%s\n", version, *addr)
+	if err := httpSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	srv.closeAll()
+}
+
+type serveConfig struct {
+	apiKey           string
+	token            string
+	maxConversations int
+	defaults         agent.ProcessOptions // workdir, model and permission mode of new conversations
+}
+
+type serveServer struct {
+	cfg     serveConfig
+	factory agent.NativeBackendFactory
+
+	mu    sync.Mutex
+	convs map[string]*serveConversation
+}
+
+func newServeServer(factory agent.NativeBackendFactory, cfg serveConfig) *serveServer {
+	return &serveServer{cfg: cfg, factory: factory, convs: make(map[string]*serveConversation)}
+}
+
+func (s *serveServer) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/conversations", s.handleList)
+	mux.HandleFunc("POST /v1/conversations", s.handleCreate)
+	mux.HandleFunc("GET /v1/conversations/{id}", s.withConversation(s.handleGet))
+	mux.HandleFunc("DELETE /v1/conversations/{id}", s.handleDelete)
+	mux.HandleFunc("POST /v1/conversations/{id}/messages", s.withConversation(s.handleSend))
+	mux.HandleFunc("POST /v1/conversations/{id}/interrupt", s.withConversation(s.handleInterrupt))
+	mux.HandleFunc("POST /v1/conversations/{id}/tool-approval", s.withConversation(s.handleToolApproval))
+	mux.HandleFunc("POST /v1/conversations/{id}/question-answer", s.withConversation(s.handleQuestionAnswer))
+	mux.HandleFunc("POST /v1/conversations/{id}/plan-approval", s.withConversation(s.handlePlanApproval))
+	mux.HandleFunc("POST /v1/conversations/{id}/model", s.withConversation(s.handleSetModel))
+	mux.HandleFunc("POST /v1/conversations/{id}/permission-mode", s.withConversation(s.handleSetPermissionMode))
+	mux.HandleFunc("GET /v1/conversations/{id}/events", s.withConversation(s.handleEvents))
+	return s.authenticate(mux)
+}
+
+// authenticate requires the server token as a bearer token, or as the token
+// query parameter for clients such as EventSource that cannot set headers.
+func (s *serveServer) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if got == "" || got == r.Header.Get("Authorization") {
+			got = r.URL.Query().Get("token")
+		}
+		if subtle.ConstantTimeCompare([]byte(got), []byte(s.cfg.token)) != 1 {
+			serveError(w, http.StatusUnauthorized, "invalid or missing token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *serveServer) withConversation(h func(http.ResponseWriter, *http.Request, *serveConversation)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		c := s.convs[r.PathValue("id")]
+		s.mu.Unlock()
+		if c == nil {
+			serveError(w, http.StatusNotFound, "conversation not found")
+			return
+		}
+		h(w, r, c)
+	}
+}
+
+// createRequest starts a conversation. Empty fields use the server defaults;
+// ResumeSession continues a persisted transcript.
+type createRequest struct {
+	Workdir           string  `json:"workdir"`
+	Model             string  `json:"model"`
+	PermissionMode    string  `json:"permissionMode"`
+	PlanMode          bool    `json:"planMode"`
+	FastMode          bool    `json:"fastMode"`
+	MaxThinkingTokens int     `json:"maxThinkingTokens"`
+	Effort            string  `json:"effort"`
+	Instructions      string  `json:"instructions"`
+	MaxBudgetUsd      float64 `json:"maxBudgetUsd"`
+	ResumeSession     string  `json:"resumeSession"`
+	Message           string  `json:"message"` // optional first message
+}
+
+func (s *serveServer) handleCreate(w http.ResponseWriter, r *http.Request) {
+	var req createRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	opts := s.cfg.defaults
+	opts.ConversationID = uuid.New().String()
+	if req.Workdir != "" {
+		opts.Workdir = req.Workdir
+	}
+	if info, err := os.Stat(opts.Workdir); err != nil || !info.IsDir() {
+		serveError(w, http.StatusBadRequest, "workdir is not a directory: "+opts.Workdir)
+		return
+	}
+	if req.Model != "" {
+		opts.Model = req.Model
+	}
+	if req.PermissionMode != "" {
+		opts.PermissionMode = req.PermissionMode
+	}
+	opts.PlanMode = req.PlanMode
+	opts.FastMode = req.FastMode
+	opts.MaxThinkingTokens = req.MaxThinkingTokens
+	opts.Effort = req.Effort
+	opts.Instructions = req.Instructions
+	opts.MaxBudgetUsd = req.MaxBudgetUsd
+	if req.ResumeSession != "" {
+		if loop.FindTranscript(loop.TranscriptDir(opts.Workdir), req.ResumeSession) == "" {
+			serveError(w, http.StatusNotFound, "session not found: "+req.ResumeSession)
+			return
+		}
+		opts.ResumeSession = req.ResumeSession
+		opts.SdkSessionID = req.ResumeSession
+	}
+
+	s.mu.Lock()
+	if s.openCountLocked() >= s.cfg.maxConversations {
+		s.mu.Unlock()
+		serveError(w, http.StatusTooManyRequests, fmt.Sprintf("at most %d conversations can be open", s.cfg.maxConversations))
+		return
+	}
+	// Reserve the slot while the backend starts.
+	s.convs[opts.ConversationID] = nil
+	s.mu.Unlock()
+
+	c, err := s.startConversation(opts)
+	s.mu.Lock()
+	if err != nil {
+		delete(s.convs, opts.ConversationID)
+	} else {
+		s.convs[c.id] = c
+	}
+	s.mu.Unlock()
+	if err != nil {
+		serveError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if req.Message != "" {
+		if err := c.send(req.Message, nil); err != nil {
+			serveError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	serveJSON(w, http.StatusCreated, c.info())
+}
+
+// openCountLocked returns how many conversations count toward the
+// -max-conversations limit: those starting or running. Stopped ones stay
+// listed until deleted but hold no backend. Caller holds s.mu.
+func (s *serveServer) openCountLocked() int {
+	n := 0
+	for _, c := range s.convs {
+		if c == nil || !c.isClosed() {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *serveServer) startConversation(opts agent.ProcessOptions) (*serveConversation, error) {
+	backend, err := s.factory(opts, s.cfg.apiKey, "")
+	if err != nil {
+		return nil, fmt.Errorf("create backend: %w", err)
+	}
+	if err := backend.Start(); err != nil {
+		return nil, fmt.Errorf("start backend: %w", err)
+	}
+	sessionID := opts.SdkSessionID
+	if sessionID == "" {
+		sessionID = opts.ConversationID
+	}
+	c := &serveConversation{
+		id:        opts.ConversationID,
+		backend:   backend,
+		workdir:   opts.Workdir,
+		model:     opts.Model,
+		permMode:  opts.PermissionMode,
+		sessionID: sessionID,
+		status:    serveStatusIdle,
+		createdAt: time.Now(),
+		changed:   make(chan struct{}),
+	}
+	if opts.PlanMode {
+		c.permMode = "plan"
+	}
+	go c.pump()
+	return c, nil
+}
+
+func (s *serveServer) handleList(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	list := make([]serveConversationInfo, 0, len(s.convs))
+	for _, c := range s.convs {
+		if c != nil {
+			list = append(list, c.info())
+		}
+	}
+	s.mu.Unlock()
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	serveJSON(w, http.StatusOK, list)
+}
+
+func (s *serveServer) handleGet(w http.ResponseWriter, r *http.Request, c *serveConversation) {
+	serveJSON(w, http.StatusOK, c.info())
+}
+
+// handleDelete stops the conversation and forgets it. Its transcript stays
+// on disk and can be resumed with resumeSession.
+func (s *serveServer) handleDelete(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	s.mu.Lock()
+	c := s.convs[id]
+	if c != nil {
+		delete(s.convs, id)
+	}
+	s.mu.Unlock()
+	if c == nil {
+		serveError(w, http.StatusNotFound, "conversation not found")
+		return
+	}
+	c.stop()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type sendRequest struct {
+	Content     string            `json:"content"`
+	Attachments []core.Attachment `json:"attachments,omitempty"`
+}
+
+func (s *serveServer) handleSend(w http.ResponseWriter, r *http.Request, c *serveConversation) {
+	var req sendRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if strings.TrimSpace(req.Content) == "" && len(req.Attachments) == 0 {
+		serveError(w, http.StatusBadRequest, "content is required")
+		return
+	}
+	if err := c.send(req.Content, req.Attachments); err != nil {
+		serveBackendError(w, err)
+		return
+	}
+	serveJSON(w, http.StatusAccepted, c.info())
+}
+
+func (s *serveServer) handleInterrupt(w http.ResponseWriter, r *http.Request, c *serveConversation) {
+	if err := c.backend.SendInterrupt(); err != nil {
+		serveBackendError(w, err)
+		return
+	}
+	serveJSON(w, http.StatusOK, c.info())
+}
+
+type toolApprovalRequest struct {
+	RequestID    string          `json:"requestId"`
+	Action       string          `json:"action"` // allow_once, allow_session, allow_always, deny_once, deny_always
+	Specifier    string          `json:"specifier,omitempty"`
+	UpdatedInput json.RawMessage `json:"updatedInput,omitempty"`
+}
+
+func (s *serveServer) handleToolApproval(w http.ResponseWriter, r *http.Request, c *serveConversation) {
+	var req toolApprovalRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if req.RequestID == "" || req.Action == "" {
+		serveError(w, http.StatusBadRequest, "requestId and action are required")
+		return
+	}
+	c.respond(w, func() error {
+		return c.backend.SendToolApprovalResponse(req.RequestID, req.Action, req.Specifier, req.UpdatedInput)
+	})
+}
+
+type questionAnswerRequest struct {
+	RequestID string            `json:"requestId"`
+	Answers   map[string]string `json:"answers"`
+}
+
+func (s *serveServer) handleQuestionAnswer(w http.ResponseWriter, r *http.Request, c *serveConversation) {
+	var req questionAnswerRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if req.RequestID == "" {
+		serveError(w, http.StatusBadRequest, "requestId is required")
+		return
+	}
+	c.respond(w, func() error {
+		return c.backend.SendUserQuestionResponse(req.RequestID, req.Answers)
+	})
+}
+
+type planApprovalRequest struct {
+	RequestID string `json:"requestId"`
+	Approved  bool   `json:"approved"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+func (s *serveServer) handlePlanApproval(w http.ResponseWriter, r *http.Request, c *serveConversation) {
+	var req planApprovalRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if req.RequestID == "" {
+		serveError(w, http.StatusBadRequest, "requestId is required")
+		return
+	}
+	c.respond(w, func() error {
+		return c.backend.SendPlanApprovalResponse(req.RequestID, req.Approved, req.Reason)
+	})
+}
+
+func (s *serveServer) handleSetModel(w http.ResponseWriter, r *http.Request, c *serveConversation) {
+	var req struct {
+		Model string `json:"model"`
+	}
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if req.Model == "" {
+		serveError(w, http.StatusBadRequest, "model is required")
+		return
+	}
+	if err := c.backend.SetModel(req.Model); err != nil {
+		serveBackendError(w, err)
+		return
+	}
+	c.mu.Lock()
+	c.model = req.Model
+	c.mu.Unlock()
+	serveJSON(w, http.StatusOK, c.info())
+}
+
+func (s *serveServer) handleSetPermissionMode(w http.ResponseWriter, r *http.Request, c *serveConversation) {
+	var req struct {
+		Mode string `json:"mode"`
+	}
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if req.Mode == "" {
+		serveError(w, http.StatusBadRequest, "mode is required")
+		return
+	}
+	if err := c.backend.SetPermissionMode(req.Mode); err != nil {
+		serveBackendError(w, err)
+		return
+	}
+	c.mu.Lock()
+	c.permMode = req.Mode
+	c.mu.Unlock()
+	serveJSON(w, http.StatusOK, c.info())
+}
+
+// handleEvents streams the conversation's events as server-sent events. Each
+// event's id is its sequence number; reconnecting clients send Last-Event-ID
+// (or ?after=) to receive what they missed, as far as the buffer reaches.
+func (s *serveServer) handleEvents(w http.ResponseWriter, r *http.Request, c *serveConversation) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		serveError(w, http.StatusInternalServerError, "streaming unsupported")
+		return
+	}
+	after := int64(0)
+	cursor := r.Header.Get("Last-Event-ID")
+	if cursor == "" {
+		cursor = r.URL.Query().Get("after")
+	}
+	if cursor != "" {
+		v, err := strconv.ParseInt(cursor, 10, 64)
+		if err != nil || v < 0 {
+			serveError(w, http.StatusBadRequest, "invalid event cursor")
+			return
+		}
+		after = v
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepalive := time.NewTicker(serveSSEKeepalive)
+	defer keepalive.Stop()
+	for {
+		events, changed, closed := c.eventsAfter(after)
+		for _, e := range events {
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.seq, e.typ, e.data)
+			after = e.seq
+		}
+		if closed {
+			fmt.Fprint(w, "event: end\ndata: {}\n\n")
+			flusher.Flush()
+			return
+		}
+		flusher.Flush()
+		select {
+		case <-changed:
+		case <-keepalive.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func (s *serveServer) closeAll() {
+	s.mu.Lock()
+	convs := make([]*serveConversation, 0, len(s.convs))
+	for _, c := range s.convs {
+		if c != nil {
+			convs = append(convs, c)
+		}
+	}
+	s.convs = make(map[string]*serveConversation)
+	s.mu.Unlock()
+	var wg sync.WaitGroup
+	for _, c := range convs {
+		wg.Add(1)
+		go func(c *serveConversation) {
+			defer wg.Done()
+			c.stop()
+		}(c)
+	}
+	wg.Wait()
+}
+
+// --- Conversations ---
+
+type serveEvent struct {
+	seq  int64
+	typ  string
+	data string
+}
+
+type serveConversation struct {
+	id        string
+	backend   agent.ConversationBackend
+	workdir   string
+	createdAt time.Time
+
+	mu        sync.Mutex
+	model     string
+	permMode  string
+	sessionID string
+	status    string
+	costUSD   float64
+	turns     int
+	events    []serveEvent // last serveEventBuffer events
+	nextSeq   int64
+	closed    bool          // backend output ended
+	changed   chan struct{} // closed and replaced whenever events or closed change
+}
+
+type serveConversationInfo struct {
+	ID             string    `json:"id"`
+	SessionID      string    `json:"sessionId"`
+	Workdir        string    `json:"workdir"`
+	Model          string    `json:"model"`
+	PermissionMode string    `json:"permissionMode"`
+	Status         string    `json:"status"`
+	CostUSD        float64   `json:"costUsd"`
+	Turns          int       `json:"turns"`
+	LastEventID    int64     `json:"lastEventId"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+func (c *serveConversation) info() serveConversationInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return serveConversationInfo{
+		ID:             c.id,
+		SessionID:      c.sessionID,
+		Workdir:        c.workdir,
+		Model:          c.model,
+		PermissionMode: c.permMode,
+		Status:         c.status,
+		CostUSD:        c.costUSD,
+		Turns:          c.turns,
+		LastEventID:    c.nextSeq,
+		CreatedAt:      c.createdAt,
+	}
+}
+
+// pump buffers the backend's events and tracks the conversation's status.
+func (c *serveConversation) pump() {
+	for line := range c.backend.Output() {
+		var e agent.AgentEvent
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			continue
+		}
+		c.mu.Lock()
+		c.track(&e)
+		c.nextSeq++
+		c.events = append(c.events, serveEvent{seq: c.nextSeq, typ: e.Type, data: line})
+		if len(c.events) > serveEventBuffer {
+			c.events = c.events[len(c.events)-serveEventBuffer:]
+		}
+		c.notifyLocked()
+		c.mu.Unlock()
+	}
+	c.mu.Lock()
+	c.closed = true
+	c.status = serveStatusStopped
+	c.notifyLocked()
+	c.mu.Unlock()
+}
+
+// track updates status and stats from an event. Caller holds c.mu.
+func (c *serveConversation) track(e *agent.AgentEvent) {
+	switch e.Type {
+	case agent.EventTypeSessionStarted:
+		if e.SessionID != "" {
+			c.sessionID = e.SessionID
+		}
+	case agent.EventTypeToolApprovalRequest, agent.EventTypeUserQuestionRequest, agent.EventTypePlanApprovalRequest:
+		c.status = serveStatusWaiting
+	case agent.EventTypeToolStart, agent.EventTypeAssistantText:
+		c.status = serveStatusRunning
+	case agent.EventTypeResult:
+		c.costUSD += e.Cost
+		c.turns++
+	case agent.EventTypeTurnComplete:
+		c.status = serveStatusIdle
+	case agent.EventTypePermModeChanged:
+		if e.Mode != "" {
+			c.permMode = e.Mode
+		}
+	case agent.EventTypeModelChanged:
+		if e.Model != "" {
+			c.model = e.Model
+		}
+	}
+}
+
+// isClosed reports whether the backend's output has ended.
+func (c *serveConversation) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+// notifyLocked wakes event streams. Caller holds c.mu.
+func (c *serveConversation) notifyLocked() {
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+// eventsAfter returns the buffered events after seq, a channel closed on the
+// next change, and whether the event stream has ended.
+func (c *serveConversation) eventsAfter(seq int64) ([]serveEvent, <-chan struct{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i := sort.Search(len(c.events), func(i int) bool { return c.events[i].seq > seq })
+	events := append([]serveEvent(nil), c.events[i:]...)
+	return events, c.changed, c.closed && len(events) == 0
+}
+
+// send queues a user message. The status changes first so that events the
+// message triggers are not overtaken by it.
+func (c *serveConversation) send(content string, attachments []core.Attachment) error {
+	c.mu.Lock()
+	prev := c.status
+	if !c.closed {
+		c.status = serveStatusRunning
+	}
+	c.mu.Unlock()
+
+	var err error
+	if len(attachments) > 0 {
+		err = c.backend.SendMessageWithAttachments(content, attachments)
+	} else {
+		err = c.backend.SendMessage(content)
+	}
+	if err != nil {
+		c.mu.Lock()
+		if c.status == serveStatusRunning {
+			c.status = prev
+		}
+		c.mu.Unlock()
+	}
+	return err
+}
+
+// respond answers a request the agent is blocked on. As in send, the status
+// changes before the call so it cannot overwrite the next request's.
+func (c *serveConversation) respond(w http.ResponseWriter, answer func() error) {
+	c.mu.Lock()
+	prev := c.status
+	if prev == serveStatusWaiting {
+		c.status = serveStatusRunning
+	}
+	c.mu.Unlock()
+
+	if err := answer(); err != nil {
+		c.mu.Lock()
+		if c.status == serveStatusRunning {
+			c.status = prev
+		}
+		c.mu.Unlock()
+		serveBackendError(w, err)
+		return
+	}
+	serveJSON(w, http.StatusOK, c.info())
+}
+
+func (c *serveConversation) stop() {
+	c.backend.Stop()
+	select {
+	case <-c.backend.Done():
+	case <-time.After(serveStopTimeout):
+		log.Printf("serve: conversation %s did not stop within %s", c.id, serveStopTimeout)
+	}
+}
+
+// --- Helpers ---
+
+// decodeBody decodes a JSON request body; an empty body leaves v unchanged.
+func decodeBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	r.Body = http.MaxBytesReader(w, r.Body, serveMaxBodyBytes)
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil && !errors.Is(err, io.EOF) {
+		serveError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return false
+	}
+	return true
+}
+
+func serveJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v) //nolint:errcheck
+}
+
+func serveError(w http.ResponseWriter, status int, msg string) {
+	serveJSON(w, status, map[string]string{"error": msg})
+}
+
+// serveBackendError reports a rejected backend call. Backends fail calls
+// they cannot accept in their current state, e.g. after they stopped.
+func serveBackendError(w http.ResponseWriter, err error) {
+	serveError(w, http.StatusConflict, err.Error())
+}
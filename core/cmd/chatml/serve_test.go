@@ -0,0 +1,106 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chatml/chatml-core/agent"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServeAuthenticate(t *testing.T) {
+	s := newServeServer(nil, serveConfig{token: "secret"})
+	h := s.authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		name   string
+		target string
+		header string
+		want   int
+	}{
+		{"missing token", "/v1/conversations", "", http.StatusUnauthorized},
+		{"wrong bearer token", "/v1/conversations", "Bearer nope", http.StatusUnauthorized},
+		{"token without bearer prefix", "/v1/conversations", "secret", http.StatusUnauthorized},
+		{"wrong query token", "/v1/conversations?token=nope", "", http.StatusUnauthorized},
+		{"bearer token", "/v1/conversations", "Bearer secret", http.StatusNoContent},
+		{"query token", "/v1/conversations/c1/events?token=secret", "", http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.target, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+
+			h.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
+
+// stubBackend is a conversation backend whose output ends when stopped.
+type stubBackend struct {
+	agent.ConversationBackend
+	out  chan string
+	done chan struct{}
+}
+
+func newStubBackend() *stubBackend {
+	return &stubBackend{out: make(chan string), done: make(chan struct{})}
+}
+
+func (b *stubBackend) Start() error          { return nil }
+func (b *stubBackend) Output() <-chan string { return b.out }
+func (b *stubBackend) Done() <-chan struct{} { return b.done }
+func (b *stubBackend) Stop() {
+	select {
+	case <-b.done:
+	default:
+		close(b.out)
+		close(b.done)
+	}
+}
+
+func TestServeCreate_StoppedConversationsDontCount(t *testing.T) {
+	var backends []*stubBackend
+	factory := func(agent.ProcessOptions, string, string) (agent.ConversationBackend, error) {
+		b := newStubBackend()
+		backends = append(backends, b)
+		return b, nil
+	}
+	s := newServeServer(factory, serveConfig{
+		token:            "secret",
+		maxConversations: 1,
+		defaults:         agent.ProcessOptions{Workdir: t.TempDir()},
+	})
+	t.Cleanup(s.closeAll)
+	h := s.routes()
+
+	create := func() int {
+		req := httptest.NewRequest("POST", "/v1/conversations", strings.NewReader(`{}`))
+		req.Header.Set("Authorization", "Bearer secret")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	require.Equal(t, http.StatusCreated, create())
+	assert.Equal(t, http.StatusTooManyRequests, create())
+
+	// The first conversation's backend exits; its slot frees up.
+	backends[0].Stop()
+	require.Eventually(t, func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.openCountLocked() == 0
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, http.StatusCreated, create())
+}
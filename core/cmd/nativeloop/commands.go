@@ -1,11 +1,14 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/chatml/chatml-core/agent"
 )
 
 // slashCmd describes a single slash command in the registry.
@@ -77,11 +80,11 @@ func init() {
 		}, handler: cmdEffort},
 		{name: "status", desc: "Show settings + session stats", usage: "/status", minArgs: 0, handler: cmdStatus},
 		{name: "cost", desc: "Show cost breakdown", usage: "/cost", minArgs: 0, handler: cmdCost},
-		{name: "context", desc: "Show context usage", usage: "/context", minArgs: 0, handler: cmdContext},
+		{name: "context", desc: "Show context usage by category", usage: "/context", minArgs: 0, handler: cmdContext},
 		{name: "verbose", desc: "Toggle verbose mode", usage: "/verbose", minArgs: 0, handler: cmdVerbose},
 		{name: "clear", desc: "Clear messages", usage: "/clear", minArgs: 0, handler: cmdClear},
 		{name: "interrupt", desc: "Interrupt current turn", usage: "/interrupt", minArgs: 0, handler: cmdInterrupt},
-		{name: "compact", desc: "Trigger compaction", usage: "/compact", minArgs: 0, handler: cmdCompact},
+		{name: "compact", desc: "Summarize the conversation to free context", usage: "/compact [focus instructions]", minArgs: 0, handler: cmdCompact},
 		{name: "history", desc: "Show message history", usage: "/history", minArgs: 0, handler: cmdHistory},
 		{name: "reset", desc: "Reset conversation", usage: "/reset", minArgs: 0, handler: cmdReset},
 		{name: "doctor", desc: "Run diagnostic checks", usage: "/doctor", minArgs: 0, handler: cmdDoctor},
@@ -89,6 +92,7 @@ func init() {
 		{name: "memory", desc: "Show auto-memory status", usage: "/memory", minArgs: 0, handler: cmdMemory},
 		{name: "resume", desc: "Resume a previous session", usage: "/resume [session-id]", minArgs: 0, handler: cmdResume},
 		{name: "sessions", desc: "Pick a session interactively", usage: "/sessions", minArgs: 0, handler: cmdSessions},
+		{name: "fork", desc: "Fork this session into a new one", usage: "/fork [message-number] [--worktree]", minArgs: 0, handler: cmdFork},
 		{name: "mcp", desc: "Show MCP server status", usage: "/mcp", minArgs: 0, handler: cmdMcp},
 		{name: "plan", desc: "Enter plan mode", usage: "/plan", minArgs: 0, handler: cmdPlan},
 		{name: "export", desc: "Export conversation to file", usage: "/export", minArgs: 0, handler: cmdExport},
@@ -228,13 +232,34 @@ func cmdCost(m *model, _ []string) tea.Cmd {
 	return nil
 }
 
+// contextBreakdownDoneMsg carries the outcome of /context. The breakdown
+// itself arrives as a context_breakdown event; only failures are reported here.
+type contextBreakdownDoneMsg struct {
+	err error
+}
+
 func cmdContext(m *model, _ []string) tea.Cmd {
+	if reporter, ok := m.backend.(agent.ContextReporter); ok && m.state != stateRunning {
+		return func() tea.Msg {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			_, err := reporter.ContextBreakdown(ctx)
+			return contextBreakdownDoneMsg{err: err}
+		}
+	}
+
 	pct := m.stats.lastContextPct
 	window := m.stats.lastContextWindow
 	if window <= 0 {
 		addSystemMsg(m, "No context data yet.")
 		return nil
 	}
+	addSystemMsg(m, contextBar(pct, window))
+	return nil
+}
+
+// contextBar renders "Context: [████░░…] 42% of 200k".
+func contextBar(pct, window int) string {
 	const barW = 30
 	filled := pct * barW / 100
 	if filled > barW {
@@ -242,8 +267,7 @@ func cmdContext(m *model, _ []string) tea.Cmd {
 	}
 	bar := strings.Repeat("█", filled) + strings.Repeat("░", barW-filled)
 	totalK := fmt.Sprintf("%.0fk", float64(window)/1000)
-	addSystemMsg(m, fmt.Sprintf("Context: [%s] %d%% of %s", bar, pct, totalK))
-	return nil
+	return fmt.Sprintf("Context: [%s] %d%% of %s", bar, pct, totalK)
 }
 
 func cmdVerbose(m *model, _ []string) tea.Cmd {
@@ -271,12 +295,29 @@ func cmdInterrupt(m *model, _ []string) tea.Cmd {
 	return nil
 }
 
-func cmdCompact(m *model, _ []string) tea.Cmd {
-	// NOTE: The native runner has no slash command processing — SendMessage
-	// would send "/compact" as a literal prompt to the LLM. Compaction
-	// requires a dedicated backend method (not yet on ConversationBackend).
-	addErrorMsg(m, "/compact is not yet implemented in the native runner. Context is compacted automatically when approaching the limit.")
-	return nil
+// compactDoneMsg carries the outcome of /compact. Progress and the summary
+// arrive as pre_compact/post_compact events; only failures are reported here.
+type compactDoneMsg struct {
+	err error
+}
+
+func cmdCompact(m *model, args []string) tea.Cmd {
+	compacter, ok := m.backend.(agent.Compacter)
+	if !ok {
+		addErrorMsg(m, "/compact is not supported by this backend.")
+		return nil
+	}
+	if m.state == stateRunning {
+		addErrorMsg(m, "Wait for the current turn to finish before compacting.")
+		return nil
+	}
+	instructions := strings.Join(args, " ")
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+		_, err := compacter.Compact(ctx, instructions)
+		return compactDoneMsg{err: err}
+	}
 }
 
 func cmdHistory(m *model, _ []string) tea.Cmd {
@@ -342,6 +383,27 @@ func cmdSessions(m *model, _ []string) tea.Cmd {
 	return nil
 }
 
+func cmdFork(m *model, args []string) tea.Cmd {
+	if m.sessionID == "" {
+		addSystemMsg(m, "No active session to fork.")
+		return nil
+	}
+	sw := sessionSwitch{source: m.sessionID, fork: true}
+	for _, arg := range args {
+		if arg == "--worktree" {
+			sw.worktree = true
+			continue
+		}
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 1 {
+			addErrorMsg(m, fmt.Sprintf("Invalid message number %q", arg))
+			return nil
+		}
+		sw.turns = n
+	}
+	return switchSession(m, sw)
+}
+
 func cmdMcp(m *model, _ []string) tea.Cmd {
 	addSystemMsg(m, "MCP servers loaded from .mcp.json in workspace root.\nFormat: { \"mcpServers\": { \"name\": { \"command\": \"...\", \"args\": [...] } } }\nSupported transports: stdio")
 	return nil
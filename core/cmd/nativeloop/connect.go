@@ -0,0 +1,314 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+	"github.com/chatml/chatml-core/agent"
+	"github.com/chatml/chatml-core/git"
+	"golang.org/x/term"
+)
+
+// backendStateDir returns the state directory of the local ChatML backend,
+// where it publishes backend.port (and backend.token when auth is enabled).
+func backendStateDir() (string, error) {
+	workspaces, err := git.WorkspacesBaseDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(workspaces), "state"), nil
+}
+
+// resolveConnectURL turns the -connect value into a base URL. "auto" finds
+// the local backend through its port file; a bare host:port gets http://.
+func resolveConnectURL(target string) (string, error) {
+	if target != "auto" {
+		if !strings.Contains(target, "://") {
+			target = "http://" + target
+		}
+		return target, nil
+	}
+	dir, err := backendStateDir()
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "backend.port"))
+	if err != nil {
+		return "", fmt.Errorf("no running ChatML backend found (%w)", err)
+	}
+	return "http://127.0.0.1:" + strings.TrimSpace(string(data)), nil
+}
+
+// resolveConnectToken returns the auth token from the flag, the
+// CHATML_AUTH_TOKEN environment variable, or the local backend's token file.
+func resolveConnectToken(token string) string {
+	if token != "" {
+		return token
+	}
+	if token := os.Getenv("CHATML_AUTH_TOKEN"); token != "" {
+		return token
+	}
+	if dir, err := backendStateDir(); err == nil {
+		if data, err := os.ReadFile(filepath.Join(dir, "backend.token")); err == nil {
+			return strings.TrimSpace(string(data))
+		}
+	}
+	return ""
+}
+
+// pickConversation asks which workspace, session and conversation to attach
+// to, skipping any choice that has a single option.
+func pickConversation(c *remoteClient) (*remoteSession, *remoteConversation, error) {
+	workspaces, err := c.listWorkspaces()
+	if err != nil {
+		return nil, nil, fmt.Errorf("list workspaces: %w", err)
+	}
+	if len(workspaces) == 0 {
+		return nil, nil, fmt.Errorf("the backend has no workspaces")
+	}
+	wsOpts := make([]huh.Option[int], len(workspaces))
+	for i, ws := range workspaces {
+		wsOpts[i] = huh.NewOption(fmt.Sprintf("%s  %s", ws.Name, ws.Path), i)
+	}
+	wsIdx, err := pick("Workspace", wsOpts)
+	if err != nil {
+		return nil, nil, err
+	}
+	ws := workspaces[wsIdx]
+
+	sessions, err := c.listSessions(ws.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("list sessions: %w", err)
+	}
+	if len(sessions) == 0 {
+		return nil, nil, fmt.Errorf("workspace %s has no sessions", ws.Name)
+	}
+	sessOpts := make([]huh.Option[int], len(sessions))
+	for i, s := range sessions {
+		sessOpts[i] = huh.NewOption(fmt.Sprintf("%s  %s · %s", s.Name, s.Branch, s.Status), i)
+	}
+	sessIdx, err := pick("Session", sessOpts)
+	if err != nil {
+		return nil, nil, err
+	}
+	sess := sessions[sessIdx]
+
+	convs, err := c.listConversations(ws.ID, sess.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("list conversations: %w", err)
+	}
+	if len(convs) == 0 {
+		return nil, nil, fmt.Errorf("session %s has no conversations", sess.Name)
+	}
+	convOpts := make([]huh.Option[int], len(convs))
+	for i, conv := range convs {
+		name := conv.Name
+		if name == "" {
+			name = conv.Type
+		}
+		convOpts[i] = huh.NewOption(fmt.Sprintf("%s  %d messages · %s · %s",
+			name, conv.MessageCount, conv.Status, formatAge(conv.UpdatedAt)), i)
+	}
+	convIdx, err := pick("Conversation", convOpts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &sess, &convs[convIdx], nil
+}
+
+func pick(title string, opts []huh.Option[int]) (int, error) {
+	if len(opts) == 1 {
+		return 0, nil
+	}
+	var idx int
+	err := huh.NewSelect[int]().
+		Title(title).
+		Options(opts...).
+		Value(&idx).
+		Run()
+	return idx, err
+}
+
+// remoteMessagesToDisplay converts stored backend messages for replay. Tool
+// calls are shown before the text of the assistant message that made them.
+func remoteMessagesToDisplay(msgs []remoteMessage) []*displayMessage {
+	var out []*displayMessage
+	for _, msg := range msgs {
+		switch msg.Role {
+		case "user":
+			out = append(out, &displayMessage{kind: msgUser, content: msg.Content})
+		case "assistant":
+			for _, tu := range msg.ToolUsage {
+				params, _ := json.Marshal(tu.Params)
+				out = append(out, &displayMessage{
+					kind:     msgTool,
+					tool:     tu.Tool,
+					params:   string(params),
+					summary:  tu.Summary,
+					success:  tu.Success == nil || *tu.Success,
+					exitCode: -1, // -1 = not set; 0 is a valid exit code
+				})
+			}
+			if msg.Content != "" {
+				out = append(out, &displayMessage{kind: msgAssistant, content: msg.Content})
+			}
+		}
+	}
+	return out
+}
+
+// remoteAttachment describes the conversation a client-mode TUI attached to.
+type remoteAttachment struct {
+	backend *remoteBackend
+	workdir string // the session's worktree, if it exists on this machine
+	model   string
+	title   string
+	history []*displayMessage
+}
+
+// attachRemote connects to the backend at target and starts a backend for
+// convID, or for a conversation picked interactively if convID is empty.
+func attachRemote(target, token, convID string) (*remoteAttachment, error) {
+	baseURL, err := resolveConnectURL(target)
+	if err != nil {
+		return nil, err
+	}
+	client := newRemoteClient(baseURL, resolveConnectToken(token))
+
+	var sess *remoteSession
+	var conv *remoteConversation
+	if convID == "" {
+		if sess, conv, err = pickConversation(client); err != nil {
+			return nil, err
+		}
+	} else {
+		if conv, err = client.getConversation(convID); err != nil {
+			return nil, fmt.Errorf("conversation %s: %w", convID, err)
+		}
+		sessions, err := client.listAllSessions()
+		if err != nil {
+			return nil, fmt.Errorf("list sessions: %w", err)
+		}
+		for i := range sessions {
+			if sessions[i].ID == conv.SessionID {
+				sess = &sessions[i]
+				break
+			}
+		}
+	}
+
+	msgs, err := client.recentMessages(conv.ID, 50)
+	if err != nil {
+		return nil, fmt.Errorf("load messages: %w", err)
+	}
+
+	att := &remoteAttachment{
+		model:   conv.Model,
+		title:   conv.Name,
+		history: remoteMessagesToDisplay(msgs),
+	}
+	if att.title == "" {
+		att.title = conv.ID
+	}
+	if sess != nil {
+		att.title = sess.Name + " / " + att.title
+		if info, err := os.Stat(sess.WorktreePath); err == nil && info.IsDir() {
+			att.workdir = sess.WorktreePath
+		}
+	}
+
+	att.backend = newRemoteBackend(client, conv.ID, agent.ProcessOptions{
+		ConversationID: conv.ID,
+		Workdir:        att.workdir,
+		Model:          conv.Model,
+	})
+	if err := att.backend.Start(); err != nil {
+		return nil, err
+	}
+	return att, nil
+}
+
+// printHistory prints the attached conversation's recent messages before the
+// TUI starts, the same way finished turns are committed to scrollback.
+func printHistory(m *model, att *remoteAttachment) {
+	if w, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && w > 0 {
+		m.width = w
+	}
+	msgs := append([]*displayMessage{{kind: msgSystem, content: "Attached to " + att.title}}, att.history...)
+	msgs = append(msgs, &displayMessage{kind: msgSystem, content: "--- End of history ---"})
+	for _, dm := range msgs {
+		if r := renderSingleMessage(dm, m.width, m.s, m.mdCache, m.verbose); r != "" {
+			fmt.Println(r)
+		}
+	}
+}
+
+// clientOpts are the command-line settings that apply in client mode.
+type clientOpts struct {
+	model      string // empty = keep the conversation's model
+	permMode   string // empty = keep the conversation's mode
+	verbose    bool
+	promptText string
+	theme      string
+}
+
+// runClient runs the TUI attached to a conversation in a running backend.
+// Exiting detaches; the conversation keeps running in the backend.
+func runClient(target, token, convID string, opts clientOpts) {
+	att, err := attachRemote(target, token, convID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	backend := att.backend
+
+	modelName := att.model
+	if opts.model != "" {
+		modelName = opts.model
+		backend.SetModel(opts.model) //nolint:errcheck // only records the model
+	}
+	permMode := "default"
+	if opts.permMode != "" {
+		if err := backend.SetPermissionMode(opts.permMode); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not set permission mode: %v\n", err)
+		} else {
+			permMode = opts.permMode
+		}
+	}
+
+	t := selectTheme(opts.theme)
+	printBanner(modelName, permMode, att.workdir, t)
+
+	m := newModel(backend, modelOpts{
+		model:      modelName,
+		permMode:   permMode,
+		workdir:    att.workdir,
+		verbose:    opts.verbose,
+		promptMode: opts.promptText != "",
+		promptText: opts.promptText,
+		theme:      t,
+	})
+	if att.workdir != "" {
+		m.gitBranch, m.gitDirty = detectGitState(att.workdir)
+	}
+	printHistory(&m, att)
+
+	defer setupPanicRecovery(&m)()
+
+	if _, err := tea.NewProgram(m).Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error running TUI: %v\n", err)
+		os.Exit(1)
+	}
+
+	backend.Stop()
+	select {
+	case <-backend.Done():
+	case <-time.After(2 * time.Second):
+	}
+}
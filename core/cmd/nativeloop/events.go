@@ -7,13 +7,20 @@ import (
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	core "github.com/chatml/chatml-core"
 	"github.com/chatml/chatml-core/agent"
+	ctxpkg "github.com/chatml/chatml-core/context"
 )
 
 // ── Message types for BubbleTea ─────────────────────────────────────────────
 
 type agentEventMsg agent.AgentEvent
-type backendDoneMsg struct{}
+
+// backendDoneMsg reports that a backend's output closed. After a session
+// switch (see switchSession) it can come from the previous backend.
+type backendDoneMsg struct {
+	backend agent.ConversationBackend
+}
 type parseErrorMsg struct {
 	raw string
 	err error
@@ -39,7 +46,7 @@ func waitForEvent(backend agent.ConversationBackend) tea.Cmd {
 		for {
 			line, ok := <-backend.Output()
 			if !ok {
-				return backendDoneMsg{}
+				return backendDoneMsg{backend: backend}
 			}
 			var event agent.AgentEvent
 			if err := json.Unmarshal([]byte(line), &event); err != nil {
@@ -85,6 +92,8 @@ func processAgentEvent(m *model, raw agentEventMsg) tea.Cmd {
 		return handleContextWarning(m, e)
 	case "context_usage":
 		return handleContextUsage(m, e)
+	case "context_breakdown":
+		return handleContextBreakdown(m, e)
 	case "todo_update":
 		return handleTodoUpdate(m, e)
 	case "permission_mode_changed":
@@ -157,6 +166,7 @@ func handleReady(m *model, e agent.AgentEvent) tea.Cmd {
 }
 
 func handleSessionStarted(m *model, e agent.AgentEvent) tea.Cmd {
+	m.sessionID = e.SessionID
 	if m.verbose {
 		m.appendActive(&displayMessage{
 			kind:    msgSystem,
@@ -595,6 +605,44 @@ func handleContextUsage(m *model, e agent.AgentEvent) tea.Cmd {
 	return nil
 }
 
+func handleContextBreakdown(m *model, e agent.AgentEvent) tea.Cmd {
+	b := e.ContextBreakdown
+	if b == nil || b.ContextWindow <= 0 {
+		return nil
+	}
+	m.appendActive(&displayMessage{
+		kind:    msgSystem,
+		content: formatContextBreakdown(b),
+	})
+	return nil
+}
+
+// formatContextBreakdown renders the context bar followed by each category
+// and its largest contributors.
+func formatContextBreakdown(b *core.ContextBreakdown) string {
+	const maxItems = 5
+	pct := b.EstimatedTokens * 100 / b.ContextWindow
+	lines := []string{contextBar(pct, b.ContextWindow) + fmt.Sprintf(" · ~%s tokens estimated", formatNum(b.EstimatedTokens))}
+	if b.ReportedTokens > 0 {
+		lines = append(lines, fmt.Sprintf("Last API-reported usage: %s tokens", formatNum(b.ReportedTokens)))
+	}
+	for _, c := range b.Categories {
+		if c.Tokens == 0 {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("  %-18s %8s  %4.1f%%", ctxpkg.FormatCategory(c.Name), formatNum(c.Tokens),
+			float64(c.Tokens)*100/float64(b.ContextWindow)))
+		for i, item := range c.Items {
+			if i == maxItems {
+				lines = append(lines, fmt.Sprintf("    … %d more", len(c.Items)-maxItems))
+				break
+			}
+			lines = append(lines, fmt.Sprintf("    %-16s %8s", item.Name, formatNum(item.Tokens)))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
 func handleTodoUpdate(m *model, e agent.AgentEvent) tea.Cmd {
 	m.prompt.todos = e.Todos
 	return nil
@@ -847,6 +895,9 @@ func handlePreCompact(m *model, e agent.AgentEvent) tea.Cmd {
 
 func handlePostCompact(m *model, e agent.AgentEvent) tea.Cmd {
 	msg := "Context compacted"
+	if e.PostTokens > 0 {
+		msg += fmt.Sprintf(" (%s → %s tokens)", formatNum(e.PreTokens), formatNum(e.PostTokens))
+	}
 	if e.CompactSummary != "" {
 		msg += ": " + truncate(e.CompactSummary, 100)
 	}
@@ -78,6 +78,8 @@ func renderInput(m *model) string {
 		inputLine = m.input.View()
 	case stateSessionPicker:
 		inputLine = renderSessionPicker(m.sessionList, m.sessionSelected, m.s, m.width)
+	case stateForkPicker:
+		inputLine = renderForkPicker(&m.forkPicker, m.s)
 	default:
 		if m.multiLineMode {
 			inputLine = m.multiLine.View() + "\n" + m.s.gray.Render("  Ctrl+Enter to send · Ctrl+E to exit multi-line")
@@ -42,6 +42,9 @@ func main() {
 	maxBudget := flag.Float64("max-budget", 0, "Maximum session budget in USD (0=unlimited)")
 	themeFlag := flag.String("theme", "auto", "Color theme: dark, light, auto")
 	versionFlag := flag.Bool("version", false, "Print version and exit")
+	connect := flag.String("connect", "", "Attach to a conversation in a running ChatML backend: URL, host:port, or auto")
+	token := flag.String("token", "", "Backend auth token for -connect (default: CHATML_AUTH_TOKEN env or the local backend's token file)")
+	conversation := flag.String("conversation", "", "Conversation ID to attach to with -connect (default: pick interactively)")
 	flag.Parse()
 
 	if *versionFlag {
@@ -49,6 +52,25 @@ func main() {
 		os.Exit(0)
 	}
 
+	if *connect != "" {
+		// Only explicitly passed settings are applied to the attached
+		// conversation; the defaults would override its current settings.
+		co := clientOpts{verbose: *verbose, promptText: *prompt, theme: *themeFlag}
+		flag.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "model":
+				co.model = *modelFlag
+			case "mode":
+				co.permMode = *mode
+			}
+		})
+		if *plan {
+			co.permMode = "plan"
+		}
+		runClient(*connect, *token, *conversation, co)
+		return
+	}
+
 	// Resolve API key
 	key := *apiKey
 	if key == "" {
@@ -123,6 +145,10 @@ func main() {
 
 	// Create the BubbleTea model
 	m := newModel(backend, modelOpts{
+		newBackend: func(o agent.ProcessOptions) (agent.ConversationBackend, error) {
+			return factory(o, key, "")
+		},
+		baseOpts:   opts,
 		model:      *modelFlag,
 		permMode:   *mode,
 		fastMode:   *fast,
@@ -156,10 +182,15 @@ func main() {
 	p := tea.NewProgram(m)
 
 	// Run the TUI (event listener starts via Init() tea.Cmd — no goroutine race)
-	if _, err := p.Run(); err != nil {
+	final, err := p.Run()
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error running TUI: %v\n", err)
 		os.Exit(1)
 	}
+	// /resume and /fork replace the backend; stop the current one.
+	if fm, ok := final.(model); ok && fm.backend != nil {
+		backend = fm.backend
+	}
 
 	// Shutdown: interrupt any in-flight operations, then stop.
 	// Only send interrupt if the backend is still running (not already finished).
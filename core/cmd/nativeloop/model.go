@@ -6,7 +6,6 @@ import (
 	"time"
 
 	"github.com/chatml/chatml-core/agent"
-	"github.com/chatml/chatml-core/loop"
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
@@ -26,6 +25,7 @@ const (
 	statePlanReview
 	stateReason
 	stateSessionPicker
+	stateForkPicker
 )
 
 // ── Session stats ───────────────────────────────────────────────────────────
@@ -144,8 +144,18 @@ type model struct {
 	themeName string
 
 	// Session picker
-	sessionList     []loop.TranscriptSummary
+	sessionList     []sessionEntry
 	sessionSelected int
+	forkPicker      forkPickerState
+
+	// Session switching (resume/fork restart the backend, see sessions.go).
+	// listenerIdle is set when the previous backend's event listener ended
+	// before the new backend was ready.
+	sessionID    string
+	newBackend   func(agent.ProcessOptions) (agent.ConversationBackend, error)
+	baseOpts     agent.ProcessOptions
+	switching    bool
+	listenerIdle bool
 
 	// Sub-agent tracking: agentID -> index in activeMsgs
 	agentMsgIdx map[string]int
@@ -208,6 +218,8 @@ func newModel(backend agent.ConversationBackend, opts modelOpts) model {
 		promptMode:  opts.promptMode,
 		promptText:  opts.promptText,
 		maxBudget:   opts.maxBudget,
+		newBackend:  opts.newBackend,
+		baseOpts:    opts.baseOpts,
 		startTime:   time.Now(),
 		notifications: true,
 		agentMsgIdx:   make(map[string]int),
@@ -218,6 +230,8 @@ func newModel(backend agent.ConversationBackend, opts modelOpts) model {
 }
 
 type modelOpts struct {
+	newBackend func(agent.ProcessOptions) (agent.ConversationBackend, error) // Used to resume and fork sessions
+	baseOpts   agent.ProcessOptions
 	model      string
 	permMode   string
 	fastMode   bool
@@ -606,6 +620,10 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case stateSessionPicker:
 			cmd := handleSessionPickerKey(&m, msg)
 			return m, cmd
+
+		case stateForkPicker:
+			cmd := handleForkPickerKey(&m, msg)
+			return m, cmd
 		}
 
 	case spinner.TickMsg:
@@ -623,6 +641,22 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 
+	case compactDoneMsg:
+		if msg.err != nil {
+			addErrorMsg(&m, "Compaction failed: "+msg.err.Error())
+			if cmd := flushPendingPrintln(&m); cmd != nil {
+				cmds = append(cmds, cmd)
+			}
+		}
+
+	case contextBreakdownDoneMsg:
+		if msg.err != nil {
+			addErrorMsg(&m, "Context breakdown failed: "+msg.err.Error())
+			if cmd := flushPendingPrintln(&m); cmd != nil {
+				cmds = append(cmds, cmd)
+			}
+		}
+
 	case gitStateMsg:
 		m.gitBranch = msg.branch
 		m.gitDirty = msg.dirty
@@ -647,7 +681,19 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmds = append(cmds, waitForEvent(m.backend))
 
 	case backendDoneMsg:
+		if m.switching {
+			// The previous backend of a session switch stopped first; the
+			// listener resumes once the new backend is in place.
+			m.listenerIdle = true
+			return m, nil
+		}
+		if msg.backend != m.backend {
+			return m, waitForEvent(m.backend)
+		}
 		return m, tea.Quit
+
+	case backendSwitchedMsg:
+		cmds = append(cmds, handleBackendSwitched(&m, msg))
 	}
 
 	return m, tea.Batch(cmds...)
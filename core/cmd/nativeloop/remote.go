@@ -0,0 +1,644 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	core "github.com/chatml/chatml-core"
+	"github.com/chatml/chatml-core/agent"
+	"github.com/gorilla/websocket"
+)
+
+// Client mode: instead of running its own agent loop, the TUI attaches to a
+// conversation owned by a running ChatML backend. remoteBackend implements
+// agent.ConversationBackend over the backend's REST API (commands) and its
+// WebSocket (events), so the rest of the TUI works unchanged.
+
+const (
+	remoteRequestTimeout = 30 * time.Second
+	remoteReconnectDelay = 2 * time.Second
+	remoteReconnectTries = 15
+)
+
+// remoteClient is a minimal client for the backend's REST API.
+type remoteClient struct {
+	baseURL string // e.g. http://127.0.0.1:9876
+	token   string
+	http    *http.Client
+}
+
+func newRemoteClient(baseURL, token string) *remoteClient {
+	return &remoteClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		http:    &http.Client{Timeout: remoteRequestTimeout},
+	}
+}
+
+// do sends a JSON request and decodes a JSON response into out (if non-nil).
+// Non-2xx responses are returned as errors carrying the backend's message.
+func (c *remoteClient) do(ctx context.Context, method, path string, body, out interface{}) (int, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return 0, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Error != "" {
+			return resp.StatusCode, fmt.Errorf("%s", apiErr.Error)
+		}
+		return resp.StatusCode, fmt.Errorf("%s %s: %s", method, path, resp.Status)
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp.StatusCode, fmt.Errorf("decode %s: %w", path, err)
+		}
+	}
+	return resp.StatusCode, nil
+}
+
+func (c *remoteClient) get(path string, out interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), remoteRequestTimeout)
+	defer cancel()
+	_, err := c.do(ctx, http.MethodGet, path, nil, out)
+	return err
+}
+
+func (c *remoteClient) post(path string, body interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), remoteRequestTimeout)
+	defer cancel()
+	_, err := c.do(ctx, http.MethodPost, path, body, nil)
+	return err
+}
+
+// wsURL returns the backend's WebSocket endpoint, authenticated with the token.
+func (c *remoteClient) wsURL() (string, error) {
+	u, err := url.Parse(c.baseURL)
+	if err != nil {
+		return "", err
+	}
+	switch u.Scheme {
+	case "https":
+		u.Scheme = "wss"
+	default:
+		u.Scheme = "ws"
+	}
+	u.Path = "/ws"
+	if c.token != "" {
+		u.RawQuery = url.Values{"token": {c.token}}.Encode()
+	}
+	return u.String(), nil
+}
+
+// --- Listing ---
+
+type remoteWorkspace struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Path   string `json:"path"`
+	Branch string `json:"branch"`
+}
+
+type remoteSession struct {
+	ID           string `json:"id"`
+	WorkspaceID  string `json:"workspaceId"`
+	Name         string `json:"name"`
+	Branch       string `json:"branch"`
+	WorktreePath string `json:"worktreePath"`
+	Status       string `json:"status"`
+}
+
+type remoteConversation struct {
+	ID           string    `json:"id"`
+	SessionID    string    `json:"sessionId"`
+	Type         string    `json:"type"`
+	Name         string    `json:"name"`
+	Status       string    `json:"status"`
+	Model        string    `json:"model"`
+	MessageCount int       `json:"messageCount"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+type remoteToolUsage struct {
+	Tool    string                 `json:"tool"`
+	Params  map[string]interface{} `json:"params"`
+	Success *bool                  `json:"success"`
+	Summary string                 `json:"summary"`
+}
+
+type remoteMessage struct {
+	ID        string            `json:"id"`
+	Role      string            `json:"role"`
+	Content   string            `json:"content"`
+	ToolUsage []remoteToolUsage `json:"toolUsage"`
+}
+
+func (c *remoteClient) listWorkspaces() ([]remoteWorkspace, error) {
+	var out []remoteWorkspace
+	return out, c.get("/api/repos", &out)
+}
+
+func (c *remoteClient) listSessions(workspaceID string) ([]remoteSession, error) {
+	var out []remoteSession
+	return out, c.get("/api/repos/"+url.PathEscape(workspaceID)+"/sessions", &out)
+}
+
+func (c *remoteClient) listAllSessions() ([]remoteSession, error) {
+	var out []remoteSession
+	return out, c.get("/api/sessions", &out)
+}
+
+func (c *remoteClient) listConversations(workspaceID, sessionID string) ([]remoteConversation, error) {
+	var out []remoteConversation
+	path := "/api/repos/" + url.PathEscape(workspaceID) + "/sessions/" + url.PathEscape(sessionID) + "/conversations"
+	return out, c.get(path, &out)
+}
+
+func (c *remoteClient) getConversation(convID string) (*remoteConversation, error) {
+	var out remoteConversation
+	if err := c.get("/api/conversations/"+url.PathEscape(convID), &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// recentMessages returns the last limit messages of a conversation, oldest first.
+func (c *remoteClient) recentMessages(convID string, limit int) ([]remoteMessage, error) {
+	var page struct {
+		Messages []remoteMessage `json:"messages"`
+	}
+	path := fmt.Sprintf("/api/conversations/%s/messages?limit=%d", url.PathEscape(convID), limit)
+	return page.Messages, c.get(path, &page)
+}
+
+// --- Backend ---
+
+// remoteBackend drives one backend conversation. Stop only detaches: the
+// conversation keeps running in the backend.
+type remoteBackend struct {
+	client *remoteClient
+	convID string
+	opts   agent.ProcessOptions
+
+	output   chan string
+	done     chan struct{}
+	stopOnce sync.Once
+
+	mu             sync.Mutex
+	conn           *websocket.Conn
+	running        bool
+	stopped        bool
+	sessionID      string
+	model          string // sent with the next message; the backend has no set-model endpoint
+	planMode       bool
+	inActiveTurn   bool
+	pendingMsg     *core.Message
+	sawErrorEvent  bool
+	producedOutput bool
+}
+
+func newRemoteBackend(client *remoteClient, convID string, opts agent.ProcessOptions) *remoteBackend {
+	return &remoteBackend{
+		client:   client,
+		convID:   convID,
+		opts:     opts,
+		output:   make(chan string, 256),
+		done:     make(chan struct{}),
+		planMode: opts.PlanMode,
+	}
+}
+
+func (b *remoteBackend) convPath(suffix string) string {
+	return "/api/conversations/" + url.PathEscape(b.convID) + suffix
+}
+
+// Start connects to the event stream. A synthetic ready event is emitted
+// once connected, since the backend's own ready events describe agent
+// processes starting, not this client.
+func (b *remoteBackend) Start() error {
+	conn, err := b.dial()
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	b.conn = conn
+	b.running = true
+	b.mu.Unlock()
+
+	b.emit(agent.AgentEvent{Type: "ready"})
+	go b.readLoop()
+	return nil
+}
+
+func (b *remoteBackend) dial() (*websocket.Conn, error) {
+	wsURL, err := b.client.wsURL()
+	if err != nil {
+		return nil, err
+	}
+	conn, resp, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		if resp != nil {
+			return nil, fmt.Errorf("connect to %s: %s", b.client.baseURL, resp.Status)
+		}
+		return nil, fmt.Errorf("connect to %s: %w", b.client.baseURL, err)
+	}
+	return conn, nil
+}
+
+// readLoop forwards this conversation's agent events to Output, reconnecting
+// if the connection drops. Events broadcast while disconnected are lost.
+func (b *remoteBackend) readLoop() {
+	defer close(b.done)
+	defer close(b.output)
+
+	for {
+		b.mu.Lock()
+		conn := b.conn
+		b.mu.Unlock()
+
+		err := b.forward(conn)
+		if b.IsStopped() {
+			return
+		}
+		b.emit(agent.AgentEvent{Type: "warning", Message: fmt.Sprintf("Lost connection to backend (%v), reconnecting…", err)})
+		if !b.reconnect() {
+			b.emit(agent.AgentEvent{Type: "error", Message: "Could not reconnect to backend"})
+			b.mu.Lock()
+			b.running = false
+			b.mu.Unlock()
+			return
+		}
+		b.emit(agent.AgentEvent{Type: "warning", Message: "Reconnected to backend; events sent while disconnected are not shown"})
+	}
+}
+
+// forward reads WebSocket messages until the connection fails.
+func (b *remoteBackend) forward(conn *websocket.Conn) error {
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		var ev struct {
+			Type           string          `json:"type"`
+			ConversationID string          `json:"conversationId"`
+			Payload        json.RawMessage `json:"payload"`
+		}
+		if json.Unmarshal(data, &ev) != nil || ev.ConversationID != b.convID {
+			continue
+		}
+		switch ev.Type {
+		case "conversation_status", "summary_updated", "ready":
+			// Not agent events (or, for ready, not meaningful to a client).
+			continue
+		}
+		if len(ev.Payload) == 0 {
+			continue
+		}
+		select {
+		case b.output <- string(ev.Payload):
+		case <-time.After(5 * time.Second):
+			// The TUI is stuck; dropping beats stalling the connection.
+		}
+	}
+}
+
+func (b *remoteBackend) reconnect() bool {
+	for i := 0; i < remoteReconnectTries; i++ {
+		time.Sleep(remoteReconnectDelay)
+		if b.IsStopped() {
+			return false
+		}
+		conn, err := b.dial()
+		if err != nil {
+			continue
+		}
+		b.mu.Lock()
+		if b.stopped {
+			b.mu.Unlock()
+			conn.Close()
+			return false
+		}
+		b.conn = conn
+		b.mu.Unlock()
+		return true
+	}
+	return false
+}
+
+// emit queues a locally generated event.
+func (b *remoteBackend) emit(e agent.AgentEvent) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	select {
+	case b.output <- string(data):
+	default:
+	}
+}
+
+func (b *remoteBackend) SendMessage(content string) error {
+	return b.SendMessageWithAttachments(content, nil)
+}
+
+func (b *remoteBackend) SendMessageWithAttachments(content string, attachments []core.Attachment) error {
+	b.mu.Lock()
+	req := map[string]interface{}{"content": content}
+	if b.model != "" {
+		req["model"] = b.model
+	}
+	b.mu.Unlock()
+	if len(attachments) > 0 {
+		req["attachments"] = attachments
+	}
+	return b.client.post(b.convPath("/messages"), req)
+}
+
+// SendStop stops the conversation's agent in the backend.
+func (b *remoteBackend) SendStop() error {
+	return b.client.post(b.convPath("/stop"), nil)
+}
+
+// SendInterrupt aborts the current turn. The backend only supports stopping
+// the agent, which it restarts on the next message.
+func (b *remoteBackend) SendInterrupt() error {
+	return b.SendStop()
+}
+
+// Stop detaches from the conversation; it keeps running in the backend.
+func (b *remoteBackend) Stop() {
+	b.TryStop()
+}
+
+func (b *remoteBackend) TryStop() bool {
+	performed := false
+	b.stopOnce.Do(func() {
+		performed = true
+		b.mu.Lock()
+		b.stopped = true
+		b.running = false
+		conn := b.conn
+		b.mu.Unlock()
+		if conn != nil {
+			conn.Close()
+		} else {
+			close(b.output)
+			close(b.done)
+		}
+	})
+	return performed
+}
+
+func (b *remoteBackend) Output() <-chan string { return b.output }
+func (b *remoteBackend) Done() <-chan struct{} { return b.done }
+
+func (b *remoteBackend) IsRunning() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.running
+}
+
+func (b *remoteBackend) IsStopped() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.stopped
+}
+
+func (b *remoteBackend) SetSessionID(sessionID string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.sessionID = sessionID
+}
+
+func (b *remoteBackend) GetSessionID() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.sessionID
+}
+
+// SetPermissionMode maps "plan" onto the backend's plan-mode toggle, which
+// is separate from its permission mode.
+func (b *remoteBackend) SetPermissionMode(mode string) error {
+	if mode == "plan" {
+		if err := b.client.post(b.convPath("/plan-mode"), map[string]bool{"enabled": true}); err != nil {
+			return err
+		}
+		b.SetPlanModeFromEvent(true)
+		return nil
+	}
+	if b.IsPlanModeActive() {
+		if err := b.client.post(b.convPath("/plan-mode"), map[string]bool{"enabled": false}); err != nil {
+			return err
+		}
+		b.SetPlanModeFromEvent(false)
+	}
+	return b.client.post(b.convPath("/permission-mode"), map[string]string{"mode": mode})
+}
+
+func (b *remoteBackend) SetFastMode(enabled bool) error {
+	return b.client.post(b.convPath("/fast-mode"), map[string]bool{"enabled": enabled})
+}
+
+// SetModel takes effect with the next message, which carries the model.
+func (b *remoteBackend) SetModel(model string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.model = model
+	return nil
+}
+
+func (b *remoteBackend) SetMaxThinkingTokens(tokens int) error {
+	return b.client.post(b.convPath("/max-thinking-tokens"), map[string]int{"maxThinkingTokens": tokens})
+}
+
+func (b *remoteBackend) SetEffort(string) error {
+	return fmt.Errorf("the backend does not support changing effort")
+}
+
+func (b *remoteBackend) SetPlanModeFromEvent(active bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.planMode = active
+}
+
+func (b *remoteBackend) SetOptionsPlanMode(enabled bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.opts.PlanMode = enabled
+}
+
+func (b *remoteBackend) SetOptionsPermissionMode(mode string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.opts.PermissionMode = mode
+}
+
+func (b *remoteBackend) IsPlanModeActive() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.planMode
+}
+
+func (b *remoteBackend) SetInActiveTurn(active bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.inActiveTurn = active
+}
+
+func (b *remoteBackend) IsInActiveTurn() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.inActiveTurn
+}
+
+func (b *remoteBackend) StoreOrDeferMessage(msg *core.Message) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.inActiveTurn {
+		b.pendingMsg = msg
+		return false
+	}
+	return true
+}
+
+func (b *remoteBackend) EndTurnAndTakePending() *core.Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.inActiveTurn = false
+	msg := b.pendingMsg
+	b.pendingMsg = nil
+	return msg
+}
+
+func (b *remoteBackend) SetSawErrorEvent() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.sawErrorEvent = true
+}
+
+func (b *remoteBackend) SawErrorEvent() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.sawErrorEvent
+}
+
+func (b *remoteBackend) SetProducedOutput() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.producedOutput = true
+}
+
+func (b *remoteBackend) ProducedOutput() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.producedOutput
+}
+
+func (b *remoteBackend) StopTask(taskID string) error {
+	return b.client.post(b.convPath("/tasks/"+url.PathEscape(taskID)+"/stop"), nil)
+}
+
+func (b *remoteBackend) SendToolApprovalResponse(requestID, action, specifier string, updatedInput json.RawMessage) error {
+	body := map[string]interface{}{
+		"requestId": requestID,
+		"action":    action,
+		"specifier": specifier,
+	}
+	// A nil RawMessage marshals as null, which would replace the tool input.
+	if len(updatedInput) > 0 {
+		body["updatedInput"] = updatedInput
+	}
+	return b.client.post(b.convPath("/approve-tool"), body)
+}
+
+func (b *remoteBackend) SendBatchToolApprovalResponse(requestID string, action string, perTool map[string]agent.ToolApprovalOverride) error {
+	return b.client.post(b.convPath("/approve-batch-tools"), map[string]interface{}{
+		"requestId": requestID,
+		"action":    action,
+		"perTool":   perTool,
+	})
+}
+
+func (b *remoteBackend) SendUserQuestionResponse(requestID string, answers map[string]string) error {
+	return b.client.post(b.convPath("/answer-question"), map[string]interface{}{
+		"requestId": requestID,
+		"answers":   answers,
+	})
+}
+
+func (b *remoteBackend) SendPlanApprovalResponse(requestID string, approved bool, reason string) error {
+	return b.client.post(b.convPath("/approve-plan"), map[string]interface{}{
+		"requestId": requestID,
+		"approved":  approved,
+		"reason":    reason,
+	})
+}
+
+func (b *remoteBackend) Options() agent.ProcessOptions {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.opts
+}
+
+// Compact implements agent.Compacter. The backend runs the compaction in the
+// background when the agent is mid-turn; the report is nil in that case.
+func (b *remoteBackend) Compact(ctx context.Context, instructions string) (*agent.CompactReport, error) {
+	var report agent.CompactReport
+	status, err := b.client.do(ctx, http.MethodPost, b.convPath("/compact"),
+		map[string]string{"instructions": instructions}, &report)
+	if err != nil {
+		return nil, err
+	}
+	if status == http.StatusAccepted {
+		return nil, nil
+	}
+	return &report, nil
+}
+
+// ContextBreakdown implements agent.ContextReporter.
+func (b *remoteBackend) ContextBreakdown(ctx context.Context) (*core.ContextBreakdown, error) {
+	var breakdown core.ContextBreakdown
+	if _, err := b.client.do(ctx, http.MethodGet, b.convPath("/context"), nil, &breakdown); err != nil {
+		return nil, err
+	}
+	return &breakdown, nil
+}
+
+var (
+	_ agent.ConversationBackend = (*remoteBackend)(nil)
+	_ agent.Compacter           = (*remoteBackend)(nil)
+	_ agent.ContextReporter     = (*remoteBackend)(nil)
+)
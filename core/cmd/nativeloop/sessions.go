@@ -2,38 +2,86 @@ package main
 
 import (
 	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"sort"
 	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
 
+	"github.com/chatml/chatml-core/agent"
 	"github.com/chatml/chatml-core/loop"
 	"github.com/chatml/chatml-core/provider"
 )
 
-// Session picker state is integrated into the model via stateSessionPicker.
+// Session picker state is integrated into the model via stateSessionPicker,
+// the fork turn picker via stateForkPicker.
 
-// loadSessionList loads available transcripts and sorts by recency.
-func loadSessionList(workdir string) []loop.TranscriptSummary {
+// sessionEntry is one row of the session picker. Forks are listed under the
+// session they branched from, indented by depth.
+type sessionEntry struct {
+	loop.TranscriptSummary
+	depth int
+}
+
+// forkPickerState holds the fork turn picker: the session being forked, the
+// text of its user turns and whether to fork into a new git worktree.
+type forkPickerState struct {
+	sessionID string
+	turns     []string
+	selected  int
+	worktree  bool
+}
+
+// sessionSwitch describes a session to resume or fork.
+type sessionSwitch struct {
+	source   string // Session to resume or fork from
+	fork     bool
+	turns    int  // With fork: keep only the first N user turns (0 = all)
+	worktree bool // With fork: continue in a new git worktree
+}
+
+// backendSwitchedMsg reports the outcome of switchSession.
+type backendSwitchedMsg struct {
+	backend agent.ConversationBackend
+	workdir string
+	info    string
+	err     error
+}
+
+// loadSessionList loads available transcripts as a tree, most recent first.
+func loadSessionList(workdir string) []sessionEntry {
 	dir := loop.TranscriptDir(workdir)
 	summaries, err := loop.ListTranscripts(dir)
 	if err != nil || len(summaries) == 0 {
 		return nil
 	}
+	roots := loop.TranscriptTree(summaries)
 	// Sort by mod time descending (most recent first)
-	sort.Slice(summaries, func(i, j int) bool {
-		return summaries[i].ModTime.After(summaries[j].ModTime)
+	sort.Slice(roots, func(i, j int) bool {
+		return roots[i].ModTime.After(roots[j].ModTime)
 	})
 	// Limit to 20 most recent
-	if len(summaries) > 20 {
-		summaries = summaries[:20]
+	if len(roots) > 20 {
+		roots = roots[:20]
+	}
+	var entries []sessionEntry
+	var walk func(nodes []*loop.TranscriptNode, depth int)
+	walk = func(nodes []*loop.TranscriptNode, depth int) {
+		for _, n := range nodes {
+			entries = append(entries, sessionEntry{TranscriptSummary: n.TranscriptSummary, depth: depth})
+			walk(n.Forks, depth+1)
+		}
 	}
-	return summaries
+	walk(roots, 0)
+	return entries
 }
 
 // renderSessionPicker renders the session selection UI.
-func renderSessionPicker(sessions []loop.TranscriptSummary, selected int, s *styles, width int) string {
+func renderSessionPicker(sessions []sessionEntry, selected int, s *styles, width int) string {
 	var sb strings.Builder
 	sb.WriteString(s.toolHeader.Render("  Select a session to resume:") + "\n\n")
 
@@ -62,7 +110,13 @@ func renderSessionPicker(sessions []loop.TranscriptSummary, selected int, s *sty
 			cost = fmt.Sprintf(" · $%.4f", sess.CostUSD)
 		}
 
-		line := fmt.Sprintf("  %s  [%s]%s  (%s)", title, model, cost, age)
+		// Forks are indented under their source
+		indent := ""
+		if sess.depth > 0 {
+			indent = strings.Repeat("  ", sess.depth-1) + "└ "
+		}
+
+		line := fmt.Sprintf("  %s%s  [%s]%s  (%s)", indent, title, model, cost, age)
 
 		if i == selected {
 			sb.WriteString(s.warn.Render("  › " + line) + "\n")
@@ -71,7 +125,7 @@ func renderSessionPicker(sessions []loop.TranscriptSummary, selected int, s *sty
 		}
 	}
 
-	sb.WriteString("\n" + s.gray.Render("  ↑/↓ navigate · Enter select · Esc cancel"))
+	sb.WriteString("\n" + s.gray.Render("  ↑/↓ navigate · Enter resume · f fork · Esc cancel"))
 	return sb.String()
 }
 
@@ -91,6 +145,11 @@ func handleSessionPickerKey(m *model, key tea.KeyMsg) tea.Cmd {
 			sess := m.sessionList[m.sessionSelected]
 			return resumeSession(m, sess.SessionID)
 		}
+	case "f":
+		if m.sessionSelected >= 0 && m.sessionSelected < len(m.sessionList) {
+			sess := m.sessionList[m.sessionSelected]
+			return openForkPicker(m, sess.SessionID)
+		}
 	case "esc", "q":
 		m.state = stateIdle
 		// Use addSystemMsg which prints to scrollback when idle, avoiding
@@ -100,35 +159,153 @@ func handleSessionPickerKey(m *model, key tea.KeyMsg) tea.Cmd {
 	return nil
 }
 
-// resumeSession loads a transcript and displays its messages.
+// openForkPicker lists the user turns of a session so one can be picked as
+// the fork point.
+func openForkPicker(m *model, sessionID string) tea.Cmd {
+	msgs, _, err := readSession(m.workdir, sessionID)
+	if err != nil {
+		m.state = stateIdle
+		addErrorMsg(m, err.Error())
+		return flushPendingPrintln(m)
+	}
+	var turns []string
+	for _, i := range loop.TurnStarts(msgs) {
+		text := "(image)"
+		for _, block := range msgs[i].Content {
+			if block.Type == provider.BlockText && block.Text != "" {
+				text = strings.Join(strings.Fields(block.Text), " ")
+				break
+			}
+		}
+		turns = append(turns, text)
+	}
+	if len(turns) == 0 {
+		m.state = stateIdle
+		addSystemMsg(m, fmt.Sprintf("Session %s has no messages to fork from.", sessionID))
+		return flushPendingPrintln(m)
+	}
+	m.forkPicker = forkPickerState{sessionID: sessionID, turns: turns, selected: len(turns) - 1}
+	m.state = stateForkPicker
+	return nil
+}
+
+// renderForkPicker renders the fork turn picker.
+func renderForkPicker(fp *forkPickerState, s *styles) string {
+	var sb strings.Builder
+	sb.WriteString(s.toolHeader.Render("  Fork after which message?") + "\n\n")
+
+	for i, text := range fp.turns {
+		line := fmt.Sprintf("  %2d. %s", i+1, truncate(text, 70))
+		if i == fp.selected {
+			sb.WriteString(s.warn.Render("  › "+line) + "\n")
+		} else {
+			sb.WriteString(s.gray.Render("    "+line) + "\n")
+		}
+	}
+
+	worktree := "off"
+	if fp.worktree {
+		worktree = "on"
+	}
+	sb.WriteString("\n" + s.gray.Render("  ↑/↓ navigate · Enter fork · w new worktree ("+worktree+") · Esc back"))
+	return sb.String()
+}
+
+// handleForkPickerKey handles keyboard input in the fork turn picker.
+func handleForkPickerKey(m *model, key tea.KeyMsg) tea.Cmd {
+	fp := &m.forkPicker
+	switch key.String() {
+	case "up":
+		if fp.selected > 0 {
+			fp.selected--
+		}
+	case "down":
+		if fp.selected < len(fp.turns)-1 {
+			fp.selected++
+		}
+	case "w":
+		fp.worktree = !fp.worktree
+	case "enter":
+		return switchSession(m, sessionSwitch{
+			source:   fp.sessionID,
+			fork:     true,
+			turns:    fp.selected + 1,
+			worktree: fp.worktree,
+		})
+	case "esc", "q":
+		if len(m.sessionList) > 0 {
+			m.state = stateSessionPicker
+		} else {
+			m.state = stateIdle
+			addSystemMsg(m, "Fork cancelled.")
+		}
+	}
+	return nil
+}
+
+// resumeSession continues a previous session: its messages are replayed and
+// the backend restarts on its transcript.
 func resumeSession(m *model, sessionID string) tea.Cmd {
-	dir := loop.TranscriptDir(m.workdir)
-	path := loop.FindTranscript(dir, sessionID)
+	return switchSession(m, sessionSwitch{source: sessionID})
+}
+
+// readSession loads a session's transcript from the transcript directory.
+func readSession(workdir, sessionID string) ([]provider.Message, *loop.TranscriptMeta, error) {
+	path := loop.FindTranscript(loop.TranscriptDir(workdir), sessionID)
 	if path == "" {
+		return nil, nil, fmt.Errorf("session %q not found", sessionID)
+	}
+	msgs, meta, err := loop.ReadTranscript(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load session: %w", err)
+	}
+	return msgs, meta, nil
+}
+
+// switchSession replaces the backend with one that resumes or forks sw.source.
+// The kept messages are displayed right away; the old backend is stopped only
+// once the new one has started, so a failed switch leaves the current
+// session running.
+func switchSession(m *model, sw sessionSwitch) tea.Cmd {
+	if m.newBackend == nil {
+		m.state = stateIdle
+		addErrorMsg(m, "Switching sessions is not supported here")
+		return flushPendingPrintln(m)
+	}
+	if m.switching {
 		m.state = stateIdle
-		addErrorMsg(m, fmt.Sprintf("Session %q not found", sessionID))
+		addErrorMsg(m, "A session switch is already in progress")
 		return flushPendingPrintln(m)
 	}
 
-	msgs, meta, err := loop.ReadTranscript(path)
+	msgs, meta, err := readSession(m.workdir, sw.source)
 	if err != nil {
 		m.state = stateIdle
-		addErrorMsg(m, "Failed to load session: "+err.Error())
+		addErrorMsg(m, err.Error())
 		return flushPendingPrintln(m)
 	}
+	if sw.fork && sw.turns > 0 {
+		msgs = msgs[:loop.TurnBoundary(msgs, sw.turns)]
+	}
 
 	// Clear current messages and display the loaded ones
 	m.activeMsgs = nil
 
 	// Show resume header
-	resumeInfo := fmt.Sprintf("Resumed session %s", sessionID)
+	info := fmt.Sprintf("Resumed session %s", sw.source)
+	if sw.fork {
+		info = fmt.Sprintf("Forked session %s", sw.source)
+		if sw.turns > 0 {
+			info += fmt.Sprintf(" after message %d", sw.turns)
+		}
+	}
 	if meta != nil && meta.Model != "" {
-		resumeInfo += fmt.Sprintf(" (model: %s)", meta.Model)
+		info += fmt.Sprintf(" (model: %s)", meta.Model)
 	}
-	if meta != nil && meta.CostUSD > 0 {
-		resumeInfo += fmt.Sprintf(" · $%.4f", meta.CostUSD)
+	if !sw.fork && meta != nil && meta.CostUSD > 0 {
+		info += fmt.Sprintf(" · $%.4f", meta.CostUSD)
 	}
-	m.appendActive(&displayMessage{kind: msgSystem, content: resumeInfo})
+	m.appendActive(&displayMessage{kind: msgSystem, content: info})
 
 	// Convert provider.Messages to displayMessages
 	for _, msg := range msgs {
@@ -139,9 +316,98 @@ func resumeSession(m *model, sessionID string) tea.Cmd {
 	}
 
 	m.appendActive(&displayMessage{kind: msgSystem, content: "--- End of resumed session ---"})
-
 	m.state = stateIdle
-	return nil
+
+	opts := m.baseOpts
+	opts.ConversationID = uuid.New().String()
+	opts.ResumeSession = sw.source
+	opts.Model = m.modelName
+	opts.PermissionMode = m.permMode
+	opts.FastMode = m.fastMode
+	opts.Workdir = m.workdir
+	if sw.fork {
+		opts.SdkSessionID = uuid.New().String()
+		opts.ForkSession = true
+		opts.ForkAtTurn = sw.turns
+	} else {
+		opts.SdkSessionID = sw.source
+	}
+
+	m.switching = true
+	m.listenerIdle = false
+	oldBackend, newBackend := m.backend, m.newBackend
+	return func() tea.Msg {
+		if sw.worktree {
+			dir, err := createForkWorktree(opts.Workdir, opts.SdkSessionID[:8])
+			if err != nil {
+				return backendSwitchedMsg{err: err}
+			}
+			opts.Workdir = dir
+		}
+		backend, err := newBackend(opts)
+		if err != nil {
+			return backendSwitchedMsg{err: fmt.Errorf("create backend: %w", err)}
+		}
+		if err := backend.Start(); err != nil {
+			return backendSwitchedMsg{err: fmt.Errorf("start backend: %w", err)}
+		}
+		oldBackend.Stop()
+		select {
+		case <-oldBackend.Done():
+		case <-time.After(5 * time.Second):
+		}
+		info := ""
+		if sw.worktree {
+			info = "Continuing in worktree " + opts.Workdir
+		}
+		return backendSwitchedMsg{backend: backend, workdir: opts.Workdir, info: info}
+	}
+}
+
+// handleBackendSwitched installs the backend started by switchSession.
+func handleBackendSwitched(m *model, msg backendSwitchedMsg) tea.Cmd {
+	m.switching = false
+	if msg.err != nil {
+		m.listenerIdle = false
+		addErrorMsg(m, "Failed to switch session: "+msg.err.Error())
+		return flushPendingPrintln(m)
+	}
+	m.backend = msg.backend
+	var cmds []tea.Cmd
+	if msg.workdir != m.workdir {
+		m.workdir = msg.workdir
+		cmds = append(cmds, detectGitStateCmd(m.workdir))
+	}
+	if msg.info != "" {
+		addSystemMsg(m, msg.info)
+		cmds = append(cmds, flushPendingPrintln(m))
+	}
+	if m.listenerIdle {
+		m.listenerIdle = false
+		cmds = append(cmds, waitForEvent(m.backend))
+	}
+	return tea.Batch(cmds...)
+}
+
+// createForkWorktree creates a git worktree for a fork on a new branch from
+// HEAD, laid out like the EnterWorktree tool's worktrees.
+func createForkWorktree(workdir, name string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
+	cmd.Dir = workdir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("not a git repository: %s", workdir)
+	}
+	root := strings.TrimSpace(string(out))
+	dir := filepath.Join(root, ".claude", "worktrees", "fork-"+name)
+	os.MkdirAll(filepath.Dir(dir), 0755) //nolint:errcheck
+
+	cmd = exec.Command("git", "worktree", "add", dir, "-b", "chatml/fork-"+name)
+	cmd.Dir = root
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("failed to create worktree: %s", strings.TrimSpace(string(out)))
+	}
+	return dir, nil
 }
 
 // providerMessageToDisplay converts a provider.Message to a displayMessage.
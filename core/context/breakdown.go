@@ -0,0 +1,146 @@
+package context
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	core "github.com/chatml/chatml-core"
+	"github.com/chatml/chatml-core/prompt"
+	"github.com/chatml/chatml-core/provider"
+)
+
+// Breakdown categories, in the order they are reported.
+const (
+	CategorySystemPrompt = "system_prompt"
+	CategoryTools        = "tools"
+	CategoryMessages     = "messages"
+	CategoryToolResults  = "tool_results"
+	CategoryAttachments  = "attachments"
+)
+
+// BuiltinToolsItem names the tools category item covering non-MCP tools.
+const BuiltinToolsItem = "built-in"
+
+// Breakdown attributes the tokens of a request to categories: system prompt
+// sections, tool definitions per MCP server, conversation messages, tool
+// results per tool, and image attachments. Counts use the same ~4 chars per
+// token heuristic as EstimateTokens, so they show proportions rather than
+// exact tokenizer output.
+func Breakdown(sections []prompt.Section, tools []provider.ToolDef, messages []provider.Message) *core.ContextBreakdown {
+	system := newTally()
+	for _, s := range sections {
+		system.add(s.Name, len(s.Text))
+	}
+
+	toolDefs := newTally()
+	for _, t := range tools {
+		toolDefs.add(toolSource(t.Name), len(t.Name)+len(t.Description)+len(t.InputSchema))
+	}
+
+	msgs := newTally()
+	results := newTally()
+	attachments := newTally()
+	toolNames := make(map[string]string)
+	for _, msg := range messages {
+		for _, block := range msg.Content {
+			switch block.Type {
+			case provider.BlockText:
+				msgs.add(string(msg.Role), len(block.Text))
+			case provider.BlockThinking:
+				msgs.add("thinking", len(block.Thinking))
+			case provider.BlockToolUse:
+				toolNames[block.ToolUseID] = block.ToolName
+				msgs.add("tool_calls", len(block.ToolName)+len(block.Input))
+			case provider.BlockToolResult:
+				name := toolNames[block.ForToolUseID]
+				if name == "" {
+					name = "unknown"
+				}
+				results.add(name, len(block.ResultContent))
+			case provider.BlockImage:
+				mediaType := block.MediaType
+				if mediaType == "" {
+					mediaType = "image"
+				}
+				attachments.add(mediaType, len(block.Base64Data))
+			}
+		}
+	}
+
+	b := &core.ContextBreakdown{
+		Categories: []core.ContextCategory{
+			system.category(CategorySystemPrompt),
+			toolDefs.category(CategoryTools),
+			msgs.category(CategoryMessages),
+			results.category(CategoryToolResults),
+			attachments.category(CategoryAttachments),
+		},
+	}
+	for _, c := range b.Categories {
+		b.EstimatedTokens += c.Tokens
+	}
+	return b
+}
+
+// FormatCategory returns a human-readable label for a breakdown category.
+func FormatCategory(name string) string {
+	switch name {
+	case CategorySystemPrompt:
+		return "System prompt"
+	case CategoryTools:
+		return "Tool definitions"
+	case CategoryMessages:
+		return "Messages"
+	case CategoryToolResults:
+		return "Tool results"
+	case CategoryAttachments:
+		return "Attachments"
+	}
+	return name
+}
+
+// toolSource returns the MCP server a tool belongs to ("mcp:github" for
+// mcp__github__create_issue), or BuiltinToolsItem.
+func toolSource(name string) string {
+	rest, ok := strings.CutPrefix(name, "mcp__")
+	if !ok {
+		return BuiltinToolsItem
+	}
+	server, _, _ := strings.Cut(rest, "__")
+	return fmt.Sprintf("mcp:%s", server)
+}
+
+// charsToTokens applies the EstimateTokens heuristic to a character count.
+func charsToTokens(chars int) int {
+	return chars / 4 * 4 / 3
+}
+
+// tally accumulates character counts per item name, preserving first-seen order.
+type tally struct {
+	order []string
+	chars map[string]int
+}
+
+func newTally() *tally {
+	return &tally{chars: make(map[string]int)}
+}
+
+func (t *tally) add(name string, chars int) {
+	if _, ok := t.chars[name]; !ok {
+		t.order = append(t.order, name)
+	}
+	t.chars[name] += chars
+}
+
+// category converts the tally to a category with items sorted largest first.
+func (t *tally) category(name string) core.ContextCategory {
+	c := core.ContextCategory{Name: name}
+	for _, item := range t.order {
+		tokens := charsToTokens(t.chars[item])
+		c.Tokens += tokens
+		c.Items = append(c.Items, core.ContextItem{Name: item, Tokens: tokens})
+	}
+	sort.SliceStable(c.Items, func(i, j int) bool { return c.Items[i].Tokens > c.Items[j].Tokens })
+	return c
+}
@@ -0,0 +1,85 @@
+package context
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	core "github.com/chatml/chatml-core"
+	"github.com/chatml/chatml-core/prompt"
+	"github.com/chatml/chatml-core/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func category(t *testing.T, b *core.ContextBreakdown, name string) core.ContextCategory {
+	t.Helper()
+	for _, c := range b.Categories {
+		if c.Name == name {
+			return c
+		}
+	}
+	require.Failf(t, "missing category", "%s", name)
+	return core.ContextCategory{}
+}
+
+func TestBreakdown_AttributesByCategory(t *testing.T) {
+	sections := []prompt.Section{
+		{Name: "core", Text: strings.Repeat("c", 300)},
+		{Name: "claude_md", Text: strings.Repeat("m", 3000)},
+	}
+	tools := []provider.ToolDef{
+		{Name: "Read", Description: strings.Repeat("r", 120)},
+		{Name: "mcp__github__create_issue", Description: strings.Repeat("g", 1200)},
+		{Name: "mcp__github__list_prs", Description: strings.Repeat("g", 1200)},
+	}
+	messages := []provider.Message{
+		{Role: provider.RoleUser, Content: []provider.ContentBlock{
+			provider.NewTextBlock(strings.Repeat("u", 60)),
+			{Type: provider.BlockImage, MediaType: "image/png", Base64Data: strings.Repeat("i", 600)},
+		}},
+		{Role: provider.RoleAssistant, Content: []provider.ContentBlock{
+			provider.NewToolUseBlock("tu_1", "Bash", json.RawMessage(`{"command":"go test ./..."}`)),
+		}},
+		{Role: provider.RoleUser, Content: []provider.ContentBlock{
+			provider.NewToolResultBlock("tu_1", strings.Repeat("o", 6000), false),
+		}},
+	}
+
+	b := Breakdown(sections, tools, messages)
+
+	var names []string
+	sum := 0
+	for _, c := range b.Categories {
+		names = append(names, c.Name)
+		sum += c.Tokens
+	}
+	assert.Equal(t, []string{CategorySystemPrompt, CategoryTools, CategoryMessages, CategoryToolResults, CategoryAttachments}, names)
+	assert.Equal(t, sum, b.EstimatedTokens)
+
+	system := category(t, b, CategorySystemPrompt)
+	assert.Equal(t, "claude_md", system.Items[0].Name, "largest section first")
+	assert.Equal(t, 1000, system.Items[0].Tokens)
+
+	toolCat := category(t, b, CategoryTools)
+	assert.Equal(t, []core.ContextItem{{Name: "mcp:github", Tokens: charsToTokens(2*1200 + len("mcp__github__create_issue") + len("mcp__github__list_prs"))}, {Name: BuiltinToolsItem, Tokens: charsToTokens(124)}}, toolCat.Items)
+
+	results := category(t, b, CategoryToolResults)
+	require.Len(t, results.Items, 1)
+	assert.Equal(t, "Bash", results.Items[0].Name)
+	assert.Equal(t, 2000, results.Items[0].Tokens)
+
+	attachments := category(t, b, CategoryAttachments)
+	assert.Equal(t, []core.ContextItem{{Name: "image/png", Tokens: 200}}, attachments.Items)
+}
+
+func TestBreakdown_Empty(t *testing.T) {
+	b := Breakdown(nil, nil, nil)
+	assert.Equal(t, 0, b.EstimatedTokens)
+	assert.Len(t, b.Categories, 5)
+}
+
+func TestToolSource(t *testing.T) {
+	assert.Equal(t, BuiltinToolsItem, toolSource("Bash"))
+	assert.Equal(t, "mcp:linear", toolSource("mcp__linear__search"))
+}
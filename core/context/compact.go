@@ -45,6 +45,18 @@ func Compact(
 	prov provider.Provider,
 	messages []provider.Message,
 	keepRecentCount int,
+) (*CompactResult, error) {
+	return CompactWithInstructions(ctx, prov, messages, keepRecentCount, "")
+}
+
+// CompactWithInstructions is Compact with user focus instructions (e.g. "keep
+// the DB migration details") appended to the summarization request.
+func CompactWithInstructions(
+	ctx context.Context,
+	prov provider.Provider,
+	messages []provider.Message,
+	keepRecentCount int,
+	instructions string,
 ) (*CompactResult, error) {
 	if keepRecentCount <= 0 {
 		keepRecentCount = 4
@@ -65,6 +77,11 @@ func Compact(
 	const maxPTLRetries = 3
 	var summary string
 
+	focus := ""
+	if instructions = strings.TrimSpace(instructions); instructions != "" {
+		focus = fmt.Sprintf("\n\nAdditional instructions from the user for this summary:\n%s", instructions)
+	}
+
 	for attempt := 0; attempt <= maxPTLRetries; attempt++ {
 		transcript := buildTranscript(toSummarize)
 
@@ -76,8 +93,8 @@ func Compact(
 					Role: provider.RoleUser,
 					Content: []provider.ContentBlock{
 						provider.NewTextBlock(fmt.Sprintf(
-							"Please summarize the following coding conversation. Preserve all technical details needed to continue the work.\n\n%s",
-							transcript,
+							"Please summarize the following coding conversation. Preserve all technical details needed to continue the work.%s\n\n%s",
+							focus, transcript,
 						)),
 					},
 				},
@@ -51,6 +51,14 @@ func (dt *DeltaTracker) HasChanged(path, currentContent string) bool {
 	return prev.Hash != hashContent(currentContent)
 }
 
+// IsTracked returns true if a state has been recorded for the file.
+func (dt *DeltaTracker) IsTracked(path string) bool {
+	dt.mu.RLock()
+	defer dt.mu.RUnlock()
+	_, ok := dt.states[path]
+	return ok
+}
+
 // FileUnchangedStub returns a stub message for files that haven't changed.
 // This allows compaction to skip unchanged file re-reads.
 const FileUnchangedStub = "[File unchanged since last read]"
@@ -22,6 +22,9 @@ type PostCompactRestorationConfig struct {
 	ToolNames []string
 	// MCPInstructions is additional context from MCP servers to re-inject.
 	MCPInstructions string
+	// SessionMemory is the session's rendered working memory (goal, decisions,
+	// open questions, file map, tasks).
+	SessionMemory string
 }
 
 // RestorePostCompact generates additional context messages to inject after compaction.
@@ -29,6 +32,7 @@ type PostCompactRestorationConfig struct {
 // - Re-inject recent file reads (up to 5 files, 5K tokens each)
 // - Re-inject MCP server instructions
 // - Re-inject available tool names as a reminder
+// - Re-inject the session's working memory
 //
 // Returns additional messages to append to the compacted conversation, or nil if nothing to restore.
 func RestorePostCompact(cfg PostCompactRestorationConfig) []provider.Message {
@@ -70,6 +74,11 @@ func RestorePostCompact(cfg PostCompactRestorationConfig) []provider.Message {
 		sections = append(sections, fmt.Sprintf("<system-reminder>\nAvailable tools: %s\n</system-reminder>", strings.Join(cfg.ToolNames, ", ")))
 	}
 
+	// 4. Re-inject the session working memory
+	if cfg.SessionMemory != "" {
+		sections = append(sections, fmt.Sprintf("<system-reminder>\nSession working memory (kept across compactions):\n\n%s\n</system-reminder>", cfg.SessionMemory))
+	}
+
 	if len(sections) == 0 {
 		return nil
 	}
@@ -86,7 +95,7 @@ func RestorePostCompact(cfg PostCompactRestorationConfig) []provider.Message {
 		{
 			Role: provider.RoleAssistant,
 			Content: []provider.ContentBlock{
-				provider.NewTextBlock("I've noted the restored context from the recent files and available tools. Continuing from where we left off."),
+				provider.NewTextBlock("I've noted the restored context. Continuing from where we left off."),
 			},
 		},
 	}
@@ -0,0 +1,459 @@
+// Package egress runs a local forward proxy that filters outbound connections
+// by destination host. Child processes (Bash commands, stdio MCP servers) are
+// pointed at it through the standard proxy environment variables, so tools
+// that honour HTTP_PROXY, HTTPS_PROXY or ALL_PROXY are held to the session's
+// egress rules. Programs that ignore those variables are not covered.
+package egress
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CheckFunc decides whether a connection to host may be made. A nil error
+// allows it. The call may block, e.g. while the user is asked for approval.
+type CheckFunc func(ctx context.Context, host string) error
+
+// dialTimeout bounds connecting to the upstream host.
+const dialTimeout = 30 * time.Second
+
+// Proxy is an HTTP (CONNECT and absolute-URI forwarding) and SOCKS5 proxy
+// listening on loopback. Decisions are cached per host for the proxy's
+// lifetime, so a command that opens many connections to one host asks once.
+type Proxy struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	check  CheckFunc
+
+	httpLn  net.Listener
+	socksLn net.Listener
+	httpSrv *http.Server
+	dialer  net.Dialer
+
+	transport *http.Transport
+
+	mu        sync.Mutex
+	decisions map[string]*decision
+	tunnels   map[net.Conn]struct{}
+	closed    bool
+	wg        sync.WaitGroup
+}
+
+type decision struct {
+	done chan struct{}
+	err  error
+}
+
+// Start listens on two loopback ports and serves until Close is called or ctx
+// is cancelled. ctx is also passed to check.
+func Start(ctx context.Context, check CheckFunc) (*Proxy, error) {
+	httpLn, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("egress: listen: %w", err)
+	}
+	socksLn, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		httpLn.Close()
+		return nil, fmt.Errorf("egress: listen: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	p := &Proxy{
+		ctx:       ctx,
+		cancel:    cancel,
+		check:     check,
+		httpLn:    httpLn,
+		socksLn:   socksLn,
+		dialer:    net.Dialer{Timeout: dialTimeout},
+		decisions: make(map[string]*decision),
+		tunnels:   make(map[net.Conn]struct{}),
+	}
+	p.transport = &http.Transport{
+		Proxy:               nil, // Never chain to an outer proxy from the environment
+		DialContext:         p.dialer.DialContext,
+		TLSHandshakeTimeout: 10 * time.Second,
+		IdleConnTimeout:     30 * time.Second,
+	}
+	p.httpSrv = &http.Server{
+		Handler:           http.HandlerFunc(p.serveHTTP),
+		ReadHeaderTimeout: 30 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+
+	p.wg.Add(2)
+	go func() {
+		defer p.wg.Done()
+		if err := p.httpSrv.Serve(httpLn); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("egress: http proxy stopped: %v", err)
+		}
+	}()
+	go func() {
+		defer p.wg.Done()
+		p.serveSOCKS()
+	}()
+	go func() {
+		<-ctx.Done()
+		p.Close()
+	}()
+	return p, nil
+}
+
+// HTTPAddr returns the host:port of the HTTP proxy listener.
+func (p *Proxy) HTTPAddr() string { return p.httpLn.Addr().String() }
+
+// SOCKSAddr returns the host:port of the SOCKS5 proxy listener.
+func (p *Proxy) SOCKSAddr() string { return p.socksLn.Addr().String() }
+
+// Env returns environment variables routing a child process through the
+// proxy. Append them after os.Environ() so they override inherited values.
+func (p *Proxy) Env() []string {
+	httpURL := "http://" + p.HTTPAddr()
+	socksURL := "socks5h://" + p.SOCKSAddr()
+	noProxy := "localhost,127.0.0.1,::1"
+	return []string{
+		"HTTP_PROXY=" + httpURL,
+		"HTTPS_PROXY=" + httpURL,
+		"http_proxy=" + httpURL,
+		"https_proxy=" + httpURL,
+		"ALL_PROXY=" + socksURL,
+		"all_proxy=" + socksURL,
+		"NO_PROXY=" + noProxy,
+		"no_proxy=" + noProxy,
+	}
+}
+
+// Close stops both listeners and tears down open tunnels. Safe to call more
+// than once.
+func (p *Proxy) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	for c := range p.tunnels {
+		c.Close()
+	}
+	p.mu.Unlock()
+
+	p.cancel()
+	p.httpSrv.Close()
+	p.socksLn.Close()
+	p.transport.CloseIdleConnections()
+	p.wg.Wait()
+	return nil
+}
+
+// allow runs the check for host once and shares the result with concurrent
+// and later callers.
+func (p *Proxy) allow(host string) error {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	p.mu.Lock()
+	d, ok := p.decisions[host]
+	if !ok {
+		d = &decision{done: make(chan struct{})}
+		p.decisions[host] = d
+	}
+	p.mu.Unlock()
+
+	if !ok {
+		d.err = p.check(p.ctx, host)
+		close(d.done)
+		return d.err
+	}
+	select {
+	case <-d.done:
+		return d.err
+	case <-p.ctx.Done():
+		return p.ctx.Err()
+	}
+}
+
+// trackTunnel registers the two ends of a tunnel so Close can end it.
+// Returns false when the proxy is already closed.
+func (p *Proxy) trackTunnel(conns ...net.Conn) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return false
+	}
+	for _, c := range conns {
+		p.tunnels[c] = struct{}{}
+	}
+	return true
+}
+
+func (p *Proxy) untrackTunnel(conns ...net.Conn) {
+	p.mu.Lock()
+	for _, c := range conns {
+		delete(p.tunnels, c)
+	}
+	p.mu.Unlock()
+}
+
+// serveHTTP handles CONNECT tunnels and plain-HTTP forwarding.
+func (p *Proxy) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodConnect {
+		p.serveConnect(w, r)
+		return
+	}
+	if r.URL.Host == "" || r.URL.Scheme != "http" {
+		http.Error(w, "egress proxy: absolute http:// URL required", http.StatusBadRequest)
+		return
+	}
+	if err := p.allow(r.URL.Hostname()); err != nil {
+		http.Error(w, blockedMessage(r.URL.Hostname(), err), http.StatusForbidden)
+		return
+	}
+
+	out := r.Clone(r.Context())
+	out.RequestURI = ""
+	removeHopHeaders(out.Header)
+	resp, err := p.transport.RoundTrip(out)
+	if err != nil {
+		http.Error(w, "egress proxy: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	removeHopHeaders(resp.Header)
+	for k, vs := range resp.Header {
+		for _, v := range vs {
+			w.Header().Add(k, v)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(w, resp.Body)
+}
+
+func (p *Proxy) serveConnect(w http.ResponseWriter, r *http.Request) {
+	host, _, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		http.Error(w, "egress proxy: CONNECT target must be host:port", http.StatusBadRequest)
+		return
+	}
+	if err := p.allow(host); err != nil {
+		http.Error(w, blockedMessage(host, err), http.StatusForbidden)
+		return
+	}
+	upstream, err := p.dialer.DialContext(r.Context(), "tcp", r.Host)
+	if err != nil {
+		http.Error(w, "egress proxy: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		upstream.Close()
+		http.Error(w, "egress proxy: hijacking not supported", http.StatusInternalServerError)
+		return
+	}
+	client, buf, err := hj.Hijack()
+	if err != nil {
+		upstream.Close()
+		return
+	}
+	if _, err := client.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n")); err != nil {
+		client.Close()
+		upstream.Close()
+		return
+	}
+	p.pipe(client, buf.Reader, upstream)
+}
+
+// pipe copies between a client connection and the upstream until either side
+// closes. clientReader carries any bytes already buffered from the client.
+func (p *Proxy) pipe(client net.Conn, clientReader io.Reader, upstream net.Conn) {
+	if !p.trackTunnel(client, upstream) {
+		client.Close()
+		upstream.Close()
+		return
+	}
+	defer p.untrackTunnel(client, upstream)
+
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(upstream, clientReader)
+		closeWrite(upstream)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(client, upstream)
+		closeWrite(client)
+		done <- struct{}{}
+	}()
+	<-done
+	<-done
+	client.Close()
+	upstream.Close()
+}
+
+func closeWrite(c net.Conn) {
+	if cw, ok := c.(interface{ CloseWrite() error }); ok {
+		cw.CloseWrite()
+	}
+}
+
+// SOCKS5 protocol constants (RFC 1928).
+const (
+	socksVersion          = 5
+	socksNoAuth           = 0x00
+	socksNoAcceptable     = 0xff
+	socksCmdConnect       = 0x01
+	socksAddrIPv4         = 0x01
+	socksAddrDomain       = 0x03
+	socksAddrIPv6         = 0x04
+	socksSucceeded        = 0x00
+	socksNotAllowed       = 0x02
+	socksHostUnreach      = 0x04
+	socksCmdNotSupported  = 0x07
+	socksAddrNotSupported = 0x08
+)
+
+func (p *Proxy) serveSOCKS() {
+	for {
+		conn, err := p.socksLn.Accept()
+		if err != nil {
+			return
+		}
+		go p.handleSOCKS(conn)
+	}
+}
+
+func (p *Proxy) handleSOCKS(conn net.Conn) {
+	conn.SetDeadline(time.Now().Add(30 * time.Second))
+	br := bufio.NewReader(conn)
+
+	// Greeting: VER NMETHODS METHODS...
+	var hdr [2]byte
+	if _, err := io.ReadFull(br, hdr[:]); err != nil || hdr[0] != socksVersion {
+		conn.Close()
+		return
+	}
+	methods := make([]byte, hdr[1])
+	if _, err := io.ReadFull(br, methods); err != nil {
+		conn.Close()
+		return
+	}
+	if !containsByte(methods, socksNoAuth) {
+		conn.Write([]byte{socksVersion, socksNoAcceptable})
+		conn.Close()
+		return
+	}
+	if _, err := conn.Write([]byte{socksVersion, socksNoAuth}); err != nil {
+		conn.Close()
+		return
+	}
+
+	// Request: VER CMD RSV ATYP DST.ADDR DST.PORT
+	var req [4]byte
+	if _, err := io.ReadFull(br, req[:]); err != nil || req[0] != socksVersion {
+		conn.Close()
+		return
+	}
+	host, err := readSOCKSAddr(br, req[3])
+	if err != nil {
+		socksReply(conn, socksAddrNotSupported)
+		conn.Close()
+		return
+	}
+	var portBuf [2]byte
+	if _, err := io.ReadFull(br, portBuf[:]); err != nil {
+		conn.Close()
+		return
+	}
+	if req[1] != socksCmdConnect {
+		socksReply(conn, socksCmdNotSupported)
+		conn.Close()
+		return
+	}
+	conn.SetDeadline(time.Time{})
+
+	if err := p.allow(host); err != nil {
+		log.Printf("egress: %s", blockedMessage(host, err))
+		socksReply(conn, socksNotAllowed)
+		conn.Close()
+		return
+	}
+	target := net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(portBuf[:]))))
+	upstream, err := p.dialer.DialContext(p.ctx, "tcp", target)
+	if err != nil {
+		socksReply(conn, socksHostUnreach)
+		conn.Close()
+		return
+	}
+	if err := socksReply(conn, socksSucceeded); err != nil {
+		conn.Close()
+		upstream.Close()
+		return
+	}
+	p.pipe(conn, br, upstream)
+}
+
+func readSOCKSAddr(r io.Reader, atyp byte) (string, error) {
+	switch atyp {
+	case socksAddrIPv4:
+		var b [4]byte
+		if _, err := io.ReadFull(r, b[:]); err != nil {
+			return "", err
+		}
+		return net.IP(b[:]).String(), nil
+	case socksAddrIPv6:
+		var b [16]byte
+		if _, err := io.ReadFull(r, b[:]); err != nil {
+			return "", err
+		}
+		return net.IP(b[:]).String(), nil
+	case socksAddrDomain:
+		var n [1]byte
+		if _, err := io.ReadFull(r, n[:]); err != nil {
+			return "", err
+		}
+		b := make([]byte, n[0])
+		if _, err := io.ReadFull(r, b); err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+	return "", fmt.Errorf("unsupported address type %d", atyp)
+}
+
+// socksReply writes a reply with an all-zero IPv4 bound address.
+func socksReply(w io.Writer, code byte) error {
+	_, err := w.Write([]byte{socksVersion, code, 0, socksAddrIPv4, 0, 0, 0, 0, 0, 0})
+	return err
+}
+
+func containsByte(b []byte, v byte) bool {
+	for _, c := range b {
+		if c == v {
+			return true
+		}
+	}
+	return false
+}
+
+// hopHeaders are connection-scoped and must not be forwarded.
+var hopHeaders = []string{
+	"Connection", "Proxy-Connection", "Keep-Alive", "Proxy-Authenticate",
+	"Proxy-Authorization", "Te", "Trailer", "Transfer-Encoding", "Upgrade",
+}
+
+func removeHopHeaders(h http.Header) {
+	for _, k := range hopHeaders {
+		h.Del(k)
+	}
+}
+
+func blockedMessage(host string, err error) string {
+	return fmt.Sprintf("connection to %s blocked by network egress policy: %v", host, err)
+}
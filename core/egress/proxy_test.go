@@ -0,0 +1,154 @@
+package egress
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startProxy starts a proxy that allows only the given host and counts checks.
+func startProxy(t *testing.T, allowed string) (*Proxy, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	p, err := Start(context.Background(), func(_ context.Context, host string) error {
+		calls.Add(1)
+		if host == allowed {
+			return nil
+		}
+		return errors.New("not allowed")
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { p.Close() })
+	return p, &calls
+}
+
+func hello(w http.ResponseWriter, _ *http.Request) { fmt.Fprint(w, "hello") }
+
+func TestProxy_Env(t *testing.T) {
+	p, _ := startProxy(t, "")
+	env := strings.Join(p.Env(), "\n")
+	assert.Contains(t, env, "HTTPS_PROXY=http://"+p.HTTPAddr())
+	assert.Contains(t, env, "all_proxy=socks5h://"+p.SOCKSAddr())
+	assert.Contains(t, env, "NO_PROXY=localhost")
+}
+
+func TestProxy_HTTPForwarding(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(hello))
+	defer upstream.Close()
+	_, port, _ := net.SplitHostPort(strings.TrimPrefix(upstream.URL, "http://"))
+
+	p, calls := startProxy(t, "127.0.0.1")
+	proxyURL, _ := url.Parse("http://" + p.HTTPAddr())
+	client := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get("http://127.0.0.1:" + port + "/")
+		require.NoError(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "hello", string(body))
+	}
+	assert.Equal(t, int32(1), calls.Load(), "decision is cached per host")
+
+	resp, err := client.Get("http://localhost:" + port + "/")
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Contains(t, string(body), "localhost blocked by network egress policy")
+}
+
+func TestProxy_ConnectTunnel(t *testing.T) {
+	upstream := httptest.NewTLSServer(http.HandlerFunc(hello))
+	defer upstream.Close()
+	_, port, _ := net.SplitHostPort(strings.TrimPrefix(upstream.URL, "https://"))
+
+	p, _ := startProxy(t, "127.0.0.1")
+	proxyURL, _ := url.Parse("http://" + p.HTTPAddr())
+	client := upstream.Client()
+	client.Transport.(*http.Transport).Proxy = http.ProxyURL(proxyURL)
+
+	resp, err := client.Get(upstream.URL)
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, "hello", string(body))
+
+	_, err = client.Get("https://localhost:" + port + "/")
+	assert.Error(t, err, "CONNECT to a denied host must fail")
+}
+
+// socksConnect performs a SOCKS5 CONNECT by domain name and returns the reply
+// code and the connection.
+func socksConnect(t *testing.T, proxyAddr, host string, port int) (byte, net.Conn) {
+	t.Helper()
+	conn, err := net.Dial("tcp", proxyAddr)
+	require.NoError(t, err)
+	_, err = conn.Write([]byte{socksVersion, 1, socksNoAuth})
+	require.NoError(t, err)
+	var greet [2]byte
+	_, err = io.ReadFull(conn, greet[:])
+	require.NoError(t, err)
+	require.Equal(t, byte(socksNoAuth), greet[1])
+
+	req := []byte{socksVersion, socksCmdConnect, 0, socksAddrDomain, byte(len(host))}
+	req = append(req, host...)
+	req = binary.BigEndian.AppendUint16(req, uint16(port))
+	_, err = conn.Write(req)
+	require.NoError(t, err)
+	var reply [10]byte
+	_, err = io.ReadFull(conn, reply[:])
+	require.NoError(t, err)
+	return reply[1], conn
+}
+
+func TestProxy_SOCKS5(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(hello))
+	defer upstream.Close()
+	addr := upstream.Listener.Addr().(*net.TCPAddr)
+
+	p, _ := startProxy(t, "127.0.0.1")
+
+	code, conn := socksConnect(t, p.SOCKSAddr(), "127.0.0.1", addr.Port)
+	require.Equal(t, byte(socksSucceeded), code)
+	fmt.Fprintf(conn, "GET / HTTP/1.1\r\nHost: 127.0.0.1\r\nConnection: close\r\n\r\n")
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	conn.Close()
+	assert.Equal(t, "hello", string(body))
+
+	code, conn = socksConnect(t, p.SOCKSAddr(), "localhost", addr.Port)
+	conn.Close()
+	assert.Equal(t, byte(socksNotAllowed), code)
+}
+
+func TestProxy_CloseUnblocksPendingCheck(t *testing.T) {
+	started := make(chan struct{})
+	p, err := Start(context.Background(), func(ctx context.Context, _ string) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	require.NoError(t, err)
+
+	done := make(chan error, 1)
+	go func() { done <- p.allow("example.com") }()
+	<-started
+	require.NoError(t, p.Close())
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
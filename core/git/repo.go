@@ -1377,14 +1377,8 @@ func (rm *RepoManager) GetBranchSyncStatus(ctx context.Context, worktreePath, ba
 		Commits:     []SyncCommit{},
 	}
 
-	// Extract branch name from remote ref (e.g. "origin/develop" -> "develop")
-	branchName := strings.TrimPrefix(targetBranch, "origin/")
-
 	// Fetch the target branch to get latest commits
-	cmd, cancel := gitCmdWithContext(ctx, TimeoutHeavy, worktreePath, "fetch", "origin", branchName)
-	_, err := cmd.CombinedOutput()
-	cancel()
-	if err != nil {
+	if err := rm.fetchTargetBranch(ctx, worktreePath, targetBranch); err != nil {
 		return status, nil // Return empty status if fetch fails
 	}
 
@@ -1397,7 +1391,7 @@ func (rm *RepoManager) GetBranchSyncStatus(ctx context.Context, worktreePath, ba
 	}
 
 	// Get count of commits between baseCommitSHA and origin/main
-	cmd, cancel = gitCmdWithContext(ctx, TimeoutMedium, worktreePath, "rev-list", "--count", baseCommitSHA+".."+status.BaseBranch)
+	cmd, cancel := gitCmdWithContext(ctx, TimeoutMedium, worktreePath, "rev-list", "--count", baseCommitSHA+".."+status.BaseBranch)
 	out, err := cmd.Output()
 	cancel()
 	if err != nil {
@@ -1432,22 +1426,47 @@ func (rm *RepoManager) GetBranchSyncStatus(ctx context.Context, worktreePath, ba
 }
 
 // RebaseOntoTarget rebases the current branch onto the specified target branch (e.g. "origin/develop").
+// A target without the "origin/" prefix is treated as a local branch and is not fetched.
 func (rm *RepoManager) RebaseOntoTarget(ctx context.Context, worktreePath, targetBranch string) (*BranchSyncResult, error) {
-	result := &BranchSyncResult{
-		ConflictFiles: []string{},
+	// Fetch the target branch to ensure we have the latest
+	if err := rm.fetchTargetBranch(ctx, worktreePath, targetBranch); err != nil {
+		return &BranchSyncResult{
+			ConflictFiles: []string{},
+			ErrorMessage:  fmt.Sprintf("failed to fetch %s from origin", strings.TrimPrefix(targetBranch, "origin/")),
+		}, nil
 	}
 
-	// Extract branch name from remote ref (e.g. "origin/develop" -> "develop")
-	branchName := strings.TrimPrefix(targetBranch, "origin/")
-	baseBranch := targetBranch
+	return rm.rebaseWithStash(ctx, worktreePath, targetBranch, targetBranch)
+}
 
-	// Fetch the target branch to ensure we have the latest
-	cmd, cancel := gitCmdWithContext(ctx, TimeoutHeavy, worktreePath, "fetch", "origin", branchName)
-	_, err := cmd.CombinedOutput()
-	cancel()
-	if err != nil {
-		result.ErrorMessage = fmt.Sprintf("failed to fetch %s from origin", branchName)
-		return result, nil
+// RestackOnto moves the commits in upstream..HEAD onto newBase
+// (git rebase --onto newBase upstream). Stacked sessions use it when the
+// parent branch was rewritten or merged: upstream is the parent commit the
+// session was last based on, so the parent's old commits are dropped rather
+// than replayed.
+func (rm *RepoManager) RestackOnto(ctx context.Context, worktreePath, newBase, upstream string) (*BranchSyncResult, error) {
+	if err := ValidateGitRef(upstream); err != nil {
+		return nil, fmt.Errorf("invalid upstream: %w", err)
+	}
+	if err := ValidateGitRef(newBase); err != nil {
+		return nil, fmt.Errorf("invalid new base: %w", err)
+	}
+
+	if err := rm.fetchTargetBranch(ctx, worktreePath, newBase); err != nil {
+		return &BranchSyncResult{
+			ConflictFiles: []string{},
+			ErrorMessage:  fmt.Sprintf("failed to fetch %s from origin", strings.TrimPrefix(newBase, "origin/")),
+		}, nil
+	}
+
+	return rm.rebaseWithStash(ctx, worktreePath, newBase, "--onto", newBase, upstream)
+}
+
+// rebaseWithStash runs `git rebase <rebaseArgs>`, stashing uncommitted changes
+// around it. On success NewBaseSha is the resolved newBase.
+func (rm *RepoManager) rebaseWithStash(ctx context.Context, worktreePath, newBase string, rebaseArgs ...string) (*BranchSyncResult, error) {
+	result := &BranchSyncResult{
+		ConflictFiles: []string{},
 	}
 
 	// Check for uncommitted changes first
@@ -1469,7 +1488,7 @@ func (rm *RepoManager) RebaseOntoTarget(ctx context.Context, worktreePath, targe
 	}
 
 	// Run rebase
-	cmd, cancel = gitCmdWithContext(ctx, TimeoutHeavy, worktreePath, "rebase", baseBranch)
+	cmd, cancel := gitCmdWithContext(ctx, TimeoutHeavy, worktreePath, append([]string{"rebase"}, rebaseArgs...)...)
 	out, err := cmd.CombinedOutput()
 	cancel()
 
@@ -1493,7 +1512,7 @@ func (rm *RepoManager) RebaseOntoTarget(ctx context.Context, worktreePath, targe
 	}
 
 	// Get new base SHA
-	cmd, cancel = gitCmdWithContext(ctx, TimeoutFast, worktreePath, "rev-parse", baseBranch)
+	cmd, cancel = gitCmdWithContext(ctx, TimeoutFast, worktreePath, "rev-parse", newBase)
 	out, err = cmd.Output()
 	cancel()
 	if err == nil {
@@ -1517,21 +1536,17 @@ func (rm *RepoManager) RebaseOntoTarget(ctx context.Context, worktreePath, targe
 }
 
 // MergeFromTarget merges the specified target branch into the current branch (e.g. "origin/develop").
+// A target without the "origin/" prefix is treated as a local branch and is not fetched.
 func (rm *RepoManager) MergeFromTarget(ctx context.Context, worktreePath, targetBranch string) (*BranchSyncResult, error) {
 	result := &BranchSyncResult{
 		ConflictFiles: []string{},
 	}
 
-	// Extract branch name from remote ref (e.g. "origin/develop" -> "develop")
-	branchName := strings.TrimPrefix(targetBranch, "origin/")
 	baseBranch := targetBranch
 
 	// Fetch the target branch to ensure we have the latest
-	cmd, cancel := gitCmdWithContext(ctx, TimeoutHeavy, worktreePath, "fetch", "origin", branchName)
-	_, err := cmd.CombinedOutput()
-	cancel()
-	if err != nil {
-		result.ErrorMessage = fmt.Sprintf("failed to fetch %s from origin", branchName)
+	if err := rm.fetchTargetBranch(ctx, worktreePath, targetBranch); err != nil {
+		result.ErrorMessage = fmt.Sprintf("failed to fetch %s from origin", strings.TrimPrefix(targetBranch, "origin/"))
 		return result, nil
 	}
 
@@ -1554,7 +1569,7 @@ func (rm *RepoManager) MergeFromTarget(ctx context.Context, worktreePath, target
 	}
 
 	// Run merge
-	cmd, cancel = gitCmdWithContext(ctx, TimeoutHeavy, worktreePath, "merge", baseBranch, "-m", "Merge "+baseBranch+" into branch")
+	cmd, cancel := gitCmdWithContext(ctx, TimeoutHeavy, worktreePath, "merge", baseBranch, "-m", "Merge "+baseBranch+" into branch")
 	out, err := cmd.CombinedOutput()
 	cancel()
 
@@ -1623,6 +1638,45 @@ func (rm *RepoManager) AbortMerge(ctx context.Context, worktreePath string) erro
 	return nil
 }
 
+// fetchTargetBranch fetches a remote-tracking target such as "origin/develop"
+// or "upstream/main". Local targets (a stacked session's parent branch) are
+// already up to date.
+func (rm *RepoManager) fetchTargetBranch(ctx context.Context, worktreePath, targetBranch string) error {
+	remote, branchName, err := rm.splitRemoteTarget(ctx, worktreePath, targetBranch)
+	if err != nil || remote == "" {
+		return err
+	}
+	cmd, cancel := gitCmdWithContext(ctx, TimeoutHeavy, worktreePath, "fetch", remote, branchName)
+	defer cancel()
+	_, err = cmd.CombinedOutput()
+	return err
+}
+
+// splitRemoteTarget splits a remote-tracking target into its remote and branch
+// name, matching the longest configured remote since remote names may contain
+// slashes. It returns an empty remote for local branches, which take
+// precedence over remote-tracking refs of the same name as in git's own ref
+// resolution.
+func (rm *RepoManager) splitRemoteTarget(ctx context.Context, worktreePath, target string) (remote, branch string, err error) {
+	cmd, cancel := gitCmdWithContext(ctx, TimeoutFast, worktreePath, "show-ref", "--verify", "--quiet", "refs/heads/"+target)
+	isLocal := cmd.Run() == nil
+	cancel()
+	if isLocal {
+		return "", "", nil
+	}
+
+	remotes, err := rm.ListRemotes(ctx, worktreePath)
+	if err != nil {
+		return "", "", err
+	}
+	for _, r := range remotes {
+		if b, ok := strings.CutPrefix(target, r+"/"); ok && b != "" && len(r) > len(remote) {
+			remote, branch = r, b
+		}
+	}
+	return remote, branch, nil
+}
+
 // PushBranch pushes the current branch to origin, setting upstream tracking.
 func (rm *RepoManager) PushBranch(ctx context.Context, worktreePath, branch string) error {
 	if err := ValidateGitRef(branch); err != nil {
@@ -1741,3 +1741,137 @@ func TestSortBranches_NameAscDesc(t *testing.T) {
 	assert.Equal(t, "bravo", branches[1].Name)
 	assert.Equal(t, "alpha", branches[2].Name)
 }
+
+// ============================================================================
+// Stacked branch sync Tests
+// ============================================================================
+
+func TestGetBranchSyncStatus_LocalParentBranch(t *testing.T) {
+	repoPath := createTestGitRepo(t)
+	rm := NewRepoManager()
+
+	runGit(t, repoPath, "checkout", "-b", "parent")
+	createAndCommitFile(t, repoPath, "parent.txt", "parent\n", "Parent commit")
+	parentTip := getCommitSHA(t, repoPath)
+
+	runGit(t, repoPath, "checkout", "-b", "child")
+	createAndCommitFile(t, repoPath, "child.txt", "child\n", "Child commit")
+
+	// Parent advances locally; it has never been pushed
+	runGit(t, repoPath, "checkout", "parent")
+	createAndCommitFile(t, repoPath, "parent2.txt", "parent2\n", "Parent follow-up")
+	runGit(t, repoPath, "checkout", "child")
+
+	status, err := rm.GetBranchSyncStatus(context.Background(), repoPath, parentTip, "parent")
+	require.NoError(t, err)
+	assert.Equal(t, 1, status.BehindBy)
+	require.Len(t, status.Commits, 1)
+	assert.Equal(t, "Parent follow-up", status.Commits[0].Subject)
+}
+
+func TestGetBranchSyncStatus_FetchesNonOriginRemote(t *testing.T) {
+	repoPath := createTestGitRepo(t)
+	rm := NewRepoManager()
+
+	upstreamDir := t.TempDir()
+	runGit(t, upstreamDir, "init", "--bare")
+	runGit(t, repoPath, "remote", "add", "upstream", upstreamDir)
+	runGit(t, repoPath, "push", "upstream", "main")
+	runGit(t, repoPath, "fetch", "upstream")
+	base := getCommitSHA(t, repoPath)
+
+	// Someone else advances upstream/main through another clone
+	other := t.TempDir()
+	runGit(t, other, "clone", "-b", "main", upstreamDir, ".")
+	runGit(t, other, "config", "user.email", "test@test.com")
+	runGit(t, other, "config", "user.name", "Test User")
+	createAndCommitFile(t, other, "upstream.txt", "upstream\n", "Upstream change")
+	runGit(t, other, "push", "origin", "HEAD:main")
+
+	status, err := rm.GetBranchSyncStatus(context.Background(), repoPath, base, "upstream/main")
+	require.NoError(t, err)
+	assert.Equal(t, 1, status.BehindBy, "upstream/main is fetched before comparing")
+}
+
+func TestSplitRemoteTarget(t *testing.T) {
+	repoPath := createTestGitRepo(t)
+	rm := NewRepoManager()
+	ctx := context.Background()
+	runGit(t, repoPath, "remote", "add", "team/fork", t.TempDir())
+	runGit(t, repoPath, "branch", "parent")
+
+	for _, tc := range []struct{ target, remote, branch string }{
+		{"origin/develop", "origin", "develop"},
+		{"team/fork/feature/x", "team/fork", "feature/x"},
+		{"parent", "", ""},
+		{"nowhere/main", "", ""},
+	} {
+		remote, branch, err := rm.splitRemoteTarget(ctx, repoPath, tc.target)
+		require.NoError(t, err)
+		assert.Equal(t, tc.remote, remote, tc.target)
+		assert.Equal(t, tc.branch, branch, tc.target)
+	}
+}
+
+func TestRestackOnto_DropsRewrittenParentCommits(t *testing.T) {
+	repoPath := createTestGitRepo(t)
+	rm := NewRepoManager()
+
+	runGit(t, repoPath, "checkout", "-b", "parent")
+	createAndCommitFile(t, repoPath, "shared.txt", "v1\n", "Parent commit")
+	oldParentTip := getCommitSHA(t, repoPath)
+
+	runGit(t, repoPath, "checkout", "-b", "child")
+	createAndCommitFile(t, repoPath, "child.txt", "child\n", "Child commit")
+
+	// Rewrite the parent (amend), as a parent rebase or review fixup would
+	runGit(t, repoPath, "checkout", "parent")
+	modifyAndCommitFile(t, repoPath, "shared.txt", "v2\n", "Parent follow-up")
+	runGit(t, repoPath, "reset", "--soft", "HEAD~2")
+	runGit(t, repoPath, "commit", "-m", "Parent commit (rewritten)")
+	newParentTip := getCommitSHA(t, repoPath)
+	runGit(t, repoPath, "checkout", "child")
+
+	result, err := rm.RestackOnto(context.Background(), repoPath, "parent", oldParentTip)
+	require.NoError(t, err)
+	require.True(t, result.Success, result.ErrorMessage)
+	assert.Equal(t, newParentTip, result.NewBaseSha)
+
+	// Only the child's own commit sits on top of the rewritten parent
+	out := runGit(t, repoPath, "log", "--pretty=format:%s", "parent..HEAD")
+	assert.Equal(t, "Child commit", out)
+}
+
+func TestRestackOnto_AfterParentSquashMerged(t *testing.T) {
+	repoPath := createTestGitRepo(t)
+	rm := NewRepoManager()
+
+	runGit(t, repoPath, "checkout", "-b", "parent")
+	createAndCommitFile(t, repoPath, "parent.txt", "parent\n", "Parent commit")
+	parentTip := getCommitSHA(t, repoPath)
+
+	runGit(t, repoPath, "checkout", "-b", "child")
+	createAndCommitFile(t, repoPath, "child.txt", "child\n", "Child commit")
+
+	// Squash-merge the parent into main and push, as GitHub would
+	runGit(t, repoPath, "checkout", "main")
+	runGit(t, repoPath, "merge", "--squash", "parent")
+	runGit(t, repoPath, "commit", "-m", "Parent (#1)")
+	runGit(t, repoPath, "push", "origin", "main")
+	runGit(t, repoPath, "checkout", "child")
+
+	result, err := rm.RestackOnto(context.Background(), repoPath, "origin/main", parentTip)
+	require.NoError(t, err)
+	require.True(t, result.Success, result.ErrorMessage)
+
+	out := runGit(t, repoPath, "log", "--pretty=format:%s", "origin/main..HEAD")
+	assert.Equal(t, "Child commit", out)
+}
+
+func TestRestackOnto_InvalidUpstream(t *testing.T) {
+	repoPath := createTestGitRepo(t)
+	rm := NewRepoManager()
+
+	_, err := rm.RestackOnto(context.Background(), repoPath, "origin/main", "--upload-pack=evil")
+	assert.Error(t, err)
+}
@@ -55,20 +55,27 @@ var AllEvents = []string{
 const (
 	HookTypeCommand = "command" // Shell command
 	HookTypeHTTP    = "http"   // HTTP POST webhook
+	HookTypePrompt  = "prompt" // Natural-language rule evaluated by a model
+	HookTypeAgent   = "agent"  // Read-only sub-agent that verifies a condition
 )
 
 // HookDef defines a single hook within a matcher group.
 type HookDef struct {
-	Type    string `json:"type"`              // "command" or "http"
+	Type    string `json:"type"`              // "command", "http", "prompt" or "agent"
 	Command string `json:"command,omitempty"` // Shell command (type=command)
 
+	// Prompt and agent hook fields. The prompt is the rule or condition to
+	// check; $ARGUMENTS is replaced by the hook input JSON.
+	Prompt string `json:"prompt,omitempty"`
+	Model  string `json:"model,omitempty"` // Model override (default: a small model)
+
 	// HTTP hook fields
 	URL     string            `json:"url,omitempty"`     // URL to POST (type=http)
 	Headers map[string]string `json:"headers,omitempty"` // Extra request headers
 
 	// Common fields
 	If            string `json:"if,omitempty"`            // Permission-rule filter (e.g. "Bash(git *)")
-	Timeout       int    `json:"timeout,omitempty"`       // Seconds, default 10
+	Timeout       int    `json:"timeout,omitempty"`       // Seconds, default 10 (prompt 30, agent 120)
 	StatusMessage string `json:"statusMessage,omitempty"` // Spinner text while running
 	Once          bool   `json:"once,omitempty"`          // Remove after first execution
 	Async         bool   `json:"async,omitempty"`         // Run in background, don't block
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -16,6 +17,9 @@ import (
 	"time"
 )
 
+// blockingExitCode is the command hook exit status that blocks the operation.
+const blockingExitCode = 2
+
 // Engine executes hook commands in response to lifecycle events.
 // Thread-safe: may be called from multiple goroutines (e.g. concurrent tool execution).
 type Engine struct {
@@ -25,6 +29,9 @@ type Engine struct {
 
 	// Once-tracking: keys are "<event>|<matcher>|<hookIdx>" for hooks already fired.
 	onceFired map[string]bool
+
+	// Runs prompt and agent hooks (see llm.go)
+	evaluator Evaluator
 }
 
 // NewEngine creates a hook engine with the given working directory and configuration.
@@ -70,7 +77,7 @@ type HookOutput struct {
 
 	// hookSpecificOutput fields (flattened for simplicity in Go)
 	UpdatedMCPToolOutput json.RawMessage `json:"updatedMCPToolOutput,omitempty"` // PostToolUse: update MCP output
-	WatchPaths           []string        `json:"watchPaths,omitempty"`           // SessionStart/CwdChanged: paths to watch
+	WatchPaths           []string        `json:"watchPaths,omitempty"`           // SessionStart/CwdChanged/FileChanged: paths to watch
 	InitialUserMessage   string          `json:"initialUserMessage,omitempty"`   // SessionStart: set first message
 	Retry                *bool           `json:"retry,omitempty"`                // PermissionDenied: retry the tool
 }
@@ -97,8 +104,9 @@ type AggregatedResult struct {
 
 // BlockingError records a hook that blocked execution.
 type BlockingError struct {
-	Command string `json:"command"`
-	Message string `json:"message"`
+	Command  string `json:"command"`
+	Message  string `json:"message"`
+	HookType string `json:"hookType,omitempty"` // Type of the hook that blocked
 }
 
 // RunPreToolUse runs hooks registered for the PreToolUse event.
@@ -158,21 +166,31 @@ func (e *Engine) RunSessionEnd(ctx context.Context, sessionID string) error {
 	return err
 }
 
-// RunPreCompact runs hooks before context compaction.
-func (e *Engine) RunPreCompact(ctx context.Context, sessionID string) error {
+// RunPreCompact runs hooks before context compaction. trigger is "auto" or
+// "manual"; customInstructions carries the user's focus for manual runs.
+// The trigger is also the matcher, so hooks can target one kind.
+func (e *Engine) RunPreCompact(ctx context.Context, sessionID, trigger, customInstructions string) error {
 	_, err := e.runEvent(ctx, EventPreCompact, &HookInput{
 		Event:     EventPreCompact,
 		SessionID: sessionID,
-	}, "")
+		Extra: map[string]interface{}{
+			"trigger":             trigger,
+			"custom_instructions": customInstructions,
+		},
+	}, trigger)
 	return err
 }
 
-// RunPostCompact runs hooks after context compaction.
-func (e *Engine) RunPostCompact(ctx context.Context, sessionID string) error {
+// RunPostCompact runs hooks after context compaction with the summary.
+func (e *Engine) RunPostCompact(ctx context.Context, sessionID, trigger, summary string) error {
 	_, err := e.runEvent(ctx, EventPostCompact, &HookInput{
 		Event:     EventPostCompact,
 		SessionID: sessionID,
-	}, "")
+		Extra: map[string]interface{}{
+			"trigger":         trigger,
+			"compact_summary": summary,
+		},
+	}, trigger)
 	return err
 }
 
@@ -222,15 +240,62 @@ func (e *Engine) RunSubagentStop(ctx context.Context, agentID string) error {
 	return err
 }
 
-// RunStop runs Stop hooks.
-func (e *Engine) RunStop(ctx context.Context, sessionID string) error {
-	_, err := e.runEvent(ctx, EventStop, &HookInput{
+// RunStop runs Stop hooks when the agent is about to end its turn. A blocking
+// result asks the agent to keep going; stopHookActive is true when the turn is
+// already continuing because of an earlier Stop hook, so hooks can avoid loops.
+func (e *Engine) RunStop(ctx context.Context, sessionID string, stopHookActive bool) (*AggregatedResult, error) {
+	return e.runEvent(ctx, EventStop, &HookInput{
 		Event:     EventStop,
 		SessionID: sessionID,
+		Extra:     map[string]interface{}{"stop_hook_active": stopHookActive},
+	}, "")
+}
+
+// RunStopFailure runs StopFailure hooks when a turn ends because of an API error.
+func (e *Engine) RunStopFailure(ctx context.Context, sessionID, errMsg string) error {
+	_, err := e.runEvent(ctx, EventStopFailure, &HookInput{
+		Event:     EventStopFailure,
+		SessionID: sessionID,
+		Extra:     map[string]interface{}{"error": errMsg},
+	}, "")
+	return err
+}
+
+// RunSetup runs Setup hooks. trigger describes why setup runs (e.g. "init").
+func (e *Engine) RunSetup(ctx context.Context, sessionID, trigger string) (*AggregatedResult, error) {
+	return e.runEvent(ctx, EventSetup, &HookInput{
+		Event:     EventSetup,
+		SessionID: sessionID,
+		Extra:     map[string]interface{}{"trigger": trigger},
+	}, "")
+}
+
+// RunInstructionsLoaded runs InstructionsLoaded hooks for one instruction file.
+func (e *Engine) RunInstructionsLoaded(ctx context.Context, path string) error {
+	_, err := e.runEvent(ctx, EventInstructionsLoad, &HookInput{
+		Event: EventInstructionsLoad,
+		Extra: map[string]interface{}{"file_path": path},
 	}, "")
 	return err
 }
 
+// RunConfigChange runs ConfigChange hooks before a session setting changes.
+// A blocking result rejects the change.
+func (e *Engine) RunConfigChange(ctx context.Context, key string, value interface{}) (*AggregatedResult, error) {
+	return e.runEvent(ctx, EventConfigChange, &HookInput{
+		Event: EventConfigChange,
+		Extra: map[string]interface{}{"source": "session", "key": key, "value": value},
+	}, "")
+}
+
+// RunCwdChanged runs CwdChanged hooks after the agent's working directory moves.
+func (e *Engine) RunCwdChanged(ctx context.Context, oldCwd, newCwd string) (*AggregatedResult, error) {
+	return e.runEvent(ctx, EventCwdChanged, &HookInput{
+		Event: EventCwdChanged,
+		Extra: map[string]interface{}{"old_cwd": oldCwd, "new_cwd": newCwd},
+	}, "")
+}
+
 // RunTaskCreated runs TaskCreated hooks.
 func (e *Engine) RunTaskCreated(ctx context.Context, taskID, subject string) error {
 	_, err := e.runEvent(ctx, EventTaskCreated, &HookInput{
@@ -275,13 +340,19 @@ func (e *Engine) RunUserPromptSubmit(ctx context.Context, promptText string) (*A
 	}, "")
 }
 
-// RunFileChanged runs FileChanged hooks.
-func (e *Engine) RunFileChanged(ctx context.Context, path string) error {
-	_, err := e.runEvent(ctx, EventFileChanged, &HookInput{
+// RunFileChanged runs FileChanged hooks for a watched file that changed on
+// disk. Hooks may return more paths to watch.
+func (e *Engine) RunFileChanged(ctx context.Context, path string) (*AggregatedResult, error) {
+	return e.runEvent(ctx, EventFileChanged, &HookInput{
 		Event: EventFileChanged,
 		Extra: map[string]interface{}{"path": path},
 	}, "")
-	return err
+}
+
+// HasHooks reports whether any hooks are configured for event. Callers use it
+// to skip building expensive hook input when nothing is listening.
+func (e *Engine) HasHooks(event string) bool {
+	return len(e.collectHooks(event, "")) > 0
 }
 
 // RunGeneric runs hooks for any event type with arbitrary extra data.
@@ -374,6 +445,12 @@ func (h resolvedHook) timeout() time.Duration {
 	if h.def.Timeout > 0 {
 		return time.Duration(h.def.Timeout) * time.Second
 	}
+	switch h.def.Type {
+	case HookTypePrompt:
+		return defaultPromptHookTimeout
+	case HookTypeAgent:
+		return defaultAgentHookTimeout
+	}
 	return 10 * time.Second
 }
 
@@ -384,6 +461,9 @@ func (h resolvedHook) describe() string {
 	if h.def.URL != "" {
 		return h.def.URL
 	}
+	if h.def.Prompt != "" {
+		return h.def.Type + ": " + truncate(h.def.Prompt, 60)
+	}
 	return "unknown"
 }
 
@@ -440,6 +520,8 @@ func (e *Engine) executeHook(ctx context.Context, rh resolvedHook, input *HookIn
 	switch rh.def.Type {
 	case HookTypeHTTP:
 		return e.executeHTTPHook(hookCtx, rh.def, input)
+	case HookTypePrompt, HookTypeAgent:
+		return e.executeLLMHook(hookCtx, rh.def, input)
 	default: // HookTypeCommand or empty (default to command)
 		return e.executeCommandHook(hookCtx, rh.def, input)
 	}
@@ -470,7 +552,16 @@ func (e *Engine) executeCommandHook(ctx context.Context, def HookDef, input *Hoo
 
 	err := cmd.Run()
 	if err != nil {
-		// Non-zero exit code — treat as hook failure but don't block
+		// Exit code 2 is a blocking error: stderr is the reason fed back to the
+		// agent (or user). Any other non-zero exit is a hook failure that doesn't block.
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == blockingExitCode {
+			reason := strings.TrimSpace(stderr.String())
+			if reason == "" {
+				reason = "Hook blocked execution"
+			}
+			return &HookOutput{Decision: "block", Reason: reason}, nil
+		}
 		return nil, fmt.Errorf("hook command failed: %w (stderr: %s)", err, stderr.String())
 	}
 
@@ -640,6 +731,11 @@ func mergeOutput(agg *AggregatedResult, out *HookOutput, rh resolvedHook) {
 	if out.Retry != nil && agg.Retry == nil {
 		agg.Retry = out.Retry
 	}
+	// "approve" is the PermissionRequest spelling of an allow decision
+	if out.Decision == "approve" && agg.PermissionDecision == "" {
+		agg.PermissionDecision = "allow"
+		agg.PermissionReason = out.Reason
+	}
 
 	// Decision field (for PermissionRequest hooks)
 	if out.Decision == "block" || (out.Continue != nil && !*out.Continue) {
@@ -651,8 +747,9 @@ func mergeOutput(agg *AggregatedResult, out *HookOutput, rh resolvedHook) {
 			msg = "Hook blocked execution"
 		}
 		agg.BlockingErrors = append(agg.BlockingErrors, BlockingError{
-			Command: rh.describe(),
-			Message: msg,
+			Command:  rh.describe(),
+			Message:  msg,
+			HookType: rh.def.Type,
 		})
 	}
 }
@@ -683,6 +780,22 @@ func aggregateToLegacyOutput(agg *AggregatedResult) *HookOutput {
 		out.StopReason = agg.StopReason
 	}
 
+	// A prompt or agent hook that blocks denies the tool call. Command and
+	// HTTP hooks keep their existing contract: they deny through
+	// permissionDecision, and exit code 2 alone does not.
+	if out.PermissionDecision == "" {
+		var msgs []string
+		for _, be := range agg.BlockingErrors {
+			if be.HookType == HookTypePrompt || be.HookType == HookTypeAgent {
+				msgs = append(msgs, be.Message)
+			}
+		}
+		if len(msgs) > 0 {
+			out.PermissionDecision = "deny"
+			out.DenyMessage = strings.Join(msgs, "\n")
+		}
+	}
+
 	return out
 }
 
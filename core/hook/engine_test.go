@@ -367,3 +367,98 @@ func TestAllEventsValid(t *testing.T) {
 		}
 	}
 }
+
+func TestEngineExitCodeTwoBlocks(t *testing.T) {
+	cfg := Config{
+		Hooks: map[string][]MatcherGroup{
+			EventStop: {
+				{Hooks: []HookDef{{Type: HookTypeCommand, Command: `echo "tests have not run" >&2; exit 2`}}},
+			},
+		},
+	}
+
+	engine := NewEngine(t.TempDir(), cfg)
+	result, err := engine.RunStop(context.Background(), "test-session", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result == nil || len(result.BlockingErrors) != 1 {
+		t.Fatalf("expected one blocking error, got %+v", result)
+	}
+	if result.BlockingErrors[0].Message != "tests have not run" {
+		t.Errorf("expected stderr as reason, got %q", result.BlockingErrors[0].Message)
+	}
+}
+
+func TestEngineOtherExitCodesDoNotBlock(t *testing.T) {
+	cfg := Config{
+		Hooks: map[string][]MatcherGroup{
+			EventStop: {
+				{Hooks: []HookDef{{Type: HookTypeCommand, Command: `exit 1`}}},
+			},
+		},
+	}
+
+	engine := NewEngine(t.TempDir(), cfg)
+	result, err := engine.RunStop(context.Background(), "test-session", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != nil && len(result.BlockingErrors) != 0 {
+		t.Errorf("expected no blocking errors, got %+v", result.BlockingErrors)
+	}
+}
+
+func TestEngineStopHookActive(t *testing.T) {
+	cfg := Config{
+		Hooks: map[string][]MatcherGroup{
+			EventStop: {
+				{Hooks: []HookDef{{Type: HookTypeCommand, Command: `grep -q '"stop_hook_active":true' && echo '{"additionalContext":"active"}'`}}},
+			},
+		},
+	}
+
+	engine := NewEngine(t.TempDir(), cfg)
+	result, err := engine.RunStop(context.Background(), "test-session", true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result == nil || len(result.AdditionalContexts) != 1 {
+		t.Errorf("expected stop_hook_active in hook input, got %+v", result)
+	}
+}
+
+func TestEngineApproveDecision(t *testing.T) {
+	cfg := Config{
+		Hooks: map[string][]MatcherGroup{
+			EventPermissionRequest: {
+				{Matcher: "Bash", Hooks: []HookDef{{Type: HookTypeCommand, Command: `echo '{"decision":"approve","reason":"safe"}'`}}},
+			},
+		},
+	}
+
+	engine := NewEngine(t.TempDir(), cfg)
+	result, err := engine.RunPermissionRequest(context.Background(), "Bash", json.RawMessage(`{}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result == nil || result.PermissionDecision != "allow" {
+		t.Errorf("expected approve to map to allow, got %+v", result)
+	}
+}
+
+func TestEngineHasHooks(t *testing.T) {
+	engine := NewEngine(t.TempDir(), Config{
+		Hooks: map[string][]MatcherGroup{
+			EventNotification: {{Hooks: []HookDef{{Command: "true"}}}},
+		},
+		LegacyHooks: []LegacyHookConfig{{Command: "true", Events: []string{EventCwdChanged}}},
+	})
+
+	if !engine.HasHooks(EventNotification) || !engine.HasHooks(EventCwdChanged) {
+		t.Error("expected configured events to report hooks")
+	}
+	if engine.HasHooks(EventStop) {
+		t.Error("expected no hooks for Stop")
+	}
+}
@@ -0,0 +1,137 @@
+package hook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Default timeouts for model-evaluated hooks, which take far longer than a
+// typical shell command.
+const (
+	defaultPromptHookTimeout = 30 * time.Second
+	defaultAgentHookTimeout  = 120 * time.Second
+)
+
+// argumentsPlaceholder in a prompt is replaced by the hook input JSON. Prompts
+// without it get the input appended.
+const argumentsPlaceholder = "$ARGUMENTS"
+
+// Evaluator runs "prompt" and "agent" hooks. The hook package has no model
+// access of its own; the runner provides one backed by the conversation's
+// provider. Both methods return the model's final text.
+type Evaluator interface {
+	// EvaluatePrompt makes a single model call with no tools.
+	EvaluatePrompt(ctx context.Context, systemPrompt, userPrompt, model string) (string, error)
+
+	// RunAgent runs a sub-agent restricted to read-only tools.
+	RunAgent(ctx context.Context, systemPrompt, userPrompt, model string) (string, error)
+}
+
+// SetEvaluator sets the evaluator used by prompt and agent hooks. Without one
+// those hooks fail (without blocking) when they fire.
+func (e *Engine) SetEvaluator(ev Evaluator) {
+	e.mu.Lock()
+	e.evaluator = ev
+	e.mu.Unlock()
+}
+
+// llmDecision is the structured answer prompt and agent hooks must give.
+type llmDecision struct {
+	Decision          string `json:"decision"` // "allow" or "deny"
+	Reason            string `json:"reason,omitempty"`
+	AdditionalContext string `json:"additionalContext,omitempty"`
+}
+
+const llmHookSystemPrompt = `You are a policy hook in a coding agent. You are given a rule and the JSON describing an event in the agent's session (a tool call, a prompt, the agent stopping, ...). Decide whether the event satisfies the rule.
+
+Respond with ONLY a JSON object, no other text:
+{"decision": "allow" | "deny", "reason": "<why, addressed to the agent; required when denying>", "additionalContext": "<optional note for the agent>"}
+
+Deny only when the rule is clearly violated or clearly not yet satisfied.`
+
+const agentHookSystemPrompt = `You are a verification agent acting as a policy hook in a coding agent. You are given a condition and the JSON describing an event in the agent's session. Use your read-only tools to inspect the working directory and check whether the condition holds. You cannot modify anything.
+
+When you are done, end your final message with ONLY a JSON object:
+{"decision": "allow" | "deny", "reason": "<what is missing or wrong, addressed to the agent; required when denying>", "additionalContext": "<optional note for the agent>"}
+
+Use "deny" when the condition does not hold.`
+
+// executeLLMHook runs a prompt or agent hook and maps its decision onto a
+// HookOutput: "deny" blocks like exit code 2, "allow" expresses no objection
+// (it never grants a tool permission on its own).
+func (e *Engine) executeLLMHook(ctx context.Context, def HookDef, input *HookInput) (*HookOutput, error) {
+	e.mu.Lock()
+	ev := e.evaluator
+	e.mu.Unlock()
+	if ev == nil {
+		return nil, fmt.Errorf("%s hooks are not supported in this session", def.Type)
+	}
+	if strings.TrimSpace(def.Prompt) == "" {
+		return nil, fmt.Errorf("%s hook has no prompt", def.Type)
+	}
+
+	userPrompt := buildLLMHookPrompt(def.Prompt, input)
+	var text string
+	var err error
+	if def.Type == HookTypeAgent {
+		text, err = ev.RunAgent(ctx, agentHookSystemPrompt, userPrompt, def.Model)
+	} else {
+		text, err = ev.EvaluatePrompt(ctx, llmHookSystemPrompt, userPrompt, def.Model)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s hook evaluation failed: %w", def.Type, err)
+	}
+
+	decision, err := parseLLMDecision(text)
+	if err != nil {
+		return nil, fmt.Errorf("%s hook: %w", def.Type, err)
+	}
+
+	out := &HookOutput{AdditionalContext: decision.AdditionalContext}
+	switch decision.Decision {
+	case "deny":
+		out.Decision = "block"
+		out.Reason = decision.Reason
+		if out.Reason == "" {
+			out.Reason = "Blocked by " + def.Type + " hook"
+		}
+	case "allow":
+	default:
+		return nil, fmt.Errorf("%s hook returned unknown decision %q", def.Type, decision.Decision)
+	}
+	return out, nil
+}
+
+// buildLLMHookPrompt fills the hook's rule with the event input.
+func buildLLMHookPrompt(rule string, input *HookInput) string {
+	inputJSON, _ := json.MarshalIndent(input, "", "  ")
+	if strings.Contains(rule, argumentsPlaceholder) {
+		return strings.ReplaceAll(rule, argumentsPlaceholder, string(inputJSON))
+	}
+	return fmt.Sprintf("Rule:\n%s\n\nEvent:\n%s", rule, inputJSON)
+}
+
+// parseLLMDecision extracts the decision object from model output, tolerating
+// surrounding prose or code fences by taking the last JSON object in the text.
+func parseLLMDecision(text string) (*llmDecision, error) {
+	text = strings.TrimSpace(text)
+	for end := strings.LastIndex(text, "}"); end >= 0; end = strings.LastIndex(text[:end], "}") {
+		for start := strings.LastIndex(text[:end], "{"); start >= 0; start = strings.LastIndex(text[:start], "{") {
+			var d llmDecision
+			if json.Unmarshal([]byte(text[start:end+1]), &d) == nil && d.Decision != "" {
+				return &d, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no decision in model response: %q", truncate(text, 200))
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
@@ -0,0 +1,140 @@
+package hook
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeEvaluator struct {
+	response string
+	err      error
+
+	gotSystem, gotUser, gotModel string
+	agent                        bool
+}
+
+func (f *fakeEvaluator) EvaluatePrompt(_ context.Context, systemPrompt, userPrompt, model string) (string, error) {
+	f.gotSystem, f.gotUser, f.gotModel = systemPrompt, userPrompt, model
+	return f.response, f.err
+}
+
+func (f *fakeEvaluator) RunAgent(_ context.Context, systemPrompt, userPrompt, model string) (string, error) {
+	f.agent = true
+	f.gotSystem, f.gotUser, f.gotModel = systemPrompt, userPrompt, model
+	return f.response, f.err
+}
+
+func llmHookEngine(event string, def HookDef, ev Evaluator) *Engine {
+	e := NewEngine("/tmp", Config{Hooks: map[string][]MatcherGroup{
+		event: {{Hooks: []HookDef{def}}},
+	}})
+	if ev != nil {
+		e.SetEvaluator(ev)
+	}
+	return e
+}
+
+func TestPromptHookDenyBlocksToolCall(t *testing.T) {
+	ev := &fakeEvaluator{response: `{"decision":"deny","reason":"commit includes a .env file"}`}
+	e := llmHookEngine(EventPreToolUse, HookDef{
+		Type:   HookTypePrompt,
+		Prompt: "Block git commits that include secrets.",
+		Model:  "haiku",
+	}, ev)
+
+	out, err := e.RunPreToolUse(context.Background(), "Bash", []byte(`{"command":"git commit -am wip"}`))
+
+	require.NoError(t, err)
+	require.NotNil(t, out)
+	assert.Equal(t, "deny", out.PermissionDecision)
+	assert.Equal(t, "commit includes a .env file", out.DenyMessage)
+	assert.False(t, ev.agent)
+	assert.Equal(t, "haiku", ev.gotModel)
+	assert.Contains(t, ev.gotUser, "Block git commits that include secrets.")
+	assert.Contains(t, ev.gotUser, "git commit -am wip")
+}
+
+func TestPromptHookAllowAddsContext(t *testing.T) {
+	ev := &fakeEvaluator{response: "Looks fine.\n```json\n{\"decision\":\"allow\",\"additionalContext\":\"remember the changelog\"}\n```"}
+	e := llmHookEngine(EventStop, HookDef{Type: HookTypePrompt, Prompt: "Is the work complete?"}, ev)
+
+	res, err := e.RunStop(context.Background(), "s1", false)
+
+	require.NoError(t, err)
+	require.NotNil(t, res)
+	assert.Empty(t, res.BlockingErrors)
+	assert.Equal(t, []string{"remember the changelog"}, res.AdditionalContexts)
+}
+
+func TestAgentHookDenyBlocksStop(t *testing.T) {
+	ev := &fakeEvaluator{response: `I found 2 TODOs left in main.go. {"decision":"deny","reason":"TODOs remain in main.go"}`}
+	e := llmHookEngine(EventStop, HookDef{
+		Type:   HookTypeAgent,
+		Prompt: "Check that all TODOs are done. Event: $ARGUMENTS",
+	}, ev)
+
+	res, err := e.RunStop(context.Background(), "s1", false)
+
+	require.NoError(t, err)
+	require.Len(t, res.BlockingErrors, 1)
+	assert.Equal(t, "TODOs remain in main.go", res.BlockingErrors[0].Message)
+	assert.True(t, ev.agent)
+	assert.Contains(t, ev.gotUser, `"event": "Stop"`)
+	assert.NotContains(t, ev.gotUser, argumentsPlaceholder)
+}
+
+func TestLLMHookFailuresDoNotBlock(t *testing.T) {
+	for name, ev := range map[string]Evaluator{
+		"no evaluator":   nil,
+		"provider error": &fakeEvaluator{err: errors.New("overloaded")},
+		"unparseable":    &fakeEvaluator{response: "I think this is fine"},
+		"unknown value":  &fakeEvaluator{response: `{"decision":"maybe"}`},
+	} {
+		t.Run(name, func(t *testing.T) {
+			e := llmHookEngine(EventStop, HookDef{Type: HookTypePrompt, Prompt: "Done?"}, ev)
+			res, err := e.RunStop(context.Background(), "s1", false)
+			require.NoError(t, err)
+			assert.Empty(t, res.BlockingErrors)
+		})
+	}
+}
+
+func TestLLMHookDefaultTimeouts(t *testing.T) {
+	assert.Equal(t, defaultPromptHookTimeout, resolvedHook{def: HookDef{Type: HookTypePrompt}}.timeout())
+	assert.Equal(t, defaultAgentHookTimeout, resolvedHook{def: HookDef{Type: HookTypeAgent}}.timeout())
+}
+
+func TestCommandHookBlockDoesNotDenyPreToolUse(t *testing.T) {
+	for name, command := range map[string]string{
+		"exit code 2":    `echo "no force push" >&2; exit 2`,
+		"decision block": `echo '{"decision":"block","reason":"no force push"}'`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			e := llmHookEngine(EventPreToolUse, HookDef{Type: HookTypeCommand, Command: command}, nil)
+
+			out, err := e.RunPreToolUse(context.Background(), "Bash", []byte(`{}`))
+
+			require.NoError(t, err)
+			require.NotNil(t, out)
+			assert.Empty(t, out.PermissionDecision)
+			assert.Empty(t, out.DenyMessage)
+		})
+	}
+}
+
+func TestCommandHookPermissionDecisionStillDenies(t *testing.T) {
+	e := llmHookEngine(EventPreToolUse, HookDef{
+		Type:    HookTypeCommand,
+		Command: `echo '{"permissionDecision":"deny","denyMessage":"no force push"}'`,
+	}, nil)
+
+	out, err := e.RunPreToolUse(context.Background(), "Bash", []byte(`{}`))
+
+	require.NoError(t, err)
+	assert.Equal(t, "deny", out.PermissionDecision)
+	assert.Equal(t, "no force push", out.DenyMessage)
+}
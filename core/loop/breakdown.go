@@ -0,0 +1,66 @@
+package loop
+
+import (
+	"context"
+	"fmt"
+
+	core "github.com/chatml/chatml-core"
+	ctxpkg "github.com/chatml/chatml-core/context"
+	"github.com/chatml/chatml-core/prompt"
+	"github.com/chatml/chatml-core/provider"
+)
+
+// ContextBreakdown estimates how the next request's tokens split across the
+// system prompt, tool definitions, messages, tool results and attachments,
+// and emits it as a context_breakdown event. The request is queued behind
+// any running turn. Implements agent.ContextReporter.
+func (r *Runner) ContextBreakdown(ctx context.Context) (*core.ContextBreakdown, error) {
+	reply := make(chan *core.ContextBreakdown, 1)
+	select {
+	case r.messageQueue <- inputMsg{Type: "context_breakdown", breakdownReply: reply}:
+	case <-r.done:
+		return nil, fmt.Errorf("context breakdown: runner stopped")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		return nil, fmt.Errorf("runner message queue full")
+	}
+
+	select {
+	case b := <-reply:
+		return b, nil
+	case <-r.done:
+		return nil, fmt.Errorf("context breakdown: runner stopped")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// contextBreakdown attributes the context of the request buildChatRequest
+// would send now. It must run on the loop goroutine, which owns r.messages.
+func (r *Runner) contextBreakdown() *core.ContextBreakdown {
+	var sections []prompt.Section
+	if r.promptBuilder != nil {
+		if r.toolRegistry != nil {
+			r.promptBuilder.SetToolPrompts(r.toolRegistry.ToolPrompts())
+		}
+		sections = r.promptBuilder.Sections()
+	} else if r.opts.Instructions != "" {
+		sections = []prompt.Section{{Name: "instructions", Text: r.opts.Instructions}}
+	}
+
+	var tools []provider.ToolDef
+	if r.toolRegistry != nil {
+		tools = r.toolRegistry.ToolDefs()
+	}
+
+	msgs := applyToolResultBudget(normalizeMessages(r.messages), 0)
+	b := ctxpkg.Breakdown(sections, tools, msgs)
+	if r.provider != nil {
+		b.ContextWindow = r.provider.MaxContextWindow()
+	}
+	if r.ctxManager != nil {
+		b.ReportedTokens = r.ctxManager.LastTokenCount()
+	}
+	return b
+}
@@ -0,0 +1,62 @@
+package loop
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/chatml/chatml-core/agent"
+	ctxpkg "github.com/chatml/chatml-core/context"
+	"github.com/chatml/chatml-core/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunner_ContextBreakdown(t *testing.T) {
+	opts := defaultOpts()
+	opts.Instructions = "Always answer in French."
+	r := NewRunner(opts, &textProvider{})
+	r.messages = conversation(2)
+	r.messages = append(r.messages,
+		provider.Message{Role: provider.RoleAssistant, Content: []provider.ContentBlock{
+			provider.NewToolUseBlock("tu_1", "Read", json.RawMessage(`{"file_path":"main.go"}`)),
+		}},
+		provider.Message{Role: provider.RoleUser, Content: []provider.ContentBlock{
+			provider.NewToolResultBlock("tu_1", "package main\n\nfunc main() {}\n", false),
+		}},
+	)
+
+	require.NoError(t, r.Start())
+	defer r.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	b, err := r.ContextBreakdown(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, r.provider.MaxContextWindow(), b.ContextWindow)
+	assert.Greater(t, b.EstimatedTokens, 0)
+	for _, c := range b.Categories {
+		switch c.Name {
+		case ctxpkg.CategorySystemPrompt:
+			require.NotEmpty(t, c.Items)
+			assert.Equal(t, "instructions", c.Items[0].Name)
+		case ctxpkg.CategoryMessages:
+			assert.Greater(t, c.Tokens, 0)
+		case ctxpkg.CategoryToolResults:
+			require.Len(t, c.Items, 1)
+			assert.Equal(t, "Read", c.Items[0].Name)
+		}
+	}
+
+	for {
+		var e agent.AgentEvent
+		require.NoError(t, json.Unmarshal([]byte(readEventWithTimeout(t, r.Output(), 2*time.Second)), &e))
+		if e.Type == "context_breakdown" {
+			require.NotNil(t, e.ContextBreakdown)
+			assert.Equal(t, b.EstimatedTokens, e.ContextBreakdown.EstimatedTokens)
+			break
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package loop
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/chatml/chatml-core/agent"
+	ctxpkg "github.com/chatml/chatml-core/context"
+)
+
+// Compaction triggers, reported in events and passed to compaction hooks.
+const (
+	compactTriggerAuto   = "auto"
+	compactTriggerManual = "manual"
+)
+
+// compactReply carries the outcome of a manual compaction back to Compact.
+type compactReply struct {
+	report *agent.CompactReport
+	err    error
+}
+
+// Compact summarizes the conversation on demand. instructions, when set,
+// tell the summarizer what to keep ("keep the DB migration details"). The
+// request is queued behind any running turn and Compact waits for the
+// result or for ctx to end. Implements agent.Compacter.
+func (r *Runner) Compact(ctx context.Context, instructions string) (*agent.CompactReport, error) {
+	if r.provider == nil {
+		return nil, fmt.Errorf("compact: no provider configured")
+	}
+	reply := make(chan compactReply, 1)
+	select {
+	case r.messageQueue <- inputMsg{Type: "compact", Content: instructions, compactReply: reply}:
+	case <-r.done:
+		return nil, fmt.Errorf("compact: runner stopped")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		return nil, fmt.Errorf("runner message queue full")
+	}
+
+	select {
+	case res := <-reply:
+		return res.report, res.err
+	case <-r.done:
+		return nil, fmt.Errorf("compact: runner stopped")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// compactHistory replaces r.messages with a summary plus the most recent
+// messages, restores recently read files and the tool list, and fires the
+// PreCompact and PostCompact hooks around it. It must run on the loop
+// goroutine, which owns r.messages.
+//
+// Only automatic failures count toward the auto-compact circuit breaker; a
+// failed manual run leaves the history untouched and is reported to the caller.
+func (r *Runner) compactHistory(ctx context.Context, trigger, instructions string) (*agent.CompactReport, error) {
+	preTokens := ctxpkg.EstimateTokens(r.messages)
+	r.emitter.emitPreCompact(trigger, instructions, preTokens)
+	if r.hookEngine != nil {
+		r.hookEngine.RunPreCompact(ctx, r.GetSessionID(), trigger, instructions) //nolint:errcheck
+	}
+
+	result, err := ctxpkg.CompactWithInstructions(ctx, r.provider, r.messages, 4, instructions)
+	if err != nil {
+		if trigger == compactTriggerAuto && r.ctxManager != nil {
+			r.ctxManager.RecordCompactFailure()
+		}
+		return nil, err
+	}
+	r.messages = result.Messages
+
+	// Post-compact context restoration: re-inject recent files, tools, MCP info
+	var toolNames []string
+	if r.toolRegistry != nil {
+		toolNames = r.toolRegistry.ToolNames()
+	}
+	restoreMsgs := ctxpkg.RestorePostCompact(ctxpkg.PostCompactRestorationConfig{
+		MaxRecentFiles: 5,
+		MaxFileTokens:  5000,
+		ReadTracker:    r.readTracker,
+		ToolNames:      toolNames,
+		SessionMemory:  r.sessionMemoryText(),
+	})
+	if len(restoreMsgs) > 0 {
+		r.messages = append(r.messages, restoreMsgs...)
+	}
+	// The summary replaced the history the memory was tracking
+	r.sessionMemoryMark = len(r.messages)
+
+	postTokens := ctxpkg.EstimateTokens(r.messages)
+	if r.ctxManager != nil {
+		r.ctxManager.RecordCompaction()
+		r.ctxManager.ResetCompactFailures()
+		r.ctxManager.UpdateTokenCount(postTokens)
+	}
+	r.emitter.emitCompactBoundary(trigger, preTokens, postTokens)
+
+	if r.hookEngine != nil {
+		r.hookEngine.RunPostCompact(ctx, r.GetSessionID(), trigger, result.Summary) //nolint:errcheck
+	}
+	r.emitter.emitPostCompact(trigger, result.Summary, preTokens, postTokens)
+
+	return &agent.CompactReport{
+		Trigger:    trigger,
+		PreTokens:  preTokens,
+		PostTokens: postTokens,
+		Summary:    result.Summary,
+	}, nil
+}
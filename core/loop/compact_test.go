@@ -0,0 +1,126 @@
+package loop
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chatml/chatml-core/agent"
+	"github.com/chatml/chatml-core/hook"
+	"github.com/chatml/chatml-core/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// summaryProvider answers every request with a fixed summary and records the
+// prompts it was sent.
+type summaryProvider struct {
+	textProvider
+	mu      sync.Mutex
+	prompts []string
+}
+
+func (p *summaryProvider) StreamChat(ctx context.Context, req provider.ChatRequest) (<-chan provider.StreamEvent, error) {
+	p.mu.Lock()
+	for _, m := range req.Messages {
+		p.prompts = append(p.prompts, messageText(m))
+	}
+	p.mu.Unlock()
+	return p.textProvider.StreamChat(ctx, req)
+}
+
+func conversation(turns int) []provider.Message {
+	var msgs []provider.Message
+	for i := 0; i < turns; i++ {
+		msgs = append(msgs,
+			provider.Message{Role: provider.RoleUser, Content: []provider.ContentBlock{provider.NewTextBlock(strings.Repeat("migrate the users table ", 50))}},
+			provider.Message{Role: provider.RoleAssistant, Content: []provider.ContentBlock{provider.NewTextBlock(strings.Repeat("added migration 0042 ", 50))}},
+		)
+	}
+	return msgs
+}
+
+func TestRunner_CompactManual(t *testing.T) {
+	prov := &summaryProvider{textProvider: textProvider{text: "Summary: migration 0042 adds users.email"}}
+	hookLog := filepath.Join(t.TempDir(), "hooks.log")
+	cfg := hook.Config{Hooks: map[string][]hook.MatcherGroup{
+		hook.EventPreCompact:  {{Matcher: "manual", Hooks: []hook.HookDef{{Type: hook.HookTypeCommand, Command: "cat >> " + hookLog, Timeout: 5}}}},
+		hook.EventPostCompact: {{Hooks: []hook.HookDef{{Type: hook.HookTypeCommand, Command: "cat >> " + hookLog, Timeout: 5}}}},
+	}}
+	r := newHookedRunner(t, prov, cfg)
+	r.messages = conversation(5)
+	before := len(r.messages)
+
+	require.NoError(t, r.Start())
+	defer r.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	report, err := r.Compact(ctx, "keep the DB migration details")
+	require.NoError(t, err)
+
+	assert.Equal(t, "manual", report.Trigger)
+	assert.Equal(t, "Summary: migration 0042 adds users.email", report.Summary)
+	assert.Greater(t, report.PreTokens, report.PostTokens)
+	assert.Less(t, len(r.messages), before)
+	assert.Contains(t, messageText(r.messages[0]), "migration 0042 adds users.email")
+
+	prov.mu.Lock()
+	require.Len(t, prov.prompts, 1)
+	assert.Contains(t, prov.prompts[0], "keep the DB migration details")
+	prov.mu.Unlock()
+
+	var types []string
+	var post agent.AgentEvent
+	for len(types) < 5 {
+		var e agent.AgentEvent
+		require.NoError(t, json.Unmarshal([]byte(readEventWithTimeout(t, r.Output(), 2*time.Second)), &e))
+		types = append(types, e.Type)
+		if e.Type == "post_compact" {
+			post = e
+		}
+	}
+	assert.Equal(t, []string{"ready", "session_started", "pre_compact", "compact_boundary", "post_compact"}, types)
+	assert.Equal(t, report.PreTokens, post.PreTokens)
+	assert.Equal(t, report.PostTokens, post.PostTokens)
+	assert.Equal(t, report.Summary, post.CompactSummary)
+
+	data, err := os.ReadFile(hookLog)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"custom_instructions":"keep the DB migration details"`)
+	assert.Contains(t, string(data), `"compact_summary":"Summary: migration 0042 adds users.email"`)
+}
+
+func TestRunner_CompactManualFailureKeepsHistory(t *testing.T) {
+	prov := &summaryProvider{textProvider: textProvider{text: "unused"}}
+	r := newHookedRunner(t, prov, hook.Config{})
+	r.messages = conversation(1)
+
+	_, err := r.compactHistory(context.Background(), compactTriggerManual, "")
+	require.Error(t, err)
+	assert.Len(t, r.messages, 2)
+	assert.Equal(t, 0, r.ctxManager.CompactFailures(), "manual failures don't trip the auto-compact breaker")
+
+	_, err = r.compactHistory(context.Background(), compactTriggerAuto, "")
+	require.Error(t, err)
+	assert.Equal(t, 1, r.ctxManager.CompactFailures())
+}
+
+func TestRunner_CompactStopped(t *testing.T) {
+	r := NewRunner(defaultOpts(), &textProvider{})
+	require.NoError(t, r.Start())
+	go func() {
+		for range r.Output() {
+		}
+	}()
+	r.Stop()
+	<-r.Done()
+
+	_, err := r.Compact(context.Background(), "")
+	assert.Error(t, err)
+}
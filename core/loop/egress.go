@@ -0,0 +1,97 @@
+package loop
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+
+	"github.com/chatml/chatml-core/egress"
+	"github.com/chatml/chatml-core/mcp"
+	"github.com/chatml/chatml-core/permission"
+	"github.com/chatml/chatml-core/provider"
+)
+
+// Network egress enforcement. The Runner is the builtin.NetworkGuard for its
+// tools (and for sub-agents, which share the parent's tool registry). Hosts
+// are checked against the engine's Network rules; a host that needs approval
+// goes through the usual approval flow as the pseudo-tool "Network", so the
+// user can allow it once, for the session, or add it as a rule.
+
+// NetworkRestricted implements builtin.NetworkGuard.
+func (r *Runner) NetworkRestricted() bool {
+	return r.permEngine != nil && r.permEngine.NetworkRestricted()
+}
+
+// CheckNetworkHost implements builtin.NetworkGuard. It blocks while the user
+// is asked about a host and records every decision in the permission audit.
+func (r *Runner) CheckNetworkHost(ctx context.Context, host string) error {
+	if !r.NetworkRestricted() {
+		return nil
+	}
+	check := r.permEngine.CheckNetwork(host)
+	if check.DecidedBy == permission.DecidedByBuiltin {
+		return nil // Loopback
+	}
+
+	input, _ := json.Marshal(map[string]string{"host": check.Specifier})
+	tc := provider.ToolUseBlock{
+		ID:    fmt.Sprintf("net-%d", atomic.AddInt64(&r.approvalCounter, 1)),
+		Name:  permission.NetworkTool,
+		Input: input,
+	}
+	if check.Decision == permission.NeedApproval {
+		if decided, _, ok := r.runPermissionRequestHooks(ctx, tc, check); ok {
+			check = decided
+		} else {
+			check, _ = r.requestApproval(ctx, tc, check)
+		}
+	}
+	r.auditPermission(tc, check)
+
+	if check.Decision != permission.Allow {
+		return fmt.Errorf("network access to %s blocked by egress policy: %s", check.Specifier, check.DenyMessage)
+	}
+	return nil
+}
+
+// routeMCPServerEgress points a stdio MCP server at a dedicated egress proxy
+// when network access is restricted. The proxy lives as long as the runner.
+func (r *Runner) routeMCPServerEgress(cfg *mcp.ServerConfig) error {
+	if !r.NetworkRestricted() {
+		return nil
+	}
+	p, err := egress.Start(context.Background(), r.CheckNetworkHost)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.egressProxies = append(r.egressProxies, p)
+	r.mu.Unlock()
+
+	env := make(map[string]string, len(cfg.Env)+8)
+	for k, v := range cfg.Env {
+		env[k] = v
+	}
+	for _, kv := range p.Env() {
+		k, v, _ := strings.Cut(kv, "=")
+		env[k] = v
+	}
+	cfg.Env = env
+	return nil
+}
+
+// closeEgressProxies stops the proxies started for MCP servers.
+func (r *Runner) closeEgressProxies() {
+	r.mu.Lock()
+	proxies := r.egressProxies
+	r.egressProxies = nil
+	r.mu.Unlock()
+	for _, p := range proxies {
+		if err := p.Close(); err != nil {
+			log.Printf("egress proxy close: %v", err)
+		}
+	}
+}
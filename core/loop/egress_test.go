@@ -0,0 +1,82 @@
+package loop
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chatml/chatml-core/mcp"
+	"github.com/chatml/chatml-core/permission"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newEgressRunner(t *testing.T, allow ...string) (*Runner, <-chan string) {
+	t.Helper()
+	r := NewRunner(defaultOpts(), nil)
+	rules := permission.NewRuleSet(permission.NetworkRules(allow, nil, permission.SourceProject))
+	r.permEngine = permission.NewEngine(permission.ModeDefault, rules)
+	e, events := newTestEmitter()
+	r.emitter = e
+	return r, events
+}
+
+func TestRunner_CheckNetworkHost_Unrestricted(t *testing.T) {
+	r, _ := newEgressRunner(t)
+	assert.False(t, r.NetworkRestricted())
+	assert.NoError(t, r.CheckNetworkHost(context.Background(), "example.com"))
+}
+
+func TestRunner_CheckNetworkHost_AllowlistedHost(t *testing.T) {
+	r, events := newEgressRunner(t, "*.npmjs.org")
+	require.True(t, r.NetworkRestricted())
+	require.NoError(t, r.CheckNetworkHost(context.Background(), "registry.npmjs.org"))
+
+	audit := readEvent(t, events)
+	assert.Equal(t, "permission_decision", audit.Type)
+	assert.Equal(t, permission.NetworkTool, audit.ToolName)
+	assert.Equal(t, "registry.npmjs.org", audit.Specifier)
+	assert.Equal(t, "allow", audit.PermissionDecision)
+	assert.Equal(t, "rule:project", audit.DecidedBy)
+}
+
+func TestRunner_CheckNetworkHost_ApprovalFlow(t *testing.T) {
+	r, events := newEgressRunner(t, "*.npmjs.org")
+
+	done := make(chan error, 1)
+	go func() { done <- r.CheckNetworkHost(context.Background(), "example.com") }()
+
+	request := readEvent(t, events)
+	require.Equal(t, "tool_approval_request", request.Type)
+	assert.Equal(t, permission.NetworkTool, request.ToolName)
+	assert.Equal(t, "example.com", request.Specifier)
+	require.NoError(t, r.SendToolApprovalResponse(request.RequestID, "deny_once", "", nil))
+
+	err := <-done
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "network access to example.com blocked")
+
+	audit := readEvent(t, events)
+	assert.Equal(t, "deny", audit.PermissionDecision)
+	assert.Equal(t, "user", audit.DecidedBy)
+	assert.Equal(t, "deny_once", audit.ApprovalAction)
+
+	go func() { done <- r.CheckNetworkHost(context.Background(), "example.com") }()
+	request = readEvent(t, events)
+	require.NoError(t, r.SendToolApprovalResponse(request.RequestID, "allow_session", "", nil))
+	require.NoError(t, <-done)
+	readEvent(t, events)
+
+	require.NoError(t, r.CheckNetworkHost(context.Background(), "example.com"), "session approval is remembered")
+}
+
+func TestRunner_RouteMCPServerEgress(t *testing.T) {
+	r, _ := newEgressRunner(t, "*.npmjs.org")
+	cfg := mcp.ServerConfig{Name: "fs", Command: "mcp-fs", Env: map[string]string{"TOKEN": "x"}}
+	require.NoError(t, r.routeMCPServerEgress(&cfg))
+	defer r.closeEgressProxies()
+
+	assert.Equal(t, "x", cfg.Env["TOKEN"])
+	assert.Contains(t, cfg.Env["HTTPS_PROXY"], "http://127.0.0.1:")
+	assert.Contains(t, cfg.Env["ALL_PROXY"], "socks5h://127.0.0.1:")
+	assert.Len(t, r.egressProxies, 1)
+}
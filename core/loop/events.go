@@ -8,6 +8,7 @@ import (
 	"encoding/json"
 	"fmt"
 
+	core "github.com/chatml/chatml-core"
 	"github.com/chatml/chatml-core/agent"
 	"github.com/chatml/chatml-core/provider"
 )
@@ -27,12 +28,19 @@ const (
 	eventSessionStarted  = "session_started"
 	eventPermissionMode      = "permission_mode_changed"
 	eventContextUsage        = "context_usage"
+	eventContextBreakdown    = "context_breakdown"
 	eventToolApprovalRequest      = "tool_approval_request"
 	eventToolBatchApprovalRequest = "tool_batch_approval_request"
 	eventContextWarning      = "context_warning"
+	eventWarning             = "warning"
 	eventSubagentStarted = "subagent_started"
 	eventSubagentStopped = "subagent_stopped"
 	eventSubagentOutput  = "subagent_output"
+	eventPermissionDecision = "permission_decision"
+	eventPreCompact         = "pre_compact"
+	eventCompactBoundary    = "compact_boundary"
+	eventPostCompact        = "post_compact"
+	eventTaskListUpdate     = "task_list_update"
 )
 
 // emitter wraps a channel and provides helper methods for emitting AgentEvent types
@@ -163,6 +171,54 @@ func (e *emitter) emitContextUsage(inputTokens, outputTokens, contextWindow, cum
 	})
 }
 
+// emitContextBreakdown reports estimated context usage per category.
+func (e *emitter) emitContextBreakdown(b *core.ContextBreakdown) {
+	e.emit(&agent.AgentEvent{
+		Type:             eventContextBreakdown,
+		ContextWindow:    b.ContextWindow,
+		ContextBreakdown: b,
+	})
+}
+
+// emitTaskList reports the full task list after it changed.
+func (e *emitter) emitTaskList(tasks []agent.TaskItem) {
+	e.emit(&agent.AgentEvent{
+		Type:  eventTaskListUpdate,
+		Tasks: tasks,
+	})
+}
+
+// emitPreCompact signals that compaction is starting.
+func (e *emitter) emitPreCompact(trigger, instructions string, preTokens int) {
+	e.emit(&agent.AgentEvent{
+		Type:               eventPreCompact,
+		Trigger:            trigger,
+		PreTokens:          preTokens,
+		CustomInstructions: instructions,
+	})
+}
+
+// emitCompactBoundary marks where the history was replaced by a summary.
+func (e *emitter) emitCompactBoundary(trigger string, preTokens, postTokens int) {
+	e.emit(&agent.AgentEvent{
+		Type:       eventCompactBoundary,
+		Trigger:    trigger,
+		PreTokens:  preTokens,
+		PostTokens: postTokens,
+	})
+}
+
+// emitPostCompact reports the summary that replaced the history.
+func (e *emitter) emitPostCompact(trigger, summary string, preTokens, postTokens int) {
+	e.emit(&agent.AgentEvent{
+		Type:           eventPostCompact,
+		Trigger:        trigger,
+		PreTokens:      preTokens,
+		PostTokens:     postTokens,
+		CompactSummary: summary,
+	})
+}
+
 // emitToolApprovalRequest asks the user to approve a tool execution.
 // Emits the same event shape that the frontend's useWebSocket.ts expects.
 func (e *emitter) emitToolApprovalRequest(requestID, toolName string, toolInput interface{}, specifier string) {
@@ -184,6 +240,22 @@ func (e *emitter) emitToolBatchApprovalRequest(requestID string, items []agent.B
 	})
 }
 
+// emitPermissionDecision records the final permission decision for a tool
+// call. The backend persists these as the permission audit log.
+func (e *emitter) emitPermissionDecision(toolUseID, toolName, specifier, decision, decidedBy, approvalAction, reason, mode string) {
+	e.emit(&agent.AgentEvent{
+		Type:               eventPermissionDecision,
+		ToolUseId:          toolUseID,
+		ToolName:           toolName,
+		Specifier:          specifier,
+		PermissionDecision: decision,
+		DecidedBy:          decidedBy,
+		ApprovalAction:     approvalAction,
+		Reason:             reason,
+		Mode:               mode,
+	})
+}
+
 // emitPermissionModeChanged signals a change in permission mode.
 func (e *emitter) emitPermissionModeChanged(mode string) {
 	e.emit(&agent.AgentEvent{
@@ -200,6 +272,14 @@ func (e *emitter) emitContextWarning(message string) {
 	})
 }
 
+// emitWarning surfaces a non-fatal notice to the user.
+func (e *emitter) emitWarning(message string) {
+	e.emit(&agent.AgentEvent{
+		Type:    eventWarning,
+		Message: message,
+	})
+}
+
 // emitSubagentStarted signals that a sub-agent has been spawned.
 func (e *emitter) emitSubagentStarted(agentId, description, parentToolUseId string) {
 	e.emit(&agent.AgentEvent{
@@ -212,6 +212,21 @@ func TestEmitter_EmitToolApprovalRequest_NilInput(t *testing.T) {
 	assert.Equal(t, "/etc/passwd", event.Specifier)
 }
 
+func TestEmitter_EmitPermissionDecision(t *testing.T) {
+	e, ch := newTestEmitter()
+	e.emitPermissionDecision("tu-1", "Bash", "npm test", "allow", "user", "allow_session", "", "default")
+
+	event := readEvent(t, ch)
+	assert.Equal(t, "permission_decision", event.Type)
+	assert.Equal(t, "tu-1", event.ToolUseId)
+	assert.Equal(t, "Bash", event.ToolName)
+	assert.Equal(t, "npm test", event.Specifier)
+	assert.Equal(t, "allow", event.PermissionDecision)
+	assert.Equal(t, "user", event.DecidedBy)
+	assert.Equal(t, "allow_session", event.ApprovalAction)
+	assert.Equal(t, "default", event.Mode)
+}
+
 func TestEmitter_SpecialCharacters(t *testing.T) {
 	e, ch := newTestEmitter()
 	e.emitAssistantText("Hello \"world\" \n\ttab & <html>")
@@ -13,10 +13,12 @@ import (
 	"github.com/chatml/chatml-core/agent"
 	"github.com/chatml/chatml-core/hook"
 	"github.com/chatml/chatml-core/mcp"
+	"github.com/chatml/chatml-core/paths"
 	"github.com/chatml/chatml-core/skills"
 	"github.com/chatml/chatml-core/permission"
 	"github.com/chatml/chatml-core/prompt"
 	"github.com/chatml/chatml-core/provider"
+	"github.com/chatml/chatml-core/redact"
 	"github.com/chatml/chatml-core/provider/anthropic"
 	ollamaprov "github.com/chatml/chatml-core/provider/ollama"
 	"github.com/chatml/chatml-core/provider/openai"
@@ -29,8 +31,14 @@ import (
 // with the full built-in tool set. Register with Manager.SetNativeBackendFactory at startup.
 func NewBackendFactory() agent.NativeBackendFactory {
 	return func(opts agent.ProcessOptions, apiKey, oauthToken string) (agent.ConversationBackend, error) {
+		// Managed (enterprise) policy: highest-priority rules and locked settings
+		policy := paths.LoadManagedPolicy()
+		if !policy.ModelAllowed(opts.Model) {
+			return nil, fmt.Errorf("model %q is not allowed by managed policy", opts.Model)
+		}
+
 		// Select provider based on model name
-		prov, err := createProvider(opts.Model, apiKey, oauthToken, opts.OllamaEndpoint)
+		prov, err := createProvider(opts.Model, apiKey, oauthToken, opts.OllamaEndpoint, opts.OllamaAuthToken)
 		if err != nil {
 			return nil, fmt.Errorf("create provider: %w", err)
 		}
@@ -46,15 +54,24 @@ func NewBackendFactory() agent.NativeBackendFactory {
 			}
 		}
 		if rules == nil {
-			// Load from all standard locations (user, project, local settings)
+			// Load from all standard locations (policy, user, project, local settings)
 			rules = permission.LoadMultiSourceRules(opts.Workdir)
+		} else {
+			// An explicit rules file replaces the settings sources, not the policy
+			rules.AddPolicyRules(permission.PolicyRules(policy))
 		}
 
 		mode := opts.PermissionMode
+		if mode == "" && policy != nil {
+			mode = policy.Permissions.DefaultMode
+		}
 		if mode == "" {
 			mode = permission.ModeBypassPermissions
 		}
 		permEngine := permission.NewEngineWithWorkdir(mode, rules, opts.Workdir)
+		if locked := policy.PermissionMode(); locked != "" {
+			permEngine.LockMode(locked)
+		}
 
 		// Pre-compute git config for enriched system prompt
 		promptCfg := prompt.BuilderConfig{
@@ -71,17 +88,27 @@ func NewBackendFactory() agent.NativeBackendFactory {
 		runner := NewRunnerFull(opts, provider.Provider(prov), nil, permEngine)
 		runner.promptBuilder = prompt.NewBuilderWithConfig(promptCfg)
 
+		// Scrub secrets (including values of the injected env vars) from tool output
+		if !opts.DisableSecretRedaction {
+			runner.redactor = redact.New(redact.Config{
+				Env:       opts.EnvVars,
+				Allowlist: opts.SecretRedactionAllowlist,
+			})
+		}
+
 		// Load skills from all standard locations
 		skillCatalog := skills.LoadAll(opts.Workdir)
 
 		// Create task manager for Tasks v2
 		taskMgr := task.NewManager()
+		runner.taskManager = taskMgr
 
 		// Create tool registry with callbacks wired to the runner
 		registry := tool.NewRegistry()
 		callbacks := &builtin.Callbacks{
 			WebSearchAPIKey: os.Getenv("BRAVE_SEARCH_API_KEY"),
 			WorkdirSetter:   runner, // Runner implements WorkdirSetter
+			Network:         runner, // Runner enforces egress rules
 			EmitEvent: func(eventType string, data interface{}) {
 				// Convert data to []TodoItem for todo_update events
 				if eventType == "todo_update" {
@@ -140,6 +167,7 @@ func NewBackendFactory() agent.NativeBackendFactory {
 
 		// Connect to external MCP servers and register their tools.
 		// Config sources in merge order (earlier name wins on collision):
+		// 0. managed settings mcpServers (when policy locks mcpServers, the only source)
 		// 1. .mcp.json (project-level, if trusted)
 		// 2. ~/.claude/settings.json mcpServers (user-level, always trusted)
 		// 3. .claude/settings.json mcpServers (project-level, if trusted)
@@ -156,8 +184,16 @@ func NewBackendFactory() agent.NativeBackendFactory {
 			}
 		}
 
+		// 0. Managed settings (always trusted)
+		if policy != nil && len(policy.MCPServers) > 0 {
+			if configs, err := mcp.ParseServerMap(policy.MCPServers); err == nil {
+				addConfigs(configs)
+			}
+		}
+		mcpLocked := policy.IsLocked(paths.LockMCPServers)
+
 		// 1. .mcp.json from workspace (project-level, gated by trust)
-		if opts.Workdir != "" && !opts.SkipDotMcp {
+		if opts.Workdir != "" && !opts.SkipDotMcp && !mcpLocked {
 			if configs, err := mcp.LoadMCPConfig(opts.Workdir); err != nil {
 				log.Printf("warning: failed to load .mcp.json: %v", err)
 			} else {
@@ -166,14 +202,14 @@ func NewBackendFactory() agent.NativeBackendFactory {
 		}
 
 		// 2. ~/.claude/settings.json (user-level, always trusted)
-		if home, err := os.UserHomeDir(); err == nil {
+		if home, err := os.UserHomeDir(); err == nil && !mcpLocked {
 			if configs, err := mcp.LoadMCPConfigFromSettings(filepath.Join(home, ".claude", "settings.json")); err == nil {
 				addConfigs(configs)
 			}
 		}
 
 		// 3. .claude/settings.json (project-level, gated by trust)
-		if opts.Workdir != "" && !opts.SkipDotMcp {
+		if opts.Workdir != "" && !opts.SkipDotMcp && !mcpLocked {
 			if configs, err := mcp.LoadMCPConfigFromSettings(filepath.Join(opts.Workdir, ".claude", "settings.json")); err == nil {
 				addConfigs(configs)
 			}
@@ -184,7 +220,7 @@ func NewBackendFactory() agent.NativeBackendFactory {
 		//    API only accepts well-formed server configs) and only the session
 		//    owner can write to their own settings. The Command field is
 		//    executed via exec.Command — do not expose this path to untrusted input.
-		if opts.McpServersJSON != "" {
+		if opts.McpServersJSON != "" && !mcpLocked {
 			var backendConfigs []mcp.ServerConfig
 			if err := json.Unmarshal([]byte(opts.McpServersJSON), &backendConfigs); err != nil {
 				log.Printf("warning: failed to parse McpServersJSON: %v", err)
@@ -202,6 +238,10 @@ func NewBackendFactory() agent.NativeBackendFactory {
 				log.Printf("warning: skipping MCP server %q (unsupported transport: %s)", cfg.Name, cfg.Type)
 				continue
 			}
+			if err := runner.routeMCPServerEgress(&cfg); err != nil {
+				log.Printf("warning: skipping MCP server %q: egress proxy failed: %v", cfg.Name, err)
+				continue
+			}
 			connCtx, connCancel := context.WithTimeout(context.Background(), 15*time.Second)
 			if _, err := mcpMgr.ConnectServer(connCtx, cfg); err != nil {
 				log.Printf("warning: failed to connect MCP server %q: %v", cfg.Name, err)
@@ -218,12 +258,21 @@ func NewBackendFactory() agent.NativeBackendFactory {
 		runner.mcpManager = mcpMgr
 
 		// Initialize hook engine from multiple sources:
+		// 0. Managed hooks (when policy locks hooks, the only source)
 		// 1. User-level hooks (~/.claude/settings.json)
 		// 2. Project-level hooks (.claude/hooks.json or .claude/settings.json or .chatml/config.json)
-		userHookConfig := hook.LoadUserConfig()
-		projectHookConfig := hook.LoadConfig(opts.Workdir)
-		mergedHookConfig := hook.MergeConfigs(userHookConfig, projectHookConfig)
+		var managedHookConfig hook.Config
+		if policy != nil && len(policy.Hooks) > 0 {
+			managedHookConfig = hook.ParseConfig(policy.Hooks)
+		}
+		mergedHookConfig := managedHookConfig
+		if !policy.IsLocked(paths.LockHooks) {
+			userHookConfig := hook.LoadUserConfig()
+			projectHookConfig := hook.LoadConfig(opts.Workdir)
+			mergedHookConfig = hook.MergeConfigs(managedHookConfig, userHookConfig, projectHookConfig)
+		}
 		runner.hookEngine = hook.NewEngine(opts.Workdir, mergedHookConfig)
+		runner.hookEngine.SetEvaluator(&hookEvaluator{runner: runner})
 
 		return runner, nil
 	}
@@ -233,14 +282,15 @@ func NewBackendFactory() agent.NativeBackendFactory {
 // Local models (gemma-4-*, ollama/*) use the Ollama provider.
 // OpenAI models (gpt-*, o1-/o1.*, o3-/o3.*, o4-/o4.*) use the OpenAI provider.
 // Everything else defaults to Anthropic.
-func createProvider(model, apiKey, oauthToken, ollamaEndpoint string) (provider.Provider, error) {
+func createProvider(model, apiKey, oauthToken, ollamaEndpoint, ollamaAuthToken string) (provider.Provider, error) {
 	if isLocalModel(model) {
 		if ollamaEndpoint == "" {
 			return nil, fmt.Errorf("ollama endpoint required for local model %q", model)
 		}
 		cfg := ollamaprov.Config{
-			Model:    toOllamaModelName(model),
-			Endpoint: ollamaEndpoint,
+			Model:     toOllamaModelName(model),
+			Endpoint:  ollamaEndpoint,
+			AuthToken: ollamaAuthToken,
 		}
 		return ollamaprov.New(cfg)
 	}
@@ -0,0 +1,217 @@
+package loop
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/chatml/chatml-core/hook"
+	"github.com/chatml/chatml-core/provider"
+)
+
+// External file change tracking. Files the agent has read are watched and
+// their last-seen content is kept in the delta tracker; when one changes on
+// disk for a reason other than the agent's own tools, the model is shown the
+// new content. Hook-requested watch paths (WatchPaths from SessionStart,
+// CwdChanged or FileChanged hooks) are watched too and fire FileChanged hooks.
+
+// fileEditingTools are tools through which the agent changes a single file
+// named in its input. Changes to that file are attributed to the agent.
+var fileEditingTools = map[string]bool{
+	"Edit":         true,
+	"Write":        true,
+	"NotebookEdit": true,
+}
+
+// startFileWatcher creates the session's file watcher. Failure (e.g. inotify
+// limits) only disables change tracking.
+func (r *Runner) startFileWatcher() {
+	if r.hookEngine == nil && r.readTracker == nil {
+		return
+	}
+	fw, err := newFileWatcher(r.onWatchedFileChanged)
+	if err != nil {
+		log.Printf("warning: file change tracking disabled: %v", err)
+		return
+	}
+	r.fileWatcher = fw
+}
+
+// onWatchedFileChanged runs FileChanged hooks for a changed path. Called from
+// the watcher, outside the loop goroutine.
+func (r *Runner) onWatchedFileChanged(path string) {
+	if r.hookEngine == nil || !r.hookEngine.HasHooks(hook.EventFileChanged) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), detachedHookTimeout)
+	defer cancel()
+	res, err := r.hookEngine.RunFileChanged(ctx, path)
+	if err != nil {
+		log.Printf("FileChanged hook error: %v", err)
+		return
+	}
+	if res != nil {
+		r.watchHookPaths(res.WatchPaths)
+		r.addPendingHookContext(res.AdditionalContexts...)
+	}
+}
+
+// watchHookPaths watches paths requested by hooks. Relative paths resolve
+// against the working directory; directories report changes to their files.
+func (r *Runner) watchHookPaths(paths []string) {
+	if r.fileWatcher == nil {
+		return
+	}
+	workdir := r.GetWorkdir()
+	for _, p := range paths {
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(workdir, p)
+		}
+		var err error
+		if info, statErr := os.Stat(p); statErr == nil && info.IsDir() {
+			err = r.fileWatcher.AddDir(p)
+		} else {
+			err = r.fileWatcher.AddFile(p)
+		}
+		if err != nil {
+			log.Printf("warning: cannot watch hook path %s: %v", p, err)
+		}
+	}
+}
+
+// syncWatchedFiles runs after each tool batch, which started at batchStart.
+// Files the agent has read start being watched with their current content as
+// the baseline, and files re-read or edited in this batch get a fresh
+// baseline so the agent's own edits aren't reported back to it. Bash can
+// write any file, so after a batch that ran it every tracked file is
+// re-baselined too, except those with a change pending from before the batch:
+// those changes are external and are still reported.
+func (r *Runner) syncWatchedFiles(toolCalls []provider.ToolUseBlock, batchStart time.Time) {
+	if r.fileWatcher == nil {
+		return
+	}
+
+	ranBash := false
+	for _, tc := range toolCalls {
+		if tc.Name == "Bash" {
+			ranBash = true
+		}
+		if tc.Name != "Read" && !fileEditingTools[tc.Name] {
+			continue
+		}
+		var in struct {
+			FilePath     string `json:"file_path"`
+			NotebookPath string `json:"notebook_path"`
+		}
+		if json.Unmarshal(tc.Input, &in) != nil {
+			continue
+		}
+		path := in.FilePath
+		if path == "" {
+			path = in.NotebookPath
+		}
+		if path == "" || !r.fileWatcher.IsWatching(path) {
+			continue
+		}
+		if tc.Name != "Read" {
+			r.fileWatcher.Forget(path)
+		}
+		r.recordFileState(path)
+	}
+	if ranBash {
+		r.fileWatcher.Forget(r.fileWatcher.ChangedSince(batchStart)...)
+		for _, p := range r.deltaTracker.TrackedPaths() {
+			if !r.fileWatcher.IsChanged(p) {
+				r.recordFileState(p)
+			}
+		}
+	}
+
+	if r.readTracker == nil {
+		return
+	}
+	for _, p := range r.readTracker.RecentFiles(maxWatchedFiles) {
+		if r.fileWatcher.IsWatching(p) {
+			continue
+		}
+		if err := r.fileWatcher.AddFile(p); err != nil {
+			continue // Watch limit reached or directory gone
+		}
+		r.recordFileState(p)
+	}
+}
+
+func (r *Runner) recordFileState(path string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	r.deltaTracker.RecordFileState(filepath.Clean(path), string(data))
+}
+
+// externalChangeReminder returns a system reminder describing files the agent
+// has read that changed on disk since it last saw them, or "" if none did.
+// Reported files get their new content as the baseline.
+func (r *Runner) externalChangeReminder() string {
+	if r.fileWatcher == nil {
+		return ""
+	}
+
+	var changed, deleted []string
+	contents := make(map[string]string)
+	for _, p := range r.fileWatcher.TakeChanged() {
+		if !r.deltaTracker.IsTracked(p) {
+			continue // Hook-only path the agent never read
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			if os.IsNotExist(err) {
+				deleted = append(deleted, p)
+			}
+			continue
+		}
+		if r.deltaTracker.HasChanged(p, string(data)) {
+			changed = append(changed, p)
+			contents[p] = string(data)
+		}
+	}
+	if len(changed) == 0 && len(deleted) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("<system-reminder>\n")
+	if len(changed) > 0 {
+		b.WriteString("These files were modified outside this conversation (by the user or another process) since you last read them. " +
+			"Their current contents are below. Take the changes into account and don't revert them unless asked.\n\n")
+		for _, msg := range r.deltaTracker.GenerateDeltaMessages(changed) {
+			for _, block := range msg.Content {
+				b.WriteString(block.Text)
+			}
+		}
+		for _, p := range changed {
+			r.deltaTracker.RecordFileState(p, contents[p])
+		}
+	}
+	for _, p := range deleted {
+		b.WriteString("Note: " + p + " was deleted outside this conversation.\n")
+	}
+	b.WriteString("</system-reminder>")
+	return b.String()
+}
+
+// appendToLastToolResult adds text to the last tool result in a tool result
+// message. Used instead of a separate text block because not every provider
+// accepts text mixed with tool results.
+func appendToLastToolResult(msg *provider.Message, text string) {
+	for i := len(msg.Content) - 1; i >= 0; i-- {
+		if msg.Content[i].Type == provider.BlockToolResult {
+			msg.Content[i].ResultContent += "\n\n" + text
+			return
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package loop
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// maxWatchedFiles caps how many files a session watches, so long sessions
+// that read hundreds of files don't exhaust inotify/kqueue resources.
+const maxWatchedFiles = 100
+
+// fileChangeDebounce coalesces the burst of events a single save produces
+// (truncate + write, or write-to-temp + rename) into one change.
+const fileChangeDebounce = 100 * time.Millisecond
+
+// fileWatcher watches individual files, plus whole directories when asked to,
+// and reports changes to them. fsnotify watches directories, so each file is
+// watched through its parent; this also catches editors that save by renaming
+// a temp file over the original.
+type fileWatcher struct {
+	mu       sync.Mutex
+	watcher  *fsnotify.Watcher
+	files    map[string]bool      // Watched file paths
+	dirs     map[string]bool      // Directories watched for any child change
+	parents  map[string]int       // Parent dir -> number of watched files in it
+	changed  map[string]time.Time // Paths changed since the last TakeChanged -> first change
+	timers   map[string]*time.Timer
+	onChange func(path string) // Called (debounced) from a timer goroutine
+	inflight sync.WaitGroup    // onChange calls in progress
+	closed   bool
+	done     chan struct{}
+}
+
+// newFileWatcher starts a watcher. onChange may be nil.
+func newFileWatcher(onChange func(path string)) (*fileWatcher, error) {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("create fsnotify watcher: %w", err)
+	}
+	fw := &fileWatcher{
+		watcher:  w,
+		files:    make(map[string]bool),
+		dirs:     make(map[string]bool),
+		parents:  make(map[string]int),
+		changed:  make(map[string]time.Time),
+		timers:   make(map[string]*time.Timer),
+		onChange: onChange,
+		done:     make(chan struct{}),
+	}
+	go fw.run()
+	return fw, nil
+}
+
+// AddFile starts watching a file. The file doesn't need to exist yet, but its
+// parent directory does. Adding a watched file again is a no-op.
+func (fw *fileWatcher) AddFile(path string) error {
+	path = filepath.Clean(path)
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+	if fw.closed || fw.files[path] {
+		return nil
+	}
+	if len(fw.files) >= maxWatchedFiles {
+		return fmt.Errorf("watch limit of %d files reached", maxWatchedFiles)
+	}
+	parent := filepath.Dir(path)
+	if fw.parents[parent] == 0 && !fw.dirs[parent] {
+		if err := fw.watcher.Add(parent); err != nil {
+			return fmt.Errorf("watch %s: %w", parent, err)
+		}
+	}
+	fw.parents[parent]++
+	fw.files[path] = true
+	return nil
+}
+
+// AddDir watches a directory; changes to any file directly inside it are reported.
+func (fw *fileWatcher) AddDir(dir string) error {
+	dir = filepath.Clean(dir)
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+	if fw.closed || fw.dirs[dir] {
+		return nil
+	}
+	if fw.parents[dir] == 0 {
+		if err := fw.watcher.Add(dir); err != nil {
+			return fmt.Errorf("watch %s: %w", dir, err)
+		}
+	}
+	fw.dirs[dir] = true
+	return nil
+}
+
+// IsWatching reports whether path is watched as a file.
+func (fw *fileWatcher) IsWatching(path string) bool {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+	return fw.files[filepath.Clean(path)]
+}
+
+// Files returns the watched file paths, sorted.
+func (fw *fileWatcher) Files() []string {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+	files := make([]string, 0, len(fw.files))
+	for p := range fw.files {
+		files = append(files, p)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// TakeChanged returns the paths that changed since the last call, sorted, and
+// clears the set.
+func (fw *fileWatcher) TakeChanged() []string {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+	if len(fw.changed) == 0 {
+		return nil
+	}
+	paths := make([]string, 0, len(fw.changed))
+	for p := range fw.changed {
+		paths = append(paths, p)
+	}
+	fw.changed = make(map[string]time.Time)
+	sort.Strings(paths)
+	return paths
+}
+
+// ChangedSince returns the pending changed paths whose first change was seen
+// at or after t, sorted. The set is left as is.
+func (fw *fileWatcher) ChangedSince(t time.Time) []string {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+	var paths []string
+	for p, at := range fw.changed {
+		if !at.Before(t) {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// IsChanged reports whether path has a pending change.
+func (fw *fileWatcher) IsChanged(path string) bool {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+	_, ok := fw.changed[filepath.Clean(path)]
+	return ok
+}
+
+// Forget drops pending changes to the given paths.
+func (fw *fileWatcher) Forget(paths ...string) {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+	for _, p := range paths {
+		delete(fw.changed, filepath.Clean(p))
+	}
+}
+
+// Close stops the watcher. No onChange calls start after Close returns.
+func (fw *fileWatcher) Close() error {
+	fw.mu.Lock()
+	if fw.closed {
+		fw.mu.Unlock()
+		return nil
+	}
+	fw.closed = true
+	for _, t := range fw.timers {
+		t.Stop()
+	}
+	fw.mu.Unlock()
+
+	err := fw.watcher.Close()
+	<-fw.done
+	fw.inflight.Wait()
+	return err
+}
+
+func (fw *fileWatcher) run() {
+	defer close(fw.done)
+	for {
+		select {
+		case event, ok := <-fw.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Remove|fsnotify.Rename) != 0 {
+				fw.handleEvent(filepath.Clean(event.Name))
+			}
+		case _, ok := <-fw.watcher.Errors:
+			if !ok {
+				return
+			}
+		}
+	}
+}
+
+func (fw *fileWatcher) handleEvent(path string) {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+	if fw.closed || (!fw.files[path] && !fw.dirs[filepath.Dir(path)]) {
+		return
+	}
+	if t, ok := fw.timers[path]; ok {
+		t.Reset(fileChangeDebounce)
+		return
+	}
+	fw.timers[path] = time.AfterFunc(fileChangeDebounce, func() {
+		fw.mu.Lock()
+		delete(fw.timers, path)
+		if fw.closed {
+			fw.mu.Unlock()
+			return
+		}
+		if _, ok := fw.changed[path]; !ok {
+			fw.changed[path] = time.Now()
+		}
+		onChange := fw.onChange
+		fw.inflight.Add(1)
+		fw.mu.Unlock()
+		defer fw.inflight.Done()
+		if onChange != nil {
+			onChange(path)
+		}
+	})
+}
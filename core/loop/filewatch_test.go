@@ -0,0 +1,213 @@
+package loop
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/chatml/chatml-core/hook"
+	"github.com/chatml/chatml-core/provider"
+	"github.com/chatml/chatml-core/tool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// waitForChange polls the watcher until it reports changes or times out.
+func waitForChange(t *testing.T, fw *fileWatcher) []string {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		if changed := fw.TakeChanged(); len(changed) > 0 {
+			return changed
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("timed out waiting for file change")
+	return nil
+}
+
+func TestFileWatcher_ReportsWatchedFileOnly(t *testing.T) {
+	dir := t.TempDir()
+	watched := filepath.Join(dir, "a.txt")
+	other := filepath.Join(dir, "b.txt")
+	require.NoError(t, os.WriteFile(watched, []byte("one"), 0644))
+
+	calls := make(chan string, 10)
+	fw, err := newFileWatcher(func(path string) { calls <- path })
+	require.NoError(t, err)
+	defer fw.Close()
+	require.NoError(t, fw.AddFile(watched))
+
+	require.NoError(t, os.WriteFile(other, []byte("x"), 0644))
+	for i := 0; i < 3; i++ {
+		require.NoError(t, os.WriteFile(watched, []byte("two"), 0644))
+	}
+
+	assert.Equal(t, []string{watched}, waitForChange(t, fw))
+	assert.Equal(t, watched, <-calls)
+	time.Sleep(2 * fileChangeDebounce)
+	assert.Empty(t, calls, "burst of writes should be debounced into one change")
+	assert.Empty(t, fw.TakeChanged())
+}
+
+func TestFileWatcher_DirReportsChildren(t *testing.T) {
+	dir := t.TempDir()
+	fw, err := newFileWatcher(nil)
+	require.NoError(t, err)
+	defer fw.Close()
+	require.NoError(t, fw.AddDir(dir))
+
+	path := filepath.Join(dir, "new.env")
+	require.NoError(t, os.WriteFile(path, []byte("X=1"), 0644))
+
+	assert.Equal(t, []string{path}, waitForChange(t, fw))
+	assert.False(t, fw.IsWatching(path))
+}
+
+func TestFileWatcher_Limit(t *testing.T) {
+	dir := t.TempDir()
+	fw, err := newFileWatcher(nil)
+	require.NoError(t, err)
+	defer fw.Close()
+
+	for i := 0; i < maxWatchedFiles; i++ {
+		require.NoError(t, fw.AddFile(filepath.Join(dir, "f"+string(rune('a'+i%26))+string(rune('a'+i/26)))))
+	}
+	assert.Error(t, fw.AddFile(filepath.Join(dir, "one-too-many")))
+	assert.Len(t, fw.Files(), maxWatchedFiles)
+}
+
+func newWatchingRunner(t *testing.T, cfg hook.Config) *Runner {
+	t.Helper()
+	r := newHookedRunner(t, nil, cfg)
+	r.readTracker = tool.NewReadTracker()
+	r.startFileWatcher()
+	require.NotNil(t, r.fileWatcher)
+	t.Cleanup(func() { r.fileWatcher.Close() })
+	return r
+}
+
+func readCall(path string) provider.ToolUseBlock {
+	input, _ := json.Marshal(map[string]string{"file_path": path})
+	return provider.ToolUseBlock{ID: "r1", Name: "Read", Input: input}
+}
+
+func TestFileChanges_ExternalEditIsReported(t *testing.T) {
+	r := newWatchingRunner(t, hook.Config{})
+	path := filepath.Join(t.TempDir(), "main.go")
+	require.NoError(t, os.WriteFile(path, []byte("package main\n"), 0644))
+
+	r.readTracker.MarkRead(path)
+	r.syncWatchedFiles([]provider.ToolUseBlock{readCall(path)}, time.Now())
+	require.True(t, r.fileWatcher.IsWatching(path))
+
+	require.NoError(t, os.WriteFile(path, []byte("package main\n\nfunc main() {}\n"), 0644))
+	require.Eventually(t, func() bool {
+		r.fileWatcher.mu.Lock()
+		defer r.fileWatcher.mu.Unlock()
+		return len(r.fileWatcher.changed) > 0
+	}, 3*time.Second, 20*time.Millisecond)
+
+	reminder := r.externalChangeReminder()
+	assert.Contains(t, reminder, "modified outside this conversation")
+	assert.Contains(t, reminder, "func main() {}")
+	assert.Empty(t, r.externalChangeReminder(), "a change is reported once")
+}
+
+func TestFileChanges_OwnEditIsNotReported(t *testing.T) {
+	r := newWatchingRunner(t, hook.Config{})
+	path := filepath.Join(t.TempDir(), "main.go")
+	require.NoError(t, os.WriteFile(path, []byte("package main\n"), 0644))
+	r.readTracker.MarkRead(path)
+	r.syncWatchedFiles(nil, time.Now())
+
+	// The agent edits the file; the batch containing the Edit re-baselines it.
+	require.NoError(t, os.WriteFile(path, []byte("package main // edited\n"), 0644))
+	time.Sleep(3 * fileChangeDebounce)
+	r.syncWatchedFiles([]provider.ToolUseBlock{editCall(path)}, time.Now())
+
+	assert.Empty(t, r.externalChangeReminder())
+}
+
+func editCall(path string) provider.ToolUseBlock {
+	input, _ := json.Marshal(map[string]string{"file_path": path})
+	return provider.ToolUseBlock{ID: "e1", Name: "Edit", Input: input}
+}
+
+// watchRead writes path, marks it read and starts watching it.
+func watchRead(t *testing.T, r *Runner, path, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	r.readTracker.MarkRead(path)
+	r.syncWatchedFiles(nil, time.Now())
+	require.True(t, r.fileWatcher.IsWatching(path))
+}
+
+func TestFileChanges_EditKeepsExternalChangesToOtherFiles(t *testing.T) {
+	r := newWatchingRunner(t, hook.Config{})
+	dir := t.TempDir()
+	edited, external := filepath.Join(dir, "a.go"), filepath.Join(dir, "b.go")
+	watchRead(t, r, edited, "package a\n")
+	watchRead(t, r, external, "package b\n")
+
+	require.NoError(t, os.WriteFile(external, []byte("package b // by the user\n"), 0644))
+	require.NoError(t, os.WriteFile(edited, []byte("package a // by the agent\n"), 0644))
+	time.Sleep(3 * fileChangeDebounce)
+	r.syncWatchedFiles([]provider.ToolUseBlock{editCall(edited)}, time.Now())
+
+	reminder := r.externalChangeReminder()
+	assert.Contains(t, reminder, "by the user")
+	assert.NotContains(t, reminder, "by the agent")
+}
+
+func TestFileChanges_BashKeepsChangesFromBeforeTheBatch(t *testing.T) {
+	r := newWatchingRunner(t, hook.Config{})
+	dir := t.TempDir()
+	written, external := filepath.Join(dir, "gen.go"), filepath.Join(dir, "main.go")
+	watchRead(t, r, written, "package gen\n")
+	watchRead(t, r, external, "package main\n")
+
+	// The user edits one file, then the agent runs a command writing the other.
+	require.NoError(t, os.WriteFile(external, []byte("package main // by the user\n"), 0644))
+	time.Sleep(3 * fileChangeDebounce)
+	batchStart := time.Now()
+	require.NoError(t, os.WriteFile(written, []byte("package gen // generated\n"), 0644))
+	time.Sleep(3 * fileChangeDebounce)
+	bash := provider.ToolUseBlock{ID: "b1", Name: "Bash", Input: json.RawMessage(`{"command":"go generate"}`)}
+	r.syncWatchedFiles([]provider.ToolUseBlock{bash}, batchStart)
+
+	reminder := r.externalChangeReminder()
+	assert.Contains(t, reminder, "by the user")
+	assert.NotContains(t, reminder, "generated")
+}
+
+func TestFileChanges_HookWatchPathsFireFileChanged(t *testing.T) {
+	dir := t.TempDir()
+	envFile := filepath.Join(dir, ".envrc")
+	out := filepath.Join(dir, "events")
+	r := newWatchingRunner(t, hook.Config{Hooks: map[string][]hook.MatcherGroup{
+		hook.EventFileChanged: {{Hooks: []hook.HookDef{{Command: `cat >> ` + out + `; echo '{"additionalContext":"env reloaded"}'`}}}},
+	}})
+
+	r.watchHookPaths([]string{envFile})
+	require.NoError(t, os.WriteFile(envFile, []byte("export A=1"), 0644))
+
+	require.Eventually(t, func() bool {
+		data, _ := os.ReadFile(out)
+		return len(data) > 0
+	}, 3*time.Second, 20*time.Millisecond)
+	require.Eventually(t, func() bool {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		return len(r.pendingHookContext) > 0
+	}, 3*time.Second, 20*time.Millisecond)
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"event":"FileChanged"`)
+	assert.Contains(t, string(data), envFile)
+	assert.Equal(t, []string{"env reloaded"}, r.takePendingHookContext())
+	assert.Empty(t, r.externalChangeReminder(), "hook-only paths aren't reported to the model")
+}
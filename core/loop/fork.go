@@ -0,0 +1,145 @@
+package loop
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/chatml/chatml-core/provider"
+)
+
+// TurnStarts returns the indexes of the messages that start a user turn: user
+// messages carrying text or images, as opposed to tool results.
+func TurnStarts(messages []provider.Message) []int {
+	var starts []int
+	for i, msg := range messages {
+		if msg.Role != provider.RoleUser {
+			continue
+		}
+		for _, block := range msg.Content {
+			if block.Type == provider.BlockText || block.Type == provider.BlockImage {
+				starts = append(starts, i)
+				break
+			}
+		}
+	}
+	return starts
+}
+
+// TurnBoundary returns how many leading messages make up the first turns user
+// turns, including the assistant's replies and tool calls. A negative turns,
+// or more turns than the messages hold, covers all messages.
+func TurnBoundary(messages []provider.Message, turns int) int {
+	starts := TurnStarts(messages)
+	if turns < 0 || turns >= len(starts) {
+		return len(messages)
+	}
+	return starts[turns]
+}
+
+// ForkTranscript creates the transcript of session newID as a branch of
+// sourceID: it copies the source's first keep messages (all when keep <= 0)
+// and records the fork in the new transcript's metadata. Entries of the new
+// transcript carry sourceID as their parent. Fails if newID already has a
+// transcript. Returns the new transcript's path.
+func ForkTranscript(dir, sourceID, newID string, keep int) (string, error) {
+	if sourceID == newID {
+		return "", fmt.Errorf("cannot fork session %s into itself", sourceID)
+	}
+	sourcePath := FindTranscript(dir, sourceID)
+	if sourcePath == "" {
+		return "", fmt.Errorf("transcript for session %s not found", sourceID)
+	}
+	if FindTranscript(dir, newID) != "" {
+		return "", fmt.Errorf("transcript for session %s already exists", newID)
+	}
+
+	msgs, meta, err := ReadTranscript(sourcePath)
+	if err != nil {
+		return "", err
+	}
+	if keep <= 0 || keep > len(msgs) {
+		keep = len(msgs)
+	}
+	msgs = msgs[:keep]
+
+	forkMeta := TranscriptMeta{CreatedAt: time.Now(), ForkedFrom: sourceID, ForkPoint: keep}
+	if meta != nil {
+		forkMeta.Model = meta.Model
+		forkMeta.Title = meta.Title
+		forkMeta.Tags = meta.Tags
+	}
+
+	tw, err := NewTranscriptWriter(dir, newID, sourceID)
+	if err != nil {
+		return "", err
+	}
+	err = tw.WriteMetadata(forkMeta)
+	for i := 0; err == nil && i < len(msgs); i++ {
+		err = tw.WriteMessage(msgs[i])
+	}
+	if closeErr := tw.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tw.Path()) //nolint:errcheck
+		return "", fmt.Errorf("fork transcript: %w", err)
+	}
+	return tw.Path(), nil
+}
+
+// TranscriptNode is a transcript in the fork tree built by TranscriptTree.
+type TranscriptNode struct {
+	TranscriptSummary
+	Forks []*TranscriptNode `json:"forks,omitempty"`
+}
+
+// TranscriptTree arranges transcripts by fork relationship. Roots are
+// transcripts that were not forked, or whose source no longer exists; forks
+// are listed under their source, oldest first.
+func TranscriptTree(summaries []TranscriptSummary) []*TranscriptNode {
+	nodes := make(map[string]*TranscriptNode, len(summaries))
+	for _, s := range summaries {
+		nodes[s.SessionID] = &TranscriptNode{TranscriptSummary: s}
+	}
+
+	var roots []*TranscriptNode
+	for _, s := range summaries {
+		node := nodes[s.SessionID]
+		if parent, ok := nodes[s.ForkedFrom]; ok && s.ForkedFrom != s.SessionID {
+			parent.Forks = append(parent.Forks, node)
+		} else {
+			roots = append(roots, node)
+		}
+	}
+	for _, node := range nodes {
+		sort.SliceStable(node.Forks, func(i, j int) bool {
+			return node.Forks[i].CreatedAt.Before(node.Forks[j].CreatedAt)
+		})
+	}
+	return roots
+}
+
+// readTranscriptMeta returns the metadata stored in a transcript's first line,
+// or nil if there is none.
+func readTranscriptMeta(path string) *TranscriptMeta {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10<<20)
+	if !scanner.Scan() {
+		return nil
+	}
+	var entry TranscriptEntry
+	if json.Unmarshal(scanner.Bytes(), &entry) != nil {
+		return nil
+	}
+	return entry.Metadata
+}
@@ -0,0 +1,100 @@
+package loop
+
+import (
+	"testing"
+
+	"github.com/chatml/chatml-core/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// threeTurns is a history of three user turns; the second one uses a tool.
+func threeTurns() []provider.Message {
+	text := func(role provider.Role, s string) provider.Message {
+		return provider.Message{Role: role, Content: []provider.ContentBlock{provider.NewTextBlock(s)}}
+	}
+	msgs := []provider.Message{text(provider.RoleUser, "one"), text(provider.RoleAssistant, "ok one")}
+	msgs = append(msgs, toolTurn("Read", "main.go")...)
+	msgs = append(msgs, text(provider.RoleAssistant, "read it"), text(provider.RoleUser, "three"), text(provider.RoleAssistant, "ok three"))
+	return msgs
+}
+
+func writeTranscript(t *testing.T, dir, sessionID string, msgs []provider.Message) {
+	t.Helper()
+	tw, err := NewTranscriptWriter(dir, sessionID, "")
+	require.NoError(t, err)
+	require.NoError(t, tw.WriteMetadata(TranscriptMeta{Model: "claude-sonnet-4-6", Title: "Migrations"}))
+	for _, m := range msgs {
+		require.NoError(t, tw.WriteMessage(m))
+	}
+	require.NoError(t, tw.Close())
+}
+
+func TestTurnBoundary(t *testing.T) {
+	msgs := threeTurns()
+	assert.Equal(t, []int{0, 2, 6}, TurnStarts(msgs), "tool results don't start a turn")
+	assert.Equal(t, 0, TurnBoundary(msgs, 0))
+	assert.Equal(t, 2, TurnBoundary(msgs, 1))
+	assert.Equal(t, 6, TurnBoundary(msgs, 2))
+	assert.Equal(t, len(msgs), TurnBoundary(msgs, 3))
+	assert.Equal(t, len(msgs), TurnBoundary(msgs, -1))
+}
+
+func TestForkTranscript(t *testing.T) {
+	dir := t.TempDir()
+	msgs := threeTurns()
+	writeTranscript(t, dir, "main", msgs)
+
+	_, err := ForkTranscript(dir, "main", "branch", TurnBoundary(msgs, 2))
+	require.NoError(t, err)
+
+	forked, meta, err := ReadTranscript(FindTranscript(dir, "branch"))
+	require.NoError(t, err)
+	assert.Equal(t, msgs[:6], forked)
+	require.NotNil(t, meta)
+	assert.Equal(t, "main", meta.ForkedFrom)
+	assert.Equal(t, 6, meta.ForkPoint)
+	assert.Equal(t, "Migrations", meta.Title)
+
+	_, err = ForkTranscript(dir, "main", "branch", 0)
+	assert.Error(t, err, "fork target already exists")
+	_, err = ForkTranscript(dir, "missing", "other", 0)
+	assert.Error(t, err)
+	_, err = ForkTranscript(dir, "main", "main", 0)
+	assert.Error(t, err)
+
+	summaries, err := ListTranscripts(dir)
+	require.NoError(t, err)
+	tree := TranscriptTree(summaries)
+	require.Len(t, tree, 1)
+	assert.Equal(t, "main", tree[0].SessionID)
+	require.Len(t, tree[0].Forks, 1)
+	assert.Equal(t, "branch", tree[0].Forks[0].SessionID)
+	assert.Equal(t, 6, tree[0].Forks[0].ForkPoint)
+}
+
+func TestRunner_ForkSessionAtTurn(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	opts := defaultOpts()
+	opts.Workdir = t.TempDir()
+	msgs := threeTurns()
+	writeTranscript(t, TranscriptDir(opts.Workdir), "main", msgs)
+
+	opts.ResumeSession = "main"
+	opts.ForkSession = true
+	opts.ForkAtTurn = 1
+	r := NewRunner(opts, &textProvider{})
+	require.NoError(t, r.Start())
+	r.Stop()
+	<-r.Done()
+
+	assert.Equal(t, msgs[:2], r.messages)
+	forked, meta, err := ReadTranscript(FindTranscript(TranscriptDir(opts.Workdir), opts.SdkSessionID))
+	require.NoError(t, err)
+	assert.Equal(t, msgs[:2], forked)
+	assert.Equal(t, "main", meta.ForkedFrom)
+
+	source, _, err := ReadTranscript(FindTranscript(TranscriptDir(opts.Workdir), "main"))
+	require.NoError(t, err)
+	assert.Len(t, source, len(msgs), "source transcript untouched")
+}
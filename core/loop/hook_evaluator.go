@@ -0,0 +1,88 @@
+package loop
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/chatml/chatml-core/provider"
+	"github.com/chatml/chatml-core/tool/builtin"
+)
+
+// hookAgentTools are the tools available to "agent" hooks: enough to inspect
+// the workspace, nothing that changes it.
+var hookAgentTools = []string{"Read", "Glob", "Grep"}
+
+// hookAgentMaxTurns bounds an agent hook's investigation.
+const hookAgentMaxTurns = 15
+
+// hookEvaluator runs "prompt" and "agent" hooks with the runner's provider.
+// Implements hook.Evaluator.
+type hookEvaluator struct {
+	runner *Runner
+}
+
+// EvaluatePrompt makes a single tool-less model call.
+func (h *hookEvaluator) EvaluatePrompt(ctx context.Context, systemPrompt, userPrompt, model string) (string, error) {
+	if h.runner.provider == nil {
+		return "", fmt.Errorf("no provider configured")
+	}
+	req := provider.ChatRequest{
+		Model:        h.model(model),
+		SystemPrompt: systemPrompt,
+		Messages: []provider.Message{
+			{
+				Role:    provider.RoleUser,
+				Content: []provider.ContentBlock{provider.NewTextBlock(userPrompt)},
+			},
+		},
+		MaxTokens: 1000,
+	}
+
+	stream, err := h.runner.provider.StreamChat(ctx, req)
+	if err != nil {
+		return "", fmt.Errorf("hook LLM call failed: %w", err)
+	}
+	var result strings.Builder
+	for event := range stream {
+		if event.Type == provider.EventTextDelta {
+			result.WriteString(event.Text)
+		}
+		if event.Type == provider.EventError && event.Error != nil {
+			for range stream {
+			}
+			return "", fmt.Errorf("hook LLM stream error: %w", event.Error)
+		}
+	}
+	return result.String(), nil
+}
+
+// RunAgent runs a read-only sub-agent and returns its final output.
+func (h *hookEvaluator) RunAgent(ctx context.Context, systemPrompt, userPrompt, model string) (string, error) {
+	if h.runner.toolRegistry == nil {
+		return "", fmt.Errorf("no tools available for agent hook")
+	}
+	res, err := h.runner.SpawnSubAgent(ctx, builtin.SubAgentOpts{
+		Prompt:      systemPrompt + "\n\n" + userPrompt,
+		Model:       h.model(model),
+		Tools:       hookAgentTools,
+		MaxTurns:    hookAgentMaxTurns,
+		Description: "Hook verification",
+	})
+	if err != nil {
+		return "", err
+	}
+	return res.Output, nil
+}
+
+// model picks the model for a hook: the hook's own choice, else a small model
+// when the provider is Anthropic, else the provider's default (the session model).
+func (h *hookEvaluator) model(override string) string {
+	if override != "" {
+		return resolveModelAlias(override)
+	}
+	if h.runner.provider != nil && h.runner.provider.Name() == "anthropic" {
+		return resolveModelAlias("haiku")
+	}
+	return ""
+}
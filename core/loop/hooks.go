@@ -0,0 +1,338 @@
+package loop
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/chatml/chatml-core/hook"
+	"github.com/chatml/chatml-core/permission"
+	"github.com/chatml/chatml-core/prompt"
+	"github.com/chatml/chatml-core/provider"
+	"github.com/chatml/chatml-core/task"
+)
+
+// Hook lifecycle wiring for the Runner. The tool hooks (PreToolUse,
+// PostToolUse, PostToolUseFailure) run inline in executeTools and the compact
+// and subagent hooks run where those happen; this file covers the remaining
+// events. TeammateIdle, Elicitation and ElicitationResult are never fired: the
+// native runner has no teammates and its MCP client does not support
+// elicitation.
+
+// maxStopHookContinuations bounds how many times Stop hooks can send the agent
+// back to work within one turn, so a hook that always blocks can't loop forever.
+const maxStopHookContinuations = 3
+
+// detachedHookTimeout bounds hooks that run outside a turn context
+// (notifications, config changes, failure reporting).
+const detachedHookTimeout = 30 * time.Second
+
+// hookBlockReason returns the combined reason when any hook in the result
+// blocked (decision "block", exit code 2 or continue=false).
+func hookBlockReason(res *hook.AggregatedResult) (string, bool) {
+	if res == nil || len(res.BlockingErrors) == 0 {
+		return "", false
+	}
+	msgs := make([]string, len(res.BlockingErrors))
+	for i, be := range res.BlockingErrors {
+		msgs[i] = be.Message
+	}
+	return strings.Join(msgs, "\n"), true
+}
+
+// hookContextReminder wraps hook additionalContext for injection into a user message.
+func hookContextReminder(contexts []string) string {
+	return "<system-reminder>\n" + strings.Join(contexts, "\n") + "\n</system-reminder>"
+}
+
+// takePendingHookContext returns and clears context collected from hooks that
+// fire outside a turn (SessionStart, Setup, CwdChanged, FileChanged). It is
+// attached to the next user message.
+func (r *Runner) takePendingHookContext() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	pending := r.pendingHookContext
+	r.pendingHookContext = nil
+	return pending
+}
+
+// addPendingHookContext queues hook context for the next user message.
+func (r *Runner) addPendingHookContext(contexts ...string) {
+	if len(contexts) == 0 {
+		return
+	}
+	r.mu.Lock()
+	r.pendingHookContext = append(r.pendingHookContext, contexts...)
+	r.mu.Unlock()
+}
+
+// runStartupHooks fires Setup (new sessions only), InstructionsLoaded and
+// SessionStart. It returns the initial user message requested by a hook, and
+// ok=false when a hook stopped the session from continuing.
+func (r *Runner) runStartupHooks(ctx context.Context, sessionID string) (initialMessage string, ok bool) {
+	if r.hookEngine == nil {
+		return "", true
+	}
+
+	var results []*hook.AggregatedResult
+	if r.opts.ResumeSession == "" {
+		res, err := r.hookEngine.RunSetup(ctx, sessionID, "init")
+		if err != nil {
+			log.Printf("Setup hook error: %v", err)
+		}
+		results = append(results, res)
+	}
+
+	if r.hookEngine.HasHooks(hook.EventInstructionsLoad) {
+		for _, entry := range prompt.LoadClaudeMD(r.GetWorkdir()) {
+			if err := r.hookEngine.RunInstructionsLoaded(ctx, entry.Path); err != nil {
+				log.Printf("InstructionsLoaded hook error: %v", err)
+			}
+		}
+	}
+
+	res, err := r.hookEngine.RunSessionStart(ctx, sessionID)
+	if err != nil {
+		log.Printf("SessionStart hook error: %v", err)
+	}
+	results = append(results, res)
+
+	for _, res := range results {
+		if res == nil {
+			continue
+		}
+		if res.PreventContinue {
+			log.Printf("Startup hook prevented continuation: %s", res.StopReason)
+			return "", false
+		}
+		r.addPendingHookContext(res.AdditionalContexts...)
+		r.watchHookPaths(res.WatchPaths)
+		if initialMessage == "" {
+			initialMessage = res.InitialUserMessage
+		}
+	}
+	return initialMessage, true
+}
+
+// runUserPromptSubmitHooks runs UserPromptSubmit hooks for a new prompt. It
+// returns context to attach to the prompt, or blocked=true when a hook rejected
+// it (the reason is surfaced to the user and the prompt is dropped).
+func (r *Runner) runUserPromptSubmitHooks(ctx context.Context, promptText string) (contexts []string, blocked bool) {
+	if r.hookEngine == nil {
+		return nil, false
+	}
+	res, err := r.hookEngine.RunUserPromptSubmit(ctx, promptText)
+	if err != nil {
+		log.Printf("UserPromptSubmit hook error: %v", err)
+		return nil, false
+	}
+	if reason, blocked := hookBlockReason(res); blocked {
+		r.emitter.emitError("Prompt blocked by hook: " + reason)
+		return nil, true
+	}
+	if res == nil {
+		return nil, false
+	}
+	return res.AdditionalContexts, false
+}
+
+// runStopHooks runs Stop hooks when the model is about to end its turn. When a
+// hook blocks, it returns the reason the agent should keep working.
+func (r *Runner) runStopHooks(ctx context.Context, stopHookActive bool) (reason string, keepGoing bool) {
+	if r.hookEngine == nil || ctx.Err() != nil {
+		return "", false
+	}
+	res, err := r.hookEngine.RunStop(ctx, r.GetSessionID(), stopHookActive)
+	if err != nil {
+		log.Printf("Stop hook error: %v", err)
+		return "", false
+	}
+	return hookBlockReason(res)
+}
+
+// runStopFailureHooks reports a turn that ended on an API error. Skipped when
+// the turn was cancelled, since that is the user stopping it, not a failure.
+func (r *Runner) runStopFailureHooks(ctx context.Context, errMsg string) {
+	if r.hookEngine == nil || ctx.Err() != nil {
+		return
+	}
+	hookCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), detachedHookTimeout)
+	defer cancel()
+	if err := r.hookEngine.RunStopFailure(hookCtx, r.GetSessionID(), errMsg); err != nil {
+		log.Printf("StopFailure hook error: %v", err)
+	}
+}
+
+// runPermissionRequestHooks lets PermissionRequest hooks answer an approval
+// prompt before it reaches the user. decided is false when no hook took a
+// position and the user should be asked as usual.
+func (r *Runner) runPermissionRequestHooks(ctx context.Context, tc provider.ToolUseBlock, check permission.CheckResult) (result permission.CheckResult, input json.RawMessage, decided bool) {
+	if r.hookEngine == nil {
+		return check, nil, false
+	}
+	res, err := r.hookEngine.RunPermissionRequest(ctx, tc.Name, tc.Input)
+	if err != nil {
+		log.Printf("PermissionRequest hook error for %s: %v", tc.Name, err)
+		return check, nil, false
+	}
+	if res == nil {
+		return check, nil, false
+	}
+
+	if reason, blocked := hookBlockReason(res); blocked || res.PermissionDecision == "deny" {
+		check.Decision = permission.Deny
+		check.DecidedBy = permission.DecidedByHook(hook.EventPermissionRequest)
+		check.DenyMessage = res.PermissionReason
+		if check.DenyMessage == "" {
+			check.DenyMessage = reason
+		}
+		if check.DenyMessage == "" {
+			check.DenyMessage = "Denied by PermissionRequest hook"
+		}
+		return check, nil, true
+	}
+	if res.PermissionDecision == "allow" {
+		check.Decision = permission.Allow
+		check.DecidedBy = permission.DecidedByHook(hook.EventPermissionRequest)
+		input = tc.Input
+		if res.UpdatedInput != nil {
+			input = res.UpdatedInput
+		}
+		return check, input, true
+	}
+	return check, nil, false
+}
+
+// runPermissionDeniedHooks runs PermissionDenied hooks for a denied tool call
+// and returns the tool result message, extended with any hook context and, when
+// a hook asks for it, a note that the model may retry the call.
+func (r *Runner) runPermissionDeniedHooks(ctx context.Context, tc provider.ToolUseBlock, reason, msg string) string {
+	if r.hookEngine == nil {
+		return msg
+	}
+	res, err := r.hookEngine.RunPermissionDenied(ctx, tc.Name, tc.Input, reason)
+	if err != nil {
+		log.Printf("PermissionDenied hook error for %s: %v", tc.Name, err)
+		return msg
+	}
+	if res == nil {
+		return msg
+	}
+	if len(res.AdditionalContexts) > 0 {
+		msg += "\n\n" + strings.Join(res.AdditionalContexts, "\n")
+	}
+	if res.Retry != nil && *res.Retry {
+		msg += "\n\nThis denial is not final: you may retry the tool call."
+	}
+	return msg
+}
+
+// runNotificationHooks fires Notification hooks in the background so the
+// prompt that triggered them (an approval or question) isn't delayed.
+func (r *Runner) runNotificationHooks(message string) {
+	if r.hookEngine == nil || !r.hookEngine.HasHooks(hook.EventNotification) {
+		return
+	}
+	r.bgWg.Add(1)
+	go func() {
+		defer r.bgWg.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), detachedHookTimeout)
+		defer cancel()
+		if err := r.hookEngine.RunNotification(ctx, message); err != nil {
+			log.Printf("Notification hook error: %v", err)
+		}
+	}()
+}
+
+// runToolLifecycleHooks fires the hooks tied to what a successful tool call
+// did: TaskCreated/TaskCompleted for the task tools and
+// WorktreeCreate/WorktreeRemove for the worktree tools. prevWorkdir is the
+// working directory before the tool ran.
+func (r *Runner) runToolLifecycleHooks(ctx context.Context, toolName string, input json.RawMessage, content, prevWorkdir string) {
+	if r.hookEngine == nil {
+		return
+	}
+	var err error
+	switch toolName {
+	case "TaskCreate":
+		var out struct {
+			Task struct {
+				ID      string `json:"id"`
+				Subject string `json:"subject"`
+			} `json:"task"`
+		}
+		if json.Unmarshal([]byte(content), &out) == nil && out.Task.ID != "" {
+			err = r.hookEngine.RunTaskCreated(ctx, out.Task.ID, out.Task.Subject)
+		}
+	case "TaskUpdate":
+		var in struct {
+			TaskID  string `json:"taskId"`
+			Subject string `json:"subject"`
+			Status  string `json:"status"`
+		}
+		if json.Unmarshal(input, &in) == nil && task.Status(in.Status) == task.StatusCompleted {
+			subject := in.Subject
+			if subject == "" && r.taskManager != nil {
+				if t := r.taskManager.Get(in.TaskID); t != nil {
+					subject = t.Subject
+				}
+			}
+			err = r.hookEngine.RunTaskCompleted(ctx, in.TaskID, subject)
+		}
+	case "EnterWorktree":
+		err = r.hookEngine.RunWorktreeCreate(ctx, r.GetWorkdir())
+	case "ExitWorktree":
+		var in struct {
+			Action string `json:"action"`
+		}
+		if json.Unmarshal(input, &in) == nil && in.Action == "remove" {
+			err = r.hookEngine.RunWorktreeRemove(ctx, prevWorkdir)
+		}
+	}
+	if err != nil {
+		log.Printf("%s lifecycle hook error: %v", toolName, err)
+	}
+}
+
+// runCwdChangedHooks fires CwdChanged when tools moved the working directory.
+// Hook context is attached to the next user message.
+func (r *Runner) runCwdChangedHooks(ctx context.Context, prevWorkdir string) {
+	if r.hookEngine == nil {
+		return
+	}
+	cwd := r.GetWorkdir()
+	if cwd == prevWorkdir {
+		return
+	}
+	res, err := r.hookEngine.RunCwdChanged(ctx, prevWorkdir, cwd)
+	if err != nil {
+		log.Printf("CwdChanged hook error: %v", err)
+		return
+	}
+	if res != nil {
+		r.addPendingHookContext(res.AdditionalContexts...)
+		r.watchHookPaths(res.WatchPaths)
+	}
+}
+
+// checkConfigChange runs ConfigChange hooks for a runtime setting change and
+// returns an error when a hook rejects it.
+func (r *Runner) checkConfigChange(key string, value interface{}) error {
+	if r.hookEngine == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), detachedHookTimeout)
+	defer cancel()
+	res, err := r.hookEngine.RunConfigChange(ctx, key, value)
+	if err != nil {
+		log.Printf("ConfigChange hook error: %v", err)
+		return nil
+	}
+	if reason, blocked := hookBlockReason(res); blocked {
+		return fmt.Errorf("%s change blocked by hook: %s", key, reason)
+	}
+	return nil
+}
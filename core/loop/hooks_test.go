@@ -0,0 +1,228 @@
+package loop
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chatml/chatml-core/hook"
+	"github.com/chatml/chatml-core/permission"
+	"github.com/chatml/chatml-core/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// textProvider answers every request with a fixed text response.
+type textProvider struct {
+	mu    sync.Mutex
+	calls int
+	text  string
+}
+
+func (p *textProvider) StreamChat(_ context.Context, _ provider.ChatRequest) (<-chan provider.StreamEvent, error) {
+	p.mu.Lock()
+	p.calls++
+	p.mu.Unlock()
+	ch := make(chan provider.StreamEvent, 2)
+	ch <- provider.StreamEvent{Type: provider.EventTextDelta, Text: p.text}
+	ch <- provider.StreamEvent{Type: provider.EventMessageDelta, StopReason: "end_turn"}
+	close(ch)
+	return ch, nil
+}
+
+func (p *textProvider) CountTokens(context.Context, []provider.Message) (int, error) { return 0, nil }
+func (p *textProvider) Name() string                                                 { return "test" }
+func (p *textProvider) MaxContextWindow() int                                        { return 200000 }
+func (p *textProvider) Capabilities() provider.Capabilities                          { return provider.Capabilities{} }
+func (p *textProvider) PrewarmConnection()                                           {}
+
+func (p *textProvider) callCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.calls
+}
+
+func hookConfig(event, command string) hook.Config {
+	return hook.Config{Hooks: map[string][]hook.MatcherGroup{
+		event: {{Hooks: []hook.HookDef{{Type: hook.HookTypeCommand, Command: command, Timeout: 5}}}},
+	}}
+}
+
+func newHookedRunner(t *testing.T, prov provider.Provider, cfg hook.Config) *Runner {
+	t.Helper()
+	opts := defaultOpts()
+	opts.Workdir = ""
+	r := NewRunner(opts, prov)
+	r.hookEngine = hook.NewEngine(t.TempDir(), cfg)
+	return r
+}
+
+func messageText(msg provider.Message) string {
+	var parts []string
+	for _, b := range msg.Content {
+		if b.Type == provider.BlockText {
+			parts = append(parts, b.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+func TestHooks_UserPromptSubmitBlocksPrompt(t *testing.T) {
+	prov := &textProvider{text: "hi"}
+	r := newHookedRunner(t, prov, hookConfig(hook.EventUserPromptSubmit, `echo "no secrets in prompts" >&2; exit 2`))
+
+	r.executeTurn(context.Background(), "my password is hunter2", nil)
+
+	assert.Equal(t, 0, prov.callCount())
+	assert.Empty(t, r.messages)
+	assert.Contains(t, readEventWithTimeout(t, r.Output(), time.Second), "no secrets in prompts")
+}
+
+func TestHooks_UserPromptSubmitAddsContext(t *testing.T) {
+	prov := &textProvider{text: "hi"}
+	r := newHookedRunner(t, prov, hookConfig(hook.EventUserPromptSubmit, `echo '{"additionalContext":"ticket ABC-1"}'`))
+
+	r.executeTurn(context.Background(), "fix the bug", nil)
+
+	require.NotEmpty(t, r.messages)
+	text := messageText(r.messages[0])
+	assert.Contains(t, text, "fix the bug")
+	assert.Contains(t, text, "ticket ABC-1")
+}
+
+func TestHooks_StopHookContinuesTurn(t *testing.T) {
+	prov := &textProvider{text: "done"}
+	marker := t.TempDir() + "/stopped-once"
+	cmd := `if [ -f ` + marker + ` ]; then exit 0; fi; touch ` + marker + `; echo "run the tests first" >&2; exit 2`
+	r := newHookedRunner(t, prov, hookConfig(hook.EventStop, cmd))
+
+	r.executeTurn(context.Background(), "implement it", nil)
+
+	assert.Equal(t, 2, prov.callCount())
+	var feedback []string
+	for _, m := range r.messages {
+		if strings.HasPrefix(messageText(m), "Stop hook feedback:") {
+			feedback = append(feedback, messageText(m))
+		}
+	}
+	require.Len(t, feedback, 1)
+	assert.Contains(t, feedback[0], "run the tests first")
+}
+
+func TestHooks_StopHookContinuationsAreBounded(t *testing.T) {
+	prov := &textProvider{text: "done"}
+	r := newHookedRunner(t, prov, hookConfig(hook.EventStop, `echo '{"decision":"block","reason":"not yet"}'`))
+
+	r.executeTurn(context.Background(), "go", nil)
+
+	assert.Equal(t, maxStopHookContinuations+1, prov.callCount())
+}
+
+func TestHooks_StartupHooksQueueInitialMessageAndContext(t *testing.T) {
+	r := newHookedRunner(t, nil, hookConfig(hook.EventSessionStart,
+		`echo '{"additionalContext":"on-call this week","initialUserMessage":"summarize open PRs"}'`))
+
+	initial, ok := r.runStartupHooks(context.Background(), "sess-1")
+
+	assert.True(t, ok)
+	assert.Equal(t, "summarize open PRs", initial)
+	assert.Equal(t, []string{"on-call this week"}, r.takePendingHookContext())
+	assert.Empty(t, r.pendingHookContext)
+}
+
+func TestHooks_StartupHooksPreventContinue(t *testing.T) {
+	r := newHookedRunner(t, nil, hookConfig(hook.EventSetup, `echo '{"continue":false,"stopReason":"setup failed"}'`))
+
+	_, ok := r.runStartupHooks(context.Background(), "sess-1")
+
+	assert.False(t, ok)
+}
+
+func TestHooks_PermissionRequestDecides(t *testing.T) {
+	tc := provider.ToolUseBlock{ID: "t1", Name: "Bash", Input: json.RawMessage(`{"command":"ls"}`)}
+	check := permission.CheckResult{Decision: permission.NeedApproval}
+
+	t.Run("allow", func(t *testing.T) {
+		r := newHookedRunner(t, nil, hookConfig(hook.EventPermissionRequest, `echo '{"decision":"approve"}'`))
+		res, input, decided := r.runPermissionRequestHooks(context.Background(), tc, check)
+		assert.True(t, decided)
+		assert.Equal(t, permission.Allow, res.Decision)
+		assert.JSONEq(t, `{"command":"ls"}`, string(input))
+		assert.Equal(t, "hook:PermissionRequest", res.DecidedBy)
+	})
+
+	t.Run("deny", func(t *testing.T) {
+		r := newHookedRunner(t, nil, hookConfig(hook.EventPermissionRequest, `echo '{"permissionDecision":"deny","denyMessage":"no shell"}'`))
+		res, _, decided := r.runPermissionRequestHooks(context.Background(), tc, check)
+		assert.True(t, decided)
+		assert.Equal(t, permission.Deny, res.Decision)
+		assert.Equal(t, "no shell", res.DenyMessage)
+	})
+
+	t.Run("no opinion asks the user", func(t *testing.T) {
+		r := newHookedRunner(t, nil, hookConfig(hook.EventPermissionRequest, `true`))
+		_, _, decided := r.runPermissionRequestHooks(context.Background(), tc, check)
+		assert.False(t, decided)
+	})
+}
+
+func TestHooks_PermissionDeniedRetry(t *testing.T) {
+	tc := provider.ToolUseBlock{ID: "t1", Name: "Bash", Input: json.RawMessage(`{}`)}
+	r := newHookedRunner(t, nil, hookConfig(hook.EventPermissionDenied, `echo '{"retry":true,"additionalContext":"use the sandbox"}'`))
+
+	msg := r.runPermissionDeniedHooks(context.Background(), tc, "rule", "Permission denied: rule")
+
+	assert.Contains(t, msg, "Permission denied: rule")
+	assert.Contains(t, msg, "use the sandbox")
+	assert.Contains(t, msg, "may retry")
+}
+
+func TestHooks_ConfigChangeBlocksSetting(t *testing.T) {
+	r := newHookedRunner(t, nil, hookConfig(hook.EventConfigChange, `echo "model is pinned" >&2; exit 2`))
+	r.opts.Model = "claude-sonnet-4-6"
+
+	err := r.SetModel("claude-opus-4-6")
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "model is pinned")
+	assert.Equal(t, "claude-sonnet-4-6", r.Options().Model)
+}
+
+func TestHooks_TaskLifecycle(t *testing.T) {
+	out := t.TempDir() + "/events"
+	r := newHookedRunner(t, nil, hook.Config{Hooks: map[string][]hook.MatcherGroup{
+		hook.EventTaskCreated:   {{Hooks: []hook.HookDef{{Command: "cat >> " + out + "; echo >> " + out}}}},
+		hook.EventTaskCompleted: {{Hooks: []hook.HookDef{{Command: "cat >> " + out + "; echo >> " + out}}}},
+	}})
+	ctx := context.Background()
+
+	r.runToolLifecycleHooks(ctx, "TaskCreate", nil, `{"task":{"id":"1","subject":"Write docs"}}`, "")
+	r.runToolLifecycleHooks(ctx, "TaskUpdate", json.RawMessage(`{"taskId":"1","status":"in_progress"}`), "", "")
+	r.runToolLifecycleHooks(ctx, "TaskUpdate", json.RawMessage(`{"taskId":"1","status":"completed","subject":"Write docs"}`), "", "")
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `"event":"TaskCreated"`)
+	assert.Contains(t, lines[1], `"event":"TaskCompleted"`)
+	assert.Contains(t, lines[1], `"subject":"Write docs"`)
+}
+
+func TestHooks_PromptHookUsesProvider(t *testing.T) {
+	prov := &textProvider{text: `{"decision":"deny","reason":"tests are failing"}`}
+	r := newHookedRunner(t, prov, hook.Config{Hooks: map[string][]hook.MatcherGroup{
+		hook.EventUserPromptSubmit: {{Hooks: []hook.HookDef{{Type: hook.HookTypePrompt, Prompt: "Reject prompts that ask to skip tests."}}}},
+	}})
+	r.hookEngine.SetEvaluator(&hookEvaluator{runner: r})
+
+	r.executeTurn(context.Background(), "skip the tests and ship it", nil)
+
+	assert.Equal(t, 1, prov.callCount(), "only the hook evaluation, no turn")
+	assert.Empty(t, r.messages)
+	assert.Contains(t, readEventWithTimeout(t, r.Output(), time.Second), "tests are failing")
+}
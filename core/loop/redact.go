@@ -0,0 +1,94 @@
+package loop
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/chatml/chatml-core/tool"
+)
+
+// Secret redaction. Tool output is scrubbed by the session's redact.Redactor
+// before it is persisted, emitted to the backend or appended to the
+// conversation, so secrets never reach the model, the transcript JSONL or
+// SQLite. Placeholders are stable for the session; file-writing tools get
+// them swapped back for the real values so that rewriting a file the model
+// read does not replace its secrets with placeholders.
+
+// restoringTools write model-authored text to disk. Their string inputs have
+// placeholders restored before execution.
+var restoringTools = map[string]bool{
+	"Write":        true,
+	"Edit":         true,
+	"NotebookEdit": true,
+}
+
+// redactToolOutput scrubs one tool result and warns the user when anything
+// was replaced.
+func (r *Runner) redactToolOutput(toolName, content string) string {
+	if r.redactor == nil {
+		return content
+	}
+	redacted, findings := r.redactor.Redact(content)
+	if len(findings) == 0 {
+		return content
+	}
+	kinds := make(map[string]bool)
+	for _, f := range findings {
+		kinds[f.Kind] = true
+	}
+	names := make([]string, 0, len(kinds))
+	for k := range kinds {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	noun := "secret"
+	if len(findings) > 1 {
+		noun = "secrets"
+	}
+	r.emitter.emitWarning(fmt.Sprintf("Redacted %d %s (%s) from %s output", len(findings), noun, strings.Join(names, ", "), toolName))
+	return redacted
+}
+
+// restoreSecretsInInputs replaces placeholders in the string inputs of
+// file-writing tool calls with the original secrets. It returns the original
+// inputs of the calls it changed, keyed by tool use ID, so callers can report
+// the redacted input rather than the restored one.
+func (r *Runner) restoreSecretsInInputs(calls []tool.ToolCall) map[string]json.RawMessage {
+	if r.redactor == nil {
+		return nil
+	}
+	var originals map[string]json.RawMessage
+	for i, tc := range calls {
+		if !restoringTools[tc.Name] || !strings.Contains(string(tc.Input), "[REDACTED:") {
+			continue
+		}
+		var input map[string]any
+		if err := json.Unmarshal(tc.Input, &input); err != nil {
+			continue
+		}
+		changed := false
+		for k, v := range input {
+			if s, ok := v.(string); ok {
+				if restored, ok := r.redactor.Restore(s); ok {
+					input[k] = restored
+					changed = true
+				}
+			}
+		}
+		if !changed {
+			continue
+		}
+		raw, err := json.Marshal(input)
+		if err != nil {
+			continue
+		}
+		if originals == nil {
+			originals = make(map[string]json.RawMessage)
+		}
+		originals[tc.ID] = tc.Input
+		calls[i].Input = raw
+	}
+	return originals
+}
@@ -0,0 +1,102 @@
+package loop
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/chatml/chatml-core/provider"
+	"github.com/chatml/chatml-core/redact"
+	"github.com/chatml/chatml-core/tool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// echoTool returns a fixed output and records the input it was called with.
+type echoTool struct {
+	name   string
+	output string
+	input  json.RawMessage
+}
+
+func (t *echoTool) Name() string                 { return t.name }
+func (t *echoTool) Description() string          { return "test tool" }
+func (t *echoTool) InputSchema() json.RawMessage { return json.RawMessage(`{"type":"object"}`) }
+func (t *echoTool) IsConcurrentSafe() bool       { return false }
+func (t *echoTool) Execute(_ context.Context, input json.RawMessage) (*tool.Result, error) {
+	t.input = input
+	return tool.TextResult(t.output), nil
+}
+
+func newRedactingRunner(t *testing.T, tools ...tool.Tool) (*Runner, <-chan string) {
+	t.Helper()
+	r := NewRunner(defaultOpts(), nil)
+	registry := tool.NewRegistry()
+	for _, tl := range tools {
+		registry.Register(tl)
+	}
+	r.toolExecutor = tool.NewExecutor(registry, 1)
+	r.redactor = redact.New(redact.Config{Env: map[string]string{"DEPLOY_TOKEN": "tok-5ecret-value"}})
+	e, events := newTestEmitter()
+	r.emitter = e
+	return r, events
+}
+
+func TestRunner_RedactsToolOutput(t *testing.T) {
+	bash := &echoTool{name: "Bash", output: "DEPLOY_TOKEN=tok-5ecret-value\nok"}
+	r, events := newRedactingRunner(t, bash)
+
+	msg := r.executeTools(context.Background(), []provider.ToolUseBlock{
+		{ID: "t1", Name: "Bash", Input: json.RawMessage(`{"command":"env"}`)},
+	}, nil)
+
+	require.Len(t, msg.Content, 1)
+	assert.Equal(t, "DEPLOY_TOKEN=[REDACTED:DEPLOY_TOKEN]\nok", msg.Content[0].ResultContent)
+
+	warning := readEvent(t, events)
+	assert.Equal(t, "warning", warning.Type)
+	assert.Equal(t, "Redacted 1 secret (env) from Bash output", warning.Message)
+
+	end := readEvent(t, events)
+	assert.Equal(t, "tool_end", end.Type)
+	assert.NotContains(t, end.Summary, "tok-5ecret-value")
+}
+
+func TestRunner_RestoresSecretsForFileWrites(t *testing.T) {
+	read := &echoTool{name: "Read", output: "token: tok-5ecret-value"}
+	write := &echoTool{name: "Write", output: "wrote .env"}
+	r, events := newRedactingRunner(t, read, write)
+
+	msg := r.executeTools(context.Background(), []provider.ToolUseBlock{
+		{ID: "t1", Name: "Read", Input: json.RawMessage(`{"file_path":".env"}`)},
+	}, nil)
+	require.Equal(t, "token: [REDACTED:DEPLOY_TOKEN]", msg.Content[0].ResultContent)
+	readEvent(t, events) // warning
+	readEvent(t, events) // tool_end
+
+	writeInput := json.RawMessage(`{"file_path":".env","content":"token: [REDACTED:DEPLOY_TOKEN]\n"}`)
+	r.executeTools(context.Background(), []provider.ToolUseBlock{
+		{ID: "t2", Name: "Write", Input: writeInput},
+	}, nil)
+
+	var got map[string]string
+	require.NoError(t, json.Unmarshal(write.input, &got))
+	assert.Equal(t, "token: tok-5ecret-value\n", got["content"], "the tool writes the real value")
+
+	end := readEvent(t, events)
+	assert.Equal(t, "tool_end", end.Type)
+	params, _ := json.Marshal(end.Params)
+	assert.False(t, strings.Contains(string(params), "tok-5ecret-value"), "events carry the redacted input")
+}
+
+func TestRunner_NoRedactorLeavesOutput(t *testing.T) {
+	bash := &echoTool{name: "Bash", output: "tok-5ecret-value"}
+	r, _ := newRedactingRunner(t, bash)
+	r.redactor = nil
+
+	msg := r.executeTools(context.Background(), []provider.ToolUseBlock{
+		{ID: "t1", Name: "Bash", Input: json.RawMessage(`{}`)},
+	}, nil)
+	assert.Equal(t, "tok-5ecret-value", msg.Content[0].ResultContent)
+}
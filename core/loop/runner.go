@@ -18,10 +18,14 @@ import (
 	"github.com/chatml/chatml-core/agent"
 	ctxpkg "github.com/chatml/chatml-core/context"
 	core "github.com/chatml/chatml-core"
+	"github.com/chatml/chatml-core/egress"
 	"github.com/chatml/chatml-core/hook"
+	"github.com/chatml/chatml-core/paths"
 	"github.com/chatml/chatml-core/permission"
 	"github.com/chatml/chatml-core/prompt"
 	"github.com/chatml/chatml-core/provider"
+	"github.com/chatml/chatml-core/redact"
+	"github.com/chatml/chatml-core/task"
 	"github.com/chatml/chatml-core/tool"
 	"github.com/chatml/chatml-core/tool/builtin"
 )
@@ -93,7 +97,15 @@ type Runner struct {
 	sessionNotes      *SessionNotes
 	bgWg              sync.WaitGroup    // Tracks background goroutines (memory extraction, etc.)
 	bgExtractCancel   context.CancelFunc // Cancel function for the latest background extraction
-	bgExtractCancelMu sync.Mutex        // Protects bgExtractCancel
+	bgExtractCancelMu sync.Mutex        // Protects bgExtractCancel and bgMemoryCancel
+
+	// Structured working memory (see sessionmemory.go). sessionMemoryMark is
+	// the index in r.messages up to which the memory has been updated; only
+	// the loop goroutine touches it.
+	sessionMemory     *SessionMemory
+	sessionMemoryMark int
+	sessionMemoryBusy atomic.Bool
+	bgMemoryCancel    context.CancelFunc
 
 	// Permission engine
 	permEngine            *permission.Engine
@@ -115,17 +127,39 @@ type Runner struct {
 	pendingQuestions     sync.Map // requestID -> chan map[string]string
 	pendingPlanApprovals sync.Map // requestID -> chan builtin.PlanApprovalResult
 
-	// Hook engine for PreToolUse/PostToolUse/Session hooks
+	// Hook engine for tool, session and lifecycle hooks (see hooks.go)
 	hookEngine *hook.Engine
 
+	// Hook additionalContext awaiting the next user message (guarded by mu;
+	// FileChanged hooks add to it from the file watcher)
+	pendingHookContext []string
+
+	// Task manager backing the task tools (used for hook payloads). The task
+	// list is saved to taskStorePath on every change (see tasks.go).
+	taskManager   *task.Manager
+	taskStorePath string
+	taskStoreMu   sync.Mutex // Serializes task list writes
+
 	// Read tracker for post-compact context restoration
 	readTracker *tool.ReadTracker
 
+	// File watcher and last-seen file contents for FileChanged hooks and
+	// external change reminders (see filechanges.go). fileWatcher is nil when
+	// watching is disabled.
+	fileWatcher  *fileWatcher
+	deltaTracker *ctxpkg.DeltaTracker
+
 	// Transcript writer for session persistence (enables resume)
 	transcript *TranscriptWriter
 
 	// MCP manager for cleanup
 	mcpManager interface{ Close() }
+
+	// Egress proxies started for MCP servers (protected by mu)
+	egressProxies []*egress.Proxy
+
+	// Secret redactor for tool output (see redact.go); nil when disabled
+	redactor *redact.Redactor
 }
 
 // inputMsg represents a message sent to the runner by the Manager.
@@ -165,6 +199,12 @@ type inputMsg struct {
 
 	// Task management
 	TaskId string
+
+	// Manual compaction: Content holds the focus instructions
+	compactReply chan compactReply
+
+	// Context breakdown request
+	breakdownReply chan *core.ContextBreakdown
 }
 
 // NewRunner creates a new native Go loop runner.
@@ -197,6 +237,7 @@ func NewRunnerFull(opts agent.ProcessOptions, prov provider.Provider, registry *
 		fastMode:       opts.FastMode,
 		emitter:        &emitter{ch: output},
 		permEngine:     permEngine,
+		deltaTracker:   ctxpkg.NewDeltaTracker(),
 	}
 
 	// Initialize context manager (requires provider for context window size)
@@ -262,6 +303,31 @@ func (r *Runner) runLoop(ctx context.Context) {
 	}
 	r.SetSessionID(sessionID)
 
+	// Fork: branch the resumed transcript into this session's own transcript
+	// (up to ForkAtTurn turns) and resume from the branch. A restarted fork
+	// already has its transcript and just resumes it.
+	resumeFrom := r.opts.ResumeSession
+	if r.opts.ForkSession && resumeFrom != "" && resumeFrom != sessionID && r.opts.Workdir != "" {
+		dir := TranscriptDir(r.opts.Workdir)
+		if FindTranscript(dir, sessionID) == "" {
+			keep := 0
+			if r.opts.ForkAtTurn > 0 {
+				if path := FindTranscript(dir, resumeFrom); path != "" {
+					if msgs, _, err := ReadTranscript(path); err == nil {
+						keep = TurnBoundary(msgs, r.opts.ForkAtTurn)
+					}
+				}
+			}
+			if _, err := ForkTranscript(dir, resumeFrom, sessionID, keep); err != nil {
+				log.Printf("warning: failed to fork session %s: %v", resumeFrom, err)
+			} else {
+				resumeFrom = sessionID
+			}
+		} else {
+			resumeFrom = sessionID
+		}
+	}
+
 	// Initialize transcript writer for session persistence
 	if r.opts.Workdir != "" {
 		tw, err := NewTranscriptWriter(TranscriptDir(r.opts.Workdir), sessionID, "")
@@ -278,29 +344,38 @@ func (r *Runner) runLoop(ctx context.Context) {
 	}
 
 	// Resume: if ResumeSession is set, load prior messages
-	if r.opts.ResumeSession != "" && r.opts.Workdir != "" {
-		transcriptPath := FindTranscript(TranscriptDir(r.opts.Workdir), r.opts.ResumeSession)
+	if resumeFrom != "" && r.opts.Workdir != "" {
+		transcriptPath := FindTranscript(TranscriptDir(r.opts.Workdir), resumeFrom)
 		if transcriptPath != "" {
 			msgs, _, err := ReadTranscript(transcriptPath)
 			if err != nil {
 				log.Printf("warning: failed to load transcript for resume: %v", err)
 			} else if len(msgs) > 0 {
 				r.messages = msgs
-				log.Printf("Resumed session %s with %d messages", r.opts.ResumeSession, len(msgs))
+				log.Printf("Resumed session %s with %d messages", resumeFrom, len(msgs))
 			}
 		}
 	}
 
+	if r.provider != nil && r.opts.Workdir != "" {
+		r.sessionMemory = NewSessionMemory(r.provider, TranscriptDir(r.opts.Workdir), sessionID, r.opts.ResumeSession)
+		r.sessionMemoryMark = len(r.messages)
+	}
+
 	r.emitter.emitSessionStarted(sessionID, "startup")
+	r.initTaskStore(sessionID)
 
-	// Run SessionStart hooks
-	if r.hookEngine != nil {
-		if result, err := r.hookEngine.RunSessionStart(ctx, sessionID); err != nil {
-			log.Printf("SessionStart hook error: %v", err)
-		} else if result != nil && result.PreventContinue {
-			log.Printf("SessionStart hook prevented continuation: %s", result.StopReason)
-			return
-		}
+	// Start watching before the startup hooks so their WatchPaths are honored.
+	r.startFileWatcher()
+
+	// Run Setup, InstructionsLoaded and SessionStart hooks. A hook-provided
+	// initial message runs as the first turn.
+	initialMessage, ok := r.runStartupHooks(ctx, sessionID)
+	if !ok {
+		return
+	}
+	if initialMessage != "" {
+		r.executeTurn(ctx, initialMessage, nil)
 	}
 
 	// Main message loop — wait for user messages and execute turns
@@ -319,6 +394,13 @@ func (r *Runner) runLoop(ctx context.Context) {
 				r.executeTurn(ctx, msg.Content, msg.Attachments)
 			case "stop":
 				return
+			case "compact":
+				report, err := r.compactHistory(ctx, compactTriggerManual, msg.Content)
+				msg.compactReply <- compactReply{report: report, err: err}
+			case "context_breakdown":
+				b := r.contextBreakdown()
+				r.emitter.emitContextBreakdown(b)
+				msg.breakdownReply <- b
 			case "interrupt":
 				// Dead code: SendInterrupt() calls turnCancel() directly, not via queue.
 				// Kept for defensive compatibility in case queue-based interrupt is added later.
@@ -370,6 +452,12 @@ func (r *Runner) executeTurn(ctx context.Context, userContent string, attachment
 		r.emitter.emitTurnComplete()
 	}()
 
+	// UserPromptSubmit hooks can reject the prompt or add context to it
+	hookContexts, blocked := r.runUserPromptSubmitHooks(turnCtx, userContent)
+	if blocked {
+		return
+	}
+
 	// Build user message content blocks
 	var contentBlocks []provider.ContentBlock
 	contentBlocks = append(contentBlocks, provider.NewTextBlock(userContent))
@@ -422,6 +510,17 @@ Write/Edit/Bash tools are blocked in plan mode.
 </system-reminder>`))
 	}
 
+	// Context from hooks (SessionStart, CwdChanged, FileChanged, UserPromptSubmit)
+	hookContexts = append(r.takePendingHookContext(), hookContexts...)
+	if len(hookContexts) > 0 {
+		contentBlocks = append(contentBlocks, provider.NewTextBlock(hookContextReminder(hookContexts)))
+	}
+
+	// Files the agent read that were changed externally between turns
+	if reminder := r.externalChangeReminder(); reminder != "" {
+		contentBlocks = append(contentBlocks, provider.NewTextBlock(reminder))
+	}
+
 	userMsg := provider.Message{
 		Role:    provider.RoleUser,
 		Content: contentBlocks,
@@ -438,6 +537,7 @@ Write/Edit/Bash tools are blocked in plan mode.
 	var activeModel string         // Tracks which model actually served each turn (for cost)
 	thinkingBudgetAttempts := 0    // Adaptive thinking: tracks retry attempts
 	const maxThinkingAttempts = 2
+	stopHookContinuations := 0     // Times Stop hooks sent the agent back to work
 
 	// Track cumulative cost across the turn
 	var cumulativeCost float64
@@ -484,40 +584,10 @@ Write/Edit/Bash tools are blocked in plan mode.
 
 			// Auto-compact: full LLM-based summarization when approaching limit
 			if r.ctxManager.ShouldAutoCompact(lastTokens) {
-				// Run PreCompact hooks
-				if r.hookEngine != nil {
-					r.hookEngine.RunPreCompact(turnCtx, r.GetSessionID()) //nolint:errcheck
-				}
-
-				compResult, compErr := ctxpkg.Compact(turnCtx, r.provider, r.messages, 4)
-				if compErr != nil {
-					r.ctxManager.RecordCompactFailure()
+				// Report what filled the window before it is summarized away
+				r.emitter.emitContextBreakdown(r.contextBreakdown())
+				if _, compErr := r.compactHistory(turnCtx, compactTriggerAuto, ""); compErr != nil {
 					r.emitter.emitError(fmt.Sprintf("Auto-compact failed: %v", compErr))
-				} else {
-					r.messages = compResult.Messages
-
-					// Post-compact context restoration: re-inject recent files, tools, MCP info
-					var toolNames []string
-					if r.toolRegistry != nil {
-						toolNames = r.toolRegistry.ToolNames()
-					}
-					restoreMsgs := ctxpkg.RestorePostCompact(ctxpkg.PostCompactRestorationConfig{
-						MaxRecentFiles: 5,
-						MaxFileTokens:  5000,
-						ReadTracker:    r.readTracker,
-						ToolNames:      toolNames,
-					})
-					if len(restoreMsgs) > 0 {
-						r.messages = append(r.messages, restoreMsgs...)
-					}
-
-					r.ctxManager.RecordCompaction()
-					r.ctxManager.ResetCompactFailures()
-
-					// Run PostCompact hooks
-					if r.hookEngine != nil {
-						r.hookEngine.RunPostCompact(turnCtx, r.GetSessionID()) //nolint:errcheck
-					}
 				}
 			}
 		}
@@ -584,6 +654,7 @@ Write/Edit/Bash tools are blocked in plan mode.
 					// Fallback also failed — break rather than falling through
 					// to unrelated recovery paths (prompt-too-long, context-overflow)
 					r.emitter.emitError(fmt.Sprintf("Fallback model %s also failed: %v", r.fallbackModel, err))
+					r.runStopFailureHooks(turnCtx, err.Error())
 					break
 				}
 			}
@@ -595,6 +666,10 @@ Write/Edit/Bash tools are blocked in plan mode.
 				compResult, compErr := ctxpkg.Compact(turnCtx, r.provider, r.messages, 4)
 				if compErr == nil {
 					r.messages = compResult.Messages
+					r.messages = append(r.messages, ctxpkg.RestorePostCompact(ctxpkg.PostCompactRestorationConfig{
+						SessionMemory: r.sessionMemoryText(),
+					})...)
+					r.sessionMemoryMark = len(r.messages)
 					if r.ctxManager != nil {
 						r.ctxManager.RecordCompaction()
 						r.ctxManager.ResetCompactFailures()
@@ -635,6 +710,7 @@ Write/Edit/Bash tools are blocked in plan mode.
 				r.mu.Lock()
 				r.sawErrorEvent = true
 				r.mu.Unlock()
+				r.runStopFailureHooks(turnCtx, err.Error())
 				break
 			}
 		} else {
@@ -662,6 +738,7 @@ Write/Edit/Bash tools are blocked in plan mode.
 		r.mu.Unlock()
 		if !hasContent && sawError {
 			// Skip appending empty/partial message from a failed stream
+			r.runStopFailureHooks(turnCtx, "stream error")
 			break
 		}
 		r.messages = append(r.messages, assistantMsg)
@@ -762,8 +839,22 @@ Write/Edit/Bash tools are blocked in plan mode.
 			escalatedMaxTokens = 0
 		}
 
-		// If no tool calls, the turn is complete
+		// If no tool calls, the turn is complete — unless a Stop hook blocks
+		// (e.g. "run the tests first"), in which case its reason goes back to
+		// the model and the turn continues.
 		if len(toolCalls) == 0 {
+			if stopHookContinuations < maxStopHookContinuations {
+				if reason, keepGoing := r.runStopHooks(turnCtx, stopHookContinuations > 0); keepGoing {
+					stopHookContinuations++
+					feedbackMsg := provider.Message{
+						Role:    provider.RoleUser,
+						Content: []provider.ContentBlock{provider.NewTextBlock("Stop hook feedback:\n" + reason)},
+					}
+					r.messages = append(r.messages, feedbackMsg)
+					r.persistMessage(feedbackMsg)
+					continue
+				}
+			}
 			r.emitter.emitResult(usage, cumulativeCost, turnCount)
 			break
 		}
@@ -773,7 +864,12 @@ Write/Edit/Bash tools are blocked in plan mode.
 		r.provider.PrewarmConnection()
 
 		// Execute tool calls and collect results
+		batchStart := time.Now()
 		toolResultMsg := r.executeTools(turnCtx, toolCalls, streamExec)
+		r.syncWatchedFiles(toolCalls, batchStart)
+		if reminder := r.externalChangeReminder(); reminder != "" {
+			appendToLastToolResult(&toolResultMsg, reminder)
+		}
 		r.messages = append(r.messages, toolResultMsg)
 		r.persistMessage(toolResultMsg)
 
@@ -809,6 +905,76 @@ Write/Edit/Bash tools are blocked in plan mode.
 			}()
 		}
 	}
+
+	r.updateSessionMemory()
+}
+
+// updateSessionMemory folds the messages since the last update into the
+// session's working memory in the background, throttled by the memory's
+// ShouldUpdate. If an update is still running the messages wait for the next
+// turn. The update's model cost is added to the session cost.
+func (r *Runner) updateSessionMemory() {
+	if r.sessionMemory == nil {
+		return
+	}
+	r.sessionMemory.IncrementTurn()
+	if r.sessionMemoryMark > len(r.messages) {
+		// History was truncated (rewind, clear); nothing new to fold in
+		r.sessionMemoryMark = len(r.messages)
+	}
+	if r.sessionMemoryMark == len(r.messages) || !r.sessionMemory.ShouldUpdate() {
+		return
+	}
+	if !r.sessionMemoryBusy.CompareAndSwap(false, true) {
+		return
+	}
+	msgSnapshot := cloneMessages(r.messages[r.sessionMemoryMark:])
+	r.sessionMemoryMark = len(r.messages)
+
+	var tasks []task.Info
+	if r.taskManager != nil {
+		tasks = []task.Info{}
+		for _, t := range r.taskManager.List() {
+			tasks = append(tasks, t.Info())
+		}
+	}
+
+	r.mu.Lock()
+	model := r.opts.Model
+	r.mu.Unlock()
+
+	updateCtx, updateCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	r.bgExtractCancelMu.Lock()
+	r.bgMemoryCancel = updateCancel
+	r.bgExtractCancelMu.Unlock()
+	r.bgWg.Add(1)
+	go func() {
+		defer r.bgWg.Done()
+		defer updateCancel()
+		defer r.sessionMemoryBusy.Store(false)
+		usage, err := r.sessionMemory.Update(updateCtx, msgSnapshot, tasks)
+		if cost := provider.CalculateCost(model, usage); cost > 0 {
+			r.mu.Lock()
+			r.sessionCost += cost
+			r.mu.Unlock()
+		}
+		if err != nil {
+			log.Printf("warning: session memory update: %v", err)
+		}
+	}()
+}
+
+// sessionMemoryText renders the working memory for re-injection, or "".
+func (r *Runner) sessionMemoryText() string {
+	if r.sessionMemory == nil {
+		return ""
+	}
+	m, err := r.sessionMemory.Load()
+	if err != nil {
+		log.Printf("warning: load session memory: %v", err)
+		return ""
+	}
+	return m.Render()
 }
 
 // buildChatRequest constructs a provider.ChatRequest from the current conversation state.
@@ -888,7 +1054,8 @@ func (r *Runner) processStream(ctx context.Context, stream <-chan provider.Strea
 	)
 
 	// Create streaming executor if enabled. Concurrent-safe tools (Read, Glob, Grep)
-	// are auto-approved and can start during streaming regardless of permission mode.
+	// are auto-approved and can start during streaming regardless of permission mode,
+	// unless a managed policy rule matches them.
 	// Serial tools requiring permission are queued and handled by executeTools.
 	var streamExec *StreamingToolExecutor
 	if r.streamingToolExecEnabled && r.toolRegistry != nil && r.toolExecutor != nil {
@@ -929,9 +1096,13 @@ func (r *Runner) processStream(ctx context.Context, stream <-chan provider.Strea
 		case provider.EventToolUseEnd:
 			if event.ToolUse != nil {
 				toolCalls = append(toolCalls, *event.ToolUse)
-				// Start concurrent-safe tools immediately during streaming
-				if streamExec != nil {
-					streamExec.AddTool(ctx, *event.ToolUse)
+				// Start concurrent-safe tools immediately during streaming.
+				// Calls matching a managed policy rule wait for executeTools
+				// so the policy is enforced.
+				if streamExec != nil && !r.policyGoverns(*event.ToolUse) {
+					if streamExec.AddTool(ctx, *event.ToolUse) {
+						r.auditStreamedTool(*event.ToolUse)
+					}
 				}
 			}
 
@@ -1076,6 +1247,14 @@ func (r *Runner) executeTools(ctx context.Context, toolCalls []provider.ToolUseB
 	resultsByID := make(map[string]provider.ContentBlock) // For pre-resolved tools
 	var resultBlocks []provider.ContentBlock
 
+	// denyCall records a permission denial as the tool's result. PermissionDenied
+	// hooks may extend the message (extra context, or permission to retry).
+	denyCall := func(tc provider.ToolUseBlock, reason string) {
+		msg := r.runPermissionDeniedHooks(ctx, tc, reason, fmt.Sprintf("Permission denied: %s", reason))
+		r.emitter.emitToolEnd(tc.ID, tc.Name, false, msg, rawToMap(tc.Input))
+		resultsByID[tc.ID] = provider.NewToolResultBlock(tc.ID, msg, true)
+	}
+
 	// Pass 1: resolve concurrent results, check permissions, collect tools needing approval.
 	var needsApproval []batchApprovalEntry // Tools that need user approval (batched)
 
@@ -1089,6 +1268,7 @@ func (r *Runner) executeTools(ctx context.Context, toolCalls []provider.ToolUseB
 					content = tcr.Result.Content
 					isError = tcr.Result.IsError
 				}
+				content = r.redactToolOutput(tcr.ToolCall.Name, content)
 				if !isError {
 					content = r.maybePersistResult(tcr.ToolCall.ID, content)
 				}
@@ -1101,6 +1281,9 @@ func (r *Runner) executeTools(ctx context.Context, toolCalls []provider.ToolUseB
 				if r.IsPlanModeActive() && !isError {
 					r.trackPlanFilePath(tcr.ToolCall.Name, tcr.ToolCall.Input)
 				}
+				if !isError && tcr.Result != nil {
+					r.runToolLifecycleHooks(ctx, tcr.ToolCall.Name, tcr.ToolCall.Input, tcr.Result.Content, r.GetWorkdir())
+				}
 				continue
 			}
 		}
@@ -1116,12 +1299,22 @@ func (r *Runner) executeTools(ctx context.Context, toolCalls []provider.ToolUseB
 
 		switch check.Decision {
 		case permission.Allow:
+			r.auditPermission(tc, check)
 			approvedCalls = append(approvedCalls, tool.ToolCall{ID: tc.ID, Name: tc.Name, Input: tc.Input})
 		case permission.Deny:
-			msg := fmt.Sprintf("Permission denied: %s", check.DenyMessage)
-			r.emitter.emitToolEnd(tc.ID, tc.Name, false, msg, rawToMap(tc.Input))
-			resultsByID[tc.ID] = provider.NewToolResultBlock(tc.ID, msg, true)
+			r.auditPermission(tc, check)
+			denyCall(tc, check.DenyMessage)
 		case permission.NeedApproval:
+			// PermissionRequest hooks can answer on the user's behalf
+			if decided, input, ok := r.runPermissionRequestHooks(ctx, tc, check); ok {
+				r.auditPermission(tc, decided)
+				if decided.Decision == permission.Allow {
+					approvedCalls = append(approvedCalls, tool.ToolCall{ID: tc.ID, Name: tc.Name, Input: input})
+				} else {
+					denyCall(tc, decided.DenyMessage)
+				}
+				continue
+			}
 			needsApproval = append(needsApproval, batchApprovalEntry{tc: tc, check: check})
 		}
 	}
@@ -1137,6 +1330,7 @@ func (r *Runner) executeTools(ctx context.Context, toolCalls []provider.ToolUseB
 				resultsByID[e.tc.ID] = provider.NewToolResultBlock(e.tc.ID, msg, true)
 				continue
 			}
+			r.auditPermission(e.tc, br.decision)
 			switch br.decision.Decision {
 			case permission.Allow:
 				input := br.input
@@ -1145,9 +1339,7 @@ func (r *Runner) executeTools(ctx context.Context, toolCalls []provider.ToolUseB
 				}
 				approvedCalls = append(approvedCalls, tool.ToolCall{ID: e.tc.ID, Name: e.tc.Name, Input: input})
 			case permission.Deny:
-				msg := fmt.Sprintf("Permission denied: %s", br.decision.DenyMessage)
-				r.emitter.emitToolEnd(e.tc.ID, e.tc.Name, false, msg, rawToMap(e.tc.Input))
-				resultsByID[e.tc.ID] = provider.NewToolResultBlock(e.tc.ID, msg, true)
+				denyCall(e.tc, br.decision.DenyMessage)
 			}
 		}
 	}
@@ -1167,6 +1359,12 @@ func (r *Runner) executeTools(ctx context.Context, toolCalls []provider.ToolUseB
 					if hookOut.DenyMessage != "" {
 						msg = hookOut.DenyMessage
 					}
+					r.auditPermission(provider.ToolUseBlock{ID: tc.ID, Name: tc.Name, Input: tc.Input}, permission.CheckResult{
+						Decision:    permission.Deny,
+						DenyMessage: msg,
+						Specifier:   permission.BuildSpecifier(tc.Name, tc.Input),
+						DecidedBy:   permission.DecidedByHook(hook.EventPreToolUse),
+					})
 					r.emitter.emitToolEnd(tc.ID, tc.Name, false, msg, rawToMap(tc.Input))
 					resultsByID[tc.ID] = provider.NewToolResultBlock(tc.ID, msg, true)
 					continue // Skip this tool
@@ -1178,14 +1376,20 @@ func (r *Runner) executeTools(ctx context.Context, toolCalls []provider.ToolUseB
 			hookFilteredCalls = append(hookFilteredCalls, tc)
 		}
 
+		workdirBefore := r.GetWorkdir()
+		redactedInputs := r.restoreSecretsInInputs(hookFilteredCalls)
 		results := r.toolExecutor.Execute(ctx, hookFilteredCalls)
 		for _, tcr := range results {
+			if input, ok := redactedInputs[tcr.ToolCall.ID]; ok {
+				tcr.ToolCall.Input = input
+			}
 			content := ""
 			isError := false
 			if tcr.Result != nil {
 				content = tcr.Result.Content
 				isError = tcr.Result.IsError
 			}
+			content = r.redactToolOutput(tcr.ToolCall.Name, content)
 
 			// Persist large results to disk with a preview
 			if !isError {
@@ -1211,9 +1415,13 @@ func (r *Runner) executeTools(ctx context.Context, toolCalls []provider.ToolUseB
 					r.hookEngine.RunPostToolUseFailure(ctx, tcr.ToolCall.Name, tcr.ToolCall.Input, content) //nolint:errcheck
 				} else {
 					r.hookEngine.RunPostToolUse(ctx, tcr.ToolCall.Name, tcr.ToolCall.Input, content) //nolint:errcheck
+					if tcr.Result != nil {
+						r.runToolLifecycleHooks(ctx, tcr.ToolCall.Name, tcr.ToolCall.Input, tcr.Result.Content, workdirBefore)
+					}
 				}
 			}
 		}
+		r.runCwdChangedHooks(ctx, workdirBefore)
 	}
 
 	// Build result blocks in original order
@@ -1273,16 +1481,51 @@ func (r *Runner) checkPermission(ctx context.Context, tc provider.ToolUseBlock)
 
 	switch result.Decision {
 	case permission.Allow:
+		r.auditPermission(tc, result)
 		return result, tc.Input
 	case permission.Deny:
+		r.auditPermission(tc, result)
 		return result, nil
 	case permission.NeedApproval:
-		return r.requestApproval(ctx, tc, result)
+		result, input := r.requestApproval(ctx, tc, result)
+		r.auditPermission(tc, result)
+		return result, input
 	default:
 		return result, tc.Input
 	}
 }
 
+// policyGoverns reports whether a managed policy rule matches the call, in
+// which case it must go through the permission engine rather than the
+// streaming executor's auto-approval.
+func (r *Runner) policyGoverns(tc provider.ToolUseBlock) bool {
+	return r.permEngine != nil && r.permEngine.PolicyAction(tc.Name, tc.Input) != ""
+}
+
+// auditStreamedTool records the implicit allow for a concurrent-safe tool the
+// streaming executor started without consulting the permission engine.
+func (r *Runner) auditStreamedTool(tc provider.ToolUseBlock) {
+	if r.permEngine == nil {
+		return
+	}
+	r.auditPermission(tc, permission.CheckResult{
+		Decision:  permission.Allow,
+		Specifier: permission.BuildSpecifier(tc.Name, tc.Input),
+		DecidedBy: permission.DecidedByMode,
+	})
+}
+
+// auditPermission emits the final permission decision for a tool call so the
+// backend can keep a durable record of who or what allowed or denied it.
+func (r *Runner) auditPermission(tc provider.ToolUseBlock, check permission.CheckResult) {
+	mode := ""
+	if r.permEngine != nil {
+		mode = r.permEngine.Mode()
+	}
+	r.emitter.emitPermissionDecision(tc.ID, tc.Name, check.Specifier, check.Decision.String(),
+		check.DecidedBy, check.Approval, check.DenyMessage, mode)
+}
+
 // requestApproval emits a tool_approval_request event and blocks until the user responds.
 func (r *Runner) requestApproval(ctx context.Context, tc provider.ToolUseBlock, check permission.CheckResult) (permission.CheckResult, json.RawMessage) {
 	requestID := fmt.Sprintf("tar-%d-%d", atomic.AddInt64(&r.approvalCounter, 1), time.Now().UnixMilli())
@@ -1300,6 +1543,7 @@ func (r *Runner) requestApproval(ctx context.Context, tc provider.ToolUseBlock,
 
 	// Emit approval request to frontend
 	r.emitter.emitToolApprovalRequest(requestID, tc.Name, toolInputObj, check.Specifier)
+	r.runNotificationHooks(fmt.Sprintf("Permission needed to use %s", tc.Name))
 
 	// Block waiting for response — no timeout (matches Claude Code behavior).
 	// Only cancelled by context (runner stop/interrupt).
@@ -1309,6 +1553,7 @@ func (r *Runner) requestApproval(ctx context.Context, tc provider.ToolUseBlock,
 	case <-ctx.Done():
 		check.Decision = permission.Deny
 		check.DenyMessage = "Tool approval cancelled"
+		check.DecidedBy = permission.DecidedByCancelled
 		return check, nil
 	}
 }
@@ -1317,6 +1562,8 @@ func (r *Runner) requestApproval(ctx context.Context, tc provider.ToolUseBlock,
 func (r *Runner) processApprovalResponse(check permission.CheckResult, resp permission.ApprovalResponse, originalInput json.RawMessage) (permission.CheckResult, json.RawMessage) {
 	// Record session/always decisions
 	r.permEngine.RecordApproval(check.RuleKey, resp)
+	check.DecidedBy = permission.DecidedByUser
+	check.Approval = resp.Action
 
 	switch {
 	case strings.HasPrefix(resp.Action, "allow"):
@@ -1385,6 +1632,7 @@ func (r *Runner) requestBatchApproval(ctx context.Context, entries []batchApprov
 
 	// Emit batch request
 	r.emitter.emitToolBatchApprovalRequest(requestID, items)
+	r.runNotificationHooks(fmt.Sprintf("Permission needed to use %d tools", len(items)))
 
 	// Block waiting for response
 	select {
@@ -1415,6 +1663,7 @@ func (r *Runner) requestBatchApproval(ctx context.Context, entries []batchApprov
 			check := e.check
 			check.Decision = permission.Deny
 			check.DenyMessage = "Batch tool approval cancelled"
+			check.DecidedBy = permission.DecidedByCancelled
 			results[e.tc.ID] = struct {
 				decision permission.CheckResult
 				input    json.RawMessage
@@ -1554,6 +1803,9 @@ func (r *Runner) GetSessionID() string {
 }
 
 func (r *Runner) SetPermissionMode(mode string) error {
+	if err := r.checkConfigChange("permissionMode", mode); err != nil {
+		return err
+	}
 	r.mu.Lock()
 	r.permissionMode = mode
 	r.planModeActive = (mode == "plan")
@@ -1572,6 +1824,9 @@ func (r *Runner) SetPermissionMode(mode string) error {
 }
 
 func (r *Runner) SetFastMode(enabled bool) error {
+	if err := r.checkConfigChange("fastMode", enabled); err != nil {
+		return err
+	}
 	// Direct mutation under lock is sufficient — buildChatRequest reads under the same lock.
 	// No message queue needed; the queued path was redundant and created a race window.
 	r.mu.Lock()
@@ -1580,14 +1835,26 @@ func (r *Runner) SetFastMode(enabled bool) error {
 	return nil
 }
 
+// SetModel switches the model for the next request. Like the backend
+// factory, it refuses models outside the managed policy's allowedModels.
 func (r *Runner) SetModel(model string) error {
+	if err := r.checkConfigChange("model", model); err != nil {
+		return err
+	}
+	model = strings.TrimSuffix(model, "[1m]")
+	if !paths.LoadManagedPolicy().ModelAllowed(model) {
+		return fmt.Errorf("model %q is not allowed by managed policy", model)
+	}
 	r.mu.Lock()
-	r.opts.Model = strings.TrimSuffix(model, "[1m]")
+	r.opts.Model = model
 	r.mu.Unlock()
 	return nil
 }
 
 func (r *Runner) SetMaxThinkingTokens(tokens int) error {
+	if err := r.checkConfigChange("maxThinkingTokens", tokens); err != nil {
+		return err
+	}
 	r.mu.Lock()
 	r.opts.MaxThinkingTokens = tokens
 	r.mu.Unlock()
@@ -1595,6 +1862,9 @@ func (r *Runner) SetMaxThinkingTokens(tokens int) error {
 }
 
 func (r *Runner) SetEffort(effort string) error {
+	if err := r.checkConfigChange("effort", effort); err != nil {
+		return err
+	}
 	r.mu.Lock()
 	r.opts.Effort = effort
 	r.mu.Unlock()
@@ -1798,6 +2068,8 @@ func (r *Runner) SpawnSubAgent(ctx context.Context, opts builtin.SubAgentOpts) (
 	if r.promptBuilder != nil {
 		childRunner.promptBuilder = r.promptBuilder
 	}
+	// Share the redactor so placeholders stay consistent across the session
+	childRunner.redactor = r.redactor
 
 	// Fork mode: deep-copy parent's messages into the child so it inherits
 	// the full conversation context. This enables Anthropic prompt cache sharing
@@ -2091,6 +2363,7 @@ func (r *Runner) EmitQuestionRequest(requestID string, questions []builtin.Quest
 		RequestID: requestID,
 		Questions: convertQuestions(questions),
 	})
+	r.runNotificationHooks("Agent is waiting for your answer")
 
 	return ch
 }
@@ -2157,6 +2430,7 @@ func (r *Runner) EmitPlanApprovalRequest(requestID string, planContent string) <
 		RequestID:   requestID,
 		PlanContent: planContent,
 	})
+	r.runNotificationHooks("Plan is ready for review")
 
 	// Clear tracked path after emitting
 	r.mu.Lock()
@@ -2196,6 +2470,11 @@ func (r *Runner) SetPermissionModeDirect(mode string) {
 // Waits for background goroutines (memory extraction) to finish so the
 // caller can safely tear down the provider after <-Done().
 func (r *Runner) cleanup() {
+	// Stop the file watcher first so no FileChanged hooks start during shutdown.
+	if r.fileWatcher != nil {
+		r.fileWatcher.Close()
+	}
+
 	// Cancel then wait for outstanding background goroutines (e.g., memory extraction).
 	// Cancelling first ensures we don't block shutdown for up to 30s waiting for
 	// an LLM call to complete. The Wait() then returns quickly.
@@ -2203,6 +2482,9 @@ func (r *Runner) cleanup() {
 	if r.bgExtractCancel != nil {
 		r.bgExtractCancel()
 	}
+	if r.bgMemoryCancel != nil {
+		r.bgMemoryCancel()
+	}
 	r.bgExtractCancelMu.Unlock()
 	r.bgWg.Wait()
 
@@ -2238,10 +2520,11 @@ func (r *Runner) cleanup() {
 		r.transcript.Close() //nolint:errcheck
 	}
 
-	// Close MCP connections
+	// Close MCP connections, then the proxies their traffic went through
 	if r.mcpManager != nil {
 		r.mcpManager.Close()
 	}
+	r.closeEgressProxies()
 }
 
 // persistMessage writes a message to the transcript file (if active).
@@ -2357,3 +2640,5 @@ func isPromptTooLong(err error) bool {
 
 // Ensure Runner implements ConversationBackend at compile time.
 var _ agent.ConversationBackend = (*Runner)(nil)
+var _ agent.Compacter = (*Runner)(nil)
+var _ agent.ContextReporter = (*Runner)(nil)
@@ -1,6 +1,7 @@
 package loop
 
 import (
+	"context"
 	"encoding/json"
 	"os"
 	"path/filepath"
@@ -9,7 +10,9 @@ import (
 
 	"github.com/chatml/chatml-core/agent"
 	core "github.com/chatml/chatml-core"
+	"github.com/chatml/chatml-core/paths"
 	"github.com/chatml/chatml-core/permission"
+	"github.com/chatml/chatml-core/provider"
 	"github.com/chatml/chatml-core/tool/builtin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -289,6 +292,21 @@ func TestRunner_SetModel(t *testing.T) {
 	assert.Equal(t, "claude-opus-4-6", r.Options().Model)
 }
 
+func TestRunner_SetModelRespectsManagedPolicy(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "managed-settings.json"), []byte(`{"allowedModels": ["claude-sonnet-*"]}`), 0644))
+	t.Cleanup(paths.SetManagedSettingsDir(dir))
+	r := NewRunner(defaultOpts(), nil)
+
+	err := r.SetModel("claude-opus-4-6")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not allowed by managed policy")
+	assert.Equal(t, "claude-sonnet-4-6", r.Options().Model)
+
+	require.NoError(t, r.SetModel("claude-sonnet-4-5[1m]"))
+	assert.Equal(t, "claude-sonnet-4-5", r.Options().Model)
+}
+
 func TestRunner_SetMaxThinkingTokens(t *testing.T) {
 	r := NewRunner(defaultOpts(), nil)
 
@@ -331,6 +349,68 @@ func TestRunner_SendToolApprovalResponse_WithPending(t *testing.T) {
 	assert.Equal(t, "Bash(ls)", resp.Specifier)
 }
 
+func TestRunner_CheckPermission_AuditsUserApproval(t *testing.T) {
+	r := NewRunner(defaultOpts(), nil)
+	r.permEngine = permission.NewEngine(permission.ModeDefault, permission.NewRuleSet(nil))
+	e, events := newTestEmitter()
+	r.emitter = e
+
+	tc := provider.ToolUseBlock{ID: "tu-1", Name: "Bash", Input: json.RawMessage(`{"command":"make build"}`)}
+	done := make(chan permission.CheckResult, 1)
+	go func() {
+		result, _ := r.checkPermission(context.Background(), tc)
+		done <- result
+	}()
+
+	request := readEvent(t, events)
+	require.Equal(t, "tool_approval_request", request.Type)
+	require.NoError(t, r.SendToolApprovalResponse(request.RequestID, "allow_session", "", nil))
+
+	result := <-done
+	assert.Equal(t, permission.Allow, result.Decision)
+	assert.Equal(t, permission.DecidedByUser, result.DecidedBy)
+
+	audit := readEvent(t, events)
+	assert.Equal(t, "permission_decision", audit.Type)
+	assert.Equal(t, "tu-1", audit.ToolUseId)
+	assert.Equal(t, "make build", audit.Specifier)
+	assert.Equal(t, "allow", audit.PermissionDecision)
+	assert.Equal(t, "user", audit.DecidedBy)
+	assert.Equal(t, "allow_session", audit.ApprovalAction)
+	assert.Equal(t, "default", audit.Mode)
+}
+
+func TestRunner_PolicyGoverns(t *testing.T) {
+	r := NewRunner(defaultOpts(), nil)
+	read := provider.ToolUseBlock{ID: "tu-1", Name: "Read", Input: json.RawMessage(`{"file_path":".env"}`)}
+	assert.False(t, r.policyGoverns(read), "no permission engine")
+
+	rules := permission.NewRuleSet(nil)
+	rules.AddPolicyRules([]permission.Rule{{Tool: "Read", Specifier: ".env", Action: "deny"}})
+	r.permEngine = permission.NewEngine(permission.ModeDefault, rules)
+	assert.True(t, r.policyGoverns(read))
+	assert.False(t, r.policyGoverns(provider.ToolUseBlock{ID: "tu-2", Name: "Read", Input: json.RawMessage(`{"file_path":"main.go"}`)}))
+}
+
+func TestRunner_AuditStreamedTool(t *testing.T) {
+	r := NewRunner(defaultOpts(), nil)
+	e, events := newTestEmitter()
+	r.emitter = e
+	tc := provider.ToolUseBlock{ID: "tu-1", Name: "Grep", Input: json.RawMessage(`{"pattern":"TODO"}`)}
+	r.auditStreamedTool(tc) // no permission engine: nothing to audit
+
+	r.permEngine = permission.NewEngine(permission.ModeAcceptEdits, permission.NewRuleSet(nil))
+	r.auditStreamedTool(tc)
+
+	audit := readEvent(t, events)
+	assert.Equal(t, "permission_decision", audit.Type)
+	assert.Equal(t, "tu-1", audit.ToolUseId)
+	assert.Equal(t, "Grep", audit.ToolName)
+	assert.Equal(t, "allow", audit.PermissionDecision)
+	assert.Equal(t, "mode", audit.DecidedBy)
+	assert.Equal(t, "acceptEdits", audit.Mode)
+}
+
 func TestRunner_SendUserQuestionResponse_NoPending(t *testing.T) {
 	r := NewRunner(defaultOpts(), nil)
 	err := r.SendUserQuestionResponse("req-1", map[string]string{"q1": "answer"})
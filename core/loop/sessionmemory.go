@@ -0,0 +1,419 @@
+package loop
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chatml/chatml-core/provider"
+	"github.com/chatml/chatml-core/task"
+)
+
+// Caps keep the working memory small enough to re-inject after compaction.
+const (
+	maxMemoryDecisions     = 30
+	maxMemoryOpenQuestions = 15
+	maxMemoryFiles         = 50
+)
+
+// Updates run at most every sessionMemoryTurns turns unless
+// sessionMemoryInterval has passed since the last one; skipped turns are
+// folded into the next update.
+const (
+	sessionMemoryTurns    = 3
+	sessionMemoryInterval = 5 * time.Minute
+)
+
+// maxMemoryRevisionAttempts bounds how often a revision is redone because
+// the memory was edited while the model was revising it.
+const maxMemoryRevisionAttempts = 2
+
+// File access recorded in the working memory's file map.
+const (
+	FileAccessRead     = "read"
+	FileAccessModified = "modified"
+)
+
+// WorkingMemory is a session's structured working memory: what the session is
+// for, what was decided, what is still open, which files matter and where the
+// task list stands. Unlike SessionNotes it is updated every few turns and
+// survives compaction, which makes it the continuity for multi-day sessions.
+type WorkingMemory struct {
+	Goal          string      `json:"goal,omitempty"`
+	Decisions     []string    `json:"decisions,omitempty"`
+	OpenQuestions []string    `json:"openQuestions,omitempty"`
+	Files         []FileNote  `json:"files,omitempty"` // Most recently touched first
+	Tasks         []task.Info `json:"tasks,omitempty"`
+	UpdatedAt     time.Time   `json:"updatedAt,omitempty"`
+}
+
+// FileNote is one entry of the working memory's file map.
+type FileNote struct {
+	Path   string `json:"path"`
+	Access string `json:"access"` // FileAccessRead or FileAccessModified
+}
+
+// IsEmpty reports whether the memory holds nothing worth injecting.
+func (m *WorkingMemory) IsEmpty() bool {
+	return m.Goal == "" && len(m.Decisions) == 0 && len(m.OpenQuestions) == 0 &&
+		len(m.Files) == 0 && len(m.Tasks) == 0
+}
+
+// Render formats the memory as markdown for the model, or "" when empty.
+func (m *WorkingMemory) Render() string {
+	if m.IsEmpty() {
+		return ""
+	}
+	var sb strings.Builder
+	if m.Goal != "" {
+		fmt.Fprintf(&sb, "## Goal\n%s\n\n", m.Goal)
+	}
+	writeList := func(title string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		fmt.Fprintf(&sb, "## %s\n", title)
+		for _, item := range items {
+			fmt.Fprintf(&sb, "- %s\n", item)
+		}
+		sb.WriteString("\n")
+	}
+	writeList("Decisions", m.Decisions)
+	writeList("Open questions", m.OpenQuestions)
+	if len(m.Files) > 0 {
+		sb.WriteString("## Files\n")
+		for _, f := range m.Files {
+			fmt.Fprintf(&sb, "- %s (%s)\n", f.Path, f.Access)
+		}
+		sb.WriteString("\n")
+	}
+	if len(m.Tasks) > 0 {
+		sb.WriteString("## Tasks\n")
+		for _, t := range m.Tasks {
+			fmt.Fprintf(&sb, "- [%s] %s: %s\n", t.Status, t.ID, t.Subject)
+		}
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// WorkingMemoryPath returns where a session's working memory is stored: next
+// to its transcript, as <dir>/<sessionID>.memory.json.
+func WorkingMemoryPath(transcriptDir, sessionID string) string {
+	return filepath.Join(transcriptDir, sessionID+".memory.json")
+}
+
+// LoadWorkingMemory reads a working memory file. A missing file yields an
+// empty memory.
+func LoadWorkingMemory(path string) (*WorkingMemory, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &WorkingMemory{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read working memory: %w", err)
+	}
+	var m WorkingMemory
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parse working memory: %w", err)
+	}
+	return &m, nil
+}
+
+// SaveWorkingMemory writes a working memory file atomically.
+func SaveWorkingMemory(path string, m *WorkingMemory) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal working memory: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("create working memory dir: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("write working memory: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// SessionMemory keeps a session's WorkingMemory file up to date. The file is
+// the source of truth, so edits made through the backend while the session
+// runs are picked up by the next update.
+type SessionMemory struct {
+	provider provider.Provider
+	path     string
+	mu       sync.Mutex // Serializes read-modify-write of the file
+
+	throttleMu sync.Mutex
+	lastRun    time.Time
+	turnCount  int // Turns since the last update
+}
+
+// NewSessionMemory creates the working memory for sessionID in transcriptDir.
+// When resuming, the memory of the resumed session carries over if the new
+// session has none yet.
+func NewSessionMemory(prov provider.Provider, transcriptDir, sessionID, resumeFrom string) *SessionMemory {
+	sm := &SessionMemory{provider: prov, path: WorkingMemoryPath(transcriptDir, sessionID)}
+	if resumeFrom != "" && resumeFrom != sessionID {
+		if _, err := os.Stat(sm.path); os.IsNotExist(err) {
+			if prev, err := LoadWorkingMemory(WorkingMemoryPath(transcriptDir, resumeFrom)); err == nil && !prev.IsEmpty() {
+				SaveWorkingMemory(sm.path, prev) //nolint:errcheck
+			}
+		}
+	}
+	return sm
+}
+
+// Path returns the working memory file path.
+func (sm *SessionMemory) Path() string {
+	return sm.path
+}
+
+// Load returns the current working memory.
+func (sm *SessionMemory) Load() (*WorkingMemory, error) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	return LoadWorkingMemory(sm.path)
+}
+
+// IncrementTurn records that a turn has completed.
+func (sm *SessionMemory) IncrementTurn() {
+	sm.throttleMu.Lock()
+	sm.turnCount++
+	sm.throttleMu.Unlock()
+}
+
+// ShouldUpdate reports whether enough turns or time have passed since the
+// last update to run another one.
+func (sm *SessionMemory) ShouldUpdate() bool {
+	sm.throttleMu.Lock()
+	defer sm.throttleMu.Unlock()
+	if sm.turnCount == 0 {
+		return false
+	}
+	return sm.turnCount >= sessionMemoryTurns || time.Since(sm.lastRun) >= sessionMemoryInterval
+}
+
+// Update folds the messages since the last update into the memory: the file
+// map and task list are refreshed directly, and the goal, decisions and open
+// questions are revised by the model. tasks replaces the stored task list
+// unless nil. If the model call fails the direct updates are still saved.
+//
+// A revision is only applied if the memory was not edited while the model
+// worked on it; otherwise it is redone against the edited memory, and
+// dropped if that races too, so edits always win. The returned usage covers
+// all model calls. Meant to run in a background goroutine with a deep-cloned
+// message slice.
+func (sm *SessionMemory) Update(ctx context.Context, messages []provider.Message, tasks []task.Info) (provider.Usage, error) {
+	sm.throttleMu.Lock()
+	sm.turnCount = 0
+	sm.lastRun = time.Now()
+	sm.throttleMu.Unlock()
+
+	var usage provider.Usage
+	for attempt := 1; ; attempt++ {
+		current, err := sm.Load()
+		if err != nil {
+			return usage, err
+		}
+		revised, callUsage, llmErr := sm.revise(ctx, current, messages)
+		usage.InputTokens += callUsage.InputTokens
+		usage.OutputTokens += callUsage.OutputTokens
+		usage.CacheReadInputTokens += callUsage.CacheReadInputTokens
+		usage.CacheCreationInputTokens += callUsage.CacheCreationInputTokens
+
+		sm.mu.Lock()
+		m, err := LoadWorkingMemory(sm.path)
+		if err != nil {
+			sm.mu.Unlock()
+			return usage, err
+		}
+		if revised != nil && !m.UpdatedAt.Equal(current.UpdatedAt) {
+			if attempt < maxMemoryRevisionAttempts {
+				sm.mu.Unlock()
+				continue
+			}
+			revised = nil
+		}
+		err = sm.apply(m, revised, messages, tasks)
+		sm.mu.Unlock()
+		if err != nil {
+			return usage, err
+		}
+		return usage, llmErr
+	}
+}
+
+// apply saves m with the direct updates and, unless nil, the revision.
+// The caller holds sm.mu.
+func (sm *SessionMemory) apply(m *WorkingMemory, revised *memoryRevision, messages []provider.Message, tasks []task.Info) error {
+	recordFileActivity(m, messages)
+	if tasks != nil {
+		m.Tasks = tasks
+	}
+	if revised != nil {
+		m.Goal = strings.TrimSpace(revised.Goal)
+		m.Decisions = capList(revised.Decisions, maxMemoryDecisions)
+		m.OpenQuestions = capList(revised.OpenQuestions, maxMemoryOpenQuestions)
+	}
+	m.UpdatedAt = time.Now()
+	return SaveWorkingMemory(sm.path, m)
+}
+
+// memoryRevision is the part of the memory the model maintains.
+type memoryRevision struct {
+	Goal          string   `json:"goal"`
+	Decisions     []string `json:"decisions"`
+	OpenQuestions []string `json:"openQuestions"`
+}
+
+// revise asks the model to update the goal, decisions and open questions
+// given the latest turn, returning the call's token usage. Returns a nil
+// revision when there is nothing to revise.
+func (sm *SessionMemory) revise(ctx context.Context, current *WorkingMemory, messages []provider.Message) (*memoryRevision, provider.Usage, error) {
+	var usage provider.Usage
+	if sm.provider == nil {
+		return nil, usage, nil
+	}
+	transcript := buildMemoryTranscript(messages)
+	if transcript == "" {
+		return nil, usage, nil
+	}
+	currentJSON, _ := json.Marshal(memoryRevision{
+		Goal:          current.Goal,
+		Decisions:     current.Decisions,
+		OpenQuestions: current.OpenQuestions,
+	})
+
+	req := provider.ChatRequest{
+		SystemPrompt: sessionMemoryPrompt,
+		Messages: []provider.Message{
+			{
+				Role: provider.RoleUser,
+				Content: []provider.ContentBlock{
+					provider.NewTextBlock(fmt.Sprintf(
+						"## Current memory\n\n%s\n\n## Latest exchange\n\n%s", currentJSON, transcript,
+					)),
+				},
+			},
+		},
+		MaxTokens: 2000,
+	}
+
+	stream, err := sm.provider.StreamChat(ctx, req)
+	if err != nil {
+		return nil, usage, fmt.Errorf("session memory LLM call failed: %w", err)
+	}
+	var result strings.Builder
+	for event := range stream {
+		if event.Type == provider.EventTextDelta {
+			result.WriteString(event.Text)
+		}
+		if event.Usage != nil {
+			switch event.Type {
+			case provider.EventMessageStart:
+				usage.InputTokens = event.Usage.InputTokens
+				usage.CacheReadInputTokens = event.Usage.CacheReadInputTokens
+				usage.CacheCreationInputTokens = event.Usage.CacheCreationInputTokens
+			case provider.EventMessageDelta:
+				usage.OutputTokens = event.Usage.OutputTokens
+			}
+		}
+		if event.Type == provider.EventError && event.Error != nil {
+			// Drain remaining events
+			for range stream {
+			}
+			return nil, usage, fmt.Errorf("session memory stream error: %w", event.Error)
+		}
+	}
+
+	text := result.String()
+	start, end := strings.Index(text, "{"), strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return nil, usage, fmt.Errorf("session memory: model returned no JSON object")
+	}
+	var rev memoryRevision
+	if err := json.Unmarshal([]byte(text[start:end+1]), &rev); err != nil {
+		return nil, usage, fmt.Errorf("session memory: parse model output: %w", err)
+	}
+	return &rev, usage, nil
+}
+
+// recordFileActivity moves files touched by Read/Edit/Write tool calls to the
+// front of the file map. A file once modified stays marked as modified.
+func recordFileActivity(m *WorkingMemory, messages []provider.Message) {
+	for _, msg := range messages {
+		for _, block := range msg.Content {
+			if block.Type != provider.BlockToolUse {
+				continue
+			}
+			var access string
+			switch block.ToolName {
+			case "Read":
+				access = FileAccessRead
+			case "Edit", "MultiEdit", "Write", "NotebookEdit":
+				access = FileAccessModified
+			default:
+				continue
+			}
+			var input struct {
+				FilePath     string `json:"file_path"`
+				NotebookPath string `json:"notebook_path"`
+			}
+			if json.Unmarshal(block.Input, &input) != nil {
+				continue
+			}
+			path := input.FilePath
+			if path == "" {
+				path = input.NotebookPath
+			}
+			if path == "" {
+				continue
+			}
+
+			files := []FileNote{{Path: path, Access: access}}
+			for _, f := range m.Files {
+				if f.Path == path {
+					if f.Access == FileAccessModified {
+						files[0].Access = FileAccessModified
+					}
+					continue
+				}
+				files = append(files, f)
+			}
+			m.Files = files
+		}
+	}
+	if len(m.Files) > maxMemoryFiles {
+		m.Files = m.Files[:maxMemoryFiles]
+	}
+}
+
+// capList drops empty entries and keeps at most n items, preferring the most
+// recent (last) ones.
+func capList(items []string, n int) []string {
+	var out []string
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	if len(out) > n {
+		out = out[len(out)-n:]
+	}
+	return out
+}
+
+const sessionMemoryPrompt = `You maintain the structured working memory of a long-running coding session. You receive the current memory as JSON and the latest exchange between the user and the assistant. Return the updated memory as a single JSON object with exactly these keys:
+
+{"goal": string, "decisions": [string], "openQuestions": [string]}
+
+- goal: one or two sentences on what the session is trying to achieve. Keep it unless the user changed direction.
+- decisions: settled choices and constraints (designs, libraries, conventions, approaches the user ruled out). Keep earlier decisions unless they were reversed, and append new ones. One sentence each.
+- openQuestions: unresolved questions and blockers. Remove the ones that were answered or resolved.
+
+Do not record file paths or task status; those are tracked separately. Output the JSON object only, with no prose and no code fences.`
@@ -0,0 +1,195 @@
+package loop
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chatml/chatml-core/hook"
+	"github.com/chatml/chatml-core/provider"
+	"github.com/chatml/chatml-core/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func toolTurn(tool, path string) []provider.Message {
+	input, _ := json.Marshal(map[string]string{"file_path": path})
+	return []provider.Message{
+		{Role: provider.RoleUser, Content: []provider.ContentBlock{provider.NewTextBlock("please look at " + path)}},
+		{Role: provider.RoleAssistant, Content: []provider.ContentBlock{provider.NewToolUseBlock("tu_"+tool, tool, input)}},
+		{Role: provider.RoleUser, Content: []provider.ContentBlock{provider.NewToolResultBlock("tu_"+tool, "ok", false)}},
+	}
+}
+
+func TestSessionMemory_Update(t *testing.T) {
+	dir := t.TempDir()
+	prov := &textProvider{text: "```json\n" + `{"goal":"Add email to users","decisions":["Use migration 0042"],"openQuestions":["Backfill existing rows?"]}` + "\n```"}
+	sm := NewSessionMemory(prov, dir, "sess-1", "")
+
+	tasks := []task.Info{{ID: "task-1", Subject: "Write migration", Status: task.StatusInProgress}}
+	_, err := sm.Update(context.Background(), toolTurn("Read", "db/schema.sql"), tasks)
+	require.NoError(t, err)
+	_, err = sm.Update(context.Background(), append(toolTurn("Edit", "db/migrations/0042.sql"), toolTurn("Read", "db/schema.sql")...), nil)
+	require.NoError(t, err)
+
+	m, err := LoadWorkingMemory(WorkingMemoryPath(dir, "sess-1"))
+	require.NoError(t, err)
+	assert.Equal(t, "Add email to users", m.Goal)
+	assert.Equal(t, []string{"Use migration 0042"}, m.Decisions)
+	assert.Equal(t, []string{"Backfill existing rows?"}, m.OpenQuestions)
+	assert.Equal(t, []FileNote{
+		{Path: "db/schema.sql", Access: FileAccessRead},
+		{Path: "db/migrations/0042.sql", Access: FileAccessModified},
+	}, m.Files)
+	assert.Equal(t, tasks, m.Tasks, "nil tasks keep the stored list")
+	assert.False(t, m.UpdatedAt.IsZero())
+
+	rendered := m.Render()
+	assert.Contains(t, rendered, "## Goal\nAdd email to users")
+	assert.Contains(t, rendered, "- db/migrations/0042.sql (modified)")
+	assert.Contains(t, rendered, "- [in_progress] task-1: Write migration")
+}
+
+func TestSessionMemory_UpdateKeepsFileMapOnModelError(t *testing.T) {
+	dir := t.TempDir()
+	path := WorkingMemoryPath(dir, "sess-1")
+	require.NoError(t, SaveWorkingMemory(path, &WorkingMemory{Goal: "Edited by the user"}))
+
+	sm := NewSessionMemory(&textProvider{text: "I can't do that"}, dir, "sess-1", "")
+	_, err := sm.Update(context.Background(), toolTurn("Write", "main.go"), nil)
+	require.Error(t, err)
+
+	m, err := LoadWorkingMemory(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Edited by the user", m.Goal)
+	assert.Equal(t, []FileNote{{Path: "main.go", Access: FileAccessModified}}, m.Files)
+}
+
+// editingProvider simulates a user saving the working memory through the
+// backend while the model is revising it.
+type editingProvider struct {
+	textProvider
+	path  string
+	edits int // Calls during which the memory is edited
+}
+
+func (p *editingProvider) StreamChat(ctx context.Context, req provider.ChatRequest) (<-chan provider.StreamEvent, error) {
+	if p.callCount() < p.edits {
+		m, _ := LoadWorkingMemory(p.path)
+		m.Goal = "Edited by the user"
+		m.UpdatedAt = time.Now().Add(time.Duration(p.callCount()+1) * time.Second)
+		SaveWorkingMemory(p.path, m) //nolint:errcheck
+	}
+	ch, err := p.textProvider.StreamChat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	out := make(chan provider.StreamEvent, 3)
+	out <- provider.StreamEvent{Type: provider.EventMessageStart, Usage: &provider.Usage{InputTokens: 100}}
+	for event := range ch {
+		if event.Type == provider.EventMessageDelta {
+			event.Usage = &provider.Usage{OutputTokens: 10}
+		}
+		out <- event
+	}
+	close(out)
+	return out, nil
+}
+
+func TestSessionMemory_UpdateRedoesRevisionAfterConcurrentEdit(t *testing.T) {
+	dir := t.TempDir()
+	path := WorkingMemoryPath(dir, "sess-1")
+	prov := &editingProvider{
+		textProvider: textProvider{text: `{"goal":"Revised","decisions":["Use migration 0042"],"openQuestions":[]}`},
+		path:         path,
+		edits:        1,
+	}
+	sm := NewSessionMemory(prov, dir, "sess-1", "")
+
+	usage, err := sm.Update(context.Background(), toolTurn("Write", "main.go"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, prov.callCount(), "the revision is redone against the edited memory")
+	assert.Equal(t, provider.Usage{InputTokens: 200, OutputTokens: 20}, usage)
+
+	m, err := LoadWorkingMemory(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Revised", m.Goal)
+	assert.Equal(t, []FileNote{{Path: "main.go", Access: FileAccessModified}}, m.Files)
+}
+
+func TestSessionMemory_UpdateKeepsConcurrentEdit(t *testing.T) {
+	dir := t.TempDir()
+	path := WorkingMemoryPath(dir, "sess-1")
+	prov := &editingProvider{
+		textProvider: textProvider{text: `{"goal":"Revised","decisions":[],"openQuestions":[]}`},
+		path:         path,
+		edits:        maxMemoryRevisionAttempts,
+	}
+	sm := NewSessionMemory(prov, dir, "sess-1", "")
+
+	_, err := sm.Update(context.Background(), toolTurn("Write", "main.go"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, maxMemoryRevisionAttempts, prov.callCount())
+
+	m, err := LoadWorkingMemory(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Edited by the user", m.Goal, "a revision racing an edit is dropped")
+	assert.Equal(t, []FileNote{{Path: "main.go", Access: FileAccessModified}}, m.Files)
+}
+
+func TestSessionMemory_ShouldUpdate(t *testing.T) {
+	sm := NewSessionMemory(nil, t.TempDir(), "sess-1", "")
+	assert.False(t, sm.ShouldUpdate(), "no turns yet")
+
+	sm.IncrementTurn()
+	assert.True(t, sm.ShouldUpdate(), "first turn of the session")
+	_, err := sm.Update(context.Background(), nil, nil)
+	require.NoError(t, err)
+
+	for i := 1; i < sessionMemoryTurns; i++ {
+		sm.IncrementTurn()
+		assert.False(t, sm.ShouldUpdate(), "turn %d", i)
+	}
+	sm.IncrementTurn()
+	assert.True(t, sm.ShouldUpdate())
+}
+
+func TestNewSessionMemory_CarriesOverOnResume(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, SaveWorkingMemory(WorkingMemoryPath(dir, "old"), &WorkingMemory{Goal: "Ship v2"}))
+
+	sm := NewSessionMemory(nil, dir, "new", "old")
+	m, err := sm.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "Ship v2", m.Goal)
+}
+
+func TestLoadWorkingMemory_Missing(t *testing.T) {
+	m, err := LoadWorkingMemory(WorkingMemoryPath(t.TempDir(), "none"))
+	require.NoError(t, err)
+	assert.True(t, m.IsEmpty())
+	assert.Equal(t, "", m.Render())
+}
+
+func TestRunner_CompactInjectsSessionMemory(t *testing.T) {
+	dir := t.TempDir()
+	prov := &summaryProvider{textProvider: textProvider{text: "Summary"}}
+	r := newHookedRunner(t, prov, hook.Config{})
+	r.messages = conversation(5)
+	r.sessionMemory = NewSessionMemory(nil, dir, "sess-1", "")
+	require.NoError(t, SaveWorkingMemory(r.sessionMemory.Path(), &WorkingMemory{Decisions: []string{"Keep the v1 API"}}))
+
+	_, err := r.compactHistory(context.Background(), compactTriggerManual, "")
+	require.NoError(t, err)
+
+	var found bool
+	for _, msg := range r.messages {
+		if text := messageText(msg); strings.Contains(text, "Session working memory") && strings.Contains(text, "Keep the v1 API") {
+			found = true
+		}
+	}
+	assert.True(t, found, "working memory re-injected after compaction")
+	assert.Equal(t, len(r.messages), r.sessionMemoryMark)
+}
@@ -51,14 +51,18 @@ func NewStreamingToolExecutor(parentCtx context.Context, registry *tool.Registry
 }
 
 // AddTool queues a completed tool_use block for execution.
-// If the tool is concurrent-safe, execution starts immediately in a goroutine.
-// Serial tools are queued and returned by Collect for permission checks.
-func (se *StreamingToolExecutor) AddTool(ctx context.Context, block provider.ToolUseBlock) {
+// If the tool is concurrent-safe, execution starts immediately in a goroutine
+// and AddTool returns true. Serial tools are queued and returned by Collect
+// for permission checks.
+//
+// Concurrent-safe tools skip the permission engine, so callers must not pass
+// calls that a managed policy rule governs.
+func (se *StreamingToolExecutor) AddTool(ctx context.Context, block provider.ToolUseBlock) bool {
 	t := se.registry.Get(block.Name)
 
 	if t != nil && t.IsConcurrentSafe() {
 		// Start concurrent-safe tools immediately — these are read-only
-		// and always allowed by the permission engine.
+		// and allowed by the permission engine unless a policy says otherwise.
 		se.mu.Lock()
 		se.running[block.ID] = struct{}{}
 		se.mu.Unlock()
@@ -80,12 +84,14 @@ func (se *StreamingToolExecutor) AddTool(ctx context.Context, block provider.Too
 			delete(se.running, block.ID)
 			se.mu.Unlock()
 		}()
-	} else {
-		// Queue serial tools for later — they need permission checks
-		se.mu.Lock()
-		se.pending = append(se.pending, block)
-		se.mu.Unlock()
+		return true
 	}
+
+	// Queue serial tools for later — they need permission checks
+	se.mu.Lock()
+	se.pending = append(se.pending, block)
+	se.mu.Unlock()
+	return false
 }
 
 // Collect must be called exactly once, after all AddTool calls are complete.
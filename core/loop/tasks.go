@@ -0,0 +1,76 @@
+package loop
+
+import (
+	"log"
+
+	"github.com/chatml/chatml-core/agent"
+	"github.com/chatml/chatml-core/task"
+)
+
+// initTaskStore restores the session's task list from its file next to the
+// transcript and saves it again on every change, so tasks and their
+// dependency graph survive runner restarts. When resuming, the resumed
+// session's tasks carry over if this session has none saved yet. The restored
+// list is reported to the client.
+func (r *Runner) initTaskStore(sessionID string) {
+	if r.taskManager == nil || r.opts.Workdir == "" {
+		return
+	}
+	dir := TranscriptDir(r.opts.Workdir)
+	r.taskStorePath = task.FilePath(dir, sessionID)
+
+	tasks, err := task.LoadFile(r.taskStorePath)
+	if err != nil {
+		log.Printf("warning: failed to load tasks: %v", err)
+	}
+	carried := false
+	if tasks == nil && r.opts.ResumeSession != "" && r.opts.ResumeSession != sessionID {
+		if tasks, err = task.LoadFile(task.FilePath(dir, r.opts.ResumeSession)); err != nil {
+			log.Printf("warning: failed to load tasks for resume: %v", err)
+		}
+		carried = len(tasks) > 0
+	}
+	if len(tasks) > 0 {
+		r.taskManager.Restore(tasks)
+		log.Printf("Restored %d tasks for session %s", len(tasks), sessionID)
+	}
+
+	r.taskManager.OnChange(r.persistTasks)
+	if carried {
+		r.persistTasks()
+	} else if len(tasks) > 0 {
+		r.emitter.emitTaskList(taskItems(r.taskManager.Snapshot()))
+	}
+}
+
+// persistTasks saves the task list and reports it to the client. Called from
+// the tool goroutines through the task manager's OnChange callback; the lock
+// is held while emitting so the client sees lists in the order they were
+// taken and the last one it gets is current.
+func (r *Runner) persistTasks() {
+	r.taskStoreMu.Lock()
+	defer r.taskStoreMu.Unlock()
+	tasks := r.taskManager.Snapshot()
+	if err := task.SaveFile(r.taskStorePath, tasks); err != nil {
+		log.Printf("warning: failed to save tasks: %v", err)
+	}
+	r.emitter.emitTaskList(taskItems(tasks))
+}
+
+// taskItems converts tasks to their wire form.
+func taskItems(tasks []*task.Task) []agent.TaskItem {
+	items := make([]agent.TaskItem, 0, len(tasks))
+	for _, t := range tasks {
+		items = append(items, agent.TaskItem{
+			ID:          t.ID,
+			Subject:     t.Subject,
+			Description: t.Description,
+			ActiveForm:  t.ActiveForm,
+			Status:      string(t.Status),
+			Owner:       t.Owner,
+			Blocks:      t.Blocks,
+			BlockedBy:   t.BlockedBy,
+		})
+	}
+	return items
+}
@@ -0,0 +1,84 @@
+package loop
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chatml/chatml-core/agent"
+	"github.com/chatml/chatml-core/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func nextTaskList(t *testing.T, r *Runner) []agent.TaskItem {
+	t.Helper()
+	for {
+		var e agent.AgentEvent
+		require.NoError(t, json.Unmarshal([]byte(readEventWithTimeout(t, r.Output(), 2*time.Second)), &e))
+		if e.Type == agent.EventTypeTaskListUpdate {
+			return e.Tasks
+		}
+	}
+}
+
+func TestRunner_TasksPersistAcrossResume(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	opts := defaultOpts()
+	opts.Workdir = t.TempDir()
+	dir := TranscriptDir(opts.Workdir)
+
+	prev := task.NewManager()
+	a := prev.Create("Write migration", "", "", nil)
+	b := prev.Create("Backfill rows", "", "", nil)
+	prev.Update(b.ID, task.UpdateOpts{AddBlockedBy: []string{a.ID}})
+	require.NoError(t, task.SaveFile(task.FilePath(dir, "old-session"), prev.Snapshot()))
+
+	opts.ResumeSession = "old-session"
+	r := NewRunner(opts, &textProvider{})
+	r.taskManager = task.NewManager()
+	require.NoError(t, r.Start())
+	defer r.Stop()
+
+	restored := nextTaskList(t, r)
+	require.Len(t, restored, 2)
+	assert.Equal(t, []string{a.ID}, restored[1].BlockedBy)
+
+	c := r.taskManager.Create("Deploy", "", "", nil)
+	assert.Equal(t, "task-3", c.ID)
+	assert.Len(t, nextTaskList(t, r), 3)
+
+	saved, err := task.LoadFile(task.FilePath(dir, opts.SdkSessionID))
+	require.NoError(t, err)
+	require.Len(t, saved, 3)
+	assert.Equal(t, "Deploy", saved[2].Subject)
+}
+
+func TestRunner_TaskListsEmittedInOrder(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	opts := defaultOpts()
+	opts.Workdir = t.TempDir()
+	r := NewRunner(opts, &textProvider{})
+	r.taskManager = task.NewManager()
+	r.initTaskStore(opts.SdkSessionID)
+
+	const n = 20
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.taskManager.Create("Task", "", "", nil)
+		}()
+	}
+
+	// Changes only add tasks, so lists emitted in order never shrink. Two
+	// changes can share a snapshot, so lengths may repeat.
+	for prev := 0; prev < n; {
+		tasks := nextTaskList(t, r)
+		require.GreaterOrEqual(t, len(tasks), prev)
+		prev = len(tasks)
+	}
+	wg.Wait()
+}
@@ -16,7 +16,7 @@ import (
 type TranscriptEntry struct {
 	Timestamp time.Time          `json:"timestamp"`
 	SessionID string             `json:"session_id"`
-	ParentID  string             `json:"parent_id,omitempty"` // Parent session for sub-agents
+	ParentID  string             `json:"parent_id,omitempty"` // Parent session for sub-agents and forks
 	Message   provider.Message   `json:"message"`
 	Metadata  *TranscriptMeta    `json:"metadata,omitempty"` // First entry only
 }
@@ -30,6 +30,8 @@ type TranscriptMeta struct {
 	CostUSD         float64   `json:"cost_usd,omitempty"`
 	InputTokens     int       `json:"input_tokens,omitempty"`
 	OutputTokens    int       `json:"output_tokens,omitempty"`
+	ForkedFrom      string    `json:"forked_from,omitempty"` // Source session when created by ForkTranscript
+	ForkPoint       int       `json:"fork_point,omitempty"`  // Number of source messages copied into the fork
 }
 
 // TranscriptWriter appends messages to a JSONL transcript file.
@@ -176,7 +178,8 @@ func FindTranscript(transcriptDir, sessionID string) string {
 	return ""
 }
 
-// ListTranscripts returns all available session transcripts.
+// ListTranscripts returns all available session transcripts. Forks record
+// their source in ForkedFrom; see TranscriptTree for the fork tree.
 func ListTranscripts(transcriptDir string) ([]TranscriptSummary, error) {
 	entries, err := os.ReadDir(transcriptDir)
 	if err != nil {
@@ -206,19 +209,14 @@ func ListTranscripts(transcriptDir string) ([]TranscriptSummary, error) {
 		}
 
 		// Read metadata from first line (quick peek)
-		if f, err := os.Open(path); err == nil {
-			scanner := bufio.NewScanner(f)
-			if scanner.Scan() {
-				var entry TranscriptEntry
-				if json.Unmarshal(scanner.Bytes(), &entry) == nil && entry.Metadata != nil {
-					summary.Model = entry.Metadata.Model
-					summary.Title = entry.Metadata.Title
-					summary.Tags = entry.Metadata.Tags
-					summary.CreatedAt = entry.Metadata.CreatedAt
-					summary.CostUSD = entry.Metadata.CostUSD
-				}
-			}
-			f.Close()
+		if meta := readTranscriptMeta(path); meta != nil {
+			summary.Model = meta.Model
+			summary.Title = meta.Title
+			summary.Tags = meta.Tags
+			summary.CreatedAt = meta.CreatedAt
+			summary.CostUSD = meta.CostUSD
+			summary.ForkedFrom = meta.ForkedFrom
+			summary.ForkPoint = meta.ForkPoint
 		}
 
 		summaries = append(summaries, summary)
@@ -229,15 +227,17 @@ func ListTranscripts(transcriptDir string) ([]TranscriptSummary, error) {
 
 // TranscriptSummary is a lightweight summary of a session transcript.
 type TranscriptSummary struct {
-	SessionID string    `json:"session_id"`
-	Path      string    `json:"path"`
-	ModTime   time.Time `json:"mod_time"`
-	SizeBytes int64     `json:"size_bytes"`
-	Model     string    `json:"model,omitempty"`
-	Title     string    `json:"title,omitempty"`
-	Tags      []string  `json:"tags,omitempty"`
-	CreatedAt time.Time `json:"created_at,omitempty"`
-	CostUSD   float64   `json:"cost_usd,omitempty"`
+	SessionID  string    `json:"session_id"`
+	Path       string    `json:"path"`
+	ModTime    time.Time `json:"mod_time"`
+	SizeBytes  int64     `json:"size_bytes"`
+	Model      string    `json:"model,omitempty"`
+	Title      string    `json:"title,omitempty"`
+	Tags       []string  `json:"tags,omitempty"`
+	CreatedAt  time.Time `json:"created_at,omitempty"`
+	CostUSD    float64   `json:"cost_usd,omitempty"`
+	ForkedFrom string    `json:"forked_from,omitempty"` // Source session of a fork
+	ForkPoint  int       `json:"fork_point,omitempty"`  // Number of source messages the fork started from
 }
 
 // TranscriptDir returns the standard transcript directory.
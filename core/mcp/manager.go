@@ -162,6 +162,12 @@ func LoadMCPConfigFromSettings(settingsPath string) ([]ServerConfig, error) {
 	return parseMCPServerMap(settings.MCPServers)
 }
 
+// ParseServerMap parses an "mcpServers" object (server name -> config), as
+// found in managed settings.
+func ParseServerMap(servers map[string]json.RawMessage) ([]ServerConfig, error) {
+	return parseMCPServerMap(servers)
+}
+
 func loadMCPConfigFile(path string) ([]ServerConfig, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
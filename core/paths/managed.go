@@ -17,6 +17,13 @@ import (
 //	Linux:   /etc/claude-code/
 //	Windows: C:\Program Files\ClaudeCode\
 func ManagedSettingsDir() string {
+	managedDirMu.RLock()
+	override := managedDirOverride
+	managedDirMu.RUnlock()
+	if override != "" {
+		return override
+	}
+
 	switch runtime.GOOS {
 	case "darwin":
 		return "/Library/Application Support/ClaudeCode"
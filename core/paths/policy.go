@@ -0,0 +1,142 @@
+package paths
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+)
+
+// Settings that managed policy can lock (values of "lockedSettings"). A locked
+// setting is controlled by the managed settings alone: user, project and
+// session configuration cannot override it.
+const (
+	LockPermissionMode = "permissionMode" // Sessions run in permissions.defaultMode
+	LockPermissions    = "permissions"    // Only managed permission rules apply
+	LockAllowedModels  = "allowedModels"  // Only models in allowedModels may be used
+	LockMCPServers     = "mcpServers"     // Only managed MCP servers are connected
+	LockHooks          = "hooks"          // Only managed hooks run
+)
+
+// LockableSettings lists the settings managed policy can lock.
+var LockableSettings = []string{LockPermissionMode, LockPermissions, LockAllowedModels, LockMCPServers, LockHooks}
+
+// ManagedPolicy is the policy-relevant subset of the managed settings.
+//
+// Example managed-settings.json:
+//
+//	{
+//	  "permissions": { "deny": ["Bash(curl *)"], "defaultMode": "default" },
+//	  "network": { "allow": ["*.npmjs.org", "github.com"] },
+//	  "allowedModels": ["claude-sonnet-*"],
+//	  "lockedSettings": ["permissionMode", "mcpServers"]
+//	}
+type ManagedPolicy struct {
+	Permissions struct {
+		Allow       []string `json:"allow,omitempty"`
+		Deny        []string `json:"deny,omitempty"`
+		Ask         []string `json:"ask,omitempty"`
+		DefaultMode string   `json:"defaultMode,omitempty"`
+	} `json:"permissions"`
+	Network struct {
+		Allow []string `json:"allow,omitempty"` // Host patterns, e.g. "*.npmjs.org"
+		Deny  []string `json:"deny,omitempty"`
+	} `json:"network"`
+	AllowedModels  []string                   `json:"allowedModels,omitempty"`  // Exact IDs or prefixes ending in "*"
+	MCPServers     map[string]json.RawMessage `json:"mcpServers,omitempty"`     // Same format as settings.json
+	Hooks          json.RawMessage            `json:"hooks,omitempty"`          // Same format as settings.json
+	LockedSettings []string                   `json:"lockedSettings,omitempty"` // See LockableSettings
+}
+
+var (
+	managedDirMu       sync.RWMutex
+	managedDirOverride string
+)
+
+// SetManagedSettingsDir points managed settings at dir instead of the
+// platform directory and returns a function restoring the previous location.
+// Intended for tests.
+func SetManagedSettingsDir(dir string) (restore func()) {
+	managedDirMu.Lock()
+	prev := managedDirOverride
+	managedDirOverride = dir
+	managedDirMu.Unlock()
+	return func() {
+		managedDirMu.Lock()
+		managedDirOverride = prev
+		managedDirMu.Unlock()
+	}
+}
+
+// LoadManagedPolicy loads the managed policy. Returns nil when no managed
+// settings exist.
+func LoadManagedPolicy() *ManagedPolicy {
+	settings := LoadManagedSettings()
+	if settings == nil {
+		return nil
+	}
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return nil
+	}
+	var p ManagedPolicy
+	if json.Unmarshal(data, &p) != nil {
+		return nil
+	}
+	return &p
+}
+
+// IsLocked reports whether a setting is controlled by policy. Safe on a nil
+// policy. A non-empty allowedModels list always locks the model choice.
+func (p *ManagedPolicy) IsLocked(setting string) bool {
+	if p == nil {
+		return false
+	}
+	if setting == LockAllowedModels && len(p.AllowedModels) > 0 {
+		return true
+	}
+	for _, s := range p.LockedSettings {
+		if s == setting {
+			return true
+		}
+	}
+	return false
+}
+
+// Locked returns the locked settings in LockableSettings order.
+func (p *ManagedPolicy) Locked() []string {
+	locked := []string{}
+	for _, s := range LockableSettings {
+		if p.IsLocked(s) {
+			locked = append(locked, s)
+		}
+	}
+	return locked
+}
+
+// PermissionMode returns the permission mode policy imposes, or "" when the
+// mode is not locked. A locked mode without a defaultMode is "default".
+func (p *ManagedPolicy) PermissionMode() string {
+	if !p.IsLocked(LockPermissionMode) {
+		return ""
+	}
+	if p.Permissions.DefaultMode != "" {
+		return p.Permissions.DefaultMode
+	}
+	return "default"
+}
+
+// ModelAllowed reports whether policy permits a model. Safe on a nil policy.
+func (p *ManagedPolicy) ModelAllowed(model string) bool {
+	if p == nil || len(p.AllowedModels) == 0 {
+		return true
+	}
+	for _, allowed := range p.AllowedModels {
+		if allowed == model {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(allowed, "*"); ok && strings.HasPrefix(model, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,8 +1,12 @@
 package permission
 
 import (
+	"errors"
+	"fmt"
+	"path"
 	"strings"
-	"unicode"
+
+	"github.com/chatml/chatml-core/shell"
 )
 
 // BashParseResult is the result of bash command security analysis.
@@ -22,476 +26,480 @@ type SimpleCommand struct {
 
 // Redirect represents a shell I/O redirection.
 type Redirect struct {
-	Op     string // ">", ">>", "<", "2>", "2>>", "&>", "&>>"
+	Op     string // ">", ">>", "<", "2>", "2>>", "&>", "&>>", "2>&", "<<", "<<<", ...
 	Target string
 }
 
-// ParseBashForSecurity performs fail-closed bash command parsing.
-// Only interprets constructs on an explicit allowlist. Anything unrecognized
-// is classified as "too-complex" — never silently allows complex commands.
+// maxNesting bounds how deeply wrappers ("sudo env nohup ...") and nested
+// shell strings ("sh -c 'bash -c ...'") are unwrapped before giving up.
+const maxNesting = 8
+
+// ParseBashForSecurity parses a command with the full shell grammar and
+// returns every simple command it would run: the members of lists and
+// pipelines, the bodies of subshells, groups, loops, conditionals and
+// functions, command and process substitutions, here-document expansions,
+// and the targets of runners such as xargs, find -exec, sudo and sh -c.
 //
-// This is a pure-Go implementation matching Claude Code's tree-sitter-based
-// fail-closed approach without requiring CGO.
+// It stays fail-closed: a string that does not parse is "parse-error", and a
+// command whose name or nested script is only known at run time is
+// "too-complex".
 func ParseBashForSecurity(command string) BashParseResult {
 	command = strings.TrimSpace(command)
 	if command == "" {
 		return BashParseResult{Kind: "simple"}
 	}
 
-	// Reject obviously complex constructs outright
-	if containsComplexConstruct(command) {
-		return BashParseResult{
-			Kind:   "too-complex",
-			Reason: "command contains complex shell constructs",
-		}
-	}
-
-	// Split on allowed separators: &&, ||, |, ;, &
-	segments := splitOnSeparators(command)
-	if segments == nil {
-		return BashParseResult{
-			Kind:   "too-complex",
-			Reason: "failed to split command on separators",
-		}
-	}
-
-	var commands []SimpleCommand
-	for _, seg := range segments {
-		seg = strings.TrimSpace(seg)
-		if seg == "" {
-			continue
-		}
-
-		cmd, err := parseSimpleCommand(seg)
-		if err != "" {
-			return BashParseResult{
-				Kind:   "too-complex",
-				Reason: err,
-			}
-		}
-		if cmd != nil {
-			commands = append(commands, *cmd)
+	w := &bashWalker{}
+	if err := w.script(command); err != nil {
+		if errors.Is(err, shell.ErrTooDeep) {
+			return BashParseResult{Kind: "too-complex", Reason: err.Error()}
 		}
+		return BashParseResult{Kind: "parse-error", Reason: err.Error()}
 	}
-
-	return BashParseResult{
-		Kind:     "simple",
-		Commands: commands,
+	if w.reason != "" {
+		return BashParseResult{Kind: "too-complex", Reason: w.reason}
 	}
+	return BashParseResult{Kind: "simple", Commands: w.commands}
 }
 
-// IsDangerousCommandAST uses the AST parser for more accurate command analysis.
-// Falls back to the simple string-based check if parsing fails.
+// IsDangerousCommandAST reports whether any command a Bash string would run
+// invokes a dangerous program or writes to a dangerous path. Strings that
+// cannot be fully analyzed are always dangerous.
 func IsDangerousCommandAST(command string) bool {
 	result := ParseBashForSecurity(command)
-
-	switch result.Kind {
-	case "too-complex":
-		// Fail-closed: complex commands are always treated as dangerous
+	if result.Kind != "simple" {
 		return true
+	}
 
-	case "simple":
-		for _, cmd := range result.Commands {
-			if len(cmd.Argv) == 0 {
-				continue
-			}
-
-			// Check argv[0] against dangerous commands list
-			base := cmd.Argv[0]
-			if idx := strings.LastIndex(base, "/"); idx >= 0 {
-				base = base[idx+1:]
-			}
-			if dangerousCommands[base] {
+	for _, cmd := range result.Commands {
+		if len(cmd.Argv) > 0 && dangerousCommands[path.Base(cmd.Argv[0])] {
+			return true
+		}
+		for _, redir := range cmd.Redirects {
+			if isWriteRedirect(redir) && IsDangerousPath(redir.Target) {
 				return true
 			}
-
-			// Check for dangerous redirections (overwriting files)
-			for _, redir := range cmd.Redirects {
-				if redir.Op == ">" || redir.Op == "&>" {
-					// Overwriting a file — potentially dangerous
-					if IsDangerousPath(redir.Target) {
-						return true
-					}
-				}
-			}
 		}
-		return false
-
-	default:
-		// Parse error — fail closed
-		return true
 	}
+	return false
 }
 
-// containsComplexConstruct detects shell features that make the command too
-// complex for safe static analysis. Fail-closed: if we see any of these,
-// the command requires user approval.
-func containsComplexConstruct(cmd string) bool {
-	// Process substitution: <(...) or >(...)
-	if strings.Contains(cmd, "<(") || strings.Contains(cmd, ">(") {
-		return true
+// isWriteRedirect reports whether a redirection opens its target for
+// writing. Descriptor duplication ("2>&1", ">&-") writes no file.
+func isWriteRedirect(r Redirect) bool {
+	if !strings.Contains(r.Op, ">") {
+		return false
 	}
-
-	// Command substitution with backticks (harder to parse)
-	if strings.Contains(cmd, "`") {
-		return true
+	if strings.HasSuffix(r.Op, ">&") {
+		return strings.Trim(r.Target, "0123456789-") != ""
 	}
+	return true
+}
 
-	// Here documents: check for << that isn't part of <<< (here-string)
-	if strings.Contains(cmd, "<<") {
-		// Remove all <<< occurrences, then check if << still remains
-		temp := strings.ReplaceAll(cmd, "<<<", "")
-		if strings.Contains(temp, "<<") {
-			return true
-		}
-	}
+// bashWalker collects the simple commands of a parsed script.
+type bashWalker struct {
+	commands []SimpleCommand
+	reason   string // First reason the script is too complex
+	depth    int
+}
 
-	// Function definitions
-	if strings.Contains(cmd, "() {") || strings.Contains(cmd, "(){") {
-		return true
+func (w *bashWalker) fail(format string, args ...any) {
+	if w.reason == "" {
+		w.reason = fmt.Sprintf(format, args...)
 	}
+}
 
-	// Arithmetic evaluation
-	// NOTE: Bare (( may false-positive on nested parens in arguments. This is fail-closed (safe direction).
-	if strings.Contains(cmd, "$((") || strings.Contains(cmd, "((") {
-		return true
+// script parses src and walks every command in it.
+func (w *bashWalker) script(src string) error {
+	f, err := shell.Parse(src)
+	if err != nil {
+		return err
 	}
-
-	// Array syntax
-	if strings.Contains(cmd, "=(") {
+	shell.Walk(f, func(n any) bool {
+		if w.reason != "" {
+			return false
+		}
+		switch n := n.(type) {
+		case *shell.CallExpr:
+			w.call(n)
+		case *shell.Subshell:
+			w.compoundRedirects(n.Redirs)
+		case *shell.Block:
+			w.compoundRedirects(n.Redirs)
+		case *shell.IfClause:
+			w.compoundRedirects(n.Redirs)
+		case *shell.WhileClause:
+			w.compoundRedirects(n.Redirs)
+		case *shell.ForClause:
+			w.compoundRedirects(n.Redirs)
+		case *shell.CaseClause:
+			w.compoundRedirects(n.Redirs)
+		case *shell.ArithmCmd:
+			w.compoundRedirects(n.Redirs)
+		case *shell.TestClause:
+			w.compoundRedirects(n.Redirs)
+		}
 		return true
-	}
+	})
+	return nil
+}
 
-	// Control flow keywords that indicate complex scripts
-	for _, kw := range []string{" if ", " then ", " else ", " elif ", " fi ",
-		" for ", " while ", " until ", " do ", " done ",
-		" case ", " esac ", " select "} {
-		if strings.Contains(" "+cmd+" ", kw) {
-			return true
-		}
+// nested walks a shell string a command hands to another shell.
+func (w *bashWalker) nested(src, what string) {
+	if w.depth >= maxNesting {
+		w.fail("%s is nested too deeply", what)
+		return
+	}
+	w.depth++
+	defer func() { w.depth-- }()
+	if err := w.script(src); err != nil {
+		w.fail("%s does not parse: %v", what, err)
 	}
-
-	return false
 }
 
-// splitOnSeparators splits a command on &&, ||, |, ;, & while respecting quotes.
-func splitOnSeparators(cmd string) []string {
-	var segments []string
-	var current strings.Builder
-	i := 0
-	inSingleQuote := false
-	inDoubleQuote := false
-
-	for i < len(cmd) {
-		ch := cmd[i]
-
-		// Handle quotes
-		if ch == '\'' && !inDoubleQuote {
-			inSingleQuote = !inSingleQuote
-			current.WriteByte(ch)
-			i++
-			continue
-		}
-		if ch == '"' && !inSingleQuote {
-			inDoubleQuote = !inDoubleQuote
-			current.WriteByte(ch)
-			i++
-			continue
-		}
-
-		// Inside quotes, everything is literal
-		if inSingleQuote || inDoubleQuote {
-			current.WriteByte(ch)
-			i++
-			continue
-		}
+// compoundRedirects records the redirections of a compound command as a
+// command without argv, so redirect checks still see them.
+func (w *bashWalker) compoundRedirects(redirs []*shell.Redirect) {
+	if len(redirs) > 0 {
+		w.commands = append(w.commands, SimpleCommand{Redirects: convertRedirects(redirs)})
+	}
+}
 
-		// Check for separators
-		if ch == '&' && i+1 < len(cmd) && cmd[i+1] == '&' {
-			segments = append(segments, current.String())
-			current.Reset()
-			i += 2
-			continue
-		}
-		if ch == '|' && i+1 < len(cmd) && cmd[i+1] == '|' {
-			segments = append(segments, current.String())
-			current.Reset()
-			i += 2
-			continue
+func (w *bashWalker) call(c *shell.CallExpr) {
+	var env map[string]string
+	if len(c.Assigns) > 0 {
+		env = make(map[string]string, len(c.Assigns))
+		for _, a := range c.Assigns {
+			env[a.Name] = assignValue(a)
 		}
-		if ch == '|' {
-			segments = append(segments, current.String())
-			current.Reset()
-			i++
-			continue
-		}
-		if ch == ';' {
-			segments = append(segments, current.String())
-			current.Reset()
-			i++
-			continue
-		}
-		if ch == '&' {
-			// Background operator — treat as separator
-			segments = append(segments, current.String())
-			current.Reset()
-			i++
-			continue
-		}
-
-		current.WriteByte(ch)
-		i++
 	}
+	w.run(c.Args, env, convertRedirects(c.Redirs), c.Text, stdinScript(c.Redirs))
+}
 
-	// Unclosed quotes — fail closed
-	if inSingleQuote || inDoubleQuote {
-		return nil
+// run records the command formed by words and descends into any command it
+// runs on the caller's behalf. stdin is the here-document or here-string fed
+// to the command, if any.
+func (w *bashWalker) run(words []*shell.Word, env map[string]string, redirs []Redirect, text string, stdin *shell.Word) {
+	if len(words) == 0 {
+		w.commands = append(w.commands, SimpleCommand{EnvVars: env, Redirects: redirs, Text: text})
+		return
 	}
-
-	if current.Len() > 0 {
-		segments = append(segments, current.String())
+	if _, ok := words[0].Lit(); !ok {
+		w.fail("command name %s is only known at run time", words[0].Raw)
+		return
 	}
-
-	return segments
-}
-
-// parseSimpleCommand parses a single command segment into a SimpleCommand.
-// Returns nil if the segment is empty, or an error reason if too complex.
-func parseSimpleCommand(segment string) (*SimpleCommand, string) {
-	tokens := tokenize(segment)
-	if len(tokens) == 0 {
-		return nil, ""
+	if w.depth >= maxNesting {
+		w.fail("command wrappers are nested too deeply")
+		return
 	}
 
-	cmd := &SimpleCommand{
-		EnvVars: make(map[string]string),
-		Text:    segment,
+	argv := make([]string, len(words))
+	for i, word := range words {
+		argv[i] = wordValue(word)
+	}
+	cmd := SimpleCommand{Argv: argv, EnvVars: env, Redirects: redirs, Text: text}
+	name := path.Base(argv[0])
+
+	// Transparent wrappers only change how the inner command runs; the inner
+	// command stands in for the whole.
+	if unwrap, ok := transparentWrappers[name]; ok {
+		if env == nil {
+			env = map[string]string{}
+		}
+		start, reason := unwrap(argv, env)
+		if reason != "" {
+			w.fail("%s", reason)
+			return
+		}
+		if start <= 0 || start >= len(words) {
+			w.commands = append(w.commands, cmd)
+			return
+		}
+		w.depth++
+		w.run(words[start:], env, redirs, rawText(words[start:]), stdin)
+		w.depth--
+		return
 	}
 
-	i := 0
-
-	// Parse leading VAR=VALUE assignments
-	for i < len(tokens) {
-		tok := tokens[i]
-		if eqIdx := strings.Index(tok, "="); eqIdx > 0 && !strings.HasPrefix(tok, "-") {
-			key := tok[:eqIdx]
-			if isValidEnvName(key) {
-				cmd.EnvVars[key] = tok[eqIdx+1:]
-				i++
-				continue
+	w.commands = append(w.commands, cmd)
+	w.depth++
+	defer func() { w.depth-- }()
+
+	switch name {
+	case "xargs":
+		w.runInner(words, skipOptions(argv, "adEILnPs", "--arg-file", "--delimiter", "--eof", "--max-lines", "--max-args", "--max-procs", "--max-chars", "--process-slot-var"))
+	case "sudo":
+		w.runInner(words, skipOptions(argv, "ugCDhprtTU", "--user", "--group", "--close-from", "--chdir", "--host", "--prompt", "--role", "--type", "--other-user", "--command-timeout"))
+	case "doas":
+		w.runInner(words, skipOptions(argv, "uC"))
+	case "strace", "ltrace":
+		w.runInner(words, skipOptions(argv, "abeEIoOpPsSuUXn", "--output", "--attach", "--user", "--env"))
+	case "exec":
+		w.runInner(words, skipOptions(argv, "a"))
+	case "find":
+		for i := 1; i < len(argv); i++ {
+			switch argv[i] {
+			case "-exec", "-execdir", "-ok", "-okdir":
+				end := i + 1
+				for end < len(argv) && argv[end] != ";" && argv[end] != "+" {
+					end++
+				}
+				if end > i+1 {
+					w.run(words[i+1:end], nil, nil, rawText(words[i+1:end]), nil)
+				}
+				i = end
 			}
 		}
-		break
+	case "watch":
+		if start := skipOptions(argv, "dnq", "--differences", "--interval", "--equexit"); start < len(argv) {
+			if src, ok := joinLiterals(words[start:]); ok {
+				w.nested(src, "watch command")
+			} else {
+				w.fail("watch command is only known at run time")
+			}
+		}
+	case "eval":
+		if len(words) > 1 {
+			if src, ok := joinLiterals(words[1:]); ok {
+				w.nested(src, "eval string")
+			} else {
+				w.fail("eval string is only known at run time")
+			}
+		}
+	case "sh", "bash", "zsh", "dash", "ksh", "mksh", "ash", "fish":
+		w.shell(words, argv, stdin)
 	}
+}
 
-	// Skip command wrappers (env, command, nohup, time, nice, etc.)
-	commandWrappers := map[string]bool{
-		"env": true, "command": true, "xargs": true,
-		"nohup": true, "time": true, "nice": true, "ionice": true, "strace": true,
+// runInner records the command starting at words[start], if any.
+func (w *bashWalker) runInner(words []*shell.Word, start int) {
+	if start > 0 && start < len(words) {
+		w.run(words[start:], nil, nil, rawText(words[start:]), nil)
 	}
-	for i < len(tokens) {
-		tok := stripQuotes(tokens[i])
-		if !commandWrappers[tok] {
-			break
-		}
-		i++
-		// Skip flags after wrapper
-		for i < len(tokens) && strings.HasPrefix(tokens[i], "-") {
+}
+
+// shell handles "sh -c script" and scripts fed to a shell on stdin.
+func (w *bashWalker) shell(words []*shell.Word, argv []string, stdin *shell.Word) {
+	hasC := false
+	i := 1
+	for i < len(argv) {
+		a := argv[i]
+		if a == "--" {
 			i++
+			break
 		}
-		// Skip any env var assignments after wrapper
-		for i < len(tokens) {
-			t := tokens[i]
-			if eqIdx := strings.Index(t, "="); eqIdx > 0 && !strings.HasPrefix(t, "-") {
-				key := t[:eqIdx]
-				if isValidEnvName(key) {
-					cmd.EnvVars[key] = t[eqIdx+1:]
-					i++
-					continue
-				}
-			}
+		if len(a) < 2 || (a[0] != '-' && a[0] != '+') {
 			break
 		}
-	}
-
-	// Parse command and arguments
-	for i < len(tokens) {
-		tok := tokens[i]
-
-		// Check for redirections
-		if isRedirectionOp(tok) {
-			if i+1 >= len(tokens) {
-				return nil, "redirection without target"
-			}
-			cmd.Redirects = append(cmd.Redirects, Redirect{
-				Op:     tok,
-				Target: stripQuotes(tokens[i+1]),
-			})
+		switch {
+		case a == "-o" || a == "+o" || a == "-O" || a == "+O":
 			i += 2
 			continue
+		case a[0] == '-' && a[1] != '-' && strings.Contains(a, "c"):
+			hasC = true
 		}
-
-		// Check for $(...) command substitution
-		// NOTE: $( inside single-quoted strings is a false positive (not a real substitution),
-		// but this is fail-closed (safe direction).
-		if strings.Contains(tok, "$(") {
-			return nil, "command substitution ($(...)) detected"
-		}
-
-		cmd.Argv = append(cmd.Argv, stripQuotes(tok))
 		i++
 	}
 
-	if len(cmd.Argv) == 0 && len(cmd.EnvVars) > 0 {
-		// Just variable assignments, no command — safe
-		return cmd, ""
+	switch {
+	case hasC:
+		if i >= len(words) {
+			w.fail("%s -c has no command string", argv[0])
+			return
+		}
+		src, ok := words[i].Lit()
+		if !ok {
+			w.fail("%s -c command string is only known at run time", argv[0])
+			return
+		}
+		w.nested(src, argv[0]+" -c string")
+	case i >= len(argv) && stdin != nil:
+		src, ok := stdin.Lit()
+		if !ok {
+			w.fail("%s stdin script is only known at run time", argv[0])
+			return
+		}
+		w.nested(src, argv[0]+" stdin script")
 	}
-
-	return cmd, ""
 }
 
-// tokenize splits a command into tokens, respecting quotes.
-func tokenize(cmd string) []string {
-	var tokens []string
-	var current strings.Builder
-	i := 0
-	inSingleQuote := false
-	inDoubleQuote := false
-	// escaped tracks whether the PREVIOUS character was an unescaped backslash
-	// inside double quotes. This correctly handles \" (escaped quote) vs \\"
-	// (escaped backslash + closing quote). Reset after each character is consumed.
-	escaped := false
-
-	for i < len(cmd) {
-		ch := cmd[i]
-
-		if ch == '\'' && !inDoubleQuote {
-			inSingleQuote = !inSingleQuote
-			current.WriteByte(ch)
-			i++
-			escaped = false
-			continue
-		}
-		if ch == '"' && !inSingleQuote {
-			if inDoubleQuote && escaped {
-				// \" inside double quotes — literal quote, not end-of-string
-				current.WriteByte(ch)
+// transparentWrappers maps wrapper programs to a function returning the
+// index of the wrapped command in argv (0 or past the end when there is
+// none). Assignments the wrapper makes are added to env; a non-empty reason
+// marks the command too complex to analyze.
+var transparentWrappers = map[string]func(argv []string, env map[string]string) (int, string){
+	"env": func(argv []string, env map[string]string) (int, string) {
+		i := 1
+		for i < len(argv) {
+			a := argv[i]
+			switch {
+			case a == "--":
 				i++
-				escaped = false
-				continue
-			}
-			inDoubleQuote = !inDoubleQuote
-			current.WriteByte(ch)
-			i++
-			escaped = false
-			continue
-		}
-
-		if inSingleQuote {
-			current.WriteByte(ch)
-			i++
-			continue
-		}
-
-		if inDoubleQuote {
-			// Inside double quotes, backslash escapes: \" \$ \` \\ \newline
-			if ch == '\\' && i+1 < len(cmd) {
-				next := cmd[i+1]
-				current.WriteByte(ch)
-				current.WriteByte(next)
+			case a == "-S" || strings.HasPrefix(a, "--split-string") || (strings.HasPrefix(a, "-") && !strings.HasPrefix(a, "--") && strings.Contains(a, "S")):
+				return 0, "env -S splits its command at run time"
+			case a == "-u" || a == "-C" || a == "--unset" || a == "--chdir":
 				i += 2
-				// \\ consumes both — the second \ is NOT an escape prefix.
-				// Only a single \ before " $ ` or newline sets escaped=true.
-				escaped = next != '\\'
+				continue
+			case strings.HasPrefix(a, "-"):
+				i++
 				continue
 			}
-			current.WriteByte(ch)
-			i++
-			escaped = false
-			continue
-		}
-
-		// Escape character (outside quotes)
-		if ch == '\\' && i+1 < len(cmd) {
-			current.WriteByte(ch)
-			current.WriteByte(cmd[i+1])
-			i += 2
-			continue
+			break
 		}
-
-		// Whitespace breaks tokens
-		if unicode.IsSpace(rune(ch)) {
-			if current.Len() > 0 {
-				tokens = append(tokens, current.String())
-				current.Reset()
+		for i < len(argv) {
+			name, value, ok := strings.Cut(argv[i], "=")
+			if !ok || !isValidEnvName(name) {
+				break
 			}
+			env[name] = value
 			i++
-			continue
 		}
-
-		// Redirection operators
-		if ch == '>' || ch == '<' {
-			if current.Len() > 0 {
-				tokens = append(tokens, current.String())
-				current.Reset()
+		return i, ""
+	},
+	"command": func(argv []string, _ map[string]string) (int, string) {
+		i := 1
+		for i < len(argv) && strings.HasPrefix(argv[i], "-") {
+			if argv[i] == "--" {
+				return i + 1, ""
 			}
-			// Handle multi-char redirections: >>, 2>, 2>>, &>, &>>
-			op := string(ch)
-			if ch == '>' && i+1 < len(cmd) && cmd[i+1] == '>' {
-				op = ">>"
-				i++
+			if strings.ContainsAny(argv[i], "vV") {
+				return 0, "" // Lookup only; nothing runs
 			}
-			tokens = append(tokens, op)
 			i++
-			continue
 		}
-
-		// Handle 2> and 2>> (stderr redirection)
-		// Only match 2> as stderr redirect when '2' starts a new token (not mid-word like "abc2>")
-		if ch == '2' && i+1 < len(cmd) && cmd[i+1] == '>' && current.Len() == 0 {
-			if i+2 < len(cmd) && cmd[i+2] == '>' {
-				tokens = append(tokens, "2>>")
-				i += 3
-			} else {
-				tokens = append(tokens, "2>")
-				i += 2
+		return i, ""
+	},
+	"builtin": func(argv []string, _ map[string]string) (int, string) {
+		return 1, ""
+	},
+	"nohup": func(argv []string, _ map[string]string) (int, string) {
+		return skipOptions(argv, ""), ""
+	},
+	"time": func(argv []string, _ map[string]string) (int, string) {
+		return skipOptions(argv, "fo", "--format", "--output"), ""
+	},
+	"nice": func(argv []string, _ map[string]string) (int, string) {
+		return skipOptions(argv, "n", "--adjustment"), ""
+	},
+	"ionice": func(argv []string, _ map[string]string) (int, string) {
+		for _, a := range argv[1:] {
+			if a == "-p" || a == "-P" || a == "-u" || a == "--pid" || a == "--pgid" || a == "--uid" {
+				return 0, "" // Changes an existing process
 			}
-			continue
 		}
+		return skipOptions(argv, "cn", "--class", "--classdata"), ""
+	},
+	"timeout": func(argv []string, _ map[string]string) (int, string) {
+		return skipOptions(argv, "sk", "--signal", "--kill-after") + 1, "" // Skip the duration
+	},
+	"stdbuf": func(argv []string, _ map[string]string) (int, string) {
+		return skipOptions(argv, "ioe", "--input", "--output", "--error"), ""
+	},
+}
 
-		// Handle &> and &>>
-		if ch == '&' && i+1 < len(cmd) && cmd[i+1] == '>' {
-			if current.Len() > 0 {
-				tokens = append(tokens, current.String())
-				current.Reset()
-			}
-			if i+2 < len(cmd) && cmd[i+2] == '>' {
-				tokens = append(tokens, "&>>")
-				i += 3
-			} else {
-				tokens = append(tokens, "&>")
-				i += 2
+// skipOptions returns the index of the first operand in argv, skipping
+// options. Short options listed in argFlags and the given long options take
+// a value, either attached ("-n5", "--max-args=5") or as the next argument.
+func skipOptions(argv []string, argFlags string, longArgs ...string) int {
+	i := 1
+	for i < len(argv) {
+		a := argv[i]
+		if a == "--" {
+			return i + 1
+		}
+		if len(a) < 2 || a[0] != '-' {
+			return i
+		}
+		if strings.HasPrefix(a, "--") {
+			i++
+			for _, long := range longArgs {
+				if a == long {
+					i++
+					break
+				}
 			}
 			continue
 		}
-
-		current.WriteByte(ch)
 		i++
+		for j := 1; j < len(a); j++ {
+			if strings.IndexByte(argFlags, a[j]) >= 0 {
+				if j == len(a)-1 {
+					i++ // Value is the next argument
+				}
+				break
+			}
+		}
+	}
+	return i
+}
+
+// wordValue returns the literal value of a word, or its source text when
+// it contains expansions.
+func wordValue(w *shell.Word) string {
+	if v, ok := w.Lit(); ok {
+		return v
+	}
+	return w.Raw
+}
+
+func assignValue(a *shell.Assign) string {
+	if a.Array != nil {
+		return "(" + rawText(a.Array) + ")"
+	}
+	if a.Value == nil {
+		return ""
 	}
+	return wordValue(a.Value)
+}
 
-	if current.Len() > 0 {
-		tokens = append(tokens, current.String())
+// rawText joins the source text of words with single spaces.
+func rawText(words []*shell.Word) string {
+	parts := make([]string, len(words))
+	for i, w := range words {
+		parts[i] = w.Raw
 	}
+	return strings.Join(parts, " ")
+}
 
-	return tokens
+// joinLiterals joins literal words with spaces the way eval and watch do.
+// ok is false when any word is expanded at run time.
+func joinLiterals(words []*shell.Word) (string, bool) {
+	parts := make([]string, len(words))
+	for i, w := range words {
+		v, ok := w.Lit()
+		if !ok {
+			return "", false
+		}
+		parts[i] = v
+	}
+	return strings.Join(parts, " "), true
 }
 
-func isRedirectionOp(tok string) bool {
-	switch tok {
-	case ">", ">>", "<", "2>", "2>>", "&>", "&>>":
-		return true
+func convertRedirects(redirs []*shell.Redirect) []Redirect {
+	var out []Redirect
+	for _, r := range redirs {
+		target := ""
+		if r.Word != nil {
+			target = wordValue(r.Word)
+		}
+		out = append(out, Redirect{Op: r.Op, Target: target})
 	}
-	return false
+	return out
+}
+
+// stdinScript returns the here-document body or here-string fed to a
+// command, or nil.
+func stdinScript(redirs []*shell.Redirect) *shell.Word {
+	var stdin *shell.Word
+	for _, r := range redirs {
+		switch r.Op {
+		case "<<", "<<-":
+			stdin = r.Hdoc
+		case "<<<":
+			stdin = r.Word
+		}
+	}
+	return stdin
 }
 
 func isValidEnvName(name string) bool {
@@ -499,34 +507,10 @@ func isValidEnvName(name string) bool {
 		return false
 	}
 	for i, ch := range name {
-		if i == 0 && !unicode.IsLetter(ch) && ch != '_' {
-			return false
-		}
-		if !unicode.IsLetter(ch) && !unicode.IsDigit(ch) && ch != '_' {
-			return false
+		if ch == '_' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || i > 0 && ch >= '0' && ch <= '9' {
+			continue
 		}
+		return false
 	}
 	return true
 }
-
-// stripQuotes removes all quote characters from a token to extract the effective
-// command name. In bash, concatenated quoted strings like 'cu'"rl" evaluate to
-// "curl". Simply stripping matching outer quotes would miss this case, so we
-// remove all single and double quotes entirely. This is intentionally aggressive:
-// a false positive (flagging a safe command) is better than a false negative
-// (missing a dangerous command hidden behind quote concatenation).
-func stripQuotes(s string) string {
-	// Fast path: no quotes at all
-	if !strings.ContainsAny(s, `'"`) {
-		return s
-	}
-
-	var b strings.Builder
-	b.Grow(len(s))
-	for _, ch := range s {
-		if ch != '\'' && ch != '"' {
-			b.WriteRune(ch)
-		}
-	}
-	return b.String()
-}
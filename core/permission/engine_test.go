@@ -774,3 +774,36 @@ func TestEngine_SubAgentEngine_PlanModeDeniesStateModifyingTools(t *testing.T) {
 	result = e.Check("NotebookEdit", fileInput("notebook.ipynb"))
 	assert.Equal(t, Deny, result.Decision)
 }
+
+// --- Decision source tests ---
+
+func TestEngine_DecidedBy(t *testing.T) {
+	rs := NewRuleSet([]Rule{
+		{Tool: "Bash", Specifier: "npm *", Action: "allow", Source: SourceProject},
+		{Tool: "Bash", Specifier: "curl *", Action: "deny", Source: SourceUser},
+		{Tool: "Bash", Specifier: "make *", Action: "ask", Source: SourceLocal},
+	})
+	rs.AddPolicyRules([]Rule{{Tool: "Bash", Specifier: "ssh *", Action: "deny", Source: SourcePolicy}})
+	e := NewEngine(ModeDefault, rs)
+
+	assert.Equal(t, DecidedByPolicy, e.Check("Bash", bashInput("ssh host")).DecidedBy)
+	assert.Equal(t, "rule:project", e.Check("Bash", bashInput("npm test")).DecidedBy)
+	assert.Equal(t, "rule:user", e.Check("Bash", bashInput("curl example.com")).DecidedBy)
+	assert.Equal(t, "rule:local", e.Check("Bash", bashInput("make build")).DecidedBy)
+	assert.Equal(t, DecidedByBuiltin, e.Check("Read", fileInput("main.go")).DecidedBy)
+	assert.Equal(t, DecidedByDefault, e.Check("Bash", bashInput("echo hi")).DecidedBy)
+
+	e.RecordApproval("Bash:echo hi", ApprovalResponse{Action: "allow_session"})
+	assert.Equal(t, DecidedBySession, e.Check("Bash", bashInput("echo hi")).DecidedBy)
+
+	e.SetMode(ModeBypassPermissions)
+	result := e.Check("Bash", bashInput("ls"))
+	assert.Equal(t, Allow, result.Decision)
+	assert.Equal(t, DecidedByMode, result.DecidedBy)
+}
+
+func TestDecision_String(t *testing.T) {
+	assert.Equal(t, "allow", Allow.String())
+	assert.Equal(t, "deny", Deny.String())
+	assert.Equal(t, "ask", NeedApproval.String())
+}
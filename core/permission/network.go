@@ -0,0 +1,169 @@
+package permission
+
+import (
+	"net"
+	"strings"
+)
+
+// NetworkTool is the pseudo-tool name egress rules are written against. Its
+// specifier is a host name or a wildcard pattern over host names, e.g.
+// "Network(registry.npmjs.org)" or "Network(*.github.com)". Egress rules live
+// alongside the other permission rules, so approvals, allow_always/deny_always
+// persistence and the audit log work the same way for network access.
+const NetworkTool = "Network"
+
+// NetworkRules converts the "network" section of a settings file into
+// Network rules. Entries are bare host patterns ("*.npmjs.org"); a
+// "Network(...)" wrapper is accepted too.
+func NetworkRules(allow, deny []string, source RuleSource) []Rule {
+	var rules []Rule
+	add := func(patterns []string, action string) {
+		for _, p := range patterns {
+			p = strings.TrimSpace(p)
+			if p == "" {
+				continue
+			}
+			r := ParsePermissionRule(p)
+			if r.Tool != NetworkTool {
+				r = Rule{Tool: NetworkTool, Specifier: NormalizeHost(p)}
+			} else {
+				r.Specifier = NormalizeHost(r.Specifier)
+			}
+			r.Action = action
+			r.Source = source
+			rules = append(rules, r)
+		}
+	}
+	add(allow, "allow")
+	add(deny, "deny")
+	return rules
+}
+
+// NormalizeHost lowercases a host name and strips a trailing dot, IPv6
+// brackets and any port.
+func NormalizeHost(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if strings.HasPrefix(host, "[") {
+		if i := strings.Index(host, "]"); i > 0 {
+			host = host[1:i]
+		}
+	} else if i := strings.LastIndex(host, ":"); i > 0 && strings.Count(host, ":") == 1 {
+		host = host[:i]
+	}
+	return strings.TrimSuffix(host, ".")
+}
+
+// HasToolRules reports whether any rule, policy rules included, targets
+// toolName, and whether any of those is an allow rule.
+func (rs *RuleSet) HasToolRules(toolName string) (found, allow bool) {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	for _, list := range [][]Rule{rs.policy, rs.rules} {
+		for _, r := range list {
+			if r.Tool != toolName {
+				continue
+			}
+			found = true
+			if r.Action == "allow" {
+				allow = true
+			}
+		}
+	}
+	return found, allow
+}
+
+// NetworkRestricted reports whether any egress rule is configured. Without
+// one, network access is unrestricted and callers can skip the egress proxy.
+func (e *Engine) NetworkRestricted() bool {
+	restricted, _ := e.rules.HasToolRules(NetworkTool)
+	return restricted
+}
+
+// CheckNetwork evaluates egress rules for a connection to host. Loopback
+// hosts are always allowed; otherwise the order is managed policy, session
+// approvals, persistent rules. A host no rule covers
+// is allowed unless an allow rule exists: an allowlist makes every other host
+// ask for approval (or fail in dontAsk mode). Permission modes other than
+// dontAsk do not apply; bypassPermissions does not lift egress rules.
+func (e *Engine) CheckNetwork(host string) CheckResult {
+	host = NormalizeHost(host)
+	result := CheckResult{
+		Specifier: host,
+		RuleKey:   NetworkTool + ":" + host,
+	}
+
+	if isLoopbackHost(host) {
+		result.Decision = Allow
+		result.DecidedBy = DecidedByBuiltin
+		return result
+	}
+
+	switch e.rules.EvaluatePolicy(NetworkTool, host) {
+	case "deny":
+		result.Decision = Deny
+		result.DenyMessage = "Network access denied by managed policy"
+		result.DecidedBy = DecidedByPolicy
+		return result
+	case "ask":
+		result.Decision = NeedApproval
+		result.DecidedBy = DecidedByPolicy
+		return result
+	case "allow":
+		result.Decision = Allow
+		result.DecidedBy = DecidedByPolicy
+		return result
+	}
+
+	if action, ok := e.lookupSessionApproval(result.RuleKey, NetworkTool, host); ok {
+		result.DecidedBy = DecidedBySession
+		if action == "allow" {
+			result.Decision = Allow
+		} else {
+			result.Decision = Deny
+			result.DenyMessage = "Network access denied by session rule"
+		}
+		return result
+	}
+
+	rule, matched := e.rules.Match(NetworkTool, host)
+	switch rule.Action {
+	case "deny":
+		result.Decision = Deny
+		result.DenyMessage = "Network access denied by egress rule"
+		result.DecidedBy = DecidedByRule(rule.Source)
+		return result
+	case "allow":
+		result.Decision = Allow
+		result.DecidedBy = DecidedByRule(rule.Source)
+		return result
+	}
+
+	if _, allowlist := e.rules.HasToolRules(NetworkTool); !allowlist && !matched {
+		result.Decision = Allow
+		result.DecidedBy = DecidedByDefault
+		return result
+	}
+
+	if e.Mode() == ModeDontAsk {
+		result.Decision = Deny
+		result.DenyMessage = "Network access not pre-approved (dontAsk mode)"
+		result.DecidedBy = DecidedByMode
+		return result
+	}
+	result.Decision = NeedApproval
+	result.DecidedBy = DecidedByDefault
+	if matched {
+		result.DecidedBy = DecidedByRule(rule.Source)
+	}
+	return result
+}
+
+// isLoopbackHost reports whether host names this machine. Local dev servers
+// are not egress.
+func isLoopbackHost(host string) bool {
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
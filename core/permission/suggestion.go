@@ -39,6 +39,12 @@ func SuggestWildcard(toolName, specifier string) *Suggestion {
 			}
 		}
 		return nil
+	case NetworkTool:
+		// specifier is the host; a broader pattern is for the user to write
+		return &Suggestion{
+			Label:     fmt.Sprintf("Yes, allow connections to %s", specifier),
+			Specifier: specifier,
+		}
 	default:
 		return nil
 	}
@@ -72,6 +72,13 @@ func TestSuggestWildcard_WebFetch_Domain(t *testing.T) {
 	assert.Contains(t, s.Label, "api.example.com")
 }
 
+func TestSuggestWildcard_NetworkHost(t *testing.T) {
+	s := SuggestWildcard(NetworkTool, "registry.npmjs.org")
+	assert.NotNil(t, s)
+	assert.Equal(t, "registry.npmjs.org", s.Specifier)
+	assert.Equal(t, "Yes, allow connections to registry.npmjs.org", s.Label)
+}
+
 func TestSuggestWildcard_UnknownTool(t *testing.T) {
 	s := SuggestWildcard("UnknownTool", "anything")
 	assert.Nil(t, s)
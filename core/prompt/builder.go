@@ -59,10 +59,27 @@ func (b *Builder) SetToolPrompts(prompts []string) {
 	b.toolPrompts = prompts
 }
 
+// Section is one named part of the system prompt, e.g. "claude_md" or
+// "environment". Names are stable so callers can attribute token usage.
+type Section struct {
+	Name string
+	Text string
+}
+
 // Build assembles the full system prompt from all sections.
+func (b *Builder) Build() string {
+	sections := b.Sections()
+	texts := make([]string, len(sections))
+	for i, s := range sections {
+		texts[i] = s.Text
+	}
+	return strings.Join(texts, "\n\n")
+}
+
+// Sections returns the non-empty system prompt sections in prompt order.
 // I/O-bound sections (CLAUDE.md, memory) are loaded in parallel goroutines
 // while CPU-bound sections are computed on the main goroutine.
-func (b *Builder) Build() string {
+func (b *Builder) Sections() []Section {
 	// Start I/O-bound sections in parallel goroutines
 	claudeMDCh := make(chan string, 1)
 	memoryCh := make(chan string, 1)
@@ -70,36 +87,32 @@ func (b *Builder) Build() string {
 	go func() { memoryCh <- b.memorySection() }()
 
 	// Compute CPU-bound sections on the main goroutine (fast, ~1-2ms)
-	var sections []string
-	sections = append(sections, b.coreSection())
-	sections = append(sections, b.systemSection())
-	sections = append(sections, b.environmentSection())
-	sections = append(sections, b.toolGuidelines())
-
-	if tp := b.toolPromptSection(); tp != "" {
-		sections = append(sections, tp)
+	var sections []Section
+	add := func(name, text string) {
+		if text != "" {
+			sections = append(sections, Section{Name: name, Text: text})
+		}
 	}
+	add("core", b.coreSection())
+	add("system", b.systemSection())
+	add("environment", b.environmentSection())
+	add("tool_guidelines", b.toolGuidelines())
+	add("tool_prompts", b.toolPromptSection())
 
 	// Collect I/O results (blocks until both goroutines complete)
-	if claudeMD := <-claudeMDCh; claudeMD != "" {
-		sections = append(sections, claudeMD)
-	}
-	if memory := <-memoryCh; memory != "" {
-		sections = append(sections, memory)
-	}
+	add("claude_md", <-claudeMDCh)
+	add("memory", <-memoryCh)
 
 	// Auto-memory guidance (after memory content, so the model knows the system exists)
-	sections = append(sections, b.autoMemorySection())
+	add("auto_memory", b.autoMemorySection())
 
 	// Remaining CPU-bound sections
-	if gs := b.gitStatusSection(); gs != "" {
-		sections = append(sections, gs)
-	}
+	add("git_status", b.gitStatusSection())
 	if b.cfg.Instructions != "" {
-		sections = append(sections, fmt.Sprintf("# Additional Instructions\n\n%s", b.cfg.Instructions))
+		add("instructions", fmt.Sprintf("# Additional Instructions\n\n%s", b.cfg.Instructions))
 	}
 
-	return strings.Join(sections, "\n\n")
+	return sections
 }
 
 // toolPromptSection assembles prompt text contributed by individual tools.
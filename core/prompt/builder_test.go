@@ -153,6 +153,23 @@ func TestBuilder_Build_ToolPrompts(t *testing.T) {
 	assert.Contains(t, prompt, "Tool B instructions")
 }
 
+func TestBuilder_Sections_NamedAndJoinedByBuild(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "CLAUDE.md"), []byte("Use gofmt for formatting"), 0644)
+
+	b := NewBuilder(dir, "", "Be brief")
+	b.SetToolPrompts([]string{"Tool A instructions"})
+	sections := b.Sections()
+
+	var names, texts []string
+	for _, s := range sections {
+		names = append(names, s.Name)
+		texts = append(texts, s.Text)
+	}
+	assert.Equal(t, []string{"core", "system", "environment", "tool_guidelines", "tool_prompts", "claude_md", "auto_memory", "instructions"}, names)
+	assert.Equal(t, strings.Join(texts, "\n\n"), b.Build())
+}
+
 func TestBuilder_Build_NoGitStatusWhenNotRepo(t *testing.T) {
 	dir := t.TempDir()
 	b := NewBuilderWithConfig(BuilderConfig{
@@ -24,6 +24,7 @@ const defaultContextWindow = 128000
 type Client struct {
 	endpoint   string // e.g., "http://127.0.0.1:39421"
 	model      string // Ollama model name, e.g., "gemma4:27b"
+	authToken  string // bearer token for remote servers; "" for none
 	httpClient *http.Client
 }
 
@@ -31,6 +32,7 @@ type Client struct {
 type Config struct {
 	Model      string       // Ollama model tag, e.g., "gemma4:27b"
 	Endpoint   string       // Ollama server endpoint, e.g., "http://127.0.0.1:11434"
+	AuthToken  string       // Optional bearer token for a remote server
 	HTTPClient *http.Client // Optional custom HTTP client
 }
 
@@ -44,8 +46,9 @@ func New(cfg Config) (*Client, error) {
 	}
 
 	c := &Client{
-		endpoint: strings.TrimRight(cfg.Endpoint, "/"),
-		model:    cfg.Model,
+		endpoint:  strings.TrimRight(cfg.Endpoint, "/"),
+		model:     cfg.Model,
+		authToken: cfg.AuthToken,
 	}
 
 	if cfg.HTTPClient != nil {
@@ -75,10 +78,17 @@ func (c *Client) MaxContextWindow() int {
 	return defaultContextWindow
 }
 
+// Capabilities reports what the configured model supports. Catalog and
+// discovered models carry probed flags; unknown models are assumed to accept
+// images, as before discovery existed.
 func (c *Client) Capabilities() provider.Capabilities {
+	thinking, images := false, true
+	if def := LookupByOllamaName(c.model); def != nil {
+		thinking, images = def.SupportsThinking, def.SupportsImages
+	}
 	return provider.Capabilities{
-		SupportsThinking:     false,
-		SupportsImages:       true,
+		SupportsThinking:     thinking,
+		SupportsImages:       images,
 		SupportsDocuments:    false,
 		SupportsCaching:      false,
 		SupportsStreaming:     true,
@@ -108,6 +118,9 @@ func (c *Client) StreamChat(ctx context.Context, req provider.ChatRequest) (<-ch
 		return nil, fmt.Errorf("ollama: create request: %w", err)
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
+	if c.authToken != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.authToken)
+	}
 
 	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
@@ -157,8 +170,9 @@ func (c *Client) buildRequestBody(req provider.ChatRequest) map[string]interface
 	}
 	body["messages"] = messages
 
-	// Tools (Ollama uses the same format as OpenAI for function calling)
-	if len(req.Tools) > 0 {
+	// Tools (Ollama uses the same format as OpenAI for function calling).
+	// Models probed without tool support reject requests that carry tools.
+	if len(req.Tools) > 0 && c.supportsTools(model) {
 		body["tools"] = convertTools(req.Tools)
 	}
 
@@ -198,6 +212,16 @@ func (c *Client) buildRequestBody(req provider.ChatRequest) map[string]interface
 	return body
 }
 
+// supportsTools reports whether model accepts tool definitions. Models
+// outside the catalog that were never probed are given the benefit of the
+// doubt.
+func (c *Client) supportsTools(model string) bool {
+	if def := LookupByOllamaName(model); def != nil {
+		return def.SupportsTools
+	}
+	return true
+}
+
 // convertMessages converts a unified provider.Message to Ollama format messages.
 func convertMessages(msg provider.Message) []map[string]interface{} {
 	var textParts []string
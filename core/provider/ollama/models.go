@@ -3,7 +3,11 @@
 // should import from here rather than maintaining parallel maps.
 package ollama
 
-import "strings"
+import (
+	"sort"
+	"strings"
+	"sync"
+)
 
 // LocalModelDef describes a locally-available model that ChatML can run via Ollama.
 type LocalModelDef struct {
@@ -13,6 +17,11 @@ type LocalModelDef struct {
 	Description   string // Short description for model selector
 	ContextWindow int    // Max context window in tokens
 	Cutoff        string // Knowledge cutoff date
+
+	SupportsTools    bool // Accepts tool definitions and emits tool calls
+	SupportsImages   bool // Accepts image input
+	SupportsThinking bool // Emits reasoning separately from the answer
+	Discovered       bool // Found installed locally rather than from the built-in catalog
 }
 
 // supportedModels is the catalog of local models we support out of the box.
@@ -25,6 +34,9 @@ var supportedModels = []LocalModelDef{
 		Description:   "Ultra-light local model (2B)",
 		ContextWindow: 128000,
 		Cutoff:        "March 2025",
+
+		SupportsTools:  true,
+		SupportsImages: true,
 	},
 	{
 		ID:            "gemma-4-e4b",
@@ -33,6 +45,9 @@ var supportedModels = []LocalModelDef{
 		Description:   "Fast local model (4B)",
 		ContextWindow: 128000,
 		Cutoff:        "March 2025",
+
+		SupportsTools:  true,
+		SupportsImages: true,
 	},
 	{
 		ID:            "gemma-4-27b",
@@ -41,6 +56,9 @@ var supportedModels = []LocalModelDef{
 		Description:   "Local MoE model (4B active)",
 		ContextWindow: 256000,
 		Cutoff:        "March 2025",
+
+		SupportsTools:  true,
+		SupportsImages: true,
 	},
 	{
 		ID:            "gemma-4-31b",
@@ -49,14 +67,24 @@ var supportedModels = []LocalModelDef{
 		Description:   "Most capable local model",
 		ContextWindow: 256000,
 		Cutoff:        "March 2025",
+
+		SupportsTools:  true,
+		SupportsImages: true,
 	},
 }
 
 // Pre-built indexes for fast lookup.
 var (
-	byID        map[string]*LocalModelDef
-	byOllama    map[string]*LocalModelDef
-	ctxWindows  map[string]int // ollama name → context window
+	byID       map[string]*LocalModelDef
+	byOllama   map[string]*LocalModelDef
+	ctxWindows map[string]int // ollama name → context window
+)
+
+// discovered holds models found installed in Ollama beyond the catalog,
+// keyed by Ollama name. Replaced wholesale by SetDiscoveredModels.
+var (
+	discoveredMu sync.RWMutex
+	discovered   map[string]LocalModelDef
 )
 
 func init() {
@@ -71,11 +99,56 @@ func init() {
 	}
 }
 
-// AllModels returns a copy of the supported local model catalog.
+// DiscoveredModelID returns the ChatML model ID for an installed Ollama model
+// outside the catalog, e.g. "ollama/qwen2.5-coder:7b".
+func DiscoveredModelID(ollamaName string) string {
+	return "ollama/" + ollamaName
+}
+
+// SetDiscoveredModels replaces the set of installed models available beyond
+// the catalog. Entries for catalog models are ignored; missing IDs are
+// derived from the Ollama name.
+func SetDiscoveredModels(models []LocalModelDef) {
+	next := make(map[string]LocalModelDef, len(models))
+	for _, m := range models {
+		if m.OllamaName == "" || byOllama[m.OllamaName] != nil {
+			continue
+		}
+		m.ID = DiscoveredModelID(m.OllamaName)
+		m.Discovered = true
+		if m.DisplayName == "" {
+			m.DisplayName = m.OllamaName
+		}
+		next[m.OllamaName] = m
+	}
+	discoveredMu.Lock()
+	discovered = next
+	discoveredMu.Unlock()
+}
+
+func lookupDiscovered(ollamaName string) *LocalModelDef {
+	discoveredMu.RLock()
+	defer discoveredMu.RUnlock()
+	if m, ok := discovered[ollamaName]; ok {
+		return &m
+	}
+	return nil
+}
+
+// AllModels returns a copy of the supported local model catalog followed by
+// any discovered models, sorted by Ollama name.
 func AllModels() []LocalModelDef {
 	out := make([]LocalModelDef, len(supportedModels))
 	copy(out, supportedModels)
-	return out
+
+	discoveredMu.RLock()
+	extra := make([]LocalModelDef, 0, len(discovered))
+	for _, m := range discovered {
+		extra = append(extra, m)
+	}
+	discoveredMu.RUnlock()
+	sort.Slice(extra, func(i, j int) bool { return extra[i].OllamaName < extra[j].OllamaName })
+	return append(out, extra...)
 }
 
 // IsLocalModel returns true if the model ID corresponds to a local model.
@@ -88,12 +161,21 @@ func IsLocalModel(modelID string) bool {
 
 // LookupByID returns the model def for a ChatML model ID, or nil.
 func LookupByID(modelID string) *LocalModelDef {
-	return byID[modelID]
+	if m := byID[modelID]; m != nil {
+		return m
+	}
+	if name, ok := strings.CutPrefix(modelID, "ollama/"); ok {
+		return LookupByOllamaName(name)
+	}
+	return nil
 }
 
 // LookupByOllamaName returns the model def for an Ollama tag, or nil.
 func LookupByOllamaName(name string) *LocalModelDef {
-	return byOllama[name]
+	if m := byOllama[name]; m != nil {
+		return m
+	}
+	return lookupDiscovered(name)
 }
 
 // ToOllamaName converts a ChatML model ID to the Ollama model tag.
@@ -109,6 +191,11 @@ func ToOllamaName(modelID string) string {
 
 // ContextWindowForOllamaModel returns the context window for an Ollama model tag.
 func ContextWindowForOllamaModel(ollamaName string) (int, bool) {
-	w, ok := ctxWindows[ollamaName]
-	return w, ok
+	if w, ok := ctxWindows[ollamaName]; ok {
+		return w, true
+	}
+	if m := lookupDiscovered(ollamaName); m != nil && m.ContextWindow > 0 {
+		return m.ContextWindow, true
+	}
+	return 0, false
 }
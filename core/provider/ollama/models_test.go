@@ -0,0 +1,87 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/chatml/chatml-core/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetDiscoveredModels(t *testing.T) {
+	t.Cleanup(func() { SetDiscoveredModels(nil) })
+	SetDiscoveredModels([]LocalModelDef{
+		{OllamaName: "qwen2.5-coder:7b", ContextWindow: 32768, SupportsTools: true},
+		{OllamaName: "gemma4:27b", ContextWindow: 1}, // Catalog entries win
+	})
+
+	def := LookupByID("ollama/qwen2.5-coder:7b")
+	require.NotNil(t, def)
+	assert.Equal(t, "ollama/qwen2.5-coder:7b", def.ID)
+	assert.Equal(t, "qwen2.5-coder:7b", def.DisplayName)
+	assert.True(t, def.Discovered)
+	assert.True(t, IsLocalModel(def.ID))
+	assert.Equal(t, "qwen2.5-coder:7b", ToOllamaName(def.ID))
+
+	w, ok := ContextWindowForOllamaModel("qwen2.5-coder:7b")
+	assert.True(t, ok)
+	assert.Equal(t, 32768, w)
+	w, _ = ContextWindowForOllamaModel("gemma4:27b")
+	assert.Equal(t, 256000, w)
+
+	all := AllModels()
+	assert.Len(t, all, len(supportedModels)+1)
+	assert.Equal(t, "ollama/qwen2.5-coder:7b", all[len(all)-1].ID)
+
+	SetDiscoveredModels(nil)
+	assert.Nil(t, LookupByID("ollama/qwen2.5-coder:7b"))
+	_, ok = ContextWindowForOllamaModel("qwen2.5-coder:7b")
+	assert.False(t, ok)
+}
+
+func TestClient_UsesDiscoveredCapabilities(t *testing.T) {
+	t.Cleanup(func() { SetDiscoveredModels(nil) })
+	SetDiscoveredModels([]LocalModelDef{
+		{OllamaName: "llama3:8b", ContextWindow: 8192},
+		{OllamaName: "llava:13b", ContextWindow: 4096, SupportsImages: true, SupportsTools: true},
+	})
+
+	plain, err := New(Config{Model: "llama3:8b", Endpoint: "http://localhost"})
+	require.NoError(t, err)
+	assert.Equal(t, 8192, plain.MaxContextWindow())
+	assert.False(t, plain.Capabilities().SupportsImages)
+
+	req := provider.ChatRequest{Tools: []provider.ToolDef{{Name: "Read", InputSchema: json.RawMessage(`{}`)}}}
+	assert.NotContains(t, plain.buildRequestBody(req), "tools", "models without tool support get no tools")
+
+	vision, err := New(Config{Model: "llava:13b", Endpoint: "http://localhost"})
+	require.NoError(t, err)
+	assert.True(t, vision.Capabilities().SupportsImages)
+	assert.Contains(t, vision.buildRequestBody(req), "tools")
+
+	unknown, err := New(Config{Model: "mystery:1b", Endpoint: "http://localhost"})
+	require.NoError(t, err)
+	assert.Equal(t, defaultContextWindow, unknown.MaxContextWindow())
+	assert.Contains(t, unknown.buildRequestBody(req), "tools")
+}
+
+func TestClient_SendsAuthToken(t *testing.T) {
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		w.Write([]byte(`{"message":{"role":"assistant","content":"hi"},"done":true,"done_reason":"stop"}` + "\n"))
+	}))
+	defer srv.Close()
+
+	c, err := New(Config{Model: "llama3:8b", Endpoint: srv.URL, AuthToken: "secret"})
+	require.NoError(t, err)
+	ch, err := c.StreamChat(context.Background(), provider.ChatRequest{})
+	require.NoError(t, err)
+	for range ch {
+	}
+	assert.Equal(t, "Bearer secret", auth)
+}
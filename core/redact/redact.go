@@ -0,0 +1,385 @@
+// Package redact detects secrets in tool output and replaces them with stable
+// placeholders before the output reaches the model, the transcript or the
+// backend. Detection combines known token formats, high-entropy strings and
+// the values of sensitive environment variables configured for the session.
+package redact
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Detector kinds. A kind can be switched off through the allowlist with a
+// "rule:<kind>" entry.
+const (
+	KindEnv         = "env"
+	KindHighEntropy = "high_entropy"
+	KindAssignment  = "assignment"
+)
+
+// Finding describes one redacted secret.
+type Finding struct {
+	Kind        string // Detector that matched (e.g. "github_token", "env")
+	Placeholder string // Text that replaced the secret
+}
+
+// Config configures a Redactor.
+type Config struct {
+	// Env holds environment variables injected into the session. Values of
+	// sensitive-looking variables are redacted wherever they appear.
+	Env map[string]string
+	// Allowlist entries are values never to redact ("*" wildcards allowed) or
+	// "rule:<kind>" to disable a detector.
+	Allowlist []string
+}
+
+type pattern struct {
+	kind string
+	re   *regexp.Regexp // When the regexp has a "secret" group, only that group is redacted
+}
+
+// patterns are the known token formats, most specific first.
+var patterns = []pattern{
+	{"private_key", regexp.MustCompile(`-----BEGIN[A-Z ]*PRIVATE KEY-----[\s\S]+?-----END[A-Z ]*PRIVATE KEY-----`)},
+	{"aws_access_key", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"aws_secret_key", regexp.MustCompile(`(?i)aws_?secret_?access_?key["']?\s*[=:]\s*["']?(?P<secret>[A-Za-z0-9/+=]{40})`)},
+	{"github_token", regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,255}|github_pat_[A-Za-z0-9_]{22,255})\b`)},
+	{"gitlab_token", regexp.MustCompile(`\bglpat-[A-Za-z0-9_\-]{20,}`)},
+	{"anthropic_key", regexp.MustCompile(`\bsk-ant-[A-Za-z0-9_\-]{20,}`)},
+	{"openai_key", regexp.MustCompile(`\bsk-(?:proj-|svcacct-)?[A-Za-z0-9_\-]{20,}`)},
+	{"slack_token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9\-]{10,}`)},
+	{"stripe_key", regexp.MustCompile(`\b[sr]k_(?:live|test)_[A-Za-z0-9]{16,}`)},
+	{"google_api_key", regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{35}`)},
+	{"npm_token", regexp.MustCompile(`\bnpm_[A-Za-z0-9]{36}\b`)},
+	{"jwt", regexp.MustCompile(`\beyJ[A-Za-z0-9_\-]{10,}\.eyJ[A-Za-z0-9_\-]{10,}\.[A-Za-z0-9_\-]{10,}`)},
+	{"url_password", regexp.MustCompile(`\b[a-zA-Z][a-zA-Z0-9+.\-]*://[^\s:/@]+:(?P<secret>[^\s@/]+)@`)},
+}
+
+// assignmentRe finds NAME=value / name: value pairs whose name suggests a
+// credential. The value is only redacted when it also looks random, so code
+// like `password: string` or `apiKey = process.env.API_KEY` is left alone.
+var assignmentRe = regexp.MustCompile(`(?i)\b[A-Z0-9_.\-]*(?:secret|token|passwd|password|api_?key|access_?key|private_?key|credentials?)[A-Z0-9_\-]*["']?\s*[=:]\s*["']?(?P<secret>[A-Za-z0-9+/=_\-!@#%^&*~]{12,})`)
+
+// entropyRe finds long runs of token characters for the entropy check.
+var entropyRe = regexp.MustCompile(`[A-Za-z0-9+/_\-]{32,}={0,2}`)
+
+// placeholderRe matches placeholders issued by Redact.
+var placeholderRe = regexp.MustCompile(`\[REDACTED:[A-Za-z0-9_]+\]`)
+
+// sensitiveEnvName matches environment variable names whose values are
+// secrets regardless of how they look.
+var sensitiveEnvName = regexp.MustCompile(`(?i)(KEY|TOKEN|SECRET|PASSWORD|PASSWD|CREDENTIAL|AUTH|PRIVATE|SESSION|COOKIE)`)
+
+// hashPrefixes introduce checksums (package-lock integrity, go.sum) that are
+// high-entropy but not secret.
+var hashPrefixes = []string{"sha1-", "sha256-", "sha384-", "sha512-", "h1:"}
+
+// minEnvSecretLen skips short values ("true", "us-east-1") that would be
+// redacted all over unrelated output.
+const minEnvSecretLen = 8
+
+type envSecret struct {
+	name  string
+	value string
+}
+
+// Redactor replaces secrets with placeholders. The same secret always maps to
+// the same placeholder for the Redactor's lifetime, so the model can still
+// tell two occurrences apart from two different secrets. Safe for concurrent
+// use.
+type Redactor struct {
+	env          []envSecret // Longest value first
+	allowValues  []string
+	disabledKind map[string]bool
+
+	mu           sync.Mutex
+	placeholders map[string]string // secret -> placeholder
+	secrets      map[string]string // placeholder -> secret
+	kindCounts   map[string]int
+}
+
+// New creates a Redactor.
+func New(cfg Config) *Redactor {
+	r := &Redactor{
+		disabledKind: make(map[string]bool),
+		placeholders: make(map[string]string),
+		secrets:      make(map[string]string),
+		kindCounts:   make(map[string]int),
+	}
+	for _, entry := range cfg.Allowlist {
+		entry = strings.TrimSpace(entry)
+		switch {
+		case entry == "":
+		case strings.HasPrefix(entry, "rule:"):
+			r.disabledKind[strings.TrimPrefix(entry, "rule:")] = true
+		default:
+			r.allowValues = append(r.allowValues, entry)
+		}
+	}
+	for name, value := range cfg.Env {
+		value = strings.TrimSpace(value)
+		if len(value) < minEnvSecretLen {
+			continue
+		}
+		if sensitiveEnvName.MatchString(name) || looksRandom(value, 16, 3.5) {
+			r.env = append(r.env, envSecret{name: name, value: value})
+		}
+	}
+	sort.Slice(r.env, func(i, j int) bool {
+		if len(r.env[i].value) != len(r.env[j].value) {
+			return len(r.env[i].value) > len(r.env[j].value)
+		}
+		return r.env[i].name < r.env[j].name
+	})
+	return r
+}
+
+// Redact returns text with every detected secret replaced, plus one finding
+// per distinct secret.
+func (r *Redactor) Redact(text string) (string, []Finding) {
+	if r == nil || text == "" {
+		return text, nil
+	}
+	var findings []Finding
+	seen := make(map[string]bool)
+	record := func(kind, secret string) string {
+		ph := r.placeholder(kind, secret)
+		if !seen[ph] {
+			seen[ph] = true
+			k, _, _ := strings.Cut(kind, ":")
+			findings = append(findings, Finding{Kind: k, Placeholder: ph})
+		}
+		return ph
+	}
+
+	if !r.disabledKind[KindEnv] {
+		for _, s := range r.env {
+			if strings.Contains(text, s.value) && !r.allowed(s.value) {
+				text = strings.ReplaceAll(text, s.value, record(KindEnv+":"+s.name, s.value))
+			}
+		}
+	}
+
+	for _, p := range patterns {
+		if r.disabledKind[p.kind] {
+			continue
+		}
+		text = r.replace(text, p.re, func(secret string) (string, bool) {
+			return record(p.kind, secret), true
+		})
+	}
+
+	if !r.disabledKind[KindAssignment] {
+		text = r.replace(text, assignmentRe, func(secret string) (string, bool) {
+			if !looksRandom(secret, 12, 3.0) || !strings.ContainsAny(secret, "0123456789") {
+				return "", false
+			}
+			return record(KindAssignment, secret), true
+		})
+	}
+
+	if !r.disabledKind[KindHighEntropy] {
+		text = r.replaceEntropy(text, func(secret string) string {
+			return record(KindHighEntropy, secret)
+		})
+	}
+	return text, findings
+}
+
+// Restore replaces placeholders issued by this Redactor with the original
+// secrets. Used for file-writing tool input so that rewriting a file the model
+// read back does not clobber its secrets with placeholders.
+func (r *Redactor) Restore(text string) (string, bool) {
+	if r == nil || !strings.Contains(text, "[REDACTED:") {
+		return text, false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	changed := false
+	out := placeholderRe.ReplaceAllStringFunc(text, func(ph string) string {
+		if secret, ok := r.secrets[ph]; ok {
+			changed = true
+			return secret
+		}
+		return ph
+	})
+	return out, changed
+}
+
+// placeholder returns the stable placeholder for secret, allocating one on
+// first sight. Environment values are labelled with the variable name; other
+// kinds are numbered.
+func (r *Redactor) placeholder(kind, secret string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if ph, ok := r.placeholders[secret]; ok {
+		return ph
+	}
+	var label string
+	if name, ok := strings.CutPrefix(kind, KindEnv+":"); ok {
+		label = name
+	} else {
+		r.kindCounts[kind]++
+		label = fmt.Sprintf("%s_%d", kind, r.kindCounts[kind])
+	}
+	ph := "[REDACTED:" + label + "]"
+	r.placeholders[secret] = ph
+	r.secrets[ph] = secret
+	return ph
+}
+
+// replace runs re over text and substitutes each match (or its "secret"
+// group) when fn accepts it and the value is not allowlisted.
+func (r *Redactor) replace(text string, re *regexp.Regexp, fn func(secret string) (string, bool)) string {
+	group := re.SubexpIndex("secret")
+	matches := re.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		return text
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		if group > 0 {
+			start, end = m[2*group], m[2*group+1]
+		}
+		if start < 0 || start < last {
+			continue
+		}
+		secret := text[start:end]
+		if strings.HasPrefix(secret, "[REDACTED:") || r.allowed(secret) {
+			continue
+		}
+		ph, ok := fn(secret)
+		if !ok {
+			continue
+		}
+		b.WriteString(text[last:start])
+		b.WriteString(ph)
+		last = end
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// replaceEntropy redacts high-entropy tokens, skipping checksums such as
+// package-lock integrity hashes and go.sum lines.
+func (r *Redactor) replaceEntropy(text string, fn func(secret string) string) string {
+	matches := entropyRe.FindAllStringIndex(text, -1)
+	if len(matches) == 0 {
+		return text
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		secret := text[m[0]:m[1]]
+		if isChecksum(text[:m[0]], secret) || !isEntropySecret(secret) || r.allowed(secret) {
+			continue
+		}
+		b.WriteString(text[last:m[0]])
+		b.WriteString(fn(secret))
+		last = m[1]
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// isChecksum reports whether token is (or directly follows) a checksum prefix.
+func isChecksum(before, token string) bool {
+	for _, prefix := range hashPrefixes {
+		if strings.HasSuffix(before, prefix) || strings.HasPrefix(token, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowed reports whether value matches an allowlist entry.
+func (r *Redactor) allowed(value string) bool {
+	for _, a := range r.allowValues {
+		if a == value || (strings.Contains(a, "*") && wildcardMatch(a, value)) {
+			return true
+		}
+	}
+	return false
+}
+
+// isEntropySecret applies the high-entropy heuristic to a candidate token:
+// mixed case plus digits and close to random, excluding hex digests and
+// UUIDs.
+func isEntropySecret(s string) bool {
+	trimmed := strings.TrimRight(s, "=")
+	if isHexLike(trimmed) {
+		return false
+	}
+	var upper, lower, digit bool
+	for _, c := range trimmed {
+		switch {
+		case c >= 'A' && c <= 'Z':
+			upper = true
+		case c >= 'a' && c <= 'z':
+			lower = true
+		case c >= '0' && c <= '9':
+			digit = true
+		}
+	}
+	return upper && lower && digit && shannonEntropy(trimmed) >= 4.5
+}
+
+func isHexLike(s string) bool {
+	for _, c := range s {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// looksRandom reports whether s is at least minLen long with per-character
+// Shannon entropy of at least minBits.
+func looksRandom(s string, minLen int, minBits float64) bool {
+	return len(s) >= minLen && shannonEntropy(s) >= minBits
+}
+
+func shannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := make(map[rune]int)
+	n := 0
+	for _, c := range s {
+		counts[c]++
+		n++
+	}
+	var h float64
+	for _, c := range counts {
+		p := float64(c) / float64(n)
+		h -= p * math.Log2(p)
+	}
+	return h
+}
+
+// wildcardMatch matches value against a pattern where "*" stands for any run
+// of characters.
+func wildcardMatch(pattern, value string) bool {
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(value, parts[0]) {
+		return false
+	}
+	value = value[len(parts[0]):]
+	for i, part := range parts[1:] {
+		if i == len(parts)-2 {
+			return strings.HasSuffix(value, part)
+		}
+		idx := strings.Index(value, part)
+		if idx < 0 {
+			return false
+		}
+		value = value[idx+len(part):]
+	}
+	return true
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 )
 
@@ -16,18 +17,69 @@ const (
 	ConfigFile = "config.json"
 )
 
+// Lifecycle hook names (keys of ChatMLConfig.Hooks)
+const (
+	HookPreSession  = "pre-session"  // After the session worktree is created
+	HookPostSession = "post-session" // Before a session is archived or deleted
+	HookPostMerge   = "post-merge"   // After the session's PR is merged
+)
+
+// Hook failure policies (values of ChatMLConfig.HookPolicies)
+const (
+	HookPolicyBlock = "block" // A failing hook aborts the lifecycle step
+	HookPolicyWarn  = "warn"  // A failing hook is reported and the step continues
+)
+
 // ChatMLConfig represents the .chatml/config.json file
 type ChatMLConfig struct {
 	SetupScripts []ScriptDef          `json:"setupScripts,omitempty"`
 	RunScripts   map[string]ScriptDef `json:"runScripts,omitempty"`
 	Hooks        map[string]string    `json:"hooks,omitempty"`
+	HookPolicies map[string]string    `json:"hookPolicies,omitempty"`
 	AutoSetup    bool                 `json:"autoSetup"`
 }
 
+// HookPolicy returns the failure policy for a lifecycle hook. Unless
+// configured, pre-session blocks and the other hooks warn.
+func (c *ChatMLConfig) HookPolicy(hookName string) string {
+	if p := c.HookPolicies[hookName]; p != "" {
+		return p
+	}
+	if hookName == HookPreSession {
+		return HookPolicyBlock
+	}
+	return HookPolicyWarn
+}
+
+// Service restart policies (values of ScriptDef.Restart)
+const (
+	RestartNever     = "never"      // A service that exits stays down (default)
+	RestartOnFailure = "on-failure" // A service that exits non-zero is restarted
+)
+
 // ScriptDef defines a named script command
 type ScriptDef struct {
 	Name    string `json:"name"`
 	Command string `json:"command"`
+
+	// Service mode (run scripts only). A service is a long-running process
+	// such as a dev server: it has no timeout, gets unique ports allocated per
+	// session, and is stopped when the session is archived or deleted.
+	Service     bool        `json:"service,omitempty"`
+	Ports       []string    `json:"ports,omitempty"`       // Named ports, exported as PORT_<NAME>; the first is also PORT
+	Ready       *ReadyProbe `json:"ready,omitempty"`       // Readiness probe; without one the service is ready once started
+	Restart     string      `json:"restart,omitempty"`     // "never" (default) or "on-failure"
+	MaxRestarts int         `json:"maxRestarts,omitempty"` // Restart limit, default 5
+	DependsOn   []string    `json:"dependsOn,omitempty"`   // Run script keys of services that must be ready first
+}
+
+// ReadyProbe decides when a service is ready. Every configured check must
+// pass. $PORT and $PORT_<NAME> are expanded in HTTP and TCP.
+type ReadyProbe struct {
+	HTTP    string `json:"http,omitempty"`    // URL that must answer with a non-5xx status
+	TCP     string `json:"tcp,omitempty"`     // host:port that must accept connections
+	Log     string `json:"log,omitempty"`     // Regular expression an output line must match
+	Timeout int    `json:"timeout,omitempty"` // Seconds, default 60
 }
 
 // ConfigPath returns the full path to .chatml/config.json for a given workspace
@@ -111,6 +163,10 @@ func ValidateConfig(config *ChatMLConfig) error {
 		if strings.TrimSpace(s.Name) == "" {
 			errs = append(errs, fmt.Sprintf("runScripts[%q]: name is empty", key))
 		}
+		errs = append(errs, validateService(key, s, config.RunScripts)...)
+	}
+	if err := checkServiceCycles(config.RunScripts); err != nil {
+		errs = append(errs, err.Error())
 	}
 
 	for hookName, cmd := range config.Hooks {
@@ -118,17 +174,126 @@ func ValidateConfig(config *ChatMLConfig) error {
 			errs = append(errs, fmt.Sprintf("hooks[%q]: command is empty", hookName))
 		}
 		// Only allow known hook names
-		switch hookName {
-		case "pre-session", "post-session", "post-merge":
-			// valid
-		default:
+		if !isHookName(hookName) {
 			errs = append(errs, fmt.Sprintf("hooks[%q]: unknown hook name", hookName))
 		}
 	}
 
+	for hookName, policy := range config.HookPolicies {
+		if !isHookName(hookName) {
+			errs = append(errs, fmt.Sprintf("hookPolicies[%q]: unknown hook name", hookName))
+		}
+		if policy != HookPolicyBlock && policy != HookPolicyWarn {
+			errs = append(errs, fmt.Sprintf("hookPolicies[%q]: policy must be %q or %q", hookName, HookPolicyBlock, HookPolicyWarn))
+		}
+	}
+
 	if len(errs) > 0 {
 		return fmt.Errorf("%s", strings.Join(errs, "; "))
 	}
 
 	return nil
 }
+
+func isHookName(name string) bool {
+	switch name {
+	case HookPreSession, HookPostSession, HookPostMerge:
+		return true
+	}
+	return false
+}
+
+// validateService checks the service fields of a run script.
+func validateService(key string, s ScriptDef, runScripts map[string]ScriptDef) []string {
+	var errs []string
+	if !s.Service {
+		if len(s.Ports) > 0 || s.Ready != nil || s.Restart != "" || s.MaxRestarts != 0 || len(s.DependsOn) > 0 {
+			errs = append(errs, fmt.Sprintf("runScripts[%q]: ports, ready, restart, maxRestarts and dependsOn require service mode", key))
+		}
+		return errs
+	}
+
+	seen := make(map[string]bool)
+	for _, name := range s.Ports {
+		if !portNamePattern.MatchString(name) {
+			errs = append(errs, fmt.Sprintf("runScripts[%q]: port name %q must be letters, digits and underscores", key, name))
+		}
+		if seen[strings.ToUpper(name)] {
+			errs = append(errs, fmt.Sprintf("runScripts[%q]: duplicate port %q", key, name))
+		}
+		seen[strings.ToUpper(name)] = true
+	}
+	if p := s.Ready; p != nil {
+		if p.HTTP == "" && p.TCP == "" && p.Log == "" {
+			errs = append(errs, fmt.Sprintf("runScripts[%q]: ready probe needs http, tcp or log", key))
+		}
+		if p.Log != "" {
+			if _, err := regexp.Compile(p.Log); err != nil {
+				errs = append(errs, fmt.Sprintf("runScripts[%q]: invalid ready.log pattern: %v", key, err))
+			}
+		}
+		if p.Timeout < 0 {
+			errs = append(errs, fmt.Sprintf("runScripts[%q]: ready.timeout is negative", key))
+		}
+	}
+	if s.Restart != "" && s.Restart != RestartNever && s.Restart != RestartOnFailure {
+		errs = append(errs, fmt.Sprintf("runScripts[%q]: restart must be %q or %q", key, RestartNever, RestartOnFailure))
+	}
+	if s.MaxRestarts < 0 {
+		errs = append(errs, fmt.Sprintf("runScripts[%q]: maxRestarts is negative", key))
+	}
+	for _, dep := range s.DependsOn {
+		d, ok := runScripts[dep]
+		switch {
+		case !ok:
+			errs = append(errs, fmt.Sprintf("runScripts[%q]: dependsOn %q is not a run script", key, dep))
+		case !d.Service:
+			errs = append(errs, fmt.Sprintf("runScripts[%q]: dependsOn %q is not a service", key, dep))
+		}
+	}
+	return errs
+}
+
+var portNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+// checkServiceCycles reports a dependency cycle between run scripts.
+func checkServiceCycles(runScripts map[string]ScriptDef) error {
+	for key := range runScripts {
+		if _, err := serviceStartOrder(key, runScripts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// serviceStartOrder returns the run script keys a service needs started, in
+// dependency order and ending with key itself.
+func serviceStartOrder(key string, runScripts map[string]ScriptDef) ([]string, error) {
+	var order []string
+	state := make(map[string]int) // 1 = visiting, 2 = done
+	var visit func(k string, path []string) error
+	visit = func(k string, path []string) error {
+		switch state[k] {
+		case 1:
+			return fmt.Errorf("runScripts: dependency cycle %s", strings.Join(append(path, k), " -> "))
+		case 2:
+			return nil
+		}
+		state[k] = 1
+		for _, dep := range runScripts[k].DependsOn {
+			if _, ok := runScripts[dep]; !ok {
+				continue // reported by validateService
+			}
+			if err := visit(dep, append(path, k)); err != nil {
+				return err
+			}
+		}
+		state[k] = 2
+		order = append(order, k)
+		return nil
+	}
+	if err := visit(key, nil); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
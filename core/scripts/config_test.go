@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -204,6 +205,10 @@ func TestValidateConfig_Valid(t *testing.T) {
 					"post-session": "./scripts/post.sh",
 					"post-merge":   "./scripts/merge.sh",
 				},
+				HookPolicies: map[string]string{
+					"pre-session": "warn",
+					"post-merge":  "block",
+				},
 				AutoSetup: true,
 			},
 		},
@@ -277,6 +282,94 @@ func TestValidateConfig_Invalid(t *testing.T) {
 			},
 			wantErr: "unknown hook name",
 		},
+		{
+			name: "unknown hook policy",
+			config: ChatMLConfig{
+				HookPolicies: map[string]string{"post-merge": "ignore"},
+			},
+			wantErr: "policy must be",
+		},
+		{
+			name: "policy for unknown hook",
+			config: ChatMLConfig{
+				HookPolicies: map[string]string{"on-start": "warn"},
+			},
+			wantErr: "unknown hook name",
+		},
+		{
+			name: "service fields without service mode",
+			config: ChatMLConfig{
+				RunScripts: map[string]ScriptDef{
+					"dev": {Name: "Dev", Command: "npm run dev", Ports: []string{"web"}},
+				},
+			},
+			wantErr: "require service mode",
+		},
+		{
+			name: "unknown restart policy",
+			config: ChatMLConfig{
+				RunScripts: map[string]ScriptDef{
+					"dev": {Name: "Dev", Command: "npm run dev", Service: true, Restart: "always"},
+				},
+			},
+			wantErr: "restart must be",
+		},
+		{
+			name: "empty ready probe",
+			config: ChatMLConfig{
+				RunScripts: map[string]ScriptDef{
+					"dev": {Name: "Dev", Command: "npm run dev", Service: true, Ready: &ReadyProbe{Timeout: 5}},
+				},
+			},
+			wantErr: "ready probe needs",
+		},
+		{
+			name: "invalid ready log pattern",
+			config: ChatMLConfig{
+				RunScripts: map[string]ScriptDef{
+					"dev": {Name: "Dev", Command: "npm run dev", Service: true, Ready: &ReadyProbe{Log: "ready ("}},
+				},
+			},
+			wantErr: "invalid ready.log pattern",
+		},
+		{
+			name: "invalid port name",
+			config: ChatMLConfig{
+				RunScripts: map[string]ScriptDef{
+					"dev": {Name: "Dev", Command: "npm run dev", Service: true, Ports: []string{"web-ui"}},
+				},
+			},
+			wantErr: "port name",
+		},
+		{
+			name: "dependency is not a service",
+			config: ChatMLConfig{
+				RunScripts: map[string]ScriptDef{
+					"web":  {Name: "Web", Command: "npm run dev", Service: true, DependsOn: []string{"test"}},
+					"test": {Name: "Test", Command: "npm test"},
+				},
+			},
+			wantErr: `dependsOn "test" is not a service`,
+		},
+		{
+			name: "unknown dependency",
+			config: ChatMLConfig{
+				RunScripts: map[string]ScriptDef{
+					"web": {Name: "Web", Command: "npm run dev", Service: true, DependsOn: []string{"db"}},
+				},
+			},
+			wantErr: `dependsOn "db" is not a run script`,
+		},
+		{
+			name: "dependency cycle",
+			config: ChatMLConfig{
+				RunScripts: map[string]ScriptDef{
+					"web": {Name: "Web", Command: "npm run dev", Service: true, DependsOn: []string{"api"}},
+					"api": {Name: "API", Command: "go run .", Service: true, DependsOn: []string{"web"}},
+				},
+			},
+			wantErr: "dependency cycle",
+		},
 		{
 			name: "multiple errors",
 			config: ChatMLConfig{
@@ -303,6 +396,36 @@ func TestValidateConfig_Invalid(t *testing.T) {
 	}
 }
 
+func TestServiceStartOrder(t *testing.T) {
+	runScripts := map[string]ScriptDef{
+		"db":  {Name: "DB", Command: "db", Service: true},
+		"api": {Name: "API", Command: "api", Service: true, DependsOn: []string{"db"}},
+		"web": {Name: "Web", Command: "web", Service: true, DependsOn: []string{"api", "db"}},
+	}
+
+	order, err := serviceStartOrder("web", runScripts)
+	if err != nil {
+		t.Fatalf("serviceStartOrder() error = %v", err)
+	}
+	if got := strings.Join(order, ","); got != "db,api,web" {
+		t.Errorf("order = %s, want db,api,web", got)
+	}
+}
+
+func TestHookPolicyDefaults(t *testing.T) {
+	config := &ChatMLConfig{HookPolicies: map[string]string{HookPostMerge: HookPolicyBlock}}
+
+	if got := config.HookPolicy(HookPreSession); got != HookPolicyBlock {
+		t.Errorf("pre-session policy = %q, want block", got)
+	}
+	if got := config.HookPolicy(HookPostSession); got != HookPolicyWarn {
+		t.Errorf("post-session policy = %q, want warn", got)
+	}
+	if got := config.HookPolicy(HookPostMerge); got != HookPolicyBlock {
+		t.Errorf("post-merge policy = %q, want block", got)
+	}
+}
+
 func TestValidateConfig_Nil(t *testing.T) {
 	err := ValidateConfig(nil)
 	if err == nil {
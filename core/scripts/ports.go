@@ -0,0 +1,48 @@
+package scripts
+
+import (
+	"fmt"
+	"net"
+	"sync"
+)
+
+// portAllocator hands out free localhost TCP ports, never giving the same port
+// to two live services even if one of them has not bound it yet.
+type portAllocator struct {
+	mu       sync.Mutex
+	reserved map[int]bool
+}
+
+func newPortAllocator() *portAllocator {
+	return &portAllocator{reserved: make(map[int]bool)}
+}
+
+// allocate reserves a port the OS reports as free.
+func (a *portAllocator) allocate() (int, error) {
+	for attempt := 0; attempt < 20; attempt++ {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			return 0, fmt.Errorf("allocate port: %w", err)
+		}
+		port := l.Addr().(*net.TCPAddr).Port
+		l.Close()
+
+		a.mu.Lock()
+		if !a.reserved[port] {
+			a.reserved[port] = true
+			a.mu.Unlock()
+			return port, nil
+		}
+		a.mu.Unlock()
+	}
+	return 0, fmt.Errorf("allocate port: no free port found")
+}
+
+// release returns ports to the pool.
+func (a *portAllocator) release(ports ...int) {
+	a.mu.Lock()
+	for _, p := range ports {
+		delete(a.reserved, p)
+	}
+	a.mu.Unlock()
+}
@@ -0,0 +1,18 @@
+//go:build !windows
+
+package scripts
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup runs the script in its own process group and makes
+// cancellation signal the whole group, so children the shell spawned (a dev
+// server started by "npm run dev") stop with it and release their ports.
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
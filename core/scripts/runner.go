@@ -44,6 +44,7 @@ type ScriptRun struct {
 	ScriptName string       `json:"scriptName"`
 	Command    string       `json:"command"`
 	Workdir    string       `json:"-"`
+	Env        []string     `json:"-"` // Extra environment (KEY=value)
 	Status     ScriptStatus `json:"status"`
 	ExitCode   *int         `json:"exitCode,omitempty"`
 	Output     []string     `json:"output"`
@@ -51,8 +52,10 @@ type ScriptRun struct {
 	FinishedAt *time.Time   `json:"finishedAt,omitempty"`
 	CreatedAt  time.Time    `json:"createdAt"`
 
-	cancel context.CancelFunc `json:"-"`
-	mu     sync.Mutex         `json:"-"`
+	cancel  context.CancelFunc `json:"-"`
+	service bool               // Run of a service; stopping it stops the service
+	onLine  func(line string)  // Sees each output line (service log readiness probe)
+	mu      sync.Mutex         `json:"-"`
 }
 
 // OutputHandler is called for each line of script output
@@ -73,6 +76,10 @@ type Runner struct {
 	onOutput        OutputHandler
 	onStatus        StatusHandler
 	onSetupProgress SetupProgressHandler
+
+	services  map[string]map[string]*service // sessionID -> run script key -> service
+	ports     *portAllocator
+	onService ServiceHandler
 }
 
 // NewRunner creates a new script runner with the given callbacks
@@ -83,6 +90,8 @@ func NewRunner(onOutput OutputHandler, onStatus StatusHandler, onSetupProgress S
 		onOutput:        onOutput,
 		onStatus:        onStatus,
 		onSetupProgress: onSetupProgress,
+		services:        make(map[string]map[string]*service),
+		ports:           newPortAllocator(),
 	}
 }
 
@@ -192,6 +201,89 @@ func (r *Runner) RunSetupScripts(ctx context.Context, sessionID, workdir string,
 	return nil
 }
 
+// HookScriptKeyPrefix prefixes the ScriptKey of lifecycle hook runs ("hook:pre-session").
+const HookScriptKeyPrefix = "hook:"
+
+// RunHook runs a lifecycle hook command and waits for it to finish. The run is
+// tracked and streamed like any other script; env is added to its environment.
+// Returns the finished run, and an error if the hook did not succeed.
+func (r *Runner) RunHook(ctx context.Context, sessionID, workdir, hookName, command string, env map[string]string) (*ScriptRun, error) {
+	now := time.Now()
+	run := &ScriptRun{
+		ID:         uuid.New().String()[:8],
+		SessionID:  sessionID,
+		ScriptKey:  HookScriptKeyPrefix + hookName,
+		ScriptName: hookName + " hook",
+		Command:    command,
+		Workdir:    workdir,
+		Status:     ScriptStatusRunning,
+		Output:     make([]string, 0, 128),
+		StartedAt:  &now,
+		CreatedAt:  now,
+	}
+	for k, v := range env {
+		run.Env = append(run.Env, k+"="+v)
+	}
+	return run, r.runAndWait(ctx, run)
+}
+
+// RunScriptAndWait runs a script like RunScript but waits for it to finish.
+// Returns the finished run, and an error if the script did not succeed.
+func (r *Runner) RunScriptAndWait(ctx context.Context, sessionID, workdir, scriptKey string, script ScriptDef) (*ScriptRun, error) {
+	now := time.Now()
+	run := &ScriptRun{
+		ID:         uuid.New().String()[:8],
+		SessionID:  sessionID,
+		ScriptKey:  scriptKey,
+		ScriptName: script.Name,
+		Command:    script.Command,
+		Workdir:    workdir,
+		Status:     ScriptStatusRunning,
+		Output:     make([]string, 0, 128),
+		StartedAt:  &now,
+		CreatedAt:  now,
+	}
+	return run, r.runAndWait(ctx, run)
+}
+
+// runAndWait registers run, executes it under DefaultScriptTimeout and
+// reports whether it succeeded.
+func (r *Runner) runAndWait(ctx context.Context, run *ScriptRun) error {
+	runCtx, cancel := context.WithTimeout(ctx, DefaultScriptTimeout)
+	defer cancel()
+	run.cancel = cancel
+
+	r.mu.Lock()
+	r.activeRuns[run.ID] = run
+	r.mu.Unlock()
+
+	r.emitStatus(run)
+	r.executeScript(runCtx, run)
+
+	run.mu.Lock()
+	status, exitCode := run.Status, run.ExitCode
+	run.mu.Unlock()
+	switch {
+	case status == ScriptStatusSuccess:
+		return nil
+	case exitCode != nil:
+		return fmt.Errorf("%s exited with code %d", run.ScriptName, *exitCode)
+	default:
+		return fmt.Errorf("%s %s", run.ScriptName, status)
+	}
+}
+
+// OutputTail returns up to n of the run's last output lines.
+func (run *ScriptRun) OutputTail(n int) []string {
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	start := len(run.Output) - n
+	if start < 0 {
+		start = 0
+	}
+	return append([]string(nil), run.Output[start:]...)
+}
+
 // StopScript cancels a running script
 func (r *Runner) StopScript(runID string) error {
 	r.mu.RLock()
@@ -201,6 +293,9 @@ func (r *Runner) StopScript(runID string) error {
 	if !ok {
 		return fmt.Errorf("run %s not found", runID)
 	}
+	if run.service {
+		return r.StopService(run.SessionID, run.ScriptKey)
+	}
 
 	run.mu.Lock()
 	if run.Status != ScriptStatusRunning {
@@ -237,8 +332,11 @@ func (r *Runner) GetSessionRuns(sessionID string) []*ScriptRun {
 	return runs
 }
 
-// CancelSessionRuns cancels all active runs for a session
+// CancelSessionRuns stops all services and cancels all active runs for a
+// session, releasing the services' ports.
 func (r *Runner) CancelSessionRuns(sessionID string) {
+	r.stopSessionServices(sessionID)
+
 	r.mu.RLock()
 	var toCancel []*ScriptRun
 	for _, run := range r.activeRuns {
@@ -273,9 +371,13 @@ func (r *Runner) executeScript(ctx context.Context, run *ScriptRun) {
 	}
 
 	cmd := exec.CommandContext(ctx, shell, "-c", run.Command)
+	setProcessGroup(cmd)
 	if run.Workdir != "" {
 		cmd.Dir = run.Workdir
 	}
+	if len(run.Env) > 0 {
+		cmd.Env = append(os.Environ(), run.Env...)
+	}
 
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
@@ -350,8 +452,13 @@ func (r *Runner) appendOutput(run *ScriptRun, line string) {
 	if len(run.Output) < maxOutputLines {
 		run.Output = append(run.Output, line)
 	}
+	onLine := run.onLine
 	run.mu.Unlock()
 
+	if onLine != nil {
+		onLine(line)
+	}
+
 	if r.onOutput != nil {
 		r.onOutput(run.SessionID, run.ID, line)
 	}
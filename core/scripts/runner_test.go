@@ -511,3 +511,70 @@ func waitForSetupStatus(t *testing.T, tc *testCallbacks, sessionID, wantStatus s
 	}
 	t.Fatalf("timeout waiting for setup %s to reach status %q", sessionID, wantStatus)
 }
+
+func TestRunHook_Success(t *testing.T) {
+	tc := newTestCallbacks()
+	runner := newTestRunner(tc)
+
+	run, err := runner.RunHook(context.Background(), "sess-1", t.TempDir(), HookPreSession,
+		`echo "session $CHATML_SESSION_ID"`, map[string]string{"CHATML_SESSION_ID": "sess-1"})
+	if err != nil {
+		t.Fatalf("RunHook() error = %v", err)
+	}
+	if run.Status != ScriptStatusSuccess {
+		t.Errorf("status = %q, want %q", run.Status, ScriptStatusSuccess)
+	}
+	if run.ScriptKey != "hook:pre-session" {
+		t.Errorf("scriptKey = %q, want hook:pre-session", run.ScriptKey)
+	}
+	if got := run.OutputTail(10); len(got) != 1 || got[0] != "session sess-1" {
+		t.Errorf("output = %v, want [session sess-1]", got)
+	}
+
+	// Hook runs show up alongside other session runs
+	if runs := runner.GetSessionRuns("sess-1"); len(runs) != 1 || runs[0].ID != run.ID {
+		t.Errorf("GetSessionRuns() = %v, want the hook run", runs)
+	}
+}
+
+func TestRunHook_Failure(t *testing.T) {
+	tc := newTestCallbacks()
+	runner := newTestRunner(tc)
+
+	run, err := runner.RunHook(context.Background(), "sess-1", t.TempDir(), HookPostMerge,
+		"echo one; echo two; echo three; exit 3", nil)
+	if err == nil {
+		t.Fatal("RunHook() expected error")
+	}
+	if !strings.Contains(err.Error(), "exited with code 3") {
+		t.Errorf("error = %q, want exit code", err)
+	}
+	if run.Status != ScriptStatusFailed {
+		t.Errorf("status = %q, want %q", run.Status, ScriptStatusFailed)
+	}
+	if got := run.OutputTail(2); len(got) != 2 || got[0] != "two" || got[1] != "three" {
+		t.Errorf("OutputTail(2) = %v, want [two three]", got)
+	}
+}
+
+func TestRunScriptAndWait(t *testing.T) {
+	tc := newTestCallbacks()
+	runner := newTestRunner(tc)
+	dir := t.TempDir()
+
+	run, err := runner.RunScriptAndWait(context.Background(), "sess-1", dir, "test", ScriptDef{Name: "Unit tests", Command: "echo ok"})
+	if err != nil {
+		t.Fatalf("RunScriptAndWait() error = %v", err)
+	}
+	if run.Status != ScriptStatusSuccess || run.ScriptKey != "test" {
+		t.Errorf("run = %q/%q, want success/test", run.Status, run.ScriptKey)
+	}
+
+	run, err = runner.RunScriptAndWait(context.Background(), "sess-1", dir, "lint", ScriptDef{Name: "Lint", Command: "echo bad; exit 1"})
+	if err == nil || err.Error() != "Lint exited with code 1" {
+		t.Errorf("error = %v, want Lint exited with code 1", err)
+	}
+	if got := run.OutputTail(1); len(got) != 1 || got[0] != "bad" {
+		t.Errorf("OutputTail(1) = %v, want [bad]", got)
+	}
+}
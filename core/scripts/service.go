@@ -0,0 +1,588 @@
+package scripts
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chatml/chatml-core/logger"
+	"github.com/google/uuid"
+)
+
+const (
+	// defaultReadyTimeout bounds how long a service may take to become ready
+	defaultReadyTimeout = 60 * time.Second
+
+	// defaultMaxRestarts caps restarts of a crashing on-failure service
+	defaultMaxRestarts = 5
+
+	// Restart backoff doubles from minRestartBackoff up to maxRestartBackoff
+	minRestartBackoff = time.Second
+	maxRestartBackoff = 30 * time.Second
+
+	// readyProbeInterval is how often HTTP and TCP probes are retried
+	readyProbeInterval = 250 * time.Millisecond
+
+	// serviceStopTimeout bounds how long stopping waits for a service to exit
+	serviceStopTimeout = 10 * time.Second
+)
+
+// ServiceStatus represents the state of a long-running service
+type ServiceStatus string
+
+const (
+	ServiceStatusPending    ServiceStatus = "pending"    // Waiting for dependencies
+	ServiceStatusStarting   ServiceStatus = "starting"   // Running, not ready yet
+	ServiceStatusReady      ServiceStatus = "ready"      // Readiness probe passed
+	ServiceStatusRestarting ServiceStatus = "restarting" // Crashed, restart scheduled
+	ServiceStatusFailed     ServiceStatus = "failed"     // Gave up: crashed, not ready in time, or a dependency failed
+	ServiceStatusStopped    ServiceStatus = "stopped"    // Stopped or exited cleanly
+)
+
+// Service is a snapshot of a service run script in a session
+type Service struct {
+	SessionID string         `json:"sessionId"`
+	Key       string         `json:"key"`
+	Name      string         `json:"name"`
+	Status    ServiceStatus  `json:"status"`
+	RunID     string         `json:"runId"` // Current (or last) run; each restart is a new run
+	Ports     map[string]int `json:"ports"`
+	DependsOn []string       `json:"dependsOn,omitempty"`
+	Restarts  int            `json:"restarts"`
+	Error     string         `json:"error,omitempty"`
+	StartedAt time.Time      `json:"startedAt"`
+}
+
+// ServiceHandler is called when a service changes status
+type ServiceHandler func(sessionID string, svc Service)
+
+// service is the live state behind a Service snapshot
+type service struct {
+	info    Service
+	def     ScriptDef
+	workdir string
+	env     []string
+	deps    []*service
+
+	ctx       context.Context // Cancelled to stop the service
+	cancel    context.CancelFunc
+	readyOnce sync.Once
+	ready     chan struct{} // Closed once the service first becomes ready
+	done      chan struct{} // Closed when the supervisor exits
+	mu        sync.Mutex
+}
+
+func (s *service) snapshot() Service {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	info := s.info
+	info.Ports = make(map[string]int, len(s.info.Ports))
+	for k, v := range s.info.Ports {
+		info.Ports[k] = v
+	}
+	return info
+}
+
+func (s *service) alive() bool {
+	select {
+	case <-s.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// SetServiceHandler sets the callback for service status changes
+func (r *Runner) SetServiceHandler(h ServiceHandler) {
+	r.mu.Lock()
+	r.onService = h
+	r.mu.Unlock()
+}
+
+// StartService starts a service run script, first starting the services it
+// depends on and waiting for them to be ready. runScripts is the workspace's
+// run script config, used to resolve dependencies. Returns the run ID of the
+// service's first run; it starts in "pending" status while dependencies come
+// up. Dependencies already running in the session are reused.
+func (r *Runner) StartService(ctx context.Context, sessionID, workdir, key string, runScripts map[string]ScriptDef) (string, error) {
+	def, ok := runScripts[key]
+	if !ok {
+		return "", fmt.Errorf("run script %q not found", key)
+	}
+	if !def.Service {
+		return "", fmt.Errorf("run script %q is not a service", key)
+	}
+	order, err := serviceStartOrder(key, runScripts)
+	if err != nil {
+		return "", err
+	}
+
+	r.mu.Lock()
+	if existing := r.services[sessionID][key]; existing != nil && existing.alive() {
+		r.mu.Unlock()
+		return "", fmt.Errorf("service %q is already running", key)
+	}
+	if r.services[sessionID] == nil {
+		r.services[sessionID] = make(map[string]*service)
+	}
+
+	var started []*service
+	var startErr error
+	for _, k := range order {
+		if svc := r.services[sessionID][k]; svc != nil && svc.alive() {
+			continue
+		}
+		svc, err := r.newService(ctx, sessionID, workdir, k, runScripts)
+		if err != nil {
+			startErr = err
+			break
+		}
+		r.services[sessionID][k] = svc
+		started = append(started, svc)
+	}
+	if startErr != nil {
+		for _, svc := range started {
+			delete(r.services[sessionID], svc.info.Key)
+			r.ports.release(portList(svc.info.Ports)...)
+		}
+		r.mu.Unlock()
+		return "", startErr
+	}
+	target := r.services[sessionID][key]
+	runID := target.info.RunID
+	r.mu.Unlock()
+
+	for _, svc := range started {
+		r.emitService(svc)
+		go r.superviseService(svc)
+	}
+	return runID, nil
+}
+
+// newService allocates ports and the first (pending) run for a service.
+// Dependencies are resolved from the session's services, so they must be
+// created first. Caller holds r.mu.
+func (r *Runner) newService(ctx context.Context, sessionID, workdir, key string, runScripts map[string]ScriptDef) (*service, error) {
+	def := runScripts[key]
+	svc := &service{
+		info: Service{
+			SessionID: sessionID,
+			Key:       key,
+			Name:      def.Name,
+			Status:    ServiceStatusPending,
+			Ports:     make(map[string]int),
+			DependsOn: def.DependsOn,
+			StartedAt: time.Now(),
+		},
+		def:     def,
+		workdir: workdir,
+		ready:   make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	names := def.Ports
+	if len(names) == 0 {
+		names = []string{"PORT"}
+	}
+	env := []string{"CHATML_SESSION_ID=" + sessionID}
+	for i, name := range names {
+		port, err := r.ports.allocate()
+		if err != nil {
+			r.ports.release(portList(svc.info.Ports)...)
+			return nil, err
+		}
+		svc.info.Ports[name] = port
+		if i == 0 {
+			env = append(env, "PORT="+strconv.Itoa(port))
+		}
+		if name != "PORT" {
+			env = append(env, "PORT_"+strings.ToUpper(name)+"="+strconv.Itoa(port))
+		}
+	}
+
+	// Dependencies' ports, e.g. API_PORT and API_PORT_HTTP for a dependency "api"
+	for _, depKey := range def.DependsOn {
+		dep := r.services[sessionID][depKey]
+		if dep == nil {
+			continue
+		}
+		svc.deps = append(svc.deps, dep)
+		prefix := envName(depKey) + "_PORT"
+		depNames := dep.def.Ports
+		if len(depNames) == 0 {
+			depNames = []string{"PORT"}
+		}
+		dep.mu.Lock()
+		for i, name := range depNames {
+			port := strconv.Itoa(dep.info.Ports[name])
+			if i == 0 {
+				env = append(env, prefix+"="+port)
+			}
+			if name != "PORT" {
+				env = append(env, prefix+"_"+strings.ToUpper(name)+"="+port)
+			}
+		}
+		dep.mu.Unlock()
+	}
+	svc.env = env
+
+	svc.ctx, svc.cancel = context.WithCancel(ctx)
+	run := newServiceRun(svc)
+	svc.info.RunID = run.ID
+	r.activeRuns[run.ID] = run
+	return svc, nil
+}
+
+// newServiceRun creates a pending run for the next start of a service.
+func newServiceRun(svc *service) *ScriptRun {
+	return &ScriptRun{
+		ID:         uuid.New().String()[:8],
+		SessionID:  svc.info.SessionID,
+		ScriptKey:  svc.info.Key,
+		ScriptName: svc.def.Name,
+		Command:    svc.def.Command,
+		Workdir:    svc.workdir,
+		Env:        svc.env,
+		Status:     ScriptStatusPending,
+		Output:     make([]string, 0, 128),
+		CreatedAt:  time.Now(),
+		service:    true,
+	}
+}
+
+// superviseService waits for dependencies, then runs the service, restarting
+// it per its restart policy until it is stopped or gives up.
+func (r *Runner) superviseService(svc *service) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			logger.Scripts.Errorf("[service:%s/%s] PANIC: %v\n%s", svc.info.SessionID, svc.info.Key, rec, debug.Stack())
+			r.setServiceStatus(svc, ServiceStatusFailed, "internal error")
+		}
+		svc.cancel()
+		r.ports.release(portList(svc.info.Ports)...)
+		close(svc.done)
+	}()
+
+	r.mu.RLock()
+	run := r.activeRuns[svc.info.RunID]
+	r.mu.RUnlock()
+
+	for _, dep := range svc.deps {
+		select {
+		case <-dep.ready:
+		case <-dep.done:
+			r.finishPendingRun(run)
+			r.setServiceStatus(svc, ServiceStatusFailed, fmt.Sprintf("dependency %q stopped before it was ready", dep.info.Key))
+			return
+		case <-svc.ctx.Done():
+			r.finishPendingRun(run)
+			r.setServiceStatus(svc, ServiceStatusStopped, "")
+			return
+		}
+	}
+
+	maxRestarts := svc.def.MaxRestarts
+	if maxRestarts == 0 {
+		maxRestarts = defaultMaxRestarts
+	}
+	backoff := minRestartBackoff
+
+	for {
+		failure := r.runServiceOnce(svc, run)
+		if svc.ctx.Err() != nil {
+			r.setServiceStatus(svc, ServiceStatusStopped, "")
+			return
+		}
+		if failure == "" {
+			r.setServiceStatus(svc, ServiceStatusStopped, "")
+			return
+		}
+
+		svc.mu.Lock()
+		restarts := svc.info.Restarts
+		svc.mu.Unlock()
+		if svc.def.Restart != RestartOnFailure || restarts >= maxRestarts {
+			r.setServiceStatus(svc, ServiceStatusFailed, failure)
+			return
+		}
+
+		r.setServiceStatus(svc, ServiceStatusRestarting, failure)
+		select {
+		case <-time.After(backoff):
+		case <-svc.ctx.Done():
+			r.setServiceStatus(svc, ServiceStatusStopped, "")
+			return
+		}
+		backoff = min(backoff*2, maxRestartBackoff)
+
+		run = newServiceRun(svc)
+		r.mu.Lock()
+		r.activeRuns[run.ID] = run
+		r.mu.Unlock()
+		svc.mu.Lock()
+		svc.info.Restarts++
+		svc.info.RunID = run.ID
+		svc.mu.Unlock()
+	}
+}
+
+// runServiceOnce runs one start of a service until it exits. It returns why
+// the run failed, or "" if the process exited cleanly or was stopped.
+func (r *Runner) runServiceOnce(svc *service, run *ScriptRun) string {
+	runCtx, cancel := context.WithCancel(svc.ctx)
+	defer cancel()
+
+	var logMatched chan struct{}
+	if svc.def.Ready != nil && svc.def.Ready.Log != "" {
+		pattern := regexp.MustCompile(svc.def.Ready.Log) // validated with the config
+		logMatched = make(chan struct{})
+		var once sync.Once
+		run.onLine = func(line string) {
+			if pattern.MatchString(line) {
+				once.Do(func() { close(logMatched) })
+			}
+		}
+	}
+
+	now := time.Now()
+	run.mu.Lock()
+	run.Status = ScriptStatusRunning
+	run.StartedAt = &now
+	run.cancel = cancel
+	run.mu.Unlock()
+	r.emitStatus(run)
+	r.setServiceStatus(svc, ServiceStatusStarting, "")
+
+	// Probe readiness while the process runs. A probe that times out kills
+	// the run, which then counts as a failure.
+	probeDone := make(chan struct{})
+	var notReady string
+	go func() {
+		defer close(probeDone)
+		if err := r.waitReady(runCtx, svc, logMatched); err != nil {
+			if runCtx.Err() == nil {
+				notReady = err.Error()
+				r.appendOutput(run, "[service not ready: "+notReady+"]")
+				cancel()
+			}
+			return
+		}
+		svc.readyOnce.Do(func() { close(svc.ready) })
+		r.setServiceStatus(svc, ServiceStatusReady, "")
+	}()
+
+	r.executeScript(runCtx, run)
+	cancel()
+	<-probeDone
+
+	if notReady != "" {
+		return notReady
+	}
+	run.mu.Lock()
+	status, exitCode := run.Status, run.ExitCode
+	run.mu.Unlock()
+	switch {
+	case status != ScriptStatusFailed:
+		return ""
+	case exitCode != nil:
+		return fmt.Sprintf("exited with code %d", *exitCode)
+	default:
+		return "failed to start"
+	}
+}
+
+// waitReady blocks until every configured readiness check passes.
+func (r *Runner) waitReady(ctx context.Context, svc *service, logMatched <-chan struct{}) error {
+	probe := svc.def.Ready
+	if probe == nil {
+		return nil
+	}
+	timeout := defaultReadyTimeout
+	if probe.Timeout > 0 {
+		timeout = time.Duration(probe.Timeout) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	expand := func(s string) string {
+		return os.Expand(s, func(name string) string {
+			for _, kv := range svc.env {
+				if k, v, _ := strings.Cut(kv, "="); k == name {
+					return v
+				}
+			}
+			return ""
+		})
+	}
+
+	if logMatched != nil {
+		select {
+		case <-logMatched:
+		case <-ctx.Done():
+			return fmt.Errorf("no output matched %q within %s", probe.Log, timeout)
+		}
+	}
+
+	var checks []readyCheck
+	if probe.HTTP != "" {
+		url := expand(probe.HTTP)
+		checks = append(checks, readyCheck{url, func(ctx context.Context) bool { return probeHTTP(ctx, url) }})
+	}
+	if probe.TCP != "" {
+		addr := expand(probe.TCP)
+		checks = append(checks, readyCheck{addr, func(ctx context.Context) bool { return probeTCP(ctx, addr) }})
+	}
+
+	for _, c := range checks {
+		for !c.check(ctx) {
+			select {
+			case <-time.After(readyProbeInterval):
+			case <-ctx.Done():
+				return fmt.Errorf("%s not reachable within %s", c.desc, timeout)
+			}
+		}
+	}
+	return nil
+}
+
+// readyCheck is one polled readiness check
+type readyCheck struct {
+	desc  string
+	check func(context.Context) bool
+}
+
+// probeHTTP reports whether url answers with a non-5xx status.
+func probeHTTP(ctx context.Context, url string) bool {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return false
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode < 500
+}
+
+// probeTCP reports whether addr accepts connections.
+func probeTCP(ctx context.Context, addr string) bool {
+	d := net.Dialer{Timeout: 2 * time.Second}
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// finishPendingRun cancels a run that never started.
+func (r *Runner) finishPendingRun(run *ScriptRun) {
+	if run != nil {
+		r.finishRun(run, ScriptStatusCancelled, -1)
+	}
+}
+
+func (r *Runner) setServiceStatus(svc *service, status ServiceStatus, errMsg string) {
+	svc.mu.Lock()
+	svc.info.Status = status
+	svc.info.Error = errMsg
+	svc.mu.Unlock()
+	r.emitService(svc)
+}
+
+func (r *Runner) emitService(svc *service) {
+	r.mu.RLock()
+	h := r.onService
+	r.mu.RUnlock()
+	if h != nil {
+		h(svc.info.SessionID, svc.snapshot())
+	}
+}
+
+// StopService stops a session's service and waits for it to exit. Services
+// that depend on it are left running.
+func (r *Runner) StopService(sessionID, key string) error {
+	r.mu.RLock()
+	svc := r.services[sessionID][key]
+	r.mu.RUnlock()
+	if svc == nil || !svc.alive() {
+		return fmt.Errorf("service %q is not running", key)
+	}
+	stopServices([]*service{svc})
+	return nil
+}
+
+// stopServices cancels services and waits (bounded) for them to exit.
+func stopServices(svcs []*service) {
+	for _, svc := range svcs {
+		svc.cancel()
+	}
+	deadline := time.After(serviceStopTimeout)
+	for _, svc := range svcs {
+		select {
+		case <-svc.done:
+		case <-deadline:
+			logger.Scripts.Warnf("[service:%s/%s] did not stop within %s", svc.info.SessionID, svc.info.Key, serviceStopTimeout)
+			return
+		}
+	}
+}
+
+// GetSessionServices returns the services started in a session, sorted by key.
+func (r *Runner) GetSessionServices(sessionID string) []Service {
+	r.mu.RLock()
+	svcs := make([]*service, 0, len(r.services[sessionID]))
+	for _, svc := range r.services[sessionID] {
+		svcs = append(svcs, svc)
+	}
+	r.mu.RUnlock()
+
+	out := make([]Service, 0, len(svcs))
+	for _, svc := range svcs {
+		out = append(out, svc.snapshot())
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out
+}
+
+// stopSessionServices stops every service in a session and forgets them.
+func (r *Runner) stopSessionServices(sessionID string) {
+	r.mu.Lock()
+	svcs := make([]*service, 0, len(r.services[sessionID]))
+	for _, svc := range r.services[sessionID] {
+		svcs = append(svcs, svc)
+	}
+	delete(r.services, sessionID)
+	r.mu.Unlock()
+	stopServices(svcs)
+}
+
+// envName turns a run script key into an environment variable prefix.
+func envName(key string) string {
+	return strings.ToUpper(strings.Map(func(c rune) rune {
+		if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') {
+			return c
+		}
+		return '_'
+	}, key))
+}
+
+func portList(ports map[string]int) []int {
+	list := make([]int, 0, len(ports))
+	for _, p := range ports {
+		list = append(list, p)
+	}
+	return list
+}
@@ -0,0 +1,260 @@
+package scripts
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// serviceEvents collects service status callbacks
+type serviceEvents struct {
+	mu     sync.Mutex
+	events []Service
+}
+
+func (se *serviceEvents) onService(sessionID string, svc Service) {
+	se.mu.Lock()
+	defer se.mu.Unlock()
+	se.events = append(se.events, svc)
+}
+
+func (se *serviceEvents) statuses(key string) []ServiceStatus {
+	se.mu.Lock()
+	defer se.mu.Unlock()
+	var out []ServiceStatus
+	for _, e := range se.events {
+		if e.Key == key {
+			out = append(out, e.Status)
+		}
+	}
+	return out
+}
+
+func waitForServiceStatus(t *testing.T, runner *Runner, sessionID, key string, want ServiceStatus, timeout time.Duration) Service {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		for _, svc := range runner.GetSessionServices(sessionID) {
+			if svc.Key == key && svc.Status == want {
+				return svc
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("timeout waiting for service %s to reach status %q (got %v)", key, want, runner.GetSessionServices(sessionID))
+	return Service{}
+}
+
+func runOutput(runner *Runner, runID string) string {
+	run := runner.GetRun(runID)
+	if run == nil {
+		return ""
+	}
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	return strings.Join(run.Output, "\n")
+}
+
+func TestStartService_PortsAndLogProbe(t *testing.T) {
+	tc := newTestCallbacks()
+	runner := newTestRunner(tc)
+	events := &serviceEvents{}
+	runner.SetServiceHandler(events.onService)
+	defer runner.CancelSessionRuns("sess-1")
+
+	runScripts := map[string]ScriptDef{
+		"dev": {
+			Name:    "Dev",
+			Command: `echo "port=$PORT web=$PORT_WEB admin=$PORT_ADMIN"; echo "listening"; sleep 30`,
+			Service: true,
+			Ports:   []string{"web", "admin"},
+			Ready:   &ReadyProbe{Log: "^listening$"},
+		},
+	}
+
+	runID, err := runner.StartService(context.Background(), "sess-1", t.TempDir(), "dev", runScripts)
+	if err != nil {
+		t.Fatalf("StartService() error = %v", err)
+	}
+
+	svc := waitForServiceStatus(t, runner, "sess-1", "dev", ServiceStatusReady, 5*time.Second)
+	web, admin := svc.Ports["web"], svc.Ports["admin"]
+	if web == 0 || admin == 0 || web == admin {
+		t.Fatalf("ports = %v, want two distinct ports", svc.Ports)
+	}
+	want := fmt.Sprintf("port=%d web=%d admin=%d", web, web, admin)
+	if out := runOutput(runner, runID); !strings.Contains(out, want) {
+		t.Errorf("output = %q, want to contain %q", out, want)
+	}
+	if got := events.statuses("dev"); len(got) < 2 || got[0] != ServiceStatusPending {
+		t.Errorf("status events = %v, want pending first", got)
+	}
+
+	if _, err := runner.StartService(context.Background(), "sess-1", t.TempDir(), "dev", runScripts); err == nil {
+		t.Error("starting a running service should fail")
+	}
+}
+
+func TestStartService_TCPProbe(t *testing.T) {
+	runner := newTestRunner(newTestCallbacks())
+	defer runner.CancelSessionRuns("sess-1")
+
+	// The test binds the allocated port itself, standing in for a server
+	runScripts := map[string]ScriptDef{
+		"srv": {
+			Name:    "Server",
+			Command: `sleep 30`,
+			Service: true,
+			Ready:   &ReadyProbe{TCP: "127.0.0.1:$PORT", Timeout: 5},
+		},
+	}
+	if _, err := runner.StartService(context.Background(), "sess-1", t.TempDir(), "srv", runScripts); err != nil {
+		t.Fatalf("StartService() error = %v", err)
+	}
+	svc := waitForServiceStatus(t, runner, "sess-1", "srv", ServiceStatusStarting, 5*time.Second)
+
+	l, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", svc.Ports["PORT"]))
+	if err != nil {
+		t.Fatalf("listen on service port: %v", err)
+	}
+	defer l.Close()
+	waitForServiceStatus(t, runner, "sess-1", "srv", ServiceStatusReady, 5*time.Second)
+}
+
+func TestStartService_DependencyOrder(t *testing.T) {
+	runner := newTestRunner(newTestCallbacks())
+	defer runner.CancelSessionRuns("sess-1")
+
+	runScripts := map[string]ScriptDef{
+		"api": {
+			Name:    "API",
+			Command: `sleep 0.3; echo "api up"; sleep 30`,
+			Service: true,
+			Ready:   &ReadyProbe{Log: "api up"},
+		},
+		"web": {
+			Name:      "Web",
+			Command:   `echo "api at $API_PORT"; sleep 30`,
+			Service:   true,
+			DependsOn: []string{"api"},
+		},
+	}
+
+	runID, err := runner.StartService(context.Background(), "sess-1", t.TempDir(), "web", runScripts)
+	if err != nil {
+		t.Fatalf("StartService() error = %v", err)
+	}
+
+	// web waits for api to be ready before starting
+	waitForServiceStatus(t, runner, "sess-1", "web", ServiceStatusPending, time.Second)
+	api := waitForServiceStatus(t, runner, "sess-1", "api", ServiceStatusReady, 5*time.Second)
+	waitForServiceStatus(t, runner, "sess-1", "web", ServiceStatusReady, 5*time.Second)
+
+	want := fmt.Sprintf("api at %d", api.Ports["PORT"])
+	waitForRunStatus(t, runner, runID, ScriptStatusRunning, time.Second)
+	deadline := time.Now().Add(2 * time.Second)
+	for !strings.Contains(runOutput(runner, runID), want) && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if out := runOutput(runner, runID); !strings.Contains(out, want) {
+		t.Errorf("output = %q, want to contain %q", out, want)
+	}
+}
+
+func TestStartService_RestartOnFailure(t *testing.T) {
+	runner := newTestRunner(newTestCallbacks())
+	events := &serviceEvents{}
+	runner.SetServiceHandler(events.onService)
+	defer runner.CancelSessionRuns("sess-1")
+
+	runScripts := map[string]ScriptDef{
+		"crash": {Name: "Crash", Command: "exit 3", Service: true, Restart: RestartOnFailure, MaxRestarts: 1},
+	}
+	if _, err := runner.StartService(context.Background(), "sess-1", t.TempDir(), "crash", runScripts); err != nil {
+		t.Fatalf("StartService() error = %v", err)
+	}
+
+	svc := waitForServiceStatus(t, runner, "sess-1", "crash", ServiceStatusFailed, 5*time.Second)
+	if svc.Restarts != 1 {
+		t.Errorf("restarts = %d, want 1", svc.Restarts)
+	}
+	if svc.Error != "exited with code 3" {
+		t.Errorf("error = %q, want exit code", svc.Error)
+	}
+	restarting := false
+	for _, s := range events.statuses("crash") {
+		restarting = restarting || s == ServiceStatusRestarting
+	}
+	if !restarting {
+		t.Errorf("status events = %v, want a restart", events.statuses("crash"))
+	}
+}
+
+func TestStartService_NotReadyInTime(t *testing.T) {
+	runner := newTestRunner(newTestCallbacks())
+	defer runner.CancelSessionRuns("sess-1")
+
+	runScripts := map[string]ScriptDef{
+		"slow": {Name: "Slow", Command: "sleep 30", Service: true, Ready: &ReadyProbe{Log: "never", Timeout: 1}},
+	}
+	if _, err := runner.StartService(context.Background(), "sess-1", t.TempDir(), "slow", runScripts); err != nil {
+		t.Fatalf("StartService() error = %v", err)
+	}
+
+	svc := waitForServiceStatus(t, runner, "sess-1", "slow", ServiceStatusFailed, 5*time.Second)
+	if !strings.Contains(svc.Error, "no output matched") {
+		t.Errorf("error = %q, want readiness timeout", svc.Error)
+	}
+}
+
+func TestCancelSessionRuns_StopsServices(t *testing.T) {
+	runner := newTestRunner(newTestCallbacks())
+
+	runScripts := map[string]ScriptDef{
+		"dev": {Name: "Dev", Command: "sleep 30", Service: true},
+	}
+	runID, err := runner.StartService(context.Background(), "sess-1", t.TempDir(), "dev", runScripts)
+	if err != nil {
+		t.Fatalf("StartService() error = %v", err)
+	}
+	svc := waitForServiceStatus(t, runner, "sess-1", "dev", ServiceStatusReady, 5*time.Second)
+
+	runner.CancelSessionRuns("sess-1")
+
+	if services := runner.GetSessionServices("sess-1"); len(services) != 0 {
+		t.Errorf("services after cancel = %v, want none", services)
+	}
+	waitForRunStatus(t, runner, runID, ScriptStatusCancelled, time.Second)
+	runner.ports.mu.Lock()
+	reserved := runner.ports.reserved[svc.Ports["PORT"]]
+	runner.ports.mu.Unlock()
+	if reserved {
+		t.Error("service port still reserved after cancel")
+	}
+}
+
+func TestStopScript_StopsService(t *testing.T) {
+	runner := newTestRunner(newTestCallbacks())
+	defer runner.CancelSessionRuns("sess-1")
+
+	runScripts := map[string]ScriptDef{
+		"dev": {Name: "Dev", Command: "sleep 30", Service: true, Restart: RestartOnFailure},
+	}
+	runID, err := runner.StartService(context.Background(), "sess-1", t.TempDir(), "dev", runScripts)
+	if err != nil {
+		t.Fatalf("StartService() error = %v", err)
+	}
+	waitForServiceStatus(t, runner, "sess-1", "dev", ServiceStatusReady, 5*time.Second)
+
+	if err := runner.StopScript(runID); err != nil {
+		t.Fatalf("StopScript() error = %v", err)
+	}
+	svc := waitForServiceStatus(t, runner, "sess-1", "dev", ServiceStatusStopped, time.Second)
+	if svc.Restarts != 0 {
+		t.Errorf("restarts = %d, a stopped service must not restart", svc.Restarts)
+	}
+}
@@ -0,0 +1,220 @@
+// Package shell parses POSIX/bash command strings into a syntax tree. It
+// covers the grammar the agent's Bash tool sees in practice — lists,
+// pipelines, subshells, groups, if/while/until/for/select/case, functions,
+// [[ ]] and (( )), here-documents, and every kind of word expansion — so
+// callers can reason about each command a string would run. It never
+// executes or expands anything.
+package shell
+
+// File is a parsed command string.
+type File struct {
+	Stmts []*Stmt
+}
+
+// Stmt is one command of a list with its separator.
+type Stmt struct {
+	Cmd        Command
+	Negated    bool // Preceded by "!"
+	Background bool // Terminated by "&"
+}
+
+// Command is a node that runs something: *CallExpr, *BinaryCmd, *Subshell,
+// *Block, *IfClause, *WhileClause, *ForClause, *CaseClause, *FuncDecl,
+// *ArithmCmd or *TestClause.
+type Command interface {
+	commandNode()
+}
+
+// CallExpr is a simple command: assignments, words and redirections.
+type CallExpr struct {
+	Assigns []*Assign
+	Args    []*Word
+	Redirs  []*Redirect
+	Text    string // Source text of the whole command
+}
+
+// BinaryCmd joins two statements with "&&", "||", "|" or "|&".
+type BinaryCmd struct {
+	Op   string
+	X, Y *Stmt
+}
+
+// Subshell is "( stmts )".
+type Subshell struct {
+	Stmts  []*Stmt
+	Redirs []*Redirect
+}
+
+// Block is "{ stmts; }".
+type Block struct {
+	Stmts  []*Stmt
+	Redirs []*Redirect
+}
+
+// IfClause is "if cond; then ...; [elif ...;] [else ...;] fi". Elif
+// branches are chained through Else as nested IfClauses.
+type IfClause struct {
+	Cond   []*Stmt
+	Then   []*Stmt
+	Else   []*Stmt
+	Redirs []*Redirect
+}
+
+// WhileClause is a while or until loop.
+type WhileClause struct {
+	Until  bool
+	Cond   []*Stmt
+	Do     []*Stmt
+	Redirs []*Redirect
+}
+
+// ForClause is "for name [in words]; do ...; done", "select ..." or the
+// arithmetic "for ((init; cond; post))" form (Arithm set).
+type ForClause struct {
+	Select bool
+	Name   string
+	Items  []*Word
+	Arithm *Word
+	Do     []*Stmt
+	Redirs []*Redirect
+}
+
+// CaseClause is "case word in pattern) ...;; esac".
+type CaseClause struct {
+	Word   *Word
+	Items  []*CaseItem
+	Redirs []*Redirect
+}
+
+// CaseItem is one "pattern|pattern) stmts ;;" arm.
+type CaseItem struct {
+	Patterns []*Word
+	Stmts    []*Stmt
+}
+
+// FuncDecl defines a function.
+type FuncDecl struct {
+	Name string
+	Body Command
+}
+
+// ArithmCmd is "(( expr ))".
+type ArithmCmd struct {
+	Expr   *Word
+	Redirs []*Redirect
+}
+
+// TestClause is "[[ expr ]]". Operands are kept as words; operators are
+// dropped.
+type TestClause struct {
+	Words  []*Word
+	Redirs []*Redirect
+}
+
+func (*CallExpr) commandNode()    {}
+func (*BinaryCmd) commandNode()   {}
+func (*Subshell) commandNode()    {}
+func (*Block) commandNode()       {}
+func (*IfClause) commandNode()    {}
+func (*WhileClause) commandNode() {}
+func (*ForClause) commandNode()   {}
+func (*CaseClause) commandNode()  {}
+func (*FuncDecl) commandNode()    {}
+func (*ArithmCmd) commandNode()   {}
+func (*TestClause) commandNode()  {}
+
+// Assign is "name=value", "name+=value" or "name=(array words)".
+type Assign struct {
+	Name   string
+	Append bool
+	Value  *Word   // nil for arrays
+	Array  []*Word // Set for "name=(...)"
+	Raw    string
+}
+
+// Redirect is an I/O redirection. Op includes any leading file descriptor
+// ("2>", "2>&", "&>>"). Hdoc holds a here-document body.
+type Redirect struct {
+	Op   string
+	Word *Word
+	Hdoc *Word
+}
+
+// Word is a shell word made of literal and expansion parts.
+type Word struct {
+	Parts []WordPart
+	Raw   string // Source text
+}
+
+// WordPart is one of *Lit, *SglQuoted, *DblQuoted, *ParamExp, *CmdSubst,
+// *ArithmExp or *ProcSubst.
+type WordPart interface {
+	wordPartNode()
+}
+
+// Lit is unquoted literal text with backslash escapes resolved.
+type Lit struct{ Value string }
+
+// SglQuoted is '...' or $'...'.
+type SglQuoted struct{ Value string }
+
+// DblQuoted is "..." with its inner parts.
+type DblQuoted struct{ Parts []WordPart }
+
+// ParamExp is $name or ${...}. Exp holds anything after the parameter name
+// inside braces (defaults, patterns), which may itself contain expansions.
+type ParamExp struct {
+	Name string
+	Exp  *Word
+}
+
+// CmdSubst is $(...) or `...`.
+type CmdSubst struct {
+	Stmts     []*Stmt
+	Backquote bool
+}
+
+// ArithmExp is $((...)) or $[...].
+type ArithmExp struct{ Expr *Word }
+
+// ProcSubst is <(...) or >(...).
+type ProcSubst struct {
+	Op    string
+	Stmts []*Stmt
+}
+
+func (*Lit) wordPartNode()       {}
+func (*SglQuoted) wordPartNode() {}
+func (*DblQuoted) wordPartNode() {}
+func (*ParamExp) wordPartNode()  {}
+func (*CmdSubst) wordPartNode()  {}
+func (*ArithmExp) wordPartNode() {}
+func (*ProcSubst) wordPartNode() {}
+
+// Lit returns the word's value when it contains no expansions, with quotes
+// removed. ok is false when any part is expanded at run time.
+func (w *Word) Lit() (string, bool) {
+	var b []byte
+	var walk func(parts []WordPart) bool
+	walk = func(parts []WordPart) bool {
+		for _, p := range parts {
+			switch p := p.(type) {
+			case *Lit:
+				b = append(b, p.Value...)
+			case *SglQuoted:
+				b = append(b, p.Value...)
+			case *DblQuoted:
+				if !walk(p.Parts) {
+					return false
+				}
+			default:
+				return false
+			}
+		}
+		return true
+	}
+	if !walk(w.Parts) {
+		return "", false
+	}
+	return string(b), true
+}
@@ -0,0 +1,1220 @@
+package shell
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxDepth bounds nesting (subshells, substitutions, compound commands) so
+// hostile input cannot exhaust the stack.
+const maxDepth = 64
+
+// ErrTooDeep is wrapped by the ParseError returned for input nested deeper
+// than the parser accepts.
+var ErrTooDeep = errors.New("nesting too deep")
+
+// ParseError reports a syntax error at a byte offset.
+type ParseError struct {
+	Pos int
+	Msg string
+	Err error // Optional underlying sentinel (ErrTooDeep)
+}
+
+func (e *ParseError) Error() string { return fmt.Sprintf("offset %d: %s", e.Pos, e.Msg) }
+
+func (e *ParseError) Unwrap() error { return e.Err }
+
+// Parse parses a command string.
+func Parse(src string) (f *File, err error) {
+	p := &parser{src: src}
+	defer func() {
+		if r := recover(); r != nil {
+			pe, ok := r.(*ParseError)
+			if !ok {
+				panic(r)
+			}
+			f, err = nil, pe
+		}
+	}()
+	stmts := p.parseStmts()
+	if !p.eof() {
+		p.failf("unexpected %q", p.peek())
+	}
+	p.flushHeredocs()
+	return &File{Stmts: stmts}, nil
+}
+
+type pendingHdoc struct {
+	redir  *Redirect
+	delim  string
+	quoted bool // Delimiter was quoted: body is literal
+	strip  bool // "<<-": leading tabs are stripped
+}
+
+// parser is a scannerless recursive-descent parser. Errors panic with
+// *ParseError and are recovered in Parse.
+type parser struct {
+	src      string
+	pos      int
+	depth    int
+	heredocs []pendingHdoc
+}
+
+func (p *parser) fail(msg string) { p.failErr(msg, nil) }
+
+func (p *parser) failf(format string, args ...any) { p.fail(fmt.Sprintf(format, args...)) }
+
+func (p *parser) failErr(msg string, err error) {
+	panic(&ParseError{Pos: p.pos, Msg: msg, Err: err})
+}
+
+func (p *parser) enter() {
+	p.depth++
+	if p.depth > maxDepth {
+		p.failErr(ErrTooDeep.Error(), ErrTooDeep)
+	}
+}
+
+func (p *parser) leave() { p.depth-- }
+
+func (p *parser) eof() bool { return p.pos >= len(p.src) }
+
+func (p *parser) peek() byte { return p.peekAt(0) }
+
+func (p *parser) peekAt(i int) byte {
+	if p.pos+i >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos+i]
+}
+
+func (p *parser) hasPrefix(s string) bool { return strings.HasPrefix(p.src[p.pos:], s) }
+
+// isMeta reports whether c ends an unquoted word.
+func isMeta(c byte) bool {
+	switch c {
+	case ' ', '\t', '\r', '\n', ';', '&', '|', '<', '>', '(', ')':
+		return true
+	}
+	return false
+}
+
+// skipBlanks skips spaces, tabs, line continuations and comments, but not
+// newlines.
+func (p *parser) skipBlanks() {
+	for !p.eof() {
+		switch c := p.peek(); {
+		case c == ' ' || c == '\t' || c == '\r':
+			p.pos++
+		case c == '\\' && p.peekAt(1) == '\n':
+			p.pos += 2
+		case c == '#':
+			for !p.eof() && p.peek() != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// newline consumes one newline and any here-document bodies that follow it.
+func (p *parser) newline() bool {
+	if p.peek() != '\n' {
+		return false
+	}
+	p.pos++
+	p.readHeredocs()
+	return true
+}
+
+func (p *parser) skipNewlines() {
+	for {
+		p.skipBlanks()
+		if !p.newline() {
+			return
+		}
+	}
+}
+
+// peekWord returns the plain unquoted word at the cursor, or "" when the
+// next token is not one. Used to recognise reserved words.
+func (p *parser) peekWord() string {
+	i := p.pos
+	for i < len(p.src) && !isMeta(p.src[i]) && !strings.ContainsRune("'\"\\$`", rune(p.src[i])) {
+		i++
+	}
+	if i < len(p.src) && !isMeta(p.src[i]) {
+		return ""
+	}
+	return p.src[p.pos:i]
+}
+
+func (p *parser) expectWord(w string) {
+	p.skipBlanks()
+	if p.peekWord() != w {
+		p.failf("expected %q", w)
+	}
+	p.pos += len(w)
+}
+
+func (p *parser) expect(s string) {
+	p.skipBlanks()
+	if !p.hasPrefix(s) {
+		p.failf("expected %q", s)
+	}
+	p.pos += len(s)
+}
+
+// atStop reports whether the cursor is at one of the list terminators:
+// reserved words ("fi", "}") or operators (")", ";;").
+func (p *parser) atStop(stops []string) bool {
+	word := p.peekWord()
+	for _, s := range stops {
+		if isOperatorStop(s) {
+			if p.hasPrefix(s) {
+				return true
+			}
+		} else if word == s {
+			return true
+		}
+	}
+	return false
+}
+
+func isOperatorStop(s string) bool { return s == ")" || strings.HasPrefix(s, ";") }
+
+// parseStmts parses a list of statements up to EOF or one of stops, which
+// is left unconsumed.
+func (p *parser) parseStmts(stops ...string) []*Stmt {
+	var stmts []*Stmt
+	for {
+		p.skipNewlines()
+		if p.eof() || p.atStop(stops) {
+			return stmts
+		}
+		s := p.parseAndOr()
+		stmts = append(stmts, s)
+		p.skipBlanks()
+		if p.atStop(stops) {
+			return stmts
+		}
+		switch c := p.peek(); {
+		case c == ';' && !p.hasPrefix(";;") && !p.hasPrefix(";&"):
+			p.pos++
+		case c == '&':
+			p.pos++
+			s.Background = true
+		case c == '\n' || p.eof():
+		default:
+			p.failf("unexpected %q", c)
+		}
+	}
+}
+
+func (p *parser) parseAndOr() *Stmt {
+	left := p.parsePipeline()
+	for {
+		p.skipBlanks()
+		var op string
+		switch {
+		case p.hasPrefix("&&"):
+			op = "&&"
+		case p.hasPrefix("||"):
+			op = "||"
+		default:
+			return left
+		}
+		p.pos += 2
+		p.skipNewlines()
+		right := p.parsePipeline()
+		left = &Stmt{Cmd: &BinaryCmd{Op: op, X: left, Y: right}}
+	}
+}
+
+func (p *parser) parsePipeline() *Stmt {
+	p.skipBlanks()
+	negated := false
+	if p.peekWord() == "!" {
+		p.pos++
+		negated = true
+	}
+	left := &Stmt{Cmd: p.parseCommand()}
+	for {
+		p.skipBlanks()
+		if p.peek() != '|' || p.hasPrefix("||") {
+			break
+		}
+		op := "|"
+		if p.hasPrefix("|&") {
+			op = "|&"
+		}
+		p.pos += len(op)
+		p.skipNewlines()
+		right := &Stmt{Cmd: p.parseCommand()}
+		left = &Stmt{Cmd: &BinaryCmd{Op: op, X: left, Y: right}}
+	}
+	left.Negated = negated
+	return left
+}
+
+func (p *parser) parseCommand() Command {
+	p.enter()
+	defer p.leave()
+	p.skipBlanks()
+	if p.eof() {
+		p.fail("expected command")
+	}
+
+	var cmd Command
+	var redirs *[]*Redirect
+	switch {
+	case p.hasPrefix("(("):
+		if c, ok := p.tryArithmCmd(); ok {
+			cmd, redirs = c, &c.Redirs
+			break
+		}
+		c := p.parseSubshell()
+		cmd, redirs = c, &c.Redirs
+	case p.peek() == '(':
+		c := p.parseSubshell()
+		cmd, redirs = c, &c.Redirs
+	default:
+		switch w := p.peekWord(); w {
+		case "{":
+			p.pos++
+			c := &Block{Stmts: p.parseStmts("}")}
+			p.expectWord("}")
+			cmd, redirs = c, &c.Redirs
+		case "if":
+			p.pos += 2
+			c := p.parseIfTail()
+			p.expectWord("fi")
+			cmd, redirs = c, &c.Redirs
+		case "while", "until":
+			p.pos += len(w)
+			c := &WhileClause{Until: w == "until", Cond: p.parseStmts("do")}
+			c.Do = p.parseDoDone()
+			cmd, redirs = c, &c.Redirs
+		case "for", "select":
+			p.pos += len(w)
+			c := p.parseFor(w == "select")
+			cmd, redirs = c, &c.Redirs
+		case "case":
+			p.pos += 4
+			c := p.parseCase()
+			cmd, redirs = c, &c.Redirs
+		case "[[":
+			p.pos += 2
+			c := p.parseTest()
+			cmd, redirs = c, &c.Redirs
+		case "function":
+			p.pos += len(w)
+			p.skipBlanks()
+			return p.parseFuncBody(p.parseName())
+		case "then", "elif", "else", "fi", "do", "done", "esac", "}", "]]":
+			p.failf("unexpected %q", w)
+		default:
+			return p.parseSimple()
+		}
+	}
+	p.parseRedirs(redirs)
+	return cmd
+}
+
+func (p *parser) parseSubshell() *Subshell {
+	p.pos++
+	c := &Subshell{Stmts: p.parseStmts(")")}
+	p.expect(")")
+	return c
+}
+
+func (p *parser) parseRedirs(dst *[]*Redirect) {
+	for {
+		p.skipBlanks()
+		r := p.tryRedirect()
+		if r == nil {
+			return
+		}
+		*dst = append(*dst, r)
+	}
+}
+
+// parseIfTail parses everything after "if" up to (not including) "fi".
+// Elif branches become a nested IfClause in Else.
+func (p *parser) parseIfTail() *IfClause {
+	c := &IfClause{Cond: p.parseStmts("then")}
+	p.expectWord("then")
+	c.Then = p.parseStmts("elif", "else", "fi")
+	switch p.peekWord() {
+	case "elif":
+		p.pos += 4
+		c.Else = []*Stmt{{Cmd: p.parseIfTail()}}
+	case "else":
+		p.pos += 4
+		c.Else = p.parseStmts("fi")
+	}
+	return c
+}
+
+// parseDoDone parses a loop body: "do ... done" or "{ ... }".
+func (p *parser) parseDoDone() []*Stmt {
+	p.skipNewlines()
+	if p.peekWord() == "{" {
+		p.pos++
+		stmts := p.parseStmts("}")
+		p.expectWord("}")
+		return stmts
+	}
+	p.expectWord("do")
+	stmts := p.parseStmts("done")
+	p.expectWord("done")
+	return stmts
+}
+
+func (p *parser) parseFor(sel bool) *ForClause {
+	c := &ForClause{Select: sel}
+	p.skipBlanks()
+	if !sel && p.hasPrefix("((") {
+		p.pos += 2
+		expr, ok := p.parseArith(true)
+		if !ok {
+			p.fail("unterminated for ((")
+		}
+		c.Arithm = expr
+		p.skipBlanks()
+		if p.peek() == ';' {
+			p.pos++
+		}
+		c.Do = p.parseDoDone()
+		return c
+	}
+
+	c.Name = p.parseName()
+	p.skipNewlines()
+	if p.peekWord() == "in" {
+		p.pos += 2
+		for {
+			p.skipBlanks()
+			if p.eof() || p.peek() == ';' || p.peek() == '\n' {
+				break
+			}
+			c.Items = append(c.Items, p.parseWord())
+		}
+		if p.peek() == ';' {
+			p.pos++
+		}
+	} else if p.peek() == ';' {
+		p.pos++
+	}
+	c.Do = p.parseDoDone()
+	return c
+}
+
+func (p *parser) parseCase() *CaseClause {
+	p.skipBlanks()
+	c := &CaseClause{Word: p.parseWord()}
+	p.skipNewlines()
+	p.expectWord("in")
+	for {
+		p.skipNewlines()
+		if p.peekWord() == "esac" {
+			p.pos += 4
+			return c
+		}
+		if p.eof() {
+			p.fail(`expected "esac"`)
+		}
+		item := &CaseItem{}
+		if p.peek() == '(' {
+			p.pos++
+		}
+		for {
+			p.skipBlanks()
+			item.Patterns = append(item.Patterns, p.parseWord())
+			p.skipBlanks()
+			if p.peek() != '|' {
+				break
+			}
+			p.pos++
+		}
+		p.expect(")")
+		item.Stmts = p.parseStmts(";;&", ";;", ";&", "esac")
+		c.Items = append(c.Items, item)
+		p.skipBlanks()
+		for _, term := range []string{";;&", ";;", ";&"} {
+			if p.hasPrefix(term) {
+				p.pos += len(term)
+				break
+			}
+		}
+	}
+}
+
+func (p *parser) parseTest() *TestClause {
+	c := &TestClause{}
+	for {
+		p.skipBlanks()
+		if p.newline() {
+			continue
+		}
+		if p.peekWord() == "]]" {
+			p.pos += 2
+			return c
+		}
+		if p.eof() {
+			p.fail(`expected "]]"`)
+		}
+		switch {
+		case p.hasPrefix("&&") || p.hasPrefix("||"):
+			p.pos += 2
+		case strings.IndexByte("()|<>", p.peek()) >= 0:
+			p.pos++
+		case p.peekWord() == "!":
+			p.pos++
+		default:
+			c.Words = append(c.Words, p.parseWord())
+		}
+	}
+}
+
+// parseName reads a variable or function name.
+func (p *parser) parseName() string {
+	start := p.pos
+	for !p.eof() && !isMeta(p.peek()) && !strings.ContainsRune("'\"\\$`", rune(p.peek())) {
+		p.pos++
+	}
+	if p.pos == start {
+		p.fail("expected name")
+	}
+	return p.src[start:p.pos]
+}
+
+// parseFuncBody parses the optional "()" and the body of a function named
+// name.
+func (p *parser) parseFuncBody(name string) *FuncDecl {
+	p.skipBlanks()
+	if p.peek() == '(' {
+		p.pos++
+		p.expect(")")
+	}
+	p.skipNewlines()
+	return &FuncDecl{Name: name, Body: p.parseCommand()}
+}
+
+// atFuncParens reports whether the cursor is at "()" (blanks allowed).
+func (p *parser) atFuncParens() bool {
+	if p.peek() != '(' {
+		return false
+	}
+	i := p.pos + 1
+	for i < len(p.src) && (p.src[i] == ' ' || p.src[i] == '\t') {
+		i++
+	}
+	return i < len(p.src) && p.src[i] == ')'
+}
+
+func (p *parser) parseSimple() Command {
+	start, end := p.pos, p.pos
+	call := &CallExpr{}
+	for {
+		p.skipBlanks()
+		if p.eof() {
+			break
+		}
+		if r := p.tryRedirect(); r != nil {
+			call.Redirs = append(call.Redirs, r)
+			end = p.pos
+			continue
+		}
+		c := p.peek()
+		if c == ';' || c == '&' || c == '|' || c == '\n' || c == ')' {
+			break
+		}
+		if c == '(' {
+			if len(call.Args) == 1 && len(call.Assigns) == 0 && len(call.Redirs) == 0 && p.atFuncParens() {
+				name, ok := call.Args[0].Lit()
+				if !ok {
+					p.fail("invalid function name")
+				}
+				return p.parseFuncBody(name)
+			}
+			p.fail(`unexpected "("`)
+		}
+		if len(call.Args) == 0 {
+			if a := p.tryAssign(); a != nil {
+				call.Assigns = append(call.Assigns, a)
+				end = p.pos
+				continue
+			}
+		}
+		call.Args = append(call.Args, p.parseWord())
+		end = p.pos
+	}
+	if len(call.Args) == 0 && len(call.Assigns) == 0 && len(call.Redirs) == 0 {
+		p.fail("expected command")
+	}
+	call.Text = p.src[start:end]
+	return call
+}
+
+// tryAssign parses "name=value", "name[i]=value", "name+=value" or
+// "name=(words)" at the cursor.
+func (p *parser) tryAssign() *Assign {
+	i := p.pos
+	if i >= len(p.src) || !isNameStart(p.src[i]) {
+		return nil
+	}
+	for i < len(p.src) && isNameChar(p.src[i]) {
+		i++
+	}
+	name := p.src[p.pos:i]
+	if i < len(p.src) && p.src[i] == '[' {
+		end := strings.IndexByte(p.src[i:], ']')
+		if end < 0 {
+			return nil
+		}
+		i += end + 1
+	}
+	a := &Assign{Name: name}
+	if strings.HasPrefix(p.src[i:], "+=") {
+		a.Append = true
+		i += 2
+	} else if i < len(p.src) && p.src[i] == '=' {
+		i++
+	} else {
+		return nil
+	}
+
+	start := p.pos
+	p.pos = i
+	switch {
+	case p.peek() == '(':
+		p.pos++
+		for {
+			p.skipNewlines()
+			if p.peek() == ')' {
+				p.pos++
+				break
+			}
+			if p.eof() {
+				p.fail("unterminated array")
+			}
+			a.Array = append(a.Array, p.parseWord())
+		}
+	case p.eof() || isMeta(p.peek()):
+		a.Value = &Word{}
+	default:
+		a.Value = p.parseWord()
+	}
+	a.Raw = p.src[start:p.pos]
+	return a
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNameChar(c byte) bool { return isNameStart(c) || (c >= '0' && c <= '9') }
+
+// redirOps are the redirection operators, longest first.
+var redirOps = []string{"&>>", "<<<", "<<-", "&>", "<<", "<>", "<&", ">>", ">&", ">|", "<", ">"}
+
+// tryRedirect parses a redirection at the cursor, including a leading file
+// descriptor ("2>") or {varname}.
+func (p *parser) tryRedirect() *Redirect {
+	i := p.pos
+	for i < len(p.src) && p.src[i] >= '0' && p.src[i] <= '9' {
+		i++
+	}
+	if i == p.pos && p.peek() == '{' {
+		if end := strings.IndexByte(p.src[i:], '}'); end > 1 {
+			j := i + end + 1
+			if j < len(p.src) && (p.src[j] == '<' || p.src[j] == '>') {
+				i = j
+			}
+		}
+	}
+	rest := p.src[i:]
+	// Process substitution is a word, not a redirection
+	if strings.HasPrefix(rest, "<(") || strings.HasPrefix(rest, ">(") {
+		return nil
+	}
+	var op string
+	for _, o := range redirOps {
+		if strings.HasPrefix(rest, o) {
+			op = o
+			break
+		}
+	}
+	if op == "" || (op[0] == '&' && i != p.pos) {
+		return nil
+	}
+	r := &Redirect{Op: p.src[p.pos:i] + op}
+	p.pos = i + len(op)
+	p.skipBlanks()
+	if p.eof() || isMeta(p.peek()) {
+		p.failf("missing target for %q", r.Op)
+	}
+	r.Word = p.parseWord()
+	if op == "<<" || op == "<<-" {
+		delim, ok := r.Word.Lit()
+		if !ok {
+			delim = r.Word.Raw
+		}
+		p.heredocs = append(p.heredocs, pendingHdoc{
+			redir:  r,
+			delim:  delim,
+			quoted: strings.ContainsAny(r.Word.Raw, `'"\`),
+			strip:  op == "<<-",
+		})
+	}
+	return r
+}
+
+// readHeredocs consumes the bodies of pending here-documents, which start on
+// the line after their operator.
+func (p *parser) readHeredocs() {
+	pending := p.heredocs
+	p.heredocs = nil
+	for _, h := range pending {
+		var body strings.Builder
+		for !p.eof() {
+			end := strings.IndexByte(p.src[p.pos:], '\n')
+			next := len(p.src)
+			if end >= 0 {
+				end += p.pos
+				next = end + 1
+			} else {
+				end = len(p.src)
+			}
+			line := p.src[p.pos:end]
+			p.pos = next
+			if h.strip {
+				line = strings.TrimLeft(line, "\t")
+			}
+			if line == h.delim {
+				break
+			}
+			body.WriteString(line)
+			body.WriteByte('\n')
+		}
+		p.setHdoc(h, body.String())
+	}
+}
+
+// flushHeredocs gives here-documents never terminated by a newline an empty
+// body.
+func (p *parser) flushHeredocs() {
+	for _, h := range p.heredocs {
+		p.setHdoc(h, "")
+	}
+	p.heredocs = nil
+}
+
+func (p *parser) setHdoc(h pendingHdoc, body string) {
+	if h.quoted {
+		h.redir.Hdoc = &Word{Parts: []WordPart{&Lit{Value: body}}, Raw: body}
+		return
+	}
+	sub := &parser{src: body, depth: p.depth}
+	parts := sub.parseQuotedParts(0)
+	h.redir.Hdoc = &Word{Parts: parts, Raw: body}
+}
+
+// parseWord parses one word at the cursor.
+func (p *parser) parseWord() *Word {
+	start := p.pos
+	var parts []WordPart
+	var lit []byte
+	flush := func() {
+		if len(lit) > 0 {
+			parts = append(parts, &Lit{Value: string(lit)})
+			lit = nil
+		}
+	}
+loop:
+	for !p.eof() {
+		c := p.peek()
+		switch {
+		case c == '\\':
+			switch {
+			case p.pos+1 >= len(p.src):
+				lit = append(lit, c)
+				p.pos++
+			case p.src[p.pos+1] == '\n':
+				p.pos += 2
+			default:
+				lit = append(lit, p.src[p.pos+1])
+				p.pos += 2
+			}
+		case c == '\'':
+			flush()
+			parts = append(parts, p.parseSglQuoted())
+		case c == '"':
+			flush()
+			parts = append(parts, p.parseDblQuoted())
+		case c == '$':
+			flush()
+			if part := p.parseDollar(); part != nil {
+				parts = append(parts, part)
+			} else {
+				lit = append(lit, '$')
+			}
+		case c == '`':
+			flush()
+			parts = append(parts, p.parseBackquote())
+		case (c == '<' || c == '>') && p.peekAt(1) == '(' && p.pos == start:
+			p.pos += 2
+			stmts := p.parseStmts(")")
+			p.expect(")")
+			parts = append(parts, &ProcSubst{Op: string(c) + "(", Stmts: stmts})
+		case strings.IndexByte("@!+*?", c) >= 0 && p.peekAt(1) == '(':
+			// Extended glob pattern: literal up to the matching paren
+			depth := 0
+			for !p.eof() {
+				ch := p.peek()
+				lit = append(lit, ch)
+				p.pos++
+				if ch == '(' {
+					depth++
+				} else if ch == ')' {
+					depth--
+					if depth == 0 {
+						break
+					}
+				}
+			}
+			if depth != 0 {
+				p.fail("unterminated extended glob")
+			}
+		case isMeta(c):
+			break loop
+		default:
+			lit = append(lit, c)
+			p.pos++
+		}
+	}
+	flush()
+	if p.pos == start {
+		p.failf("unexpected %q", p.peek())
+	}
+	return &Word{Parts: parts, Raw: p.src[start:p.pos]}
+}
+
+func (p *parser) parseSglQuoted() *SglQuoted {
+	p.pos++
+	end := strings.IndexByte(p.src[p.pos:], '\'')
+	if end < 0 {
+		p.fail("unterminated single quote")
+	}
+	v := p.src[p.pos : p.pos+end]
+	p.pos += end + 1
+	return &SglQuoted{Value: v}
+}
+
+func (p *parser) parseDblQuoted() *DblQuoted {
+	p.pos++
+	parts := p.parseQuotedParts('"')
+	return &DblQuoted{Parts: parts}
+}
+
+// parseQuotedParts parses double-quoted content up to the closing quote (or
+// to EOF when quote is 0, for here-document bodies).
+func (p *parser) parseQuotedParts(quote byte) []WordPart {
+	var parts []WordPart
+	var lit []byte
+	flush := func() {
+		if len(lit) > 0 {
+			parts = append(parts, &Lit{Value: string(lit)})
+			lit = nil
+		}
+	}
+	for {
+		if p.eof() {
+			if quote != 0 {
+				p.fail("unterminated double quote")
+			}
+			break
+		}
+		c := p.peek()
+		if quote != 0 && c == quote {
+			p.pos++
+			break
+		}
+		switch c {
+		case '\\':
+			next := p.peekAt(1)
+			switch {
+			case next == '\n':
+				p.pos += 2
+			case next == '$' || next == '`' || next == '\\' || (quote != 0 && next == quote):
+				lit = append(lit, next)
+				p.pos += 2
+			default:
+				lit = append(lit, c)
+				p.pos++
+			}
+		case '$':
+			flush()
+			if part := p.parseDollar(); part != nil {
+				parts = append(parts, part)
+			} else {
+				lit = append(lit, '$')
+			}
+		case '`':
+			flush()
+			parts = append(parts, p.parseBackquote())
+		default:
+			lit = append(lit, c)
+			p.pos++
+		}
+	}
+	flush()
+	return parts
+}
+
+// parseDollar parses an expansion starting at "$". It returns nil (having
+// consumed the "$") when the dollar sign is literal.
+func (p *parser) parseDollar() WordPart {
+	p.enter()
+	defer p.leave()
+	switch next := p.peekAt(1); {
+	case p.hasPrefix("$(("):
+		save := p.pos
+		p.pos += 3
+		if expr, ok := p.parseArith(true); ok {
+			return &ArithmExp{Expr: expr}
+		}
+		p.pos = save
+		fallthrough
+	case next == '(':
+		p.pos += 2
+		stmts := p.parseStmts(")")
+		p.expect(")")
+		return &CmdSubst{Stmts: stmts}
+	case next == '[':
+		p.pos += 2
+		expr, ok := p.parseArith(false)
+		if !ok {
+			p.fail("unterminated $[")
+		}
+		return &ArithmExp{Expr: expr}
+	case next == '{':
+		p.pos += 2
+		return p.parseParamExp()
+	case next == '\'':
+		p.pos++
+		return p.parseANSIQuoted()
+	case next == '"':
+		p.pos++
+		return p.parseDblQuoted()
+	case isNameStart(next):
+		p.pos++
+		start := p.pos
+		for !p.eof() && isNameChar(p.peek()) {
+			p.pos++
+		}
+		return &ParamExp{Name: p.src[start:p.pos]}
+	case next != 0 && strings.IndexByte("@*#?$!-0123456789", next) >= 0:
+		p.pos += 2
+		return &ParamExp{Name: string(next)}
+	}
+	p.pos++
+	return nil
+}
+
+// parseParamExp parses the inside of "${...}" after the opening brace.
+func (p *parser) parseParamExp() *ParamExp {
+	start := p.pos
+	// Length and indirection prefixes: ${#var}, ${!ref} (but ${#} and ${!}
+	// are special parameters)
+	if c := p.peek(); (c == '#' || c == '!') && p.peekAt(1) != '}' {
+		p.pos++
+	}
+	switch c := p.peek(); {
+	case isNameStart(c):
+		for !p.eof() && isNameChar(p.peek()) {
+			p.pos++
+		}
+	case c >= '0' && c <= '9':
+		for !p.eof() && p.peek() >= '0' && p.peek() <= '9' {
+			p.pos++
+		}
+	case c != 0 && strings.IndexByte("@*#?$!-", c) >= 0:
+		p.pos++
+	}
+	pe := &ParamExp{Name: p.src[start:p.pos]}
+
+	restStart := p.pos
+	var parts []WordPart
+	var lit []byte
+	flush := func() {
+		if len(lit) > 0 {
+			parts = append(parts, &Lit{Value: string(lit)})
+			lit = nil
+		}
+	}
+	depth := 0
+	for {
+		if p.eof() {
+			p.fail("unterminated ${")
+		}
+		c := p.peek()
+		if c == '}' && depth == 0 {
+			break
+		}
+		switch c {
+		case '{':
+			depth++
+			lit = append(lit, c)
+			p.pos++
+		case '}':
+			depth--
+			lit = append(lit, c)
+			p.pos++
+		case '\\':
+			if p.pos+1 < len(p.src) {
+				lit = append(lit, p.src[p.pos+1])
+				p.pos += 2
+			} else {
+				p.pos++
+			}
+		case '\'':
+			flush()
+			parts = append(parts, p.parseSglQuoted())
+		case '"':
+			flush()
+			parts = append(parts, p.parseDblQuoted())
+		case '$':
+			flush()
+			if part := p.parseDollar(); part != nil {
+				parts = append(parts, part)
+			} else {
+				lit = append(lit, '$')
+			}
+		case '`':
+			flush()
+			parts = append(parts, p.parseBackquote())
+		default:
+			lit = append(lit, c)
+			p.pos++
+		}
+	}
+	flush()
+	if p.pos > restStart {
+		pe.Exp = &Word{Parts: parts, Raw: p.src[restStart:p.pos]}
+	}
+	p.pos++ // closing brace
+	return pe
+}
+
+// parseANSIQuoted parses $'...' (the cursor is on the quote), decoding its
+// backslash escapes the way bash does.
+func (p *parser) parseANSIQuoted() *SglQuoted {
+	p.pos++
+	var b []byte
+	for {
+		if p.eof() {
+			p.fail("unterminated $'")
+		}
+		c := p.peek()
+		if c == '\'' {
+			p.pos++
+			return &SglQuoted{Value: string(b)}
+		}
+		if c == '\\' && p.pos+1 < len(p.src) {
+			p.pos++
+			b = p.ansiEscape(b)
+			continue
+		}
+		b = append(b, c)
+		p.pos++
+	}
+}
+
+// ansiEscapes maps single-character $'...' escapes to their bytes.
+var ansiEscapes = map[byte]byte{
+	'a': 7, 'b': 8, 'e': 27, 'E': 27, 'f': 12, 'n': '\n', 'r': '\r', 't': '\t', 'v': 11,
+	'\\': '\\', '\'': '\'', '"': '"', '?': '?',
+}
+
+// ansiEscape decodes one $'...' escape (the cursor is after the backslash)
+// and appends it to b.
+func (p *parser) ansiEscape(b []byte) []byte {
+	c := p.src[p.pos]
+	p.pos++
+	if v, ok := ansiEscapes[c]; ok {
+		return append(b, v)
+	}
+	digits := func(max int, isDigit func(byte) bool, base int) (int, bool) {
+		n, count := 0, 0
+		for count < max && !p.eof() && isDigit(p.peek()) {
+			d, _ := strconv.ParseInt(string(p.peek()), base, 8)
+			n = n*base + int(d)
+			p.pos++
+			count++
+		}
+		return n, count > 0
+	}
+	switch {
+	case c >= '0' && c <= '7':
+		p.pos--
+		n, _ := digits(3, func(d byte) bool { return d >= '0' && d <= '7' }, 8)
+		return append(b, byte(n))
+	case c == 'x':
+		if n, ok := digits(2, isHexDigit, 16); ok {
+			return append(b, byte(n))
+		}
+	case c == 'u' || c == 'U':
+		max := 4
+		if c == 'U' {
+			max = 8
+		}
+		if n, ok := digits(max, isHexDigit, 16); ok {
+			return utf8.AppendRune(b, rune(n))
+		}
+	case c == 'c' && !p.eof():
+		ctl := p.peek()
+		p.pos++
+		return append(b, ctl&0x1f)
+	}
+	return append(b, '\\', c)
+}
+
+func isHexDigit(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
+
+// parseBackquote parses `...`. The content is unescaped and parsed as a
+// separate command string.
+func (p *parser) parseBackquote() *CmdSubst {
+	p.pos++
+	var b []byte
+	for {
+		if p.eof() {
+			p.fail("unterminated backquote")
+		}
+		c := p.peek()
+		if c == '`' {
+			p.pos++
+			break
+		}
+		if c == '\\' && p.pos+1 < len(p.src) {
+			next := p.src[p.pos+1]
+			if next == '`' || next == '$' || next == '\\' {
+				b = append(b, next)
+			} else {
+				b = append(b, c, next)
+			}
+			p.pos += 2
+			continue
+		}
+		b = append(b, c)
+		p.pos++
+	}
+	sub := &parser{src: string(b), depth: p.depth + 1}
+	stmts, err := sub.parseNested()
+	if err != nil {
+		p.failErr("in backquote: "+err.Msg, err.Err)
+	}
+	return &CmdSubst{Stmts: stmts, Backquote: true}
+}
+
+// parseNested parses a complete command string for a nested parser.
+func (p *parser) parseNested() (stmts []*Stmt, perr *ParseError) {
+	defer func() {
+		if r := recover(); r != nil {
+			pe, ok := r.(*ParseError)
+			if !ok {
+				panic(r)
+			}
+			perr = pe
+		}
+	}()
+	p.enter()
+	stmts = p.parseStmts()
+	if !p.eof() {
+		p.failf("unexpected %q", p.peek())
+	}
+	p.flushHeredocs()
+	return stmts, nil
+}
+
+// tryArithmCmd parses "(( expr ))". ok is false (with the cursor restored)
+// when the input is a nested subshell instead, e.g. "((a; b) )".
+func (p *parser) tryArithmCmd() (*ArithmCmd, bool) {
+	save := p.pos
+	p.pos += 2
+	if expr, ok := p.parseArith(true); ok {
+		return &ArithmCmd{Expr: expr}, true
+	}
+	p.pos = save
+	return nil, false
+}
+
+// parseArith parses an arithmetic expression up to "))" (double) or "]".
+// Expansions inside are parsed; everything else is kept literally. ok is
+// false when the closing token is not found at paren depth zero.
+func (p *parser) parseArith(double bool) (*Word, bool) {
+	start := p.pos
+	var parts []WordPart
+	var lit []byte
+	flush := func() {
+		if len(lit) > 0 {
+			parts = append(parts, &Lit{Value: string(lit)})
+			lit = nil
+		}
+	}
+	depth := 0
+	for {
+		if p.eof() {
+			return nil, false
+		}
+		c := p.peek()
+		switch {
+		case c == '(':
+			depth++
+		case c == ')' && double:
+			if depth == 0 {
+				if p.peekAt(1) != ')' {
+					return nil, false
+				}
+				flush()
+				w := &Word{Parts: parts, Raw: p.src[start:p.pos]}
+				p.pos += 2
+				return w, true
+			}
+			depth--
+		case c == ']' && !double && depth == 0:
+			flush()
+			w := &Word{Parts: parts, Raw: p.src[start:p.pos]}
+			p.pos++
+			return w, true
+		}
+		switch c {
+		case '$':
+			flush()
+			if part := p.parseDollar(); part != nil {
+				parts = append(parts, part)
+			} else {
+				lit = append(lit, '$')
+			}
+		case '`':
+			flush()
+			parts = append(parts, p.parseBackquote())
+		case '\'':
+			flush()
+			parts = append(parts, p.parseSglQuoted())
+		case '"':
+			flush()
+			parts = append(parts, p.parseDblQuoted())
+		default:
+			lit = append(lit, c)
+			p.pos++
+		}
+	}
+}
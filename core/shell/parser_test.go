@@ -0,0 +1,152 @@
+package shell
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// calls returns the source text of every simple command in f, in walk order.
+func calls(f *File) []string {
+	var out []string
+	Walk(f, func(n any) bool {
+		if c, ok := n.(*CallExpr); ok {
+			out = append(out, c.Text)
+		}
+		return true
+	})
+	return out
+}
+
+func TestParse_Commands(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []string
+	}{
+		{"ls -la", []string{"ls -la"}},
+		{"a | b |& c", []string{"a", "b", "c"}},
+		{"a && b || c; d & e", []string{"a", "b", "c", "d", "e"}},
+		{"(cd dir && make) > log 2>&1", []string{"cd dir", "make"}},
+		{"{ a; b; } | c", []string{"a", "b", "c"}},
+		{"echo $(whoami) `id -u`", []string{"echo $(whoami) `id -u`", "whoami", "id -u"}},
+		{`echo "x $(date +%s) ${HOME:-$(pwd)}"`, []string{`echo "x $(date +%s) ${HOME:-$(pwd)}"`, "date +%s", "pwd"}},
+		{"diff <(ls a) >(wc -l)", []string{"diff <(ls a) >(wc -l)", "ls a", "wc -l"}},
+		{"if test -f x; then rm x; elif true; then :; else echo no; fi", []string{"test -f x", "rm x", "true", ":", "echo no"}},
+		{"for f in *.go; do gofmt -l \"$f\"; done", []string{"gofmt -l \"$f\""}},
+		{"for ((i=0; i<3; i++)); do echo $i; done", []string{"echo $i"}},
+		{"while read -r l; do echo $l; done < file", []string{"read -r l", "echo $l"}},
+		{"until false; do break; done", []string{"false", "break"}},
+		{"case $x in a|b) one;; *) two;; esac", []string{"one", "two"}},
+		{"f() { echo hi; }; f", []string{"echo hi", "f"}},
+		{"function g { echo hi; }", []string{"echo hi"}},
+		{"[[ -n $(cat f) && $a =~ ^(x|y)$ ]]", []string{"cat f"}},
+		{"(( n = $(nproc) * 2 ))", []string{"nproc"}},
+		{"echo $((1 + $(id -u)))", []string{"echo $((1 + $(id -u)))", "id -u"}},
+		{"A=1 B=$(hostname) make", []string{"A=1 B=$(hostname) make", "hostname"}},
+		{"arr=(one $(two) three)", []string{"arr=(one $(two) three)", "two"}},
+		{"cat <<EOF\nhello $(whoami)\nEOF\necho done", []string{"cat <<EOF", "whoami", "echo done"}},
+		{"cat <<'EOF'\nhello $(whoami)\nEOF", []string{"cat <<'EOF'"}},
+		{"cat <<-EOF | grep x\n\tbody\n\tEOF", []string{"cat <<-EOF", "grep x"}},
+		{"x=$(cat <<EOF\nin $(nested)\nEOF\n)", []string{"x=$(cat <<EOF\nin $(nested)\nEOF\n)", "cat <<EOF", "nested"}},
+		{"echo a # comment; rm -rf /", []string{"echo a"}},
+		{"echo 'it''s' \"a\\\"b\" \\; $'c\\'d'", []string{"echo 'it''s' \"a\\\"b\" \\; $'c\\'d'"}},
+		{"! grep -q x f", []string{"grep -q x f"}},
+		{"echo `echo \\`nested\\``", []string{"echo `echo \\`nested\\``", "echo `nested`", "nested"}},
+		{"ls \\\n  -la", []string{"ls \\\n  -la"}},
+		{"shopt -s extglob; ls !(*.go)", []string{"shopt -s extglob", "ls !(*.go)"}},
+		{"exec 3>&1 {fd}>out", []string{"exec 3>&1 {fd}>out"}},
+		{"$((1))", []string{"$((1))"}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			f, err := Parse(tt.src)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, calls(f))
+		})
+	}
+}
+
+func TestParse_Structure(t *testing.T) {
+	f, err := Parse("FOO=bar cmd 'a b' c\"d\"e 2>/dev/null >>out &")
+	require.NoError(t, err)
+	require.Len(t, f.Stmts, 1)
+	assert.True(t, f.Stmts[0].Background)
+
+	call := f.Stmts[0].Cmd.(*CallExpr)
+	require.Len(t, call.Assigns, 1)
+	assert.Equal(t, "FOO", call.Assigns[0].Name)
+	var args []string
+	for _, w := range call.Args {
+		v, ok := w.Lit()
+		require.True(t, ok)
+		args = append(args, v)
+	}
+	assert.Equal(t, []string{"cmd", "a b", "cde"}, args)
+	require.Len(t, call.Redirs, 2)
+	assert.Equal(t, "2>", call.Redirs[0].Op)
+	assert.Equal(t, ">>", call.Redirs[1].Op)
+
+	f, err = Parse("cat <<EOF\nx\nEOF")
+	require.NoError(t, err)
+	hd := f.Stmts[0].Cmd.(*CallExpr).Redirs[0]
+	assert.Equal(t, "<<", hd.Op)
+	assert.Equal(t, "x\n", hd.Hdoc.Raw)
+
+	f, err = Parse("echo $HOME")
+	require.NoError(t, err)
+	_, ok := f.Stmts[0].Cmd.(*CallExpr).Args[1].Lit()
+	assert.False(t, ok, "expansions are not literal")
+}
+
+func TestParse_ANSIQuoted(t *testing.T) {
+	for src, want := range map[string]string{
+		`$'a\tb'`:    "a\tb",
+		`$'\x63url'`: "curl",
+		`$'\143url'`: "curl",
+		`$'\u00e9'`:  "é",
+		`$'it\'s'`:   "it's",
+		`$'\cA'`:     "\x01",
+		`$'\q'`:      `\q`,
+	} {
+		f, err := Parse(src)
+		require.NoError(t, err, src)
+		got, ok := f.Stmts[0].Cmd.(*CallExpr).Args[0].Lit()
+		require.True(t, ok)
+		assert.Equal(t, want, got, src)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, src := range []string{
+		`echo "unterminated`,
+		"echo 'unterminated",
+		"echo $(missing",
+		"echo `missing",
+		"if true; then echo",
+		"for x in a; do echo",
+		"case x in a) b",
+		"{ echo",
+		"( echo",
+		"echo )",
+		"| grep",
+		"a && ",
+		"a ;; b",
+		"echo >",
+		"then echo",
+		"echo ${unterminated",
+	} {
+		_, err := Parse(src)
+		assert.Error(t, err, src)
+	}
+}
+
+func TestParse_TooDeep(t *testing.T) {
+	src := strings.Repeat("$(", 100) + "x" + strings.Repeat(")", 100)
+	_, err := Parse(src)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrTooDeep))
+}
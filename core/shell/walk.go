@@ -0,0 +1,112 @@
+package shell
+
+// Walk traverses the tree rooted at node depth-first, calling fn for each
+// node before its children: *File, *Stmt, every Command and WordPart type,
+// *Word, *Assign, *Redirect and *CaseItem. Children are skipped when fn
+// returns false.
+func Walk(node any, fn func(node any) bool) {
+	if node == nil || !fn(node) {
+		return
+	}
+	stmts := func(ss []*Stmt) {
+		for _, s := range ss {
+			Walk(s, fn)
+		}
+	}
+	words := func(ws []*Word) {
+		for _, w := range ws {
+			Walk(w, fn)
+		}
+	}
+	redirs := func(rs []*Redirect) {
+		for _, r := range rs {
+			Walk(r, fn)
+		}
+	}
+	parts := func(ps []WordPart) {
+		for _, p := range ps {
+			Walk(p, fn)
+		}
+	}
+
+	switch n := node.(type) {
+	case *File:
+		stmts(n.Stmts)
+	case *Stmt:
+		Walk(n.Cmd, fn)
+	case *CallExpr:
+		for _, a := range n.Assigns {
+			Walk(a, fn)
+		}
+		words(n.Args)
+		redirs(n.Redirs)
+	case *BinaryCmd:
+		Walk(n.X, fn)
+		Walk(n.Y, fn)
+	case *Subshell:
+		stmts(n.Stmts)
+		redirs(n.Redirs)
+	case *Block:
+		stmts(n.Stmts)
+		redirs(n.Redirs)
+	case *IfClause:
+		stmts(n.Cond)
+		stmts(n.Then)
+		stmts(n.Else)
+		redirs(n.Redirs)
+	case *WhileClause:
+		stmts(n.Cond)
+		stmts(n.Do)
+		redirs(n.Redirs)
+	case *ForClause:
+		words(n.Items)
+		if n.Arithm != nil {
+			Walk(n.Arithm, fn)
+		}
+		stmts(n.Do)
+		redirs(n.Redirs)
+	case *CaseClause:
+		Walk(n.Word, fn)
+		for _, it := range n.Items {
+			Walk(it, fn)
+		}
+		redirs(n.Redirs)
+	case *CaseItem:
+		words(n.Patterns)
+		stmts(n.Stmts)
+	case *FuncDecl:
+		Walk(n.Body, fn)
+	case *ArithmCmd:
+		Walk(n.Expr, fn)
+		redirs(n.Redirs)
+	case *TestClause:
+		words(n.Words)
+		redirs(n.Redirs)
+	case *Assign:
+		if n.Value != nil {
+			Walk(n.Value, fn)
+		}
+		words(n.Array)
+	case *Redirect:
+		if n.Word != nil {
+			Walk(n.Word, fn)
+		}
+		if n.Hdoc != nil {
+			Walk(n.Hdoc, fn)
+		}
+	case *Word:
+		parts(n.Parts)
+	case *DblQuoted:
+		parts(n.Parts)
+	case *ParamExp:
+		if n.Exp != nil {
+			Walk(n.Exp, fn)
+		}
+	case *CmdSubst:
+		stmts(n.Stmts)
+	case *ArithmExp:
+		Walk(n.Expr, fn)
+	case *ProcSubst:
+		stmts(n.Stmts)
+	}
+}
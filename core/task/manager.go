@@ -53,12 +53,37 @@ func (t *Task) IsTerminal() bool {
 	return t.Status == StatusCompleted || t.Status == StatusFailed || t.Status == StatusStopped
 }
 
+// Info is a point-in-time copy of a task's descriptive fields, safe to read
+// while the task keeps running.
+type Info struct {
+	ID        string   `json:"id"`
+	Subject   string   `json:"subject"`
+	Status    Status   `json:"status"`
+	Owner     string   `json:"owner,omitempty"`
+	BlockedBy []string `json:"blocked_by,omitempty"`
+}
+
+// Info returns a snapshot of the task's descriptive fields.
+func (t *Task) Info() Info {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return Info{
+		ID:        t.ID,
+		Subject:   t.Subject,
+		Status:    t.Status,
+		Owner:     t.Owner,
+		BlockedBy: append([]string(nil), t.BlockedBy...),
+	}
+}
+
 // Manager tracks all tasks for a session.
 type Manager struct {
 	mu     sync.RWMutex
 	tasks  map[string]*Task
 	order  []string // Preserves creation order
 	nextID int
+
+	onChange func() // Called after every mutation, outside the locks
 }
 
 // NewManager creates an empty task manager.
@@ -71,7 +96,6 @@ func NewManager() *Manager {
 // Create adds a new task and returns its ID.
 func (m *Manager) Create(subject, description, activeForm string, metadata map[string]interface{}) *Task {
 	m.mu.Lock()
-	defer m.mu.Unlock()
 
 	m.nextID++
 	id := fmt.Sprintf("task-%d", m.nextID)
@@ -90,6 +114,9 @@ func (m *Manager) Create(subject, description, activeForm string, metadata map[s
 
 	m.tasks[id] = t
 	m.order = append(m.order, id)
+	m.mu.Unlock()
+
+	m.notify()
 	return t
 }
 
@@ -119,6 +146,14 @@ func (m *Manager) List() []*Task {
 // value is indistinguishable from "not provided". A pointer-based or sentinel
 // approach would be needed to support clearing.
 func (m *Manager) Update(id string, opts UpdateOpts) error {
+	if err := m.update(id, opts); err != nil {
+		return err
+	}
+	m.notify()
+	return nil
+}
+
+func (m *Manager) update(id string, opts UpdateOpts) error {
 	m.mu.RLock()
 	t, ok := m.tasks[id]
 	m.mu.RUnlock()
@@ -190,10 +225,9 @@ type UpdateOpts struct {
 // Delete removes a task.
 func (m *Manager) Delete(id string) bool {
 	m.mu.Lock()
-	defer m.mu.Unlock()
-
 	t, ok := m.tasks[id]
 	if !ok {
+		m.mu.Unlock()
 		return false
 	}
 
@@ -211,6 +245,9 @@ func (m *Manager) Delete(id string) bool {
 			break
 		}
 	}
+	m.mu.Unlock()
+
+	m.notify()
 	return true
 }
 
@@ -224,13 +261,14 @@ func (m *Manager) Stop(id string) error {
 	}
 
 	t.mu.Lock()
-	defer t.mu.Unlock()
-
 	if t.cancel != nil {
 		t.cancel()
 	}
 	t.Status = StatusStopped
 	t.UpdatedAt = time.Now()
+	t.mu.Unlock()
+
+	m.notify()
 	return nil
 }
 
@@ -247,6 +285,23 @@ func (m *Manager) SetCancel(id string, cancel context.CancelFunc) {
 	t.mu.Unlock()
 }
 
+// OnChange registers fn to be called after every task creation, update,
+// deletion or stop. Used to persist the task list and report it to clients.
+func (m *Manager) OnChange(fn func()) {
+	m.mu.Lock()
+	m.onChange = fn
+	m.mu.Unlock()
+}
+
+func (m *Manager) notify() {
+	m.mu.RLock()
+	fn := m.onChange
+	m.mu.RUnlock()
+	if fn != nil {
+		fn()
+	}
+}
+
 // FormatList returns a human-readable task list.
 func FormatList(tasks []*Task) string {
 	if len(tasks) == 0 {
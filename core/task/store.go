@@ -0,0 +1,109 @@
+package task
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Snapshot returns copies of all tasks in creation order, safe to serialize
+// while the originals keep changing.
+func (m *Manager) Snapshot() []*Task {
+	tasks := m.List()
+	out := make([]*Task, 0, len(tasks))
+	for _, t := range tasks {
+		t.mu.Lock()
+		c := &Task{
+			ID:          t.ID,
+			Subject:     t.Subject,
+			Description: t.Description,
+			ActiveForm:  t.ActiveForm,
+			Status:      t.Status,
+			Owner:       t.Owner,
+			Output:      t.Output,
+			Error:       t.Error,
+			CreatedAt:   t.CreatedAt,
+			UpdatedAt:   t.UpdatedAt,
+			Blocks:      append([]string(nil), t.Blocks...),
+			BlockedBy:   append([]string(nil), t.BlockedBy...),
+		}
+		if t.Metadata != nil {
+			c.Metadata = make(map[string]interface{}, len(t.Metadata))
+			for k, v := range t.Metadata {
+				c.Metadata[k] = v
+			}
+		}
+		t.mu.Unlock()
+		out = append(out, c)
+	}
+	return out
+}
+
+// Restore replaces the manager's tasks with previously saved ones, keeping
+// their IDs and dependency graph. New tasks are numbered after the highest
+// restored ID. Does not call the OnChange callback.
+func (m *Manager) Restore(tasks []*Task) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.tasks = make(map[string]*Task, len(tasks))
+	m.order = m.order[:0]
+	m.nextID = 0
+	for _, t := range tasks {
+		if t == nil || t.ID == "" {
+			continue
+		}
+		if _, dup := m.tasks[t.ID]; dup {
+			continue
+		}
+		m.tasks[t.ID] = t
+		m.order = append(m.order, t.ID)
+		if n, err := strconv.Atoi(strings.TrimPrefix(t.ID, "task-")); err == nil && n > m.nextID {
+			m.nextID = n
+		}
+	}
+}
+
+// FilePath returns where a session's task list is stored: next to its
+// transcript, as <dir>/<sessionID>.tasks.json.
+func FilePath(transcriptDir, sessionID string) string {
+	return filepath.Join(transcriptDir, sessionID+".tasks.json")
+}
+
+// LoadFile reads a task list file. A missing file yields no tasks.
+func LoadFile(path string) ([]*Task, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read tasks: %w", err)
+	}
+	var tasks []*Task
+	if err := json.Unmarshal(data, &tasks); err != nil {
+		return nil, fmt.Errorf("parse tasks: %w", err)
+	}
+	return tasks, nil
+}
+
+// SaveFile writes a task list file atomically.
+func SaveFile(path string, tasks []*Task) error {
+	if tasks == nil {
+		tasks = []*Task{}
+	}
+	data, err := json.MarshalIndent(tasks, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal tasks: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("create tasks dir: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("write tasks: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
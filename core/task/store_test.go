@@ -0,0 +1,67 @@
+package task
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestOnChange(t *testing.T) {
+	mgr := NewManager()
+	calls := 0
+	mgr.OnChange(func() { calls++ })
+
+	tsk := mgr.Create("Build", "", "", nil)
+	mgr.Update(tsk.ID, UpdateOpts{Status: StatusInProgress})
+	mgr.Stop(tsk.ID)
+	mgr.Delete(tsk.ID)
+	if calls != 4 {
+		t.Errorf("expected 4 change notifications, got %d", calls)
+	}
+
+	mgr.Update("task-99", UpdateOpts{Status: StatusCompleted})
+	mgr.Delete("task-99")
+	if calls != 4 {
+		t.Errorf("failed mutations should not notify, got %d calls", calls)
+	}
+}
+
+func TestSaveAndRestore(t *testing.T) {
+	mgr := NewManager()
+	a := mgr.Create("Write migration", "Add users.email", "Writing migration", map[string]interface{}{"pr": "42"})
+	b := mgr.Create("Backfill", "", "", nil)
+	mgr.Update(a.ID, UpdateOpts{Status: StatusCompleted, AddBlocks: []string{b.ID}})
+	mgr.Update(b.ID, UpdateOpts{AddBlockedBy: []string{a.ID}})
+
+	path := FilePath(t.TempDir(), "sess-1")
+	if err := SaveFile(path, mgr.Snapshot()); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	tasks, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	restored := NewManager()
+	restored.Restore(tasks)
+	list := restored.List()
+	if len(list) != 2 || list[0].ID != a.ID || list[1].ID != b.ID {
+		t.Fatalf("expected tasks restored in order, got %v", list)
+	}
+	if list[0].Status != StatusCompleted || list[0].Metadata["pr"] != "42" {
+		t.Errorf("task fields not restored: %+v", list[0])
+	}
+	if len(list[1].BlockedBy) != 1 || list[1].BlockedBy[0] != a.ID {
+		t.Errorf("dependency graph not restored: %v", list[1].BlockedBy)
+	}
+
+	if c := restored.Create("Deploy", "", "", nil); c.ID != "task-3" {
+		t.Errorf("expected new IDs to continue after restored ones, got %s", c.ID)
+	}
+}
+
+func TestLoadFileMissing(t *testing.T) {
+	tasks, err := LoadFile(filepath.Join(t.TempDir(), "none.tasks.json"))
+	if err != nil || tasks != nil {
+		t.Errorf("expected no tasks and no error, got %v, %v", tasks, err)
+	}
+}
@@ -51,6 +51,7 @@ func (w *limitWriter) Write(p []byte) (int, error) {
 // BashTool executes shell commands in the workspace directory.
 type BashTool struct {
 	workdir string
+	guard   NetworkGuard // Optional: routes commands through the egress proxy
 
 	// Background process tracking — these are killed on Cleanup().
 	bgMu        sync.Mutex
@@ -62,6 +63,12 @@ func NewBashTool(workdir string) *BashTool {
 	return &BashTool{workdir: workdir}
 }
 
+// NewBashToolWithGuard creates a Bash tool whose commands are held to the
+// guard's network egress rules.
+func NewBashToolWithGuard(workdir string, guard NetworkGuard) *BashTool {
+	return &BashTool{workdir: workdir, guard: guard}
+}
+
 // Cleanup sends SIGTERM to all tracked background processes.
 // Call this when the session is being torn down.
 func (t *BashTool) Cleanup() {
@@ -136,11 +143,18 @@ func (t *BashTool) Execute(ctx context.Context, input json.RawMessage) (*tool.Re
 
 	// Background execution: start command and return immediately
 	if in.RunInBackground {
+		// The proxy outlives this call, so it must not share the turn's context.
+		env, stopProxy, err := egressEnv(context.WithoutCancel(ctx), t.guard)
+		if err != nil {
+			return tool.ErrorResult(fmt.Sprintf("Failed to start network egress proxy: %v", err)), nil
+		}
 		cmd := exec.Command("bash", "-c", in.Command)
 		cmd.Dir = t.workdir
+		cmd.Env = env
 		cmd.Stdout = io.Discard // Prevent output leaking to server's stdout
 		cmd.Stderr = io.Discard
 		if err := cmd.Start(); err != nil {
+			stopProxy()
 			return tool.ErrorResult(fmt.Sprintf("Failed to start background command: %v", err)), nil
 		}
 		proc := cmd.Process
@@ -151,6 +165,7 @@ func (t *BashTool) Execute(ctx context.Context, input json.RawMessage) (*tool.Re
 		t.bgMu.Unlock()
 
 		go func() {
+			defer stopProxy()
 			if err := cmd.Wait(); err != nil {
 				log.Printf("bash: background command (PID %d) exited with error: %v", proc.Pid, err)
 			}
@@ -179,8 +194,15 @@ func (t *BashTool) Execute(ctx context.Context, input json.RawMessage) (*tool.Re
 	cmdCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
+	env, stopProxy, err := egressEnv(cmdCtx, t.guard)
+	if err != nil {
+		return tool.ErrorResult(fmt.Sprintf("Failed to start network egress proxy: %v", err)), nil
+	}
+	defer stopProxy()
+
 	cmd := exec.CommandContext(cmdCtx, "bash", "-c", in.Command)
 	cmd.Dir = t.workdir
+	cmd.Env = env // nil inherits the server's environment
 
 	// TODO(security): OS-level sandboxing for bash commands. macOS sandbox-exec
 	// (Seatbelt) is deprecated — need an alternative approach (e.g., App Sandbox
@@ -201,7 +223,7 @@ func (t *BashTool) Execute(ctx context.Context, input json.RawMessage) (*tool.Re
 	cmd.Stdout = stdout
 	cmd.Stderr = stderr
 
-	err := cmd.Run()
+	err = cmd.Run()
 
 	stdoutStr := stdout.buf.String()
 	stderrStr := stderr.buf.String()
@@ -3,6 +3,7 @@ package builtin
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
@@ -1183,3 +1184,50 @@ func TestWebFetchTool_CacheExpired(t *testing.T) {
 	// Should have tried to fetch (and failed), not returned cached content
 	assert.NotEqual(t, "old content", result.Content)
 }
+
+// --- Network egress guard tests ---
+
+// denyGuard restricts network access and denies every host.
+type denyGuard struct{ hosts []string }
+
+func (g *denyGuard) NetworkRestricted() bool { return true }
+
+func (g *denyGuard) CheckNetworkHost(_ context.Context, host string) error {
+	g.hosts = append(g.hosts, host)
+	return fmt.Errorf("network access to %s denied", host)
+}
+
+func TestWebFetchTool_GuardDeniesCachedHost(t *testing.T) {
+	guard := &denyGuard{}
+	wf := NewWebFetchToolWithGuard(guard)
+	wf.cache["https://docs.example.com/page"] = &cachedResponse{
+		content:   "cached content",
+		metadata:  map[string]interface{}{},
+		fetchedAt: time.Now(),
+	}
+
+	result, err := wf.Execute(context.Background(), json.RawMessage(`{"url":"https://docs.example.com/page"}`))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content, "network access to docs.example.com denied")
+	assert.Equal(t, []string{"docs.example.com"}, guard.hosts)
+}
+
+func TestWebSearchTool_GuardChecksAPIHost(t *testing.T) {
+	guard := &denyGuard{}
+	ws := NewWebSearchToolWithGuard("key", guard)
+
+	result, err := ws.Execute(context.Background(), json.RawMessage(`{"query":"golang"}`))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Equal(t, []string{webSearchAPIHost}, guard.hosts)
+}
+
+func TestBashTool_GuardSetsProxyEnv(t *testing.T) {
+	bash := NewBashToolWithGuard(t.TempDir(), &denyGuard{})
+	result, err := bash.Execute(context.Background(), json.RawMessage(`{"command":"echo $HTTPS_PROXY $NO_PROXY"}`))
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.Contains(t, result.Content, "http://127.0.0.1:")
+	assert.Contains(t, result.Content, "localhost")
+}
@@ -0,0 +1,34 @@
+package builtin
+
+import (
+	"context"
+	"os"
+
+	"github.com/chatml/chatml-core/egress"
+)
+
+// NetworkGuard enforces the session's network egress rules. WebFetch and
+// WebSearch check each host before connecting; Bash routes commands through
+// a filtering egress proxy while any egress rule is configured.
+type NetworkGuard interface {
+	// NetworkRestricted reports whether any egress rule applies. When false,
+	// tools skip the proxy entirely.
+	NetworkRestricted() bool
+	// CheckNetworkHost returns nil when host may be contacted. It may block
+	// while the user is asked to approve the host.
+	CheckNetworkHost(ctx context.Context, host string) error
+}
+
+// egressEnv starts an egress proxy for one child process when guard restricts
+// network access. It returns the environment for the process (nil to inherit
+// unchanged) and a function that stops the proxy once the process exits.
+func egressEnv(ctx context.Context, guard NetworkGuard) ([]string, func(), error) {
+	if guard == nil || !guard.NetworkRestricted() {
+		return nil, func() {}, nil
+	}
+	p, err := egress.Start(ctx, guard.CheckNetworkHost)
+	if err != nil {
+		return nil, nil, err
+	}
+	return append(os.Environ(), p.Env()...), func() { p.Close() }, nil
+}
@@ -16,6 +16,9 @@ type Callbacks struct {
 	WebSearchAPIKey  string                                    // Brave Search API key for WebSearch tool
 	WorkdirSetter    WorkdirSetter                             // For EnterWorktree/ExitWorktree
 
+	// Network enforces egress rules for Bash, WebFetch and WebSearch.
+	Network NetworkGuard
+
 	// ReadTrackerOut is populated after RegisterAllWithCallbacks returns.
 	// The runner uses it for post-compact context restoration.
 	ReadTrackerOut *tool.ReadTracker
@@ -38,8 +41,13 @@ func RegisterAllWithCallbacks(reg *tool.Registry, workdir string, cb *Callbacks)
 	// Shared read tracker: Read marks files as read, Edit/Write check before modifying.
 	tracker := tool.NewReadTracker()
 
+	var network NetworkGuard
+	if cb != nil {
+		network = cb.Network
+	}
+
 	// File/shell tools
-	reg.Register(NewBashTool(workdir))
+	reg.Register(NewBashToolWithGuard(workdir, network))
 	reg.Register(NewReadToolWithTracker(workdir, tracker))
 	reg.Register(NewWriteToolWithTracker(workdir, tracker))
 	reg.Register(NewEditToolWithTracker(workdir, tracker))
@@ -50,12 +58,12 @@ func RegisterAllWithCallbacks(reg *tool.Registry, workdir string, cb *Callbacks)
 	reg.Register(NewNotebookEditTool(workdir, tracker))
 
 	// Web tools
-	reg.Register(NewWebFetchTool())
+	reg.Register(NewWebFetchToolWithGuard(network))
 	var webSearchAPIKey string
 	if cb != nil {
 		webSearchAPIKey = cb.WebSearchAPIKey
 	}
-	reg.Register(NewWebSearchTool(webSearchAPIKey))
+	reg.Register(NewWebSearchToolWithGuard(webSearchAPIKey, network))
 
 	// Interactive tools (require callbacks)
 	var emitFn func(string, interface{})
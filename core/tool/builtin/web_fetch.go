@@ -38,6 +38,7 @@ type cachedResponse struct {
 type WebFetchTool struct {
 	mu    sync.RWMutex
 	cache map[string]*cachedResponse
+	guard NetworkGuard // Optional: checks each host, redirects included
 }
 
 func NewWebFetchTool() *WebFetchTool {
@@ -46,6 +47,26 @@ func NewWebFetchTool() *WebFetchTool {
 	}
 }
 
+// NewWebFetchToolWithGuard creates a WebFetch tool held to the guard's network
+// egress rules.
+func NewWebFetchToolWithGuard(guard NetworkGuard) *WebFetchTool {
+	t := NewWebFetchTool()
+	t.guard = guard
+	return t
+}
+
+// checkHost applies the egress rules to the host of rawURL.
+func (t *WebFetchTool) checkHost(ctx context.Context, rawURL string) error {
+	if t.guard == nil {
+		return nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" {
+		return nil // Rejected later as an invalid URL
+	}
+	return t.guard.CheckNetworkHost(ctx, u.Hostname())
+}
+
 func (t *WebFetchTool) Name() string { return "WebFetch" }
 
 func (t *WebFetchTool) Description() string {
@@ -86,6 +107,12 @@ func (t *WebFetchTool) Execute(ctx context.Context, input json.RawMessage) (*too
 		return tool.ErrorResult("url is required"), nil
 	}
 
+	// Egress rules apply to cached responses too: a host denied since the
+	// response was cached must not be served.
+	if err := t.checkHost(ctx, in.URL); err != nil {
+		return tool.ErrorResult(err.Error()), nil
+	}
+
 	// Check cache first
 	t.mu.RLock()
 	if cached, ok := t.cache[in.URL]; ok && time.Since(cached.fetchedAt) < webFetchCacheTTL {
@@ -160,6 +187,9 @@ func (t *WebFetchTool) Execute(ctx context.Context, input json.RawMessage) (*too
 			if req.URL.Scheme == "http" {
 				return fmt.Errorf("redirect to plaintext HTTP rejected (HTTPS downgrade)")
 			}
+			if err := t.checkHost(req.Context(), req.URL.String()); err != nil {
+				return err
+			}
 			redirectCount++
 			finalURL = req.URL.String()
 			return nil
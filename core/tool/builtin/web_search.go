@@ -13,9 +13,13 @@ import (
 	"github.com/chatml/chatml-core/tool"
 )
 
+// webSearchAPIHost is the Brave Search API host, checked against egress rules.
+const webSearchAPIHost = "api.search.brave.com"
+
 // WebSearchTool performs web searches via the Brave Search API.
 type WebSearchTool struct {
 	apiKey string
+	guard  NetworkGuard // Optional: checks the search API host
 }
 
 // NewWebSearchTool creates a new WebSearchTool with the given Brave Search API key.
@@ -23,6 +27,12 @@ func NewWebSearchTool(apiKey string) *WebSearchTool {
 	return &WebSearchTool{apiKey: apiKey}
 }
 
+// NewWebSearchToolWithGuard creates a WebSearchTool held to the guard's
+// network egress rules.
+func NewWebSearchToolWithGuard(apiKey string, guard NetworkGuard) *WebSearchTool {
+	return &WebSearchTool{apiKey: apiKey, guard: guard}
+}
+
 func (t *WebSearchTool) Name() string { return "WebSearch" }
 
 func (t *WebSearchTool) Description() string {
@@ -94,8 +104,14 @@ func (t *WebSearchTool) Execute(ctx context.Context, input json.RawMessage) (*to
 		query += " -site:" + domain
 	}
 
+	if t.guard != nil {
+		if err := t.guard.CheckNetworkHost(ctx, webSearchAPIHost); err != nil {
+			return tool.ErrorResult(err.Error()), nil
+		}
+	}
+
 	// Build URL
-	searchURL := fmt.Sprintf("https://api.search.brave.com/res/v1/web/search?q=%s&count=5", url.QueryEscape(query))
+	searchURL := fmt.Sprintf("https://%s/res/v1/web/search?q=%s&count=5", webSearchAPIHost, url.QueryEscape(query))
 
 	// Create HTTP request with timeout
 	httpCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
@@ -24,3 +24,26 @@ type Message struct {
 	Role    string `json:"role"`
 	Content string `json:"content"`
 }
+
+// ContextBreakdown attributes estimated context window usage to categories
+// (system prompt, tool definitions, messages, tool results, attachments).
+type ContextBreakdown struct {
+	ContextWindow   int               `json:"contextWindow"`
+	EstimatedTokens int               `json:"estimatedTokens"`          // Sum of all categories
+	ReportedTokens  int               `json:"reportedTokens,omitempty"` // Last count reported by the API
+	Categories      []ContextCategory `json:"categories"`
+}
+
+// ContextCategory is one slice of the context window, itemized where possible
+// (system prompt sections, MCP servers, tools whose results take up space).
+type ContextCategory struct {
+	Name   string        `json:"name"`
+	Tokens int           `json:"tokens"`
+	Items  []ContextItem `json:"items,omitempty"`
+}
+
+// ContextItem is a named contributor within a ContextCategory.
+type ContextItem struct {
+	Name   string `json:"name"`
+	Tokens int    `json:"tokens"`
+}
@@ -25,6 +25,7 @@ import { WorkspacePicker } from './WorkspacePicker';
 import type { LinearIssueDTO } from '@/lib/api';
 import { PlateInput, type PlateInputHandle } from './PlateInput';
 import { type ModelEntry, buildStaticModelList } from '@/lib/models';
+import { useLocalModels } from '@/hooks/useLocalModels';
 import type { MentionItem } from '@/components/ui/mention-node';
 import { trackEvent } from '@/lib/telemetry';
 import { playSound } from '@/lib/sounds';
@@ -66,7 +67,8 @@ export function ChatInput({ onMessageSubmit }: ChatInputProps) {
 
   // Dynamic model list from SDK, with static fallback
   const dynamicModels = useAppStore((s) => s.supportedModels);
-  const MODELS = useMemo(() => buildStaticModelList(dynamicModels).map((m) => ({ ...m, icon: Sparkles })), [dynamicModels]);
+  const localModels = useLocalModels();
+  const MODELS = useMemo(() => buildStaticModelList(dynamicModels, localModels).map((m) => ({ ...m, icon: Sparkles })), [dynamicModels, localModels]);
 
   const [selectedModel, setSelectedModel] = useState<ModelEntry>(
     () => MODELS.find((m) => m.id === defaultModel) ?? MODELS[0]
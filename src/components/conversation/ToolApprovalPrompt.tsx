@@ -26,6 +26,7 @@ function ToolIcon({ toolName, className }: { toolName: string; className?: strin
     case 'NotebookEdit':
       return <Pencil className={className} />;
     case 'WebFetch':
+    case 'Network':
       return <Globe className={className} />;
     case 'WebSearch':
       return <Search className={className} />;
@@ -46,6 +47,8 @@ function getToolLabel(toolName: string): string {
       return 'Edit file';
     case 'WebFetch':
       return 'Fetch URL';
+    case 'Network':
+      return 'Network access';
     case 'WebSearch':
       return 'Search web';
     default:
@@ -64,6 +67,8 @@ function getActionVerb(toolName: string): string {
       return 'Edit';
     case 'WebFetch':
       return 'Fetch';
+    case 'Network':
+      return 'Connect to';
     case 'WebSearch':
       return 'Search';
     default:
@@ -89,6 +94,8 @@ function getTargetName(toolName: string, toolInput: Record<string, unknown>): st
     }
     case 'WebSearch':
       return (toolInput.query as string) || 'web search';
+    case 'Network':
+      return (toolInput.host as string) || 'host';
     default:
       return toolName;
   }
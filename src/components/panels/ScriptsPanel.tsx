@@ -13,8 +13,9 @@ import {
   runScript,
   rerunSetupScripts,
   stopScript,
+  getScriptServices,
 } from '@/lib/api';
-import type { ChatMLConfig, ScriptRun, SetupProgress, ScriptRunStatus } from '@/lib/types';
+import type { ChatMLConfig, ScriptRun, ScriptService, SetupProgress, ScriptRunStatus } from '@/lib/types';
 import { cn } from '@/lib/utils';
 import {
   Play,
@@ -33,6 +34,8 @@ export function ScriptsPanel() {
   const { selectedSessionId, selectedWorkspaceId } = useSelectedIds();
   const scriptRuns = useAppStore((s) => s.scriptRuns);
   const setupProgress = useAppStore((s) => s.setupProgress);
+  const scriptServices = useAppStore((s) => s.scriptServices);
+  const setScriptServices = useAppStore((s) => s.setScriptServices);
   const [config, setConfig] = useState<ChatMLConfig | null>(null);
   const [loading, setLoading] = useState(false);
   const [error, setError] = useState<string | null>(null);
@@ -40,6 +43,7 @@ export function ScriptsPanel() {
 
   const sessionRuns = selectedSessionId ? scriptRuns[selectedSessionId] || [] : [];
   const sessionSetupProgress = selectedSessionId ? setupProgress[selectedSessionId] : undefined;
+  const sessionServices = selectedSessionId ? scriptServices[selectedSessionId] || [] : [];
 
   // Load config when workspace changes
   useEffect(() => {
@@ -52,6 +56,14 @@ export function ScriptsPanel() {
       .finally(() => setLoading(false));
   }, [selectedWorkspaceId]);
 
+  // Load running services when session changes; updates then arrive over WebSocket
+  useEffect(() => {
+    if (!selectedWorkspaceId || !selectedSessionId) return;
+    getScriptServices(selectedWorkspaceId, selectedSessionId)
+      .then((services) => setScriptServices(selectedSessionId, services))
+      .catch(() => {});
+  }, [selectedWorkspaceId, selectedSessionId, setScriptServices]);
+
   const handleDetect = useCallback(async () => {
     if (!selectedWorkspaceId) return;
     setLoading(true);
@@ -263,7 +275,7 @@ export function ScriptsPanel() {
                 return (
                   <ScriptRunItem
                     key={key}
-                    name={script.name}
+                    name={script.service ? `${script.name} (service)` : script.name}
                     command={script.command}
                     run={run}
                     onRun={() => handleRunScript(key)}
@@ -274,11 +286,59 @@ export function ScriptsPanel() {
             </div>
           </div>
         )}
+
+        {/* Services Section */}
+        {sessionServices.length > 0 && (
+          <div>
+            <span className="text-xs font-medium text-muted-foreground uppercase tracking-wide block mb-1.5">
+              Services
+            </span>
+            <div className="space-y-1">
+              {sessionServices.map((service) => (
+                <ServiceItem key={service.key} service={service} />
+              ))}
+            </div>
+          </div>
+        )}
       </div>
     </ScrollArea>
   );
 }
 
+const SERVICE_STATUS_CLASS: Record<ScriptService['status'], string> = {
+  pending: 'text-muted-foreground',
+  starting: 'text-text-info',
+  ready: 'text-text-success',
+  restarting: 'text-text-warning',
+  failed: 'text-text-error',
+  stopped: 'text-muted-foreground',
+};
+
+function ServiceItem({ service }: { service: ScriptService }) {
+  const ports = Object.entries(service.ports ?? {});
+  return (
+    <div className="rounded-sm border border-border/50 px-2 py-1.5 space-y-0.5">
+      <div className="flex items-center gap-2">
+        <span className="text-xs font-medium flex-1 truncate">{service.name}</span>
+        {service.restarts > 0 && (
+          <span className="text-2xs text-muted-foreground tabular-nums">
+            {service.restarts} restart{service.restarts === 1 ? '' : 's'}
+          </span>
+        )}
+        <span className={cn('text-2xs', SERVICE_STATUS_CLASS[service.status])}>{service.status}</span>
+      </div>
+      {ports.length > 0 && (
+        <div className="text-2xs text-muted-foreground font-mono truncate">
+          {ports.map(([name, port]) => `${name}=${port}`).join(' ')}
+        </div>
+      )}
+      {service.error && (
+        <div className="text-2xs text-text-error truncate" title={service.error}>{service.error}</div>
+      )}
+    </div>
+  );
+}
+
 function StatusIcon({ status }: { status?: ScriptRunStatus }) {
   switch (status) {
     case 'running':
@@ -17,8 +17,10 @@ import { Eye, EyeOff } from 'lucide-react';
 import { useSettingsStore, SETTINGS_DEFAULTS } from '@/stores/settingsStore';
 import { useAppStore } from '@/stores/appStore';
 import { buildStaticModelList, isLocalModel } from '@/lib/models';
+import { useLocalModels, resetLocalModels } from '@/hooks/useLocalModels';
+import { SHOW_UNRELEASED } from '@/lib/constants';
 import type { ThinkingLevel } from '@/lib/thinkingLevels';
-import { getAnthropicApiKey, setAnthropicApiKey } from '@/lib/api';
+import { getAnthropicApiKey, setAnthropicApiKey, getOllamaRemote, setOllamaRemote, type OllamaRemoteStatus } from '@/lib/api';
 import { useToast } from '@/components/ui/toast';
 import { SettingsRow } from '../shared/SettingsRow';
 import { SettingsGroup } from '../shared/SettingsGroup';
@@ -40,7 +42,8 @@ export function AIModelSettings() {
   const setMaxThinkingTokens = useSettingsStore((s) => s.setMaxThinkingTokens);
 
   const dynamicModels = useAppStore((s) => s.supportedModels);
-  const modelOptions = useMemo(() => buildStaticModelList(dynamicModels), [dynamicModels]);
+  const installedLocalModels = useLocalModels();
+  const modelOptions = useMemo(() => buildStaticModelList(dynamicModels, installedLocalModels), [dynamicModels, installedLocalModels]);
 
   const cloudModels = useMemo(() => modelOptions.filter((m) => !isLocalModel(m.id)), [modelOptions]);
   const localModels = useMemo(() => modelOptions.filter((m) => isLocalModel(m.id)), [modelOptions]);
@@ -177,6 +180,12 @@ export function AIModelSettings() {
       <SettingsGroup label="Authentication">
         <ApiKeySection />
       </SettingsGroup>
+
+      {SHOW_UNRELEASED && (
+        <SettingsGroup label="Local models">
+          <OllamaServerSection />
+        </SettingsGroup>
+      )}
     </div>
   );
 }
@@ -311,3 +320,97 @@ function ApiKeySection() {
     </SettingsRow>
   );
 }
+
+function OllamaServerSection() {
+  const [status, setStatus] = useState<OllamaRemoteStatus | null>(null);
+  const [endpointInput, setEndpointInput] = useState('');
+  const [tokenInput, setTokenInput] = useState('');
+  const [saving, setSaving] = useState(false);
+  const toasts = useToast();
+
+  useEffect(() => {
+    getOllamaRemote().then((data) => {
+      setStatus(data);
+      setEndpointInput(data.endpoint ?? '');
+    }).catch(() => {
+      // ignore -- settings page should still render
+    });
+  }, []);
+
+  const save = async (endpoint: string) => {
+    setSaving(true);
+    try {
+      const result = await setOllamaRemote(endpoint.trim(), tokenInput ? tokenInput : undefined);
+      setStatus(result);
+      setEndpointInput(result.endpoint ?? '');
+      setTokenInput('');
+      resetLocalModels();
+      if (!result.enabled) {
+        toasts.success('Local models will use the bundled Ollama runtime.', 'Remote server removed');
+      } else if (result.reachable) {
+        toasts.success(`Connected to Ollama ${result.version ?? ''}`.trim(), 'Remote server saved');
+      } else {
+        toasts.error(result.error ?? 'Server is unreachable', 'Remote server saved');
+      }
+    } catch (err) {
+      toasts.error(err instanceof Error ? err.message : 'Failed to save Ollama server');
+    } finally {
+      setSaving(false);
+    }
+  };
+
+  return (
+    <SettingsRow
+      settingId="ollamaRemoteEndpoint"
+      variant="stacked"
+      title="Ollama server"
+      description="Use an existing Ollama server, such as a shared GPU machine, instead of the bundled runtime. Leave empty to use the bundled runtime."
+    >
+      {status?.enabled && (
+        <p className="text-xs text-muted-foreground mb-2">
+          {status.reachable
+            ? `Connected${status.version ? ` (Ollama ${status.version})` : ''}`
+            : status.error ?? 'Unreachable'}
+        </p>
+      )}
+
+      <div className="flex flex-col gap-2 max-w-md">
+        <input
+          type="text"
+          value={endpointInput}
+          onChange={(e) => setEndpointInput(e.target.value)}
+          placeholder="http://gpu-box.local:11434"
+          aria-label="Ollama server URL"
+          className="w-full px-3 py-1.5 text-sm bg-background border border-border rounded-md focus:outline-none focus:ring-1 focus:ring-primary"
+        />
+        <input
+          type="password"
+          value={tokenInput}
+          onChange={(e) => setTokenInput(e.target.value)}
+          placeholder={status?.hasToken ? 'Enter new token to replace' : 'Bearer token (optional)'}
+          aria-label="Ollama bearer token"
+          className="w-full px-3 py-1.5 text-sm bg-background border border-border rounded-md focus:outline-none focus:ring-1 focus:ring-primary"
+        />
+        <div className="flex items-center gap-2">
+          <Button
+            size="sm"
+            disabled={!endpointInput.trim() || saving}
+            onClick={() => save(endpointInput)}
+          >
+            {saving ? 'Saving...' : 'Save'}
+          </Button>
+          {status?.enabled && (
+            <Button
+              size="sm"
+              variant="outline"
+              disabled={saving}
+              onClick={() => save('')}
+            >
+              Use bundled runtime
+            </Button>
+          )}
+        </div>
+      </div>
+    </SettingsRow>
+  );
+}
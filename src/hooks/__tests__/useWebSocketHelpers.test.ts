@@ -4,6 +4,7 @@ import {
   isAuthErrorMessage,
   isAgentEvent,
   isAgentTodoItemArray,
+  isAgentTaskItemArray,
   isValidConversationStatus,
   isModelUsageRecord,
   isUserQuestionArray,
@@ -159,6 +160,24 @@ describe('lib hooks/useWebSocketHelpers', () => {
     });
   });
 
+  describe('isAgentTaskItemArray', () => {
+    it('accepts valid array of tasks', () => {
+      expect(isAgentTaskItemArray([
+        { id: 'task-1', subject: 'Write migration', status: 'completed', blocks: ['task-2'] },
+        { id: 'task-2', subject: 'Backfill', status: 'pending', blockedBy: ['task-1'] },
+      ])).toBe(true);
+      expect(isAgentTaskItemArray([])).toBe(true);
+    });
+
+    it('rejects array with invalid status', () => {
+      expect(isAgentTaskItemArray([{ id: 'task-1', subject: 'x', status: 'done' }])).toBe(false);
+    });
+
+    it('rejects array missing required fields', () => {
+      expect(isAgentTaskItemArray([{ subject: 'x', status: 'pending' }])).toBe(false);
+    });
+  });
+
   describe('isValidConversationStatus', () => {
     it.each([
       ['active', true],
@@ -0,0 +1,47 @@
+import { useEffect, useState } from 'react';
+import { listOllamaModels, type OllamaModel } from '@/lib/api/ollama';
+import { SHOW_UNRELEASED } from '@/lib/constants';
+
+// Module-level cache: discovery probes every installed model, so fetch once
+// per session and share the result across model pickers.
+let cachedModels: OllamaModel[] | null = null;
+let inflight: Promise<OllamaModel[]> | null = null;
+
+function loadLocalModels(): Promise<OllamaModel[]> {
+  if (!inflight) {
+    inflight = listOllamaModels()
+      .catch(() => [] as OllamaModel[])
+      .then((models) => {
+        cachedModels = models;
+        return models;
+      });
+  }
+  return inflight;
+}
+
+/** Drop the cached list, e.g. after switching Ollama servers. */
+export function resetLocalModels(): void {
+  cachedModels = null;
+  inflight = null;
+}
+
+/**
+ * Installed Ollama models with probed capabilities, for model pickers.
+ * Empty while local models are unreleased or Ollama has never run.
+ */
+export function useLocalModels(): OllamaModel[] {
+  const [models, setModels] = useState<OllamaModel[]>(() => cachedModels ?? []);
+
+  useEffect(() => {
+    if (!SHOW_UNRELEASED || cachedModels) return;
+    let cancelled = false;
+    loadLocalModels().then((m) => {
+      if (!cancelled) setModels(m);
+    });
+    return () => {
+      cancelled = true;
+    };
+  }, []);
+
+  return models;
+}
@@ -28,7 +28,7 @@ import { unregisterSession, getSessionDirName } from '@/lib/tauri';
 import { markPlanModeExited, isInPlanModeExitCooldown, clearPlanModeState } from '@/hooks/useWebSocketPlanMode';
 import { startReconciling, stopReconciling, isReconciling, clearReconciliationState } from '@/hooks/useWebSocketReconciliation';
 import {
-  normalizeUsage, isAuthErrorMessage, isAgentEvent, isAgentTodoItemArray,
+  normalizeUsage, isAuthErrorMessage, isAgentEvent, isAgentTodoItemArray, isAgentTaskItemArray,
   isValidConversationStatus, isModelUsageRecord, isUserQuestionArray,
   isMcpServerStatusArray, getWsUrl, mapStatus, notifyBackgroundSession,
   BATCHABLE_EVENTS, RECONCILIATION_SUPPRESSED_EVENTS,
@@ -355,6 +355,16 @@ export function useWebSocket(enabled: boolean = true) {
         }
         break;
 
+      case 'task_list_update': {
+        // The full list is sent on every change; an omitted list means all
+        // tasks were deleted.
+        const tasks = event?.tasks ?? [];
+        if (isAgentTaskItemArray(tasks)) {
+          store.setAgentTasks(conversationId, tasks);
+        }
+        break;
+      }
+
       case 'name_suggestion':
         if (event?.name && !useSettingsStore.getState().strictPrivacy) {
           store.updateConversation(conversationId, { name: event.name });
@@ -1557,6 +1567,15 @@ export function useWebSocket(enabled: boolean = true) {
           return;
         }
 
+        // Handle service status events
+        if (data.type === 'service_status' && data.sessionId) {
+          const service = data.payload as import('@/lib/types').ScriptService | undefined;
+          if (service?.key) {
+            getStore().upsertScriptService(data.sessionId, service);
+          }
+          return;
+        }
+
         // Handle setup progress events
         if (data.type === 'setup_progress' && data.sessionId) {
           const payload = data.payload as import('@/lib/types').SetupProgress | undefined;
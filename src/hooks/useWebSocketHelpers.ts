@@ -1,4 +1,4 @@
-import type { AgentEvent, AgentTodoItem, UserQuestion, TokenUsage, ModelUsageInfo, McpServerStatus } from '@/lib/types';
+import type { AgentEvent, AgentTodoItem, AgentTaskItem, UserQuestion, TokenUsage, ModelUsageInfo, McpServerStatus } from '@/lib/types';
 import { useAppStore } from '@/stores/appStore';
 import { useSettingsStore } from '@/stores/settingsStore';
 import { getBackendPortSync } from '@/lib/backend-port';
@@ -72,6 +72,25 @@ export function isAgentTodoItemArray(payload: unknown): payload is AgentTodoItem
   return Array.isArray(payload) && payload.every(isAgentTodoItem);
 }
 
+const VALID_TASK_STATUSES = ['pending', 'in_progress', 'completed', 'failed', 'stopped'];
+
+export function isAgentTaskItem(item: unknown): item is AgentTaskItem {
+  if (typeof item !== 'object' || item === null) {
+    return false;
+  }
+  const obj = item as Record<string, unknown>;
+  return (
+    typeof obj.id === 'string' &&
+    typeof obj.subject === 'string' &&
+    typeof obj.status === 'string' &&
+    VALID_TASK_STATUSES.includes(obj.status)
+  );
+}
+
+export function isAgentTaskItemArray(payload: unknown): payload is AgentTaskItem[] {
+  return Array.isArray(payload) && payload.every(isAgentTaskItem);
+}
+
 const VALID_CONVERSATION_STATUSES = ['active', 'idle', 'completed'] as const;
 type ConversationStatus = typeof VALID_CONVERSATION_STATUSES[number];
 
@@ -121,7 +140,7 @@ export const BATCHABLE_EVENTS = new Set([
   'assistant_text', 'thinking_delta', 'thinking_start', 'thinking',
   // Low-frequency informational events that don't depend on streaming text state
   'name_suggestion', 'input_suggestion', 'context_usage', 'context_window_size',
-  'todo_update', 'tool_progress', 'agent_notification', 'warning',
+  'todo_update', 'task_list_update', 'tool_progress', 'agent_notification', 'warning',
   'hook_pre_tool', 'hook_post_tool', 'hook_response', 'hook_tool_failure',
   'session_started', 'session_ended', 'session_id_update',
   'auth_status', 'status_update', 'agent_stderr', 'json_parse_error',
@@ -22,6 +22,10 @@ describe('getModelDisplayName', () => {
     expect(getModelDisplayName('unknown-model')).toBe('unknown-model');
   });
 
+  it('shows discovered local models by their Ollama tag', () => {
+    expect(getModelDisplayName('ollama/llama3:8b')).toBe('llama3:8b');
+  });
+
   // Bedrock ARN display name tests
   it('handles Bedrock inference profile ARN', () => {
     const arn = 'arn:aws:bedrock:us-east-1:451348473281:application-inference-profile/6atmd50rvy0c';
@@ -261,6 +265,27 @@ describe('buildTurnConfigLabel', () => {
 });
 
 describe('buildStaticModelList', () => {
+  it('appends discovered local models outside the catalog', () => {
+    const local = [
+      {
+        id: 'ollama/qwen2.5-coder:7b', name: 'qwen2.5-coder:7b', digest: 'a', size: 1, modified_at: '',
+        family: 'qwen2', parameter_size: '7.6B', context_length: 32768,
+        supports_tools: true, supports_vision: false, supports_thinking: true, catalog: false,
+      },
+      {
+        id: 'gemma-4-27b', name: 'gemma4:27b', digest: 'b', size: 1, modified_at: '',
+        context_length: 262144, supports_tools: true, supports_vision: true, supports_thinking: false, catalog: true,
+      },
+    ];
+    const result = buildStaticModelList([], local);
+    expect(result).toHaveLength(MODELS.length + 1);
+    const qwen = result[result.length - 1];
+    expect(qwen.id).toBe('ollama/qwen2.5-coder:7b');
+    expect(qwen.provider).toBe('ollama');
+    expect(qwen.supportsThinking).toBe(true);
+    expect(qwen.description).toBe('qwen2 7.6B \u00b7 32K context');
+  });
+
   it('returns static entries with defaults when SDK models is empty', () => {
     const result = buildStaticModelList([]);
     expect(result).toHaveLength(MODELS.length);
@@ -22,6 +22,12 @@ import {
   deleteConversation,
   setConversationFastMode,
   setConversationMaxThinkingTokens,
+  compactConversation,
+  getConversationContext,
+  getWorkingMemory,
+  saveWorkingMemory,
+  getConversationTasks,
+  forkConversation,
   approveTool,
   approveBatchTools,
   answerQAHandoff,
@@ -479,6 +485,127 @@ describe('lib/api/conversations', () => {
     });
   });
 
+  describe('compactConversation', () => {
+    it('POSTs instructions and returns the report', async () => {
+      let capturedBody: unknown;
+      server.use(
+        http.post(`${API_BASE}/api/conversations/:convId/compact`, async ({ request }) => {
+          capturedBody = await request.json();
+          return HttpResponse.json({ trigger: 'manual', preTokens: 90000, postTokens: 12000, summary: 'S' });
+        })
+      );
+
+      const report = await compactConversation('conv-1', 'keep the DB migration details');
+      expect(capturedBody).toEqual({ instructions: 'keep the DB migration details' });
+      expect(report).toEqual({ trigger: 'manual', preTokens: 90000, postTokens: 12000, summary: 'S' });
+    });
+
+    it('returns null when compaction runs asynchronously', async () => {
+      server.use(
+        http.post(`${API_BASE}/api/conversations/:convId/compact`, () =>
+          HttpResponse.json({ status: 'compacting' }, { status: 202 })
+        )
+      );
+
+      expect(await compactConversation('conv-1')).toBeNull();
+    });
+
+    it('throws ApiError on conflict', async () => {
+      server.use(
+        http.post(`${API_BASE}/api/conversations/:convId/compact`, () =>
+          HttpResponse.json({ error: 'conversation is not running' }, { status: 409 })
+        )
+      );
+
+      await expect(compactConversation('conv-1')).rejects.toBeInstanceOf(ApiError);
+    });
+  });
+
+  describe('getConversationContext', () => {
+    it('GETs the context breakdown', async () => {
+      const breakdown = {
+        contextWindow: 200000,
+        estimatedTokens: 42000,
+        categories: [{ name: 'tools', tokens: 12000, items: [{ name: 'mcp:github', tokens: 9000 }] }],
+      };
+      server.use(
+        http.get(`${API_BASE}/api/conversations/:convId/context`, () => HttpResponse.json(breakdown))
+      );
+
+      expect(await getConversationContext('conv-1')).toEqual(breakdown);
+    });
+  });
+
+  describe('working memory', () => {
+    it('GETs the working memory', async () => {
+      server.use(
+        http.get(`${API_BASE}/api/conversations/:convId/working-memory`, () =>
+          HttpResponse.json({ goal: 'Ship v2', decisions: ['Keep the v1 API'] })
+        )
+      );
+
+      expect(await getWorkingMemory('conv-1')).toEqual({ goal: 'Ship v2', decisions: ['Keep the v1 API'] });
+    });
+
+    it('PUTs the edited memory', async () => {
+      let capturedBody: unknown;
+      server.use(
+        http.put(`${API_BASE}/api/conversations/:convId/working-memory`, async ({ request }) => {
+          capturedBody = await request.json();
+          return HttpResponse.json({ ...(capturedBody as object), updatedAt: '2026-10-19T00:00:00Z' });
+        })
+      );
+
+      const saved = await saveWorkingMemory('conv-1', { goal: 'Ship v2', openQuestions: [] });
+      expect(capturedBody).toEqual({ goal: 'Ship v2', openQuestions: [] });
+      expect(saved.updatedAt).toBe('2026-10-19T00:00:00Z');
+    });
+  });
+
+  describe('getConversationTasks', () => {
+    it('GETs the stored task list', async () => {
+      const tasks = [
+        { id: 'task-1', subject: 'Write migration', status: 'completed', blocks: ['task-2'], blockedBy: [], updatedAt: '2026-10-19T00:00:00Z' },
+        { id: 'task-2', subject: 'Backfill', status: 'pending', blocks: [], blockedBy: ['task-1'], updatedAt: '2026-10-19T00:00:00Z' },
+      ];
+      server.use(
+        http.get(`${API_BASE}/api/conversations/:convId/tasks`, ({ params }) => {
+          expect(params.convId).toBe('conv-1');
+          return HttpResponse.json(tasks);
+        })
+      );
+
+      expect(await getConversationTasks('conv-1')).toEqual(tasks);
+    });
+  });
+
+  describe('forkConversation', () => {
+    it('POSTs the fork point and returns the new conversation', async () => {
+      let capturedBody: unknown;
+      server.use(
+        http.post(`${API_BASE}/api/conversations/:convId/fork`, async ({ params, request }) => {
+          expect(params.convId).toBe('conv-1');
+          capturedBody = await request.json();
+          return HttpResponse.json({ ...mockConversation, id: 'conv-2', name: 'Fork' }, { status: 201 });
+        })
+      );
+
+      const fork = await forkConversation('conv-1', { messageId: 'msg-1', sessionId: 'sess-2' });
+      expect(capturedBody).toEqual({ messageId: 'msg-1', sessionId: 'sess-2' });
+      expect(fork.id).toBe('conv-2');
+    });
+
+    it('throws ApiError when the conversation has no transcript', async () => {
+      server.use(
+        http.post(`${API_BASE}/api/conversations/:convId/fork`, () =>
+          HttpResponse.json({ error: 'conversation has no agent transcript to fork' }, { status: 409 })
+        )
+      );
+
+      await expect(forkConversation('conv-1')).rejects.toBeInstanceOf(ApiError);
+    });
+  });
+
   describe('approveTool', () => {
     it('POSTs requestId + action', async () => {
       let capturedBody: unknown;
@@ -0,0 +1,82 @@
+import { describe, it, expect } from 'vitest';
+import { http, HttpResponse } from 'msw';
+import { server } from '@/__mocks__/server';
+import { listOllamaModels, getOllamaRemote, setOllamaRemote, type OllamaModel } from '../ollama';
+
+const API_BASE = 'http://localhost:9876';
+
+const qwen: OllamaModel = {
+  id: 'ollama/qwen2.5-coder:7b',
+  name: 'qwen2.5-coder:7b',
+  digest: 'abc',
+  size: 4_700_000_000,
+  modified_at: '2026-03-01T12:00:00Z',
+  family: 'qwen2',
+  parameter_size: '7.6B',
+  context_length: 32768,
+  supports_tools: true,
+  supports_vision: false,
+  supports_thinking: false,
+  catalog: false,
+};
+
+describe('lib/api/ollama', () => {
+  describe('listOllamaModels', () => {
+    it('returns discovered models with capabilities', async () => {
+      server.use(http.get(`${API_BASE}/api/ollama/models`, () => HttpResponse.json([qwen])));
+
+      const models = await listOllamaModels();
+      expect(models).toEqual([qwen]);
+    });
+
+    it('throws on server error', async () => {
+      server.use(
+        http.get(`${API_BASE}/api/ollama/models`, () =>
+          HttpResponse.json({ error: 'Failed to list models' }, { status: 500 })
+        )
+      );
+
+      await expect(listOllamaModels()).rejects.toThrow();
+    });
+  });
+
+  describe('ollama remote', () => {
+    it('returns the remote server status', async () => {
+      server.use(
+        http.get(`${API_BASE}/api/ollama/remote`, () =>
+          HttpResponse.json({ enabled: true, endpoint: 'http://gpu-box:11434', hasToken: true, reachable: true, version: '0.14.1' })
+        )
+      );
+
+      const status = await getOllamaRemote();
+      expect(status.endpoint).toBe('http://gpu-box:11434');
+      expect(status.reachable).toBe(true);
+    });
+
+    it('omits the token to keep the stored one', async () => {
+      let body: unknown;
+      server.use(
+        http.put(`${API_BASE}/api/ollama/remote`, async ({ request }) => {
+          body = await request.json();
+          return HttpResponse.json({ enabled: true, endpoint: 'http://gpu-box:11434', hasToken: true, reachable: true });
+        })
+      );
+
+      await setOllamaRemote('http://gpu-box:11434');
+      expect(body).toEqual({ endpoint: 'http://gpu-box:11434' });
+
+      await setOllamaRemote('http://gpu-box:11434', 'secret');
+      expect(body).toEqual({ endpoint: 'http://gpu-box:11434', token: 'secret' });
+    });
+
+    it('throws on an invalid endpoint', async () => {
+      server.use(
+        http.put(`${API_BASE}/api/ollama/remote`, () =>
+          HttpResponse.json({ error: 'endpoint must use http or https' }, { status: 400 })
+        )
+      );
+
+      await expect(setOllamaRemote('ftp://gpu-box')).rejects.toThrow();
+    });
+  });
+});
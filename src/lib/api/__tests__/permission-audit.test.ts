@@ -0,0 +1,66 @@
+import { describe, it, expect } from 'vitest';
+import { http, HttpResponse } from 'msw';
+import { server } from '@/__mocks__/server';
+import { listPermissionAudit, exportPermissionAudit, type PermissionAuditEntry } from '../permission-audit';
+import { ApiError } from '../base';
+
+const API_BASE = 'http://localhost:9876';
+
+const mockEntry: PermissionAuditEntry = {
+  id: 1,
+  workspaceId: 'ws-1',
+  sessionId: 's-1',
+  conversationId: 'c-1',
+  toolName: 'Bash',
+  specifier: 'npm test',
+  decision: 'allow',
+  decidedBy: 'rule:project',
+  mode: 'default',
+  timestamp: '2026-03-01T12:00:00Z',
+};
+
+describe('lib/api/permission-audit', () => {
+  describe('listPermissionAudit', () => {
+    it('passes filters and limit as query params', async () => {
+      let capturedSearch = '';
+      server.use(
+        http.get(`${API_BASE}/api/permission-audit`, ({ request }) => {
+          capturedSearch = new URL(request.url).search;
+          return HttpResponse.json([mockEntry]);
+        })
+      );
+
+      const entries = await listPermissionAudit({ workspaceId: 'ws-1', tool: 'Bash', decision: 'deny' }, 50);
+      expect(entries).toEqual([mockEntry]);
+      const params = new URLSearchParams(capturedSearch);
+      expect(params.get('workspaceId')).toBe('ws-1');
+      expect(params.get('tool')).toBe('Bash');
+      expect(params.get('decision')).toBe('deny');
+      expect(params.get('limit')).toBe('50');
+      expect(params.has('sessionId')).toBe(false);
+    });
+  });
+
+  describe('exportPermissionAudit', () => {
+    it('returns the JSONL body', async () => {
+      const body = JSON.stringify(mockEntry) + '\n';
+      server.use(
+        http.get(`${API_BASE}/api/permission-audit/export`, () =>
+          new HttpResponse(body, { headers: { 'Content-Type': 'application/x-ndjson' } })
+        )
+      );
+
+      await expect(exportPermissionAudit({ sessionId: 's-1' })).resolves.toBe(body);
+    });
+
+    it('throws ApiError on failure', async () => {
+      server.use(
+        http.get(`${API_BASE}/api/permission-audit/export`, () =>
+          HttpResponse.text('bad since', { status: 400 })
+        )
+      );
+
+      await expect(exportPermissionAudit({ since: 'yesterday' })).rejects.toBeInstanceOf(ApiError);
+    });
+  });
+});
@@ -9,6 +9,7 @@ import {
   rerunSetupScripts,
   stopScript,
   getScriptRuns,
+  getScriptServices,
 } from '../scripts';
 import type { ChatMLConfig, ScriptRun } from '@/lib/types';
 
@@ -170,4 +171,29 @@ describe('lib/api/scripts', () => {
       expect(runs[0].id).toBe('run-1');
     });
   });
+
+  describe('getScriptServices', () => {
+    it('returns session services', async () => {
+      server.use(
+        http.get(
+          `${API_BASE}/api/repos/:workspaceId/sessions/:sessionId/scripts/services`,
+          () => HttpResponse.json([{
+            sessionId: 'session-1',
+            key: 'dev',
+            name: 'Dev Server',
+            status: 'ready',
+            runId: 'run-1',
+            ports: { PORT: 41234 },
+            restarts: 0,
+            startedAt: '2024-01-01T00:00:00Z',
+          }])
+        )
+      );
+
+      const services = await getScriptServices('ws-1', 'session-1');
+      expect(services).toHaveLength(1);
+      expect(services[0].status).toBe('ready');
+      expect(services[0].ports.PORT).toBe(41234);
+    });
+  });
 });
@@ -5,6 +5,8 @@ import {
   getEnvSettings,
   getClaudeEnv,
   setEnvSettings,
+  getSecretRedaction,
+  setSecretRedaction,
   getAnthropicApiKey,
   getClaudeAuthStatus,
   setAnthropicApiKey,
@@ -65,6 +67,33 @@ describe('lib/api/settings', () => {
     });
   });
 
+  describe('secret redaction', () => {
+    it('fetches workspace settings', async () => {
+      server.use(
+        http.get(`${API_BASE}/api/repos/ws-1/secret-redaction`, () =>
+          HttpResponse.json({ enabled: true, allowlist: ['sk-test-*'] })
+        )
+      );
+
+      const result = await getSecretRedaction('ws-1');
+      expect(result).toEqual({ enabled: true, allowlist: ['sk-test-*'] });
+    });
+
+    it('PUTs settings and returns the saved copy', async () => {
+      let capturedBody: unknown;
+      server.use(
+        http.put(`${API_BASE}/api/repos/ws-1/secret-redaction`, async ({ request }) => {
+          capturedBody = await request.json();
+          return HttpResponse.json({ enabled: false, allowlist: ['rule:jwt'] });
+        })
+      );
+
+      const result = await setSecretRedaction('ws-1', { enabled: false, allowlist: [' rule:jwt '] });
+      expect(capturedBody).toEqual({ enabled: false, allowlist: [' rule:jwt '] });
+      expect(result.allowlist).toEqual(['rule:jwt']);
+    });
+  });
+
   describe('Anthropic API key', () => {
     it('getAnthropicApiKey returns configured + maskedKey', async () => {
       server.use(
@@ -219,3 +219,26 @@ export async function abortBranchSync(
   );
   await handleVoidResponse(res, 'Failed to abort branch sync');
 }
+
+// Result of restacking one session stacked on another
+export interface RestackResultDTO {
+  sessionId: string;
+  success: boolean;
+  newBaseSha?: string;
+  newTarget?: string;
+  conflictFiles?: string[];
+  skipped?: string;
+  errorMessage?: string;
+}
+
+// Rebase every session stacked on this one onto its current branch tip
+export async function restackSession(
+  workspaceId: string,
+  sessionId: string
+): Promise<RestackResultDTO[]> {
+  const res = await fetchWithAuth(
+    `${getApiBase()}/api/repos/${workspaceId}/sessions/${sessionId}/restack`,
+    { method: 'POST' }
+  );
+  return handleResponse<RestackResultDTO[]>(res);
+}
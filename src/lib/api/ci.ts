@@ -151,6 +151,7 @@ export type CIFailureContextStatus =
 
 export interface CIFailureContextDTO {
   branch: string;
+  headSha?: string;
   status: CIFailureContextStatus;
   failedRuns: FailedRunContext[];
   totalFailed: number;
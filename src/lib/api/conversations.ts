@@ -1,5 +1,5 @@
 import { getApiBase, fetchWithAuth, handleResponse, handleVoidResponse, ApiError } from './base';
-import type { AttachmentContextType, AttachmentContextMeta } from '@/lib/types';
+import type { AgentTaskItem, AttachmentContextType, AttachmentContextMeta } from '@/lib/types';
 
 // Conversation DTOs and functions
 export interface ConversationDTO {
@@ -413,6 +413,122 @@ export async function setConversationMaxThinkingTokens(convId: string, maxThinki
   }
 }
 
+export interface CompactReport {
+  trigger: string;
+  preTokens: number;
+  postTokens: number;
+  summary?: string;
+}
+
+/**
+ * Summarize a running conversation's context now. `instructions` focus the
+ * summary ("keep the DB migration details"). Returns null when the agent
+ * compacts asynchronously; progress then arrives as compact_boundary events.
+ */
+export async function compactConversation(convId: string, instructions?: string): Promise<CompactReport | null> {
+  const res = await fetchWithAuth(`${getApiBase()}/api/conversations/${convId}/compact`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify(instructions ? { instructions } : {}),
+  });
+  if (res.status === 202) return null;
+  return handleResponse(res);
+}
+
+export interface ContextBreakdownItem {
+  name: string;
+  tokens: number;
+}
+
+export interface ContextBreakdownCategory {
+  name: 'system_prompt' | 'tools' | 'messages' | 'tool_results' | 'attachments' | string;
+  tokens: number;
+  items?: ContextBreakdownItem[];
+}
+
+export interface ContextBreakdown {
+  contextWindow: number;
+  estimatedTokens: number;
+  reportedTokens?: number;
+  categories: ContextBreakdownCategory[];
+}
+
+/** Estimated context usage by category for a running native-loop conversation. */
+export async function getConversationContext(convId: string): Promise<ContextBreakdown> {
+  const res = await fetchWithAuth(`${getApiBase()}/api/conversations/${convId}/context`);
+  return handleResponse(res);
+}
+
+export interface WorkingMemoryFile {
+  path: string;
+  access: 'read' | 'modified';
+}
+
+export interface WorkingMemoryTask {
+  id: string;
+  subject: string;
+  status: string;
+  owner?: string;
+  blocked_by?: string[];
+}
+
+/** Structured per-session memory kept by the native loop across compactions. */
+export interface WorkingMemory {
+  goal?: string;
+  decisions?: string[];
+  openQuestions?: string[];
+  files?: WorkingMemoryFile[];
+  tasks?: WorkingMemoryTask[];
+  updatedAt?: string;
+}
+
+export async function getWorkingMemory(convId: string): Promise<WorkingMemory> {
+  const res = await fetchWithAuth(`${getApiBase()}/api/conversations/${convId}/working-memory`);
+  return handleResponse(res);
+}
+
+export async function saveWorkingMemory(convId: string, memory: WorkingMemory): Promise<WorkingMemory> {
+  const res = await fetchWithAuth(`${getApiBase()}/api/conversations/${convId}/working-memory`, {
+    method: 'PUT',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify(memory),
+  });
+  return handleResponse(res);
+}
+
+/** A conversation's agent task as last reported by the agent. */
+export interface ConversationTask extends AgentTaskItem {
+  blocks: string[];
+  blockedBy: string[];
+  updatedAt: string;
+}
+
+export async function getConversationTasks(convId: string): Promise<ConversationTask[]> {
+  const res = await fetchWithAuth(`${getApiBase()}/api/conversations/${convId}/tasks`);
+  return handleResponse(res);
+}
+
+export interface ForkConversationOptions {
+  /** Fork after this message's turn; omit to fork the whole conversation. */
+  messageId?: string;
+  /** Session (same workspace) to create the fork in; defaults to the source's session. */
+  sessionId?: string;
+  name?: string;
+}
+
+/**
+ * Branch a conversation into a new one that shares its history up to a
+ * message and continues independently. Returns the new conversation.
+ */
+export async function forkConversation(convId: string, options: ForkConversationOptions = {}): Promise<ConversationDTO> {
+  const res = await fetchWithAuth(`${getApiBase()}/api/conversations/${convId}/fork`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify(options),
+  });
+  return handleResponse<ConversationDTO>(res);
+}
+
 export async function approvePlan(convId: string, requestId: string, approved: boolean, reason?: string): Promise<void> {
   const res = await fetchWithAuth(`${getApiBase()}/api/conversations/${convId}/approve-plan`, {
     method: 'POST',
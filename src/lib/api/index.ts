@@ -23,3 +23,5 @@ export * from './avatars';
 export * from './gstack';
 export * from './scheduled-tasks';
 export * from './stats';
+export * from './permission-audit';
+export * from './ollama';
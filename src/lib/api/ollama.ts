@@ -0,0 +1,57 @@
+import { getApiBase, fetchWithAuth, handleResponse } from './base';
+
+/** An installed Ollama model with capabilities probed by the backend. */
+export interface OllamaModel {
+  /** ChatML model ID: the catalog ID, or "ollama/<name>" for other models */
+  id: string;
+  name: string;
+  digest: string;
+  size: number;
+  modified_at: string;
+  family?: string;
+  parameter_size?: string;
+  quantization?: string;
+  /** Max context window in tokens; 0 when the model does not report one */
+  context_length: number;
+  supports_tools: boolean;
+  supports_vision: boolean;
+  supports_thinking: boolean;
+  /** Part of the built-in model catalog */
+  catalog: boolean;
+}
+
+/** List installed Ollama models. Returns the last discovery while Ollama is stopped. */
+export async function listOllamaModels(): Promise<OllamaModel[]> {
+  const res = await fetchWithAuth(`${getApiBase()}/api/ollama/models`);
+  return handleResponse<OllamaModel[]>(res);
+}
+
+/** The external Ollama server setting. The token itself is never returned. */
+export interface OllamaRemoteStatus {
+  enabled: boolean;
+  endpoint?: string;
+  hasToken: boolean;
+  /** Whether the server answered its health check */
+  reachable: boolean;
+  version?: string;
+  error?: string;
+}
+
+/** Get the external Ollama server setting and its health. */
+export async function getOllamaRemote(): Promise<OllamaRemoteStatus> {
+  const res = await fetchWithAuth(`${getApiBase()}/api/ollama/remote`);
+  return handleResponse<OllamaRemoteStatus>(res);
+}
+
+/**
+ * Point ChatML at an external Ollama server, or back at the managed binary
+ * when endpoint is empty. Omit token to keep the stored one; pass '' to clear it.
+ */
+export async function setOllamaRemote(endpoint: string, token?: string): Promise<OllamaRemoteStatus> {
+  const res = await fetchWithAuth(`${getApiBase()}/api/ollama/remote`, {
+    method: 'PUT',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify(token === undefined ? { endpoint } : { endpoint, token }),
+  });
+  return handleResponse<OllamaRemoteStatus>(res);
+}
@@ -0,0 +1,59 @@
+import { getApiBase, fetchWithAuth, handleResponse, ApiError } from './base';
+
+export interface PermissionAuditEntry {
+  id: number;
+  workspaceId: string;
+  sessionId: string;
+  conversationId: string;
+  toolUseId?: string;
+  toolName: string;
+  specifier?: string;
+  decision: 'allow' | 'deny';
+  /** policy, rule:<source>, session, mode, builtin, safety, default, user, cancelled or hook:<event> */
+  decidedBy: string;
+  /** The user's answer (allow_once, allow_session, ...) when decidedBy is "user" */
+  approvalAction?: string;
+  reason?: string;
+  mode?: string;
+  timestamp: string;
+}
+
+export interface PermissionAuditFilter {
+  workspaceId?: string;
+  sessionId?: string;
+  conversationId?: string;
+  tool?: string;
+  decision?: 'allow' | 'deny';
+  /** RFC 3339 timestamps */
+  since?: string;
+  until?: string;
+}
+
+function permissionAuditQuery(filter?: PermissionAuditFilter, limit?: number): string {
+  const params = new URLSearchParams();
+  for (const [key, value] of Object.entries(filter ?? {})) {
+    if (value) params.set(key, value);
+  }
+  if (limit !== undefined) params.set('limit', String(limit));
+  const qs = params.toString();
+  return qs ? `?${qs}` : '';
+}
+
+/** Recorded permission decisions, newest first (server default limit 200, max 1000). */
+export async function listPermissionAudit(
+  filter?: PermissionAuditFilter,
+  limit?: number
+): Promise<PermissionAuditEntry[]> {
+  const res = await fetchWithAuth(`${getApiBase()}/api/permission-audit${permissionAuditQuery(filter, limit)}`);
+  return handleResponse<PermissionAuditEntry[]>(res);
+}
+
+/** Every matching decision as JSON Lines, oldest first. */
+export async function exportPermissionAudit(filter?: PermissionAuditFilter): Promise<string> {
+  const res = await fetchWithAuth(`${getApiBase()}/api/permission-audit/export${permissionAuditQuery(filter)}`);
+  const text = await res.text();
+  if (!res.ok) {
+    throw new ApiError(text || `HTTP ${res.status}`, res.status, text);
+  }
+  return text;
+}
@@ -1,4 +1,5 @@
 import { getApiBase, fetchWithAuth, handleResponse, handleVoidResponse, ApiError } from './base';
+import type { SessionDTO } from './sessions';
 
 export type CheckStatus = 'pending' | 'success' | 'failure' | 'none';
 
@@ -55,6 +56,82 @@ export async function unlinkPR(workspaceId: string, sessionId: string): Promise<
   await handleVoidResponse(res, 'Failed to unlink pull request');
 }
 
+export type MergeMethod = 'merge' | 'squash' | 'rebase';
+
+export interface MergePROptions {
+  method?: MergeMethod;
+  mode?: 'now' | 'auto' | 'queue';
+  commitTitle?: string;
+  commitMessage?: string;
+  deleteBranch?: boolean; // Defaults to true
+  archive?: boolean; // Defaults to true except for base sessions
+}
+
+export interface MergePRResultDTO {
+  status: 'merged' | 'auto_merge_enabled' | 'queued';
+  sha?: string;
+  branchDeleteScheduled: boolean; // The remote branch is deleted in the background after stacked sessions are retargeted
+  hookError?: string; // A blocking lifecycle hook failed; cleanup was skipped
+  session?: SessionDTO;
+}
+
+export async function mergePR(
+  workspaceId: string,
+  sessionId: string,
+  options: MergePROptions = {}
+): Promise<MergePRResultDTO> {
+  const res = await fetchWithAuth(`${getApiBase()}/api/repos/${workspaceId}/sessions/${sessionId}/pr/merge`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify(options),
+  });
+  return handleResponse<MergePRResultDTO>(res);
+}
+
+// PR automation (auto-address review feedback)
+export interface PRAutomationPolicyDTO {
+  sessionId: string;
+  addressFeedback: boolean;
+  maxIterations: number;
+  budgetUsd: number;
+  iterations: number;
+  spentUsd: number;
+  activeConversationId?: string;
+  stoppedReason?: 'max_iterations' | 'budget';
+  fixCi: boolean;
+  maxCiAttempts: number;
+  ciAttempts: number;
+  ciLastSha?: string;
+  ciLastFailedJobs: string[] | null;
+  ciRepeatCount: number;
+  ciStoppedReason?: 'max_ci_attempts' | 'same_job_failing' | 'budget';
+  updatedAt: string;
+}
+
+export async function getPRAutomationPolicy(workspaceId: string, sessionId: string): Promise<PRAutomationPolicyDTO> {
+  const res = await fetchWithAuth(`${getApiBase()}/api/repos/${workspaceId}/sessions/${sessionId}/pr/automation`);
+  return handleResponse<PRAutomationPolicyDTO>(res);
+}
+
+export async function updatePRAutomationPolicy(
+  workspaceId: string,
+  sessionId: string,
+  data: {
+    addressFeedback?: boolean;
+    maxIterations?: number;
+    budgetUsd?: number;
+    fixCi?: boolean;
+    maxCiAttempts?: number;
+  }
+): Promise<PRAutomationPolicyDTO> {
+  const res = await fetchWithAuth(`${getApiBase()}/api/repos/${workspaceId}/sessions/${sessionId}/pr/automation`, {
+    method: 'PUT',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify(data),
+  });
+  return handleResponse<PRAutomationPolicyDTO>(res);
+}
+
 // PR Dashboard types
 export interface PRLabel {
   name: string;
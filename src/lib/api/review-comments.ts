@@ -7,7 +7,7 @@ export interface ReviewCommentDTO {
   lineNumber: number;
   title?: string;
   content: string;
-  source: 'claude' | 'user';
+  source: 'claude' | 'user' | 'github';
   author: string;
   severity?: 'error' | 'warning' | 'suggestion' | 'info';
   createdAt: string;
@@ -15,6 +15,15 @@ export interface ReviewCommentDTO {
   resolvedAt?: string;
   resolvedBy?: string;
   resolutionType?: 'fixed' | 'ignored';
+  githubCommentId?: number;
+  githubThreadId?: string;
+  githubUrl?: string;
+}
+
+export interface PublishReviewResultDTO {
+  reviewId: number;
+  reviewUrl?: string;
+  published: number;
 }
 
 export interface CommentStatsDTO {
@@ -102,3 +111,32 @@ export async function deleteReviewComment(
     throw new ApiError(text || 'Delete failed', res.status, text);
   }
 }
+
+/** Publish unresolved local comments to the session's PR as a pending GitHub review. */
+export async function publishReviewComments(
+  workspaceId: string,
+  sessionId: string,
+  commentIds?: string[]
+): Promise<PublishReviewResultDTO> {
+  const res = await fetchWithAuth(
+    `${getApiBase()}/api/repos/${workspaceId}/sessions/${sessionId}/comments/publish`,
+    {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ commentIds }),
+    }
+  );
+  return handleResponse<PublishReviewResultDTO>(res);
+}
+
+/** Import the PR's GitHub review threads now instead of waiting for the next poll. */
+export async function syncReviewComments(
+  workspaceId: string,
+  sessionId: string
+): Promise<ReviewCommentDTO[]> {
+  const res = await fetchWithAuth(
+    `${getApiBase()}/api/repos/${workspaceId}/sessions/${sessionId}/comments/sync`,
+    { method: 'POST' }
+  );
+  return handleResponse<ReviewCommentDTO[]>(res);
+}
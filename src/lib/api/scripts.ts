@@ -1,5 +1,5 @@
 import { getApiBase, fetchWithAuth, handleResponse, handleVoidResponse } from './base';
-import type { ChatMLConfig, ScriptRun } from '@/lib/types';
+import type { ChatMLConfig, ScriptRun, ScriptService } from '@/lib/types';
 
 export async function getWorkspaceConfig(workspaceId: string): Promise<ChatMLConfig> {
   const res = await fetchWithAuth(
@@ -65,3 +65,10 @@ export async function getScriptRuns(workspaceId: string, sessionId: string): Pro
   );
   return handleResponse<ScriptRun[]>(res);
 }
+
+export async function getScriptServices(workspaceId: string, sessionId: string): Promise<ScriptService[]> {
+  const res = await fetchWithAuth(
+    `${getApiBase()}/api/repos/${workspaceId}/sessions/${sessionId}/scripts/services`
+  );
+  return handleResponse<ScriptService[]>(res);
+}
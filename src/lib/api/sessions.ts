@@ -25,6 +25,7 @@ export interface SessionDTO {
   targetBranch?: string;
   sessionType?: 'worktree' | 'base' | 'scheduled';
   scheduledTaskId?: string;
+  parentSessionId?: string;
   pinned?: boolean;
   archived?: boolean;
   archiveSummary?: string;
@@ -56,6 +57,7 @@ export function mapSessionDTO(session: SessionDTO): import('@/lib/types').Worktr
     targetBranch: session.targetBranch,
     sessionType: session.sessionType,
     scheduledTaskId: session.scheduledTaskId,
+    parentSessionId: session.parentSessionId,
     pinned: session.pinned,
     archived: session.archived,
     archiveSummary: session.archiveSummary,
@@ -83,7 +85,7 @@ export async function listAllSessions(includeArchived?: boolean): Promise<Sessio
 
 export async function createSession(
   workspaceId: string,
-  data: { name?: string; branch?: string; branchPrefix?: string; worktreePath?: string; task?: string; checkoutExisting?: boolean; systemMessage?: string; sessionType?: 'worktree' | 'base' | 'scheduled' } = {}
+  data: { name?: string; branch?: string; branchPrefix?: string; worktreePath?: string; task?: string; checkoutExisting?: boolean; systemMessage?: string; sessionType?: 'worktree' | 'base' | 'scheduled'; parentSessionId?: string } = {}
 ): Promise<SessionDTO> {
   const res = await fetchWithAuth(`${getApiBase()}/api/repos/${workspaceId}/sessions`, {
     method: 'POST',
@@ -31,6 +31,25 @@ export async function getClaudeEnv(): Promise<Record<string, string>> {
   return data.env;
 }
 
+export type PolicyLockedSetting = 'permissionMode' | 'permissions' | 'allowedModels' | 'mcpServers' | 'hooks';
+
+// Organization-managed settings (managed-settings.json) and what they lock
+export interface ManagedPolicy {
+  managed: boolean;
+  settingsDir?: string;
+  lockedSettings: PolicyLockedSetting[];
+  permissionMode?: string;
+  allowedModels?: string[];
+  permissions: { allow: string[]; deny: string[]; ask: string[] };
+  mcpServers: string[];
+  hasHooks: boolean;
+}
+
+export async function getManagedPolicy(): Promise<ManagedPolicy> {
+  const res = await fetchWithAuth(`${getApiBase()}/api/settings/managed-policy`);
+  return handleResponse<ManagedPolicy>(res);
+}
+
 export async function setEnvSettings(envVars: string): Promise<void> {
   const res = await fetchWithAuth(
     `${getApiBase()}/api/settings/env`,
@@ -43,6 +62,33 @@ export async function setEnvSettings(envVars: string): Promise<void> {
   await handleResponse(res);
 }
 
+// Per-workspace secret redaction for tool output. Allowlist entries are
+// literal values or `*` globs; `rule:<kind>` turns off one detector.
+export interface SecretRedactionSettings {
+  enabled: boolean;
+  allowlist: string[];
+}
+
+export async function getSecretRedaction(workspaceId: string): Promise<SecretRedactionSettings> {
+  const res = await fetchWithAuth(`${getApiBase()}/api/repos/${workspaceId}/secret-redaction`);
+  return handleResponse<SecretRedactionSettings>(res);
+}
+
+export async function setSecretRedaction(
+  workspaceId: string,
+  settings: SecretRedactionSettings
+): Promise<SecretRedactionSettings> {
+  const res = await fetchWithAuth(
+    `${getApiBase()}/api/repos/${workspaceId}/secret-redaction`,
+    {
+      method: 'PUT',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify(settings),
+    }
+  );
+  return handleResponse<SecretRedactionSettings>(res);
+}
+
 // Anthropic API Key
 export async function getAnthropicApiKey(): Promise<{ configured: boolean; maskedKey: string }> {
   const res = await fetchWithAuth(`${getApiBase()}/api/settings/anthropic-api-key`);
@@ -1,6 +1,7 @@
 import React from 'react';
 import { useAppStore } from '@/stores/appStore';
 import { SHOW_UNRELEASED } from './constants';
+import type { OllamaModel } from './api/ollama';
 
 // ---------------------------------------------------------------------------
 // Canonical model catalog — single source of truth for display names & descriptions
@@ -142,12 +143,22 @@ function enrichWithSdk(staticModel: (typeof MODELS)[number], sdkModel?: SdkModel
   };
 }
 
+/** Short description for a discovered local model, e.g. "qwen2 7.6B · 32K context". */
+function describeLocalModel(m: OllamaModel): string {
+  const parts = [[m.family, m.parameter_size].filter(Boolean).join(' ')];
+  if (m.context_length > 0) parts.push(`${Math.round(m.context_length / 1024)}K context`);
+  if (m.supports_vision) parts.push('vision');
+  if (!m.supports_tools) parts.push('no tools');
+  return parts.filter(Boolean).join(' \u00b7 ') || 'Local model';
+}
+
 /**
  * Build the model list from the static catalog, enriched with SDK-reported capabilities.
  * The static MODELS array is always the source of truth for which models appear in the UI.
  * SDK data is only used to update capability flags (supportsEffort, supportsFastMode, etc.).
+ * Installed Ollama models outside the catalog are appended after it.
  */
-export function buildStaticModelList(sdkModels: SdkModelEntry[]): StaticModelEntry[] {
+export function buildStaticModelList(sdkModels: SdkModelEntry[], localModels: OllamaModel[] = []): StaticModelEntry[] {
   // Index SDK models by base ID for O(1) lookup
   const sdkByBase = new Map<string, SdkModelEntry>();
   for (const m of sdkModels) {
@@ -155,7 +166,7 @@ export function buildStaticModelList(sdkModels: SdkModelEntry[]): StaticModelEnt
     if (!sdkByBase.has(base)) sdkByBase.set(base, m);
   }
 
-  return MODELS.map((staticModel) => {
+  const curated: StaticModelEntry[] = MODELS.map((staticModel) => {
     const sdk = sdkByBase.get(toBaseId(staticModel.id));
     return {
       id: staticModel.id,
@@ -165,6 +176,20 @@ export function buildStaticModelList(sdkModels: SdkModelEntry[]): StaticModelEnt
       ...enrichWithSdk(staticModel, sdk),
     };
   });
+
+  const discovered: StaticModelEntry[] = localModels
+    .filter((m) => !m.catalog)
+    .map((m) => ({
+      id: m.id,
+      name: m.name,
+      description: describeLocalModel(m),
+      provider: 'ollama' as const,
+      supportsThinking: m.supports_thinking,
+      supportsEffort: false,
+      supportsFastMode: false,
+    }));
+
+  return [...curated, ...discovered];
 }
 
 // ---------------------------------------------------------------------------
@@ -242,6 +267,9 @@ export function getModelDisplayName(modelId: string): string {
   const info = getModelInfo(modelId);
   if (info) return info.name;
 
+  // Discovered local models are shown by their Ollama tag
+  if (modelId.startsWith('ollama/')) return modelId.slice('ollama/'.length);
+
   // Handle Bedrock inference profile ARNs (e.g. "arn:aws:bedrock:us-east-1:...:application-inference-profile/abc123")
   if (modelId.startsWith('arn:aws:bedrock:')) {
     const parts = modelId.split('/');
@@ -31,6 +31,9 @@ export function suggestWildcard(toolName: string, specifier: string): WildcardSu
         return { label: `Yes, allow fetching from ${domain}`, specifier };
       }
       return null;
+    case 'Network':
+      // Egress approval: specifier is the host
+      return { label: `Yes, allow connections to ${specifier}`, specifier };
     default:
       return null;
   }
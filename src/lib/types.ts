@@ -49,6 +49,7 @@ export interface WorktreeSession {
   targetBranch?: string; // Per-session target branch override (e.g. "origin/develop")
   sessionType?: 'worktree' | 'base' | 'scheduled'; // "base" = operates on repo directly, "scheduled" = per-run session for scheduled tasks
   scheduledTaskId?: string; // FK to scheduled_tasks if created by scheduler
+  parentSessionId?: string; // Stacked sessions: the session whose branch this one is based on
   createdAt: string;
   updatedAt: string;
 }
@@ -339,6 +340,8 @@ export interface AgentEvent {
   errors?: unknown[];
   // Todo update fields
   todos?: AgentTodoItem[];
+  // Task list update fields (native loop TaskCreate/TaskUpdate tools)
+  tasks?: AgentTaskItem[];
 
   // Session management fields
   sessionId?: string;
@@ -410,6 +413,7 @@ export interface AgentEvent {
   // Compact boundary / post_compact fields
   trigger?: 'manual' | 'auto';
   preTokens?: number;
+  postTokens?: number;
   customInstructions?: string | null;
   compactSummary?: string;
 
@@ -621,6 +625,7 @@ export const AgentEventTypes = {
   TOOL_END: 'tool_end',
   NAME_SUGGESTION: 'name_suggestion',
   TODO_UPDATE: 'todo_update',
+  TASK_LIST_UPDATE: 'task_list_update',
   RESULT: 'result',
   COMPLETE: 'complete',
   ERROR: 'error',
@@ -737,6 +742,18 @@ export interface AgentTodoItem {
   activeForm: string;
 }
 
+// Agent task from the native loop's TaskCreate/TaskUpdate tools
+export interface AgentTaskItem {
+  id: string;
+  subject: string;
+  description?: string;
+  activeForm?: string;
+  status: 'pending' | 'in_progress' | 'completed' | 'failed' | 'stopped';
+  owner?: string;
+  blocks?: string[];
+  blockedBy?: string[];
+}
+
 // File checkpoint for rewind support
 export interface CheckpointInfo {
   uuid: string;
@@ -846,7 +863,7 @@ export interface ReviewComment {
   lineNumber: number;
   title?: string;
   content: string;
-  source: 'claude' | 'user';
+  source: 'claude' | 'user' | 'github';
   author: string;
   severity?: 'error' | 'warning' | 'suggestion' | 'info';
   createdAt: string;
@@ -854,6 +871,9 @@ export interface ReviewComment {
   resolvedAt?: string;
   resolvedBy?: string;
   resolutionType?: 'fixed' | 'ignored';
+  githubCommentId?: number;
+  githubThreadId?: string;
+  githubUrl?: string;
 }
 
 // Comment statistics per file
@@ -889,12 +909,27 @@ export interface BranchSyncResult {
 export interface ScriptDef {
   name: string;
   command: string;
+  // Service mode (run scripts only): long-running process with per-session ports
+  service?: boolean;
+  ports?: string[];
+  ready?: ReadyProbe;
+  restart?: 'never' | 'on-failure';
+  maxRestarts?: number;
+  dependsOn?: string[];
+}
+
+export interface ReadyProbe {
+  http?: string;
+  tcp?: string;
+  log?: string;
+  timeout?: number;
 }
 
 export interface ChatMLConfig {
   setupScripts: ScriptDef[];
   runScripts: Record<string, ScriptDef>;
   hooks: Record<string, string>;
+  hookPolicies?: Record<string, 'block' | 'warn'>;
   autoSetup: boolean;
 }
 
@@ -914,6 +949,21 @@ export interface ScriptRun {
   createdAt: string;
 }
 
+export type ScriptServiceStatus = 'pending' | 'starting' | 'ready' | 'restarting' | 'failed' | 'stopped';
+
+export interface ScriptService {
+  sessionId: string;
+  key: string;
+  name: string;
+  status: ScriptServiceStatus;
+  runId: string;
+  ports: Record<string, number>;
+  dependsOn?: string[];
+  restarts: number;
+  error?: string;
+  startedAt: string;
+}
+
 export interface SetupProgress {
   current: number;
   total: number;
@@ -9,6 +9,7 @@ import type {
   TerminalSession,
   TerminalInstance,
   AgentTodoItem,
+  AgentTaskItem,
   CustomTodoItem,
   McpServerStatus,
   McpServerConfig,
@@ -24,6 +25,7 @@ import type {
   Attachment,
   Summary,
   ScriptRun,
+  ScriptService,
   SetupProgress,
   TimelineEntry,
   InputSuggestion,
@@ -281,6 +283,7 @@ interface AppState {
 
   // Todo state
   agentTodos: { [conversationId: string]: AgentTodoItem[] };
+  agentTasks: { [conversationId: string]: AgentTaskItem[] };
   customTodos: { [sessionId: string]: CustomTodoItem[] };
 
   // Terminal instances (bottom panel terminals per session)
@@ -376,6 +379,8 @@ interface AppState {
   setupProgress: Record<string, SetupProgress>;
   // Per-run version counters bumped on each output line to trigger re-renders
   scriptOutputVersions: Record<string, number>;  // keyed by "sessionId:runId"
+  // Long-running run script services (keyed by sessionId)
+  scriptServices: Record<string, ScriptService[]>;
 
   // Draft input actions
   setDraftInput: (sessionId: string, draft: { text: string; attachments: Attachment[] }) => void;
@@ -386,6 +391,8 @@ interface AppState {
   updateScriptRunStatus: (sessionId: string, run: ScriptRun) => void;
   appendScriptOutput: (sessionId: string, runId: string, line: string) => void;
   setSetupProgress: (sessionId: string, progress: SetupProgress) => void;
+  setScriptServices: (sessionId: string, services: ScriptService[]) => void;
+  upsertScriptService: (sessionId: string, service: ScriptService) => void;
 
   // Workspace actions
   setWorkspaces: (workspaces: Workspace[]) => void;
@@ -560,6 +567,7 @@ interface AppState {
   // Todo actions
   setAgentTodos: (conversationId: string, todos: AgentTodoItem[]) => void;
   clearAgentTodos: (conversationId: string) => void;
+  setAgentTasks: (conversationId: string, tasks: AgentTaskItem[]) => void;
   addCustomTodo: (sessionId: string, content: string) => void;
   toggleCustomTodo: (sessionId: string, todoId: string) => void;
   deleteCustomTodo: (sessionId: string, todoId: string) => void;
@@ -685,6 +693,7 @@ export const useAppStore = create<AppState>((set, get) => ({
   backgroundTasks: {},
   queuedMessages: {},
   agentTodos: {},
+  agentTasks: {},
   customTodos: {},
   terminalInstances: {},
   activeTerminalId: {},
@@ -730,6 +739,7 @@ export const useAppStore = create<AppState>((set, get) => ({
   scriptRuns: {},
   setupProgress: {},
   scriptOutputVersions: {},
+  scriptServices: {},
 
   // Draft input actions
   setDraftInput: (sessionId, draft) => set((state) => ({
@@ -787,6 +797,18 @@ export const useAppStore = create<AppState>((set, get) => ({
     },
   })),
 
+  setScriptServices: (sessionId, services) => set((state) => ({
+    scriptServices: { ...state.scriptServices, [sessionId]: services },
+  })),
+
+  upsertScriptService: (sessionId, service) => set((state) => {
+    const existing = state.scriptServices[sessionId] || [];
+    const next = existing.some((s) => s.key === service.key)
+      ? existing.map((s) => (s.key === service.key ? service : s))
+      : [...existing, service];
+    return { scriptServices: { ...state.scriptServices, [sessionId]: next } };
+  }),
+
   // Workspace actions
   setWorkspaces: (workspaces) => set({ workspaces }),
   addWorkspace: (workspace) => set((state) => ({
@@ -814,11 +836,13 @@ export const useAppStore = create<AppState>((set, get) => ({
     const cleanedStreamingState = { ...state.streamingState };
     const cleanedActiveTools = { ...state.activeTools };
     const cleanedAgentTodos = { ...state.agentTodos };
+    const cleanedAgentTasks = { ...state.agentTasks };
     const cleanedPagination = { ...state.messagePagination };
     for (const convId of workspaceConvIds) {
       delete cleanedStreamingState[convId];
       delete cleanedActiveTools[convId];
       delete cleanedAgentTodos[convId];
+      delete cleanedAgentTasks[convId];
       delete cleanedPagination[convId];
     }
 
@@ -861,6 +885,7 @@ export const useAppStore = create<AppState>((set, get) => ({
       streamingState: cleanedStreamingState,
       activeTools: cleanedActiveTools,
       agentTodos: cleanedAgentTodos,
+      agentTasks: cleanedAgentTasks,
       messagePagination: cleanedPagination,
       customTodos: cleanedCustomTodos,
       sessionOutputs: cleanedSessionOutputs,
@@ -922,6 +947,7 @@ export const useAppStore = create<AppState>((set, get) => ({
       const cleanedStreamingState = { ...state.streamingState };
       const cleanedActiveTools = { ...state.activeTools };
       const cleanedAgentTodos = { ...state.agentTodos };
+      const cleanedAgentTasks = { ...state.agentTasks };
       const cleanedContextUsage = { ...state.contextUsage };
       const cleanedQueuedMessages = { ...state.queuedMessages };
       const cleanedPagination = { ...state.messagePagination };
@@ -929,6 +955,7 @@ export const useAppStore = create<AppState>((set, get) => ({
         delete cleanedStreamingState[convId];
         delete cleanedActiveTools[convId];
         delete cleanedAgentTodos[convId];
+        delete cleanedAgentTasks[convId];
         delete cleanedContextUsage[convId];
         delete cleanedQueuedMessages[convId];
         delete cleanedPagination[convId];
@@ -974,6 +1001,7 @@ export const useAppStore = create<AppState>((set, get) => ({
         streamingState: cleanedStreamingState,
         activeTools: cleanedActiveTools,
         agentTodos: cleanedAgentTodos,
+        agentTasks: cleanedAgentTasks,
         contextUsage: cleanedContextUsage,
         queuedMessages: cleanedQueuedMessages,
         messagePagination: cleanedPagination,
@@ -1193,6 +1221,8 @@ export const useAppStore = create<AppState>((set, get) => ({
     // eslint-disable-next-line @typescript-eslint/no-unused-vars
     const { [id]: _todos, ...remainingAgentTodos } = state.agentTodos;
     // eslint-disable-next-line @typescript-eslint/no-unused-vars
+    const { [id]: _tasks, ...remainingAgentTasks } = state.agentTasks;
+    // eslint-disable-next-line @typescript-eslint/no-unused-vars
     const { [id]: _question, ...remainingPendingQuestions } = state.pendingUserQuestion;
     // eslint-disable-next-line @typescript-eslint/no-unused-vars
     const { [id]: _qaHandoff, ...remainingQAHandoffs } = state.pendingQAHandoff;
@@ -1249,6 +1279,7 @@ export const useAppStore = create<AppState>((set, get) => ({
       streamingState: remainingStreamingState,
       activeTools: remainingActiveTools,
       agentTodos: remainingAgentTodos,
+      agentTasks: remainingAgentTasks,
       pendingUserQuestion: remainingPendingQuestions,
       pendingQAHandoff: remainingQAHandoffs,
       interruptedState: remainingInterruptedState,
@@ -2475,6 +2506,12 @@ updateFileTabContent: (id, content) => set((state) => ({
       [conversationId]: [],
     },
   })),
+  setAgentTasks: (conversationId, tasks) => set((state) => ({
+    agentTasks: {
+      ...state.agentTasks,
+      [conversationId]: tasks,
+    },
+  })),
   addCustomTodo: (sessionId, content) => set((state) => ({
     customTodos: {
       ...state.customTodos,